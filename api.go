@@ -0,0 +1,786 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/linzeyan/transform-go/pkg/code"
+	"github.com/linzeyan/transform-go/pkg/convert"
+	"github.com/linzeyan/transform-go/pkg/generate"
+	"github.com/linzeyan/transform-go/pkg/qrcode"
+	"github.com/linzeyan/transform-go/pkg/quota"
+	"github.com/linzeyan/transform-go/pkg/schemaregistry"
+	"github.com/linzeyan/transform-go/pkg/sign"
+	"github.com/linzeyan/transform-go/pkg/store"
+)
+
+// schemaRegistry is set from TRANSFORM_GO_SCHEMA_REGISTRY; the registry
+// endpoints answer 404 without it.
+var schemaRegistry *schemaregistry.Client
+
+// savedStore is kept in the storage TRANSFORM_GO_STORE names; the store
+// endpoints, and presets and pipelines in convert requests, answer 404
+// without it.
+var savedStore *store.Store
+
+// quotas is loaded from TRANSFORM_GO_QUOTAS; without it every key may make
+// any request.
+var quotas *quota.Quotas
+
+// scriptLimits is set from TRANSFORM_GO_SCRIPTS; without it requests
+// with a script option are refused.
+var scriptLimits *convert.ScriptLimits
+
+// signer is loaded from TRANSFORM_GO_SIGNING_KEY; without it responses are
+// not signed.
+var signer *sign.Signer
+
+// registerAPI mounts the JSON endpoints. Responses mirror the WASM
+// bindings: {"result": ...} on success and {"error": "..."} otherwise.
+func registerAPI(r gin.IRouter) {
+	v1 := r.Group("/api/v1", apiSign, apiQuota)
+	v1.POST("/convert", apiConvert)
+	v1.POST("/convert/upload", apiConvertUpload)
+	v1.POST("/format", apiFormat)
+	v1.POST("/validate", apiValidate)
+	v1.POST("/query", apiQuery)
+	v1.POST("/encode", apiEncode)
+	v1.POST("/decode", apiDecode)
+	v1.POST("/decode-image", apiDecodeImage)
+	v1.POST("/hash", apiHash)
+	v1.POST("/hmac", apiHMAC)
+	v1.POST("/jwt/encode", apiJWTEncode)
+	v1.POST("/jwt/decode", apiJWTDecode)
+	v1.POST("/jwt/verify", apiJWTVerify)
+	v1.POST("/resolve", apiResolve)
+	v1.POST("/registry/decode", apiRegistryDecode)
+	v1.POST("/registry/struct", apiRegistryStruct)
+	v1.GET("/user-agents", apiUserAgents)
+	v1.GET("/store/:kind", apiStoreList)
+	v1.GET("/store/:kind/:name", apiStoreGet)
+	v1.PUT("/store/:kind/:name", apiStorePut)
+	v1.DELETE("/store/:kind/:name", apiStoreDelete)
+	v1.GET("/profiles", apiProfiles)
+	v1.GET("/health", apiHealth)
+	v1.GET("/metrics", apiMetrics)
+	v1.GET("/usage", apiUsage)
+	v1.GET("/signing-key", apiSigningKey)
+}
+
+type convertRequest struct {
+	From     string          `json:"from"`
+	To       string          `json:"to"`
+	Input    string          `json:"input"`
+	Options  json.RawMessage `json:"options"`
+	Preset   string          `json:"preset"`
+	Pipeline string          `json:"pipeline"`
+}
+
+type formatRequest struct {
+	Format  string          `json:"format"`
+	Input   string          `json:"input"`
+	Minify  bool            `json:"minify"`
+	Options json.RawMessage `json:"options"`
+}
+
+type validateRequest struct {
+	Format string `json:"format"`
+	Input  string `json:"input"`
+}
+
+type queryRequest struct {
+	Input string `json:"input"`
+	Query string `json:"query"`
+}
+
+type inputRequest struct {
+	Input string `json:"input"`
+}
+
+type decodeRequest struct {
+	Encoding string `json:"encoding"`
+	Input    string `json:"input"`
+}
+
+type decodeImageRequest struct {
+	Image   string          `json:"image"`
+	From    string          `json:"from"`
+	To      string          `json:"to"`
+	Options json.RawMessage `json:"options"`
+}
+
+type hmacRequest struct {
+	Input     string `json:"input"`
+	Key       string `json:"key"`
+	Algorithm string `json:"algorithm"`
+}
+
+type jwtEncodeRequest struct {
+	Payload   string `json:"payload"`
+	Secret    string `json:"secret"`
+	Algorithm string `json:"algorithm"`
+}
+
+type jwtTokenRequest struct {
+	Token string `json:"token"`
+}
+
+type jwtVerifyRequest struct {
+	Token     string  `json:"token"`
+	JWKS      string  `json:"jwks"`
+	Secret    string  `json:"secret"`
+	Algorithm string  `json:"algorithm"`
+	Leeway    float64 `json:"leeway"`
+}
+
+type resolveRequest struct {
+	Entry string            `json:"entry"`
+	Files map[string]string `json:"files"`
+	To    string            `json:"to"`
+}
+
+type registryDecodeRequest struct {
+	Payload  string `json:"payload"`
+	Encoding string `json:"encoding"`
+	Subject  string `json:"subject"`
+}
+
+type registrySubjectRequest struct {
+	Subject string `json:"subject"`
+	Version string `json:"version"`
+}
+
+func apiConvert(c *gin.Context) {
+	var req convertRequest
+	if !bindRequest(c, &req) {
+		return
+	}
+	if req.Pipeline != "" {
+		apiConvertPipeline(c, req)
+		return
+	}
+	if req.Preset != "" {
+		preset, ok := storedEntry(c, store.KindPreset, req.Preset)
+		if !ok {
+			return
+		}
+		req.From = cmp.Or(req.From, preset.From)
+		req.To = cmp.Or(req.To, preset.To)
+		if len(req.Options) == 0 {
+			req.Options = preset.Options
+		}
+	}
+	opts, err := apiConvertOptions(req.Options)
+	if err != nil {
+		apiError(c, err)
+		return
+	}
+	out, err := convert.ConvertFormatsWithOptions(req.From, req.To, req.Input, opts)
+	apiRespond(c, out, err)
+}
+
+// apiConvertUpload converts a multipart "file" using the "from", "to" and
+// optional "options" form fields. Uploads that are not UTF-8 are transcoded
+// first and the response carries a "warning" naming the detected charset.
+func apiConvertUpload(c *gin.Context) {
+	header, err := c.FormFile("file")
+	if err != nil {
+		apiError(c, err)
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		apiError(c, err)
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		apiError(c, err)
+		return
+	}
+	opts, err := apiConvertOptions(json.RawMessage(c.PostForm("options")))
+	if err != nil {
+		apiError(c, err)
+		return
+	}
+	input, charset, err := code.TranscodeToUTF8(data)
+	if err != nil {
+		apiError(c, err)
+		return
+	}
+	out, err := convert.ConvertFormatsWithOptions(c.PostForm("from"), c.PostForm("to"), input, opts)
+	if err != nil {
+		apiError(c, err)
+		return
+	}
+	resp := gin.H{"result": out}
+	if charset.Name != code.CharsetUTF8 {
+		resp["warning"] = fmt.Sprintf("input was decoded from %s", charset.Name)
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+func apiFormat(c *gin.Context) {
+	var req formatRequest
+	if !bindRequest(c, &req) {
+		return
+	}
+	opts, err := apiConvertOptions(req.Options)
+	if err != nil {
+		apiError(c, err)
+		return
+	}
+	out, err := convert.FormatContentWithOptions(req.Format, req.Input, req.Minify, opts)
+	apiRespond(c, out, err)
+}
+
+// apiValidate checks the input's syntax without converting it, answering
+// {"result": true} or the error with its position.
+func apiValidate(c *gin.Context) {
+	var req validateRequest
+	if !bindRequest(c, &req) {
+		return
+	}
+	apiRespond(c, true, convert.Validate(req.Format, req.Input))
+}
+
+// apiQuery answers the values a JSONPath or jq-style query selects, as a
+// JSON array.
+func apiQuery(c *gin.Context) {
+	var req queryRequest
+	if !bindRequest(c, &req) {
+		return
+	}
+	out, err := convert.QueryJSON(req.Input, req.Query)
+	if err != nil {
+		apiError(c, err)
+		return
+	}
+	apiRespond(c, json.RawMessage(out), nil)
+}
+
+func apiEncode(c *gin.Context) {
+	var req inputRequest
+	if !bindRequest(c, &req) {
+		return
+	}
+	out, err := code.EncodeContent(req.Input)
+	apiRespond(c, out, err)
+}
+
+func apiDecode(c *gin.Context) {
+	var req decodeRequest
+	if !bindRequest(c, &req) {
+		return
+	}
+	out, err := code.DecodeContent(req.Encoding, req.Input)
+	apiRespond(c, out, err)
+}
+
+// apiDecodeImage reads the QR code in a screenshot, posted as a multipart
+// "file" or as JSON with the image in base64 or as a data: URL. Only QR
+// codes are supported; Data Matrix, Aztec, PDF417 and 1D barcodes are not
+// read. The result holds the decoded text and its detected format and, when
+// "to" is given, the text converted from that format (or "from") to "to".
+func apiDecodeImage(c *gin.Context) {
+	var req decodeImageRequest
+	var data []byte
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		header, err := c.FormFile("file")
+		if err != nil {
+			apiError(c, err)
+			return
+		}
+		file, err := header.Open()
+		if err != nil {
+			apiError(c, err)
+			return
+		}
+		defer file.Close()
+		if data, err = io.ReadAll(file); err != nil {
+			apiError(c, err)
+			return
+		}
+		req.From, req.To = c.PostForm("from"), c.PostForm("to")
+		req.Options = json.RawMessage(c.PostForm("options"))
+	} else {
+		if !bindRequest(c, &req) {
+			return
+		}
+		image := strings.TrimSpace(req.Image)
+		if strings.HasPrefix(image, "data:") {
+			_, image, _ = strings.Cut(image, ",")
+		}
+		var err error
+		if data, err = base64.StdEncoding.DecodeString(image); err != nil {
+			apiError(c, fmt.Errorf("image is not base64: %w", err))
+			return
+		}
+	}
+	opts, err := apiConvertOptions(req.Options)
+	if err != nil {
+		apiError(c, err)
+		return
+	}
+	text, err := qrcode.DecodeImage(data)
+	if err != nil {
+		apiError(c, err)
+		return
+	}
+	result := gin.H{"text": text}
+	if format, _, err := convert.DetectFormat(text); err == nil {
+		result["format"] = format
+	}
+	if req.To != "" {
+		from := req.From
+		if from == "" {
+			from, _ = result["format"].(string)
+		}
+		if from == "" {
+			apiError(c, errors.New("cannot detect the format of the decoded text; set \"from\""))
+			return
+		}
+		out, err := convert.ConvertFormatsWithOptions(from, req.To, text, opts)
+		if err != nil {
+			apiError(c, err)
+			return
+		}
+		result["output"] = out
+	}
+	apiRespond(c, result, nil)
+}
+
+func apiHash(c *gin.Context) {
+	var req inputRequest
+	if !bindRequest(c, &req) {
+		return
+	}
+	apiRespond(c, code.HashContent(req.Input), nil)
+}
+
+func apiHMAC(c *gin.Context) {
+	var req hmacRequest
+	if !bindRequest(c, &req) {
+		return
+	}
+	res, err := code.HMACContent(req.Input, req.Key, req.Algorithm)
+	apiRespond(c, res, err)
+}
+
+func apiJWTEncode(c *gin.Context) {
+	var req jwtEncodeRequest
+	if !bindRequest(c, &req) {
+		return
+	}
+	token, err := code.JWTEncode(req.Payload, req.Secret, req.Algorithm)
+	apiRespond(c, gin.H{"token": token}, err)
+}
+
+func apiJWTDecode(c *gin.Context) {
+	var req jwtTokenRequest
+	if !bindRequest(c, &req) {
+		return
+	}
+	parts, err := code.JWTDecode(req.Token)
+	apiRespond(c, gin.H{
+		"header":    parts.Header,
+		"payload":   parts.Payload,
+		"signature": parts.Signature,
+		"algorithm": parts.Algorithm,
+	}, err)
+}
+
+// apiJWTVerify verifies against "jwks" when given, otherwise against the
+// HMAC "secret" or PEM key; an optional "leeway" in seconds applies to the
+// claim checks of both.
+func apiJWTVerify(c *gin.Context) {
+	var req jwtVerifyRequest
+	if !bindRequest(c, &req) {
+		return
+	}
+	leeway := time.Duration(req.Leeway * float64(time.Second))
+	if req.JWKS != "" {
+		res, err := code.JWTVerifyJWKSWithLeeway(req.Token, req.JWKS, leeway)
+		apiRespond(c, res, err)
+		return
+	}
+	res, err := code.JWTVerifyWithLeeway(req.Token, req.Secret, req.Algorithm, leeway)
+	apiRespond(c, res, err)
+}
+
+// apiResolve inlines the $ref and !include references of "entry" using the
+// posted "files"; the server never reads its own filesystem.
+func apiResolve(c *gin.Context) {
+	var req resolveRequest
+	if !bindRequest(c, &req) {
+		return
+	}
+	out, err := convert.ResolveIncludeFiles(req.Files, req.Entry, req.To)
+	apiRespond(c, out, err)
+}
+
+// apiRegistryDecode decodes a Kafka record value, given as "base64" (the
+// default) or "hex", with its schema from the configured registry.
+func apiRegistryDecode(c *gin.Context) {
+	var req registryDecodeRequest
+	if !bindRequest(c, &req) || !registryEnabled(c) {
+		return
+	}
+	var payload []byte
+	var err error
+	switch strings.ToLower(req.Encoding) {
+	case "", "base64":
+		payload, err = base64.StdEncoding.DecodeString(strings.TrimSpace(req.Payload))
+	case "hex":
+		payload, err = hex.DecodeString(strings.Join(strings.Fields(req.Payload), ""))
+	default:
+		err = fmt.Errorf("unsupported payload encoding: %s", req.Encoding)
+	}
+	if err != nil {
+		apiError(c, err)
+		return
+	}
+	res, err := schemaRegistry.Decode(c.Request.Context(), payload, req.Subject)
+	apiRespond(c, res, err)
+}
+
+// apiRegistryStruct generates Go structs from a schema version, the latest
+// by default, of a subject in the configured registry.
+func apiRegistryStruct(c *gin.Context) {
+	var req registrySubjectRequest
+	if !bindRequest(c, &req) || !registryEnabled(c) {
+		return
+	}
+	out, err := schemaRegistry.GoStruct(c.Request.Context(), req.Subject, req.Version)
+	apiRespond(c, out, err)
+}
+
+// apiUserAgents lists example user agents, filtered by the "browser" and
+// "os" query parameters. The server refreshes the browser and platform
+// tables behind them every few hours, sharing them through the store.
+func apiUserAgents(c *gin.Context) {
+	list, err := generate.GenerateUserAgents(c.Query("browser"), c.Query("os"))
+	apiRespond(c, list, err)
+}
+
+// apiConvertPipeline runs a saved pipeline over the input, each step
+// converting the previous step's output. A from in the request overrides
+// the pipeline's.
+func apiConvertPipeline(c *gin.Context, req convertRequest) {
+	pipeline, ok := storedEntry(c, store.KindPipeline, req.Pipeline)
+	if !ok {
+		return
+	}
+	from, out := cmp.Or(req.From, pipeline.From), req.Input
+	for i, step := range pipeline.Steps {
+		opts, err := apiConvertOptions(step.Options)
+		if err == nil {
+			out, err = convert.ConvertFormatsWithOptions(from, step.To, out, opts)
+		}
+		if err != nil {
+			apiError(c, fmt.Errorf("step %d (%s to %s): %w", i+1, from, step.To, err))
+			return
+		}
+		from = step.To
+	}
+	apiRespond(c, out, nil)
+}
+
+func apiStoreList(c *gin.Context) {
+	if !storeEnabled(c) {
+		return
+	}
+	entries, err := savedStore.List(apiKey(c), c.Param("kind"))
+	apiRespond(c, entries, err)
+}
+
+func apiStoreGet(c *gin.Context) {
+	if e, ok := storedEntry(c, c.Param("kind"), c.Param("name")); ok {
+		apiRespond(c, e, nil)
+	}
+}
+
+// apiStorePut saves the posted entry under the kind and name in the path,
+// replacing one of the same name.
+func apiStorePut(c *gin.Context) {
+	var e store.Entry
+	if !bindRequest(c, &e) || !storeEnabled(c) {
+		return
+	}
+	e.Kind, e.Name = c.Param("kind"), c.Param("name")
+	saved, err := savedStore.Put(apiKey(c), e)
+	apiRespond(c, saved, err)
+}
+
+func apiStoreDelete(c *gin.Context) {
+	if !storeEnabled(c) {
+		return
+	}
+	err := savedStore.Delete(apiKey(c), c.Param("kind"), c.Param("name"))
+	if errors.Is(err, store.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("no saved %s named %q", c.Param("kind"), c.Param("name"))})
+		return
+	}
+	apiRespond(c, c.Param("name"), err)
+}
+
+// storedEntry looks up the caller's entry, answering 404 when the store is
+// off or has no such entry.
+func storedEntry(c *gin.Context, kind, name string) (store.Entry, bool) {
+	if !storeEnabled(c) {
+		return store.Entry{}, false
+	}
+	e, err := savedStore.Get(apiKey(c), kind, name)
+	if errors.Is(err, store.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("no saved %s named %q", kind, name)})
+		return e, false
+	}
+	if err != nil {
+		apiError(c, err)
+		return e, false
+	}
+	return e, true
+}
+
+// apiKey returns the key from an X-API-Key or bearer Authorization header,
+// or "" for the shared anonymous namespace. Keys partition the store; they
+// are only checked when quotas are on.
+func apiKey(c *gin.Context) string {
+	if key := c.GetHeader("X-API-Key"); key != "" {
+		return key
+	}
+	if token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer "); ok {
+		return strings.TrimSpace(token)
+	}
+	return ""
+}
+
+func storeEnabled(c *gin.Context) bool {
+	if savedStore == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "no store; start the server with " + storeEnv + "=<file>"})
+		return false
+	}
+	return true
+}
+
+func registryEnabled(c *gin.Context) bool {
+	if schemaRegistry == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "no schema registry; start the server with " + registryEnv + "=<url>"})
+		return false
+	}
+	return true
+}
+
+func apiProfiles(c *gin.Context) {
+	apiRespond(c, convert.ListProfiles(), nil)
+}
+
+// apiHealth runs the self-test suite and answers 503 when any check fails,
+// so it can back a readiness probe.
+func apiHealth(c *gin.Context) {
+	report := convert.SelfTest()
+	status := http.StatusOK
+	if !report.OK {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, gin.H{"result": report})
+}
+
+type metricsResponse struct {
+	*convert.MetricsReport
+	Usage []quota.Usage `json:"usage,omitempty"`
+}
+
+// apiMetrics returns the local conversion metrics, which the server
+// records only when started with TRANSFORM_GO_METRICS set, and with quotas
+// on, today's usage of the caller's key, or of every key for an admin.
+func apiMetrics(c *gin.Context) {
+	if !convert.MetricsEnabled() && quotas == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "metrics are off; start the server with " + metricsEnv + "=<file>"})
+		return
+	}
+	var resp metricsResponse
+	if convert.MetricsEnabled() {
+		report := convert.Metrics()
+		resp.MetricsReport = &report
+	}
+	if quotas != nil {
+		usage, err := quotas.Report(apiKey(c))
+		if err != nil {
+			apiQuotaError(c, err)
+			return
+		}
+		resp.Usage = usage
+	}
+	apiRespond(c, resp, nil)
+}
+
+// apiUsage returns today's usage and limits of the caller's key.
+func apiUsage(c *gin.Context) {
+	if quotas == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "quotas are off; start the server with " + quotasEnv + "=<file>"})
+		return
+	}
+	usage, err := quotas.Usage(apiKey(c))
+	if err != nil {
+		apiQuotaError(c, err)
+		return
+	}
+	apiRespond(c, usage, nil)
+}
+
+// apiQuota admits each request against the caller's quota when quotas are
+// on, and bounds the body it may read to the key's input size. The
+// profiles, health, usage and signing-key endpoints are free.
+func apiQuota(c *gin.Context) {
+	feature := apiFeature(c.FullPath())
+	if quotas == nil || feature == "" {
+		return
+	}
+	limits, err := quotas.Admit(apiKey(c), feature, c.Request.ContentLength)
+	if err != nil {
+		apiQuotaError(c, err)
+		return
+	}
+	if limits.MaxInputBytes > 0 {
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limits.MaxInputBytes)
+	}
+}
+
+// apiFeature names the feature a route belongs to in the quota file: the
+// first segment after /api/v1, such as "convert" for /api/v1/convert/upload
+// or "jwt" for /api/v1/jwt/verify. Free endpoints have none.
+func apiFeature(route string) string {
+	name, _, _ := strings.Cut(strings.TrimPrefix(route, "/api/v1/"), "/")
+	switch name {
+	case "profiles", "health", "usage", "signing-key":
+		return ""
+	}
+	return name
+}
+
+func apiQuotaError(c *gin.Context, err error) {
+	status := http.StatusBadRequest
+	switch {
+	case errors.Is(err, quota.ErrUnknownKey):
+		status = http.StatusUnauthorized
+	case errors.Is(err, quota.ErrFeature):
+		status = http.StatusForbidden
+	case errors.Is(err, quota.ErrTooLarge):
+		status = http.StatusRequestEntityTooLarge
+	case errors.Is(err, quota.ErrExhausted):
+		status = http.StatusTooManyRequests
+		retry := time.Until(quotas.ResetAt()).Seconds()
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retry))))
+	}
+	c.AbortWithStatusJSON(status, gin.H{"error": err.Error()})
+}
+
+// apiSigningKey returns the public key responses are signed with, for
+// clients to pin.
+func apiSigningKey(c *gin.Context) {
+	if signer == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "signing is off; start the server with " + signingKeyEnv + "=<file>"})
+		return
+	}
+	apiRespond(c, gin.H{"keyId": signer.KeyID(), "publicKey": signer.PublicPEM()}, nil)
+}
+
+// apiSign holds back each response when signing is on and sends it with
+// the signature of its body and the key's ID in the sign package's headers.
+func apiSign(c *gin.Context) {
+	if signer == nil {
+		return
+	}
+	w := &signingWriter{ResponseWriter: c.Writer}
+	c.Writer = w
+	c.Next()
+	c.Writer = w.ResponseWriter
+	if w.body.Len() == 0 {
+		return
+	}
+	if signature, err := signer.Sign(w.body.Bytes()); err == nil {
+		c.Header(sign.SignatureHeader, signature)
+		c.Header(sign.KeyIDHeader, signer.KeyID())
+	}
+	w.ResponseWriter.Write(w.body.Bytes())
+}
+
+// signingWriter keeps the body apiSign signs; gin sends the status and
+// headers with the first write, so they wait too.
+type signingWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *signingWriter) Write(b []byte) (int, error) { return w.body.Write(b) }
+
+func (w *signingWriter) WriteString(s string) (int, error) { return w.body.WriteString(s) }
+
+// apiConvertOptions decodes an options object such as
+// {"indent": 4, "sortKeys": true, "tagCase": "snake"} on top of the defaults.
+func apiConvertOptions(raw json.RawMessage) (convert.ConvertOption, error) {
+	o := convert.NewConvertOptions()
+	if len(raw) > 0 && string(raw) != "null" {
+		if err := json.Unmarshal(raw, &o); err != nil {
+			return nil, err
+		}
+	}
+	// Clients may lower the YAML alias cap but not lift it.
+	if o.YAMLAliasLimit < 0 || o.YAMLAliasLimit > convert.DefaultYAMLAliasLimit {
+		return nil, fmt.Errorf("yamlAliasLimit must be between 0 and %d", convert.DefaultYAMLAliasLimit)
+	}
+	// Scripts run only on servers that set their memory cap.
+	if o.Script != "" {
+		if scriptLimits == nil {
+			return nil, errors.New("scripts are off; start the server with " + scriptsEnv + "=<bytes>")
+		}
+		o.ScriptLimits = *scriptLimits
+	}
+	return convert.WithOptions(o), nil
+}
+
+func bindRequest(c *gin.Context, req any) bool {
+	if err := c.ShouldBindJSON(req); err != nil {
+		apiError(c, err)
+		return false
+	}
+	return true
+}
+
+func apiRespond(c *gin.Context, result any, err error) {
+	if err != nil {
+		apiError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"result": result})
+}
+
+// apiError answers 400, or 413 for a body over the key's input size,
+// with the position of a syntax error in the input.
+func apiError(c *gin.Context, err error) {
+	status := http.StatusBadRequest
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		status = http.StatusRequestEntityTooLarge
+	}
+	body := gin.H{"error": err.Error()}
+	var syntaxErr *convert.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		body["position"] = syntaxErr
+	}
+	c.JSON(status, body)
+}
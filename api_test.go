@@ -0,0 +1,535 @@
+package main
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"image"
+	"image/png"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/linzeyan/transform-go/pkg/code"
+	"github.com/linzeyan/transform-go/pkg/convert"
+	"github.com/linzeyan/transform-go/pkg/qrcode"
+	"github.com/linzeyan/transform-go/pkg/quota"
+	"github.com/linzeyan/transform-go/pkg/schemaregistry"
+	"github.com/linzeyan/transform-go/pkg/sign"
+	"github.com/linzeyan/transform-go/pkg/storage"
+	"github.com/linzeyan/transform-go/pkg/store"
+	"github.com/stretchr/testify/require"
+)
+
+func apiRequest(t *testing.T, path, body string) (int, map[string]any) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	r, err := newRouter()
+	require.NoError(t, err)
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, req)
+	var resp map[string]any
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	return w.Code, resp
+}
+
+func TestAPIConvert(t *testing.T) {
+	status, resp := apiRequest(t, "/api/v1/convert", `{"from":"JSON","to":"YAML","input":"{\"b\":1,\"a\":2}"}`)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, "b: 1\na: 2", resp["result"])
+
+	status, resp = apiRequest(t, "/api/v1/format", `{"format":"JSON","input":"{\"b\":1,\"a\":2}","options":{"indent":4,"sortKeys":true}}`)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, "{\n    \"a\": 2,\n    \"b\": 1\n}", resp["result"])
+
+	script := `{"from":"YAML","to":"JSON","input":"port: 80",` +
+		`"options":{"script":"def transform(value):\n    value[\"port\"] += 1\n    return value\n"}}`
+	status, resp = apiRequest(t, "/api/v1/convert", script)
+	require.Equal(t, http.StatusBadRequest, status)
+	require.Equal(t, "scripts are off; start the server with TRANSFORM_GO_SCRIPTS=<bytes>", resp["error"])
+
+	scriptLimits = &convert.ScriptLimits{MaxMemory: 1 << 20}
+	defer func() { scriptLimits = nil }()
+	status, resp = apiRequest(t, "/api/v1/convert", script)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, "{\n  \"port\": 81\n}", resp["result"])
+
+	status, resp = apiRequest(t, "/api/v1/convert", `{"from":"JSON","to":"YAML","input":"{}",`+
+		`"options":{"script":"def transform(value):\n    for i in range(2000):\n        for j in range(2000): pass\n"}}`)
+	require.Equal(t, http.StatusBadRequest, status)
+	require.Contains(t, resp["error"], "steps")
+
+	status, resp = apiRequest(t, "/api/v1/convert", `{"from":"JSON","to":"YAML","input":"{}",`+
+		`"options":{"script":"def transform(value):\n    return len(\"x\" * (1 << 28))\n","scriptLimits":{"maxMemory":1073741824}}}`)
+	require.Equal(t, http.StatusBadRequest, status)
+	require.Equal(t, "script: line 2: script exceeded its limit of 1048576 bytes", resp["error"])
+
+	status, resp = apiRequest(t, "/api/v1/convert", `{"from":"JSON","to":"Protobuf","input":"{\"a\":1}","options":{"protoHttp":true}}`)
+	require.Equal(t, http.StatusOK, status)
+	require.Contains(t, resp["result"], `get: "/v1/auto_generateds/{id}"`)
+
+	status, resp = apiRequest(t, "/api/v1/convert", `{"from":"JSON","to":"YAML","input":"{"}`)
+	require.Equal(t, http.StatusBadRequest, status)
+	require.NotEmpty(t, resp["error"])
+
+	status, resp = apiRequest(t, "/api/v1/convert", `{"from":"JSON","to":"YAML","input":"{\"a\": ]}"}`)
+	require.Equal(t, http.StatusBadRequest, status)
+	require.Equal(t, "line 1, column 7: unexpected ']' looking for a value", resp["error"])
+	require.Equal(t, map[string]any{"format": "JSON", "line": 1.0, "column": 7.0, "offset": 6.0,
+		"snippet": `{"a": ]}`, "message": resp["error"]}, resp["position"])
+
+	status, resp = apiRequest(t, "/api/v1/convert", `{"from":"YAML","to":"JSON","input":"a: &a [1]\nb: *a","options":{"yamlAliases":"forbid"}}`)
+	require.Equal(t, http.StatusBadRequest, status)
+	require.Contains(t, resp["error"], "alias *a not allowed")
+	status, resp = apiRequest(t, "/api/v1/convert", `{"from":"YAML","to":"JSON","input":"a: 1","options":{"yamlAliasLimit":-1}}`)
+	require.Equal(t, http.StatusBadRequest, status)
+	require.Contains(t, resp["error"], "yamlAliasLimit")
+
+	status, resp = apiRequest(t, "/api/v1/validate", `{"format":"TOML","input":"a = 1"}`)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, true, resp["result"])
+	status, resp = apiRequest(t, "/api/v1/validate", `{"format":"TOML","input":"a = 1\nb = "}`)
+	require.Equal(t, http.StatusBadRequest, status)
+	require.Equal(t, 2.0, resp["position"].(map[string]any)["line"])
+
+	status, resp = apiRequest(t, "/api/v1/query", `{"input":"a: [1, 2, 3]","query":"$.a[?@ > 1]"}`)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, []any{2.0, 3.0}, resp["result"])
+	status, resp = apiRequest(t, "/api/v1/query", `{"input":"{}","query":"$["}`)
+	require.Equal(t, http.StatusBadRequest, status)
+	require.Contains(t, resp["error"], "invalid query")
+
+	status, resp = apiRequest(t, "/api/v1/convert", `not json`)
+	require.Equal(t, http.StatusBadRequest, status)
+	require.NotEmpty(t, resp["error"])
+}
+
+func TestAPIConvertUpload(t *testing.T) {
+	upload := func(content []byte) (int, map[string]any) {
+		var body bytes.Buffer
+		form := multipart.NewWriter(&body)
+		require.NoError(t, form.WriteField("from", "CSV"))
+		require.NoError(t, form.WriteField("to", "JSON"))
+		part, err := form.CreateFormFile("file", "data.csv")
+		require.NoError(t, err)
+		_, err = part.Write(content)
+		require.NoError(t, err)
+		require.NoError(t, form.Close())
+
+		gin.SetMode(gin.TestMode)
+		r, err := newRouter()
+		require.NoError(t, err)
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/convert/upload", &body)
+		req.Header.Set("Content-Type", form.FormDataContentType())
+		r.ServeHTTP(w, req)
+		var resp map[string]any
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return w.Code, resp
+	}
+
+	status, resp := upload([]byte("city\nZ\xfcrich\n"))
+	require.Equal(t, http.StatusOK, status)
+	require.Contains(t, resp["result"], "Zürich")
+	require.Equal(t, "input was decoded from iso-8859-1", resp["warning"])
+
+	status, resp = upload([]byte("\xef\xbb\xbfcity\nZürich\n"))
+	require.Equal(t, http.StatusOK, status)
+	require.Contains(t, resp["result"], `"city": "Zürich"`)
+	require.NotContains(t, resp, "warning")
+}
+
+func TestAPIDecodeImage(t *testing.T) {
+	qr, err := qrcode.Encode(`{"name":"Ricky","port":80}`, qrcode.M)
+	require.NoError(t, err)
+	var img bytes.Buffer
+	require.NoError(t, png.Encode(&img, qr.Image(4)))
+	encoded := base64.StdEncoding.EncodeToString(img.Bytes())
+
+	status, resp := apiRequest(t, "/api/v1/decode-image", `{"image":"data:image/png;base64,`+encoded+`","to":"YAML"}`)
+	require.Equal(t, http.StatusOK, status)
+	result := resp["result"].(map[string]any)
+	require.Equal(t, `{"name":"Ricky","port":80}`, result["text"])
+	require.Equal(t, "JSON", result["format"])
+	require.Equal(t, "name: Ricky\nport: 80", result["output"])
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("file", "screenshot.png")
+	require.NoError(t, err)
+	_, err = part.Write(img.Bytes())
+	require.NoError(t, err)
+	require.NoError(t, form.Close())
+	gin.SetMode(gin.TestMode)
+	r, err := newRouter()
+	require.NoError(t, err)
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/decode-image", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Equal(t, `{"name":"Ricky","port":80}`, resp["result"].(map[string]any)["text"])
+	require.NotContains(t, resp["result"], "output")
+
+	status, resp = apiRequest(t, "/api/v1/decode-image", `{"image":"not base64!"}`)
+	require.Equal(t, http.StatusBadRequest, status)
+	require.Contains(t, resp["error"], "base64")
+
+	var blank bytes.Buffer
+	require.NoError(t, png.Encode(&blank, image.NewGray(image.Rect(0, 0, 40, 40))))
+	status, resp = apiRequest(t, "/api/v1/decode-image", `{"image":"`+base64.StdEncoding.EncodeToString(blank.Bytes())+`"}`)
+	require.Equal(t, http.StatusBadRequest, status)
+	require.Equal(t, "no QR code found", resp["error"])
+}
+
+func TestAPIEncodeAndHash(t *testing.T) {
+	status, resp := apiRequest(t, "/api/v1/encode", `{"input":"hi"}`)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, "aGk=", resp["result"].(map[string]any)[code.EncodingBase64Std])
+
+	status, resp = apiRequest(t, "/api/v1/decode", `{"encoding":"base64_standard","input":"aGk="}`)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, "hi", resp["result"])
+
+	status, resp = apiRequest(t, "/api/v1/hash", `{"input":"abc"}`)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, "900150983cd24fb0d6963f7d28e17f72", resp["result"].(map[string]any)["md5"])
+
+	status, resp = apiRequest(t, "/api/v1/hmac", `{"input":"abc","key":"k","algorithm":"sha1"}`)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, "sha1", resp["result"].(map[string]any)["algorithm"])
+}
+
+func TestAPIJWT(t *testing.T) {
+	status, resp := apiRequest(t, "/api/v1/jwt/encode", `{"payload":"{\"sub\":\"1\"}","secret":"s"}`)
+	require.Equal(t, http.StatusOK, status)
+	token := resp["result"].(map[string]any)["token"].(string)
+
+	status, resp = apiRequest(t, "/api/v1/jwt/decode", `{"token":"`+token+`"}`)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, "HS256", resp["result"].(map[string]any)["algorithm"])
+
+	status, resp = apiRequest(t, "/api/v1/jwt/verify", `{"token":"`+token+`","secret":"s"}`)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, true, resp["result"].(map[string]any)["valid"])
+
+	status, _ = apiRequest(t, "/api/v1/jwt/encode", `{"payload":"{}"}`)
+	require.Equal(t, http.StatusBadRequest, status)
+
+	status, _ = apiRequest(t, "/api/v1/unknown", `{}`)
+	require.Equal(t, http.StatusNotFound, status)
+}
+
+func TestAPIResolve(t *testing.T) {
+	status, resp := apiRequest(t, "/api/v1/resolve", `{"entry":"app.yaml","to":"YAML","files":{"app.yaml":"db: !include conf/db.json","conf/db.json":"{\"host\":\"localhost\"}"}}`)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, "db:\n  host: localhost", resp["result"])
+
+	status, resp = apiRequest(t, "/api/v1/resolve", `{"entry":"a.json","files":{"a.json":"{\"$ref\":\"a.json\"}"}}`)
+	require.Equal(t, http.StatusBadRequest, status)
+	require.Contains(t, resp["error"], "include cycle")
+}
+
+func TestAPIRegistry(t *testing.T) {
+	status, resp := apiRequest(t, "/api/v1/registry/struct", `{"subject":"users-value"}`)
+	require.Equal(t, http.StatusNotFound, status)
+	require.Contains(t, resp["error"], registryEnv)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		schema := `{\"type\": \"record\", \"name\": \"User\", \"fields\": [{\"name\": \"age\", \"type\": \"int\"}]}`
+		switch r.URL.Path {
+		case "/schemas/ids/7":
+			w.Write([]byte(`{"schema": "` + schema + `"}`))
+		case "/subjects/users-value/versions/latest":
+			w.Write([]byte(`{"subject": "users-value", "id": 7, "version": 1, "schema": "` + schema + `"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error_code": 40401, "message": "Subject not found."}`))
+		}
+	}))
+	defer srv.Close()
+	client, err := schemaregistry.New(srv.URL)
+	require.NoError(t, err)
+	schemaRegistry = client
+	defer func() { schemaRegistry = nil }()
+
+	// Schema 7 with age 27.
+	status, resp = apiRequest(t, "/api/v1/registry/decode", `{"payload":"AAAAAAc2"}`)
+	require.Equal(t, http.StatusOK, status, resp)
+	require.Equal(t, map[string]any{"schemaId": float64(7), "schemaType": "AVRO", "value": map[string]any{"age": float64(27)}}, resp["result"])
+	status, resp = apiRequest(t, "/api/v1/registry/decode", `{"payload":"36","encoding":"hex","subject":"users-value"}`)
+	require.Equal(t, http.StatusOK, status, resp)
+	require.Equal(t, map[string]any{"age": float64(27)}, resp["result"].(map[string]any)["value"])
+	status, resp = apiRequest(t, "/api/v1/registry/decode", `{"payload":"36","encoding":"ascii85"}`)
+	require.Equal(t, http.StatusBadRequest, status)
+	require.Equal(t, "unsupported payload encoding: ascii85", resp["error"])
+
+	status, resp = apiRequest(t, "/api/v1/registry/struct", `{"subject":"users-value"}`)
+	require.Equal(t, http.StatusOK, status, resp)
+	require.Contains(t, resp["result"], "Age")
+	status, resp = apiRequest(t, "/api/v1/registry/struct", `{"subject":"orders-value"}`)
+	require.Equal(t, http.StatusBadRequest, status)
+	require.Equal(t, "schema registry: Subject not found.", resp["error"])
+}
+
+func TestAPIStore(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r, err := newRouter()
+	require.NoError(t, err)
+	do := func(method, path, key, body string) (int, map[string]any) {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if key != "" {
+			req.Header.Set("Authorization", "Bearer "+key)
+		}
+		r.ServeHTTP(w, req)
+		var resp map[string]any
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return w.Code, resp
+	}
+
+	status, resp := do(http.MethodGet, "/api/v1/store/presets", "", "")
+	require.Equal(t, http.StatusNotFound, status)
+	require.Contains(t, resp["error"], storeEnv)
+
+	savedStore = store.New(storage.NewMemory())
+	defer func() { savedStore = nil }()
+
+	status, resp = do(http.MethodPut, "/api/v1/store/presets/to-toml", "alice", `{"from":"YAML","to":"TOML","options":{"sortKeys":false}}`)
+	require.Equal(t, http.StatusOK, status, resp)
+	require.Equal(t, "to-toml", resp["result"].(map[string]any)["name"])
+	status, _ = do(http.MethodPut, "/api/v1/store/pipelines/yaml-msgpack-json", "alice",
+		`{"from":"YAML","steps":[{"to":"MsgPack"},{"to":"JSON","options":{"indent":4}}]}`)
+	require.Equal(t, http.StatusOK, status)
+	status, resp = do(http.MethodPut, "/api/v1/store/macros/x", "alice", `{}`)
+	require.Equal(t, http.StatusBadRequest, status)
+	require.Contains(t, resp["error"], "unknown kind")
+
+	status, resp = do(http.MethodGet, "/api/v1/store/presets", "alice", "")
+	require.Equal(t, http.StatusOK, status)
+	require.Len(t, resp["result"], 1)
+	status, resp = do(http.MethodGet, "/api/v1/store/presets", "bob", "")
+	require.Equal(t, http.StatusOK, status)
+	require.Empty(t, resp["result"])
+
+	status, resp = do(http.MethodPost, "/api/v1/convert", "alice", `{"preset":"to-toml","input":"name: app\nport: 80"}`)
+	require.Equal(t, http.StatusOK, status, resp)
+	require.Equal(t, "name = 'app'\nport = 80", strings.TrimSpace(resp["result"].(string)))
+	status, resp = do(http.MethodPost, "/api/v1/convert", "alice", `{"preset":"to-toml","to":"JSON","input":"b: 1"}`)
+	require.Equal(t, http.StatusOK, status, resp)
+	require.JSONEq(t, `{"b":1}`, resp["result"].(string))
+	status, resp = do(http.MethodPost, "/api/v1/convert", "bob", `{"preset":"to-toml","input":"a: 1"}`)
+	require.Equal(t, http.StatusNotFound, status)
+	require.Contains(t, resp["error"], "to-toml")
+
+	status, resp = do(http.MethodPost, "/api/v1/convert", "alice", `{"pipeline":"yaml-msgpack-json","input":"a: 1"}`)
+	require.Equal(t, http.StatusOK, status, resp)
+	require.Equal(t, "{\n    \"a\": 1\n}", resp["result"])
+	status, resp = do(http.MethodPost, "/api/v1/convert", "alice", `{"pipeline":"yaml-msgpack-json","input":"a: ["}`)
+	require.Equal(t, http.StatusBadRequest, status)
+	require.Contains(t, resp["error"], "step 1 (YAML to MsgPack)")
+
+	status, _ = do(http.MethodDelete, "/api/v1/store/presets/to-toml", "alice", "")
+	require.Equal(t, http.StatusOK, status)
+	status, _ = do(http.MethodGet, "/api/v1/store/presets/to-toml", "alice", "")
+	require.Equal(t, http.StatusNotFound, status)
+	status, _ = do(http.MethodDelete, "/api/v1/store/presets/to-toml", "alice", "")
+	require.Equal(t, http.StatusNotFound, status)
+}
+
+func TestAPIProfiles(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r, err := newRouter()
+	require.NoError(t, err)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/profiles", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Contains(t, w.Body.String(), `"name":"kubernetes-yaml"`)
+
+	status, resp := apiRequest(t, "/api/v1/format", `{"format":"JSON","input":"{\"b\":1,\"a\":2}","options":{"profile":"prettier-json"}}`)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, "{\n  \"b\": 1,\n  \"a\": 2\n}\n", resp["result"])
+}
+
+func TestAPIMetrics(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r, err := newRouter()
+	require.NoError(t, err)
+	get := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/metrics", nil))
+		return w
+	}
+	require.Equal(t, http.StatusNotFound, get().Code)
+
+	convert.EnableMetrics(true)
+	defer convert.EnableMetrics(false)
+	status, _ := apiRequest(t, "/api/v1/convert", `{"from":"JSON","to":"YAML","input":"{\"a\":1}"}`)
+	require.Equal(t, http.StatusOK, status)
+	w := get()
+	require.Equal(t, http.StatusOK, w.Code)
+	var resp struct {
+		Result convert.MetricsReport `json:"result"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Equal(t, 1, resp.Result.Conversions["JSON -> YAML"].Count)
+	require.Equal(t, int64(len(`{"a":1}`)), resp.Result.Conversions["JSON -> YAML"].Bytes)
+}
+
+func TestAPIQuotas(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r, err := newRouter()
+	require.NoError(t, err)
+	do := func(method, path, key, body string) (*httptest.ResponseRecorder, map[string]any) {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if key != "" {
+			req.Header.Set("X-API-Key", key)
+		}
+		r.ServeHTTP(w, req)
+		var resp map[string]any
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return w, resp
+	}
+
+	w, resp := do(http.MethodGet, "/api/v1/usage", "", "")
+	require.Equal(t, http.StatusNotFound, w.Code)
+	require.Contains(t, resp["error"], quotasEnv)
+
+	cfg, err := quota.Parse([]byte(`
+keys:
+  ops:
+    key: ops-key
+    features: [admin, metrics]
+  other:
+    key: other-key
+  team:
+    key: team-key
+    requestsPerDay: 2
+    maxInputBytes: 100
+    features: [convert]
+`))
+	require.NoError(t, err)
+	q, err := quota.New(cfg)
+	require.NoError(t, err)
+	quotas = q
+	defer func() { quotas = nil }()
+
+	convertBody := `{"from":"JSON","to":"YAML","input":"{\"a\":1}"}`
+	w, _ = do(http.MethodPost, "/api/v1/convert", "", convertBody)
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+	w, _ = do(http.MethodPost, "/api/v1/convert", "stranger", convertBody)
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+	w, _ = do(http.MethodGet, "/api/v1/health", "", "")
+	require.Equal(t, http.StatusOK, w.Code)
+
+	w, resp = do(http.MethodPost, "/api/v1/convert", "team-key", convertBody)
+	require.Equal(t, http.StatusOK, w.Code, resp)
+	w, _ = do(http.MethodPost, "/api/v1/hash", "team-key", `{"input":"abc"}`)
+	require.Equal(t, http.StatusForbidden, w.Code)
+	w, _ = do(http.MethodPost, "/api/v1/convert", "team-key", `{"from":"JSON","to":"YAML","input":"`+strings.Repeat(" ", 100)+`"}`)
+	require.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	w, _ = do(http.MethodPost, "/api/v1/convert", "team-key", convertBody)
+	require.Equal(t, http.StatusOK, w.Code)
+	w, resp = do(http.MethodPost, "/api/v1/convert", "team-key", convertBody)
+	require.Equal(t, http.StatusTooManyRequests, w.Code)
+	require.Contains(t, resp["error"], "quota")
+	require.NotEmpty(t, w.Header().Get("Retry-After"))
+
+	w, resp = do(http.MethodGet, "/api/v1/usage", "team-key", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	usage := resp["result"].(map[string]any)
+	require.Equal(t, "team", usage["name"])
+	require.EqualValues(t, 2, usage["requests"])
+	require.EqualValues(t, 3, usage["rejected"])
+	require.EqualValues(t, 2, usage["requestsPerDay"])
+
+	w, resp = do(http.MethodGet, "/api/v1/metrics", "ops-key", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	result := resp["result"].(map[string]any)
+	require.NotContains(t, result, "conversions")
+	require.Len(t, result["usage"], 3)
+	w, _ = do(http.MethodGet, "/api/v1/metrics", "team-key", "")
+	require.Equal(t, http.StatusForbidden, w.Code)
+
+	// Any other key sees its own usage, never the team's.
+	w, resp = do(http.MethodGet, "/api/v1/metrics", "other-key", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	result = resp["result"].(map[string]any)
+	require.Len(t, result["usage"], 1)
+	require.Equal(t, "other", result["usage"].([]any)[0].(map[string]any)["name"])
+}
+
+func TestAPIHealth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r, err := newRouter()
+	require.NoError(t, err)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/health", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Contains(t, w.Body.String(), `"ok":true`)
+	require.Contains(t, w.Body.String(), `"failed":0`)
+}
+
+func TestAPISigning(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r, err := newRouter()
+	require.NoError(t, err)
+	do := func(method, path, body string) *http.Response {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		r.ServeHTTP(w, req)
+		return w.Result()
+	}
+
+	resp := do(http.MethodGet, "/api/v1/signing-key", "")
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+	resp = do(http.MethodPost, "/api/v1/convert", `{"from":"JSON","to":"YAML","input":"{\"a\":1}"}`)
+	require.Empty(t, resp.Header.Get(sign.SignatureHeader))
+
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	signer = sign.NewSigner(key)
+	defer func() { signer = nil }()
+
+	resp = do(http.MethodGet, "/api/v1/signing-key", "")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var keyResp struct {
+		Result struct {
+			KeyID     string `json:"keyId"`
+			PublicKey string `json:"publicKey"`
+		} `json:"result"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&keyResp))
+	require.Equal(t, signer.KeyID(), keyResp.Result.KeyID)
+	pub, err := sign.ParsePublicKey([]byte(keyResp.Result.PublicKey))
+	require.NoError(t, err)
+
+	resp = do(http.MethodPost, "/api/v1/convert", `{"from":"JSON","to":"YAML","input":"{\"a\":1}"}`)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "application/json; charset=utf-8", resp.Header.Get("Content-Type"))
+	body, err := sign.VerifyResponse(pub, resp)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"result":"a: 1"}`, string(body))
+
+	// Errors are signed too, and a changed body no longer verifies.
+	resp = do(http.MethodPost, "/api/v1/convert", `{"from":"JSON","to":"YAML","input":"{"}`)
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	body, err = io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	signature := resp.Header.Get(sign.SignatureHeader)
+	require.NoError(t, sign.Verify(pub, body, signature))
+	require.ErrorIs(t, sign.Verify(pub, bytes.Replace(body, []byte("error"), []byte("result"), 1), signature), sign.ErrSignature)
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/linzeyan/transform-go/pkg/convert"
+)
+
+// Rules name the kinds of finding; they are the SARIF rule IDs.
+const (
+	ruleParse  = "parse"
+	ruleSchema = "schema"
+	ruleFormat = "format"
+)
+
+// extensionFormats picks the format of a file by its extension; files with
+// other extensions are detected from their content.
+var extensionFormats = map[string]string{
+	".json":       "JSON",
+	".yaml":       "YAML",
+	".yml":        "YAML",
+	".toml":       "TOML",
+	".xml":        "XML",
+	".go":         "Go Struct",
+	".hcl":        "HCL",
+	".tf":         "HCL",
+	".tfvars":     "HCL",
+	".properties": "Properties",
+	".env":        "Dotenv",
+	".avsc":       "Avro Schema",
+	".csv":        "CSV",
+	".ndjson":     "NDJSON",
+	".jsonl":      "NDJSON",
+	".plist":      "Plist",
+	".toon":       "TOON",
+}
+
+// finding is one problem in one file. Line and Column are 1-based, and 0
+// when the problem has no known position; Pointer is the JSON Pointer of
+// the value a schema finding is about.
+type finding struct {
+	Path    string `json:"path"`
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+	Pointer string `json:"pointer,omitempty"`
+}
+
+type checker struct {
+	format      string
+	schema      string
+	checkFormat bool
+	options     convert.ConvertOptions
+}
+
+// check returns the findings for one file; the error is for a check that
+// could not run, such as for a format that is not supported.
+func (c *checker) check(path, input string) ([]finding, error) {
+	format := c.format
+	if format == "" {
+		format = extensionFormats[strings.ToLower(filepath.Ext(path))]
+	}
+	diags, err := convert.Diagnose(format, input, c.schema)
+	if err != nil {
+		return nil, err
+	}
+	var findings []finding
+	parsed := true
+	for _, d := range diags {
+		findings = append(findings, finding{
+			Path:    displayPath(path),
+			Line:    d.Line,
+			Column:  d.Column,
+			Rule:    d.Source,
+			Message: d.Message,
+			Pointer: d.Path,
+		})
+		parsed = parsed && d.Source != ruleParse
+	}
+	if !c.checkFormat || !parsed || strings.TrimSpace(input) == "" {
+		return findings, nil
+	}
+	if format == "" {
+		if format, _, err = convert.DetectFormat(input); err != nil {
+			return nil, err
+		}
+	}
+	formatted, err := convert.FormatContentWithOptions(format, input, false, convert.WithOptions(c.options))
+	if err != nil {
+		return nil, err
+	}
+	finalNewline := convert.NewConvertOptions(convert.WithOptions(c.options)).FinalNewline
+	if line, column, drift := formatDrift(input, formatted, finalNewline); drift {
+		findings = append(findings, finding{
+			Path:    displayPath(path),
+			Line:    line,
+			Column:  column,
+			Rule:    ruleFormat,
+			Message: "not formatted as " + format + "; reformat the file",
+		})
+	}
+	return findings, nil
+}
+
+// formatDrift compares a file with its formatted text and returns the
+// position of the first difference. CRLF line endings count as LF, and
+// unless the options ask for a final newline, trailing newlines are not
+// compared: the formatters leave them out but editors add one.
+func formatDrift(input, formatted string, finalNewline bool) (line, column int, drift bool) {
+	input = strings.ReplaceAll(input, "\r\n", "\n")
+	if !finalNewline {
+		input = strings.TrimRight(input, "\n")
+		formatted = strings.TrimRight(formatted, "\n")
+	}
+	if input == formatted {
+		return 0, 0, false
+	}
+	i := 0
+	for i < len(input) && i < len(formatted) && input[i] == formatted[i] {
+		i++
+	}
+	start := strings.LastIndexByte(input[:i], '\n') + 1
+	return strings.Count(input[:i], "\n") + 1, utf8.RuneCountInString(input[start:i]) + 1, true
+}
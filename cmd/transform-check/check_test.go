@@ -0,0 +1,195 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const portSchema = `{"properties":{"port":{"type":"integer"}}}`
+
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+	}
+	return dir
+}
+
+func runCheck(t *testing.T, stdin string, args ...string) (int, string, string) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	code := run(args, strings.NewReader(stdin), &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestRun_ExitCodes(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"good.json":    "{\n  \"port\": 80\n}\n",
+		"broken.json":  "{\n  \"port\": ]\n}\n",
+		"invalid.yaml": "port: http\n",
+		"messy.json":   "{\"port\": 80}\n",
+		"schema.json":  portSchema,
+	})
+	at := func(name string) string { return filepath.Join(dir, name) }
+	schema := "-schema=" + at("schema.json")
+
+	code, out, _ := runCheck(t, "", schema, "-fmt", at("good.json"))
+	require.Equal(t, exitOK, code)
+	require.Empty(t, out)
+
+	code, out, _ = runCheck(t, "", schema, at("invalid.yaml"))
+	require.Equal(t, exitSchema, code)
+	require.Equal(t, at("invalid.yaml")+":1:7: schema: /port: expected integer, got string\n", out)
+
+	code, out, _ = runCheck(t, "", "-fmt", at("messy.json"))
+	require.Equal(t, exitUnformatted, code)
+	require.Contains(t, out, "messy.json:1:2: format: not formatted as JSON")
+
+	// A parse error outranks the rest, whatever the order of the files.
+	code, _, _ = runCheck(t, "", schema, "-fmt", at("messy.json"), at("invalid.yaml"), at("broken.json"))
+	require.Equal(t, exitParse, code)
+
+	code, _, stderr := runCheck(t, "", at("missing.json"))
+	require.Equal(t, exitError, code)
+	require.Contains(t, stderr, "transform-check:")
+
+	code, _, _ = runCheck(t, "")
+	require.Equal(t, exitUsage, code)
+	code, _, stderr = runCheck(t, "", "-report=xml", at("good.json"))
+	require.Equal(t, exitUsage, code)
+	require.Contains(t, stderr, `unknown report format "xml"`)
+}
+
+func TestRun_StdinAndOptions(t *testing.T) {
+	code, out, _ := runCheck(t, "{\"b\": 1, \"a\": 2}", "-format=JSON", "-fmt", "-options", `{"indent":2,"sortKeys":false}`, "-")
+	require.Equal(t, exitUnformatted, code)
+	require.Contains(t, out, "<stdin>:1:2: format:")
+
+	code, _, _ = runCheck(t, "{\n  \"b\": 1,\n  \"a\": 2\n}\n", "-format=JSON", "-fmt", "-options", `{"profile":"prettier-json"}`, "-")
+	require.Equal(t, exitOK, code)
+
+	code, _, _ = runCheck(t, "{\n  \"b\": 1\n}", "-format=JSON", "-fmt", "-options", `{"profile":"prettier-json"}`, "-")
+	require.Equal(t, exitUnformatted, code, "the profile asks for a final newline")
+}
+
+func TestRun_Reports(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"app.yaml":    "port: http\n",
+		"schema.json": portSchema,
+	})
+	args := []string{"-schema=" + filepath.Join(dir, "schema.json"), filepath.Join(dir, "app.yaml")}
+
+	code, out, _ := runCheck(t, "", append([]string{"-report=json"}, args...)...)
+	require.Equal(t, exitSchema, code)
+	var report struct {
+		ExitCode int       `json:"exitCode"`
+		Findings []finding `json:"findings"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(out), &report))
+	require.Equal(t, exitSchema, report.ExitCode)
+	require.Len(t, report.Findings, 1)
+	require.Equal(t, "/port", report.Findings[0].Pointer)
+
+	sarifFile := filepath.Join(dir, "out.sarif")
+	code, out, _ = runCheck(t, "", append([]string{"-report=sarif", "-o", sarifFile}, args...)...)
+	require.Equal(t, exitSchema, code)
+	require.Empty(t, out)
+	data, err := os.ReadFile(sarifFile)
+	require.NoError(t, err)
+	var sarif struct {
+		Version string `json:"version"`
+		Runs    []struct {
+			Tool struct {
+				Driver struct {
+					Name  string `json:"name"`
+					Rules []struct {
+						ID string `json:"id"`
+					} `json:"rules"`
+				} `json:"driver"`
+			} `json:"tool"`
+			Results []struct {
+				RuleID    string `json:"ruleId"`
+				RuleIndex int    `json:"ruleIndex"`
+				Level     string `json:"level"`
+				Locations []struct {
+					PhysicalLocation struct {
+						ArtifactLocation struct {
+							URI string `json:"uri"`
+						} `json:"artifactLocation"`
+						Region struct {
+							StartLine   int `json:"startLine"`
+							StartColumn int `json:"startColumn"`
+						} `json:"region"`
+					} `json:"physicalLocation"`
+				} `json:"locations"`
+			} `json:"results"`
+		} `json:"runs"`
+	}
+	require.NoError(t, json.Unmarshal(data, &sarif))
+	require.Equal(t, "2.1.0", sarif.Version)
+	r := sarif.Runs[0]
+	require.Equal(t, "transform-check", r.Tool.Driver.Name)
+	require.Len(t, r.Results, 1)
+	res := r.Results[0]
+	require.Equal(t, "schema", res.RuleID)
+	require.Equal(t, "schema", r.Tool.Driver.Rules[res.RuleIndex].ID)
+	require.Equal(t, "error", res.Level)
+	loc := res.Locations[0].PhysicalLocation
+	require.Equal(t, filepath.ToSlash(filepath.Join(dir, "app.yaml")), loc.ArtifactLocation.URI)
+	require.Equal(t, 1, loc.Region.StartLine)
+	require.Equal(t, 7, loc.Region.StartColumn)
+}
+
+func TestRun_Preset(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Header.Get("X-API-Key") != "ci":
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":"no saved presets named \"wide-json\""}`))
+		case r.URL.Path == "/api/v1/store/presets/wide-json":
+			w.Write([]byte(`{"result":{"name":"wide-json","kind":"presets","from":"JSON","to":"YAML","options":{"indent":4}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+	dir := writeFiles(t, map[string]string{"app.conf": "{\n    \"port\": 80\n}\n"})
+	file := filepath.Join(dir, "app.conf")
+
+	code, _, _ := runCheck(t, "", "-fmt", "-format=JSON", file)
+	require.Equal(t, exitUnformatted, code)
+	code, out, errOut := runCheck(t, "", "-server="+srv.URL, "-api-key=ci", "-preset=wide-json", "-fmt", file)
+	require.Equal(t, exitOK, code, errOut)
+	require.Empty(t, out)
+	// -options given as well wins over the preset's.
+	code, _, _ = runCheck(t, "", "-server="+srv.URL, "-api-key=ci", "-preset=wide-json", "-options={}", "-fmt", file)
+	require.Equal(t, exitUnformatted, code)
+
+	code, _, errOut = runCheck(t, "", "-server="+srv.URL, "-preset=wide-json", file)
+	require.Equal(t, exitError, code)
+	require.Contains(t, errOut, `no saved presets named "wide-json"`)
+	t.Setenv(serverEnv, "")
+	code, _, errOut = runCheck(t, "", "-preset=wide-json", file)
+	require.Equal(t, exitError, code)
+	require.Contains(t, errOut, serverEnv)
+}
+
+func TestFormatDrift(t *testing.T) {
+	_, _, drift := formatDrift("a\r\nb\n", "a\nb", false)
+	require.False(t, drift)
+	_, _, drift = formatDrift("a\nb", "a\nb\n", true)
+	require.True(t, drift)
+	line, column, drift := formatDrift("a: 1\nbé: x\n", "a: 1\nbé: y\n", false)
+	require.True(t, drift)
+	require.Equal(t, 2, line)
+	require.Equal(t, 5, column)
+}
@@ -0,0 +1,183 @@
+// Command transform-check checks configuration files in CI: that they
+// parse, that they satisfy a JSON Schema and, with -fmt, that they are
+// formatted as transform-go formats them.
+//
+//	transform-check -schema deploy.schema.json -fmt -report sarif deploy/*.yaml
+//
+// -preset takes the format and options of a preset saved on a transform-go
+// server, as in
+//
+//	transform-check -server https://transform.example -preset k8s -fmt deploy/*.yaml
+//
+// Findings are written as text, JSON or SARIF 2.1.0, which GitHub code
+// scanning shows on pull requests. The exit code tells the kind of the most
+// serious finding apart, so a pipeline can treat them differently:
+//
+//	0  every file passed
+//	1  the check could not run, such as for an unreadable file or schema
+//	2  the command line is wrong
+//	3  a file does not parse
+//	4  a file does not satisfy the schema
+//	5  a file is not formatted
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/linzeyan/transform-go/pkg/convert"
+)
+
+// Exit codes, documented above; they are part of the command's interface
+// and must not be renumbered.
+const (
+	exitOK          = 0
+	exitError       = 1
+	exitUsage       = 2
+	exitParse       = 3
+	exitSchema      = 4
+	exitUnformatted = 5
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("transform-check", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	format := fs.String("format", "", "format of every file; by default picked by extension, or detected")
+	schemaFile := fs.String("schema", "", "JSON Schema `file` the documents must satisfy")
+	checkFormat := fs.Bool("fmt", false, "report files that are not formatted")
+	options := fs.String("options", "", "formatting options as a JSON `object`, as in the HTTP API")
+	presetName := fs.String("preset", "", "take -format and -options from the saved preset `name`; flags given as well win")
+	server := fs.String("server", os.Getenv(serverEnv), "transform-go server `URL` to fetch -preset from (default $"+serverEnv+")")
+	apiKey := fs.String("api-key", os.Getenv(apiKeyEnv), "API `key` the preset was saved with (default $"+apiKeyEnv+")")
+	report := fs.String("report", "text", "report `format`: text, json or sarif")
+	output := fs.String("o", "", "write the report to `file` instead of standard output")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "usage: transform-check [flags] file... (- reads standard input)")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
+	}
+	fail := func(code int, err error) int {
+		fmt.Fprintf(stderr, "transform-check: %v\n", err)
+		return code
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return exitUsage
+	}
+	write, ok := reporters[*report]
+	if !ok {
+		return fail(exitUsage, fmt.Errorf("unknown report format %q; use text, json or sarif", *report))
+	}
+
+	c := checker{format: *format, checkFormat: *checkFormat, options: convert.NewConvertOptions()}
+	if *presetName != "" {
+		p, err := fetchPreset(*server, *apiKey, *presetName)
+		if err != nil {
+			return fail(exitError, err)
+		}
+		if c.format == "" {
+			c.format = p.From
+		}
+		if *options == "" && len(p.Options) > 0 {
+			if err := json.Unmarshal(p.Options, &c.options); err != nil {
+				return fail(exitError, fmt.Errorf("preset %q: %w", *presetName, err))
+			}
+		}
+	}
+	if *options != "" {
+		if err := json.Unmarshal([]byte(*options), &c.options); err != nil {
+			return fail(exitUsage, fmt.Errorf("-options: %w", err))
+		}
+	}
+	if *schemaFile != "" {
+		data, err := os.ReadFile(*schemaFile)
+		if err != nil {
+			return fail(exitError, err)
+		}
+		c.schema = string(data)
+	}
+
+	var findings []finding
+	for _, path := range fs.Args() {
+		input, err := readInput(path, stdin)
+		if err != nil {
+			return fail(exitError, err)
+		}
+		found, err := c.check(path, input)
+		if err != nil {
+			return fail(exitError, fmt.Errorf("%s: %w", path, err))
+		}
+		findings = append(findings, found...)
+	}
+	code := exitCode(findings)
+
+	if *output == "" {
+		if err := write(stdout, findings, code); err != nil {
+			return fail(exitError, err)
+		}
+		return code
+	}
+	f, err := os.Create(*output)
+	if err != nil {
+		return fail(exitError, err)
+	}
+	err = write(f, findings, code)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fail(exitError, err)
+	}
+	return code
+}
+
+func readInput(path string, stdin io.Reader) (string, error) {
+	if path == "-" {
+		data, err := io.ReadAll(stdin)
+		return string(data), err
+	}
+	data, err := os.ReadFile(path)
+	return string(data), err
+}
+
+// exitCode returns the code of the most serious finding: a file that does
+// not parse cannot be validated, and an invalid one is worse than an
+// unformatted one.
+func exitCode(findings []finding) int {
+	code := exitOK
+	for _, f := range findings {
+		c := ruleExitCodes[f.Rule]
+		if code == exitOK || c < code {
+			code = c
+		}
+	}
+	return code
+}
+
+var ruleExitCodes = map[string]int{
+	ruleParse:  exitParse,
+	ruleSchema: exitSchema,
+	ruleFormat: exitUnformatted,
+}
+
+// displayPath is how a path appears in reports.
+func displayPath(path string) string {
+	if path == "-" {
+		return "<stdin>"
+	}
+	return strings.TrimPrefix(path, "./")
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Environment fallbacks for -server and -api-key, so a CI secret need not
+// appear on the command line.
+const (
+	serverEnv = "TRANSFORM_GO_SERVER"
+	apiKeyEnv = "TRANSFORM_GO_API_KEY"
+)
+
+// preset is the part of a saved preset the checker uses.
+type preset struct {
+	From    string          `json:"from"`
+	To      string          `json:"to"`
+	Options json.RawMessage `json:"options"`
+}
+
+// fetchPreset reads the preset named name from a transform-go server
+// started with TRANSFORM_GO_STORE, as the owner of key.
+func fetchPreset(server, key, name string) (preset, error) {
+	if server == "" {
+		return preset{}, fmt.Errorf("-preset needs -server or %s", serverEnv)
+	}
+	endpoint := strings.TrimSuffix(server, "/") + "/api/v1/store/presets/" + url.PathEscape(name)
+	req, err := http.NewRequest(http.MethodGet, endpoint, nil)
+	if err != nil {
+		return preset{}, err
+	}
+	if key != "" {
+		req.Header.Set("X-API-Key", key)
+	}
+	client := &http.Client{Timeout: 20 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return preset{}, err
+	}
+	defer resp.Body.Close()
+	var body struct {
+		Result preset `json:"result"`
+		Error  string `json:"error"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&body); err != nil {
+		return preset{}, fmt.Errorf("preset %q: %s", name, resp.Status)
+	}
+	if body.Error != "" {
+		return preset{}, fmt.Errorf("preset %q: %s", name, body.Error)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return preset{}, errors.New(resp.Status)
+	}
+	return body.Result, nil
+}
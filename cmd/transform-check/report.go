@@ -0,0 +1,109 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+)
+
+type reporter func(w io.Writer, findings []finding, code int) error
+
+var reporters = map[string]reporter{
+	"text":  writeText,
+	"json":  writeJSON,
+	"sarif": writeSARIF,
+}
+
+// writeText writes one line per finding in the file:line:column form
+// editors and CI logs link to.
+func writeText(w io.Writer, findings []finding, _ int) error {
+	for _, f := range findings {
+		pos := f.Path
+		if f.Line > 0 {
+			pos = fmt.Sprintf("%s:%d:%d", f.Path, f.Line, f.Column)
+		}
+		msg := f.Message
+		if f.Pointer != "" {
+			msg = f.Pointer + ": " + msg
+		}
+		if _, err := fmt.Fprintf(w, "%s: %s: %s\n", pos, f.Rule, msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeJSON(w io.Writer, findings []finding, code int) error {
+	if findings == nil {
+		findings = []finding{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(map[string]any{"exitCode": code, "findings": findings})
+}
+
+// sarifRules describes the rules for code scanning tools; the order is the
+// ruleIndex of the results.
+var sarifRules = []struct {
+	id, level, description string
+}{
+	{ruleParse, "error", "The file does not parse."},
+	{ruleSchema, "error", "The document does not satisfy the JSON Schema."},
+	{ruleFormat, "warning", "The file is not formatted."},
+}
+
+// writeSARIF writes a SARIF 2.1.0 log, the format GitHub code scanning
+// uploads take.
+func writeSARIF(w io.Writer, findings []finding, _ int) error {
+	rules := make([]map[string]any, len(sarifRules))
+	index := map[string]int{}
+	level := map[string]string{}
+	for i, r := range sarifRules {
+		rules[i] = map[string]any{
+			"id":                   r.id,
+			"shortDescription":     map[string]string{"text": r.description},
+			"defaultConfiguration": map[string]string{"level": r.level},
+		}
+		index[r.id] = i
+		level[r.id] = r.level
+	}
+	results := make([]map[string]any, 0, len(findings))
+	for _, f := range findings {
+		location := map[string]any{
+			"artifactLocation": map[string]string{"uri": filepath.ToSlash(f.Path)},
+		}
+		if f.Line > 0 {
+			region := map[string]int{"startLine": f.Line}
+			if f.Column > 0 {
+				region["startColumn"] = f.Column
+			}
+			location["region"] = region
+		}
+		result := map[string]any{
+			"ruleId":    f.Rule,
+			"ruleIndex": index[f.Rule],
+			"level":     level[f.Rule],
+			"message":   map[string]string{"text": f.Message},
+			"locations": []map[string]any{{"physicalLocation": location}},
+		}
+		if f.Pointer != "" {
+			result["properties"] = map[string]string{"pointer": f.Pointer}
+		}
+		results = append(results, result)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(map[string]any{
+		"$schema": "https://json.schemastore.org/sarif-2.1.0.json",
+		"version": "2.1.0",
+		"runs": []map[string]any{{
+			"tool": map[string]any{"driver": map[string]any{
+				"name":           "transform-check",
+				"informationUri": "https://github.com/linzeyan/transform-go",
+				"rules":          rules,
+			}},
+			"results": results,
+		}},
+	})
+}
@@ -0,0 +1,27 @@
+// Command transform-lsp serves the converters to editors over stdio, so an
+// extension can format, convert and check documents as the user types
+// without starting a process per request.
+//
+// Messages are JSON-RPC 2.0 framed as in the Language Server Protocol, a
+// Content-Length header and a blank line before each body:
+//
+//	Content-Length: 81
+//
+//	{"jsonrpc":"2.0","id":1,"method":"format","params":{"format":"JSON","text":"{}"}}
+//
+// The methods are initialize, formats, detect, format, convert,
+// diagnostics, shutdown and exit; see server.go for their parameters.
+package main
+
+import (
+	"log"
+	"os"
+)
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("transform-lsp: ")
+	if err := newServer().serve(os.Stdin, os.Stdout); err != nil {
+		log.Fatal(err)
+	}
+}
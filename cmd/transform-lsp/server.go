@@ -0,0 +1,305 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/textproto"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/linzeyan/transform-go/pkg/convert"
+)
+
+// JSON-RPC error codes; requestFailed is the Language Server Protocol's code
+// for a request that was understood but could not be carried out, such as
+// a document that does not convert.
+const (
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+	codeRequestFailed  = -32803
+)
+
+// maxMessageSize bounds one message; documents past it are better served
+// by the HTTP API's uploads.
+const maxMessageSize = 64 << 20
+
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *rpcError) Error() string { return e.Message }
+
+type formatParams struct {
+	Format  string          `json:"format"`
+	Text    string          `json:"text"`
+	Minify  bool            `json:"minify"`
+	Options json.RawMessage `json:"options"`
+}
+
+type convertParams struct {
+	From    string          `json:"from"`
+	To      string          `json:"to"`
+	Text    string          `json:"text"`
+	Options json.RawMessage `json:"options"`
+}
+
+type diagnosticsParams struct {
+	Format string `json:"format"`
+	Text   string `json:"text"`
+	Schema string `json:"schema"`
+}
+
+type textParams struct {
+	Text string `json:"text"`
+}
+
+// textResult wraps text results so that an empty document is still sent.
+type textResult struct {
+	Text string `json:"text"`
+}
+
+type handler func(params json.RawMessage) (any, error)
+
+// server answers requests concurrently, so that a slow conversion does not
+// hold up the diagnostics of the next keystroke; replies may therefore
+// arrive out of order, as JSON-RPC allows.
+type server struct {
+	methods map[string]handler
+
+	writeMu  sync.Mutex
+	shutdown atomic.Bool
+}
+
+func newServer() *server {
+	s := &server{}
+	s.methods = map[string]handler{
+		"initialize":  s.initialize,
+		"formats":     formats,
+		"detect":      detect,
+		"format":      format,
+		"convert":     convertText,
+		"diagnostics": diagnostics,
+		"shutdown":    s.stop,
+	}
+	return s
+}
+
+// serve reads requests from r until exit or the end of input and writes
+// the replies to w.
+func (s *server) serve(r io.Reader, w io.Writer) error {
+	in := bufio.NewReader(r)
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	for {
+		body, err := readMessage(in)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		var req request
+		if err := json.Unmarshal(body, &req); err != nil {
+			s.reply(w, response{ID: json.RawMessage("null"), Error: &rpcError{codeParseError, err.Error()}})
+			continue
+		}
+		switch req.Method {
+		case "exit":
+			return nil
+		case "$/cancelRequest", "initialized":
+			// Requests are short; they finish rather than being cancelled.
+			continue
+		}
+		wg.Go(func() { s.handle(w, req) })
+	}
+}
+
+func (s *server) handle(w io.Writer, req request) {
+	var result any
+	var err error
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		err = &rpcError{codeInvalidRequest, "not a JSON-RPC 2.0 request"}
+	} else if s.shutdown.Load() {
+		err = &rpcError{codeInvalidRequest, "server is shutting down"}
+	} else if h, ok := s.methods[req.Method]; !ok {
+		err = &rpcError{codeMethodNotFound, "unknown method: " + req.Method}
+	} else {
+		result, err = h(req.Params)
+	}
+	if req.ID == nil {
+		// Notifications get no reply, not even an error.
+		return
+	}
+	resp := response{ID: req.ID, Result: result}
+	if err != nil {
+		var rerr *rpcError
+		if !errors.As(err, &rerr) {
+			rerr = &rpcError{codeRequestFailed, err.Error()}
+		}
+		resp.Result, resp.Error = nil, rerr
+	} else if result == nil {
+		resp.Result = json.RawMessage("null")
+	}
+	s.reply(w, resp)
+}
+
+func (s *server) reply(w io.Writer, resp response) {
+	resp.JSONRPC = "2.0"
+	body, err := json.Marshal(resp)
+	if err != nil {
+		body, _ = json.Marshal(response{JSONRPC: "2.0", ID: resp.ID, Error: &rpcError{codeRequestFailed, err.Error()}})
+	}
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	fmt.Fprintf(w, "Content-Length: %d\r\n\r\n%s", len(body), body)
+}
+
+// readMessage reads one Content-Length framed message body.
+func readMessage(r *bufio.Reader) ([]byte, error) {
+	header, err := textproto.NewReader(r).ReadMIMEHeader()
+	if err != nil {
+		if errors.Is(err, io.EOF) && len(header) == 0 {
+			return nil, io.EOF
+		}
+		return nil, fmt.Errorf("reading header: %w", err)
+	}
+	size, err := strconv.Atoi(header.Get("Content-Length"))
+	if err != nil || size < 0 {
+		return nil, fmt.Errorf("missing or invalid Content-Length: %q", header.Get("Content-Length"))
+	}
+	if size > maxMessageSize {
+		return nil, fmt.Errorf("message of %d bytes is over the %d byte limit", size, maxMessageSize)
+	}
+	body := make([]byte, size)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, fmt.Errorf("reading body: %w", err)
+	}
+	return body, nil
+}
+
+// decodeParams unmarshals params into v, reporting failures as invalid
+// params.
+func decodeParams(params json.RawMessage, v any) error {
+	if len(params) == 0 {
+		return &rpcError{codeInvalidParams, "params required"}
+	}
+	if err := json.Unmarshal(params, v); err != nil {
+		return &rpcError{codeInvalidParams, err.Error()}
+	}
+	return nil
+}
+
+// convertOptions decodes an options object such as {"indent": 4} on top of
+// the defaults, as the HTTP API does.
+func convertOptions(raw json.RawMessage) (convert.ConvertOption, error) {
+	o := convert.NewConvertOptions()
+	if len(raw) > 0 && string(raw) != "null" {
+		if err := json.Unmarshal(raw, &o); err != nil {
+			return nil, &rpcError{codeInvalidParams, "options: " + err.Error()}
+		}
+	}
+	return convert.WithOptions(o), nil
+}
+
+func (s *server) initialize(json.RawMessage) (any, error) {
+	methods := make([]string, 0, len(s.methods)+1)
+	for name := range s.methods {
+		methods = append(methods, name)
+	}
+	methods = append(methods, "exit")
+	sort.Strings(methods)
+	return map[string]any{
+		"serverInfo": map[string]string{"name": "transform-lsp"},
+		"methods":    methods,
+	}, nil
+}
+
+func (s *server) stop(json.RawMessage) (any, error) {
+	s.shutdown.Store(true)
+	return nil, nil
+}
+
+func formats(json.RawMessage) (any, error) {
+	return convert.ListFormats(), nil
+}
+
+func detect(params json.RawMessage) (any, error) {
+	var p textParams
+	if err := decodeParams(params, &p); err != nil {
+		return nil, err
+	}
+	return convert.DetectFormatCandidates(p.Text), nil
+}
+
+func format(params json.RawMessage) (any, error) {
+	var p formatParams
+	if err := decodeParams(params, &p); err != nil {
+		return nil, err
+	}
+	opts, err := convertOptions(p.Options)
+	if err != nil {
+		return nil, err
+	}
+	out, err := convert.FormatContentWithOptions(p.Format, p.Text, p.Minify, opts)
+	if err != nil {
+		return nil, err
+	}
+	return textResult{out}, nil
+}
+
+// convertText converts text between formats; an empty "from" is detected.
+func convertText(params json.RawMessage) (any, error) {
+	var p convertParams
+	if err := decodeParams(params, &p); err != nil {
+		return nil, err
+	}
+	opts, err := convertOptions(p.Options)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(p.From) == "" {
+		if p.From, _, err = convert.DetectFormat(p.Text); err != nil {
+			return nil, err
+		}
+	}
+	out, err := convert.ConvertFormatsWithOptions(p.From, p.To, p.Text, opts)
+	if err != nil {
+		return nil, err
+	}
+	return textResult{out}, nil
+}
+
+func diagnostics(params json.RawMessage) (any, error) {
+	var p diagnosticsParams
+	if err := decodeParams(params, &p); err != nil {
+		return nil, err
+	}
+	diags, err := convert.Diagnose(p.Format, p.Text, p.Schema)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]any{"diagnostics": diags}, nil
+}
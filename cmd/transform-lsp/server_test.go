@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func frame(body string) string {
+	return fmt.Sprintf("Content-Length: %d\r\n\r\n%s", len(body), body)
+}
+
+// exchange sends the messages and returns the replies keyed by id.
+func exchange(t *testing.T, messages ...string) map[string]response {
+	t.Helper()
+	var in strings.Builder
+	for _, m := range messages {
+		in.WriteString(frame(m))
+	}
+	var out bytes.Buffer
+	require.NoError(t, newServer().serve(strings.NewReader(in.String()), &out))
+
+	replies := map[string]response{}
+	r := bufio.NewReader(&out)
+	for {
+		body, err := readMessage(r)
+		if err != nil {
+			break
+		}
+		var resp struct {
+			response
+			Result json.RawMessage `json:"result"`
+		}
+		require.NoError(t, json.Unmarshal(body, &resp))
+		require.Equal(t, "2.0", resp.JSONRPC)
+		resp.response.Result = resp.Result
+		replies[string(resp.ID)] = resp.response
+	}
+	return replies
+}
+
+func result(t *testing.T, r response, v any) {
+	t.Helper()
+	require.Nil(t, r.Error)
+	require.NoError(t, json.Unmarshal(r.Result.(json.RawMessage), v))
+}
+
+func TestServer(t *testing.T) {
+	replies := exchange(t,
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{}}`,
+		`{"jsonrpc":"2.0","method":"initialized"}`,
+		`{"jsonrpc":"2.0","id":2,"method":"format","params":{"format":"JSON","text":"{\"b\":1,\"a\":2}","options":{"indent":4}}}`,
+		`{"jsonrpc":"2.0","id":"3","method":"convert","params":{"to":"YAML","text":"{\"a\":[1,2]}"}}`,
+		`{"jsonrpc":"2.0","id":4,"method":"diagnostics","params":{"format":"JSON","text":"{\n  \"a\": ]\n}"}}`,
+		`{"jsonrpc":"2.0","id":5,"method":"diagnostics","params":{"format":"YAML","text":"port: http\n","schema":"{\"properties\":{\"port\":{\"type\":\"integer\"}}}"}}`,
+		`{"jsonrpc":"2.0","id":6,"method":"detect","params":{"text":"a = 1"}}`,
+		`{"jsonrpc":"2.0","id":7,"method":"convert","params":{"from":"JSON","to":"YAML","text":"{"}}`,
+		`{"jsonrpc":"2.0","id":8,"method":"nope"}`,
+		`{"jsonrpc":"2.0","id":9,"method":"format"}`,
+		`{"jsonrpc":"2.0","id":10,"method":"convert","params":{"from":"JSON","to":"YAML","text":"{}","options":{"indent":"x"}}}`,
+		`not json`,
+		`{"jsonrpc":"2.0","method":"nope"}`,
+		`{"jsonrpc":"2.0","id":11,"method":"formats"}`,
+	)
+	require.Len(t, replies, 12)
+
+	var init struct {
+		Methods []string `json:"methods"`
+	}
+	result(t, replies["1"], &init)
+	require.Equal(t, []string{"convert", "detect", "diagnostics", "exit", "format", "formats", "initialize", "shutdown"}, init.Methods)
+
+	var text textResult
+	result(t, replies["2"], &text)
+	require.Equal(t, "{\n    \"b\": 1,\n    \"a\": 2\n}", text.Text)
+	result(t, replies[`"3"`], &text)
+	require.Equal(t, "a:\n  - 1\n  - 2", text.Text)
+
+	var diags struct {
+		Diagnostics []map[string]any `json:"diagnostics"`
+	}
+	result(t, replies["4"], &diags)
+	require.Len(t, diags.Diagnostics, 1)
+	require.Equal(t, float64(2), diags.Diagnostics[0]["line"])
+	require.Equal(t, "parse", diags.Diagnostics[0]["source"])
+	result(t, replies["5"], &diags)
+	require.Len(t, diags.Diagnostics, 1)
+	require.Equal(t, "/port", diags.Diagnostics[0]["path"])
+	require.Equal(t, float64(7), diags.Diagnostics[0]["column"])
+
+	var candidates []map[string]any
+	result(t, replies["6"], &candidates)
+	require.Equal(t, "TOML", candidates[0]["format"])
+
+	require.Equal(t, codeRequestFailed, replies["7"].Error.Code)
+	require.Equal(t, &rpcError{codeMethodNotFound, "unknown method: nope"}, replies["8"].Error)
+	require.Equal(t, &rpcError{codeInvalidParams, "params required"}, replies["9"].Error)
+	require.Equal(t, codeInvalidParams, replies["10"].Error.Code)
+	require.Equal(t, codeParseError, replies["null"].Error.Code)
+
+	var formats []map[string]any
+	result(t, replies["11"], &formats)
+	require.NotEmpty(t, formats)
+}
+
+func TestServer_Shutdown(t *testing.T) {
+	replies := exchange(t,
+		`{"jsonrpc":"2.0","id":1,"method":"shutdown"}`,
+		`{"jsonrpc":"2.0","method":"exit"}`,
+		`{"jsonrpc":"2.0","id":2,"method":"formats"}`,
+	)
+	require.Len(t, replies, 1)
+	require.Nil(t, replies["1"].Error)
+	require.Equal(t, json.RawMessage("null"), replies["1"].Result)
+}
+
+func TestReadMessage(t *testing.T) {
+	for input, msg := range map[string]string{
+		"Content-Type: application/json\r\n\r\n{}": `missing or invalid Content-Length: ""`,
+		"Content-Length: 10\r\n\r\n{}":             "reading body: unexpected EOF",
+		"Content-Length: 999999999999\r\n\r\n":     "message of 999999999999 bytes is over the 67108864 byte limit",
+	} {
+		_, err := readMessage(bufio.NewReader(strings.NewReader(input)))
+		require.EqualError(t, err, msg, input)
+	}
+}
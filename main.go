@@ -5,32 +5,149 @@ import (
 	"io/fs"
 	"log"
 	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/linzeyan/transform-go/pkg/convert"
+	"github.com/linzeyan/transform-go/pkg/generate"
+	"github.com/linzeyan/transform-go/pkg/quota"
+	"github.com/linzeyan/transform-go/pkg/schemaregistry"
+	"github.com/linzeyan/transform-go/pkg/sign"
+	"github.com/linzeyan/transform-go/pkg/storage"
+	"github.com/linzeyan/transform-go/pkg/store"
 )
 
 //go:embed web/*
 var webFS embed.FS
 
+// metricsEnv names a file to opt in to local conversion metrics; the
+// report is rewritten there every metricsInterval and never sent anywhere.
+const (
+	metricsEnv      = "TRANSFORM_GO_METRICS"
+	metricsInterval = 10 * time.Second
+)
+
+// registryEnv names the schema registry the /api/v1/registry endpoints
+// fetch from. The server only talks to this one registry, never to a URL
+// taken from a request.
+const registryEnv = "TRANSFORM_GO_SCHEMA_REGISTRY"
+
+// storeEnv names the storage saved snippets, presets and pipelines and the
+// user-agent tables are kept in, as storage.Open reads it: memory:, dir:,
+// a bbolt file or a redis:// URL.
+const storeEnv = "TRANSFORM_GO_STORE"
+
+// quotasEnv names the file of API keys and their daily requests, input
+// size and endpoints; with it set, requests need a key the file admits.
+const quotasEnv = "TRANSFORM_GO_QUOTAS"
+
+// signingKeyEnv names a PEM Ed25519 private key; with it set, API responses
+// carry a signature of their body.
+const signingKeyEnv = "TRANSFORM_GO_SIGNING_KEY"
+
+// scriptsEnv sets the bytes a transform script in a request may hold;
+// without it the script option is refused.
+const scriptsEnv = "TRANSFORM_GO_SCRIPTS"
+
 func main() {
+	r, err := newRouter()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if path := os.Getenv(metricsEnv); path != "" {
+		convert.EnableMetrics(true)
+		go saveMetrics(path)
+		log.Printf("recording conversion metrics to %s", path)
+	}
+	if addr := os.Getenv(registryEnv); addr != "" {
+		if schemaRegistry, err = schemaregistry.New(addr); err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("using schema registry %s", schemaRegistry.URL())
+	}
+	if spec := os.Getenv(storeEnv); spec != "" {
+		s, err := storage.Open(spec)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer s.Close()
+		savedStore = store.New(s)
+		generate.SetUserAgentCache(s)
+		if u, err := url.Parse(spec); err == nil && u.User != nil {
+			spec = u.Redacted()
+		}
+		log.Printf("saving snippets, presets and caches to %s", spec)
+	}
+	if path := os.Getenv(quotasEnv); path != "" {
+		cfg, err := quota.Load(path)
+		if err == nil {
+			quotas, err = quota.New(cfg)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("enforcing the quotas in %s", path)
+	}
+	if path := os.Getenv(signingKeyEnv); path != "" {
+		if signer, err = sign.LoadSigner(path); err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("signing responses with key %s", signer.KeyID())
+	}
+
+	if limit := os.Getenv(scriptsEnv); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n <= 0 {
+			log.Fatalf("%s must be a positive number of bytes, not %q", scriptsEnv, limit)
+		}
+		scriptLimits = &convert.ScriptLimits{MaxMemory: n}
+		log.Printf("running transform scripts within %d bytes", n)
+	}
+
+	log.Println("listening on :8880")
+	if err := r.Run(":8880"); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func newRouter() (*gin.Engine, error) {
 	r := gin.Default()
+	registerAPI(r)
 
 	// 取出 web/ 子目錄
 	sub, err := fs.Sub(webFS, "web")
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
-	// 直接把 / 對應到嵌入式檔案系統
+	// 靜態檔案改由 NoRoute 提供，避免 "/" 的萬用路由與 /api 衝突
 	// 會自動處理 Content-Type（含 .wasm）
-	r.StaticFS("/", http.FS(sub))
-
-	// SPA，需要把未知路由回傳 index.html
+	files := http.FileServer(http.FS(sub))
 	r.NoRoute(func(c *gin.Context) {
-		c.FileFromFS("index.html", http.FS(sub))
+		name := strings.TrimPrefix(c.Request.URL.Path, "/")
+		if strings.HasPrefix(name, "api/") {
+			c.JSON(http.StatusNotFound, gin.H{"error": "unknown endpoint"})
+			return
+		}
+		// SPA，需要把未知路由回傳 index.html
+		if name != "" {
+			if _, err := fs.Stat(sub, name); err != nil {
+				c.FileFromFS("index.html", http.FS(sub))
+				return
+			}
+		}
+		files.ServeHTTP(c.Writer, c.Request)
 	})
+	return r, nil
+}
 
-	log.Println("listening on :8880")
-	if err := r.Run(":8880"); err != nil {
-		log.Fatal(err)
+func saveMetrics(path string) {
+	for range time.Tick(metricsInterval) {
+		if err := convert.WriteMetrics(path); err != nil {
+			log.Printf("metrics: %v", err)
+		}
 	}
 }
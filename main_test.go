@@ -0,0 +1,30 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRouterServesWebAssets(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r, err := newRouter()
+	require.NoError(t, err)
+	for path, want := range map[string]string{
+		"/":          "<title>transform-go</title>",
+		"/app.js":    "function",
+		"/some/page": "<title>transform-go</title>",
+	} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code == http.StatusMovedPermanently {
+			w = httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		}
+		require.Equal(t, http.StatusOK, w.Code, path)
+		require.Contains(t, w.Body.String(), want, path)
+	}
+}
@@ -0,0 +1,100 @@
+package code
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+const (
+	base36Alphabet = "0123456789abcdefghijklmnopqrstuvwxyz"
+	base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+	base62Alphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+)
+
+// encodeBaseX treats data as a big-endian integer and writes it in the given
+// alphabet; each leading zero byte is kept as one leading zero digit.
+func encodeBaseX(data []byte, alphabet string) string {
+	zeros := 0
+	for zeros < len(data) && data[zeros] == 0 {
+		zeros++
+	}
+	num := new(big.Int).SetBytes(data)
+	radix := big.NewInt(int64(len(alphabet)))
+	mod := new(big.Int)
+	var out []byte
+	for num.Sign() > 0 {
+		num.DivMod(num, radix, mod)
+		out = append(out, alphabet[mod.Int64()])
+	}
+	for i := 0; i < zeros; i++ {
+		out = append(out, alphabet[0])
+	}
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return string(out)
+}
+
+func decodeBaseX(input, alphabet string) ([]byte, error) {
+	var lookup [256]int
+	for i := range lookup {
+		lookup[i] = -1
+	}
+	for i := 0; i < len(alphabet); i++ {
+		lookup[alphabet[i]] = i
+	}
+	zeros := 0
+	for zeros < len(input) && input[zeros] == alphabet[0] {
+		zeros++
+	}
+	num := new(big.Int)
+	radix := big.NewInt(int64(len(alphabet)))
+	for i := 0; i < len(input); i++ {
+		idx := lookup[input[i]]
+		if idx == -1 {
+			return nil, fmt.Errorf("invalid base%d character %q", len(alphabet), input[i])
+		}
+		num.Mul(num, radix)
+		num.Add(num, big.NewInt(int64(idx)))
+	}
+	body := num.Bytes()
+	out := make([]byte, zeros+len(body))
+	copy(out[zeros:], body)
+	return out, nil
+}
+
+// encodeBase58Check appends the first four bytes of the double SHA-256 of
+// data before Base58 encoding, as Bitcoin addresses do.
+func encodeBase58Check(data []byte) string {
+	payload := append(append([]byte{}, data...), base58Checksum(data)...)
+	return encodeBaseX(payload, base58Alphabet)
+}
+
+func decodeBase58Check(input string) ([]byte, error) {
+	raw, err := decodeBaseX(input, base58Alphabet)
+	if err != nil {
+		return nil, err
+	}
+	if len(raw) < 4 {
+		return nil, errors.New("base58check input too short")
+	}
+	data, checksum := raw[:len(raw)-4], raw[len(raw)-4:]
+	if !bytes.Equal(checksum, base58Checksum(data)) {
+		return nil, errors.New("base58check checksum mismatch")
+	}
+	return data, nil
+}
+
+func base58Checksum(data []byte) []byte {
+	first := sha256.Sum256(data)
+	second := sha256.Sum256(first[:])
+	return second[:4]
+}
+
+func decodeBase36(input string) ([]byte, error) {
+	return decodeBaseX(strings.ToLower(input), base36Alphabet)
+}
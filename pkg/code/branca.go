@@ -0,0 +1,82 @@
+package code
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+const (
+	brancaVersion    = 0xBA
+	brancaHeaderSize = 1 + 4 + chacha20poly1305.NonceSizeX
+)
+
+// BrancaInfo describes a decrypted Branca token.
+type BrancaInfo struct {
+	Payload   string `json:"payload"`
+	Timestamp string `json:"timestamp"`
+	Unix      int64  `json:"unix"`
+}
+
+// BrancaEncode encrypts payload into a Branca token with a 32-byte key.
+func BrancaEncode(payload, key string) (string, error) {
+	nonce := make([]byte, chacha20poly1305.NonceSizeX)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	return brancaSeal([]byte(payload), key, nonce, uint32(time.Now().Unix()))
+}
+
+func brancaSeal(payload []byte, key string, nonce []byte, ts uint32) (string, error) {
+	k, err := parseTokenKey(key)
+	if err != nil {
+		return "", err
+	}
+	aead, err := chacha20poly1305.NewX(k)
+	if err != nil {
+		return "", err
+	}
+	header := make([]byte, brancaHeaderSize)
+	header[0] = brancaVersion
+	binary.BigEndian.PutUint32(header[1:5], ts)
+	copy(header[5:], nonce)
+	sealed := aead.Seal(header, nonce, payload, header)
+	return encodeBaseX(sealed, base62Alphabet), nil
+}
+
+// BrancaDecode decrypts a Branca token and reports its embedded timestamp.
+func BrancaDecode(token, key string) (BrancaInfo, error) {
+	var info BrancaInfo
+	raw, err := decodeBaseX(strings.TrimSpace(token), base62Alphabet)
+	if err != nil {
+		return info, err
+	}
+	if len(raw) < brancaHeaderSize+chacha20poly1305.Overhead {
+		return info, errors.New("branca token too short")
+	}
+	if raw[0] != brancaVersion {
+		return info, errors.New("invalid branca version " + strconv.Itoa(int(raw[0])))
+	}
+	k, err := parseTokenKey(key)
+	if err != nil {
+		return info, err
+	}
+	aead, err := chacha20poly1305.NewX(k)
+	if err != nil {
+		return info, err
+	}
+	header := raw[:brancaHeaderSize]
+	plain, err := aead.Open(nil, header[5:], raw[brancaHeaderSize:], header)
+	if err != nil {
+		return info, errors.New("unable to decrypt branca token")
+	}
+	info.Unix = int64(binary.BigEndian.Uint32(header[1:5]))
+	info.Timestamp = time.Unix(info.Unix, 0).UTC().Format(time.RFC3339)
+	info.Payload = prettyIfJSON(plain)
+	return info, nil
+}
@@ -0,0 +1,31 @@
+package code
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const brancaTestKey = "supersecretkeyyoushouldnotcommit"
+
+func TestBrancaVector(t *testing.T) {
+	token := "875GH233T7IYrxtgXxlQBYiFobZMQdHAT51vChKsAIYCFxZtL1evV54vYqLyZtQ0ekPHt8kJHQp0a"
+	info, err := BrancaDecode(token, brancaTestKey)
+	require.NoError(t, err)
+	require.Equal(t, "Hello world!", info.Payload)
+	require.Equal(t, int64(123206400), info.Unix)
+}
+
+func TestBrancaRoundTrip(t *testing.T) {
+	token, err := BrancaEncode(`{"user":"ada"}`, brancaTestKey)
+	require.NoError(t, err)
+	info, err := BrancaDecode(token, brancaTestKey)
+	require.NoError(t, err)
+	require.Contains(t, info.Payload, `"user": "ada"`)
+	require.NotEmpty(t, info.Timestamp)
+
+	_, err = BrancaDecode(token, "0000000000000000000000000000000x")
+	require.Error(t, err)
+	_, err = BrancaDecode("not-base62!", brancaTestKey)
+	require.Error(t, err)
+}
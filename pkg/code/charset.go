@@ -0,0 +1,238 @@
+package code
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding/traditionalchinese"
+	xunicode "golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/encoding/unicode/utf32"
+)
+
+const (
+	CharsetUTF8     = "utf-8"
+	CharsetUTF16LE  = "utf-16le"
+	CharsetUTF16BE  = "utf-16be"
+	CharsetUTF32LE  = "utf-32le"
+	CharsetUTF32BE  = "utf-32be"
+	CharsetLatin1   = "iso-8859-1"
+	CharsetShiftJIS = "shift_jis"
+	CharsetGBK      = "gbk"
+	CharsetBig5     = "big5"
+)
+
+// charsetEncodings decodes every detected charset except UTF-8. Latin-1 is
+// read as windows-1252, as browsers do, so smart quotes from Windows files
+// survive.
+var charsetEncodings = map[string]encoding.Encoding{
+	CharsetUTF16LE:  xunicode.UTF16(xunicode.LittleEndian, xunicode.IgnoreBOM),
+	CharsetUTF16BE:  xunicode.UTF16(xunicode.BigEndian, xunicode.IgnoreBOM),
+	CharsetUTF32LE:  utf32.UTF32(utf32.LittleEndian, utf32.IgnoreBOM),
+	CharsetUTF32BE:  utf32.UTF32(utf32.BigEndian, utf32.IgnoreBOM),
+	CharsetLatin1:   charmap.Windows1252,
+	CharsetShiftJIS: japanese.ShiftJIS,
+	CharsetGBK:      simplifiedchinese.GBK,
+	CharsetBig5:     traditionalchinese.Big5,
+}
+
+var charsetBOMs = []struct {
+	name string
+	bom  []byte
+}{
+	{CharsetUTF8, []byte{0xEF, 0xBB, 0xBF}},
+	{CharsetUTF32LE, []byte{0xFF, 0xFE, 0x00, 0x00}},
+	{CharsetUTF32BE, []byte{0x00, 0x00, 0xFE, 0xFF}},
+	{CharsetUTF16LE, []byte{0xFF, 0xFE}},
+	{CharsetUTF16BE, []byte{0xFE, 0xFF}},
+}
+
+// Charset is the result of DetectCharset. Exact is true when a byte order
+// mark or valid UTF-8 settled the answer rather than a heuristic.
+type Charset struct {
+	Name  string `json:"name"`
+	BOM   bool   `json:"bom"`
+	Exact bool   `json:"exact"`
+}
+
+// DetectCharset guesses the encoding of data: a byte order mark wins, then
+// valid UTF-8, then UTF-16/32 without a BOM (from the position of zero
+// bytes), then Shift-JIS, GBK or Big5 when the bytes decode to plausible
+// CJK text, and Latin-1 otherwise.
+func DetectCharset(data []byte) Charset {
+	for _, b := range charsetBOMs {
+		if bytes.HasPrefix(data, b.bom) {
+			return Charset{Name: b.name, BOM: true, Exact: true}
+		}
+	}
+	if name := detectWideCharset(data); name != "" {
+		return Charset{Name: name}
+	}
+	if utf8.Valid(data) {
+		return Charset{Name: CharsetUTF8, Exact: true}
+	}
+	return Charset{Name: detectLegacyCharset(data)}
+}
+
+// TranscodeToUTF8 detects the encoding of data and returns it as UTF-8
+// text without a byte order mark.
+func TranscodeToUTF8(data []byte) (string, Charset, error) {
+	cs := DetectCharset(data)
+	if cs.Name == CharsetUTF8 {
+		return string(bytes.TrimPrefix(data, charsetBOMs[0].bom)), cs, nil
+	}
+	out, err := decodeCharset(cs.Name, data)
+	if err != nil {
+		return "", cs, err
+	}
+	return strings.TrimPrefix(out, "\ufeff"), cs, nil
+}
+
+func decodeCharset(name string, data []byte) (string, error) {
+	enc, ok := charsetEncodings[name]
+	if !ok {
+		return "", fmt.Errorf("unsupported charset %q", name)
+	}
+	out, err := enc.NewDecoder().Bytes(data)
+	if err != nil {
+		return "", fmt.Errorf("decode %s: %w", name, err)
+	}
+	return string(out), nil
+}
+
+// detectWideCharset spots BOM-less UTF-16 and UTF-32 by the zero bytes that
+// mostly-ASCII text leaves in the high half of each code unit.
+func detectWideCharset(data []byte) string {
+	if bytes.IndexByte(data, 0) < 0 {
+		return ""
+	}
+	if len(data)%4 == 0 {
+		var zeros [4]int
+		for i, b := range data {
+			if b == 0 {
+				zeros[i%4]++
+			}
+		}
+		units := len(data) / 4
+		if zeros[2] == units && zeros[3] == units && zeros[1]*2 > units {
+			return CharsetUTF32LE
+		}
+		if zeros[0] == units && zeros[1] == units && zeros[2]*2 > units {
+			return CharsetUTF32BE
+		}
+	}
+	if len(data)%2 == 0 {
+		var zeros [2]int
+		for i, b := range data {
+			if b == 0 {
+				zeros[i%2]++
+			}
+		}
+		units := len(data) / 2
+		if zeros[1]*2 > units && zeros[0]*10 < units {
+			return CharsetUTF16LE
+		}
+		if zeros[0]*2 > units && zeros[1]*10 < units {
+			return CharsetUTF16BE
+		}
+	}
+	return ""
+}
+
+// detectLegacyCharset picks among the double-byte CJK encodings when data
+// decodes cleanly into CJK characters that mostly sit next to each other;
+// isolated high bytes between ASCII letters are accented Latin-1 text.
+func detectLegacyCharset(data []byte) string {
+	plausible := map[string]cjkStats{}
+	for _, name := range []string{CharsetShiftJIS, CharsetGBK, CharsetBig5} {
+		text, err := decodeCharset(name, data)
+		if err != nil || strings.ContainsRune(text, utf8.RuneError) {
+			continue
+		}
+		if stats := measureCJK(text); stats.plausible() {
+			plausible[name] = stats
+		}
+	}
+	if stats, ok := plausible[CharsetShiftJIS]; ok && stats.kana*5 >= stats.wide {
+		return CharsetShiftJIS
+	}
+	_, gbk := plausible[CharsetGBK]
+	_, big5 := plausible[CharsetBig5]
+	switch {
+	case gbk && big5:
+		if gb2312Share(data) >= 0.9 {
+			return CharsetGBK
+		}
+		return CharsetBig5
+	case gbk:
+		return CharsetGBK
+	case big5:
+		return CharsetBig5
+	}
+	if _, ok := plausible[CharsetShiftJIS]; ok {
+		return CharsetShiftJIS
+	}
+	return CharsetLatin1
+}
+
+type cjkStats struct {
+	wide      int // non-ASCII runes
+	cjk       int // ideographs, kana, CJK punctuation and fullwidth forms
+	kana      int
+	clustered int // non-ASCII runes next to another non-ASCII rune
+}
+
+func (s cjkStats) plausible() bool {
+	return s.wide > 0 && s.cjk*10 >= s.wide*9 && s.clustered*2 >= s.wide
+}
+
+func measureCJK(text string) cjkStats {
+	var s cjkStats
+	runes := []rune(text)
+	wide := func(i int) bool { return i >= 0 && i < len(runes) && runes[i] >= utf8.RuneSelf }
+	for i, r := range runes {
+		if r < utf8.RuneSelf {
+			continue
+		}
+		s.wide++
+		switch {
+		case unicode.In(r, unicode.Hiragana, unicode.Katakana):
+			s.kana++
+			s.cjk++
+		case unicode.Is(unicode.Han, r), r >= 0x3000 && r <= 0x303F, r >= 0xFF00 && r <= 0xFFEF:
+			s.cjk++
+		}
+		if wide(i-1) || wide(i+1) {
+			s.clustered++
+		}
+	}
+	return s
+}
+
+// gb2312Share is the fraction of double-byte pairs inside the GB2312 block
+// (both bytes 0xA1-0xFE). Simplified Chinese text stays there almost
+// entirely, while half of the common Big5 characters use a trail byte
+// below 0x80.
+func gb2312Share(data []byte) float64 {
+	pairs, inside := 0, 0
+	for i := 0; i < len(data); i++ {
+		if data[i] < 0x81 || i+1 == len(data) {
+			continue
+		}
+		pairs++
+		if data[i] >= 0xA1 && data[i+1] >= 0xA1 && data[i+1] <= 0xFE {
+			inside++
+		}
+		i++
+	}
+	if pairs == 0 {
+		return 0
+	}
+	return float64(inside) / float64(pairs)
+}
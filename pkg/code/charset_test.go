@@ -0,0 +1,51 @@
+package code
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding/traditionalchinese"
+	xunicode "golang.org/x/text/encoding/unicode"
+)
+
+func encodeCharset(t *testing.T, enc encoding.Encoding, text string) []byte {
+	t.Helper()
+	out, err := enc.NewEncoder().Bytes([]byte(text))
+	require.NoError(t, err)
+	return out
+}
+
+func TestDetectCharset(t *testing.T) {
+	cases := []struct {
+		name string
+		data []byte
+		text string
+	}{
+		{CharsetUTF8, []byte("plain ascii"), "plain ascii"},
+		{CharsetUTF8, []byte("\xEF\xBB\xBFname,city\n"), "name,city\n"},
+		{CharsetUTF16LE, encodeCharset(t, xunicode.UTF16(xunicode.LittleEndian, xunicode.UseBOM), "{\"a\":1}"), "{\"a\":1}"},
+		{CharsetUTF16BE, encodeCharset(t, xunicode.UTF16(xunicode.BigEndian, xunicode.IgnoreBOM), "key: value"), "key: value"},
+		{CharsetUTF32LE, []byte{'h', 0, 0, 0, 'i', 0, 0, 0}, "hi"},
+		{CharsetLatin1, encodeCharset(t, charmap.Windows1252, "Café, naïve façade – “résumé”"), "Café, naïve façade – “résumé”"},
+		{CharsetShiftJIS, encodeCharset(t, japanese.ShiftJIS, "名前,住所\n山田太郎,東京都のどこか\n"), "名前,住所\n山田太郎,東京都のどこか\n"},
+		{CharsetGBK, encodeCharset(t, simplifiedchinese.GBK, "姓名,城市\n张三,北京市海淀区\n李四,上海市浦东新区\n"), "姓名,城市\n张三,北京市海淀区\n李四,上海市浦东新区\n"},
+		{CharsetBig5, encodeCharset(t, traditionalchinese.Big5, "姓名,城市\n陳大文,臺北市中正區\n林小明,高雄市前鎮區\n"), "姓名,城市\n陳大文,臺北市中正區\n林小明,高雄市前鎮區\n"},
+	}
+	for _, tc := range cases {
+		cs := DetectCharset(tc.data)
+		require.Equal(t, tc.name, cs.Name, tc.text)
+		text, got, err := TranscodeToUTF8(tc.data)
+		require.NoError(t, err)
+		require.Equal(t, cs, got)
+		require.Equal(t, tc.text, text)
+	}
+
+	cs := DetectCharset([]byte("\xFF\xFEa\x00"))
+	require.True(t, cs.BOM)
+	require.True(t, cs.Exact)
+	require.False(t, DetectCharset([]byte("caf\xE9")).Exact)
+}
@@ -22,6 +22,12 @@ import (
 	"io"
 	"net/url"
 	"strings"
+	"sync"
+
+	"github.com/zeebo/blake3"
+	"golang.org/x/crypto/blake2b"
+	"golang.org/x/crypto/blake2s"
+	"golang.org/x/crypto/sha3"
 )
 
 const (
@@ -33,6 +39,10 @@ const (
 	EncodingBase64RawStd       = "base64_raw_standard"
 	EncodingBase64URL          = "base64_url"
 	EncodingBase64RawURL       = "base64_raw_url"
+	EncodingBase36             = "base36"
+	EncodingBase58             = "base58"
+	EncodingBase58Check        = "base58_check"
+	EncodingBase62             = "base62"
 	EncodingBase85ASCII        = "base85_ascii85"
 	EncodingBase91             = "base91"
 	EncodingHexUpper           = "hex_upper"
@@ -43,10 +53,12 @@ var (
 	base32HexNoPadding = base32.HexEncoding.WithPadding(base32.NoPadding)
 	base64RawStd       = base64.StdEncoding.WithPadding(base64.NoPadding)
 	base64RawURL       = base64.URLEncoding.WithPadding(base64.NoPadding)
-	crc32Castagnoli    = crc32.MakeTable(crc32.Castagnoli)
-	crc64ISOTable      = crc64.MakeTable(crc64.ISO)
-	crc64ECMATable     = crc64.MakeTable(crc64.ECMA)
-	base91Lookup       = initBase91Lookup()
+	// The CRC and base91 tables are built on first use; the CRC-64 ones
+	// alone take 32KB.
+	crc32Castagnoli = sync.OnceValue(func() *crc32.Table { return crc32.MakeTable(crc32.Castagnoli) })
+	crc64ISOTable   = sync.OnceValue(func() *crc64.Table { return crc64.MakeTable(crc64.ISO) })
+	crc64ECMATable  = sync.OnceValue(func() *crc64.Table { return crc64.MakeTable(crc64.ECMA) })
+	base91Lookup    = sync.OnceValue(initBase91Lookup)
 )
 
 // EncodeContent runs through all supported encodings and returns every representation.
@@ -61,6 +73,10 @@ func EncodeContent(input string) (map[string]string, error) {
 		EncodingBase64RawStd:       base64RawStd.EncodeToString(data),
 		EncodingBase64URL:          base64.URLEncoding.EncodeToString(data),
 		EncodingBase64RawURL:       base64RawURL.EncodeToString(data),
+		EncodingBase36:             encodeBaseX(data, base36Alphabet),
+		EncodingBase58:             encodeBaseX(data, base58Alphabet),
+		EncodingBase58Check:        encodeBase58Check(data),
+		EncodingBase62:             encodeBaseX(data, base62Alphabet),
 		EncodingBase91:             encodeBase91(data),
 		EncodingHexUpper:           hexUpper(data),
 	}
@@ -114,10 +130,36 @@ func HashContent(input string) map[string]string {
 	sumSHA512_256 := sha512.Sum512_256(data)
 	out["sha512_256"] = hex.EncodeToString(sumSHA512_256[:])
 
+	sumSHA3_224 := sha3.Sum224(data)
+	out["sha3_224"] = hex.EncodeToString(sumSHA3_224[:])
+
+	sumSHA3_256 := sha3.Sum256(data)
+	out["sha3_256"] = hex.EncodeToString(sumSHA3_256[:])
+
+	sumSHA3_384 := sha3.Sum384(data)
+	out["sha3_384"] = hex.EncodeToString(sumSHA3_384[:])
+
+	sumSHA3_512 := sha3.Sum512(data)
+	out["sha3_512"] = hex.EncodeToString(sumSHA3_512[:])
+
+	out["keccak256"] = digestHash(sha3.NewLegacyKeccak256(), data)
+
+	sumBLAKE2b256 := blake2b.Sum256(data)
+	out["blake2b_256"] = hex.EncodeToString(sumBLAKE2b256[:])
+
+	sumBLAKE2b512 := blake2b.Sum512(data)
+	out["blake2b_512"] = hex.EncodeToString(sumBLAKE2b512[:])
+
+	sumBLAKE2s256 := blake2s.Sum256(data)
+	out["blake2s_256"] = hex.EncodeToString(sumBLAKE2s256[:])
+
+	sumBLAKE3 := blake3.Sum256(data)
+	out["blake3"] = hex.EncodeToString(sumBLAKE3[:])
+
 	out["crc32_ieee"] = fmt.Sprintf("%08x", crc32.ChecksumIEEE(data))
-	out["crc32_castagnoli"] = fmt.Sprintf("%08x", crc32.Checksum(data, crc32Castagnoli))
-	out["crc64_iso"] = fmt.Sprintf("%016x", crc64.Checksum(data, crc64ISOTable))
-	out["crc64_ecma"] = fmt.Sprintf("%016x", crc64.Checksum(data, crc64ECMATable))
+	out["crc32_castagnoli"] = fmt.Sprintf("%08x", crc32.Checksum(data, crc32Castagnoli()))
+	out["crc64_iso"] = fmt.Sprintf("%016x", crc64.Checksum(data, crc64ISOTable()))
+	out["crc64_ecma"] = fmt.Sprintf("%016x", crc64.Checksum(data, crc64ECMATable()))
 	out["adler32"] = fmt.Sprintf("%08x", adler32.Checksum(data))
 
 	out["fnv32"] = fmt.Sprintf("%08x", digest32(fnv.New32(), data))
@@ -155,6 +197,14 @@ var encodingDecoders = map[string]func(string) ([]byte, error){
 	EncodingBase64RawURL: func(s string) ([]byte, error) {
 		return base64RawURL.DecodeString(s)
 	},
+	EncodingBase36: decodeBase36,
+	EncodingBase58: func(s string) ([]byte, error) {
+		return decodeBaseX(s, base58Alphabet)
+	},
+	EncodingBase58Check: decodeBase58Check,
+	EncodingBase62: func(s string) ([]byte, error) {
+		return decodeBaseX(s, base62Alphabet)
+	},
 	EncodingBase85ASCII: decodeBase85,
 	EncodingBase91:      decodeBase91,
 	EncodingHexUpper: func(s string) ([]byte, error) {
@@ -201,9 +251,10 @@ func decodeBase91(input string) ([]byte, error) {
 	var bits uint
 	var b = -1
 	var out []byte
+	lookup := base91Lookup()
 	for i := 0; i < len(input); i++ {
 		c := input[i]
-		index := base91Lookup[c]
+		index := lookup[c]
 		if index == -1 {
 			return nil, fmt.Errorf("invalid base91 character %q", c)
 		}
@@ -232,8 +283,8 @@ func decodeBase91(input string) ([]byte, error) {
 	return out, nil
 }
 
-func initBase91Lookup() [256]int {
-	var table [256]int
+func initBase91Lookup() *[256]int {
+	table := new([256]int)
 	for i := range table {
 		table[i] = -1
 	}
@@ -243,7 +294,7 @@ func initBase91Lookup() [256]int {
 	return table
 }
 
-var base91Alphabet = []byte("ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789!#$%&()*+,./:;?@[]^_`{|}~\"")
+var base91Alphabet = []byte("ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789!#$%&()*+,./:;<=>?@[]^_`{|}~\"")
 
 func hexUpper(data []byte) string {
 	buf := make([]byte, hex.EncodedLen(len(data)))
@@ -289,6 +340,8 @@ type JWTParts struct {
 	Algorithm string
 }
 
+// JWTEncode signs payloadInput with an HMAC secret for HS*, or with a PEM
+// private key for RS*, PS* and ES*.
 func JWTEncode(payloadInput, secret, algorithm string) (string, error) {
 	if strings.TrimSpace(secret) == "" {
 		return "", errors.New("secret is required")
@@ -380,6 +433,8 @@ func signJWT(signingInput, secret, algorithm string) (string, error) {
 		mac = hmac.New(sha512.New384, []byte(secret))
 	case "HS512":
 		mac = hmac.New(sha512.New, []byte(secret))
+	case "RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512":
+		return signJWTWithKey(signingInput, secret, algorithm)
 	default:
 		return "", fmt.Errorf("unsupported algorithm %s", algorithm)
 	}
@@ -17,6 +17,10 @@ func TestEncodeContent(t *testing.T) {
 	require.Equal(t, "aGk", res[EncodingBase64RawStd])
 	require.Equal(t, "aGk=", res[EncodingBase64URL])
 	require.Equal(t, "aGk", res[EncodingBase64RawURL])
+	require.Equal(t, "kmh", res[EncodingBase36])
+	require.Equal(t, "8wr", res[EncodingBase58])
+	require.Equal(t, "tzgy3cTQ", res[EncodingBase58Check])
+	require.Equal(t, "6x7", res[EncodingBase62])
 	require.Equal(t, "BP@", res[EncodingBase85ASCII])
 	require.Equal(t, "qaD", res[EncodingBase91])
 	require.Equal(t, "6869", res[EncodingHexUpper])
@@ -33,8 +37,14 @@ func TestDecodeContent(t *testing.T) {
 		{EncodingBase32HexNoPadding, "D1KG", "hi"},
 		{EncodingBase64URL, "aGk=", "hi"},
 		{EncodingBase64RawURL, "aGk", "hi"},
+		{EncodingBase36, "KMH", "hi"},
+		{EncodingBase58, "8wr", "hi"},
+		{EncodingBase58, "1112", "\x00\x00\x00\x01"},
+		{EncodingBase58Check, "tzgy3cTQ", "hi"},
+		{EncodingBase62, "6x7", "hi"},
 		{EncodingBase85ASCII, "BP@", "hi"},
 		{EncodingBase91, "qaD", "hi"},
+		{EncodingBase91, ">OwJh>Io0Tv!8PE", "Hello World!"},
 		{EncodingHexUpper, "6869", "hi"},
 	}
 	for _, tc := range cases {
@@ -46,6 +56,10 @@ func TestDecodeContent(t *testing.T) {
 	require.Error(t, err)
 	_, err = DecodeContent(EncodingBase32Std, "invalid===")
 	require.Error(t, err)
+	_, err = DecodeContent(EncodingBase58, "0OIl")
+	require.Error(t, err)
+	_, err = DecodeContent(EncodingBase58Check, "tzgy3cTR")
+	require.Error(t, err)
 }
 
 func TestHashContent(t *testing.T) {
@@ -54,6 +68,11 @@ func TestHashContent(t *testing.T) {
 	require.Equal(t, "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824", res["sha256"])
 	require.Equal(t, "3610a686", res["crc32_ieee"])
 	require.Equal(t, "a430d84680aabd0b", res["fnv64a"])
+	require.Equal(t, "3338be694f50c5f338814986cdf0686453a888b84f424d792af4b9202398f392", res["sha3_256"])
+	require.Equal(t, "1c8aff950685c2ed4bc3174f3472287b56d9517b9c948127319a09a7a36deac8", res["keccak256"])
+	require.Equal(t, "324dcf027dd4a30a932c441f365a25e86b173defa4b8e58948253471b81b72cf", res["blake2b_256"])
+	require.Equal(t, "19213bacc58dee6dbde3ceb9a47cbb330b3d86f8cca8997eb00be456f140ca25", res["blake2s_256"])
+	require.Equal(t, "af1349b9f5f9a1a6a0404dea36dcc9499bcb25c9adc112b7cc9a93cae41f3262", HashContent("")["blake3"])
 }
 
 func TestURLEncodeDecode(t *testing.T) {
@@ -0,0 +1,94 @@
+package code
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+)
+
+const (
+	fernetVersion  = 0x80
+	fernetOverhead = 1 + 8 + aes.BlockSize + sha256.Size
+)
+
+// FernetInfo describes a Fernet token; Payload is only set when a key is given.
+type FernetInfo struct {
+	Version   string `json:"version"`
+	Timestamp string `json:"timestamp"`
+	Unix      int64  `json:"unix"`
+	IV        string `json:"iv"`
+	Length    int    `json:"ciphertextLength"`
+	Payload   string `json:"payload,omitempty"`
+	Verified  bool   `json:"verified"`
+}
+
+// FernetDecode parses a Fernet token and, when the url-safe base64 key is
+// supplied, checks its HMAC and decrypts the payload.
+func FernetDecode(token, key string) (FernetInfo, error) {
+	var info FernetInfo
+	raw, err := base64.URLEncoding.DecodeString(strings.TrimSpace(token))
+	if err != nil {
+		return info, errors.New("fernet token must be url-safe base64")
+	}
+	if len(raw) < fernetOverhead || (len(raw)-fernetOverhead)%aes.BlockSize != 0 {
+		return info, errors.New("invalid fernet token length")
+	}
+	if raw[0] != fernetVersion {
+		return info, errors.New("unsupported fernet version")
+	}
+	info.Version = "0x80"
+	info.Unix = int64(binary.BigEndian.Uint64(raw[1:9]))
+	info.Timestamp = time.Unix(info.Unix, 0).UTC().Format(time.RFC3339)
+	iv := raw[9 : 9+aes.BlockSize]
+	info.IV = hex.EncodeToString(iv)
+	body := raw[9+aes.BlockSize : len(raw)-sha256.Size]
+	info.Length = len(body)
+	if strings.TrimSpace(key) == "" {
+		return info, nil
+	}
+	k, err := base64.URLEncoding.DecodeString(strings.TrimSpace(key))
+	if err != nil || len(k) != 32 {
+		return info, errors.New("fernet key must be 32 bytes of url-safe base64")
+	}
+	mac := hmac.New(sha256.New, k[:16])
+	mac.Write(raw[:len(raw)-sha256.Size])
+	if !hmac.Equal(mac.Sum(nil), raw[len(raw)-sha256.Size:]) {
+		return info, errors.New("invalid fernet signature")
+	}
+	block, err := aes.NewCipher(k[16:])
+	if err != nil {
+		return info, err
+	}
+	plain := make([]byte, len(body))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(plain, body)
+	plain, err = pkcs7Unpad(plain)
+	if err != nil {
+		return info, err
+	}
+	info.Payload = prettyIfJSON(plain)
+	info.Verified = true
+	return info, nil
+}
+
+func pkcs7Unpad(data []byte) ([]byte, error) {
+	if len(data) == 0 {
+		return nil, errors.New("invalid padding")
+	}
+	pad := int(data[len(data)-1])
+	if pad == 0 || pad > aes.BlockSize || pad > len(data) {
+		return nil, errors.New("invalid padding")
+	}
+	for _, b := range data[len(data)-pad:] {
+		if int(b) != pad {
+			return nil, errors.New("invalid padding")
+		}
+	}
+	return data[:len(data)-pad], nil
+}
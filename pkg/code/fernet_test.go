@@ -0,0 +1,29 @@
+package code
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFernetDecode(t *testing.T) {
+	const token = "gAAAAAAdwJ6wAAECAwQFBgcICQoLDA0ODy021cpGVWKZ_eEwCGM4BLLF_5CV9dOPmrhuVUPgJobwOz7JcbmrR64jVmpU4IwqDA=="
+	const key = "cw_0x689RpI-jtRR7oE8h_eQsKImvJapLeSbXpwF4e4="
+
+	info, err := FernetDecode(token, "")
+	require.NoError(t, err)
+	require.Equal(t, "1985-10-26T08:20:00Z", info.Timestamp)
+	require.Equal(t, "000102030405060708090a0b0c0d0e0f", info.IV)
+	require.False(t, info.Verified)
+	require.Empty(t, info.Payload)
+
+	info, err = FernetDecode(token, key)
+	require.NoError(t, err)
+	require.True(t, info.Verified)
+	require.Equal(t, "hello", info.Payload)
+
+	_, err = FernetDecode(token, "AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA=")
+	require.Error(t, err)
+	_, err = FernetDecode("gAAA", "")
+	require.Error(t, err)
+}
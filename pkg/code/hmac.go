@@ -0,0 +1,75 @@
+package code
+
+import (
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"strings"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// hmacHashes maps the algorithm names accepted by HMACContent (the same keys
+// HashContent reports) to their hash constructors.
+var hmacHashes = map[string]func() hash.Hash{
+	"md5":        md5.New,
+	"sha1":       sha1.New,
+	"sha224":     sha256.New224,
+	"sha256":     sha256.New,
+	"sha384":     sha512.New384,
+	"sha512":     sha512.New,
+	"sha512_224": sha512.New512_224,
+	"sha512_256": sha512.New512_256,
+	"sha3_224":   sha3.New224,
+	"sha3_256":   sha3.New256,
+	"sha3_384":   sha3.New384,
+	"sha3_512":   sha3.New512,
+}
+
+// HMACResult is a keyed digest in the encodings webhook providers use.
+type HMACResult struct {
+	Algorithm string `json:"algorithm"`
+	Hex       string `json:"hex"`
+	Base64    string `json:"base64"`
+	Base64URL string `json:"base64url"`
+}
+
+// HMACContent computes the HMAC of input with key. algorithm defaults to
+// sha256 and also accepts names such as "SHA-256" or "HS512".
+func HMACContent(input, key, algorithm string) (HMACResult, error) {
+	name := normalizeHMACAlgorithm(algorithm)
+	newHash, ok := hmacHashes[name]
+	if !ok {
+		return HMACResult{}, fmt.Errorf("unsupported HMAC algorithm %q", algorithm)
+	}
+	mac := hmac.New(newHash, []byte(key))
+	_, _ = mac.Write([]byte(input))
+	sum := mac.Sum(nil)
+	return HMACResult{
+		Algorithm: name,
+		Hex:       hex.EncodeToString(sum),
+		Base64:    base64.StdEncoding.EncodeToString(sum),
+		Base64URL: base64.RawURLEncoding.EncodeToString(sum),
+	}, nil
+}
+
+func normalizeHMACAlgorithm(algorithm string) string {
+	name := strings.ToLower(strings.TrimSpace(algorithm))
+	name = strings.TrimPrefix(name, "hmac")
+	name = strings.Trim(strings.ReplaceAll(name, "/", "_"), "-_ ")
+	switch {
+	case name == "":
+		return "sha256"
+	case strings.HasPrefix(name, "hs"):
+		return "sha" + name[2:]
+	case strings.HasPrefix(name, "sha3"):
+		return "sha3_" + strings.Trim(name[4:], "-_")
+	}
+	return strings.ReplaceAll(name, "-", "")
+}
@@ -0,0 +1,32 @@
+package code
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHMACContent(t *testing.T) {
+	const msg = "The quick brown fox jumps over the lazy dog"
+	res, err := HMACContent(msg, "key", "")
+	require.NoError(t, err)
+	require.Equal(t, "sha256", res.Algorithm)
+	require.Equal(t, "f7bc83f430538424b13298e6aa6fb143ef4d59a14946175997479dbc2d1a3cd8", res.Hex)
+
+	for alg, want := range map[string]string{
+		"SHA-1":       "de7c9b85b8b78aa6bc8a7a36f70a90701c9db4d9",
+		"HMAC-SHA256": "f7bc83f430538424b13298e6aa6fb143ef4d59a14946175997479dbc2d1a3cd8",
+		"SHA3-256":    "8c6e0683409427f8931711b10ca92a506eb1fafa48fadd66d76126f47ac2c333",
+	} {
+		res, err := HMACContent(msg, "key", alg)
+		require.NoError(t, err, alg)
+		require.Equal(t, want, res.Hex, alg)
+	}
+
+	res, err = HMACContent(msg, "key", "HS512")
+	require.NoError(t, err)
+	require.Equal(t, "tCrwkFe6weLUFwjkipAuCbX/fxKrQopP6GZTxz3SSPuC+UilSfe3kaW0GRXuTR7Dk1NX5OIxclDQNyr6Lr7rOg==", res.Base64)
+
+	_, err = HMACContent(msg, "key", "crc32")
+	require.Error(t, err)
+}
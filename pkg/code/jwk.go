@@ -0,0 +1,294 @@
+package code
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// JWTVerification reports the outcome of verifying a token signature and
+// its exp/nbf/iat claims (RFC 3339, UTC).
+type JWTVerification struct {
+	Valid     bool   `json:"valid"`
+	Algorithm string `json:"algorithm"`
+	KeyID     string `json:"kid,omitempty"`
+	Header    string `json:"header"`
+	Payload   string `json:"payload"`
+	Reason    string `json:"reason,omitempty"`
+	ExpiresAt string `json:"exp,omitempty"`
+	NotBefore string `json:"nbf,omitempty"`
+	IssuedAt  string `json:"iat,omitempty"`
+}
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// JWTVerifyJWKS verifies an RS*, PS*, ES* or EdDSA token against a JSON Web
+// Key Set, selecting the key by the token's kid header, and validates the
+// exp, nbf and iat claims as JWTVerify does.
+func JWTVerifyJWKS(token, jwks string) (JWTVerification, error) {
+	return JWTVerifyJWKSWithLeeway(token, jwks, 0)
+}
+
+// JWTVerifyJWKSWithLeeway is JWTVerifyJWKS with a clock skew allowance
+// applied to the time-based claims.
+func JWTVerifyJWKSWithLeeway(token, jwks string, leeway time.Duration) (JWTVerification, error) {
+	var res JWTVerification
+	segments := strings.Split(strings.TrimSpace(token), ".")
+	if len(segments) != 3 {
+		return res, errors.New("invalid JWT token")
+	}
+	parts, err := JWTDecode(token)
+	if err != nil {
+		return res, err
+	}
+	res.Header = parts.Header
+	res.Payload = parts.Payload
+	res.Algorithm = parts.Algorithm
+	var header struct {
+		Kid string `json:"kid"`
+	}
+	headerJSON, _ := base64.RawURLEncoding.DecodeString(segments[0])
+	_ = json.Unmarshal(headerJSON, &header)
+	res.KeyID = header.Kid
+
+	keys, err := parseJWKS(jwks)
+	if err != nil {
+		return res, err
+	}
+	jwk, err := selectJWK(keys, header.Kid, parts.Algorithm)
+	if err != nil {
+		res.Reason = err.Error()
+		return res, nil
+	}
+	pub, err := jwk.publicKey()
+	if err != nil {
+		return res, err
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(segments[2])
+	if err != nil {
+		return res, fmt.Errorf("invalid signature: %w", err)
+	}
+	if err := verifyJWTSignature(parts.Algorithm, segments[0]+"."+segments[1], signature, pub); err != nil {
+		res.Reason = err.Error()
+		return res, nil
+	}
+	payloadJSON, _ := base64.RawURLEncoding.DecodeString(segments[1])
+	if reason := checkJWTClaims(&res, payloadJSON, time.Now(), leeway); reason != "" {
+		res.Reason = reason
+		return res, nil
+	}
+	res.Valid = true
+	return res, nil
+}
+
+func parseJWKS(input string) ([]jsonWebKey, error) {
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.Unmarshal([]byte(input), &set); err != nil {
+		return nil, fmt.Errorf("invalid JWKS: %w", err)
+	}
+	if len(set.Keys) == 0 {
+		var single jsonWebKey
+		if err := json.Unmarshal([]byte(input), &single); err == nil && single.Kty != "" {
+			return []jsonWebKey{single}, nil
+		}
+		return nil, errors.New("JWKS contains no keys")
+	}
+	return set.Keys, nil
+}
+
+func selectJWK(keys []jsonWebKey, kid, alg string) (jsonWebKey, error) {
+	wantKty := jwkTypeForAlg(alg)
+	for _, k := range keys {
+		if kid != "" && k.Kid != kid {
+			continue
+		}
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		if k.Alg != "" && k.Alg != alg {
+			continue
+		}
+		if wantKty != "" && k.Kty != wantKty {
+			continue
+		}
+		return k, nil
+	}
+	if kid != "" {
+		return jsonWebKey{}, fmt.Errorf("no key with kid %q for %s", kid, alg)
+	}
+	return jsonWebKey{}, fmt.Errorf("no key usable for %s", alg)
+}
+
+func jwkTypeForAlg(alg string) string {
+	switch {
+	case strings.HasPrefix(alg, "RS"), strings.HasPrefix(alg, "PS"):
+		return "RSA"
+	case strings.HasPrefix(alg, "ES"):
+		return "EC"
+	case alg == "EdDSA":
+		return "OKP"
+	}
+	return ""
+}
+
+func (k jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeJWKInt(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("invalid RSA modulus: %w", err)
+		}
+		e, err := decodeJWKInt(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("invalid RSA exponent: %w", err)
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %s", k.Crv)
+		}
+		x, err := decodeJWKInt(k.X)
+		if err != nil {
+			return nil, fmt.Errorf("invalid EC x: %w", err)
+		}
+		y, err := decodeJWKInt(k.Y)
+		if err != nil {
+			return nil, fmt.Errorf("invalid EC y: %w", err)
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %s", k.Crv)
+		}
+		raw, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil || len(raw) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 key")
+		}
+		return ed25519.PublicKey(raw), nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %s", k.Kty)
+	}
+}
+
+func decodeJWKInt(s string) (*big.Int, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(raw), nil
+}
+
+func jwtHash(alg string) (crypto.Hash, error) {
+	if alg == "EdDSA" {
+		return 0, nil
+	}
+	if len(alg) < 5 {
+		return 0, fmt.Errorf("unsupported algorithm %s", alg)
+	}
+	switch alg[2:] {
+	case "256":
+		return crypto.SHA256, nil
+	case "384":
+		return crypto.SHA384, nil
+	case "512":
+		return crypto.SHA512, nil
+	}
+	return 0, fmt.Errorf("unsupported algorithm %s", alg)
+}
+
+func hashSigningInput(h crypto.Hash, signingInput string) []byte {
+	switch h {
+	case crypto.SHA384:
+		sum := sha512.Sum384([]byte(signingInput))
+		return sum[:]
+	case crypto.SHA512:
+		sum := sha512.Sum512([]byte(signingInput))
+		return sum[:]
+	default:
+		sum := sha256.Sum256([]byte(signingInput))
+		return sum[:]
+	}
+}
+
+func verifyJWTSignature(alg, signingInput string, signature []byte, key crypto.PublicKey) error {
+	h, err := jwtHash(alg)
+	if err != nil {
+		return err
+	}
+	switch {
+	case strings.HasPrefix(alg, "RS"), strings.HasPrefix(alg, "PS"):
+		pub, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("%s requires an RSA key", alg)
+		}
+		digest := hashSigningInput(h, signingInput)
+		if strings.HasPrefix(alg, "PS") {
+			err = rsa.VerifyPSS(pub, h, digest, signature, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthAuto})
+		} else {
+			err = rsa.VerifyPKCS1v15(pub, h, digest, signature)
+		}
+		if err != nil {
+			return errors.New("signature mismatch")
+		}
+		return nil
+	case strings.HasPrefix(alg, "ES"):
+		pub, ok := key.(*ecdsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("%s requires an EC key", alg)
+		}
+		if want := ecCurveBits(alg); pub.Curve.Params().BitSize != want {
+			return fmt.Errorf("%s requires a P-%d key", alg, want)
+		}
+		size := (pub.Curve.Params().BitSize + 7) / 8
+		if len(signature) != 2*size {
+			return errors.New("invalid ECDSA signature length")
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		if !ecdsa.Verify(pub, hashSigningInput(h, signingInput), r, s) {
+			return errors.New("signature mismatch")
+		}
+		return nil
+	case alg == "EdDSA":
+		pub, ok := key.(ed25519.PublicKey)
+		if !ok {
+			return errors.New("EdDSA requires an Ed25519 key")
+		}
+		if !ed25519.Verify(pub, []byte(signingInput), signature) {
+			return errors.New("signature mismatch")
+		}
+		return nil
+	}
+	return fmt.Errorf("unsupported algorithm %s", alg)
+}
@@ -0,0 +1,101 @@
+package code
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func signTestToken(t *testing.T, header, payload string, sign func(string) []byte) string {
+	t.Helper()
+	input := base64.RawURLEncoding.EncodeToString([]byte(header)) + "." +
+		base64.RawURLEncoding.EncodeToString([]byte(payload))
+	return input + "." + base64.RawURLEncoding.EncodeToString(sign(input))
+}
+
+func TestJWTVerifyJWKS(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	edPub, edPriv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	b64 := base64.RawURLEncoding.EncodeToString
+	jwks, err := json.Marshal(map[string]any{"keys": []map[string]string{
+		{"kty": "RSA", "kid": "rsa1", "n": b64(rsaKey.N.Bytes()), "e": b64([]byte{1, 0, 1})},
+		{"kty": "EC", "kid": "ec1", "crv": "P-256", "x": b64(ecKey.X.FillBytes(make([]byte, 32))), "y": b64(ecKey.Y.FillBytes(make([]byte, 32)))},
+		{"kty": "OKP", "kid": "ed1", "crv": "Ed25519", "x": b64(edPub)},
+	}})
+	require.NoError(t, err)
+	payload := `{"sub":"42"}`
+
+	rsToken := signTestToken(t, `{"alg":"RS256","kid":"rsa1"}`, payload, func(in string) []byte {
+		sum := sha256.Sum256([]byte(in))
+		sig, err := rsa.SignPKCS1v15(rand.Reader, rsaKey, crypto.SHA256, sum[:])
+		require.NoError(t, err)
+		return sig
+	})
+	res, err := JWTVerifyJWKS(rsToken, string(jwks))
+	require.NoError(t, err)
+	require.True(t, res.Valid, res.Reason)
+	require.Equal(t, "rsa1", res.KeyID)
+	require.Contains(t, res.Payload, `"sub": "42"`)
+
+	esToken := signTestToken(t, `{"alg":"ES256","kid":"ec1"}`, payload, func(in string) []byte {
+		sum := sha256.Sum256([]byte(in))
+		r, s, err := ecdsa.Sign(rand.Reader, ecKey, sum[:])
+		require.NoError(t, err)
+		return append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	})
+	res, err = JWTVerifyJWKS(esToken, string(jwks))
+	require.NoError(t, err)
+	require.True(t, res.Valid, res.Reason)
+
+	edToken := signTestToken(t, `{"alg":"EdDSA","kid":"ed1"}`, payload, func(in string) []byte {
+		return ed25519.Sign(edPriv, []byte(in))
+	})
+	res, err = JWTVerifyJWKS(edToken, string(jwks))
+	require.NoError(t, err)
+	require.True(t, res.Valid, res.Reason)
+
+	tampered := edToken[:len(edToken)-4] + "AAAA"
+	res, err = JWTVerifyJWKS(tampered, string(jwks))
+	require.NoError(t, err)
+	require.False(t, res.Valid)
+
+	unknownKid := signTestToken(t, `{"alg":"EdDSA","kid":"nope"}`, payload, func(in string) []byte {
+		return ed25519.Sign(edPriv, []byte(in))
+	})
+	res, err = JWTVerifyJWKS(unknownKid, string(jwks))
+	require.NoError(t, err)
+	require.False(t, res.Valid)
+	require.Contains(t, res.Reason, "nope")
+
+	_, err = JWTVerifyJWKS(rsToken, `{"keys":[]}`)
+	require.Error(t, err)
+
+	expired := signTestToken(t, `{"alg":"EdDSA","kid":"ed1"}`, fmt.Sprintf(`{"exp":%d}`, time.Now().Unix()-30), func(in string) []byte {
+		return ed25519.Sign(edPriv, []byte(in))
+	})
+	res, err = JWTVerifyJWKS(expired, string(jwks))
+	require.NoError(t, err)
+	require.False(t, res.Valid)
+	require.Contains(t, res.Reason, "token expired")
+	require.NotEmpty(t, res.ExpiresAt)
+
+	res, err = JWTVerifyJWKSWithLeeway(expired, string(jwks), time.Minute)
+	require.NoError(t, err)
+	require.True(t, res.Valid, res.Reason)
+}
@@ -0,0 +1,141 @@
+package code
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/linzeyan/transform-go/pkg/common"
+)
+
+// pemOneLineRe matches a PEM block whose line breaks were lost, as happens
+// when a key is pasted into a single-line input.
+var pemOneLineRe = common.LazyRegexp(`^(-----BEGIN [A-Z0-9 ]+-----)\s*(.*?)\s*(-----END [A-Z0-9 ]+-----)$`)
+
+func decodePEM(input string) (*pem.Block, error) {
+	text := strings.TrimSpace(strings.ReplaceAll(input, `\n`, "\n"))
+	if !strings.Contains(text, "\n") {
+		if m := pemOneLineRe().FindStringSubmatch(text); m != nil {
+			text = m[1] + "\n" + strings.Join(strings.Fields(m[2]), "\n") + "\n" + m[3]
+		}
+	}
+	block, _ := pem.Decode([]byte(text))
+	if block == nil {
+		return nil, errors.New("key must be PEM encoded")
+	}
+	return block, nil
+}
+
+// parsePEMPrivateKey reads a PKCS#1, PKCS#8 or SEC 1 private key.
+func parsePEMPrivateKey(input string) (crypto.Signer, error) {
+	block, err := decodePEM(input)
+	if err != nil {
+		return nil, err
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParseECPrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("unsupported private key: %w", err)
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported private key type %T", key)
+	}
+	return signer, nil
+}
+
+// parsePEMPublicKey reads a PKIX or PKCS#1 public key or a certificate. A
+// private key is accepted too and its public half is used.
+func parsePEMPublicKey(input string) (crypto.PublicKey, error) {
+	block, err := decodePEM(input)
+	if err != nil {
+		return nil, err
+	}
+	switch block.Type {
+	case "CERTIFICATE":
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		return cert.PublicKey, nil
+	case "RSA PUBLIC KEY":
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	case "PUBLIC KEY":
+		return x509.ParsePKIXPublicKey(block.Bytes)
+	}
+	signer, err := parsePEMPrivateKey(input)
+	if err != nil {
+		return nil, err
+	}
+	return signer.Public(), nil
+}
+
+// signJWTWithKey signs an RS*, PS* or ES* token with a PEM private key.
+func signJWTWithKey(signingInput, pemKey, alg string) (string, error) {
+	h, err := jwtHash(alg)
+	if err != nil {
+		return "", err
+	}
+	key, err := parsePEMPrivateKey(pemKey)
+	if err != nil {
+		return "", err
+	}
+	digest := hashSigningInput(h, signingInput)
+	var signature []byte
+	switch {
+	case strings.HasPrefix(alg, "RS"), strings.HasPrefix(alg, "PS"):
+		priv, ok := key.(*rsa.PrivateKey)
+		if !ok {
+			return "", fmt.Errorf("%s requires an RSA key", alg)
+		}
+		if strings.HasPrefix(alg, "PS") {
+			signature, err = rsa.SignPSS(rand.Reader, priv, h, digest, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+		} else {
+			signature, err = rsa.SignPKCS1v15(rand.Reader, priv, h, digest)
+		}
+	case strings.HasPrefix(alg, "ES"):
+		priv, ok := key.(*ecdsa.PrivateKey)
+		if !ok {
+			return "", fmt.Errorf("%s requires an EC key", alg)
+		}
+		if want := ecCurveBits(alg); priv.Curve.Params().BitSize != want {
+			return "", fmt.Errorf("%s requires a P-%d key", alg, want)
+		}
+		r, s, signErr := ecdsa.Sign(rand.Reader, priv, digest)
+		if signErr != nil {
+			return "", signErr
+		}
+		size := (priv.Curve.Params().BitSize + 7) / 8
+		signature = make([]byte, 2*size)
+		r.FillBytes(signature[:size])
+		s.FillBytes(signature[size:])
+	default:
+		return "", fmt.Errorf("unsupported algorithm %s", alg)
+	}
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+func ecCurveBits(alg string) int {
+	switch alg {
+	case "ES384":
+		return 384
+	case "ES512":
+		return 521
+	}
+	return 256
+}
@@ -0,0 +1,135 @@
+package code
+
+import (
+	"crypto/hmac"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// JWTVerify checks a token signature and validates the exp, nbf and iat
+// claims. key is the HMAC secret for HS* and a PEM public key (or
+// certificate) for RS*, PS* and ES*. algorithm may be empty to accept the
+// token's own alg header; otherwise the header must match it. Either way
+// the key must suit the algorithm: an HS* token never verifies against a
+// PEM key, and RS*, PS* and ES* tokens need an RSA key or an EC key on the
+// matching curve.
+func JWTVerify(token, key, algorithm string) (JWTVerification, error) {
+	return JWTVerifyWithLeeway(token, key, algorithm, 0)
+}
+
+// JWTVerifyWithLeeway is JWTVerify with a clock skew allowance applied to
+// the time-based claims.
+func JWTVerifyWithLeeway(token, key, algorithm string, leeway time.Duration) (JWTVerification, error) {
+	var res JWTVerification
+	segments := strings.Split(strings.TrimSpace(token), ".")
+	if len(segments) != 3 {
+		return res, errors.New("invalid JWT token")
+	}
+	parts, err := JWTDecode(token)
+	if err != nil {
+		return res, err
+	}
+	res.Header = parts.Header
+	res.Payload = parts.Payload
+	res.Algorithm = parts.Algorithm
+
+	if algorithm != "" && algorithm != parts.Algorithm {
+		res.Reason = fmt.Sprintf("algorithm mismatch: token uses %s, expected %s", parts.Algorithm, algorithm)
+		return res, nil
+	}
+	if strings.TrimSpace(key) == "" {
+		return res, errors.New("key is required")
+	}
+	if reason, err := checkJWTSignature(segments, key, parts.Algorithm); err != nil {
+		return res, err
+	} else if reason != "" {
+		res.Reason = reason
+		return res, nil
+	}
+
+	payloadJSON, _ := base64.RawURLEncoding.DecodeString(segments[1])
+	if reason := checkJWTClaims(&res, payloadJSON, time.Now(), leeway); reason != "" {
+		res.Reason = reason
+		return res, nil
+	}
+	res.Valid = true
+	return res, nil
+}
+
+// checkJWTSignature returns why the signature does not verify, or "" when
+// it does. Malformed keys are reported as errors.
+func checkJWTSignature(segments []string, key, alg string) (string, error) {
+	signingInput := segments[0] + "." + segments[1]
+	if strings.HasPrefix(alg, "HS") {
+		// A token may not pick HMAC to turn a public key into its secret.
+		if _, err := parsePEMPublicKey(key); err == nil {
+			return fmt.Sprintf("%s requires an HMAC secret, not a PEM key", alg), nil
+		}
+		expected, err := signJWT(signingInput, key, alg)
+		if err != nil {
+			return err.Error(), nil
+		}
+		if !hmac.Equal([]byte(expected), []byte(segments[2])) {
+			return "signature mismatch", nil
+		}
+		return "", nil
+	}
+	if jwkTypeForAlg(alg) == "" {
+		return fmt.Sprintf("unsupported algorithm %s", alg), nil
+	}
+	pub, err := parsePEMPublicKey(key)
+	if err != nil {
+		return "", err
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(segments[2])
+	if err != nil {
+		return "", fmt.Errorf("invalid signature: %w", err)
+	}
+	if err := verifyJWTSignature(alg, signingInput, signature, pub); err != nil {
+		return err.Error(), nil
+	}
+	return "", nil
+}
+
+// checkJWTClaims records the registered time claims on res and returns why
+// they make the token invalid at now, or "" when they do not.
+func checkJWTClaims(res *JWTVerification, payload []byte, now time.Time, leeway time.Duration) string {
+	var claims map[string]any
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return "payload is not a JSON object"
+	}
+	times := map[string]time.Time{}
+	for _, name := range []string{"exp", "nbf", "iat"} {
+		raw, ok := claims[name]
+		if !ok {
+			continue
+		}
+		seconds, ok := raw.(float64)
+		if !ok {
+			return fmt.Sprintf("invalid %s claim", name)
+		}
+		times[name] = time.Unix(int64(seconds), 0).UTC()
+	}
+	format := func(name string) string {
+		if t, ok := times[name]; ok {
+			return t.Format(time.RFC3339)
+		}
+		return ""
+	}
+	res.ExpiresAt, res.NotBefore, res.IssuedAt = format("exp"), format("nbf"), format("iat")
+
+	if exp, ok := times["exp"]; ok && !now.Before(exp.Add(leeway)) {
+		return "token expired at " + res.ExpiresAt
+	}
+	if nbf, ok := times["nbf"]; ok && now.Add(leeway).Before(nbf) {
+		return "token not valid before " + res.NotBefore
+	}
+	if iat, ok := times["iat"]; ok && now.Add(leeway).Before(iat) {
+		return "token issued in the future at " + res.IssuedAt
+	}
+	return ""
+}
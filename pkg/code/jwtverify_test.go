@@ -0,0 +1,170 @@
+package code
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestJWTVerify(t *testing.T) {
+	now := time.Now().Unix()
+	token, err := JWTEncode(fmt.Sprintf(`{"sub":"1","iat":%d,"exp":%d}`, now, now+3600), "secret", "HS384")
+	require.NoError(t, err)
+
+	res, err := JWTVerify(token, "secret", "")
+	require.NoError(t, err)
+	require.True(t, res.Valid, res.Reason)
+	require.Equal(t, "HS384", res.Algorithm)
+	require.Equal(t, time.Unix(now+3600, 0).UTC().Format(time.RFC3339), res.ExpiresAt)
+
+	res, err = JWTVerify(token, "wrong", "HS384")
+	require.NoError(t, err)
+	require.False(t, res.Valid)
+	require.Equal(t, "signature mismatch", res.Reason)
+
+	res, err = JWTVerify(token, "secret", "HS256")
+	require.NoError(t, err)
+	require.False(t, res.Valid)
+	require.Contains(t, res.Reason, "algorithm mismatch")
+
+	_, err = JWTVerify("not-a-token", "secret", "")
+	require.Error(t, err)
+}
+
+func TestJWTVerifyClaims(t *testing.T) {
+	now := time.Now().Unix()
+	expired, err := JWTEncode(fmt.Sprintf(`{"exp":%d}`, now-30), "secret", "HS256")
+	require.NoError(t, err)
+	res, err := JWTVerify(expired, "secret", "")
+	require.NoError(t, err)
+	require.False(t, res.Valid)
+	require.Contains(t, res.Reason, "token expired")
+
+	res, err = JWTVerifyWithLeeway(expired, "secret", "", time.Minute)
+	require.NoError(t, err)
+	require.True(t, res.Valid, res.Reason)
+
+	notYet, err := JWTEncode(fmt.Sprintf(`{"nbf":%d}`, now+600), "secret", "HS256")
+	require.NoError(t, err)
+	res, err = JWTVerify(notYet, "secret", "")
+	require.NoError(t, err)
+	require.Contains(t, res.Reason, "not valid before")
+
+	badClaim, err := JWTEncode(`{"exp":"tomorrow"}`, "secret", "HS256")
+	require.NoError(t, err)
+	res, err = JWTVerify(badClaim, "secret", "")
+	require.NoError(t, err)
+	require.Equal(t, "invalid exp claim", res.Reason)
+}
+
+func TestJWTAsymmetricSignAndVerify(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ec256, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	ec384, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+
+	rsaPriv := pemEncode(t, "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaKey))
+	rsaPub := pemEncode(t, "PUBLIC KEY", mustMarshalPKIX(t, &rsaKey.PublicKey))
+	ec256DER, err := x509.MarshalECPrivateKey(ec256)
+	require.NoError(t, err)
+	ec384DER, err := x509.MarshalPKCS8PrivateKey(ec384)
+	require.NoError(t, err)
+
+	cases := []struct {
+		alg, priv, pub string
+	}{
+		{"RS256", rsaPriv, rsaPub},
+		{"RS512", rsaPriv, rsaPub},
+		{"PS256", rsaPriv, rsaPub},
+		{"ES256", pemEncode(t, "EC PRIVATE KEY", ec256DER), pemEncode(t, "PUBLIC KEY", mustMarshalPKIX(t, &ec256.PublicKey))},
+		{"ES384", pemEncode(t, "PRIVATE KEY", ec384DER), pemEncode(t, "PUBLIC KEY", mustMarshalPKIX(t, &ec384.PublicKey))},
+	}
+	for _, tc := range cases {
+		token, err := JWTEncode(`{"sub":"1"}`, tc.priv, tc.alg)
+		require.NoError(t, err, tc.alg)
+		res, err := JWTVerify(token, tc.pub, tc.alg)
+		require.NoError(t, err, tc.alg)
+		require.True(t, res.Valid, "%s: %s", tc.alg, res.Reason)
+
+		// A one-line paste of the public key still parses.
+		res, err = JWTVerify(token, strings.Join(strings.Fields(tc.pub), " "), "")
+		require.NoError(t, err, tc.alg)
+		require.True(t, res.Valid, "%s: %s", tc.alg, res.Reason)
+	}
+
+	token, err := JWTEncode(`{"sub":"1"}`, rsaPriv, "RS256")
+	require.NoError(t, err)
+	res, err := JWTVerify(token[:len(token)-4]+"AAAA", rsaPub, "")
+	require.NoError(t, err)
+	require.False(t, res.Valid)
+
+	_, err = JWTEncode(`{}`, rsaPriv, "ES256")
+	require.Error(t, err)
+	_, err = JWTEncode(`{}`, cases[3].priv, "ES384")
+	require.Error(t, err)
+	_, err = JWTVerify(token, "not a key", "")
+	require.Error(t, err)
+
+	// ES256 against a P-384 key fails on the key, not the signature.
+	es256, err := JWTEncode(`{"sub":"1"}`, cases[3].priv, "ES256")
+	require.NoError(t, err)
+	res, err = JWTVerify(es256, cases[4].pub, "")
+	require.NoError(t, err)
+	require.False(t, res.Valid)
+	require.Equal(t, "ES256 requires a P-256 key", res.Reason)
+}
+
+func TestJWTVerifyRejectsHMACWithPublicKey(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	rsaPub := pemEncode(t, "PUBLIC KEY", mustMarshalPKIX(t, &rsaKey.PublicKey))
+
+	// The attacker signs HS256 with the public key as the HMAC secret.
+	forged, err := JWTEncode(`{"sub":"admin"}`, rsaPub, "HS256")
+	require.NoError(t, err)
+	for _, alg := range []string{"", "HS256"} {
+		res, err := JWTVerify(forged, rsaPub, alg)
+		require.NoError(t, err)
+		require.False(t, res.Valid)
+		require.Equal(t, "HS256 requires an HMAC secret, not a PEM key", res.Reason)
+	}
+
+	cert := pemEncode(t, "CERTIFICATE", selfSignedCert(t, rsaKey))
+	forged, err = JWTEncode(`{"sub":"admin"}`, cert, "HS512")
+	require.NoError(t, err)
+	res, err := JWTVerify(forged, cert, "")
+	require.NoError(t, err)
+	require.False(t, res.Valid)
+}
+
+func selfSignedCert(t *testing.T, key *rsa.PrivateKey) []byte {
+	t.Helper()
+	tmpl := &x509.Certificate{SerialNumber: big.NewInt(1), NotAfter: time.Now().Add(time.Hour)}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	return der
+}
+
+func pemEncode(t *testing.T, typ string, der []byte) string {
+	t.Helper()
+	return string(pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: der}))
+}
+
+func mustMarshalPKIX(t *testing.T, pub any) []byte {
+	t.Helper()
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	require.NoError(t, err)
+	return der
+}
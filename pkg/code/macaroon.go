@@ -0,0 +1,277 @@
+package code
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// MacaroonCaveat is a single first- or third-party caveat.
+type MacaroonCaveat struct {
+	Identifier     string `json:"identifier"`
+	Location       string `json:"location,omitempty"`
+	VerificationID string `json:"verificationId,omitempty"`
+	ThirdParty     bool   `json:"thirdParty"`
+}
+
+// MacaroonInfo lists the contents of a serialized macaroon.
+type MacaroonInfo struct {
+	Version    int              `json:"version"`
+	Location   string           `json:"location,omitempty"`
+	Identifier string           `json:"identifier"`
+	Caveats    []MacaroonCaveat `json:"caveats"`
+	Signature  string           `json:"signature"`
+}
+
+const (
+	macaroonFieldEOS        = 0
+	macaroonFieldLocation   = 1
+	macaroonFieldIdentifier = 2
+	macaroonFieldVID        = 4
+	macaroonFieldSignature  = 6
+)
+
+// MacaroonDecode decodes a V1 or V2 (binary or JSON) macaroon and lists its caveats.
+func MacaroonDecode(input string) (MacaroonInfo, error) {
+	trimmed := strings.TrimSpace(input)
+	if strings.HasPrefix(trimmed, "{") {
+		return decodeMacaroonJSON([]byte(trimmed))
+	}
+	raw, err := decodeAnyBase64(trimmed)
+	if err != nil {
+		return MacaroonInfo{}, errors.New("macaroon must be base64 encoded")
+	}
+	if len(raw) == 0 {
+		return MacaroonInfo{}, errors.New("empty macaroon")
+	}
+	switch {
+	case raw[0] == 2:
+		return decodeMacaroonV2(raw[1:])
+	case raw[0] == '{':
+		return decodeMacaroonJSON(raw)
+	default:
+		return decodeMacaroonV1(raw)
+	}
+}
+
+func decodeAnyBase64(s string) ([]byte, error) {
+	s = strings.TrimRight(s, "=")
+	if strings.ContainsAny(s, "+/") {
+		return base64.RawStdEncoding.DecodeString(s)
+	}
+	return base64.RawURLEncoding.DecodeString(s)
+}
+
+func decodeMacaroonV1(raw []byte) (MacaroonInfo, error) {
+	info := MacaroonInfo{Version: 1, Caveats: []MacaroonCaveat{}}
+	for len(raw) > 0 {
+		if len(raw) < 4 {
+			return info, errors.New("truncated macaroon packet")
+		}
+		size, err := strconv.ParseUint(string(raw[:4]), 16, 16)
+		if err != nil || int(size) > len(raw) || size < 5 {
+			return info, errors.New("invalid macaroon packet header")
+		}
+		packet := raw[4:size]
+		raw = raw[size:]
+		packet = bytes.TrimSuffix(packet, []byte("\n"))
+		key, value, ok := bytes.Cut(packet, []byte(" "))
+		if !ok {
+			return info, fmt.Errorf("invalid macaroon packet %q", packet)
+		}
+		last := len(info.Caveats) - 1
+		switch string(key) {
+		case "location":
+			info.Location = string(value)
+		case "identifier":
+			info.Identifier = string(value)
+		case "cid":
+			info.Caveats = append(info.Caveats, MacaroonCaveat{Identifier: string(value)})
+		case "vid":
+			if last < 0 {
+				return info, errors.New("vid without caveat")
+			}
+			info.Caveats[last].VerificationID = base64.RawURLEncoding.EncodeToString(value)
+			info.Caveats[last].ThirdParty = true
+		case "cl":
+			if last < 0 {
+				return info, errors.New("cl without caveat")
+			}
+			info.Caveats[last].Location = string(value)
+		case "signature":
+			info.Signature = hex.EncodeToString(value)
+		default:
+			return info, fmt.Errorf("unknown macaroon field %s", key)
+		}
+	}
+	if info.Identifier == "" {
+		return info, errors.New("macaroon has no identifier")
+	}
+	return info, nil
+}
+
+type macaroonField struct {
+	kind uint64
+	data []byte
+}
+
+func readMacaroonSection(raw []byte) ([]macaroonField, []byte, error) {
+	var fields []macaroonField
+	for {
+		if len(raw) == 0 {
+			return nil, nil, errors.New("truncated macaroon")
+		}
+		kind, n := binary.Uvarint(raw)
+		if n <= 0 {
+			return nil, nil, errors.New("invalid macaroon field type")
+		}
+		raw = raw[n:]
+		if kind == macaroonFieldEOS {
+			return fields, raw, nil
+		}
+		size, n := binary.Uvarint(raw)
+		if n <= 0 || uint64(len(raw)-n) < size {
+			return nil, nil, errors.New("invalid macaroon field length")
+		}
+		fields = append(fields, macaroonField{kind: kind, data: raw[n : n+int(size)]})
+		raw = raw[n+int(size):]
+	}
+}
+
+func decodeMacaroonV2(raw []byte) (MacaroonInfo, error) {
+	info := MacaroonInfo{Version: 2, Caveats: []MacaroonCaveat{}}
+	header, rest, err := readMacaroonSection(raw)
+	if err != nil {
+		return info, err
+	}
+	for _, f := range header {
+		switch f.kind {
+		case macaroonFieldLocation:
+			info.Location = string(f.data)
+		case macaroonFieldIdentifier:
+			info.Identifier = string(f.data)
+		}
+	}
+	for {
+		section, next, err := readMacaroonSection(rest)
+		if err != nil {
+			return info, err
+		}
+		rest = next
+		if len(section) == 0 {
+			break
+		}
+		var caveat MacaroonCaveat
+		for _, f := range section {
+			switch f.kind {
+			case macaroonFieldLocation:
+				caveat.Location = string(f.data)
+			case macaroonFieldIdentifier:
+				caveat.Identifier = string(f.data)
+			case macaroonFieldVID:
+				caveat.VerificationID = base64.RawURLEncoding.EncodeToString(f.data)
+				caveat.ThirdParty = true
+			}
+		}
+		info.Caveats = append(info.Caveats, caveat)
+	}
+	kind, n := binary.Uvarint(rest)
+	if n <= 0 || kind != macaroonFieldSignature {
+		return info, errors.New("macaroon signature missing")
+	}
+	size, m := binary.Uvarint(rest[n:])
+	if m <= 0 || uint64(len(rest)-n-m) < size {
+		return info, errors.New("invalid macaroon signature")
+	}
+	info.Signature = hex.EncodeToString(rest[n+m : n+m+int(size)])
+	return info, nil
+}
+
+func decodeMacaroonJSON(raw []byte) (MacaroonInfo, error) {
+	var doc struct {
+		V   int    `json:"v"`
+		L   string `json:"l"`
+		I   string `json:"i"`
+		I64 string `json:"i64"`
+		S   string `json:"s"`
+		S64 string `json:"s64"`
+		C   []struct {
+			I   string `json:"i"`
+			I64 string `json:"i64"`
+			L   string `json:"l"`
+			V   string `json:"v"`
+			V64 string `json:"v64"`
+		} `json:"c"`
+		Location   string `json:"location"`
+		Identifier string `json:"identifier"`
+		Signature  string `json:"signature"`
+		Caveats    []struct {
+			CID string `json:"cid"`
+			VID string `json:"vid"`
+			CL  string `json:"cl"`
+		} `json:"caveats"`
+	}
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return MacaroonInfo{}, err
+	}
+	info := MacaroonInfo{Caveats: []MacaroonCaveat{}}
+	if doc.V == 2 {
+		info.Version = 2
+		info.Location = doc.L
+		info.Identifier = firstDecoded(doc.I, doc.I64)
+		sig, err := decodeAnyBase64(doc.S64)
+		if err == nil {
+			info.Signature = hex.EncodeToString(sig)
+		}
+		for _, c := range doc.C {
+			caveat := MacaroonCaveat{Identifier: firstDecoded(c.I, c.I64), Location: c.L}
+			if vid := firstNonEmptyString(c.V, c.V64); vid != "" {
+				caveat.VerificationID = vid
+				caveat.ThirdParty = true
+			}
+			info.Caveats = append(info.Caveats, caveat)
+		}
+		return info, nil
+	}
+	info.Version = 1
+	info.Location = doc.Location
+	info.Identifier = doc.Identifier
+	info.Signature = doc.Signature
+	for _, c := range doc.Caveats {
+		info.Caveats = append(info.Caveats, MacaroonCaveat{
+			Identifier:     c.CID,
+			Location:       c.CL,
+			VerificationID: c.VID,
+			ThirdParty:     c.VID != "",
+		})
+	}
+	if info.Identifier == "" {
+		return info, errors.New("macaroon has no identifier")
+	}
+	return info, nil
+}
+
+func firstDecoded(plain, b64 string) string {
+	if plain != "" {
+		return plain
+	}
+	if b, err := decodeAnyBase64(b64); err == nil {
+		return string(b)
+	}
+	return ""
+}
+
+func firstNonEmptyString(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,70 @@
+package code
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func macaroonV1Packet(key, value string) string {
+	body := key + " " + value + "\n"
+	return fmt.Sprintf("%04x", len(body)+4) + body
+}
+
+func macaroonV2Field(kind uint64, data string) []byte {
+	out := binary.AppendUvarint(nil, kind)
+	out = binary.AppendUvarint(out, uint64(len(data)))
+	return append(out, data...)
+}
+
+func TestMacaroonDecodeV1(t *testing.T) {
+	raw := macaroonV1Packet("location", "https://example.com") +
+		macaroonV1Packet("identifier", "key-1") +
+		macaroonV1Packet("cid", "account = 42") +
+		macaroonV1Packet("cid", "third-party") +
+		macaroonV1Packet("vid", "vvv") +
+		macaroonV1Packet("cl", "https://auth.example.com") +
+		macaroonV1Packet("signature", "\x01\x02")
+	info, err := MacaroonDecode(base64.URLEncoding.EncodeToString([]byte(raw)))
+	require.NoError(t, err)
+	require.Equal(t, 1, info.Version)
+	require.Equal(t, "key-1", info.Identifier)
+	require.Len(t, info.Caveats, 2)
+	require.Equal(t, "account = 42", info.Caveats[0].Identifier)
+	require.True(t, info.Caveats[1].ThirdParty)
+	require.Equal(t, "https://auth.example.com", info.Caveats[1].Location)
+	require.Equal(t, "0102", info.Signature)
+}
+
+func TestMacaroonDecodeV2(t *testing.T) {
+	raw := []byte{2}
+	raw = append(raw, macaroonV2Field(macaroonFieldLocation, "loc")...)
+	raw = append(raw, macaroonV2Field(macaroonFieldIdentifier, "root-id")...)
+	raw = append(raw, macaroonFieldEOS)
+	raw = append(raw, macaroonV2Field(macaroonFieldIdentifier, "time < 2030")...)
+	raw = append(raw, macaroonFieldEOS)
+	raw = append(raw, macaroonFieldEOS)
+	raw = append(raw, macaroonV2Field(macaroonFieldSignature, "\xaa\xbb")...)
+
+	info, err := MacaroonDecode(base64.RawURLEncoding.EncodeToString(raw))
+	require.NoError(t, err)
+	require.Equal(t, 2, info.Version)
+	require.Equal(t, "loc", info.Location)
+	require.Equal(t, "root-id", info.Identifier)
+	require.Equal(t, []MacaroonCaveat{{Identifier: "time < 2030"}}, info.Caveats)
+	require.Equal(t, "aabb", info.Signature)
+
+	_, err = MacaroonDecode(base64.RawURLEncoding.EncodeToString(raw[:10]))
+	require.Error(t, err)
+}
+
+func TestMacaroonDecodeJSON(t *testing.T) {
+	info, err := MacaroonDecode(`{"v":2,"l":"loc","i":"id","c":[{"i":"op = read"}],"s64":"qrs"}`)
+	require.NoError(t, err)
+	require.Equal(t, "id", info.Identifier)
+	require.Equal(t, "op = read", info.Caveats[0].Identifier)
+	require.NotEmpty(t, info.Signature)
+}
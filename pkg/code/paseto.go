@@ -0,0 +1,294 @@
+package code
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/blake2b"
+	"golang.org/x/crypto/chacha20"
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+// PASETOInfo describes a decoded PASETO token.
+type PASETOInfo struct {
+	Version  string `json:"version"`
+	Purpose  string `json:"purpose"`
+	Payload  string `json:"payload"`
+	Footer   string `json:"footer,omitempty"`
+	Verified bool   `json:"verified"`
+}
+
+// PASETOEncode builds a v2/v4 local (symmetric key) or public (Ed25519
+// private key) token. Keys are accepted as hex, base64 or raw bytes.
+func PASETOEncode(version, purpose, payload, key, footer string) (string, error) {
+	k, err := parseTokenKey(key)
+	if err != nil {
+		return "", err
+	}
+	header := version + "." + purpose + "."
+	var body []byte
+	switch header {
+	case "v2.local.":
+		nonce := make([]byte, chacha20poly1305.NonceSizeX)
+		if _, err := rand.Read(nonce); err != nil {
+			return "", err
+		}
+		body, err = pasetoV2Encrypt(k, nonce, []byte(payload), []byte(footer))
+	case "v4.local.":
+		nonce := make([]byte, 32)
+		if _, err := rand.Read(nonce); err != nil {
+			return "", err
+		}
+		body, err = pasetoV4Encrypt(k, nonce, []byte(payload), []byte(footer))
+	case "v2.public.", "v4.public.":
+		priv, perr := ed25519PrivateKey(k)
+		if perr != nil {
+			return "", perr
+		}
+		pieces := [][]byte{[]byte(header), []byte(payload), []byte(footer)}
+		if version == "v4" {
+			pieces = append(pieces, nil)
+		}
+		sig := ed25519.Sign(priv, pae(pieces...))
+		body = append([]byte(payload), sig...)
+	default:
+		return "", fmt.Errorf("unsupported PASETO type %s.%s", version, purpose)
+	}
+	if err != nil {
+		return "", err
+	}
+	token := header + base64.RawURLEncoding.EncodeToString(body)
+	if footer != "" {
+		token += "." + base64.RawURLEncoding.EncodeToString([]byte(footer))
+	}
+	return token, nil
+}
+
+// PASETODecode splits a token and, when key is given, verifies (public) or
+// decrypts (local) it. Public payloads are readable without a key.
+func PASETODecode(token, key string) (PASETOInfo, error) {
+	var info PASETOInfo
+	segments := strings.Split(strings.TrimSpace(token), ".")
+	if len(segments) < 3 || len(segments) > 4 {
+		return info, errors.New("invalid PASETO token")
+	}
+	info.Version, info.Purpose = segments[0], segments[1]
+	header := info.Version + "." + info.Purpose + "."
+	body, err := base64.RawURLEncoding.DecodeString(segments[2])
+	if err != nil {
+		return info, fmt.Errorf("invalid token body: %w", err)
+	}
+	var footer []byte
+	if len(segments) == 4 {
+		if footer, err = base64.RawURLEncoding.DecodeString(segments[3]); err != nil {
+			return info, fmt.Errorf("invalid footer: %w", err)
+		}
+		info.Footer = string(footer)
+	}
+	switch header {
+	case "v2.public.", "v4.public.":
+		if len(body) < ed25519.SignatureSize {
+			return info, errors.New("token body too short")
+		}
+		msg := body[:len(body)-ed25519.SignatureSize]
+		sig := body[len(body)-ed25519.SignatureSize:]
+		info.Payload = prettyIfJSON(msg)
+		if strings.TrimSpace(key) == "" {
+			return info, nil
+		}
+		k, err := parseTokenKey(key)
+		if err != nil {
+			return info, err
+		}
+		if len(k) == ed25519.PrivateKeySize {
+			k = k[32:]
+		}
+		if len(k) != ed25519.PublicKeySize {
+			return info, errors.New("public key must be 32 bytes")
+		}
+		pieces := [][]byte{[]byte(header), msg, footer}
+		if info.Version == "v4" {
+			pieces = append(pieces, nil)
+		}
+		if !ed25519.Verify(ed25519.PublicKey(k), pae(pieces...), sig) {
+			return info, errors.New("invalid token signature")
+		}
+		info.Verified = true
+		return info, nil
+	case "v2.local.", "v4.local.":
+		if strings.TrimSpace(key) == "" {
+			return info, errors.New("key required to decrypt local token")
+		}
+		k, err := parseTokenKey(key)
+		if err != nil {
+			return info, err
+		}
+		var plain []byte
+		if info.Version == "v2" {
+			plain, err = pasetoV2Decrypt(k, body, footer)
+		} else {
+			plain, err = pasetoV4Decrypt(k, body, footer)
+		}
+		if err != nil {
+			return info, err
+		}
+		info.Payload = prettyIfJSON(plain)
+		info.Verified = true
+		return info, nil
+	}
+	return info, fmt.Errorf("unsupported PASETO type %s.%s", info.Version, info.Purpose)
+}
+
+func pasetoV2Encrypt(key, random, payload, footer []byte) ([]byte, error) {
+	h, err := blake2b.New(chacha20poly1305.NonceSizeX, random)
+	if err != nil {
+		return nil, err
+	}
+	h.Write(payload)
+	nonce := h.Sum(nil)
+	aead, err := chacha20poly1305.NewX(key)
+	if err != nil {
+		return nil, err
+	}
+	ad := pae([]byte("v2.local."), nonce, footer)
+	return aead.Seal(nonce, nonce, payload, ad), nil
+}
+
+func pasetoV2Decrypt(key, body, footer []byte) ([]byte, error) {
+	if len(body) < chacha20poly1305.NonceSizeX+chacha20poly1305.Overhead {
+		return nil, errors.New("token body too short")
+	}
+	aead, err := chacha20poly1305.NewX(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := body[:chacha20poly1305.NonceSizeX]
+	ad := pae([]byte("v2.local."), nonce, footer)
+	plain, err := aead.Open(nil, nonce, body[chacha20poly1305.NonceSizeX:], ad)
+	if err != nil {
+		return nil, errors.New("unable to decrypt token")
+	}
+	return plain, nil
+}
+
+func pasetoV4Keys(key, nonce []byte) (ek, n2, ak []byte, err error) {
+	if len(key) != 32 {
+		return nil, nil, nil, errors.New("local key must be 32 bytes")
+	}
+	h, err := blake2b.New(56, key)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	h.Write([]byte("paseto-encryption-key"))
+	h.Write(nonce)
+	tmp := h.Sum(nil)
+	a, err := blake2b.New256(key)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	a.Write([]byte("paseto-auth-key-for-aead"))
+	a.Write(nonce)
+	return tmp[:32], tmp[32:], a.Sum(nil), nil
+}
+
+func pasetoV4Encrypt(key, nonce, payload, footer []byte) ([]byte, error) {
+	ek, n2, ak, err := pasetoV4Keys(key, nonce)
+	if err != nil {
+		return nil, err
+	}
+	stream, err := chacha20.NewUnauthenticatedCipher(ek, n2)
+	if err != nil {
+		return nil, err
+	}
+	ciphertext := make([]byte, len(payload))
+	stream.XORKeyStream(ciphertext, payload)
+	mac, err := blake2b.New256(ak)
+	if err != nil {
+		return nil, err
+	}
+	mac.Write(pae([]byte("v4.local."), nonce, ciphertext, footer, nil))
+	out := append(append([]byte{}, nonce...), ciphertext...)
+	return mac.Sum(out), nil
+}
+
+func pasetoV4Decrypt(key, body, footer []byte) ([]byte, error) {
+	if len(body) < 64 {
+		return nil, errors.New("token body too short")
+	}
+	nonce := body[:32]
+	ciphertext := body[32 : len(body)-32]
+	tag := body[len(body)-32:]
+	ek, n2, ak, err := pasetoV4Keys(key, nonce)
+	if err != nil {
+		return nil, err
+	}
+	mac, err := blake2b.New256(ak)
+	if err != nil {
+		return nil, err
+	}
+	mac.Write(pae([]byte("v4.local."), nonce, ciphertext, footer, nil))
+	if !hmac.Equal(mac.Sum(nil), tag) {
+		return nil, errors.New("invalid token authentication tag")
+	}
+	stream, err := chacha20.NewUnauthenticatedCipher(ek, n2)
+	if err != nil {
+		return nil, err
+	}
+	plain := make([]byte, len(ciphertext))
+	stream.XORKeyStream(plain, ciphertext)
+	return plain, nil
+}
+
+// pae is PASETO's pre-authentication encoding.
+func pae(pieces ...[]byte) []byte {
+	out := make([]byte, 8)
+	binary.LittleEndian.PutUint64(out, uint64(len(pieces)))
+	for _, p := range pieces {
+		var n [8]byte
+		binary.LittleEndian.PutUint64(n[:], uint64(len(p))&^(1<<63))
+		out = append(out, n[:]...)
+		out = append(out, p...)
+	}
+	return out
+}
+
+func ed25519PrivateKey(k []byte) (ed25519.PrivateKey, error) {
+	switch len(k) {
+	case ed25519.SeedSize:
+		return ed25519.NewKeyFromSeed(k), nil
+	case ed25519.PrivateKeySize:
+		return ed25519.PrivateKey(k), nil
+	}
+	return nil, errors.New("Ed25519 private key must be a 32-byte seed or 64-byte key")
+}
+
+// parseTokenKey accepts hex, base64 (standard or URL) or raw key material.
+func parseTokenKey(key string) ([]byte, error) {
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return nil, errors.New("key is required")
+	}
+	if b, err := hex.DecodeString(key); err == nil && len(b) >= 32 {
+		return b, nil
+	}
+	for _, enc := range []*base64.Encoding{base64.StdEncoding, base64.RawStdEncoding, base64.URLEncoding, base64.RawURLEncoding} {
+		if b, err := enc.DecodeString(key); err == nil && len(b) >= 32 {
+			return b, nil
+		}
+	}
+	return []byte(key), nil
+}
+
+func prettyIfJSON(data []byte) string {
+	if pretty, err := prettyJSON(data); err == nil {
+		return pretty
+	}
+	return string(data)
+}
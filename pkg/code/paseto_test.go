@@ -0,0 +1,79 @@
+package code
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	pasetoTestSecret = "b4cbfb43df4ce210727d953e4a713307fa19bb7d9f85041438d9e11b942a37741eb9dbbbbc047c03fd70604e0071f0987e16b28b757225c11f00415d0e20b1a2"
+	pasetoTestPublic = "1eb9dbbbbc047c03fd70604e0071f0987e16b28b757225c11f00415d0e20b1a2"
+	pasetoTestLocal  = "707172737475767778797a7b7c7d7e7f808182838485868788898a8b8c8d8e8f"
+)
+
+func TestPASETOPublicVector(t *testing.T) {
+	payload := `{"data":"this is a signed message","exp":"2022-01-01T00:00:00+00:00"}`
+	token, err := PASETOEncode("v4", "public", payload, pasetoTestSecret, "")
+	require.NoError(t, err)
+	require.Equal(t, "v4.public.eyJkYXRhIjoidGhpcyBpcyBhIHNpZ25lZCBtZXNzYWdlIiwiZXhwIjoiMjAyMi0wMS0wMVQwMDowMDowMCswMDowMCJ9bg_XBBzds8lTZShVlwwKSgeKpLT3yukTw6JUz3W4h_ExsQV-P0V54zemZDcAxFaSeef1QlXEFtkqxT1ciiQEDA", token)
+
+	info, err := PASETODecode(token, pasetoTestPublic)
+	require.NoError(t, err)
+	require.True(t, info.Verified)
+	require.Contains(t, info.Payload, "this is a signed message")
+
+	unverified, err := PASETODecode(token, "")
+	require.NoError(t, err)
+	require.False(t, unverified.Verified)
+
+	_, err = PASETODecode(token[:len(token)-2]+"AA", pasetoTestPublic)
+	require.Error(t, err)
+}
+
+func TestPASETOLocalVector(t *testing.T) {
+	key, _ := hex.DecodeString(pasetoTestLocal)
+	payload := `{"data":"this is a secret message","exp":"2022-01-01T00:00:00+00:00"}`
+	body, err := pasetoV4Encrypt(key, make([]byte, 32), []byte(payload), nil)
+	require.NoError(t, err)
+	token := "v4.local." + encodeRawURL(body)
+	require.Equal(t, "v4.local.AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAQAr68PS4AXe7If_ZgesdkUMvSwscFlAl1pk5HC0e8kApeaqMfGo_7OpBnwJOAbY9V7WU6abu74MmcUE8YWAiaArVI8XJ5hOb_4v9RmDkneN0S92dx0OW4pgy7omxgf3S8c3LlQg", token)
+}
+
+func TestPASETORoundTrip(t *testing.T) {
+	for _, version := range []string{"v2", "v4"} {
+		token, err := PASETOEncode(version, "local", `{"sub":"a"}`, pasetoTestLocal, "kid-1")
+		require.NoError(t, err)
+		info, err := PASETODecode(token, pasetoTestLocal)
+		require.NoError(t, err, version)
+		require.True(t, info.Verified)
+		require.Equal(t, "kid-1", info.Footer)
+		require.Contains(t, info.Payload, `"sub": "a"`)
+
+		_, err = PASETODecode(token, "")
+		require.Error(t, err)
+
+		pub, err := PASETOEncode(version, "public", "hello", pasetoTestSecret, "")
+		require.NoError(t, err)
+		info, err = PASETODecode(pub, pasetoTestPublic)
+		require.NoError(t, err, version)
+		require.Equal(t, "hello", info.Payload)
+	}
+	_, err := PASETOEncode("v3", "local", "x", pasetoTestLocal, "")
+	require.Error(t, err)
+
+	// Local keys are 32 bytes, as for v2, however they are written.
+	token, err := PASETOEncode("v4", "local", "x", pasetoTestLocal, "")
+	require.NoError(t, err)
+	for _, key := range []string{"short", pasetoTestLocal + "00", pasetoTestLocal[:62]} {
+		_, err = PASETOEncode("v4", "local", "x", key, "")
+		require.EqualError(t, err, "local key must be 32 bytes", key)
+		_, err = PASETODecode(token, key)
+		require.EqualError(t, err, "local key must be 32 bytes", key)
+	}
+}
+
+func encodeRawURL(b []byte) string {
+	return base64RawURL.EncodeToString(b)
+}
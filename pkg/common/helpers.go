@@ -6,8 +6,12 @@ import (
 	"fmt"
 	"go/ast"
 	"reflect"
+	"regexp"
+	"strconv"
 	"strings"
+	"sync"
 	"unicode"
+	"unicode/utf8"
 
 	"gopkg.in/yaml.v3"
 )
@@ -20,6 +24,8 @@ func LooksInteger(num json.Number) bool {
 	return err == nil
 }
 
+// NormalizeJSONNumbers replaces the json.Number values in v with
+// NumberValue, recursively.
 func NormalizeJSONNumbers(v any) any {
 	switch val := v.(type) {
 	case map[string]any:
@@ -34,16 +40,100 @@ func NormalizeJSONNumbers(v any) any {
 		}
 		return val
 	case json.Number:
-		if i, err := val.Int64(); err == nil {
+		return NumberValue(val)
+	default:
+		return v
+	}
+}
+
+// NumberValue returns num as an int64 or uint64 when it is an integer in
+// their range, as a float64 when that has the value num is written with,
+// and otherwise as num itself, so that 64-bit IDs and long decimals keep
+// their digits: encoders write a json.Number as its literal.
+func NumberValue(num json.Number) any {
+	s := num.String()
+	if !strings.ContainsAny(s, ".eE") {
+		if i, err := strconv.ParseInt(s, 10, 64); err == nil {
 			return i
 		}
-		if f, err := val.Float64(); err == nil {
-			return f
+		if u, err := strconv.ParseUint(s, 10, 64); err == nil {
+			return u
 		}
-		return val.String()
-	default:
-		return v
+		return num
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil || !sameDecimal(s, strconv.FormatFloat(f, 'g', -1, 64)) {
+		return num
+	}
+	return f
+}
+
+// ExactNumber reports whether NumberValue holds num in a Go number type.
+func ExactNumber(num json.Number) bool {
+	_, ok := NumberValue(num).(json.Number)
+	return !ok
+}
+
+// sameDecimal reports whether two decimal literals have the same value.
+func sameDecimal(a, b string) bool {
+	an, ad, ae, aok := decimalParts(a)
+	bn, bd, be, bok := decimalParts(b)
+	return aok && bok && an == bn && ad == bd && ae == be
+}
+
+// decimalParts splits a decimal literal into its sign, its significant
+// digits and the power of ten they are scaled by; zero is "0" scaled by 1.
+func decimalParts(s string) (neg bool, digits string, exp int, ok bool) {
+	s, neg = strings.CutPrefix(s, "-")
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		var err error
+		if exp, err = strconv.Atoi(strings.TrimPrefix(s[i+1:], "+")); err != nil {
+			return false, "", 0, false
+		}
+		s = s[:i]
+	}
+	whole, frac, _ := strings.Cut(s, ".")
+	digits = strings.TrimLeft(whole+frac, "0")
+	exp -= len(frac)
+	trimmed := strings.TrimRight(digits, "0")
+	exp += len(digits) - len(trimmed)
+	if trimmed == "" {
+		return neg, "0", 0, true
+	}
+	return neg, trimmed, exp, true
+}
+
+// YAMLNumberNode returns the YAML scalar for num written as it is, tagged
+// as an integer or a float. yaml.v3 writes the tag only when the literal
+// would not read back as that type, such as for an integer beyond 64 bits.
+func YAMLNumberNode(num json.Number) *yaml.Node {
+	tag := "!!int"
+	if strings.ContainsAny(num.String(), ".eE") {
+		tag = "!!float"
 	}
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: num.String()}
+}
+
+// yamlNumbers replaces json.Number values with YAMLNumberNode, which
+// yaml.v3 would otherwise write as strings.
+func yamlNumbers(v any) any {
+	switch val := v.(type) {
+	case map[string]any:
+		res := make(map[string]any, len(val))
+		for k, vv := range val {
+			res[k] = yamlNumbers(vv)
+		}
+		return res
+	case []any:
+		res := make([]any, len(val))
+		for i, item := range val {
+			res[i] = yamlNumbers(item)
+		}
+		return res
+	case json.Number:
+		return YAMLNumberNode(val)
+	}
+	return v
 }
 
 func NormalizeYAML(v any) any {
@@ -72,10 +162,15 @@ func NormalizeYAML(v any) any {
 }
 
 func EncodeYAML(data any) (string, error) {
+	return EncodeYAMLIndent(data, 2)
+}
+
+// EncodeYAMLIndent encodes data as YAML with the given indent width.
+func EncodeYAMLIndent(data any, indent int) (string, error) {
 	buf := &bytes.Buffer{}
 	enc := yaml.NewEncoder(buf)
-	enc.SetIndent(2)
-	if err := enc.Encode(data); err != nil {
+	enc.SetIndent(indent)
+	if err := enc.Encode(yamlNumbers(data)); err != nil {
 		_ = enc.Close()
 		return "", err
 	}
@@ -103,25 +198,25 @@ func JSONFieldName(field *ast.Field) string {
 }
 
 func ExportName(key string) string {
-	var runes []rune
+	var buf strings.Builder
+	buf.Grow(len(key))
 	capNext := true
 	for _, r := range key {
-		if unicode.IsLetter(r) || unicode.IsDigit(r) {
-			if capNext {
-				runes = append(runes, unicode.ToUpper(r))
-				capNext = false
-			} else {
-				runes = append(runes, r)
-			}
-		} else {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
 			capNext = true
+			continue
 		}
+		if capNext {
+			r = unicode.ToUpper(r)
+			capNext = false
+		}
+		// Leading digits are dropped, as an identifier cannot start with one.
+		if buf.Len() == 0 && !unicode.IsLetter(r) {
+			continue
+		}
+		buf.WriteRune(r)
 	}
-	out := string(runes)
-	out = strings.TrimLeftFunc(out, func(r rune) bool {
-		return !unicode.IsLetter(r) && r != '_'
-	})
-	return out
+	return buf.String()
 }
 
 func LowerFirst(s string) string {
@@ -133,6 +228,7 @@ func LowerFirst(s string) string {
 		return strings.ToLower(s)
 	}
 	var buf strings.Builder
+	buf.Grow(len(s))
 	buf.WriteString(strings.ToLower(words[0]))
 	for _, word := range words[1:] {
 		if word == "" {
@@ -142,52 +238,48 @@ func LowerFirst(s string) string {
 			buf.WriteString(word)
 			continue
 		}
-		runes := []rune(strings.ToLower(word))
-		runes[0] = unicode.ToUpper(runes[0])
-		buf.WriteString(string(runes))
+		lower := strings.ToLower(word)
+		first, size := utf8.DecodeRuneInString(lower)
+		buf.WriteRune(unicode.ToUpper(first))
+		buf.WriteString(lower[size:])
 	}
 	return buf.String()
 }
 
+// SplitWords splits s at case and digit boundaries. The words are
+// substrings of s, so splitting allocates only the result slice.
 func SplitWords(s string) []string {
 	if s == "" {
 		return nil
 	}
-	runes := []rune(s)
+	if !utf8.ValidString(s) {
+		// Invalid bytes read as U+FFFD, one per byte.
+		s = string([]rune(s))
+	}
 	var parts []string
-	current := []rune{runes[0]}
-	for i := 1; i < len(runes); i++ {
-		r := runes[i]
-		prev := runes[i-1]
-		nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+	start := 0
+	prev, size := utf8.DecodeRuneInString(s)
+	for i := size; i < len(s); i += size {
+		var r rune
+		r, size = utf8.DecodeRuneInString(s[i:])
+		next, _ := utf8.DecodeRuneInString(s[i+size:])
+		nextLower := i+size < len(s) && unicode.IsLower(next)
+		var split bool
 		switch {
 		case unicode.IsUpper(r):
-			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
-				parts = append(parts, string(current))
-				current = []rune{r}
-			} else {
-				current = append(current, r)
-			}
+			split = unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower)
 		case unicode.IsDigit(r):
-			if unicode.IsDigit(prev) || unicode.IsUpper(prev) {
-				current = append(current, r)
-			} else {
-				parts = append(parts, string(current))
-				current = []rune{r}
-			}
+			split = !unicode.IsDigit(prev) && !unicode.IsUpper(prev)
 		default:
-			if unicode.IsDigit(prev) {
-				parts = append(parts, string(current))
-				current = []rune{r}
-			} else {
-				current = append(current, r)
-			}
+			split = unicode.IsDigit(prev)
 		}
+		if split {
+			parts = append(parts, s[start:i])
+			start = i
+		}
+		prev = r
 	}
-	if len(current) > 0 {
-		parts = append(parts, string(current))
-	}
-	return parts
+	return append(parts, s[start:])
 }
 
 func IsAllUpper(s string) bool {
@@ -220,3 +312,11 @@ func FindMatchingBrace(src string, openIdx int) int {
 	}
 	return -1
 }
+
+// LazyRegexp returns a function that compiles expr on its first call and
+// returns the same *regexp.Regexp afterwards, so a package's patterns cost
+// nothing at startup. Like regexp.MustCompile it panics on an invalid
+// expression, on that first call.
+func LazyRegexp(expr string) func() *regexp.Regexp {
+	return sync.OnceValue(func() *regexp.Regexp { return regexp.MustCompile(expr) })
+}
@@ -1,12 +1,35 @@
 package common
 
 import (
+	"encoding/json"
+	"math"
 	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/require"
 )
 
+func TestNumberValue(t *testing.T) {
+	for lit, want := range map[string]any{
+		"42":                        int64(42),
+		"-9223372036854775808":      int64(-9223372036854775808),
+		"12345678901234567890":      uint64(12345678901234567890),
+		"-9223372036854775809":      json.Number("-9223372036854775809"),
+		"18446744073709551616":      json.Number("18446744073709551616"),
+		"0.1":                       0.1,
+		"1.50":                      1.5,
+		"1e2":                       100.0,
+		"-0.0":                      math.Copysign(0, -1),
+		"9007199254740993.0":        json.Number("9007199254740993.0"),
+		"0.10000000000000000000001": json.Number("0.10000000000000000000001"),
+		"1e400":                     json.Number("1e400"),
+	} {
+		require.Equal(t, want, NumberValue(json.Number(lit)), lit)
+	}
+	require.True(t, ExactNumber("2.5e-3"))
+	require.False(t, ExactNumber("2.50000000000000000001e-3"))
+}
+
 func TestExportName(t *testing.T) {
 	require.Equal(t, "UserName", ExportName("user_name"))
 	require.Equal(t, "HTTPServerV2", ExportName("HTTP_server_v2"))
@@ -117,3 +140,12 @@ func FuzzFindMatchingBrace(f *testing.F) {
 		_ = FindMatchingBrace(s, idx)
 	})
 }
+
+func TestLazyRegexp(t *testing.T) {
+	re := LazyRegexp(`^a+$`)
+	require.Same(t, re(), re())
+	require.True(t, re().MatchString("aaa"))
+
+	bad := LazyRegexp(`(`)
+	require.Panics(t, func() { bad() })
+}
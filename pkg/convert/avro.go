@@ -0,0 +1,312 @@
+package convert
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/linzeyan/transform-go/pkg/common"
+)
+
+const formatAvro = "Avro Schema"
+
+var (
+	avroNameRe    = common.LazyRegexp(`[^A-Za-z0-9_]`)
+	avroRecordRe  = common.LazyRegexp(`"type"\s*:\s*"record"`)
+	avroFieldsRe  = common.LazyRegexp(`"fields"\s*:\s*\[`)
+	avroPrimitive = map[string]any{
+		"null": nil, "boolean": false, "int": 0, "long": 0,
+		"float": 0.0, "double": 0.0, "bytes": "", "string": "",
+	}
+)
+
+// avroRecord and the types below keep the attribute order of hand-written
+// .avsc files, which a map would sort.
+type avroRecord struct {
+	Type   string      `json:"type"`
+	Name   string      `json:"name"`
+	Fields []avroField `json:"fields"`
+}
+
+type avroField struct {
+	Name string `json:"name"`
+	Type any    `json:"type"`
+}
+
+type avroArray struct {
+	Type  string `json:"type"`
+	Items any    `json:"items"`
+}
+
+func JSONToAvro(input string) (string, error) {
+	return JSONToAvroWithOptions(input)
+}
+
+// JSONToAvroWithOptions infers an Avro schema from a JSON sample. Objects
+// become records named after their key, integers long and other numbers
+// double; arrays take the type of their first non-null element, as a
+// ["null", T] union when they also hold nulls. Keys that are not valid Avro
+// names are rewritten with underscores.
+func JSONToAvroWithOptions(input string, opts ...ConvertOption) (string, error) {
+	o := NewConvertOptions(opts...)
+	doc, err := parseJSONDoc(input)
+	if err != nil {
+		return "", err
+	}
+	b := avroBuilder{opts: o, names: map[string]bool{}}
+	formatted, err := json.MarshalIndent(b.typeOf("AutoGenerated", doc), "", o.indentString())
+	if err != nil {
+		return "", err
+	}
+	return string(formatted), nil
+}
+
+type avroBuilder struct {
+	opts  ConvertOptions
+	names map[string]bool
+}
+
+func (b *avroBuilder) typeOf(name string, n *docNode) any {
+	switch n.kind {
+	case docObject:
+		return b.record(name, n)
+	case docArray:
+		var sample *docNode
+		nullable := false
+		for _, item := range n.items {
+			if item.kind == docNull {
+				nullable = true
+			} else if sample == nil {
+				sample = item
+			}
+		}
+		if sample == nil {
+			return avroArray{Type: "array", Items: "null"}
+		}
+		items := b.typeOf(name+"Item", sample)
+		if nullable {
+			items = []any{"null", items}
+		}
+		return avroArray{Type: "array", Items: items}
+	case docNumber:
+		if common.LooksInteger(json.Number(n.text)) {
+			return "long"
+		}
+		return "double"
+	case docString:
+		return "string"
+	case docBool:
+		return "boolean"
+	}
+	return "null"
+}
+
+// record names must be unique within a schema, so a repeated name gets a
+// numeric suffix.
+func (b *avroBuilder) record(name string, n *docNode) avroRecord {
+	name = sanitizeTypeName(name)
+	unique := name
+	for i := 2; b.names[unique]; i++ {
+		unique = name + strconv.Itoa(i)
+	}
+	b.names[unique] = true
+	rec := avroRecord{Type: "record", Name: unique, Fields: []avroField{}}
+
+	order := make([]int, len(n.keys))
+	for i := range order {
+		order[i] = i
+	}
+	if b.opts.SortKeys {
+		sort.SliceStable(order, func(i, j int) bool { return n.keys[order[i]].name < n.keys[order[j]].name })
+	}
+	seen := map[string]bool{}
+	for _, i := range order {
+		key := n.keys[i].name
+		field := avroFieldName(key)
+		for j := 2; seen[field]; j++ {
+			field = avroFieldName(key) + "_" + strconv.Itoa(j)
+		}
+		seen[field] = true
+		rec.Fields = append(rec.Fields, avroField{Name: field, Type: b.typeOf(key, n.items[i])})
+	}
+	return rec
+}
+
+// avroFieldName keeps letters, digits and underscores and never starts
+// with a digit, as the Avro name grammar requires.
+func avroFieldName(key string) string {
+	name := avroNameRe().ReplaceAllString(key, "_")
+	if name == "" || name[0] >= '0' && name[0] <= '9' {
+		name = "_" + name
+	}
+	return name
+}
+
+// AvroToJSON generates a sample document from an Avro schema (.avsc).
+// Fields take their default when one is given; enums take their first
+// symbol, unions their first non-null branch, arrays and maps one element
+// and recursive records stop at null.
+func AvroToJSON(input string) (string, error) {
+	schema, err := decodeJSONValue(input)
+	if err != nil {
+		return "", err
+	}
+	s := avroSchema{named: map[string]any{}, seen: map[string]int{}}
+	sample, err := s.sample(schema, "")
+	if err != nil {
+		return "", err
+	}
+	return encodeDoc(sample)
+}
+
+// avroDefine records the full and short names of t in named when t is a
+// record, enum or fixed type, and returns the namespace its own type names
+// resolve in.
+func avroDefine(named map[string]any, t map[string]any, namespace string) (string, error) {
+	kind, _ := t["type"].(string)
+	switch kind {
+	case "record", "error", "enum", "fixed":
+	default:
+		return namespace, nil
+	}
+	name, _ := t["name"].(string)
+	if name == "" {
+		return "", fmt.Errorf("avro: %s without a name", kind)
+	}
+	if ns, ok := t["namespace"].(string); ok {
+		namespace = ns
+	}
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		namespace = name[:i]
+	}
+	named[name] = t
+	named[name[strings.LastIndex(name, ".")+1:]] = t
+	if namespace != "" && !strings.Contains(name, ".") {
+		named[namespace+"."+name] = t
+	}
+	return namespace, nil
+}
+
+// avroLookup finds a named type defined earlier in the schema.
+func avroLookup(named map[string]any, name, namespace string) (any, error) {
+	if def, ok := named[name]; ok {
+		return def, nil
+	}
+	if def, ok := named[namespace+"."+name]; ok {
+		return def, nil
+	}
+	return nil, fmt.Errorf("avro: unknown type %q", name)
+}
+
+type avroSchema struct {
+	// named maps the full and short names of records, enums and fixed
+	// types to their definitions.
+	named map[string]any
+	seen  map[string]int
+	b     docBuilder
+}
+
+func (s *avroSchema) sample(schema any, namespace string) (*docNode, error) {
+	switch t := schema.(type) {
+	case string:
+		if v, ok := avroPrimitive[t]; ok {
+			return s.b.sample(v), nil
+		}
+		def, err := avroLookup(s.named, t, namespace)
+		if err != nil {
+			return nil, err
+		}
+		return s.sample(def, namespace)
+	case []any:
+		// A union samples as its first non-null branch.
+		for _, branch := range t {
+			if branch != "null" {
+				return s.sample(branch, namespace)
+			}
+		}
+		return s.b.null(), nil
+	case map[string]any:
+		return s.complex(t, namespace)
+	}
+	return nil, errors.New("avro: a schema is a type name, a union or an object")
+}
+
+func (s *avroSchema) complex(t map[string]any, namespace string) (*docNode, error) {
+	kind, _ := t["type"].(string)
+	namespace, err := avroDefine(s.named, t, namespace)
+	if err != nil {
+		return nil, err
+	}
+	switch kind {
+	case "record", "error":
+		name := t["name"].(string)
+		if s.seen[name] > 0 {
+			return s.b.null(), nil
+		}
+		s.seen[name]++
+		defer func() { s.seen[name]-- }()
+		obj := s.b.object()
+		fields, _ := t["fields"].([]any)
+		for _, f := range fields {
+			field, ok := f.(map[string]any)
+			if !ok {
+				return nil, errors.New("avro: a record field must be an object")
+			}
+			fieldName, _ := field["name"].(string)
+			if def, ok := field["default"]; ok {
+				v, err := s.b.value(common.NormalizeJSONNumbers(def))
+				if err != nil {
+					return nil, err
+				}
+				s.b.set(obj, fieldName, v)
+				continue
+			}
+			v, err := s.sample(field["type"], namespace)
+			if err != nil {
+				return nil, err
+			}
+			s.b.set(obj, fieldName, v)
+		}
+		return obj, nil
+	case "enum":
+		if def, ok := t["default"]; ok {
+			return s.b.value(def)
+		}
+		if symbols, ok := t["symbols"].([]any); ok && len(symbols) > 0 {
+			return s.b.value(symbols[0])
+		}
+		return s.b.str(""), nil
+	case "fixed":
+		return s.b.str(""), nil
+	case "array":
+		if t["items"] == "null" {
+			return s.b.array(), nil
+		}
+		item, err := s.sample(t["items"], namespace)
+		if err != nil {
+			return nil, err
+		}
+		return s.b.array(item), nil
+	case "map":
+		value, err := s.sample(t["values"], namespace)
+		if err != nil {
+			return nil, err
+		}
+		obj := s.b.object()
+		s.b.set(obj, "key", value)
+		return obj, nil
+	}
+	if kind == "" {
+		// {"type": {...}} wraps another schema.
+		if inner, ok := t["type"]; ok {
+			return s.sample(inner, namespace)
+		}
+		return nil, errors.New("avro: schema object without a type")
+	}
+	// Primitives may carry a logicalType; the sample keeps the underlying
+	// type, as Avro's JSON encoding does.
+	return s.sample(kind, namespace)
+}
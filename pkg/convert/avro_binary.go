@@ -0,0 +1,247 @@
+package convert
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// avroMaxBlock bounds the element count a single array or map block may
+// claim, so that a corrupt count fails instead of allocating gigabytes.
+const avroMaxBlock = 1 << 20
+
+var errAvroTruncated = errors.New("avro: unexpected end of data")
+
+// DecodeAvro decodes a value written in Avro's binary encoding with the
+// given schema (.avsc) and returns it as JSON. A union decodes to the value
+// of its branch, without the {"type": value} wrapper of Avro's own JSON
+// encoding; bytes and fixed values decode to base64 and logical types to
+// their underlying type.
+func DecodeAvro(schema string, data []byte) (string, error) {
+	s, err := decodeJSONValue(schema)
+	if err != nil {
+		return "", err
+	}
+	d := avroDecoder{named: map[string]any{}, data: data}
+	v, err := d.value(s, "")
+	if err != nil {
+		return "", err
+	}
+	if len(d.data) > 0 {
+		return "", fmt.Errorf("avro: %s left after the value", plural(len(d.data), "byte"))
+	}
+	return encodeJSON(v)
+}
+
+type avroDecoder struct {
+	named map[string]any
+	data  []byte
+}
+
+func (d *avroDecoder) value(schema any, namespace string) (any, error) {
+	switch t := schema.(type) {
+	case string:
+		switch t {
+		case "null":
+			return nil, nil
+		case "boolean":
+			b, err := d.take(1)
+			if err != nil {
+				return nil, err
+			}
+			return b[0] != 0, nil
+		case "int", "long":
+			return d.long()
+		case "float":
+			b, err := d.take(4)
+			if err != nil {
+				return nil, err
+			}
+			return avroFloat(float64(math.Float32frombits(binary.LittleEndian.Uint32(b))), 32), nil
+		case "double":
+			b, err := d.take(8)
+			if err != nil {
+				return nil, err
+			}
+			return avroFloat(math.Float64frombits(binary.LittleEndian.Uint64(b)), 64), nil
+		case "bytes":
+			b, err := d.bytes()
+			if err != nil {
+				return nil, err
+			}
+			return base64.StdEncoding.EncodeToString(b), nil
+		case "string":
+			b, err := d.bytes()
+			if err != nil {
+				return nil, err
+			}
+			return string(b), nil
+		}
+		def, err := avroLookup(d.named, t, namespace)
+		if err != nil {
+			return nil, err
+		}
+		return d.value(def, namespace)
+	case []any:
+		i, err := d.long()
+		if err != nil {
+			return nil, err
+		}
+		if i < 0 || i >= int64(len(t)) {
+			return nil, fmt.Errorf("avro: union branch %d of %d", i, len(t))
+		}
+		return d.value(t[i], namespace)
+	case map[string]any:
+		return d.complex(t, namespace)
+	}
+	return nil, errors.New("avro: a schema is a type name, a union or an object")
+}
+
+func (d *avroDecoder) complex(t map[string]any, namespace string) (any, error) {
+	kind, _ := t["type"].(string)
+	namespace, err := avroDefine(d.named, t, namespace)
+	if err != nil {
+		return nil, err
+	}
+	switch kind {
+	case "record", "error":
+		fields, _ := t["fields"].([]any)
+		obj := make(map[string]any, len(fields))
+		for _, f := range fields {
+			field, ok := f.(map[string]any)
+			if !ok {
+				return nil, errors.New("avro: a record field must be an object")
+			}
+			name, _ := field["name"].(string)
+			v, err := d.value(field["type"], namespace)
+			if err != nil {
+				return nil, fmt.Errorf("%w in field %q", err, name)
+			}
+			obj[name] = v
+		}
+		return obj, nil
+	case "enum":
+		i, err := d.long()
+		if err != nil {
+			return nil, err
+		}
+		symbols, _ := t["symbols"].([]any)
+		if i < 0 || i >= int64(len(symbols)) {
+			return nil, fmt.Errorf("avro: enum symbol %d of %d", i, len(symbols))
+		}
+		return symbols[i], nil
+	case "fixed":
+		size, err := strconv.Atoi(fmt.Sprint(t["size"]))
+		if err != nil || size < 0 {
+			return nil, fmt.Errorf("avro: fixed %v has no valid size", t["name"])
+		}
+		b, err := d.take(size)
+		if err != nil {
+			return nil, err
+		}
+		return base64.StdEncoding.EncodeToString(b), nil
+	case "array":
+		arr := []any{}
+		err := d.blocks(func() error {
+			v, err := d.value(t["items"], namespace)
+			arr = append(arr, v)
+			return err
+		})
+		return arr, err
+	case "map":
+		obj := map[string]any{}
+		err := d.blocks(func() error {
+			key, err := d.bytes()
+			if err != nil {
+				return err
+			}
+			v, err := d.value(t["values"], namespace)
+			obj[string(key)] = v
+			return err
+		})
+		return obj, err
+	}
+	if kind == "" {
+		if inner, ok := t["type"]; ok {
+			return d.value(inner, namespace)
+		}
+		return nil, errors.New("avro: schema object without a type")
+	}
+	return d.value(kind, namespace)
+}
+
+// blocks reads the blocks of an array or map, calling item once per
+// element. A negative count is followed by the block's size in bytes,
+// which is not needed here.
+func (d *avroDecoder) blocks(item func() error) error {
+	for {
+		n, err := d.long()
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return nil
+		}
+		if n < 0 {
+			n = -n
+			if _, err := d.long(); err != nil {
+				return err
+			}
+		}
+		if n > avroMaxBlock {
+			return fmt.Errorf("avro: block of %d items", n)
+		}
+		for range n {
+			if err := item(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// long reads a zig-zag varint, the encoding of both int and long.
+func (d *avroDecoder) long() (int64, error) {
+	v, n := binary.Varint(d.data)
+	if n <= 0 {
+		return 0, errors.New("avro: truncated or invalid varint")
+	}
+	d.data = d.data[n:]
+	return v, nil
+}
+
+func (d *avroDecoder) bytes() ([]byte, error) {
+	n, err := d.long()
+	if err != nil {
+		return nil, err
+	}
+	if n < 0 {
+		return nil, fmt.Errorf("avro: negative length %d", n)
+	}
+	if n > int64(len(d.data)) {
+		return nil, errAvroTruncated
+	}
+	return d.take(int(n))
+}
+
+func (d *avroDecoder) take(n int) ([]byte, error) {
+	if n > len(d.data) {
+		return nil, errAvroTruncated
+	}
+	b := d.data[:n]
+	d.data = d.data[n:]
+	return b, nil
+}
+
+// avroFloat keeps the shortest digits that round-trip at the given bit
+// size, so a float 0.1 decodes as 0.1. NaN and infinities, which JSON
+// cannot hold, decode as strings.
+func avroFloat(f float64, bits int) any {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return strconv.FormatFloat(f, 'g', -1, bits)
+	}
+	return json.Number(strconv.FormatFloat(f, 'g', -1, bits))
+}
@@ -0,0 +1,97 @@
+package convert
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const sampleAvroUser = `{
+	"type": "record", "name": "User", "namespace": "com.example",
+	"fields": [
+		{"name": "id", "type": "long"},
+		{"name": "name", "type": "string"},
+		{"name": "email", "type": ["null", "string"]},
+		{"name": "score", "type": "float"},
+		{"name": "ratio", "type": "double"},
+		{"name": "active", "type": "boolean"},
+		{"name": "role", "type": {"type": "enum", "name": "Role", "symbols": ["ADMIN", "USER"]}},
+		{"name": "tags", "type": {"type": "array", "items": "string"}},
+		{"name": "attrs", "type": {"type": "map", "values": "int"}},
+		{"name": "hash", "type": {"type": "fixed", "name": "Hash", "size": 2}},
+		{"name": "raw", "type": "bytes"},
+		{"name": "manager", "type": ["null", "User"]},
+		{"name": "created", "type": {"type": "long", "logicalType": "timestamp-millis"}}
+	]
+}`
+
+func avroString(b []byte, s string) []byte {
+	return append(binary.AppendVarint(b, int64(len(s))), s...)
+}
+
+func TestDecodeAvro(t *testing.T) {
+	var b []byte
+	b = binary.AppendVarint(b, -42)
+	b = avroString(b, "Ricky")
+	b = binary.AppendVarint(b, 1)
+	b = avroString(b, "r@example.com")
+	b = binary.LittleEndian.AppendUint32(b, math.Float32bits(0.1))
+	b = binary.LittleEndian.AppendUint64(b, math.Float64bits(2.5))
+	b = append(b, 1)
+	b = binary.AppendVarint(b, 1)
+	// Tags in two blocks, the second with its byte size.
+	b = binary.AppendVarint(b, 1)
+	b = avroString(b, "a")
+	b = binary.AppendVarint(b, -1)
+	b = binary.AppendVarint(b, 2)
+	b = avroString(b, "b")
+	b = binary.AppendVarint(b, 0)
+	b = binary.AppendVarint(b, 1)
+	b = avroString(b, "k")
+	b = binary.AppendVarint(b, 7)
+	b = binary.AppendVarint(b, 0)
+	b = append(b, 0xca, 0xfe)
+	b = avroString(b, "\x00\x01")
+	// The manager is a User too, with empty fields and no manager.
+	b = binary.AppendVarint(b, 1)
+	b = binary.AppendVarint(b, 1)
+	b = avroString(b, "")
+	b = binary.AppendVarint(b, 0)
+	b = binary.LittleEndian.AppendUint32(b, 0)
+	b = binary.LittleEndian.AppendUint64(b, 0)
+	b = append(b, 0)
+	b = binary.AppendVarint(b, 0)
+	b = binary.AppendVarint(b, 0)
+	b = binary.AppendVarint(b, 0)
+	b = append(b, 0, 0)
+	b = avroString(b, "")
+	b = binary.AppendVarint(b, 0)
+	b = binary.AppendVarint(b, 0)
+	b = binary.AppendVarint(b, 1700000000000)
+
+	out, err := DecodeAvro(sampleAvroUser, b)
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"id": -42, "name": "Ricky", "email": "r@example.com", "score": 0.1, "ratio": 2.5,
+		"active": true, "role": "USER", "tags": ["a", "b"], "attrs": {"k": 7},
+		"hash": "yv4=", "raw": "AAE=",
+		"manager": {"id": 1, "name": "", "email": null, "score": 0, "ratio": 0, "active": false,
+			"role": "ADMIN", "tags": [], "attrs": {}, "hash": "AAA=", "raw": "", "manager": null, "created": 0},
+		"created": 1700000000000
+	}`, out)
+
+	for data, msg := range map[string]string{
+		"":             "avro: truncated or invalid varint in field \"id\"",
+		"\x02\x0aab":   "avro: unexpected end of data in field \"name\"",
+		"\x02\x00\x04": "avro: union branch 2 of 2 in field \"email\"",
+	} {
+		_, err := DecodeAvro(sampleAvroUser, []byte(data))
+		require.EqualError(t, err, msg, data)
+	}
+	_, err = DecodeAvro(`"int"`, []byte{2, 2})
+	require.EqualError(t, err, "avro: 1 byte left after the value")
+	_, err = DecodeAvro(`"Missing"`, nil)
+	require.EqualError(t, err, `avro: unknown type "Missing"`)
+}
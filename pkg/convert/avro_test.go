@@ -0,0 +1,89 @@
+package convert
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONToAvro(t *testing.T) {
+	out, err := JSONToAvroWithOptions(`{"name":"x","id":1,"score":0.5,"ok":true,"gone":null,"tags":["a",null],"user-id":2,
+		"address":{"city":"x"},"items":[{"sku":"a"}],"meta":{"address":{"zip":"1"}},"empty":[]}`, WithSortKeys(true))
+	require.NoError(t, err)
+	require.JSONEq(t, `{"type":"record","name":"AutoGenerated","fields":[
+		{"name":"address","type":{"type":"record","name":"Address","fields":[{"name":"city","type":"string"}]}},
+		{"name":"empty","type":{"type":"array","items":"null"}},
+		{"name":"gone","type":"null"},
+		{"name":"id","type":"long"},
+		{"name":"items","type":{"type":"array","items":{"type":"record","name":"ItemsItem","fields":[{"name":"sku","type":"string"}]}}},
+		{"name":"meta","type":{"type":"record","name":"Meta","fields":[
+			{"name":"address","type":{"type":"record","name":"Address2","fields":[{"name":"zip","type":"string"}]}}]}},
+		{"name":"name","type":"string"},
+		{"name":"ok","type":"boolean"},
+		{"name":"score","type":"double"},
+		{"name":"tags","type":{"type":"array","items":["null","string"]}},
+		{"name":"user_id","type":"long"}
+	]}`, out)
+
+	out, err = JSONToAvroWithOptions(`{"b":1,"a":"x"}`, WithSortKeys(false), WithIndent(4))
+	require.NoError(t, err)
+	require.Equal(t, `{
+    "type": "record",
+    "name": "AutoGenerated",
+    "fields": [
+        {
+            "name": "b",
+            "type": "long"
+        },
+        {
+            "name": "a",
+            "type": "string"
+        }
+    ]
+}`, out)
+
+	out, err = JSONToAvro(`[1, 2]`)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"type":"array","items":"long"}`, out)
+}
+
+func TestAvroToJSON(t *testing.T) {
+	out, err := AvroToJSON(`{
+		"type": "record", "name": "User", "namespace": "com.example",
+		"fields": [
+			{"name": "id", "type": "long"},
+			{"name": "email", "type": ["null", "string"], "default": null},
+			{"name": "role", "type": {"type": "enum", "name": "Role", "symbols": ["ADMIN", "USER"]}},
+			{"name": "backup", "type": "com.example.Role"},
+			{"name": "created", "type": {"type": "long", "logicalType": "timestamp-millis"}},
+			{"name": "labels", "type": {"type": "map", "values": "string"}},
+			{"name": "scores", "type": {"type": "array", "items": "double"}},
+			{"name": "retries", "type": "int", "default": 3},
+			{"name": "next", "type": ["null", "User"]}
+		]
+	}`)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"id":0,"email":null,"role":"ADMIN","backup":"ADMIN","created":0,
+		"labels":{"key":""},"scores":[0],"retries":3,"next":null}`, out)
+
+	_, err = AvroToJSON(`{"type":"record","name":"A","fields":[{"name":"b","type":"Missing"}]}`)
+	require.ErrorContains(t, err, `unknown type "Missing"`)
+	_, err = AvroToJSON(`{"type":"record","fields":[]}`)
+	require.ErrorContains(t, err, "record without a name")
+}
+
+func TestConvertFormats_Avro(t *testing.T) {
+	schema := `{"type":"object","properties":{"name":{"type":"string"},"tags":{"type":"array","items":{"type":"string"}}}}`
+	out, err := ConvertFormats(formatSchema, formatAvro, schema)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"type":"record","name":"AutoGenerated","fields":[
+		{"name":"name","type":"string"},{"name":"tags","type":{"type":"array","items":"string"}}]}`, out)
+
+	back, err := ConvertFormats(formatAvro, formatSchema, out)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"type":"object","required":["name","tags"],"properties":{
+		"name":{"type":"string"},"tags":{"type":"array","items":{"type":"string"}}}}`, back)
+
+	format, _, _ := DetectFormat(out)
+	require.Equal(t, formatAvro, format)
+}
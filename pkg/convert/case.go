@@ -0,0 +1,103 @@
+package convert
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/linzeyan/transform-go/pkg/common"
+)
+
+const (
+	CaseCamel          = "camel"
+	CasePascal         = "pascal"
+	CaseSnake          = "snake"
+	CaseScreamingSnake = "screaming-snake"
+	CaseKebab          = "kebab"
+	CaseTrain          = "train"
+	CaseDot            = "dot"
+)
+
+// CaseConvert rewrites every line of input as one identifier in the target
+// case: camel, pascal, snake, screaming-snake, kebab, train or dot. Targets
+// may also be written by example, as in "camelCase" or "SCREAMING_SNAKE".
+// Words break at separators and at case and digit changes; indentation
+// and blank lines are kept.
+func CaseConvert(input, target string) (string, error) {
+	style, ok := caseStyles[normalizeCaseName(target)]
+	if !ok {
+		return "", fmt.Errorf("unsupported case: %s", target)
+	}
+	lines := strings.Split(input, "\n")
+	for i, line := range lines {
+		body := strings.TrimLeftFunc(line, unicode.IsSpace)
+		indent := line[:len(line)-len(body)]
+		words := identifierWords(body)
+		if len(words) == 0 {
+			continue
+		}
+		for j, w := range words {
+			words[j] = style.word(j, w)
+		}
+		lines[i] = indent + strings.Join(words, style.sep)
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+type caseStyle struct {
+	sep  string
+	word func(i int, w string) string
+}
+
+var caseStyles = map[string]caseStyle{
+	"camel": {"", func(i int, w string) string {
+		if i == 0 {
+			return strings.ToLower(w)
+		}
+		return titleWord(w)
+	}},
+	"pascal":         {"", func(_ int, w string) string { return titleWord(w) }},
+	"snake":          {"_", func(_ int, w string) string { return strings.ToLower(w) }},
+	"screamingsnake": {"_", func(_ int, w string) string { return strings.ToUpper(w) }},
+	"kebab":          {"-", func(_ int, w string) string { return strings.ToLower(w) }},
+	"train":          {"-", func(_ int, w string) string { return titleWord(w) }},
+	"dot":            {".", func(_ int, w string) string { return strings.ToLower(w) }},
+}
+
+// normalizeCaseName folds "SCREAMING_SNAKE", "camelCase" and
+// "screaming-snake" to the keys of caseStyles.
+func normalizeCaseName(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	name = strings.NewReplacer("-", "", "_", "", ".", "", " ", "").Replace(name)
+	if trimmed := strings.TrimSuffix(name, "case"); trimmed != "" {
+		name = trimmed
+	}
+	switch name {
+	case "constant", "upper", "uppersnake", "screaming":
+		return "screamingsnake"
+	case "lowercamel":
+		return "camel"
+	case "uppercamel":
+		return "pascal"
+	}
+	return name
+}
+
+// identifierWords splits at every run of non-alphanumerics and then at
+// case and digit boundaries.
+func identifierWords(s string) []string {
+	var words []string
+	chunks := strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, chunk := range chunks {
+		words = append(words, common.SplitWords(chunk)...)
+	}
+	return words
+}
+
+func titleWord(w string) string {
+	runes := []rune(strings.ToLower(w))
+	runes[0] = unicode.ToUpper(runes[0])
+	return string(runes)
+}
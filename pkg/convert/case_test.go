@@ -0,0 +1,36 @@
+package convert
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCaseConvert(t *testing.T) {
+	input := "userID\n  HTTPServer_port\n\nmax-retry.count2"
+	cases := map[string]string{
+		CaseCamel:          "userId\n  httpServerPort\n\nmaxRetryCount2",
+		CasePascal:         "UserId\n  HttpServerPort\n\nMaxRetryCount2",
+		CaseSnake:          "user_id\n  http_server_port\n\nmax_retry_count_2",
+		CaseScreamingSnake: "USER_ID\n  HTTP_SERVER_PORT\n\nMAX_RETRY_COUNT_2",
+		CaseKebab:          "user-id\n  http-server-port\n\nmax-retry-count-2",
+		CaseTrain:          "User-Id\n  Http-Server-Port\n\nMax-Retry-Count-2",
+		CaseDot:            "user.id\n  http.server.port\n\nmax.retry.count.2",
+	}
+	for target, want := range cases {
+		got, err := CaseConvert(input, target)
+		require.NoError(t, err, target)
+		require.Equal(t, want, got, target)
+	}
+
+	for _, alias := range []string{"SCREAMING_SNAKE", "snake_case", "camelCase", "Train-Case", "dot.case", "PascalCase"} {
+		_, err := CaseConvert("a b", alias)
+		require.NoError(t, err, alias)
+	}
+	out, err := CaseConvert("hello world", "SCREAMING_SNAKE")
+	require.NoError(t, err)
+	require.Equal(t, "HELLO_WORLD", out)
+
+	_, err = CaseConvert("x", "sponge")
+	require.Error(t, err)
+}
@@ -0,0 +1,288 @@
+package convert
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/linzeyan/transform-go/pkg/common"
+)
+
+var (
+	conventionalCommitRe = common.LazyRegexp(`^(?:[*-]\s+)?(?:([0-9a-fA-F]{7,40})\s+)?(?:\([^)]*\)\s+)?(\w+)(?:\(([^)]*)\))?(!)?:\s+(.+)$`)
+	plainCommitRe        = common.LazyRegexp(`^(?:[*-]\s+)?(?:([0-9a-fA-F]{7,40})\s+)?(?:\([^)]*\)\s+)?(.+)$`)
+	gitLogCommitRe       = common.LazyRegexp(`^commit\s+([0-9a-fA-F]{7,40})`)
+	breakingFooterRe     = common.LazyRegexp(`^BREAKING[ -]CHANGE:\s*`)
+	changelogItemRe      = common.LazyRegexp(`^[-*]\s+(?:\*\*([^*]+):\*\*\s+)?(.+?)(?:\s+\(([0-9a-fA-F]{7,40})\))?$`)
+	changelogVersionRe   = common.LazyRegexp(`^\[?v?(\d+\.\d+[^\]\s]*|Unreleased)\]?`)
+	changelogDateRe      = common.LazyRegexp(`\d{4}-\d{2}-\d{2}`)
+)
+
+// ChangelogEntry is one change, as parsed from a conventional commit subject
+// or a changelog bullet.
+type ChangelogEntry struct {
+	Type        string `json:"type"`
+	Scope       string `json:"scope,omitempty"`
+	Description string `json:"description"`
+	Hash        string `json:"hash,omitempty"`
+	Breaking    bool   `json:"breaking,omitempty"`
+}
+
+// ChangelogRelease groups entries under a version heading.
+type ChangelogRelease struct {
+	Version string           `json:"version,omitempty"`
+	Date    string           `json:"date,omitempty"`
+	Entries []ChangelogEntry `json:"entries"`
+}
+
+type changelogSection struct {
+	Type  string
+	Title string
+}
+
+// changelogSections lists sections in output order.
+var changelogSections = []changelogSection{
+	{"feat", "Features"},
+	{"fix", "Bug Fixes"},
+	{"perf", "Performance Improvements"},
+	{"revert", "Reverts"},
+	{"docs", "Documentation"},
+	{"refactor", "Code Refactoring"},
+	{"build", "Build System"},
+	{"ci", "Continuous Integration"},
+	{"test", "Tests"},
+	{"style", "Styles"},
+	{"chore", "Chores"},
+	{"other", "Other Changes"},
+}
+
+const changelogBreakingTitle = "Breaking Changes"
+
+// ConventionalCommitsToChangelog groups commit subjects (git log --oneline
+// output, plain git log output or one subject per line) into a Markdown
+// changelog. Breaking changes are listed first and again under their type.
+func ConventionalCommitsToChangelog(input, version string) (string, error) {
+	entries := parseConventionalCommits(input)
+	if len(entries) == 0 {
+		return "", errors.New("no commits found")
+	}
+	level := "##"
+	var b strings.Builder
+	if v := strings.TrimSpace(version); v != "" {
+		b.WriteString("## " + v + "\n\n")
+		level = "###"
+	}
+	writeSection := func(title string, items []ChangelogEntry) {
+		if len(items) == 0 {
+			return
+		}
+		b.WriteString(level + " " + title + "\n\n")
+		for _, e := range items {
+			b.WriteString(formatChangelogItem(e) + "\n")
+		}
+		b.WriteString("\n")
+	}
+	var breaking []ChangelogEntry
+	for _, e := range entries {
+		if e.Breaking {
+			breaking = append(breaking, e)
+		}
+	}
+	writeSection(changelogBreakingTitle, breaking)
+	for _, section := range changelogSections {
+		var items []ChangelogEntry
+		for _, e := range entries {
+			if e.Type == section.Type {
+				items = append(items, e)
+			}
+		}
+		writeSection(section.Title, items)
+	}
+	return strings.TrimRight(b.String(), "\n") + "\n", nil
+}
+
+// ConventionalCommitsToJSON parses commit subjects into changelog entries.
+func ConventionalCommitsToJSON(input string) (string, error) {
+	entries := parseConventionalCommits(input)
+	if len(entries) == 0 {
+		return "", errors.New("no commits found")
+	}
+	return encodeJSON(entries)
+}
+
+// ChangelogToJSON parses a Markdown changelog back into releases. Section
+// headings map to commit types (Keep a Changelog's Added and Fixed map to
+// feat and fix); other headings start a new release.
+func ChangelogToJSON(input string) (string, error) {
+	var releases []ChangelogRelease
+	var current *ChangelogRelease
+	sectionType := ""
+	breakingSection := false
+	// breakingSeen holds entries from the breaking section until their
+	// type section lists them again.
+	var breakingSeen []ChangelogEntry
+	flush := func() {
+		if current == nil {
+			return
+		}
+		current.Entries = append(current.Entries, breakingSeen...)
+		breakingSeen = nil
+		releases = append(releases, *current)
+	}
+	for _, raw := range strings.Split(strings.ReplaceAll(input, "\r\n", "\n"), "\n") {
+		line := strings.TrimSpace(raw)
+		if level := markdownHeadingLevel(line); level > 0 {
+			title := strings.TrimSpace(line[level:])
+			if typ, breaking, ok := changelogSectionType(title); ok {
+				sectionType, breakingSection = typ, breaking
+				if current == nil {
+					current = &ChangelogRelease{}
+				}
+				continue
+			}
+			if level == 1 && !changelogVersionRe().MatchString(title) {
+				continue
+			}
+			flush()
+			current = &ChangelogRelease{Version: title, Entries: []ChangelogEntry{}}
+			if m := changelogVersionRe().FindStringSubmatch(title); m != nil {
+				current.Version = m[1]
+			}
+			current.Date = changelogDateRe().FindString(title)
+			sectionType, breakingSection = "", false
+			continue
+		}
+		m := changelogItemRe().FindStringSubmatch(line)
+		if m == nil || (sectionType == "" && !breakingSection) {
+			continue
+		}
+		if current == nil {
+			current = &ChangelogRelease{}
+		}
+		entry := ChangelogEntry{Type: sectionType, Scope: m[1], Description: m[2], Hash: m[3]}
+		if breakingSection {
+			entry.Breaking = true
+			breakingSeen = append(breakingSeen, entry)
+			continue
+		}
+		for i, seen := range breakingSeen {
+			if seen.Scope == entry.Scope && seen.Description == entry.Description && seen.Hash == entry.Hash {
+				entry.Breaking = true
+				breakingSeen = append(breakingSeen[:i], breakingSeen[i+1:]...)
+				break
+			}
+		}
+		current.Entries = append(current.Entries, entry)
+	}
+	flush()
+	if len(releases) == 0 {
+		return "", errors.New("no changelog entries found")
+	}
+	for i := range releases {
+		if releases[i].Entries == nil {
+			releases[i].Entries = []ChangelogEntry{}
+		}
+	}
+	return encodeJSON(releases)
+}
+
+func parseConventionalCommits(input string) []ChangelogEntry {
+	var entries []ChangelogEntry
+	pendingHash := ""
+	for _, raw := range strings.Split(strings.ReplaceAll(input, "\r\n", "\n"), "\n") {
+		line := strings.TrimSpace(raw)
+		if line == "" {
+			continue
+		}
+		if m := gitLogCommitRe().FindStringSubmatch(line); m != nil {
+			pendingHash = shortHash(m[1])
+			continue
+		}
+		if breakingFooterRe().MatchString(line) {
+			if len(entries) > 0 {
+				entries[len(entries)-1].Breaking = true
+			}
+			continue
+		}
+		indented := raw != line && strings.TrimLeft(raw, " \t") == line
+		if pendingHash == "" && indented {
+			// Body text of a full git log entry.
+			continue
+		}
+		if pendingHash != "" && !indented {
+			// Author:, Date:, Merge: headers of a full git log entry.
+			continue
+		}
+		entry := parseCommitSubject(line)
+		if pendingHash != "" {
+			entry.Hash = pendingHash
+			pendingHash = ""
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func parseCommitSubject(line string) ChangelogEntry {
+	if m := conventionalCommitRe().FindStringSubmatch(line); m != nil && isChangelogType(strings.ToLower(m[2])) {
+		return ChangelogEntry{
+			Type:        strings.ToLower(m[2]),
+			Scope:       m[3],
+			Description: strings.TrimSpace(m[5]),
+			Hash:        shortHash(m[1]),
+			Breaking:    m[4] == "!",
+		}
+	}
+	m := plainCommitRe().FindStringSubmatch(line)
+	return ChangelogEntry{Type: "other", Description: strings.TrimSpace(m[2]), Hash: shortHash(m[1])}
+}
+
+func isChangelogType(typ string) bool {
+	for _, s := range changelogSections {
+		if s.Type == typ {
+			return true
+		}
+	}
+	return false
+}
+
+// changelogSectionType maps a section heading to a commit type.
+func changelogSectionType(title string) (string, bool, bool) {
+	t := strings.ToLower(strings.Trim(title, " :⚠"))
+	if strings.Contains(t, "breaking") {
+		return "", true, true
+	}
+	for _, s := range changelogSections {
+		if t == strings.ToLower(s.Title) {
+			return s.Type, false, true
+		}
+	}
+	switch t {
+	case "added", "new features", "feature":
+		return "feat", false, true
+	case "fixed", "fixes", "bug fix":
+		return "fix", false, true
+	case "changed", "removed", "deprecated", "security":
+		return t, false, true
+	}
+	return "", false, false
+}
+
+func formatChangelogItem(e ChangelogEntry) string {
+	item := "- "
+	if e.Scope != "" {
+		item += fmt.Sprintf("**%s:** ", e.Scope)
+	}
+	item += e.Description
+	if e.Hash != "" {
+		item += " (" + e.Hash + ")"
+	}
+	return item
+}
+
+func shortHash(hash string) string {
+	if len(hash) > 7 {
+		return strings.ToLower(hash[:7])
+	}
+	return strings.ToLower(hash)
+}
@@ -0,0 +1,103 @@
+package convert
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const sampleGitLog = `a1b2c3d (HEAD -> main, tag: v1.2.0) feat(api)!: drop v1 endpoints
+b2c3d4e fix: handle empty input
+c3d4e5f feat: add TOML output
+d4e5f6a Merge pull request #12 from dev
+e5f6a7b docs(readme): document flags`
+
+func TestConventionalCommitsToChangelog(t *testing.T) {
+	out, err := ConventionalCommitsToChangelog(sampleGitLog, "1.2.0")
+	require.NoError(t, err)
+	require.Equal(t, `## 1.2.0
+
+### Breaking Changes
+
+- **api:** drop v1 endpoints (a1b2c3d)
+
+### Features
+
+- **api:** drop v1 endpoints (a1b2c3d)
+- add TOML output (c3d4e5f)
+
+### Bug Fixes
+
+- handle empty input (b2c3d4e)
+
+### Documentation
+
+- **readme:** document flags (e5f6a7b)
+
+### Other Changes
+
+- Merge pull request #12 from dev (d4e5f6a)
+`, out)
+
+	_, err = ConventionalCommitsToChangelog("  \n", "")
+	require.Error(t, err)
+}
+
+func TestConventionalCommitsFullGitLog(t *testing.T) {
+	input := `commit 0123456789abcdef0123456789abcdef01234567
+Author: Dev <dev@example.com>
+Date:   Mon Jan 1 10:00:00 2024 +0000
+
+    refactor(core): split parser
+
+    BREAKING CHANGE: Parse now returns an error.
+
+commit fedcba9876543210fedcba9876543210fedcba98
+Author: Dev <dev@example.com>
+Date:   Sun Dec 31 10:00:00 2023 +0000
+
+    perf: cache lookups
+`
+	out, err := ConventionalCommitsToJSON(input)
+	require.NoError(t, err)
+	var entries []ChangelogEntry
+	require.NoError(t, json.Unmarshal([]byte(out), &entries))
+	require.Equal(t, []ChangelogEntry{
+		{Type: "refactor", Scope: "core", Description: "split parser", Hash: "0123456", Breaking: true},
+		{Type: "perf", Description: "cache lookups", Hash: "fedcba9"},
+	}, entries)
+}
+
+func TestChangelogToJSONRoundTrip(t *testing.T) {
+	md, err := ConventionalCommitsToChangelog(sampleGitLog, "1.2.0 (2024-05-01)")
+	require.NoError(t, err)
+	out, err := ChangelogToJSON("# Changelog\n\n" + md + "\n## [1.1.0] - 2024-04-01\n\n### Added\n\n- initial release\n")
+	require.NoError(t, err)
+	var releases []ChangelogRelease
+	require.NoError(t, json.Unmarshal([]byte(out), &releases))
+	require.Len(t, releases, 2)
+	require.Equal(t, "1.2.0", releases[0].Version)
+	require.Equal(t, "2024-05-01", releases[0].Date)
+	require.Equal(t, parseConventionalCommitsByType(t), releases[0].Entries)
+	require.Equal(t, ChangelogRelease{Version: "1.1.0", Date: "2024-04-01", Entries: []ChangelogEntry{{Type: "feat", Description: "initial release"}}}, releases[1])
+
+	_, err = ChangelogToJSON("just text")
+	require.Error(t, err)
+}
+
+// parseConventionalCommitsByType returns the sample entries in changelog
+// section order, as ChangelogToJSON reads them back.
+func parseConventionalCommitsByType(t *testing.T) []ChangelogEntry {
+	t.Helper()
+	entries := parseConventionalCommits(sampleGitLog)
+	var ordered []ChangelogEntry
+	for _, s := range changelogSections {
+		for _, e := range entries {
+			if e.Type == s.Type {
+				ordered = append(ordered, e)
+			}
+		}
+	}
+	return ordered
+}
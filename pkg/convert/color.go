@@ -0,0 +1,342 @@
+package convert
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/linzeyan/transform-go/pkg/common"
+)
+
+var (
+	hexColorRe  = common.LazyRegexp(`^#?([0-9a-fA-F]{3,4}|[0-9a-fA-F]{6}|[0-9a-fA-F]{8})$`)
+	funcColorRe = common.LazyRegexp(`(?i)^(rgba?|hsla?|hwb)\(\s*(.*?)\s*\)$`)
+)
+
+// ColorResult shows one color in every CSS notation. Luminance and the
+// contrast ratios follow WCAG 2 and ignore alpha.
+type ColorResult struct {
+	Input         string  `json:"input"`
+	Format        string  `json:"format"`
+	Hex           string  `json:"hex"`
+	RGB           string  `json:"rgb"`
+	HSL           string  `json:"hsl"`
+	HWB           string  `json:"hwb"`
+	Name          string  `json:"name,omitempty"`
+	Red           int     `json:"red"`
+	Green         int     `json:"green"`
+	Blue          int     `json:"blue"`
+	Alpha         float64 `json:"alpha"`
+	Luminance     float64 `json:"luminance"`
+	ContrastWhite float64 `json:"contrastWhite"`
+	ContrastBlack float64 `json:"contrastBlack"`
+	TextColor     string  `json:"textColor"`
+}
+
+// ColorInfo parses a hex color (#rgb, #rgba, #rrggbb, #rrggbbaa), rgb(),
+// rgba(), hsl(), hsla(), hwb() in comma or space syntax, or a CSS color
+// name, and renders it in all of them. TextColor is black or white,
+// whichever contrasts more.
+func ColorInfo(input string) (ColorResult, error) {
+	trimmed := strings.TrimSpace(input)
+	res := ColorResult{Input: trimmed}
+	if trimmed == "" {
+		return res, errors.New("input is empty")
+	}
+	r, g, b, a, format, err := parseColor(trimmed)
+	if err != nil {
+		return res, err
+	}
+	res.Format = format
+	res.Red, res.Green, res.Blue = int(math.Round(r)), int(math.Round(g)), int(math.Round(b))
+	res.Alpha = roundTo(a, 3)
+	res.Hex = fmt.Sprintf("#%02x%02x%02x", res.Red, res.Green, res.Blue)
+	if res.Alpha < 1 {
+		res.Hex += fmt.Sprintf("%02x", int(math.Round(a*255)))
+	}
+	h, s, l := rgbToHSL(r, g, b)
+	_, w, k := rgbToHWB(r, g, b)
+	if res.Alpha < 1 {
+		alpha := colorNumber(res.Alpha)
+		res.RGB = fmt.Sprintf("rgba(%d, %d, %d, %s)", res.Red, res.Green, res.Blue, alpha)
+		res.HSL = fmt.Sprintf("hsla(%s, %s%%, %s%%, %s)", colorNumber(h), colorNumber(s), colorNumber(l), alpha)
+		res.HWB = fmt.Sprintf("hwb(%s %s%% %s%% / %s)", colorNumber(h), colorNumber(w), colorNumber(k), alpha)
+	} else {
+		res.RGB = fmt.Sprintf("rgb(%d, %d, %d)", res.Red, res.Green, res.Blue)
+		res.HSL = fmt.Sprintf("hsl(%s, %s%%, %s%%)", colorNumber(h), colorNumber(s), colorNumber(l))
+		res.HWB = fmt.Sprintf("hwb(%s %s%% %s%%)", colorNumber(h), colorNumber(w), colorNumber(k))
+		res.Name = colorName(res.Red, res.Green, res.Blue)
+	}
+	lum := relativeLuminance(res.Red, res.Green, res.Blue)
+	res.Luminance = roundTo(lum, 4)
+	res.ContrastWhite = roundTo(1.05/(lum+0.05), 2)
+	res.ContrastBlack = roundTo((lum+0.05)/0.05, 2)
+	res.TextColor = "white"
+	if res.ContrastBlack > res.ContrastWhite {
+		res.TextColor = "black"
+	}
+	return res, nil
+}
+
+// parseColor returns channels in 0-255 and alpha in 0-1.
+func parseColor(s string) (r, g, b, a float64, format string, err error) {
+	if rgb, ok := cssColorNames[strings.ToLower(s)]; ok {
+		return float64(rgb >> 16), float64(rgb >> 8 & 0xff), float64(rgb & 0xff), 1, "named", nil
+	}
+	if strings.EqualFold(s, "transparent") {
+		return 0, 0, 0, 0, "named", nil
+	}
+	if m := hexColorRe().FindStringSubmatch(s); m != nil {
+		digits := m[1]
+		if len(digits) <= 4 {
+			var b strings.Builder
+			for _, c := range digits {
+				b.WriteRune(c)
+				b.WriteRune(c)
+			}
+			digits = b.String()
+		}
+		v, _ := strconv.ParseUint(digits, 16, 32)
+		a = 1
+		if len(digits) == 8 {
+			a = float64(v&0xff) / 255
+			v >>= 8
+		}
+		return float64(v >> 16), float64(v >> 8 & 0xff), float64(v & 0xff), a, "hex", nil
+	}
+	m := funcColorRe().FindStringSubmatch(s)
+	if m == nil {
+		return 0, 0, 0, 0, "", fmt.Errorf("unrecognized color: %s", s)
+	}
+	fn := strings.ToLower(m[1])
+	args, err := colorArgs(m[2])
+	if err != nil {
+		return 0, 0, 0, 0, "", fmt.Errorf("%s: %w", s, err)
+	}
+	if len(args) != 3 && len(args) != 4 {
+		return 0, 0, 0, 0, "", fmt.Errorf("%s: expected 3 or 4 components", s)
+	}
+	a = 1
+	if len(args) == 4 {
+		if a, err = colorComponent(args[3], 1); err != nil {
+			return 0, 0, 0, 0, "", fmt.Errorf("%s: %w", s, err)
+		}
+	}
+	vals := make([]float64, 3)
+	switch fn {
+	case "rgb", "rgba":
+		for i := range vals {
+			if vals[i], err = colorComponent(args[i], 255); err != nil {
+				return 0, 0, 0, 0, "", fmt.Errorf("%s: %w", s, err)
+			}
+		}
+		return clampColor(vals[0], 255), clampColor(vals[1], 255), clampColor(vals[2], 255), clampColor(a, 1), "rgb", nil
+	}
+	if vals[0], err = parseHue(args[0]); err != nil {
+		return 0, 0, 0, 0, "", fmt.Errorf("%s: %w", s, err)
+	}
+	for i := 1; i < 3; i++ {
+		if vals[i], err = colorComponent(strings.TrimSuffix(args[i], "%")+"%", 1); err != nil {
+			return 0, 0, 0, 0, "", fmt.Errorf("%s: %w", s, err)
+		}
+		vals[i] = clampColor(vals[i], 1)
+	}
+	if fn == "hwb" {
+		r, g, b = hwbToRGB(vals[0], vals[1], vals[2])
+		return r, g, b, clampColor(a, 1), "hwb", nil
+	}
+	r, g, b = hslToRGB(vals[0], vals[1], vals[2])
+	return r, g, b, clampColor(a, 1), "hsl", nil
+}
+
+// colorArgs splits "255, 0, 0, 0.5" or "255 0 0 / 50%" into components.
+func colorArgs(s string) ([]string, error) {
+	main, alpha, slash := strings.Cut(s, "/")
+	var args []string
+	if strings.Contains(main, ",") {
+		for _, part := range strings.Split(main, ",") {
+			args = append(args, strings.TrimSpace(part))
+		}
+	} else {
+		args = strings.Fields(main)
+	}
+	if slash {
+		if len(args) != 3 {
+			return nil, errors.New("alpha after / needs three components")
+		}
+		args = append(args, strings.TrimSpace(alpha))
+	}
+	for _, arg := range args {
+		if arg == "" {
+			return nil, errors.New("empty component")
+		}
+	}
+	return args, nil
+}
+
+// colorComponent reads a number or a percentage of full.
+func colorComponent(s string, full float64) (float64, error) {
+	if pct, ok := strings.CutSuffix(s, "%"); ok {
+		v, err := strconv.ParseFloat(pct, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid percentage %s", s)
+		}
+		return v / 100 * full, nil
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number %s", s)
+	}
+	return v, nil
+}
+
+// parseHue reads degrees, optionally with a deg, rad, grad or turn unit,
+// normalized to [0, 360).
+func parseHue(s string) (float64, error) {
+	lower := strings.ToLower(s)
+	scale := 1.0
+	for _, unit := range []struct {
+		suffix string
+		scale  float64
+	}{{"deg", 1}, {"grad", 0.9}, {"rad", 180 / math.Pi}, {"turn", 360}} {
+		if v, ok := strings.CutSuffix(lower, unit.suffix); ok {
+			lower, scale = v, unit.scale
+			break
+		}
+	}
+	v, err := strconv.ParseFloat(lower, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid hue %s", s)
+	}
+	return math.Mod(math.Mod(v*scale, 360)+360, 360), nil
+}
+
+func clampColor(v, max float64) float64 {
+	return math.Min(math.Max(v, 0), max)
+}
+
+func hslToRGB(h, s, l float64) (float64, float64, float64) {
+	f := func(n float64) float64 {
+		k := math.Mod(n+h/30, 12)
+		a := s * math.Min(l, 1-l)
+		return (l - a*math.Max(-1, math.Min(math.Min(k-3, 9-k), 1))) * 255
+	}
+	return f(0), f(8), f(4)
+}
+
+func hwbToRGB(h, w, b float64) (float64, float64, float64) {
+	if w+b >= 1 {
+		gray := w / (w + b) * 255
+		return gray, gray, gray
+	}
+	r, g, bl := hslToRGB(h, 1, 0.5)
+	scale := func(c float64) float64 { return (c/255*(1-w-b) + w) * 255 }
+	return scale(r), scale(g), scale(bl)
+}
+
+// rgbToHSL returns hue in degrees and saturation and lightness in percent.
+func rgbToHSL(r, g, b float64) (h, s, l float64) {
+	r, g, b = r/255, g/255, b/255
+	hi, lo := math.Max(r, math.Max(g, b)), math.Min(r, math.Min(g, b))
+	l = (hi + lo) / 2
+	if d := hi - lo; d > 0 {
+		s = d / (1 - math.Abs(2*l-1))
+		h = colorHue(r, g, b, hi, d)
+	}
+	return h, s * 100, l * 100
+}
+
+// rgbToHWB returns hue in degrees and whiteness and blackness in percent.
+func rgbToHWB(r, g, b float64) (h, w, k float64) {
+	r, g, b = r/255, g/255, b/255
+	hi, lo := math.Max(r, math.Max(g, b)), math.Min(r, math.Min(g, b))
+	if d := hi - lo; d > 0 {
+		h = colorHue(r, g, b, hi, d)
+	}
+	return h, lo * 100, (1 - hi) * 100
+}
+
+func colorHue(r, g, b, hi, d float64) float64 {
+	var h float64
+	switch hi {
+	case r:
+		h = math.Mod((g-b)/d+6, 6)
+	case g:
+		h = (b-r)/d + 2
+	default:
+		h = (r-g)/d + 4
+	}
+	return h * 60
+}
+
+// relativeLuminance is the WCAG 2 relative luminance of an sRGB color.
+func relativeLuminance(r, g, b int) float64 {
+	linear := func(c int) float64 {
+		v := float64(c) / 255
+		if v <= 0.04045 {
+			return v / 12.92
+		}
+		return math.Pow((v+0.055)/1.055, 2.4)
+	}
+	return 0.2126*linear(r) + 0.7152*linear(g) + 0.0722*linear(b)
+}
+
+func colorNumber(v float64) string {
+	return strconv.FormatFloat(roundTo(v, 1), 'f', -1, 64)
+}
+
+// colorName returns the CSS name of an exact match, preferring the
+// alphabetically first of aliases such as aqua and cyan.
+func colorName(r, g, b int) string {
+	want := uint32(r)<<16 | uint32(g)<<8 | uint32(b)
+	name := ""
+	for n, rgb := range cssColorNames {
+		if rgb == want && (name == "" || n < name) {
+			name = n
+		}
+	}
+	return name
+}
+
+// cssColorNames holds the CSS Color Module Level 4 named colors.
+var cssColorNames = map[string]uint32{
+	"aliceblue": 0xf0f8ff, "antiquewhite": 0xfaebd7, "aqua": 0x00ffff, "aquamarine": 0x7fffd4,
+	"azure": 0xf0ffff, "beige": 0xf5f5dc, "bisque": 0xffe4c4, "black": 0x000000,
+	"blanchedalmond": 0xffebcd, "blue": 0x0000ff, "blueviolet": 0x8a2be2, "brown": 0xa52a2a,
+	"burlywood": 0xdeb887, "cadetblue": 0x5f9ea0, "chartreuse": 0x7fff00, "chocolate": 0xd2691e,
+	"coral": 0xff7f50, "cornflowerblue": 0x6495ed, "cornsilk": 0xfff8dc, "crimson": 0xdc143c,
+	"cyan": 0x00ffff, "darkblue": 0x00008b, "darkcyan": 0x008b8b, "darkgoldenrod": 0xb8860b,
+	"darkgray": 0xa9a9a9, "darkgreen": 0x006400, "darkgrey": 0xa9a9a9, "darkkhaki": 0xbdb76b,
+	"darkmagenta": 0x8b008b, "darkolivegreen": 0x556b2f, "darkorange": 0xff8c00, "darkorchid": 0x9932cc,
+	"darkred": 0x8b0000, "darksalmon": 0xe9967a, "darkseagreen": 0x8fbc8f, "darkslateblue": 0x483d8b,
+	"darkslategray": 0x2f4f4f, "darkslategrey": 0x2f4f4f, "darkturquoise": 0x00ced1, "darkviolet": 0x9400d3,
+	"deeppink": 0xff1493, "deepskyblue": 0x00bfff, "dimgray": 0x696969, "dimgrey": 0x696969,
+	"dodgerblue": 0x1e90ff, "firebrick": 0xb22222, "floralwhite": 0xfffaf0, "forestgreen": 0x228b22,
+	"fuchsia": 0xff00ff, "gainsboro": 0xdcdcdc, "ghostwhite": 0xf8f8ff, "gold": 0xffd700,
+	"goldenrod": 0xdaa520, "gray": 0x808080, "green": 0x008000, "greenyellow": 0xadff2f,
+	"grey": 0x808080, "honeydew": 0xf0fff0, "hotpink": 0xff69b4, "indianred": 0xcd5c5c,
+	"indigo": 0x4b0082, "ivory": 0xfffff0, "khaki": 0xf0e68c, "lavender": 0xe6e6fa,
+	"lavenderblush": 0xfff0f5, "lawngreen": 0x7cfc00, "lemonchiffon": 0xfffacd, "lightblue": 0xadd8e6,
+	"lightcoral": 0xf08080, "lightcyan": 0xe0ffff, "lightgoldenrodyellow": 0xfafad2, "lightgray": 0xd3d3d3,
+	"lightgreen": 0x90ee90, "lightgrey": 0xd3d3d3, "lightpink": 0xffb6c1, "lightsalmon": 0xffa07a,
+	"lightseagreen": 0x20b2aa, "lightskyblue": 0x87cefa, "lightslategray": 0x778899, "lightslategrey": 0x778899,
+	"lightsteelblue": 0xb0c4de, "lightyellow": 0xffffe0, "lime": 0x00ff00, "limegreen": 0x32cd32,
+	"linen": 0xfaf0e6, "magenta": 0xff00ff, "maroon": 0x800000, "mediumaquamarine": 0x66cdaa,
+	"mediumblue": 0x0000cd, "mediumorchid": 0xba55d3, "mediumpurple": 0x9370db, "mediumseagreen": 0x3cb371,
+	"mediumslateblue": 0x7b68ee, "mediumspringgreen": 0x00fa9a, "mediumturquoise": 0x48d1cc, "mediumvioletred": 0xc71585,
+	"midnightblue": 0x191970, "mintcream": 0xf5fffa, "mistyrose": 0xffe4e1, "moccasin": 0xffe4b5,
+	"navajowhite": 0xffdead, "navy": 0x000080, "oldlace": 0xfdf5e6, "olive": 0x808000,
+	"olivedrab": 0x6b8e23, "orange": 0xffa500, "orangered": 0xff4500, "orchid": 0xda70d6,
+	"palegoldenrod": 0xeee8aa, "palegreen": 0x98fb98, "paleturquoise": 0xafeeee, "palevioletred": 0xdb7093,
+	"papayawhip": 0xffefd5, "peachpuff": 0xffdab9, "peru": 0xcd853f, "pink": 0xffc0cb,
+	"plum": 0xdda0dd, "powderblue": 0xb0e0e6, "purple": 0x800080, "rebeccapurple": 0x663399,
+	"red": 0xff0000, "rosybrown": 0xbc8f8f, "royalblue": 0x4169e1, "saddlebrown": 0x8b4513,
+	"salmon": 0xfa8072, "sandybrown": 0xf4a460, "seagreen": 0x2e8b57, "seashell": 0xfff5ee,
+	"sienna": 0xa0522d, "silver": 0xc0c0c0, "skyblue": 0x87ceeb, "slateblue": 0x6a5acd,
+	"slategray": 0x708090, "slategrey": 0x708090, "snow": 0xfffafa, "springgreen": 0x00ff7f,
+	"steelblue": 0x4682b4, "tan": 0xd2b48c, "teal": 0x008080, "thistle": 0xd8bfd8,
+	"tomato": 0xff6347, "turquoise": 0x40e0d0, "violet": 0xee82ee, "wheat": 0xf5deb3,
+	"white": 0xffffff, "whitesmoke": 0xf5f5f5, "yellow": 0xffff00, "yellowgreen": 0x9acd32,
+}
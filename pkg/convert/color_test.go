@@ -0,0 +1,48 @@
+package convert
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestColorInfo(t *testing.T) {
+	for _, in := range []string{"#ff6347", "FF6347", "tomato", "rgb(255, 99, 71)", "rgb(255 99 71)", "hsl(9.1, 100%, 63.9%)", "hwb(9.1 27.8% 0%)"} {
+		res, err := ColorInfo(in)
+		require.NoError(t, err, in)
+		require.InDelta(t, 255, res.Red, 0, in)
+		require.InDelta(t, 99, res.Green, 1, in)
+		require.InDelta(t, 71, res.Blue, 1, in)
+	}
+
+	res, err := ColorInfo("Tomato")
+	require.NoError(t, err)
+	require.Equal(t, "named", res.Format)
+	require.Equal(t, "#ff6347", res.Hex)
+	require.Equal(t, "rgb(255, 99, 71)", res.RGB)
+	require.Equal(t, "hsl(9.1, 100%, 63.9%)", res.HSL)
+	require.Equal(t, "hwb(9.1 27.8% 0%)", res.HWB)
+	require.Equal(t, "tomato", res.Name)
+	require.Equal(t, 0.3064, res.Luminance)
+	require.Equal(t, 2.95, res.ContrastWhite)
+	require.Equal(t, 7.13, res.ContrastBlack)
+	require.Equal(t, "black", res.TextColor)
+
+	res, err = ColorInfo("hsla(240deg 100% 25% / 50%)")
+	require.NoError(t, err)
+	require.Equal(t, "hsl", res.Format)
+	require.Equal(t, "#00008080", res.Hex)
+	require.Equal(t, "rgba(0, 0, 128, 0.5)", res.RGB)
+	require.Empty(t, res.Name)
+	require.Equal(t, "white", res.TextColor)
+
+	res, err = ColorInfo("#0ff")
+	require.NoError(t, err)
+	require.Equal(t, "aqua", res.Name)
+	require.Equal(t, 21.0, roundTo(res.ContrastBlack*res.ContrastWhite, 0))
+
+	for _, in := range []string{"", "#12345", "rgb(1, 2)", "hsl(red, 1%, 2%)", "chartreuse-ish"} {
+		_, err := ColorInfo(in)
+		require.Error(t, err, in)
+	}
+}
@@ -0,0 +1,94 @@
+package convert
+
+import (
+	"sync"
+	"time"
+)
+
+// ConvertResult is one target of ConvertToMany: the converted Output, or
+// Error when that target failed.
+type ConvertResult struct {
+	Output string `json:"output,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// ConvertToMany converts input into every format in targets, keyed by
+// target name. Unlike calling ConvertFormats once per target, the source
+// is parsed a single time and the targets are generated concurrently.
+func ConvertToMany(from string, targets []string, input string) (map[string]ConvertResult, error) {
+	return ConvertToManyWithOptions(from, targets, input)
+}
+
+// ConvertToManyWithOptions is ConvertToMany with opts applied to every
+// target, as ConvertFormatsWithOptions would. An unknown source format, a
+// source that does not parse or a failing script fails the whole call; a
+// target that cannot be generated only sets the Error of its result.
+func ConvertToManyWithOptions(from string, targets []string, input string, opts ...ConvertOption) (map[string]ConvertResult, error) {
+	o := NewConvertOptions(opts...)
+	if err := o.checkProfile(); err != nil {
+		return nil, err
+	}
+	o = o.tomlDatetimesFor(targets...)
+	start := time.Now()
+
+	// Targets with a direct path from the source (and the source itself)
+	// do not need the JSON form, unless a script has to run over it.
+	var mid string
+	var err error
+	needJSON := false
+	for _, to := range targets {
+		if o.Script != "" || directConversion(from, to) == nil {
+			needJSON = true
+			break
+		}
+	}
+	if needJSON {
+		if o.Script != "" {
+			mid, err = scriptJSON(from, input, o)
+		} else {
+			mid, err = readToJSON(from, input, o)
+		}
+		if err != nil {
+			for _, to := range targets {
+				recordConversion(from, to, start, len(input), err)
+			}
+			return nil, withPosition(from, input, err)
+		}
+	}
+
+	results := make(map[string]ConvertResult, len(targets))
+	seen := make(map[string]bool, len(targets))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, to := range targets {
+		if seen[to] {
+			continue
+		}
+		seen[to] = true
+		wg.Go(func() {
+			var out string
+			var err error
+			direct := directConversion(from, to)
+			switch {
+			case o.Script != "":
+				out, err = writeScriptOutput(to, mid, o)
+			case direct != nil:
+				out, err = direct(input, o)
+			default:
+				out, err = writeFromJSON(to, mid, o)
+			}
+			recordConversion(from, to, start, len(input), err)
+			var r ConvertResult
+			if err != nil {
+				r.Error = err.Error()
+			} else {
+				r.Output = o.finish(o.addProvenance(from, to, out, time.Now()))
+			}
+			mu.Lock()
+			results[to] = r
+			mu.Unlock()
+		})
+	}
+	wg.Wait()
+	return results, nil
+}
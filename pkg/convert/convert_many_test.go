@@ -0,0 +1,69 @@
+package convert
+
+import (
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvertToMany(t *testing.T) {
+	const input = "name: Ricky\nage: 27\ntags: [a, b]\n"
+	targets := []string{formatJSON, formatTOML, formatGoStruct, formatProtobuf, formatYAML, formatTOML, "Nope"}
+	results, err := ConvertToMany(formatYAML, targets, input)
+	require.NoError(t, err)
+	require.Len(t, results, 6)
+	for _, to := range targets[:5] {
+		want, err := ConvertFormats(formatYAML, to, input)
+		require.NoError(t, err)
+		require.Equal(t, ConvertResult{Output: want}, results[to], to)
+	}
+	require.Equal(t, ConvertResult{Error: "unsupported target format: Nope"}, results["Nope"])
+
+	// Direct paths and options behave as in ConvertFormatsWithOptions.
+	const goStruct = "type User struct {\n\tName string `json:\"name\"`\n}\n"
+	opts := []ConvertOption{WithIndent(4), WithFinalNewline(true)}
+	results, err = ConvertToManyWithOptions(formatGoStruct, []string{formatGraphQL, formatJSON, formatSQL}, goStruct, opts...)
+	require.NoError(t, err)
+	for to, r := range results {
+		want, err := ConvertFormatsWithOptions(formatGoStruct, to, goStruct, opts...)
+		require.NoError(t, err)
+		require.Equal(t, ConvertResult{Output: want}, r, to)
+	}
+
+	script := "def transform(value):\n    value[\"b\"] = value[\"a\"] + 1\n    return value\n"
+	results, err = ConvertToManyWithOptions(formatJSON, []string{formatYAML, formatJSON}, `{"a": 1}`, WithScript(script))
+	require.NoError(t, err)
+	require.Equal(t, "a: 1\nb: 2", results[formatYAML].Output)
+	require.JSONEq(t, `{"a": 1, "b": 2}`, results[formatJSON].Output)
+
+	_, err = ConvertToMany(formatYAML, []string{formatJSON}, "a: [")
+	require.Error(t, err)
+	_, err = ConvertToMany("Nope", []string{formatJSON}, "x")
+	require.EqualError(t, err, "unsupported source format: Nope")
+
+	results, err = ConvertToMany(formatYAML, nil, input)
+	require.NoError(t, err)
+	require.Empty(t, results)
+}
+
+func TestConvertToMany_ParsesOnce(t *testing.T) {
+	name := "ConvertToMany Counter"
+	var parses atomic.Int32
+	RegisterFormat(name, FormatAdapter{
+		ToJSON: func(s string) (string, error) {
+			parses.Add(1)
+			return `{"a": 1}`, nil
+		},
+	})
+	t.Cleanup(func() {
+		adaptersMu.Lock()
+		delete(adapters, name)
+		adaptersMu.Unlock()
+	})
+	results, err := ConvertToMany(name, []string{formatJSON, formatYAML, formatTOML, formatXML, formatHCL}, "anything")
+	require.NoError(t, err)
+	require.Len(t, results, 5)
+	require.Equal(t, int32(1), parses.Load())
+	require.Equal(t, "a: 1", results[formatYAML].Output)
+}
@@ -11,7 +11,7 @@ name = "Alice"
 age = 30
 `
 	sampleNestedJSON = `{"user": {"name": "Bob", "age": 42}}`
-	sampleGoStruct = `
+	sampleGoStruct   = `
 type User struct {
 	Name string  ` + "`json:\"name\"`" + `
 	Age  int     ` + "`json:\"age\"`" + `
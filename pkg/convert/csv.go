@@ -0,0 +1,292 @@
+package convert
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/linzeyan/transform-go/pkg/common"
+)
+
+const (
+	CSVHeaderAuto = "auto"
+	CSVHeaderYes  = "yes"
+	CSVHeaderNo   = "no"
+)
+
+// CSVOptions controls CSV parsing and generation. A zero Delimiter is
+// detected from the first line when reading and defaults to ',' when writing.
+// The header written for JSON objects lists keys in the order they first
+// appear across the records, or sorted when SortKeys is set.
+type CSVOptions struct {
+	Delimiter rune
+	Header    string
+	SortKeys  bool
+}
+
+func CSVToJSON(input string) (string, error) {
+	return CSVToJSONWithOptions(input, CSVOptions{Header: CSVHeaderAuto})
+}
+
+func JSONToCSV(input string) (string, error) {
+	return JSONToCSVWithOptions(input, CSVOptions{Header: CSVHeaderYes})
+}
+
+// CSVToJSONWithOptions turns CSV into an array of objects keyed by the header
+// row, or an array of arrays when the input has no header. Fields past the
+// end of the header go under generated names, column4 for the fourth, in
+// every row.
+func CSVToJSONWithOptions(input string, opts CSVOptions) (string, error) {
+	records, err := readCSVRecords(input, opts.Delimiter)
+	if err != nil {
+		return "", err
+	}
+	var header []string
+	switch opts.Header {
+	case CSVHeaderYes:
+		header = records[0]
+	case CSVHeaderNo:
+	default:
+		if looksLikeCSVHeader(records) {
+			header = records[0]
+		}
+	}
+	if header == nil {
+		rows := make([]any, len(records))
+		for i, rec := range records {
+			row := make([]any, len(rec))
+			for j, cell := range rec {
+				row[j] = csvCellValue(cell)
+			}
+			rows[i] = row
+		}
+		return encodeJSON(rows)
+	}
+	rows := make([]map[string]any, 0, len(records)-1)
+	header = slices.Clip(header)
+	for _, rec := range records[1:] {
+		for j := len(header); j < len(rec); j++ {
+			header = append(header, csvExtraColumn(header, j))
+		}
+		obj := make(map[string]any, len(header))
+		for j, name := range header {
+			if j < len(rec) {
+				obj[name] = csvCellValue(rec[j])
+			} else {
+				obj[name] = nil
+			}
+		}
+		rows = append(rows, obj)
+	}
+	return tableJSON(header, rows)
+}
+
+// JSONToCSVWithOptions writes an array of objects (header = union of keys in
+// the order they first appear) or an array of arrays as CSV. Nested values are stored as compact JSON.
+func JSONToCSVWithOptions(input string, opts CSVOptions) (string, error) {
+	data, err := decodeJSONValue(input)
+	if err != nil {
+		return "", err
+	}
+	var items []any
+	switch val := data.(type) {
+	case []any:
+		items = val
+	case map[string]any:
+		items = []any{val}
+	default:
+		return "", errors.New("CSV requires an array of objects or arrays")
+	}
+	buf := &bytes.Buffer{}
+	w := csv.NewWriter(buf)
+	if opts.Delimiter != 0 {
+		w.Comma = opts.Delimiter
+	}
+	header := csvHeaderFor(items, tableKeyOrder(input, data, opts.SortKeys))
+	if header != nil && opts.Header != CSVHeaderNo {
+		if err := w.Write(header); err != nil {
+			return "", err
+		}
+	}
+	for i, item := range items {
+		var record []string
+		switch row := item.(type) {
+		case map[string]any:
+			if header == nil {
+				return "", fmt.Errorf("row %d: cannot mix objects and arrays", i+1)
+			}
+			record = make([]string, len(header))
+			for j, key := range header {
+				record[j] = csvCellString(row[key])
+			}
+		case []any:
+			record = make([]string, len(row))
+			for j, cell := range row {
+				record[j] = csvCellString(cell)
+			}
+		default:
+			record = []string{csvCellString(row)}
+		}
+		if err := w.Write(record); err != nil {
+			return "", err
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return "", err
+	}
+	return strings.TrimRight(buf.String(), "\n"), nil
+}
+
+// csvExtraColumn names the field at index j of rows longer than header,
+// avoiding the names header already has.
+func csvExtraColumn(header []string, j int) string {
+	name := "column" + strconv.Itoa(j+1)
+	for slices.Contains(header, name) {
+		name += "_"
+	}
+	return name
+}
+
+func readCSVRecords(input string, delim rune) ([][]string, error) {
+	if strings.TrimSpace(input) == "" {
+		return nil, errors.New("empty CSV input")
+	}
+	if delim == 0 {
+		delim = detectCSVDelimiter(input)
+	}
+	r := csv.NewReader(strings.NewReader(input))
+	r.Comma = delim
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, errors.New("empty CSV input")
+	}
+	return records, nil
+}
+
+func detectCSVDelimiter(input string) rune {
+	line, _, _ := strings.Cut(input, "\n")
+	best, bestCount := ',', 0
+	for _, candidate := range []rune{',', ';', '\t', '|'} {
+		if n := strings.Count(line, string(candidate)); n > bestCount {
+			best, bestCount = candidate, n
+		}
+	}
+	return best
+}
+
+func looksLikeCSVHeader(records [][]string) bool {
+	if len(records) < 2 {
+		return false
+	}
+	seen := map[string]bool{}
+	for _, cell := range records[0] {
+		cell = strings.TrimSpace(cell)
+		if cell == "" || seen[cell] {
+			return false
+		}
+		if _, isString := csvCellValue(cell).(string); !isString {
+			return false
+		}
+		seen[cell] = true
+	}
+	return true
+}
+
+func csvCellValue(cell string) any {
+	switch cell {
+	case "":
+		return ""
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+	if numberPattern().MatchString(cell) {
+		return json.Number(cell)
+	}
+	return cell
+}
+
+func csvCellString(v any) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case map[string]any, []any:
+		raw, err := json.Marshal(common.NormalizeJSONNumbers(val))
+		if err != nil {
+			return fmt.Sprint(val)
+		}
+		return string(raw)
+	default:
+		return fmt.Sprint(val)
+	}
+}
+
+// csvHeaderFor returns the union of the keys of the objects among items,
+// in the order order lists them and sorted after that.
+func csvHeaderFor(items []any, order []string) []string {
+	union := map[string]any{}
+	for _, item := range items {
+		if obj, ok := item.(map[string]any); ok {
+			for k := range obj {
+				union[k] = nil
+			}
+		}
+	}
+	if len(union) == 0 {
+		return nil
+	}
+	return keysInOrder(union, order)
+}
+
+// tableJSON writes rows as an indented JSON array of objects whose keys
+// follow columns, keeping the first of any repeated column name.
+func tableJSON(columns []string, rows []map[string]any) (string, error) {
+	a := &docArena{}
+	doc := a.node(docArray, docPos{})
+	seen := make(map[string]bool, len(columns))
+	for _, row := range rows {
+		obj := a.node(docObject, docPos{})
+		clear(seen)
+		for _, name := range columns {
+			if seen[name] {
+				continue
+			}
+			seen[name] = true
+			cell, err := docFromValue(a, row[name], "", nil)
+			if err != nil {
+				return "", err
+			}
+			obj.keys = append(obj.keys, docKey{name: name})
+			obj.items = append(obj.items, cell)
+		}
+		doc.items = append(doc.items, obj)
+	}
+	return encodeDoc(doc)
+}
+
+// tableKeyOrder returns the order the keys of the rows of data, decoded
+// from input, first appear in, or nil when sorted is set.
+func tableKeyOrder(input string, data any, sorted bool) []string {
+	if sorted {
+		return nil
+	}
+	path := ""
+	if _, ok := data.([]any); ok {
+		path = "[]"
+	}
+	return jsonKeyOrder(input)[path]
+}
@@ -0,0 +1,91 @@
+package convert
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const sampleCSV = "name,age,active\nAlice,30,true\nBob,41,false\n"
+
+func TestCSVToJSON(t *testing.T) {
+	out, err := CSVToJSON(sampleCSV)
+	require.NoError(t, err)
+	require.Contains(t, out, `"name": "Alice"`)
+	require.Contains(t, out, `"age": 30`)
+	require.Contains(t, out, `"active": false`)
+	require.Contains(t, out, "{\n    \"name\": \"Alice\",\n    \"age\": 30,\n    \"active\": true\n  }")
+
+	noHeader, err := CSVToJSON("1;2\n3;4")
+	require.NoError(t, err)
+	require.JSONEq(t, `[[1,2],[3,4]]`, noHeader)
+
+	forced, err := CSVToJSONWithOptions("a|b\nc|d", CSVOptions{Delimiter: '|', Header: CSVHeaderYes})
+	require.NoError(t, err)
+	require.JSONEq(t, `[{"a":"c","b":"d"}]`, forced)
+
+	// Fields past the header are kept, under generated names.
+	ragged, err := CSVToJSON("name,age\nAlice,30,admin\nBob,41\n")
+	require.NoError(t, err)
+	require.JSONEq(t, `[{"name":"Alice","age":30,"column3":"admin"},{"name":"Bob","age":41,"column3":null}]`, ragged)
+	ragged, err = CSVToJSONWithOptions("a,b,column4\n1,2\n3,4,5,6\n", CSVOptions{Header: CSVHeaderYes})
+	require.NoError(t, err)
+	require.JSONEq(t, `[{"a":1,"b":2,"column4":null,"column4_":null},{"a":3,"b":4,"column4":5,"column4_":6}]`, ragged)
+
+	_, err = CSVToJSON("  ")
+	require.Error(t, err)
+}
+
+func TestJSONToCSV(t *testing.T) {
+	out, err := JSONToCSV(`[{"name":"Alice","tags":["x"]},{"name":"Bob","age":41}]`)
+	require.NoError(t, err)
+	require.Equal(t, "name,tags,age\nAlice,\"[\"\"x\"\"]\",\nBob,,41", out)
+
+	sorted, err := JSONToCSVWithOptions(`[{"name":"Alice","tags":["x"]},{"name":"Bob","age":41}]`, CSVOptions{SortKeys: true})
+	require.NoError(t, err)
+	require.Equal(t, "age,name,tags\n,Alice,\"[\"\"x\"\"]\"\n41,Bob,", sorted)
+
+	tsv, err := JSONToCSVWithOptions(`[[1,"a"],[2,"b"]]`, CSVOptions{Delimiter: '\t'})
+	require.NoError(t, err)
+	require.Equal(t, "1\ta\n2\tb", tsv)
+
+	_, err = JSONToCSV(`"scalar"`)
+	require.Error(t, err)
+}
+
+func TestCSVConvertFormats(t *testing.T) {
+	yamlOut, err := ConvertFormats("CSV", "YAML", sampleCSV)
+	require.NoError(t, err)
+	require.Contains(t, yamlOut, "name: Alice")
+
+	back, err := ConvertFormats("JSON", "CSV", `[{"id":1},{"id":2}]`)
+	require.NoError(t, err)
+	require.Equal(t, "id\n1\n2", back)
+
+	roundTrip, err := ConvertFormats("CSV", "JSON", "b,a\n1,2")
+	require.NoError(t, err)
+	require.Equal(t, "[\n  {\n    \"b\": 1,\n    \"a\": 2\n  }\n]", roundTrip)
+
+	sorted, err := ConvertFormatsWithOptions("JSON", "CSV", `[{"b":1,"a":2}]`, WithSortKeys(true))
+	require.NoError(t, err)
+	require.Equal(t, "a,b\n2,1", sorted)
+}
+
+func Benchmark_CSVToJSON(b *testing.B) {
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = CSVToJSON(sampleCSV)
+	}
+}
+
+func Fuzz_CSVRoundTrip(f *testing.F) {
+	f.Add(sampleCSV)
+	f.Fuzz(func(t *testing.T, input string) {
+		jsonOut, err := CSVToJSON(input)
+		if err != nil {
+			t.Skip()
+		}
+		_, _ = JSONToCSV(jsonOut)
+	})
+}
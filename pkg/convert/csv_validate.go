@@ -0,0 +1,105 @@
+package convert
+
+import (
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// CSVValidationError is a schema violation in one data row (1-based, header
+// excluded). Column is empty for row-level errors.
+type CSVValidationError struct {
+	Row     int    `json:"row"`
+	Column  string `json:"column,omitempty"`
+	Keyword string `json:"keyword"`
+	Message string `json:"message"`
+}
+
+// CSVValidationReport is the result of ValidateCSV.
+type CSVValidationReport struct {
+	Valid  bool                 `json:"valid"`
+	Rows   int                  `json:"rows"`
+	Errors []CSVValidationError `json:"errors"`
+}
+
+// ValidateCSV checks every row of CSV (with a header row) against a JSON
+// Schema describing one row as an object, or an array schema whose items
+// do. Cells are cast to the type their property declares first; empty cells
+// are treated as missing so "required" applies to them.
+func ValidateCSV(input, schema string) (CSVValidationReport, error) {
+	report := CSVValidationReport{Errors: []CSVValidationError{}}
+	root, err := decodeJSONValue(schema)
+	if err != nil {
+		return report, err
+	}
+	rowSchema, ok := root.(map[string]any)
+	if !ok {
+		return report, errors.New("schema must be a JSON object")
+	}
+	if items, ok := rowSchema["items"].(map[string]any); ok && rowSchema["properties"] == nil {
+		rowSchema = items
+	}
+	table, err := readCSVTable(input, 0)
+	if err != nil {
+		return report, err
+	}
+	props, _ := rowSchema["properties"].(map[string]any)
+	report.Rows = len(table.Rows)
+	for i, row := range table.Rows {
+		obj := make(map[string]any, len(row))
+		for _, col := range table.Columns {
+			cell := row[col]
+			if cell == nil || cell == "" {
+				continue
+			}
+			obj[col] = csvCastForSchema(props[col], cell)
+		}
+		for _, e := range validateSchema(rowSchema, obj, "") {
+			column := e.Property
+			if column == "" {
+				column, _, _ = strings.Cut(strings.TrimPrefix(e.Path, "/"), "/")
+				column = strings.NewReplacer("~1", "/", "~0", "~").Replace(column)
+			}
+			report.Errors = append(report.Errors, CSVValidationError{Row: i + 1, Column: column, Keyword: e.Keyword, Message: e.Message})
+		}
+	}
+	report.Valid = len(report.Errors) == 0
+	return report, nil
+}
+
+// csvCastForSchema converts a cell to the type its property schema declares.
+// Cells that do not convert keep their inferred value so the type check
+// reports them.
+func csvCastForSchema(schema, cell any) any {
+	s, _ := schema.(map[string]any)
+	var types []string
+	switch t := s["type"].(type) {
+	case string:
+		types = []string{t}
+	case []any:
+		for _, one := range t {
+			if name, ok := one.(string); ok {
+				types = append(types, name)
+			}
+		}
+	}
+	if len(types) == 0 {
+		return cell
+	}
+	text := csvCellString(cell)
+	if slices.Contains(types, "integer") || slices.Contains(types, "number") {
+		if f, err := exprNumber(text); err == nil {
+			return f
+		}
+	}
+	if slices.Contains(types, "boolean") {
+		if b, err := strconv.ParseBool(strings.TrimSpace(text)); err == nil {
+			return b
+		}
+	}
+	if slices.Contains(types, "string") {
+		return text
+	}
+	return cell
+}
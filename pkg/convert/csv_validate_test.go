@@ -0,0 +1,46 @@
+package convert
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const sampleRowSchema = `{
+	"type": "object",
+	"required": ["id", "email"],
+	"additionalProperties": false,
+	"properties": {
+		"id": {"type": "integer", "minimum": 1},
+		"email": {"type": "string", "format": "email"},
+		"zip": {"type": "string", "pattern": "^[0-9]{5}$"},
+		"active": {"type": "boolean"}
+	}
+}`
+
+func TestValidateCSV(t *testing.T) {
+	report, err := ValidateCSV("id,email,zip,active\n1,a@example.com,01234,true\n2,b@example.com,,false\n", sampleRowSchema)
+	require.NoError(t, err)
+	require.True(t, report.Valid)
+	require.Equal(t, 2, report.Rows)
+	require.Empty(t, report.Errors)
+
+	report, err = ValidateCSV("id,email,zip,active,extra\n0,nope,1234,maybe,x\n,c@example.com,12345,1,\n", sampleRowSchema)
+	require.NoError(t, err)
+	require.False(t, report.Valid)
+	require.Equal(t, []CSVValidationError{
+		{Row: 1, Column: "active", Keyword: "type", Message: "expected boolean, got string"},
+		{Row: 1, Column: "email", Keyword: "format", Message: "must be a valid email"},
+		{Row: 1, Column: "extra", Keyword: "additionalProperties", Message: `property "extra" is not allowed`},
+		{Row: 1, Column: "id", Keyword: "minimum", Message: "must be >= 1"},
+		{Row: 1, Column: "zip", Keyword: "pattern", Message: "must match ^[0-9]{5}$"},
+		{Row: 2, Column: "id", Keyword: "required", Message: `missing required property "id"`},
+	}, report.Errors)
+
+	report, err = ValidateCSV("n\n1.5\n", `{"type":"array","items":{"properties":{"n":{"type":"integer"}}}}`)
+	require.NoError(t, err)
+	require.Equal(t, "expected integer, got number", report.Errors[0].Message)
+
+	_, err = ValidateCSV("a\n1\n", `[]`)
+	require.Error(t, err)
+}
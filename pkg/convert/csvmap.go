@@ -0,0 +1,403 @@
+package convert
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// CSVMapSpec reshapes tabular data. Steps run in a fixed order: split, join,
+// derive, cast, rename, select, drop; so derive and cast see the original
+// column names while select and drop see the renamed ones.
+type CSVMapSpec struct {
+	Split  []CSVSplit        `json:"split,omitempty"`
+	Join   []CSVJoin         `json:"join,omitempty"`
+	Derive []CSVDerive       `json:"derive,omitempty"`
+	Cast   map[string]string `json:"cast,omitempty"`
+	Rename map[string]string `json:"rename,omitempty"`
+	Select []string          `json:"select,omitempty"`
+	Drop   []string          `json:"drop,omitempty"`
+}
+
+// CSVSplit splits Column on Separator into the Into columns. The last
+// column keeps any remaining text.
+type CSVSplit struct {
+	Column    string   `json:"column"`
+	Separator string   `json:"separator"`
+	Into      []string `json:"into"`
+	Keep      bool     `json:"keep,omitempty"`
+}
+
+// CSVJoin joins Columns with Separator into the Into column.
+type CSVJoin struct {
+	Columns   []string `json:"columns"`
+	Separator string   `json:"separator"`
+	Into      string   `json:"into"`
+	Keep      bool     `json:"keep,omitempty"`
+}
+
+// CSVDerive adds (or replaces) column Name with the value of Expr, e.g.
+// "price * qty" or "upper(first) + ' ' + last".
+type CSVDerive struct {
+	Name string `json:"name"`
+	Expr string `json:"expr"`
+}
+
+const (
+	CSVCastString = "string"
+	CSVCastInt    = "int"
+	CSVCastFloat  = "float"
+	CSVCastBool   = "bool"
+)
+
+// csvTable is a header plus rows keyed by column name, keeping column order.
+type csvTable struct {
+	Columns []string
+	Rows    []map[string]any
+}
+
+// MapCSV applies spec (JSON) to CSV input with a header row and returns CSV.
+func MapCSV(input, spec string, opts CSVOptions) (string, error) {
+	table, err := readCSVTable(input, opts.Delimiter)
+	if err != nil {
+		return "", err
+	}
+	if err := table.apply(spec); err != nil {
+		return "", err
+	}
+	return table.csv(opts.Delimiter)
+}
+
+// MapCSVToJSON applies spec to CSV input and returns an array of objects.
+func MapCSVToJSON(input, spec string, opts CSVOptions) (string, error) {
+	table, err := readCSVTable(input, opts.Delimiter)
+	if err != nil {
+		return "", err
+	}
+	if err := table.apply(spec); err != nil {
+		return "", err
+	}
+	return table.json()
+}
+
+// MapJSONToCSV applies spec to a JSON array of objects and returns CSV.
+func MapJSONToCSV(input, spec string, opts CSVOptions) (string, error) {
+	table, err := jsonTable(input, opts.SortKeys)
+	if err != nil {
+		return "", err
+	}
+	if err := table.apply(spec); err != nil {
+		return "", err
+	}
+	return table.csv(opts.Delimiter)
+}
+
+func readCSVTable(input string, delim rune) (*csvTable, error) {
+	records, err := readCSVRecords(input, delim)
+	if err != nil {
+		return nil, err
+	}
+	table := &csvTable{Columns: records[0], Rows: make([]map[string]any, 0, len(records)-1)}
+	for _, rec := range records[1:] {
+		row := make(map[string]any, len(table.Columns))
+		for j, name := range table.Columns {
+			if j < len(rec) {
+				row[name] = csvCellValue(rec[j])
+			} else {
+				row[name] = nil
+			}
+		}
+		table.Rows = append(table.Rows, row)
+	}
+	return table, nil
+}
+
+// jsonTable reads an array of objects; columns are the union of keys in the
+// order they first appear, or sorted when sorted is set.
+func jsonTable(input string, sorted bool) (*csvTable, error) {
+	data, err := decodeJSONValue(input)
+	if err != nil {
+		return nil, err
+	}
+	var items []any
+	switch val := data.(type) {
+	case []any:
+		items = val
+	case map[string]any:
+		items = []any{val}
+	default:
+		return nil, errors.New("expected an array of objects")
+	}
+	table := &csvTable{Columns: csvHeaderFor(items, tableKeyOrder(input, data, sorted)), Rows: make([]map[string]any, 0, len(items))}
+	for i, item := range items {
+		obj, ok := item.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("row %d: expected an object", i+1)
+		}
+		table.Rows = append(table.Rows, obj)
+	}
+	return table, nil
+}
+
+func (t *csvTable) csv(delim rune) (string, error) {
+	buf := &bytes.Buffer{}
+	w := csv.NewWriter(buf)
+	if delim != 0 {
+		w.Comma = delim
+	}
+	if err := w.Write(t.Columns); err != nil {
+		return "", err
+	}
+	for _, row := range t.Rows {
+		record := make([]string, len(t.Columns))
+		for j, name := range t.Columns {
+			record[j] = csvCellString(row[name])
+		}
+		if err := w.Write(record); err != nil {
+			return "", err
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return "", err
+	}
+	return strings.TrimRight(buf.String(), "\n"), nil
+}
+
+func (t *csvTable) json() (string, error) {
+	return tableJSON(t.Columns, t.Rows)
+}
+
+func (t *csvTable) has(name string) bool {
+	return slices.Contains(t.Columns, name)
+}
+
+func (t *csvTable) addColumn(name string) {
+	if !t.has(name) {
+		t.Columns = append(t.Columns, name)
+	}
+}
+
+func (t *csvTable) removeColumn(name string) {
+	t.Columns = slices.DeleteFunc(t.Columns, func(c string) bool { return c == name })
+	for _, row := range t.Rows {
+		delete(row, name)
+	}
+}
+
+func (t *csvTable) require(names ...string) error {
+	for _, name := range names {
+		if !t.has(name) {
+			return fmt.Errorf("unknown column %q", name)
+		}
+	}
+	return nil
+}
+
+func (t *csvTable) apply(raw string) error {
+	var spec CSVMapSpec
+	if strings.TrimSpace(raw) != "" {
+		dec := json.NewDecoder(strings.NewReader(raw))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&spec); err != nil {
+			return fmt.Errorf("invalid mapping spec: %w", err)
+		}
+	}
+	for _, s := range spec.Split {
+		if err := t.split(s); err != nil {
+			return err
+		}
+	}
+	for _, j := range spec.Join {
+		if err := t.join(j); err != nil {
+			return err
+		}
+	}
+	for _, d := range spec.Derive {
+		if err := t.derive(d); err != nil {
+			return err
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(spec.Cast)) {
+		if err := t.cast(name, spec.Cast[name]); err != nil {
+			return err
+		}
+	}
+	if err := t.rename(spec.Rename); err != nil {
+		return err
+	}
+	if len(spec.Select) > 0 {
+		if err := t.require(spec.Select...); err != nil {
+			return err
+		}
+		for _, name := range slices.Clone(t.Columns) {
+			if !slices.Contains(spec.Select, name) {
+				t.removeColumn(name)
+			}
+		}
+		t.Columns = slices.Clone(spec.Select)
+	}
+	if err := t.require(spec.Drop...); err != nil {
+		return err
+	}
+	for _, name := range spec.Drop {
+		t.removeColumn(name)
+	}
+	return nil
+}
+
+func (t *csvTable) split(s CSVSplit) error {
+	if err := t.require(s.Column); err != nil {
+		return err
+	}
+	if s.Separator == "" || len(s.Into) == 0 {
+		return fmt.Errorf("split %q needs a separator and target columns", s.Column)
+	}
+	for _, row := range t.Rows {
+		parts := strings.SplitN(csvCellString(row[s.Column]), s.Separator, len(s.Into))
+		for i, name := range s.Into {
+			if i < len(parts) {
+				row[name] = csvCellValue(strings.TrimSpace(parts[i]))
+			} else {
+				row[name] = nil
+			}
+		}
+	}
+	for _, name := range s.Into {
+		t.addColumn(name)
+	}
+	if !s.Keep && !slices.Contains(s.Into, s.Column) {
+		t.removeColumn(s.Column)
+	}
+	return nil
+}
+
+func (t *csvTable) join(j CSVJoin) error {
+	if err := t.require(j.Columns...); err != nil {
+		return err
+	}
+	if j.Into == "" {
+		return errors.New("join needs a target column")
+	}
+	for _, row := range t.Rows {
+		parts := make([]string, 0, len(j.Columns))
+		for _, name := range j.Columns {
+			if s := csvCellString(row[name]); s != "" {
+				parts = append(parts, s)
+			}
+		}
+		row[j.Into] = strings.Join(parts, j.Separator)
+	}
+	t.addColumn(j.Into)
+	if !j.Keep {
+		for _, name := range j.Columns {
+			if name != j.Into {
+				t.removeColumn(name)
+			}
+		}
+	}
+	return nil
+}
+
+func (t *csvTable) derive(d CSVDerive) error {
+	if d.Name == "" {
+		return errors.New("derive needs a column name")
+	}
+	expr, err := compileExpr(d.Expr)
+	if err != nil {
+		return fmt.Errorf("derive %q: %w", d.Name, err)
+	}
+	for i, row := range t.Rows {
+		v, err := expr.eval(func(name string) any { return row[name] })
+		if err != nil {
+			return fmt.Errorf("derive %q, row %d: %w", d.Name, i+1, err)
+		}
+		if f, ok := v.(float64); ok {
+			v = json.Number(strconv.FormatFloat(f, 'f', -1, 64))
+		}
+		row[d.Name] = v
+	}
+	t.addColumn(d.Name)
+	return nil
+}
+
+func (t *csvTable) cast(name, kind string) error {
+	if err := t.require(name); err != nil {
+		return err
+	}
+	for i, row := range t.Rows {
+		v := row[name]
+		if v == nil || v == "" {
+			row[name] = nil
+			continue
+		}
+		switch strings.ToLower(kind) {
+		case CSVCastString:
+			row[name] = exprText(v)
+		case CSVCastInt, CSVCastFloat:
+			f, err := exprNumber(v)
+			if err != nil {
+				return fmt.Errorf("cast %q, row %d: %w", name, i+1, err)
+			}
+			if strings.EqualFold(kind, CSVCastInt) {
+				row[name] = json.Number(strconv.FormatInt(int64(math.Trunc(f)), 10))
+			} else {
+				row[name] = json.Number(strconv.FormatFloat(f, 'f', -1, 64))
+			}
+		case CSVCastBool:
+			if b, ok := v.(bool); ok {
+				row[name] = b
+				continue
+			}
+			b, err := strconv.ParseBool(strings.TrimSpace(exprText(v)))
+			if err != nil {
+				switch strings.ToLower(strings.TrimSpace(exprText(v))) {
+				case "yes", "y", "on":
+					b = true
+				case "no", "n", "off":
+					b = false
+				default:
+					return fmt.Errorf("cast %q, row %d: %q is not a boolean", name, i+1, exprText(v))
+				}
+			}
+			row[name] = b
+		default:
+			return fmt.Errorf("unsupported cast type %q", kind)
+		}
+	}
+	return nil
+}
+
+func (t *csvTable) rename(names map[string]string) error {
+	if len(names) == 0 {
+		return nil
+	}
+	for _, from := range slices.Sorted(maps.Keys(names)) {
+		if err := t.require(from); err != nil {
+			return err
+		}
+	}
+	for i, col := range t.Columns {
+		if to, ok := names[col]; ok {
+			t.Columns[i] = to
+		}
+	}
+	for _, row := range t.Rows {
+		moved := make(map[string]any, len(names))
+		for from, to := range names {
+			moved[to] = row[from]
+			delete(row, from)
+		}
+		for k, v := range moved {
+			row[k] = v
+		}
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package convert
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const sampleOrdersCSV = "id,name,price,qty,date\n1,Alice Smith,2.5,4,2024-01-02\n2,Bob Jones,10,1,2024-03-04\n"
+
+func TestMapCSV(t *testing.T) {
+	out, err := MapCSV(sampleOrdersCSV, `{
+		"split": [{"column": "name", "separator": " ", "into": ["first", "last"]}],
+		"derive": [{"name": "total", "expr": "price * qty"}, {"name": "label", "expr": "upper(last) + ', ' + first"}],
+		"rename": {"id": "order_id"},
+		"drop": ["date"]
+	}`, CSVOptions{})
+	require.NoError(t, err)
+	require.Equal(t, "order_id,price,qty,first,last,total,label\n1,2.5,4,Alice,Smith,10,\"SMITH, Alice\"\n2,10,1,Bob,Jones,10,\"JONES, Bob\"", out)
+
+	out, err = MapCSV(sampleOrdersCSV, `{
+		"join": [{"columns": ["id", "date"], "separator": "@", "into": "key"}],
+		"select": ["key", "name"]
+	}`, CSVOptions{})
+	require.NoError(t, err)
+	require.Equal(t, "key,name\n1@2024-01-02,Alice Smith\n2@2024-03-04,Bob Jones", out)
+
+	_, err = MapCSV(sampleOrdersCSV, `{"select": ["missing"]}`, CSVOptions{})
+	require.Error(t, err)
+	_, err = MapCSV(sampleOrdersCSV, `{"derive": [{"name": "x", "expr": "price /"}]}`, CSVOptions{})
+	require.Error(t, err)
+	_, err = MapCSV(sampleOrdersCSV, `{"unknown": true}`, CSVOptions{})
+	require.Error(t, err)
+}
+
+func TestMapCSVCast(t *testing.T) {
+	out, err := MapCSVToJSON("id,score,ok\n1,3.7,yes\n2,,no\n", `{"cast": {"id": "string", "score": "int", "ok": "bool"}}`, CSVOptions{})
+	require.NoError(t, err)
+	require.JSONEq(t, `[{"id":"1","score":3,"ok":true},{"id":"2","score":null,"ok":false}]`, out)
+
+	_, err = MapCSVToJSON("a\nx\n", `{"cast": {"a": "float"}}`, CSVOptions{})
+	require.Error(t, err)
+}
+
+func TestMapJSONToCSV(t *testing.T) {
+	out, err := MapJSONToCSV(`[{"b":2,"a":1},{"a":3,"b":4}]`, `{"derive": [{"name": "sum", "expr": "if(a > 2, a + b, 0)"}], "select": ["sum", "a"]}`, CSVOptions{Delimiter: ';'})
+	require.NoError(t, err)
+	require.Equal(t, "sum;a\n0;1\n7;3", out)
+
+	out, err = MapJSONToCSV(`[{"b":2,"a":1},{"c":3}]`, `{}`, CSVOptions{})
+	require.NoError(t, err)
+	require.Equal(t, "b,a,c\n2,1,\n,,3", out)
+}
+
+func TestCompileExpr(t *testing.T) {
+	row := map[string]any{"first name": "Ann", "n": "4"}
+	for expr, want := range map[string]any{
+		"1 + 2 * 3":              7.0,
+		"(1 + 2) * 3":            9.0,
+		"-n % 3":                 -1.0,
+		"[first name] + '!'":     "Ann!",
+		"len([first name]) > 2":  true,
+		"round(10 / 3, 2)":       3.33,
+		`concat(lower("AB"), n)`: "ab4",
+	} {
+		node, err := compileExpr(expr)
+		require.NoError(t, err, expr)
+		got, err := node.eval(func(name string) any { return row[name] })
+		require.NoError(t, err, expr)
+		require.Equal(t, want, got, expr)
+	}
+	for _, expr := range []string{"", "1 +", "nope(1)", "'open", "(1"} {
+		_, err := compileExpr(expr)
+		require.Error(t, err, expr)
+	}
+	node, err := compileExpr("1 / 0")
+	require.NoError(t, err)
+	_, err = node.eval(func(string) any { return nil })
+	require.Error(t, err)
+}
@@ -0,0 +1,293 @@
+package convert
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/linzeyan/transform-go/pkg/common"
+)
+
+var (
+	goStructPattern      = common.LazyRegexp(`(?m)^\s*type\s+\w+\s+struct\s*\{`)
+	protoSyntaxPattern   = common.LazyRegexp(`(?m)^\s*syntax\s*=\s*"proto[23]"`)
+	protoMessagePattern  = common.LazyRegexp(`(?m)^\s*(message|enum|service)\s+\w+\s*\{`)
+	graphQLTypePattern   = common.LazyRegexp(`(?m)^\s*(type|input|interface|enum|union|scalar|schema|extend\s+type)\b\s*\w*\s*(implements\s+[^{]*)?[{=]`)
+	graphQLFieldPattern  = common.LazyRegexp(`(?m)^\s*\w+(\([^)]*\))?\s*:\s*\[?\w+!?\]?!?\s*$`)
+	toonHeaderPattern    = common.LazyRegexp(`(?m)^\s*(?:"(?:[^"\\\n]|\\.)*"|[\w.-]*)\[#?\d+[,|\t]?\](\{[^}]*\})?:`)
+	tomlPattern          = common.LazyRegexp(`(?m)^\s*(\[\[?[\w."' -]+\]\]?|[\w."-]+\s*=\s*\S)`)
+	yamlKeyPattern       = common.LazyRegexp(`(?m)^\s*(- )?[\w"' .-]+:(\s|$)`)
+	regHeaderPattern     = common.LazyRegexp(`^(Windows Registry Editor Version \d+\.\d+|REGEDIT4)`)
+	regKeyPattern        = common.LazyRegexp(`(?m)^\[-?HKEY_[A-Z_]+`)
+	base64OnlyPattern    = common.LazyRegexp(`^[A-Za-z0-9+/\s]+={0,2}$`)
+	jsonSchemaKeyPattern = common.LazyRegexp(`"\$schema"\s*:|"properties"\s*:\s*\{`)
+	dotenvExportPattern  = common.LazyRegexp(`(?m)^export\s+[A-Za-z_]\w*=`)
+	propertiesPattern    = common.LazyRegexp(`(?m)^[\w-]+(\.[\w-]+)+\s*=`)
+)
+
+// FormatCandidate is one possible format for an input with a confidence
+// between 0 and 1.
+type FormatCandidate struct {
+	Format     string  `json:"format"`
+	Confidence float64 `json:"confidence"`
+}
+
+// DetectFormat returns the most likely format name (as accepted by
+// ConvertFormats) and its confidence.
+func DetectFormat(input string) (string, float64, error) {
+	candidates := DetectFormatCandidates(input)
+	if len(candidates) == 0 {
+		return "", 0, errors.New("unable to detect format")
+	}
+	return candidates[0].Format, candidates[0].Confidence, nil
+}
+
+// DetectFormatCandidates scores every known format against input, best
+// first. Formats that do not match at all are omitted.
+func DetectFormatCandidates(input string) []FormatCandidate {
+	trimmed := strings.TrimSpace(strings.TrimPrefix(input, "\uFEFF"))
+	if trimmed == "" {
+		return nil
+	}
+	scores := map[string]float64{}
+	set := func(format string, score float64) {
+		if score > scores[format] {
+			scores[format] = score
+		}
+	}
+
+	switch trimmed[0] {
+	case '{', '[':
+		if json.Valid([]byte(trimmed)) {
+			set(formatJSON, 1)
+			set(formatYAML, 0.4)
+			if trimmed[0] == '{' && jsonSchemaKeyPattern().MatchString(trimmed) {
+				if strings.Contains(trimmed, `"$schema"`) {
+					set(formatSchema, 1)
+					scores[formatJSON] = 0.9
+				} else {
+					set(formatSchema, 0.6)
+				}
+			}
+			if avroRecordRe().MatchString(trimmed) && avroFieldsRe().MatchString(trimmed) {
+				set(formatAvro, 1)
+				scores[formatJSON] = 0.9
+			}
+		} else if isNDJSON(trimmed) {
+			set(formatNDJSON, 0.95)
+		} else {
+			set(formatJSON, 0.3)
+		}
+	case '<':
+		detectXMLFamily(trimmed, set)
+	}
+
+	if strings.HasPrefix(trimmed, "bplist00") || isBase64BinaryPlist(trimmed) {
+		set(formatBPlist, 0.99)
+	}
+	if regHeaderPattern().MatchString(trimmed) {
+		set(formatReg, 0.99)
+	} else if regKeyPattern().MatchString(trimmed) {
+		set(formatReg, 0.8)
+	}
+	if goStructPattern().MatchString(trimmed) {
+		if _, err := parseGoStructDefinitions(trimmed); err == nil {
+			set(formatGoStruct, 0.95)
+		} else {
+			set(formatGoStruct, 0.6)
+		}
+	}
+	if hclBlockRe().MatchString(trimmed) {
+		if _, err := HCLToJSON(trimmed); err == nil {
+			set(formatHCL, 0.95)
+		}
+	}
+	if sqlCreateTableRe().MatchString(trimmed) {
+		set(formatSQL, 0.95)
+	}
+	if protoSyntaxPattern().MatchString(trimmed) {
+		set(formatProtobuf, 0.98)
+	} else if protoMessagePattern().MatchString(trimmed) && !strings.Contains(trimmed, ":") {
+		set(formatProtobuf, 0.85)
+	}
+	if graphQLTypePattern().MatchString(trimmed) && graphQLFieldPattern().MatchString(trimmed) && !goStructPattern().MatchString(trimmed) {
+		set(formatGraphQL, 0.85)
+	}
+	if toonHeaderPattern().MatchString(trimmed) {
+		if _, err := TOONToJSON(trimmed); err == nil {
+			set(formatTOON, 0.9)
+		}
+	}
+	if scores[formatReg] == 0 && tomlPattern().MatchString(trimmed) && strings.Contains(trimmed, "=") {
+		if _, err := TOMLToJSON(trimmed); err == nil {
+			set(formatTOML, 0.9)
+		}
+	}
+	if scores[formatReg] == 0 {
+		detectDotenv(trimmed, set)
+	}
+	if isMarkdownTable(trimmed) {
+		set(formatMarkdownTable, 0.95)
+	}
+	if isQueryString(trimmed) {
+		set(formatQueryString, 0.9)
+	}
+	for name, detect := range registeredDetectors() {
+		set(name, min(detect(trimmed), 1))
+	}
+	if len(scores) == 0 || maxScore(scores) < 0.9 {
+		detectLooseFormats(trimmed, set)
+	}
+
+	out := make([]FormatCandidate, 0, len(scores))
+	for format, score := range scores {
+		out = append(out, FormatCandidate{Format: format, Confidence: score})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Confidence != out[j].Confidence {
+			return out[i].Confidence > out[j].Confidence
+		}
+		return out[i].Format < out[j].Format
+	})
+	return out
+}
+
+func detectXMLFamily(input string, set func(string, float64)) {
+	lower := strings.ToLower(input)
+	switch {
+	case strings.Contains(lower, "<table") && (strings.HasPrefix(lower, "<table") || strings.Contains(lower, "<html") || strings.Contains(lower, "<!doctype html")):
+		set(formatHTMLTable, 0.97)
+		set(formatXML, 0.5)
+	case strings.Contains(lower, "<plist"):
+		set(formatPlist, 0.98)
+		set(formatXML, 0.6)
+	case strings.Contains(lower, "<!doctype properties") || strings.Contains(lower, "<properties"):
+		set(formatPropsXML, 0.97)
+		set(formatXML, 0.6)
+	default:
+		if _, err := XMLToJSON(input); err == nil {
+			set(formatXML, 0.95)
+		} else {
+			set(formatXML, 0.5)
+		}
+	}
+}
+
+// detectLooseFormats scores formats with little syntax of their own, which
+// only matter when nothing stricter matched.
+func detectLooseFormats(input string, set func(string, float64)) {
+	if yamlKeyPattern().MatchString(input) || strings.HasPrefix(input, "- ") || strings.HasPrefix(input, "---") {
+		if out, err := YAMLToJSON(input); err == nil {
+			if s := strings.TrimSpace(out); strings.HasPrefix(s, "{") || strings.HasPrefix(s, "[") {
+				set(formatYAML, 0.75)
+			}
+		}
+	}
+	if propertiesPattern().MatchString(input) && isPropertiesShape(input) {
+		set(formatProperties, 0.8)
+	}
+	if rows := csvShape(input); rows >= 2 {
+		set(formatCSV, 0.7)
+	}
+	if base64OnlyPattern().MatchString(input) && len(input) >= 4 {
+		if _, err := MsgPackToJSON(input); err == nil {
+			set(formatMsgPack, 0.6)
+		}
+	}
+}
+
+// detectDotenv claims NAME=VALUE files that use export or only upper case
+// names, which TOML and .properties files rarely do.
+func detectDotenv(input string, set func(string, float64)) {
+	vars, err := parseDotenv(input)
+	if err != nil || len(vars.keys) == 0 {
+		return
+	}
+	if dotenvExportPattern().MatchString(input) {
+		set(formatDotenv, 0.95)
+		return
+	}
+	for _, k := range vars.keys {
+		if !dotenvUpperRe().MatchString(k.name) {
+			return
+		}
+	}
+	set(formatDotenv, 0.92)
+}
+
+// isQueryString reports whether input is one key=value pair or more
+// joined by & or following a "?", without any whitespace.
+func isQueryString(input string) bool {
+	if strings.ContainsAny(input, " \t\r\n") || !strings.Contains(input, "=") || !strings.ContainsAny(input, "&?") {
+		return false
+	}
+	_, err := QueryStringToJSON(input)
+	return err == nil
+}
+
+// isPropertiesShape reports whether every line of input is blank, a
+// comment, a continuation or a key followed by = or :.
+func isPropertiesShape(input string) bool {
+	continued := false
+	for _, line := range strings.Split(input, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case continued:
+			continued = continuesProperty(line)
+		case line == "" || line[0] == '#' || line[0] == '!':
+		case !strings.ContainsAny(line, "=:"):
+			return false
+		default:
+			continued = continuesProperty(line)
+		}
+	}
+	return true
+}
+
+// csvShape returns the number of records when input parses as CSV with at
+// least two columns and a consistent column count.
+func csvShape(input string) int {
+	r := csv.NewReader(strings.NewReader(input))
+	records, err := r.ReadAll()
+	if err != nil || len(records) == 0 || len(records[0]) < 2 {
+		return 0
+	}
+	return len(records)
+}
+
+func isNDJSON(input string) bool {
+	lines := 0
+	for _, line := range strings.Split(input, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if !json.Valid([]byte(line)) {
+			return false
+		}
+		lines++
+	}
+	return lines >= 2
+}
+
+func isBase64BinaryPlist(input string) bool {
+	if !base64OnlyPattern().MatchString(input) {
+		return false
+	}
+	raw, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(input), ""))
+	return err == nil && bytes.HasPrefix(raw, []byte("bplist00"))
+}
+
+func maxScore(scores map[string]float64) float64 {
+	best := 0.0
+	for _, s := range scores {
+		if s > best {
+			best = s
+		}
+	}
+	return best
+}
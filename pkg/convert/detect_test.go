@@ -0,0 +1,54 @@
+package convert
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectFormat(t *testing.T) {
+	cases := map[string]string{
+		`{"name": "Ricky", "age": 27}`:                                                formatJSON,
+		"{\"a\":1}\n{\"a\":2}\n":                                                      formatNDJSON,
+		"name: Ricky\nage: 27\ntags:\n  - a\n":                                        formatYAML,
+		"title = \"demo\"\n\n[owner]\nname = \"Tom\"\n":                               formatTOML,
+		"<root>\n  <name>Ricky</name>\n</root>":                                       formatXML,
+		"type User struct {\n\tName string `json:\"name\"`\n}":                        formatGoStruct,
+		"syntax = \"proto3\";\nmessage User {\n  string name = 1;\n}":                 formatProtobuf,
+		"type User {\n  id: ID!\n  name: String\n}":                                   formatGraphQL,
+		"users[2]{id,name}:\n  1,Alice\n  2,Bob":                                      formatTOON,
+		"gaRuYW1lpVJpY2t5":                                                            formatMsgPack,
+		"name,age\nRicky,27\nTom,30":                                                  formatCSV,
+		"<?xml version=\"1.0\"?>\n<plist version=\"1.0\"><dict/></plist>":             formatPlist,
+		"<properties><entry key=\"a\">1</entry></properties>":                         formatPropsXML,
+		"Windows Registry Editor Version 5.00\r\n\r\n[HKEY_CURRENT_USER\\Foo]\r\n":    formatReg,
+		`{"$schema": "http://json-schema.org/draft-07/schema#", "type": "object"}`:    formatSchema,
+		"provider \"aws\" {\n  region = \"us-east-1\"\n}":                             formatHCL,
+		"# app\nDB_HOST=localhost\nDB_PASS=\"s3cr\\$t\"\n":                            formatDotenv,
+		"export PATH=/usr/bin\nlevel=debug\n":                                         formatDotenv,
+		"?q=go+structs&page=2&tags[]=a":                                               formatQueryString,
+		"https://example.com/search?q=%E2%9C%93&sort=asc":                             formatQueryString,
+		"<table><tr><th>id</th></tr><tr><td>1</td></tr></table>":                      formatHTMLTable,
+		"<!DOCTYPE html><html><body><table><tr><td>1</td></tr></table></body></html>": formatHTMLTable,
+		"| id | name |\n| --: | :--- |\n| 1 | alpha |":                                formatMarkdownTable,
+		"# app\nserver.port=8080\nspring.datasource.url=jdbc:h2:mem:db\n":             formatProperties,
+	}
+	for input, want := range cases {
+		got, confidence, err := DetectFormat(input)
+		require.NoError(t, err, input)
+		require.Equal(t, want, got, input)
+		require.Greater(t, confidence, 0.5, input)
+	}
+}
+
+func TestDetectFormatCandidates(t *testing.T) {
+	candidates := DetectFormatCandidates(`{"a": 1}`)
+	require.Equal(t, formatJSON, candidates[0].Format)
+	require.Equal(t, 1.0, candidates[0].Confidence)
+	require.Equal(t, formatYAML, candidates[1].Format)
+
+	_, _, err := DetectFormat("   ")
+	require.Error(t, err)
+	_, _, err = DetectFormat("just some words")
+	require.Error(t, err)
+}
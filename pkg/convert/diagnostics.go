@@ -0,0 +1,121 @@
+package convert
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/linzeyan/transform-go/pkg/common"
+)
+
+// diagnosticLineRe finds the position parsers put in their messages, as in
+// "line 3, column 7: ..." or "yaml: line 3: ...".
+var diagnosticLineRe = common.LazyRegexp(`\bline (\d+)(?:, column (\d+))?`)
+
+// Diagnostic is one problem in a document, placed for an editor. Line and
+// Column are 1-based, and 0 when the problem has no known position. Source
+// is "parse" for a document that does not parse and "schema" for a schema
+// violation, whose Path is a JSON Pointer to the offending value.
+type Diagnostic struct {
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+	Source  string `json:"source"`
+	Message string `json:"message"`
+	Path    string `json:"path,omitempty"`
+}
+
+// Diagnose parses input as format, or the detected format when format is
+// empty, and checks it against schema when one is given. Problems with the
+// document come back as diagnostics, an empty list when there are none;
+// the error is for unusable arguments, such as an unknown format or a
+// schema that does not parse.
+func Diagnose(format, input, schema string) ([]Diagnostic, error) {
+	diags := []Diagnostic{}
+	if strings.TrimSpace(input) == "" {
+		return diags, nil
+	}
+	if format == "" {
+		var err error
+		if format, _, err = DetectFormat(input); err != nil {
+			return nil, err
+		}
+	}
+	if _, ok := lookupAdapter(format); !ok && format != formatJSON {
+		return nil, errors.New("unsupported source format: " + format)
+	}
+	mid, err := readToJSON(format, input, NewConvertOptions())
+	if err == nil && format == formatJSON {
+		_, err = parseJSONDoc(input)
+	}
+	if err != nil {
+		d := Diagnostic{Source: "parse", Message: err.Error()}
+		var syntaxErr *SyntaxError
+		if errors.As(withPosition(format, input, err), &syntaxErr) {
+			d.Line, d.Column = syntaxErr.Line, syntaxErr.Column
+		} else if m := diagnosticLineRe().FindStringSubmatch(d.Message); m != nil {
+			d.Line, _ = strconv.Atoi(m[1])
+			d.Column, _ = strconv.Atoi(m[2])
+		}
+		return append(diags, d), nil
+	}
+	if schema == "" {
+		return diags, nil
+	}
+	errs, err := ValidateAgainstSchema(schema, mid)
+	if err != nil {
+		return nil, err
+	}
+	doc := positionedDoc(format, input)
+	for _, e := range errs {
+		d := Diagnostic{Source: "schema", Message: e.Message, Path: e.Path}
+		if n := docAtPointer(doc, e.Path); n != nil {
+			d.Line, d.Column = n.pos.line, n.pos.column
+		}
+		diags = append(diags, d)
+	}
+	return diags, nil
+}
+
+// positionedDoc parses input again into the document model for the formats
+// whose parsers record positions, JSON and YAML, and returns nil for the
+// rest.
+func positionedDoc(format, input string) *docNode {
+	switch format {
+	case formatJSON:
+		doc, _ := parseJSONDoc(input)
+		return doc
+	case formatYAML:
+		var arena docArena
+		doc, _ := docFromYAMLStream(&arena, input, NewConvertOptions())
+		return doc
+	}
+	return nil
+}
+
+// docAtPointer finds the node an RFC 6901 pointer names, or nil.
+func docAtPointer(n *docNode, pointer string) *docNode {
+	if n == nil || pointer == "" {
+		return n
+	}
+	for _, part := range strings.Split(strings.TrimPrefix(pointer, "/"), "/") {
+		part = strings.ReplaceAll(strings.ReplaceAll(part, "~1", "/"), "~0", "~")
+		var next *docNode
+		switch n.kind {
+		case docObject:
+			for i, k := range n.keys {
+				if k.name == part {
+					next = n.items[i]
+				}
+			}
+		case docArray:
+			if i, err := strconv.Atoi(part); err == nil && i >= 0 && i < len(n.items) {
+				next = n.items[i]
+			}
+		}
+		if next == nil {
+			return nil
+		}
+		n = next
+	}
+	return n
+}
@@ -0,0 +1,50 @@
+package convert
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiagnose(t *testing.T) {
+	for _, c := range []struct {
+		format, input string
+		want          Diagnostic
+	}{
+		{formatJSON, "{\n  \"a\": 1,\n  \"b\": ]\n}", Diagnostic{Line: 3, Column: 8, Source: "parse", Message: `line 3, column 8: unexpected ']' looking for a value`}},
+		{formatYAML, "a: 1\nb: [\n", Diagnostic{Line: 2, Source: "parse", Message: "yaml: line 2: did not find expected node content"}},
+		{formatTOML, "a = 1\nb = \n", Diagnostic{Line: 2, Column: 5, Source: "parse", Message: "toml: incomplete number"}},
+	} {
+		diags, err := Diagnose(c.format, c.input, "")
+		require.NoError(t, err, c.format)
+		require.Equal(t, []Diagnostic{c.want}, diags, c.format)
+	}
+
+	diags, err := Diagnose("", "name: demo\n", "")
+	require.NoError(t, err)
+	require.Empty(t, diags)
+	require.NotNil(t, diags)
+
+	const schema = `{"type": "object", "required": ["name"], "properties": {
+		"ports": {"type": "array", "items": {"type": "integer"}}}}`
+	diags, err = Diagnose(formatYAML, "ports:\n  - 80\n  - http\n", schema)
+	require.NoError(t, err)
+	require.Len(t, diags, 2)
+	require.Equal(t, Diagnostic{Line: 1, Column: 1, Source: "schema", Message: diags[0].Message}, diags[0])
+	require.Equal(t, Diagnostic{Line: 3, Column: 5, Source: "schema", Message: diags[1].Message, Path: "/ports/1"}, diags[1])
+	require.Contains(t, diags[0].Message, "name")
+
+	diags, err = Diagnose(formatJSON, "{\"ports\": [\"x\"], \"name\": 1}", schema)
+	require.NoError(t, err)
+	require.Equal(t, []Diagnostic{{Line: 1, Column: 12, Source: "schema", Message: diags[0].Message, Path: "/ports/0"}}, diags)
+
+	// Formats without positions still report the violation.
+	diags, err = Diagnose(formatTOML, "ports = [\"x\"]\nname = \"a\"\n", schema)
+	require.NoError(t, err)
+	require.Equal(t, []Diagnostic{{Source: "schema", Message: diags[0].Message, Path: "/ports/0"}}, diags)
+
+	_, err = Diagnose("Nope", "x", "")
+	require.EqualError(t, err, "unsupported source format: Nope")
+	_, err = Diagnose(formatJSON, "{}", "{")
+	require.Error(t, err)
+}
@@ -0,0 +1,236 @@
+package convert
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// DocumentChange is one difference DiffDocuments found: Kind is "added",
+// "removed" or "changed" and Path the JSON Pointer of the value. From is
+// the old value and To the new one, as JSON; an added value has no From
+// and a removed one no To.
+type DocumentChange struct {
+	Kind string          `json:"kind"`
+	Path string          `json:"path"`
+	From json.RawMessage `json:"from,omitempty"`
+	To   json.RawMessage `json:"to,omitempty"`
+}
+
+// DiffDocuments compares two documents in any formats ConvertFormats reads,
+// so a YAML file can be checked against its TOML or JSON counterpart. Both
+// are read into JSON; objects are compared by key, arrays by index and
+// numbers by value. An empty format is detected from the input.
+func DiffDocuments(formatA, inputA, formatB, inputB string) ([]DocumentChange, error) {
+	_, a, err := readDocumentNode(formatA, inputA)
+	if err != nil {
+		return nil, fmt.Errorf("a: %w", err)
+	}
+	_, b, err := readDocumentNode(formatB, inputB)
+	if err != nil {
+		return nil, fmt.Errorf("b: %w", err)
+	}
+	changes := []DocumentChange{}
+	for _, c := range diffDocNodes(a, b, "", nil) {
+		change := DocumentChange{Kind: c.Kind, Path: c.Path}
+		if c.from != nil {
+			change.From = c.from.appendJSON(nil)
+		}
+		if c.to != nil {
+			change.To = c.to.appendJSON(nil)
+		}
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
+
+// UnifiedDiff reads two documents like DiffDocuments and renders the
+// difference as a unified diff of their indented JSON, with keys sorted so
+// that only content shows, and context lines of context around each change.
+// Documents with the same content give "".
+func UnifiedDiff(formatA, inputA, formatB, inputB string, context int) (string, error) {
+	var lines [2][]string
+	var formats [2]string
+	for i, in := range [2][2]string{{formatA, inputA}, {formatB, inputB}} {
+		format, doc, err := readDocumentNode(in[0], in[1])
+		if err != nil {
+			return "", fmt.Errorf("%c: %w", 'a'+i, err)
+		}
+		doc.sortKeys(sortedNames)
+		out, err := docToJSON(doc, "  ")
+		if err != nil {
+			return "", err
+		}
+		formats[i], lines[i] = format, strings.Split(out, "\n")
+	}
+	edits := diffLines(lines[0], lines[1])
+	if !slices.ContainsFunc(edits, func(e lineEdit) bool { return e.op != ' ' }) {
+		return "", nil
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- a (%s)\n+++ b (%s)\n", formats[0], formats[1])
+	writeHunks(&b, edits, max(context, 0))
+	return b.String(), nil
+}
+
+// readDocumentNode reads input in format, or in the format DetectFormat
+// finds when format is empty, and returns the format and the document.
+func readDocumentNode(format, input string) (string, *docNode, error) {
+	if format == "" {
+		return decodeDocumentNode(input)
+	}
+	mid, err := readToJSON(format, input, NewConvertOptions())
+	if err != nil {
+		return "", nil, withPosition(format, input, err)
+	}
+	doc, err := parseJSONDoc(mid)
+	if err != nil {
+		return "", nil, withPosition(format, input, err)
+	}
+	return format, doc, nil
+}
+
+// lineEdit is one line of a line diff: op is ' ' for a line both sides
+// share, '-' for one only in a and '+' for one only in b.
+type lineEdit struct {
+	op   byte
+	text string
+}
+
+// diffLines returns the shortest edit script from a to b, found with
+// Myers' O(ND) algorithm after the common prefix and suffix are set aside.
+func diffLines(a, b []string) []lineEdit {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	edits := make([]lineEdit, 0, len(a)+len(b))
+	for _, line := range a[:prefix] {
+		edits = append(edits, lineEdit{' ', line})
+	}
+	edits = append(edits, myers(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, line := range a[len(a)-suffix:] {
+		edits = append(edits, lineEdit{' ', line})
+	}
+	return edits
+}
+
+func myers(a, b []string) []lineEdit {
+	n, m := len(a), len(b)
+	offset := n + m + 1
+	v := make([]int, 2*offset+1)
+	// trace[d] keeps v[k] for k in [-d-1, d+1] as round d found it.
+	var trace [][]int
+	for d := 0; d <= n+m; d++ {
+		done := false
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || k != d && v[offset+k-1] < v[offset+k+1] {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				done = true
+				break
+			}
+		}
+		trace = append(trace, slices.Clone(v[offset-d-1:offset+d+2]))
+		if done {
+			break
+		}
+	}
+
+	var edits []lineEdit
+	x, y := n, m
+	for d := len(trace) - 1; d > 0; d-- {
+		prev := trace[d-1] // v[k] is prev[k+d]
+		k := x - y
+		prevK := k - 1
+		if k == -d || k != d && prev[k-1+d] < prev[k+1+d] {
+			prevK = k + 1
+		}
+		prevX := prev[prevK+d]
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			edits = append(edits, lineEdit{' ', a[x-1]})
+			x, y = x-1, y-1
+		}
+		if x == prevX {
+			edits = append(edits, lineEdit{'+', b[y-1]})
+			y--
+		} else {
+			edits = append(edits, lineEdit{'-', a[x-1]})
+			x--
+		}
+	}
+	for x > 0 && y > 0 {
+		edits = append(edits, lineEdit{' ', a[x-1]})
+		x, y = x-1, y-1
+	}
+	slices.Reverse(edits)
+	return edits
+}
+
+// writeHunks writes edits as unified diff hunks, joining changes that are
+// at most 2*context lines apart.
+func writeHunks(b *strings.Builder, edits []lineEdit, context int) {
+	// lineA[i] and lineB[i] count the lines of a and b before edits[i].
+	lineA := make([]int, len(edits)+1)
+	lineB := make([]int, len(edits)+1)
+	for i, e := range edits {
+		lineA[i+1], lineB[i+1] = lineA[i], lineB[i]
+		if e.op != '+' {
+			lineA[i+1]++
+		}
+		if e.op != '-' {
+			lineB[i+1]++
+		}
+	}
+	for i := 0; i < len(edits); {
+		for i < len(edits) && edits[i].op == ' ' {
+			i++
+		}
+		if i == len(edits) {
+			break
+		}
+		start, last := max(i-context, 0), i
+		for j := i; j < len(edits) && j-last <= 2*context+1; j++ {
+			if edits[j].op != ' ' {
+				last = j
+			}
+		}
+		stop := min(last+context+1, len(edits))
+		fmt.Fprintf(b, "@@ -%s +%s @@\n", hunkRange(lineA[start], lineA[stop]), hunkRange(lineB[start], lineB[stop]))
+		for _, e := range edits[start:stop] {
+			b.WriteByte(e.op)
+			b.WriteString(e.text)
+			b.WriteByte('\n')
+		}
+		i = stop
+	}
+}
+
+// hunkRange formats the lines after from up to to as a hunk header range:
+// the first line and, unless it is 1, the count; an empty range names the
+// line before it.
+func hunkRange(from, to int) string {
+	switch to - from {
+	case 0:
+		return fmt.Sprintf("%d,0", from)
+	case 1:
+		return fmt.Sprint(from + 1)
+	}
+	return fmt.Sprintf("%d,%d", from+1, to-from)
+}
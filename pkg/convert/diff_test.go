@@ -0,0 +1,122 @@
+package convert
+
+import (
+	"encoding/json"
+	"errors"
+	"math/rand/v2"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffDocuments(t *testing.T) {
+	yamlDoc := "name: demo\nport: 8080\ntags: [a, b]\ndb:\n  host: localhost\n  user: admin\n"
+	tomlDoc := "name = \"demo\"\nport = 8080.0\ntags = [\"a\"]\ndebug = true\n[db]\nhost = \"db.internal\"\nuser = \"admin\"\n"
+
+	changes, err := DiffDocuments(formatYAML, yamlDoc, formatTOML, tomlDoc)
+	require.NoError(t, err)
+	got, err := json.Marshal(changes)
+	require.NoError(t, err)
+	require.JSONEq(t, `[
+		{"kind": "removed", "path": "/tags/1", "from": "b"},
+		{"kind": "changed", "path": "/db/host", "from": "localhost", "to": "db.internal"},
+		{"kind": "added", "path": "/debug", "to": true}
+	]`, string(got))
+
+	changes, err = DiffDocuments("", `{"a": {"b": [1, 2]}}`, formatYAML, "a:\n  b: [1, 2]\n")
+	require.NoError(t, err)
+	require.Empty(t, changes)
+	require.NotNil(t, changes)
+
+	changes, err = DiffDocuments(formatJSON, `{"a": null}`, formatJSON, `{"a": {"x": 1}}`)
+	require.NoError(t, err)
+	require.Equal(t, []DocumentChange{{Kind: "changed", Path: "/a", From: json.RawMessage(`null`), To: json.RawMessage(`{"x":1}`)}}, changes)
+
+	_, err = DiffDocuments(formatJSON, "{\n  \"a\": 1,,\n}", formatJSON, `{}`)
+	var syntaxErr *SyntaxError
+	require.True(t, errors.As(err, &syntaxErr), "%v", err)
+	require.Equal(t, 2, syntaxErr.Line)
+	require.ErrorContains(t, err, "a: ")
+	_, err = DiffDocuments(formatJSON, `{}`, "Nope", `x`)
+	require.ErrorContains(t, err, "b: ")
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	out, err := UnifiedDiff(formatYAML, "b: 2\na: 1\nc: [x, y]\n", formatJSON, `{"a": 1, "b": 3, "c": ["x", "y", "z"]}`, 1)
+	require.NoError(t, err)
+	require.Equal(t, `--- a (YAML)
++++ b (JSON)
+@@ -2,6 +2,7 @@
+   "a": 1,
+-  "b": 2,
++  "b": 3,
+   "c": [
+     "x",
+-    "y"
++    "y",
++    "z"
+   ]
+`, out)
+
+	out, err = UnifiedDiff("", "a: 1\nb: 2\n", formatJSON, `{"b": 2, "a": 1}`, 3)
+	require.NoError(t, err)
+	require.Empty(t, out)
+
+	// Changes far apart get their own hunks.
+	var a, b []string
+	for i := range 20 {
+		a = append(a, `"k`+string(rune('a'+i))+`": 1`)
+		b = append(b, `"k`+string(rune('a'+i))+`": 1`)
+	}
+	b[1], b[18] = `"kb": 2`, `"ks": 2`
+	out, err = UnifiedDiff(formatJSON, "{"+strings.Join(a, ",")+"}", formatJSON, "{"+strings.Join(b, ",")+"}", 1)
+	require.NoError(t, err)
+	require.Equal(t, 2, strings.Count(out, "@@ -"), out)
+	require.Contains(t, out, "@@ -2,3 +2,3 @@\n   \"ka\": 1,\n-  \"kb\": 1,\n+  \"kb\": 2,\n   \"kc\": 1,\n@@ -19,3 +19,3 @@\n")
+}
+
+func TestDiffLines(t *testing.T) {
+	r := rand.New(rand.NewPCG(1, 2))
+	lines := func() []string {
+		out := make([]string, r.IntN(12))
+		for i := range out {
+			out[i] = string(rune('a' + r.IntN(4)))
+		}
+		return out
+	}
+	for range 500 {
+		a, b := lines(), lines()
+		var gotA, gotB []string
+		kept := 0
+		for _, e := range diffLines(a, b) {
+			if e.op != '+' {
+				gotA = append(gotA, e.text)
+			}
+			if e.op != '-' {
+				gotB = append(gotB, e.text)
+			}
+			if e.op == ' ' {
+				kept++
+			}
+		}
+		require.Equal(t, strings.Join(a, ","), strings.Join(gotA, ","), "%q %q", a, b)
+		require.Equal(t, strings.Join(b, ","), strings.Join(gotB, ","), "%q %q", a, b)
+
+		// The script is shortest when it keeps a longest common subsequence.
+		lcs := make([][]int, len(a)+1)
+		for i := range lcs {
+			lcs[i] = make([]int, len(b)+1)
+		}
+		for i := len(a) - 1; i >= 0; i-- {
+			for j := len(b) - 1; j >= 0; j-- {
+				if a[i] == b[j] {
+					lcs[i][j] = lcs[i+1][j+1] + 1
+				} else {
+					lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+				}
+			}
+		}
+		require.Equal(t, lcs[0][0], kept, "%q %q", a, b)
+	}
+}
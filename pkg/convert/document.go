@@ -0,0 +1,779 @@
+package convert
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// docKind is the type of a docNode.
+type docKind uint8
+
+const (
+	docNull docKind = iota
+	docBool
+	docNumber
+	docString
+	docArray
+	docObject
+)
+
+// docPos is a 1-based line and column (in bytes) in the source; the zero
+// value means the position is unknown.
+type docPos struct{ line, column int }
+
+// docNode is one value of the document model the converters share instead
+// of map[string]any: objects keep their key order, scalars keep their type
+// and source text, and every node remembers where it came from and the
+// comments attached to it.
+type docNode struct {
+	kind    docKind
+	text    string     // the string, the number literal, or "true"/"false"
+	items   []*docNode // array elements, or object values parallel to keys
+	keys    []docKey
+	pos     docPos
+	comment docComments
+}
+
+// docKey is an object key; comments written before a field attach to it.
+type docKey struct {
+	name    string
+	pos     docPos
+	comment docComments
+}
+
+// docComments are the comment lines before a node, the comment after it on
+// its own line and the lines after it. blank is set when formatting finds
+// a blank line before the node and its head comment in the source.
+type docComments struct {
+	head, line, foot string
+	blank            bool
+}
+
+// docArena hands out nodes, and the key and element slices of objects and
+// arrays, from shared blocks, so a parsed document costs a few allocations
+// instead of several per value.
+type docArena struct {
+	block []docNode
+	keys  []docKey
+	items []*docNode
+}
+
+const docArenaBlock = 256
+
+func (a *docArena) node(kind docKind, pos docPos) *docNode {
+	if len(a.block) == cap(a.block) {
+		a.block = make([]docNode, 0, docArenaBlock)
+	}
+	a.block = append(a.block, docNode{kind: kind, pos: pos})
+	return &a.block[len(a.block)-1]
+}
+
+// keySlice copies keys into the arena. The result is capped at its length,
+// so appending to it reallocates instead of overwriting its neighbour.
+func (a *docArena) keySlice(keys []docKey) []docKey {
+	if len(keys) == 0 {
+		return nil
+	}
+	if cap(a.keys)-len(a.keys) < len(keys) {
+		a.keys = make([]docKey, 0, max(docArenaBlock, len(keys)))
+	}
+	start := len(a.keys)
+	a.keys = append(a.keys, keys...)
+	return a.keys[start:len(a.keys):len(a.keys)]
+}
+
+// itemSlice copies items into the arena, like keySlice.
+func (a *docArena) itemSlice(items []*docNode) []*docNode {
+	if len(items) == 0 {
+		return nil
+	}
+	if cap(a.items)-len(a.items) < len(items) {
+		a.items = make([]*docNode, 0, max(docArenaBlock, len(items)))
+	}
+	start := len(a.items)
+	a.items = append(a.items, items...)
+	return a.items[start:len(a.items):len(a.items)]
+}
+
+// value converts n to the generic form the encoders take, with numbers as
+// json.Number like decodeJSONValue.
+func (n *docNode) value() any {
+	switch n.kind {
+	case docBool:
+		return n.text == "true"
+	case docNumber:
+		return json.Number(n.text)
+	case docString:
+		return n.text
+	case docArray:
+		out := make([]any, len(n.items))
+		for i, item := range n.items {
+			out[i] = item.value()
+		}
+		return out
+	case docObject:
+		out := make(map[string]any, len(n.keys))
+		for i, k := range n.keys {
+			out[k.name] = n.items[i].value()
+		}
+		return out
+	}
+	return nil
+}
+
+// docToJSON writes n as JSON indented by indent, with objects in key
+// order; strings are escaped as encoding/json escapes them without HTML
+// escaping.
+func docToJSON(n *docNode, indent string) (string, error) {
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, n.appendJSON(nil), "", indent); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// encodeDoc writes n as encodeJSON writes a value: indented by two spaces
+// and ending in a newline.
+func encodeDoc(n *docNode) (string, error) {
+	out, err := docToJSON(n, "  ")
+	if err != nil {
+		return "", err
+	}
+	return out + "\n", nil
+}
+
+func (n *docNode) appendJSON(b []byte) []byte {
+	switch n.kind {
+	case docBool, docNumber:
+		return append(b, n.text...)
+	case docString:
+		return appendJSONString(b, n.text)
+	case docArray:
+		b = append(b, '[')
+		for i, item := range n.items {
+			if i > 0 {
+				b = append(b, ',')
+			}
+			b = item.appendJSON(b)
+		}
+		return append(b, ']')
+	case docObject:
+		b = append(b, '{')
+		for i, k := range n.keys {
+			if i > 0 {
+				b = append(b, ',')
+			}
+			b = appendJSONString(b, k.name)
+			b = append(b, ':')
+			b = n.items[i].appendJSON(b)
+		}
+		return append(b, '}')
+	}
+	return append(b, "null"...)
+}
+
+func appendJSONString(b []byte, s string) []byte {
+	const hex = "0123456789abcdef"
+	b = append(b, '"')
+	for _, r := range s {
+		switch {
+		case r == '"' || r == '\\':
+			b = append(b, '\\', byte(r))
+		case r == '\n':
+			b = append(b, `\n`...)
+		case r == '\r':
+			b = append(b, `\r`...)
+		case r == '\t':
+			b = append(b, `\t`...)
+		case r == '\b':
+			b = append(b, `\b`...)
+		case r == '\f':
+			b = append(b, `\f`...)
+		case r < 0x20:
+			b = append(b, '\\', 'u', '0', '0', hex[r>>4], hex[r&0xf])
+		case r == '\u2028' || r == '\u2029':
+			b = append(b, '\\', 'u', '2', '0', '2', hex[r&0xf])
+		default:
+			b = utf8.AppendRune(b, r)
+		}
+	}
+	return append(b, '"')
+}
+
+// docFromValue builds the document model for a decoded value, putting the
+// keys of each object in the order order lists for its path, as
+// orderPath spells paths; keys it does not list follow in sorted order.
+func docFromValue(a *docArena, v any, path string, order map[string][]string) (*docNode, error) {
+	switch val := v.(type) {
+	case map[string]any:
+		doc := a.node(docObject, docPos{})
+		seen := make(map[string]bool, len(val))
+		add := func(name string) error {
+			child, err := docFromValue(a, val[name], orderPath(path, name), order)
+			if err != nil {
+				return err
+			}
+			seen[name] = true
+			doc.keys = append(doc.keys, docKey{name: name})
+			doc.items = append(doc.items, child)
+			return nil
+		}
+		for _, name := range order[path] {
+			if _, ok := val[name]; ok && !seen[name] {
+				if err := add(name); err != nil {
+					return nil, err
+				}
+			}
+		}
+		rest := make([]string, 0, len(val)-len(doc.keys))
+		for name := range val {
+			if !seen[name] {
+				rest = append(rest, name)
+			}
+		}
+		sort.Strings(rest)
+		for _, name := range rest {
+			if err := add(name); err != nil {
+				return nil, err
+			}
+		}
+		return doc, nil
+	case []any:
+		doc := a.node(docArray, docPos{})
+		for i, item := range val {
+			child, err := docFromValue(a, item, orderIndex(path, i), order)
+			if err != nil {
+				return nil, err
+			}
+			doc.items = append(doc.items, child)
+		}
+		return doc, nil
+	}
+	return docScalar(a, v, docPos{})
+}
+
+// orderPath and orderIndex spell the paths docFromValue looks key orders
+// up by: the key or index of each step after its parent's path, apart
+// so that no key name can run into another.
+func orderPath(path, key string) string {
+	return path + "\x00" + key
+}
+
+func orderIndex(path string, i int) string {
+	return path + "[" + strconv.Itoa(i) + "]"
+}
+
+// keyOrder lists the key order of every object by path ("" for the root,
+// "a.b" for nested objects and "a[]" for array elements), merging the keys
+// of all elements of an array in first-seen order.
+func (n *docNode) keyOrder() map[string][]string {
+	order := map[string][]string{}
+	// children and elems hold the paths of the keys and elements below each
+	// path, so that the records of a long array do not build them again.
+	children := map[string]map[string]string{}
+	elems := map[string]string{}
+	var walk func(n *docNode, path string)
+	walk = func(n *docNode, path string) {
+		switch n.kind {
+		case docArray:
+			elem, ok := elems[path]
+			if !ok {
+				elem = path + "[]"
+				elems[path] = elem
+			}
+			for _, item := range n.items {
+				walk(item, elem)
+			}
+		case docObject:
+			paths := children[path]
+			if paths == nil {
+				paths = map[string]string{}
+				children[path] = paths
+			}
+			for i, k := range n.keys {
+				child, ok := paths[k.name]
+				if !ok {
+					child = keyPath(path, k.name)
+					paths[k.name] = child
+					order[path] = append(order[path], k.name)
+				}
+				walk(n.items[i], child)
+			}
+		}
+	}
+	walk(n, "")
+	return order
+}
+
+// sortKeys reorders every object's fields, with their comments, into the
+// order sorted returns for its key names.
+func (n *docNode) sortKeys(sorted func([]string) []string) {
+	for _, item := range n.items {
+		item.sortKeys(sorted)
+	}
+	if n.kind != docObject || len(n.keys) < 2 {
+		return
+	}
+	index := make(map[string]int, len(n.keys))
+	names := make([]string, len(n.keys))
+	for i, k := range n.keys {
+		index[k.name] = i
+		names[i] = k.name
+	}
+	keys := make([]docKey, 0, len(n.keys))
+	items := make([]*docNode, 0, len(n.items))
+	for _, name := range sorted(names) {
+		i := index[name]
+		keys = append(keys, n.keys[i])
+		items = append(items, n.items[i])
+	}
+	n.keys, n.items = keys, items
+}
+
+// sortedNames is the key order of JSON objects as encoding/json writes
+// maps, for sortKeys.
+func sortedNames(names []string) []string {
+	names = slices.Clone(names)
+	slices.Sort(names)
+	return names
+}
+
+// docKeyIndex finds repeated keys while an object is parsed: it scans
+// small objects and switches to a map once they grow. A key find does not
+// see is recorded as the next one, so the caller must append it.
+type docKeyIndex struct {
+	names map[string]int
+}
+
+func (x *docKeyIndex) find(keys []docKey, key string) int {
+	if x.names == nil && len(keys) <= 16 {
+		for i, k := range keys {
+			if k.name == key {
+				return i
+			}
+		}
+		return -1
+	}
+	if x.names == nil {
+		x.names = make(map[string]int, len(keys))
+		for i, k := range keys {
+			x.names[k.name] = i
+		}
+	}
+	if i, ok := x.names[key]; ok {
+		return i
+	}
+	x.names[key] = len(keys)
+	return -1
+}
+
+// docBuilder assembles documents for readers that set the keys of their
+// objects one at a time, keeping the order keys are first set in.
+type docBuilder struct {
+	arena docArena
+	index map[*docNode]map[string]int // keys of objects grown past a scan
+}
+
+func (b *docBuilder) object() *docNode { return b.arena.node(docObject, docPos{}) }
+
+func (b *docBuilder) array(items ...*docNode) *docNode {
+	n := b.arena.node(docArray, docPos{})
+	n.items = items
+	return n
+}
+
+func (b *docBuilder) str(s string) *docNode { return b.scalar(docString, s) }
+
+// number takes the literal of a JSON number.
+func (b *docBuilder) number(lit string) *docNode { return b.scalar(docNumber, lit) }
+
+func (b *docBuilder) boolean(v bool) *docNode { return b.scalar(docBool, strconv.FormatBool(v)) }
+
+func (b *docBuilder) null() *docNode { return b.arena.node(docNull, docPos{}) }
+
+func (b *docBuilder) scalar(kind docKind, text string) *docNode {
+	n := b.arena.node(kind, docPos{})
+	n.text = text
+	return n
+}
+
+// value builds the node for a decoded value, with the keys of any objects
+// in it sorted.
+func (b *docBuilder) value(v any) (*docNode, error) {
+	return docFromValue(&b.arena, v, "", nil)
+}
+
+// sample builds the node for a placeholder scalar a schema reader made up,
+// which is always a nil, bool, number or string it can represent.
+func (b *docBuilder) sample(v any) *docNode {
+	n, err := docScalar(&b.arena, v, docPos{})
+	if err != nil {
+		return b.null()
+	}
+	return n
+}
+
+// get returns the value of key in obj, or nil.
+func (b *docBuilder) get(obj *docNode, key string) *docNode {
+	if i := b.find(obj, key); i >= 0 {
+		return obj.items[i]
+	}
+	return nil
+}
+
+// set stores value under key in obj: a new key goes last and a repeated
+// one keeps its place.
+func (b *docBuilder) set(obj *docNode, key string, value *docNode) {
+	if i := b.find(obj, key); i >= 0 {
+		obj.items[i] = value
+		return
+	}
+	if names := b.index[obj]; names != nil {
+		names[key] = len(obj.keys)
+	}
+	obj.keys = append(obj.keys, docKey{name: key})
+	obj.items = append(obj.items, value)
+}
+
+func (b *docBuilder) find(obj *docNode, key string) int {
+	names := b.index[obj]
+	if names == nil {
+		if len(obj.keys) <= 16 {
+			for i, k := range obj.keys {
+				if k.name == key {
+					return i
+				}
+			}
+			return -1
+		}
+		names = make(map[string]int, len(obj.keys))
+		for i, k := range obj.keys {
+			names[k.name] = i
+		}
+		if b.index == nil {
+			b.index = map[*docNode]map[string]int{}
+		}
+		b.index[obj] = names
+	}
+	if i, ok := names[key]; ok {
+		return i
+	}
+	return -1
+}
+
+// docMaxDepth bounds nesting, as encoding/json does.
+const docMaxDepth = 10000
+
+// parseJSONDoc parses the first JSON value in input into the document
+// model. Anything after that value is ignored, as json.Decoder does, and
+// errors name the line and column where parsing stopped; blank input
+// returns io.EOF. A repeated key
+// keeps its first position and its last value.
+func parseJSONDoc(input string) (*docNode, error) {
+	p := &jsonDocParser{src: input, line: 1}
+	p.skipSpace()
+	if p.i == len(p.src) {
+		return nil, io.EOF
+	}
+	return p.value(0)
+}
+
+type jsonDocParser struct {
+	src       string
+	i         int
+	line      int
+	lineStart int
+	arena     docArena
+	// keys and items are stacks shared by the objects and arrays being
+	// parsed; each copies its own part into the arena once complete.
+	keys  []docKey
+	items []*docNode
+}
+
+func (p *jsonDocParser) pos() docPos {
+	return docPos{line: p.line, column: p.i - p.lineStart + 1}
+}
+
+func (p *jsonDocParser) errorf(format string, args ...any) error {
+	pos := p.pos()
+	msg := fmt.Sprintf("line %d, column %d: %s", pos.line, pos.column, fmt.Sprintf(format, args...))
+	return newSyntaxError(p.src, p.i, msg, nil)
+}
+
+func (p *jsonDocParser) unexpected(context string) error {
+	if p.i >= len(p.src) {
+		return p.errorf("unexpected end of input %s", context)
+	}
+	r, _ := utf8.DecodeRuneInString(p.src[p.i:])
+	return p.errorf("unexpected %q %s", r, context)
+}
+
+func (p *jsonDocParser) skipSpace() {
+	for p.i < len(p.src) {
+		switch p.src[p.i] {
+		case '\n':
+			p.line++
+			p.lineStart = p.i + 1
+		case ' ', '\t', '\r':
+		default:
+			return
+		}
+		p.i++
+	}
+}
+
+func (p *jsonDocParser) value(depth int) (*docNode, error) {
+	if depth > docMaxDepth {
+		return nil, p.errorf("exceeded max depth")
+	}
+	if p.i >= len(p.src) {
+		return nil, p.unexpected("looking for a value")
+	}
+	pos := p.pos()
+	switch c := p.src[p.i]; {
+	case c == '{':
+		return p.object(pos, depth)
+	case c == '[':
+		return p.array(pos, depth)
+	case c == '"':
+		s, err := p.string()
+		if err != nil {
+			return nil, err
+		}
+		n := p.arena.node(docString, pos)
+		n.text = s
+		return n, nil
+	case c == '-' || c >= '0' && c <= '9':
+		text, err := p.number()
+		if err != nil {
+			return nil, err
+		}
+		n := p.arena.node(docNumber, pos)
+		n.text = text
+		return n, nil
+	}
+	for _, lit := range []string{"true", "false", "null"} {
+		if strings.HasPrefix(p.src[p.i:], lit) {
+			p.i += len(lit)
+			if lit == "null" {
+				return p.arena.node(docNull, pos), nil
+			}
+			n := p.arena.node(docBool, pos)
+			n.text = lit
+			return n, nil
+		}
+	}
+	return nil, p.unexpected("looking for a value")
+}
+
+func (p *jsonDocParser) object(pos docPos, depth int) (*docNode, error) {
+	n := p.arena.node(docObject, pos)
+	var index docKeyIndex
+	keyBase, itemBase := len(p.keys), len(p.items)
+	p.i++
+	p.skipSpace()
+	if p.i < len(p.src) && p.src[p.i] == '}' {
+		p.i++
+		return n, nil
+	}
+	for {
+		if p.i >= len(p.src) || p.src[p.i] != '"' {
+			return nil, p.unexpected("looking for an object key")
+		}
+		key := docKey{pos: p.pos()}
+		var err error
+		if key.name, err = p.string(); err != nil {
+			return nil, err
+		}
+		p.skipSpace()
+		if p.i >= len(p.src) || p.src[p.i] != ':' {
+			return nil, p.unexpected("after object key")
+		}
+		p.i++
+		p.skipSpace()
+		value, err := p.value(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		if i := index.find(p.keys[keyBase:], key.name); i >= 0 {
+			p.items[itemBase+i] = value
+		} else {
+			p.keys = append(p.keys, key)
+			p.items = append(p.items, value)
+		}
+		p.skipSpace()
+		if p.i < len(p.src) && p.src[p.i] == ',' {
+			p.i++
+			p.skipSpace()
+			continue
+		}
+		if p.i < len(p.src) && p.src[p.i] == '}' {
+			p.i++
+			n.keys, n.items = p.arena.keySlice(p.keys[keyBase:]), p.arena.itemSlice(p.items[itemBase:])
+			p.keys, p.items = p.keys[:keyBase], p.items[:itemBase]
+			return n, nil
+		}
+		return nil, p.unexpected("after object value")
+	}
+}
+
+func (p *jsonDocParser) array(pos docPos, depth int) (*docNode, error) {
+	n := p.arena.node(docArray, pos)
+	base := len(p.items)
+	p.i++
+	p.skipSpace()
+	if p.i < len(p.src) && p.src[p.i] == ']' {
+		p.i++
+		return n, nil
+	}
+	for {
+		item, err := p.value(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		p.items = append(p.items, item)
+		p.skipSpace()
+		if p.i < len(p.src) && p.src[p.i] == ',' {
+			p.i++
+			p.skipSpace()
+			continue
+		}
+		if p.i < len(p.src) && p.src[p.i] == ']' {
+			p.i++
+			n.items = p.arena.itemSlice(p.items[base:])
+			p.items = p.items[:base]
+			return n, nil
+		}
+		return nil, p.unexpected("after array element")
+	}
+}
+
+// string reads a quoted string. Invalid UTF-8 and unpaired surrogates
+// become U+FFFD, as in encoding/json.
+func (p *jsonDocParser) string() (string, error) {
+	start := p.i + 1
+	end := start
+	for end < len(p.src) && p.src[end] != '"' && p.src[end] != '\\' && p.src[end] >= 0x20 && p.src[end] < utf8.RuneSelf {
+		end++
+	}
+	if end < len(p.src) && p.src[end] == '"' {
+		p.i = end + 1
+		return p.src[start:end], nil
+	}
+	var b strings.Builder
+	b.WriteString(p.src[start:end])
+	p.i = end
+	for p.i < len(p.src) {
+		c := p.src[p.i]
+		switch {
+		case c == '"':
+			p.i++
+			return b.String(), nil
+		case c < 0x20:
+			return "", p.errorf("invalid control character %q in string", c)
+		case c == '\\':
+			if p.i+1 >= len(p.src) {
+				p.i++
+				return "", p.unexpected("in string escape")
+			}
+			esc := p.src[p.i+1]
+			switch esc {
+			case '"', '\\', '/':
+				b.WriteByte(esc)
+			case 'b':
+				b.WriteByte('\b')
+			case 'f':
+				b.WriteByte('\f')
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			case 't':
+				b.WriteByte('\t')
+			case 'u':
+				r, ok := p.hex4(p.i + 2)
+				if !ok {
+					return "", p.errorf("invalid \\u escape in string")
+				}
+				p.i += 6
+				if utf16.IsSurrogate(r) {
+					if r2, ok := p.hex4(p.i + 2); ok && strings.HasPrefix(p.src[p.i:], "\\u") {
+						if pair := utf16.DecodeRune(r, r2); pair != utf8.RuneError {
+							r = pair
+							p.i += 6
+						} else {
+							r = utf8.RuneError
+						}
+					} else {
+						r = utf8.RuneError
+					}
+				}
+				b.WriteRune(r)
+				continue
+			default:
+				p.i++
+				return "", p.errorf("invalid escape \\%c in string", esc)
+			}
+			p.i += 2
+		default:
+			r, size := utf8.DecodeRuneInString(p.src[p.i:])
+			b.WriteRune(r)
+			p.i += size
+		}
+	}
+	return "", p.unexpected("in string")
+}
+
+func (p *jsonDocParser) hex4(at int) (rune, bool) {
+	if at+4 > len(p.src) {
+		return 0, false
+	}
+	v, err := strconv.ParseUint(p.src[at:at+4], 16, 32)
+	return rune(v), err == nil
+}
+
+// number reads a number and returns its literal text.
+func (p *jsonDocParser) number() (string, error) {
+	start := p.i
+	digits := func() int {
+		n := 0
+		for p.i < len(p.src) && p.src[p.i] >= '0' && p.src[p.i] <= '9' {
+			p.i++
+			n++
+		}
+		return n
+	}
+	if p.src[p.i] == '-' {
+		p.i++
+	}
+	switch {
+	case p.i < len(p.src) && p.src[p.i] == '0':
+		p.i++
+	case digits() == 0:
+		return "", p.unexpected("in number")
+	}
+	if p.i < len(p.src) && p.src[p.i] == '.' {
+		p.i++
+		if digits() == 0 {
+			return "", p.unexpected("after decimal point in number")
+		}
+	}
+	if p.i < len(p.src) && (p.src[p.i] == 'e' || p.src[p.i] == 'E') {
+		p.i++
+		if p.i < len(p.src) && (p.src[p.i] == '+' || p.src[p.i] == '-') {
+			p.i++
+		}
+		if digits() == 0 {
+			return "", p.unexpected("in exponent of number")
+		}
+	}
+	return p.src[start:p.i], nil
+}
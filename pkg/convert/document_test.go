@@ -0,0 +1,185 @@
+package convert
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestParseJSONDoc(t *testing.T) {
+	doc, err := parseJSONDoc("{\n  \"b\": [1, {\"y\": 2, \"x\": 3}],\n  \"a\": \"\\u00e9\\ud83d\\ude00\",\n  \"b\": [{\"z\": null}]\n}")
+	require.NoError(t, err)
+	require.Equal(t, []string{"b", "a"}, []string{doc.keys[0].name, doc.keys[1].name})
+	require.Equal(t, docPos{line: 2, column: 3}, doc.keys[0].pos)
+	require.Equal(t, docPos{line: 3, column: 8}, doc.items[1].pos)
+	require.Equal(t, "é😀", doc.items[1].text)
+	require.Equal(t, map[string][]string{"": {"b", "a"}, "b[]": {"z"}}, doc.keyOrder())
+
+	order := jsonKeyOrder(`{"z":1,"a":[{"q":1},{"p":2,"q":3}],"m":{"k":true}}`)
+	require.Equal(t, map[string][]string{"": {"z", "a", "m"}, "a[]": {"q", "p"}, "m": {"k"}}, order)
+
+	errs := map[string]string{
+		"{\n  \"a\": 1,\n  \"b\" 2\n}": "line 3, column 7: unexpected '2' after object key",
+		"[1, 2":                        "line 1, column 6: unexpected end of input",
+		"{\"a\": tru}":                 "line 1, column 7",
+		"\"bad \\x\"":                  "line 1, column 7: invalid escape",
+		"-":                            "line 1, column 2",
+	}
+	for input, want := range errs {
+		_, err := parseJSONDoc(input)
+		require.ErrorContains(t, err, want, input)
+	}
+	_, err = parseJSONDoc(" \n\t")
+	require.ErrorIs(t, err, io.EOF)
+	_, err = parseJSONDoc(strings.Repeat("[", docMaxDepth+2))
+	require.ErrorContains(t, err, "exceeded max depth")
+}
+
+func TestParseJSONDoc_MatchesEncodingJSON(t *testing.T) {
+	inputs := []string{
+		`{"a":1,"b":[true,false,null],"c":{"d":-1.5e3,"e":"x\"y\\z\/\b\f\n\r\t"}}`,
+		`"\ud800"`, "\"\xff\"", `12345678901234567890`, `0.1`, `[]`, `{}`,
+		`{"a":1,"a":2}`, `1 2`, `{} trailing`, `01`, `nullx`,
+	}
+	for _, input := range inputs {
+		dec := json.NewDecoder(strings.NewReader(input))
+		dec.UseNumber()
+		var want any
+		require.NoError(t, dec.Decode(&want), input)
+		got, err := decodeJSONValue(input)
+		require.NoError(t, err, input)
+		require.Equal(t, want, got, input)
+	}
+	for _, input := range []string{`{"a" 1}`, `[1,]`, `"a`, "\"\x01\"", `1.`, `-`, `{"a":1,}`} {
+		_, err := decodeJSONValue(input)
+		require.Error(t, err, input)
+	}
+}
+
+func TestDocFromYAML(t *testing.T) {
+	input := `# service
+base: &base
+  port: 80 # default
+  host: localhost
+api:
+  <<: *base
+  port: 8080
+  when: 2024-01-02
+`
+	var root yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(input), &root))
+	r, err := newYAMLReader(&docArena{}, NewConvertOptions())
+	require.NoError(t, err)
+	doc, err := r.read(&root, 0)
+	require.NoError(t, err)
+	require.Equal(t, "# service", doc.keys[0].comment.head)
+	api := doc.items[1]
+	require.Equal(t, []string{"port", "when", "host"}, doc.keyOrder()["api"])
+	require.Equal(t, docPos{line: 7, column: 3}, api.keys[0].pos)
+	require.Equal(t, map[string]any{
+		"port": json.Number("8080"), "when": "2024-01-02T00:00:00Z", "host": "localhost",
+	}, api.value())
+
+	require.NoError(t, yaml.Unmarshal([]byte("a: 1\nb: 2\na: 3\n"), &root))
+	_, err = r.read(&root, 0)
+	require.ErrorContains(t, err, `line 3: mapping key "a" already defined at line 1`)
+
+	require.Equal(t, []string{"a", "a2", "a10", "b"}, yamlKeyOrder([]string{"b", "a10", "a2", "a"}))
+}
+
+func TestDocFromYAML_Aliases(t *testing.T) {
+	convert := func(input string, opts ...ConvertOption) (string, error) {
+		return ConvertFormatsWithOptions(formatYAML, formatJSON, input, opts...)
+	}
+	merged := "base: &base {port: 80, host: a}\napi:\n  <<: *base\n  port: 8080\nlist: [*base]\n"
+	out, err := convert(merged)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"base": {"port": 80, "host": "a"}, "api": {"port": 8080, "host": "a"}, "list": [{"port": 80, "host": "a"}]}`, out)
+	_, err = convert(merged, WithYAMLAliases(YAMLAliasesForbid))
+	require.ErrorContains(t, err, "line 3: alias *base not allowed")
+	out, err = convert("a: {<<: {x: 1}, y: 2}\n", WithYAMLAliases(YAMLAliasesForbid))
+	require.NoError(t, err)
+	require.JSONEq(t, `{"a": {"x": 1, "y": 2}}`, out)
+
+	// An alias inside the node it names is cut, or rejected when strict.
+	recursive := "a: &x\n  b: 1\n  c: *x\nd: *x\n"
+	out, err = convert(recursive)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"a": {"b": 1, "c": null}, "d": {"b": 1, "c": null}}`, out)
+	_, err = convert(recursive, WithYAMLAliases(YAMLAliasesStrict))
+	require.ErrorContains(t, err, "line 3: alias *x is inside the node it names")
+	_, err = convert("a: &x [1, *x]\n", WithYAMLAliases(YAMLAliasesStrict))
+	require.Error(t, err)
+
+	// Nested aliases that would expand to a billion values stop early.
+	var laughs strings.Builder
+	laughs.WriteString("a: &a [lol, lol, lol, lol, lol, lol, lol, lol, lol, lol]\n")
+	for c := 'b'; c <= 'i'; c++ {
+		p := string(c - 1)
+		laughs.WriteString(string(c) + ": &" + string(c) + " [*" + p + strings.Repeat(", *"+p, 9) + "]\n")
+	}
+	_, err = convert(laughs.String())
+	require.ErrorContains(t, err, "line 5: alias *d expands to too many values")
+	_, err = FormatContent(formatYAML, laughs.String(), false)
+	require.ErrorContains(t, err, "expands to too many values")
+	_, err = convert("a: &a [1, 2, 3]\nb: [*a, *a]\n", WithYAMLAliasLimit(7))
+	require.ErrorContains(t, err, "line 2: alias *a expands to too many values")
+	out, err = convert("a: &a [1, 2, 3]\nb: [*a, *a]\n", WithYAMLAliasLimit(8))
+	require.NoError(t, err)
+	require.JSONEq(t, `{"a": [1, 2, 3], "b": [[1, 2, 3], [1, 2, 3]]}`, out)
+
+	_, err = convert("a: 1", WithYAMLAliases("nope"))
+	require.Error(t, err)
+}
+
+func TestFormatContent_YAMLKeepsComments(t *testing.T) {
+	input := `# Service settings
+name: api   # public name
+ports:
+    - 80
+    - 443
+# Limits below
+limits: {cpu: 2, memory: 1Gi}
+`
+	out, err := FormatContentWithOptions(formatYAML, input, false, WithSortKeys(false))
+	require.NoError(t, err)
+	require.Equal(t, `# Service settings
+name: api # public name
+ports:
+  - 80
+  - 443
+# Limits below
+limits:
+  cpu: 2
+  memory: 1Gi`, out)
+
+	out, err = FormatContentWithOptions(formatYAML, "b: 1 # one\na:\n  d: x\n  c: w\n", false, WithIndent(4), WithSortKeys(true))
+	require.NoError(t, err)
+	require.Equal(t, "a:\n    c: w\n    d: x\nb: 1 # one", out)
+
+	// Blank lines between entries stay, one per gap, and move with their
+	// entries when keys are sorted.
+	input = "# Service\n\nname: api\n\n\n# Hosts\nhosts:\n  - a\n\n  - b\nport: 80\n"
+	out, err = FormatContentWithOptions(formatYAML, input, false, WithSortKeys(false))
+	require.NoError(t, err)
+	require.Equal(t, "# Service\n\nname: api\n\n# Hosts\nhosts:\n  - a\n\n  - b\nport: 80", out)
+	out, err = FormatContentWithOptions(formatYAML, "b: 1\n\na: 2\n", false, WithSortKeys(true))
+	require.NoError(t, err)
+	require.Equal(t, "a: 2\nb: 1", out)
+
+	// A flow collection after a blank line keeps the one blank line above
+	// it, not one between its entries, and a comment that happens to read
+	// like a marker is kept as written.
+	input = "a: 1\n\nb: [1, 2,\n\n  3]\nc: {p: 1, q: 2}\n\n# transform-go:blank-line\nd: [[1, 2], 3]\n"
+	out, err = FormatContentWithOptions(formatYAML, input, false, WithSortKeys(false))
+	require.NoError(t, err)
+	require.Equal(t, "a: 1\n\nb:\n  - 1\n  - 2\n\n  - 3\nc:\n  p: 1\n  q: 2\n\n# transform-go:blank-line\nd:\n  - - 1\n    - 2\n  - 3", out)
+
+	out, err = FormatContent(formatYAML, "", false)
+	require.NoError(t, err)
+	require.Equal(t, "null", out)
+}
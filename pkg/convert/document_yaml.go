@@ -0,0 +1,545 @@
+package convert
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/linzeyan/transform-go/pkg/common"
+	"gopkg.in/yaml.v3"
+)
+
+// yamlReader converts parsed YAML into the document model. Aliases are
+// read as YAMLAliases says, and the values they expand into are counted
+// against limit so that a few lines of nested aliases (the "billion
+// laughs") cannot exhaust memory.
+type yamlReader struct {
+	arena   *docArena
+	aliases string
+	limit   int // values aliases may still expand into; negative is unlimited
+	// anchored holds the anchored nodes being read, whose aliases inside
+	// them are recursive.
+	anchored map[*yaml.Node]bool
+	// alias is the outermost alias being expanded.
+	alias *yaml.Node
+	// lines is the source when formatting, to find the blank lines
+	// between entries, which the parser drops.
+	lines []string
+}
+
+func newYAMLReader(a *docArena, o ConvertOptions) (*yamlReader, error) {
+	r := &yamlReader{arena: a, aliases: o.YAMLAliases, limit: o.YAMLAliasLimit, anchored: map[*yaml.Node]bool{}}
+	switch r.aliases {
+	case "", YAMLAliasesResolve, YAMLAliasesStrict, YAMLAliasesForbid:
+	default:
+		return nil, fmt.Errorf("unsupported YAML aliases mode %q", r.aliases)
+	}
+	if r.limit == 0 {
+		r.limit = DefaultYAMLAliasLimit
+	}
+	return r, nil
+}
+
+// read converts a parsed YAML node into the document model, keeping key
+// order, positions and comments. Aliases and merge keys are expanded and
+// scalars are resolved the way yaml.Unmarshal resolves them.
+func (r *yamlReader) read(n *yaml.Node, depth int) (*docNode, error) {
+	if depth > docMaxDepth {
+		return nil, fmt.Errorf("line %d: exceeded max depth", n.Line)
+	}
+	if r.alias != nil && r.limit >= 0 {
+		if r.limit == 0 {
+			return nil, fmt.Errorf("line %d: alias *%s expands to too many values", r.alias.Line, r.alias.Value)
+		}
+		r.limit--
+	}
+	if n.Anchor != "" {
+		r.anchored[n] = true
+		defer delete(r.anchored, n)
+	}
+	a := r.arena
+	pos := docPos{line: n.Line, column: n.Column}
+	var doc *docNode
+	switch n.Kind {
+	case 0:
+		return a.node(docNull, pos), nil
+	case yaml.DocumentNode:
+		if len(n.Content) == 0 {
+			doc = a.node(docNull, pos)
+			break
+		}
+		var err error
+		if doc, err = r.read(n.Content[0], depth+1); err != nil {
+			return nil, err
+		}
+		doc.comment.head = joinComments(n.HeadComment, doc.comment.head)
+		doc.comment.foot = joinComments(doc.comment.foot, n.FootComment)
+		return doc, nil
+	case yaml.AliasNode:
+		return r.readAlias(n, depth)
+	case yaml.SequenceNode:
+		doc = a.node(docArray, pos)
+		for i, item := range n.Content {
+			child, err := r.read(item, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			child.comment.blank = r.blankBetween(n, n.Content[:i], item.Line, item.HeadComment)
+			doc.items = append(doc.items, child)
+		}
+	case yaml.MappingNode:
+		var err error
+		if doc, err = r.readMapping(n, depth); err != nil {
+			return nil, err
+		}
+	case yaml.ScalarNode:
+		if lit, ok := yamlNumberLiteral(n); ok {
+			doc = a.node(docNumber, pos)
+			doc.text = lit
+			break
+		}
+		var v any
+		if err := n.Decode(&v); err != nil {
+			return nil, err
+		}
+		var err error
+		if doc, err = docScalar(a, v, pos); err != nil {
+			return nil, fmt.Errorf("line %d: %w", n.Line, err)
+		}
+	default:
+		return nil, fmt.Errorf("line %d: unsupported YAML node", n.Line)
+	}
+	doc.comment = docComments{head: n.HeadComment, line: n.LineComment, foot: n.FootComment}
+	return doc, nil
+}
+
+// readAlias reads the node an alias names once more. An alias inside the
+// node it names has no end; resolve reads it as null and strict rejects it.
+func (r *yamlReader) readAlias(n *yaml.Node, depth int) (*docNode, error) {
+	switch {
+	case r.aliases == YAMLAliasesForbid:
+		return nil, fmt.Errorf("line %d: alias *%s not allowed", n.Line, n.Value)
+	case !r.anchored[n.Alias]:
+	case r.aliases == YAMLAliasesStrict:
+		return nil, fmt.Errorf("line %d: alias *%s is inside the node it names", n.Line, n.Value)
+	default:
+		return r.arena.node(docNull, docPos{line: n.Line, column: n.Column}), nil
+	}
+	if r.alias == nil {
+		r.alias = n
+		defer func() { r.alias = nil }()
+	}
+	return r.read(n.Alias, depth+1)
+}
+
+// yamlNumberLiteral returns the literal of a number that yaml.Unmarshal
+// would round or, for an integer beyond 64 bits, read as a string, so the
+// document keeps its digits.
+func yamlNumberLiteral(n *yaml.Node) (string, bool) {
+	if !numberPattern().MatchString(n.Value) {
+		return "", false
+	}
+	switch tag := n.ShortTag(); {
+	case tag == "!!int" || tag == "!!float":
+	case tag == "!!str" && n.Style == 0:
+	default:
+		return "", false
+	}
+	if common.ExactNumber(json.Number(n.Value)) {
+		return "", false
+	}
+	return n.Value, true
+}
+
+func (r *yamlReader) readMapping(n *yaml.Node, depth int) (*docNode, error) {
+	doc := r.arena.node(docObject, docPos{line: n.Line, column: n.Column})
+	var merges []*docNode
+	var index docKeyIndex
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		k, v := n.Content[i], n.Content[i+1]
+		value, err := r.read(v, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		if k.Kind == yaml.ScalarNode && k.Tag == "!!merge" {
+			merges = append(merges, value)
+			continue
+		}
+		var name any
+		if err := k.Decode(&name); err != nil {
+			return nil, err
+		}
+		key := docKey{
+			name: yamlKeyName(name),
+			pos:  docPos{line: k.Line, column: k.Column},
+			comment: docComments{
+				head:  k.HeadComment,
+				line:  k.LineComment,
+				foot:  k.FootComment,
+				blank: r.blankBetween(n, n.Content[:i], k.Line, k.HeadComment),
+			},
+		}
+		if j := index.find(doc.keys, key.name); j >= 0 {
+			return nil, fmt.Errorf("line %d: mapping key %q already defined at line %d", k.Line, key.name, doc.keys[j].pos.line)
+		}
+		doc.keys = append(doc.keys, key)
+		doc.items = append(doc.items, value)
+	}
+	// Keys written in the mapping win over merged ones, and earlier merges
+	// win over later ones.
+	for _, m := range merges {
+		sources := []*docNode{m}
+		if m.kind == docArray {
+			sources = m.items
+		}
+		for _, src := range sources {
+			if src.kind != docObject {
+				return nil, fmt.Errorf("line %d: map merge requires a mapping or a list of mappings", src.pos.line)
+			}
+			for j, k := range src.keys {
+				if index.find(doc.keys, k.name) < 0 {
+					doc.keys = append(doc.keys, k)
+					doc.items = append(doc.items, src.items[j])
+				}
+			}
+		}
+	}
+	return doc, nil
+}
+
+// blankBetween reports whether the source line before an entry of the
+// collection n on line, and before its head comment, is blank. An entry
+// sharing a line with what comes before it in n, as in [1, 2], never is:
+// the blank line is above the whole collection.
+func (r *yamlReader) blankBetween(n *yaml.Node, before []*yaml.Node, line int, head string) bool {
+	switch {
+	case len(before) > 0 && line <= yamlEndLine(before[len(before)-1]):
+		return false
+	case len(before) == 0 && n.Style&yaml.FlowStyle != 0 && line <= n.Line:
+		return false
+	}
+	if head != "" {
+		line -= strings.Count(head, "\n") + 1
+	}
+	return line > 1 && line-2 < len(r.lines) && strings.TrimSpace(r.lines[line-2]) == ""
+}
+
+// yamlEndLine returns the line the last entry inside n starts on.
+func yamlEndLine(n *yaml.Node) int {
+	line := n.Line
+	for len(n.Content) > 0 {
+		n = n.Content[len(n.Content)-1]
+		line = max(line, n.Line)
+	}
+	return line
+}
+
+// yamlKeyName spells a decoded YAML key the way common.NormalizeYAML does.
+func yamlKeyName(v any) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	return fmt.Sprint(v)
+}
+
+// docScalar types a decoded YAML scalar; timestamps become RFC 3339
+// strings, as encoding/json writes them.
+func docScalar(a *docArena, v any, pos docPos) (*docNode, error) {
+	var n *docNode
+	switch val := v.(type) {
+	case nil:
+		return a.node(docNull, pos), nil
+	case bool:
+		n = a.node(docBool, pos)
+		n.text = strconv.FormatBool(val)
+	case int:
+		n = a.node(docNumber, pos)
+		n.text = strconv.Itoa(val)
+	case int64:
+		n = a.node(docNumber, pos)
+		n.text = strconv.FormatInt(val, 10)
+	case uint64:
+		n = a.node(docNumber, pos)
+		n.text = strconv.FormatUint(val, 10)
+	case json.Number:
+		n = a.node(docNumber, pos)
+		n.text = val.String()
+	case float64:
+		if math.IsInf(val, 0) || math.IsNaN(val) {
+			return nil, fmt.Errorf("unsupported value: %v", val)
+		}
+		// encoding/json's spelling, so documents read this way convert
+		// as they do through a map.
+		text, err := json.Marshal(val)
+		if err != nil {
+			return nil, err
+		}
+		n = a.node(docNumber, pos)
+		n.text = string(text)
+	case string:
+		n = a.node(docString, pos)
+		n.text = val
+	case time.Time:
+		n = a.node(docString, pos)
+		n.text = val.Format(time.RFC3339Nano)
+	default:
+		n = a.node(docString, pos)
+		n.text = fmt.Sprint(val)
+	}
+	return n, nil
+}
+
+// docToYAML builds the YAML node for n, carrying its comments over. The
+// nodes that go below a blank line are added to blanks, when it is not nil.
+func docToYAML(n *docNode, blanks yamlBlanks) (*yaml.Node, error) {
+	var node *yaml.Node
+	switch n.kind {
+	case docObject:
+		node = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		for i, k := range n.keys {
+			key := &yaml.Node{}
+			if err := key.Encode(k.name); err != nil {
+				return nil, err
+			}
+			key.HeadComment, key.LineComment, key.FootComment = k.comment.head, k.comment.line, k.comment.foot
+			if k.comment.blank {
+				blanks.add(key)
+			}
+			value, err := docToYAML(n.items[i], blanks)
+			if err != nil {
+				return nil, err
+			}
+			node.Content = append(node.Content, key, value)
+		}
+	case docArray:
+		node = &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		for _, item := range n.items {
+			value, err := docToYAML(item, blanks)
+			if err != nil {
+				return nil, err
+			}
+			node.Content = append(node.Content, value)
+		}
+	default:
+		v := n.value()
+		if num, ok := v.(json.Number); ok {
+			v = common.NumberValue(num)
+		}
+		if num, ok := v.(json.Number); ok {
+			node = common.YAMLNumberNode(num)
+			break
+		}
+		node = &yaml.Node{}
+		if err := node.Encode(v); err != nil {
+			return nil, err
+		}
+	}
+	node.HeadComment, node.LineComment, node.FootComment = n.comment.head, n.comment.line, n.comment.foot
+	if n.comment.blank {
+		blanks.add(node)
+	}
+	return node, nil
+}
+
+// yamlBlanks holds the nodes docToYAML built that go below a blank line.
+// The encoder cannot write blank lines, so insert finds where the nodes
+// ended up in its output and adds them there.
+type yamlBlanks map[*yaml.Node]bool
+
+func (b yamlBlanks) add(n *yaml.Node) {
+	if b != nil {
+		b[n] = true
+	}
+}
+
+// insert adds the blank lines to out, the encoding of root, above each
+// node and its head comment.
+func (b yamlBlanks) insert(root *yaml.Node, out string) (string, error) {
+	if len(b) == 0 {
+		return out, nil
+	}
+	var written yaml.Node
+	if err := yaml.Unmarshal([]byte(out), &written); err != nil {
+		return "", err
+	}
+	above := map[int]bool{}
+	b.find(root, &written, above)
+	lines := strings.Split(out, "\n")
+	spaced := make([]string, 0, len(lines)+len(above))
+	for i, line := range lines {
+		if above[i+1] {
+			spaced = append(spaced, "")
+		}
+		spaced = append(spaced, line)
+	}
+	return strings.Join(spaced, "\n"), nil
+}
+
+// find walks built and the node read back from its encoding together,
+// marking the lines the blank lines go above.
+func (b yamlBlanks) find(built, written *yaml.Node, above map[int]bool) {
+	if written.Kind == yaml.DocumentNode && len(written.Content) == 1 {
+		written = written.Content[0]
+	}
+	if b[built] {
+		line := written.Line
+		if built.HeadComment != "" {
+			line -= strings.Count(built.HeadComment, "\n") + 1
+		}
+		above[line] = true
+	}
+	if len(built.Content) != len(written.Content) {
+		return
+	}
+	for i, child := range built.Content {
+		b.find(child, written.Content[i], above)
+	}
+}
+
+// yamlKeyOrder sorts key names the way yaml.v3 sorts map keys (numbers
+// inside names compare by value), so sorted output matches encoding a map.
+func yamlKeyOrder(names []string) []string {
+	set := make(map[string]bool, len(names))
+	for _, name := range names {
+		set[name] = true
+	}
+	var node yaml.Node
+	if err := node.Encode(set); err != nil {
+		return names
+	}
+	sorted := make([]string, 0, len(names))
+	for i := 0; i < len(node.Content); i += 2 {
+		sorted = append(sorted, node.Content[i].Value)
+	}
+	return sorted
+}
+
+// formatYAMLDocument reindents YAML through the document model, so
+// comments stay with the keys and values they describe; keys keep their
+// order unless SortKeys is set. Every document of a stream is kept.
+func formatYAMLDocument(input string, o ConvertOptions) (string, error) {
+	roots, err := yamlDocuments(input, false)
+	if err != nil {
+		return "", err
+	}
+	indent := o.Indent
+	if indent <= 0 {
+		indent = 2
+	}
+	r, err := newYAMLReader(&docArena{}, o)
+	if err != nil {
+		return "", err
+	}
+	r.lines = strings.Split(input, "\n")
+	parts := make([]string, len(roots))
+	for i, root := range roots {
+		doc, err := r.read(root, 0)
+		if err != nil {
+			return "", err
+		}
+		if o.SortKeys {
+			doc.sortKeys(yamlKeyOrder)
+		}
+		blanks := yamlBlanks{}
+		node, err := docToYAML(doc, blanks)
+		if err != nil {
+			return "", err
+		}
+		out, err := common.EncodeYAMLIndent(node, indent)
+		if err != nil {
+			return "", err
+		}
+		if out, err = blanks.insert(node, out); err != nil {
+			return "", err
+		}
+		parts[i] = strings.TrimLeft(out, "\n")
+	}
+	return strings.Join(parts, "\n---\n"), nil
+}
+
+// yamlDocuments parses the documents of a YAML stream, or only the first
+// when first is set. Documents left empty, as a trailing --- leaves one,
+// are skipped unless the stream has nothing else.
+func yamlDocuments(input string, first bool) ([]*yaml.Node, error) {
+	dec := yaml.NewDecoder(strings.NewReader(input))
+	var roots []*yaml.Node
+	for !first || len(roots) == 0 {
+		root := &yaml.Node{}
+		err := dec.Decode(root)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !emptyYAMLDocument(root) {
+			roots = append(roots, root)
+		} else if first {
+			return []*yaml.Node{root}, nil
+		}
+	}
+	if len(roots) == 0 {
+		// Comments alone, or no documents but empty ones, read as null.
+		root := &yaml.Node{}
+		if err := yaml.Unmarshal([]byte(input), root); err != nil {
+			return nil, err
+		}
+		roots = append(roots, root)
+	}
+	return roots, nil
+}
+
+func emptyYAMLDocument(root *yaml.Node) bool {
+	if root.HeadComment != "" || root.FootComment != "" || len(root.Content) != 1 {
+		return false
+	}
+	n := root.Content[0]
+	return n.Kind == yaml.ScalarNode && n.Tag == "!!null" && n.Value == "" &&
+		n.HeadComment == "" && n.LineComment == "" && n.FootComment == ""
+}
+
+// docFromYAMLStream reads a YAML stream as the YAMLDocuments setting says:
+// one document as itself, or several as an array.
+func docFromYAMLStream(a *docArena, input string, o ConvertOptions) (*docNode, error) {
+	mode := o.YAMLDocuments
+	switch mode {
+	case "", YAMLDocumentsAuto, YAMLDocumentsFirst, YAMLDocumentsStream:
+	default:
+		return nil, fmt.Errorf("unsupported YAML documents mode %q", mode)
+	}
+	r, err := newYAMLReader(a, o)
+	if err != nil {
+		return nil, err
+	}
+	roots, err := yamlDocuments(input, mode == YAMLDocumentsFirst)
+	if err != nil {
+		return nil, err
+	}
+	if len(roots) == 1 && mode != YAMLDocumentsStream {
+		return r.read(roots[0], 0)
+	}
+	doc := a.node(docArray, docPos{line: 1, column: 1})
+	for _, root := range roots {
+		item, err := r.read(root, 1)
+		if err != nil {
+			return nil, err
+		}
+		doc.items = append(doc.items, item)
+	}
+	return doc, nil
+}
+
+func joinComments(a, b string) string {
+	switch {
+	case a == "":
+		return b
+	case b == "":
+		return a
+	}
+	return a + "\n" + b
+}
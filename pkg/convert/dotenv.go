@@ -0,0 +1,156 @@
+package convert
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/linzeyan/transform-go/pkg/common"
+)
+
+const formatDotenv = "Dotenv"
+
+var (
+	dotenvNameRe  = common.LazyRegexp(`^[A-Za-z_][A-Za-z0-9_.-]*$`)
+	dotenvBareRe  = common.LazyRegexp(`^[A-Za-z0-9_./:@%+,-]+$`)
+	dotenvUpperRe = common.LazyRegexp(`^[A-Z_][A-Z0-9_]*$`)
+
+	dotenvEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, `$`, `\$`, "\n", `\n`, "\r", `\r`, "\t", `\t`)
+)
+
+// DotenvToJSON reads a .env file into a flat JSON object of strings, in the
+// order the names are first set. Lines are KEY=VALUE with an optional
+// export prefix and # comments. Double quoted values take \n, \r, \t, \",
+// \\ and \$ escapes and may span lines, single quoted values are taken
+// literally, and unquoted values end at a " #" comment. References such as
+// ${HOME} are kept as written.
+func DotenvToJSON(input string) (string, error) {
+	vars, err := parseDotenv(input)
+	if err != nil {
+		return "", err
+	}
+	return encodeDoc(vars)
+}
+
+// parseDotenv reads the variables of a .env file into an object in the
+// order they are first set.
+func parseDotenv(input string) (*docNode, error) {
+	src := strings.ReplaceAll(strings.TrimPrefix(input, "\uFEFF"), "\r\n", "\n")
+	lineAt := func(pos int) int { return 1 + strings.Count(src[:pos], "\n") }
+	lineEnd := func(pos int) int {
+		if i := strings.IndexByte(src[pos:], '\n'); i >= 0 {
+			return pos + i
+		}
+		return len(src)
+	}
+	b := &docBuilder{}
+	vars := b.object()
+	for pos := 0; pos < len(src); {
+		start, end := pos, lineEnd(pos)
+		pos = end + 1
+		stmt := strings.TrimSpace(src[start:end])
+		if stmt == "" || stmt[0] == '#' {
+			continue
+		}
+		eq := strings.IndexByte(src[start:end], '=')
+		if eq < 0 {
+			return nil, fmt.Errorf("dotenv: line %d: expected NAME=VALUE", lineAt(start))
+		}
+		name := strings.TrimSpace(src[start : start+eq])
+		if rest, ok := strings.CutPrefix(name, "export"); ok && rest != "" && (rest[0] == ' ' || rest[0] == '\t') {
+			name = strings.TrimSpace(rest)
+		}
+		if !dotenvNameRe().MatchString(name) {
+			return nil, fmt.Errorf("dotenv: line %d: %q is not a valid variable name", lineAt(start), name)
+		}
+		v := start + eq + 1
+		for v < end && (src[v] == ' ' || src[v] == '\t') {
+			v++
+		}
+		if v == end || (src[v] != '"' && src[v] != '\'') {
+			value := src[v:end]
+			for _, comment := range []string{" #", "\t#"} {
+				if i := strings.Index(value, comment); i >= 0 {
+					value = value[:i]
+				}
+			}
+			b.set(vars, name, b.str(strings.TrimRight(value, " \t")))
+			continue
+		}
+
+		// A quoted value may run over several lines.
+		quote := src[v]
+		var sb strings.Builder
+		closed := -1
+		for i := v + 1; i < len(src) && closed < 0; i++ {
+			switch c := src[i]; {
+			case c == quote:
+				closed = i
+			case c == '\\' && quote == '"' && i+1 < len(src):
+				i++
+				switch src[i] {
+				case 'n':
+					sb.WriteByte('\n')
+				case 'r':
+					sb.WriteByte('\r')
+				case 't':
+					sb.WriteByte('\t')
+				case '"', '\\', '$':
+					sb.WriteByte(src[i])
+				default:
+					sb.WriteByte('\\')
+					sb.WriteByte(src[i])
+				}
+			default:
+				sb.WriteByte(c)
+			}
+		}
+		if closed < 0 {
+			return nil, fmt.Errorf("dotenv: line %d: %c is not closed", lineAt(start), quote)
+		}
+		end = lineEnd(closed)
+		if tail := strings.TrimSpace(src[closed+1 : end]); tail != "" && tail[0] != '#' {
+			return nil, fmt.Errorf("dotenv: line %d: unexpected %q after the closing quote", lineAt(closed), tail)
+		}
+		pos = end + 1
+		b.set(vars, name, b.str(sb.String()))
+	}
+	return vars, nil
+}
+
+// JSONToDotenv writes a JSON object as a .env file with the names in the
+// order of the input. Nested keys are joined with underscores and array
+// elements with their index; values that are not plain words are double
+// quoted, with $ escaped so that no shell or loader expands them.
+func JSONToDotenv(input string) (string, error) {
+	return jsonToDotenvWithOptions(input, NewConvertOptions())
+}
+
+// jsonToDotenvWithOptions sorts the names when SortKeys is set.
+func jsonToDotenvWithOptions(input string, o ConvertOptions) (string, error) {
+	data, err := decodeJSONValue(input)
+	if err != nil {
+		return "", err
+	}
+	if _, ok := data.(map[string]any); !ok {
+		return "", errors.New("Dotenv root must be an object")
+	}
+	flat := flattenProperties(data, "_", o.sourceOrder(input))
+	for _, name := range flat.keys {
+		if !dotenvNameRe().MatchString(name) {
+			return "", fmt.Errorf("dotenv: %q is not a valid variable name", name)
+		}
+	}
+	var b strings.Builder
+	for _, name := range flat.keys {
+		b.WriteString(name + "=" + dotenvQuote(flat.values[name]) + "\n")
+	}
+	return b.String(), nil
+}
+
+func dotenvQuote(s string) string {
+	if s == "" || dotenvBareRe().MatchString(s) {
+		return s
+	}
+	return `"` + dotenvEscaper.Replace(s) + `"`
+}
@@ -0,0 +1,66 @@
+package convert
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDotenvToJSON(t *testing.T) {
+	out, err := DotenvToJSON("# app settings\n" +
+		"APP_NAME=demo\n" +
+		"export PORT = 8080\n" +
+		"EMPTY=\n" +
+		"URL=http://localhost:8080/#top  # trailing comment\n" +
+		"HOME_DIR=${HOME}/app\n" +
+		"GREETING=\"Hello\\n\\\"world\\\" \\$5 \\d\" # quoted\n" +
+		"LITERAL='no \\n escapes $HOME'\n" +
+		"CERT=\"-----BEGIN-----\r\nabc\r\n-----END-----\"\r\n" +
+		"APP_NAME=override\n")
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"APP_NAME": "override",
+		"PORT": "8080",
+		"EMPTY": "",
+		"URL": "http://localhost:8080/#top",
+		"HOME_DIR": "${HOME}/app",
+		"GREETING": "Hello\n\"world\" $5 \\d",
+		"LITERAL": "no \\n escapes $HOME",
+		"CERT": "-----BEGIN-----\nabc\n-----END-----"
+	}`, out)
+
+	for input, msg := range map[string]string{
+		"A=1\nnot a pair\n":          "dotenv: line 2: expected NAME=VALUE",
+		"A=1\n1ST=x\n":               `dotenv: line 2: "1ST" is not a valid variable name`,
+		"A=\"open\nB=2\n":            `dotenv: line 1: " is not closed`,
+		"A='x'\nB=\"multi\nline\" x": `dotenv: line 3: unexpected "x" after the closing quote`,
+	} {
+		_, err := DotenvToJSON(input)
+		require.EqualError(t, err, msg, input)
+	}
+}
+
+func TestJSONToDotenv(t *testing.T) {
+	out, err := JSONToDotenv(`{"APP_NAME": "demo", "db": {"host": "localhost", "ports": [5432, 5433]},
+		"greeting": "Hello \"world\"\n", "price": "$5", "debug": false, "unset": null}`)
+	require.NoError(t, err)
+	require.Equal(t, `APP_NAME=demo
+db_host=localhost
+db_ports_0=5432
+db_ports_1=5433
+greeting="Hello \"world\"\n"
+price="\$5"
+debug=false
+unset=
+`, out)
+
+	back, err := DotenvToJSON(out)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"APP_NAME": "demo", "db_host": "localhost", "db_ports_0": "5432", "db_ports_1": "5433",
+		"debug": "false", "greeting": "Hello \"world\"\n", "price": "$5", "unset": ""}`, back)
+
+	_, err = JSONToDotenv(`{"a b": 1}`)
+	require.EqualError(t, err, `dotenv: "a b" is not a valid variable name`)
+	_, err = JSONToDotenv(`"x"`)
+	require.EqualError(t, err, "Dotenv root must be an object")
+}
@@ -0,0 +1,125 @@
+package convert
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+)
+
+// maxEmbeddedDepth bounds depth 0 ("unlimited") so hostile input cannot
+// recurse forever.
+const maxEmbeddedDepth = 32
+
+// ParseEmbeddedJSON replaces string values that hold stringified JSON
+// objects or arrays ("body": "{\"a\":1}") with the parsed value, as log
+// payloads often nest JSON in JSON. Each level of embedding counts towards
+// depth, including strings that were stringified twice; depth 0 means no
+// limit. Strings holding only a number, boolean or plain text stay strings.
+func ParseEmbeddedJSON(input string, depth int) (string, error) {
+	data, err := decodeJSONValue(input)
+	if err != nil {
+		return "", err
+	}
+	if depth <= 0 || depth > maxEmbeddedDepth {
+		depth = maxEmbeddedDepth
+	}
+	return encodeJSON(parseEmbeddedValue(data, depth))
+}
+
+// StringifyEmbeddedJSON is the reverse of ParseEmbeddedJSON: objects and
+// arrays nested depth levels below the root (default 1, its direct values)
+// are replaced by their compact JSON text.
+func StringifyEmbeddedJSON(input string, depth int) (string, error) {
+	data, err := decodeJSONValue(input)
+	if err != nil {
+		return "", err
+	}
+	if depth <= 0 {
+		depth = 1
+	}
+	out, err := stringifyEmbeddedValue(data, depth)
+	if err != nil {
+		return "", err
+	}
+	return encodeJSON(out)
+}
+
+func parseEmbeddedValue(v any, depth int) any {
+	switch val := v.(type) {
+	case map[string]any:
+		for k, item := range val {
+			val[k] = parseEmbeddedValue(item, depth)
+		}
+	case []any:
+		for i, item := range val {
+			val[i] = parseEmbeddedValue(item, depth)
+		}
+	case string:
+		if depth == 0 {
+			return val
+		}
+		if inner, ok := embeddedJSON(val); ok {
+			return parseEmbeddedValue(inner, depth-1)
+		}
+	}
+	return v
+}
+
+// embeddedJSON decodes s when it is a JSON object, array or string literal
+// (a doubly stringified payload).
+func embeddedJSON(s string) (any, bool) {
+	trimmed := strings.TrimSpace(s)
+	if trimmed == "" || !strings.ContainsRune(`{["`, rune(trimmed[0])) || !json.Valid([]byte(trimmed)) {
+		return nil, false
+	}
+	inner, err := decodeJSONValue(trimmed)
+	if err != nil {
+		return nil, false
+	}
+	if str, ok := inner.(string); ok {
+		if _, ok := embeddedJSON(str); !ok {
+			return nil, false
+		}
+	}
+	return inner, true
+}
+
+func stringifyEmbeddedValue(v any, depth int) (any, error) {
+	switch val := v.(type) {
+	case map[string]any:
+		for k, item := range val {
+			out, err := stringifyEmbeddedChild(item, depth)
+			if err != nil {
+				return nil, err
+			}
+			val[k] = out
+		}
+	case []any:
+		for i, item := range val {
+			out, err := stringifyEmbeddedChild(item, depth)
+			if err != nil {
+				return nil, err
+			}
+			val[i] = out
+		}
+	}
+	return v, nil
+}
+
+func stringifyEmbeddedChild(v any, depth int) (any, error) {
+	switch v.(type) {
+	case map[string]any, []any:
+	default:
+		return v, nil
+	}
+	if depth > 1 {
+		return stringifyEmbeddedValue(v, depth-1)
+	}
+	buf := &bytes.Buffer{}
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
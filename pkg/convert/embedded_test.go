@@ -0,0 +1,48 @@
+package convert
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseEmbeddedJSON(t *testing.T) {
+	input := `{"level":"info","id":"42","body":"{\"user\":{\"id\":1},\"meta\":\"{\\\"ip\\\":\\\"10.0.0.1\\\"}\"}","list":"[1, 2]","twice":"\"{\\\"a\\\":true}\""}`
+	out, err := ParseEmbeddedJSON(input, 0)
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"level": "info",
+		"id": "42",
+		"body": {"user": {"id": 1}, "meta": {"ip": "10.0.0.1"}},
+		"list": [1, 2],
+		"twice": {"a": true}
+	}`, out)
+
+	out, err = ParseEmbeddedJSON(input, 1)
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"level": "info",
+		"id": "42",
+		"body": {"user": {"id": 1}, "meta": "{\"ip\":\"10.0.0.1\"}"},
+		"list": [1, 2],
+		"twice": "{\"a\":true}"
+	}`, out)
+
+	_, err = ParseEmbeddedJSON(`{"a":`, 0)
+	require.Error(t, err)
+}
+
+func TestStringifyEmbeddedJSON(t *testing.T) {
+	input := `{"level":"info","body":{"user":{"id":1},"tags":["<a>"]},"n":1}`
+	out, err := StringifyEmbeddedJSON(input, 1)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"level":"info","body":"{\"tags\":[\"<a>\"],\"user\":{\"id\":1}}","n":1}`, out)
+
+	out, err = StringifyEmbeddedJSON(input, 2)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"level":"info","body":{"user":"{\"id\":1}","tags":"[\"<a>\"]"},"n":1}`, out)
+
+	back, err := ParseEmbeddedJSON(out, 0)
+	require.NoError(t, err)
+	require.JSONEq(t, input, back)
+}
@@ -0,0 +1,439 @@
+package convert
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// exprNode is a compiled expression. Values are float64, string, bool or
+// nil; lookup resolves column (field) names.
+type exprNode interface {
+	eval(lookup func(string) any) (any, error)
+}
+
+type exprLiteral struct{ value any }
+
+type exprRef struct{ name string }
+
+type exprUnary struct {
+	op      byte
+	operand exprNode
+}
+
+type exprBinary struct {
+	op          string
+	left, right exprNode
+}
+
+type exprCall struct {
+	name string
+	args []exprNode
+}
+
+// compileExpr parses a small arithmetic and string expression language:
+// numbers, 'single' or "double" quoted strings, column names (bare or
+// [bracketed] when they contain spaces), + - * / %, comparisons
+// (== != < <= > >=), parentheses and the functions listed in exprFuncs.
+// + concatenates when either side is not a number.
+func compileExpr(src string) (exprNode, error) {
+	p := &exprParser{src: src}
+	node, err := p.parseComparison()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if p.pos < len(p.src) {
+		return nil, fmt.Errorf("unexpected %q at offset %d", p.src[p.pos:], p.pos)
+	}
+	return node, nil
+}
+
+type exprParser struct {
+	src string
+	pos int
+}
+
+func (p *exprParser) skipSpace() {
+	for p.pos < len(p.src) && (p.src[p.pos] == ' ' || p.src[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+func (p *exprParser) consume(tokens ...string) string {
+	p.skipSpace()
+	for _, tok := range tokens {
+		if strings.HasPrefix(p.src[p.pos:], tok) {
+			p.pos += len(tok)
+			return tok
+		}
+	}
+	return ""
+}
+
+func (p *exprParser) parseComparison() (exprNode, error) {
+	left, err := p.parseSum()
+	if err != nil {
+		return nil, err
+	}
+	if op := p.consume("==", "!=", "<=", ">=", "<", ">"); op != "" {
+		right, err := p.parseSum()
+		if err != nil {
+			return nil, err
+		}
+		return exprBinary{op: op, left: left, right: right}, nil
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseSum() (exprNode, error) {
+	left, err := p.parseProduct()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op := p.consume("+", "-")
+		if op == "" {
+			return left, nil
+		}
+		right, err := p.parseProduct()
+		if err != nil {
+			return nil, err
+		}
+		left = exprBinary{op: op, left: left, right: right}
+	}
+}
+
+func (p *exprParser) parseProduct() (exprNode, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op := p.consume("*", "/", "%")
+		if op == "" {
+			return left, nil
+		}
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = exprBinary{op: op, left: left, right: right}
+	}
+}
+
+func (p *exprParser) parseUnary() (exprNode, error) {
+	if p.consume("-") != "" {
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return exprUnary{op: '-', operand: operand}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *exprParser) parsePrimary() (exprNode, error) {
+	p.skipSpace()
+	if p.pos >= len(p.src) {
+		return nil, errors.New("unexpected end of expression")
+	}
+	ch := p.src[p.pos]
+	switch {
+	case ch == '(':
+		p.pos++
+		node, err := p.parseComparison()
+		if err != nil {
+			return nil, err
+		}
+		if p.consume(")") == "" {
+			return nil, errors.New("missing )")
+		}
+		return node, nil
+	case ch == '\'' || ch == '"':
+		end := strings.IndexByte(p.src[p.pos+1:], ch)
+		if end < 0 {
+			return nil, errors.New("unterminated string")
+		}
+		lit := p.src[p.pos+1 : p.pos+1+end]
+		p.pos += end + 2
+		return exprLiteral{value: lit}, nil
+	case ch == '[':
+		end := strings.IndexByte(p.src[p.pos:], ']')
+		if end < 0 {
+			return nil, errors.New("missing ]")
+		}
+		name := p.src[p.pos+1 : p.pos+end]
+		p.pos += end + 1
+		return exprRef{name: name}, nil
+	case ch >= '0' && ch <= '9' || ch == '.':
+		start := p.pos
+		for p.pos < len(p.src) && (p.src[p.pos] >= '0' && p.src[p.pos] <= '9' || p.src[p.pos] == '.') {
+			p.pos++
+		}
+		num, err := strconv.ParseFloat(p.src[start:p.pos], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q", p.src[start:p.pos])
+		}
+		return exprLiteral{value: num}, nil
+	}
+	start := p.pos
+	for p.pos < len(p.src) {
+		r := rune(p.src[p.pos])
+		if !(unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r >= 0x80) {
+			break
+		}
+		p.pos++
+	}
+	name := p.src[start:p.pos]
+	if name == "" {
+		return nil, fmt.Errorf("unexpected %q at offset %d", p.src[p.pos:], p.pos)
+	}
+	switch name {
+	case "true", "false":
+		return exprLiteral{value: name == "true"}, nil
+	case "null":
+		return exprLiteral{}, nil
+	}
+	if p.consume("(") == "" {
+		return exprRef{name: name}, nil
+	}
+	if _, ok := exprFuncs[name]; !ok {
+		return nil, fmt.Errorf("unknown function %s", name)
+	}
+	var args []exprNode
+	if p.consume(")") == "" {
+		for {
+			arg, err := p.parseComparison()
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
+			if p.consume(",") != "" {
+				continue
+			}
+			if p.consume(")") == "" {
+				return nil, fmt.Errorf("missing ) after %s arguments", name)
+			}
+			break
+		}
+	}
+	return exprCall{name: name, args: args}, nil
+}
+
+// exprFuncs are the functions available to expressions.
+var exprFuncs = map[string]func(args []any) (any, error){
+	"upper":  func(a []any) (any, error) { return strings.ToUpper(exprText(exprArg(a, 0))), nil },
+	"lower":  func(a []any) (any, error) { return strings.ToLower(exprText(exprArg(a, 0))), nil },
+	"trim":   func(a []any) (any, error) { return strings.TrimSpace(exprText(exprArg(a, 0))), nil },
+	"len":    func(a []any) (any, error) { return float64(len([]rune(exprText(exprArg(a, 0))))), nil },
+	"concat": func(a []any) (any, error) { return exprConcat(a), nil },
+	"round": func(a []any) (any, error) {
+		n, err := exprNumber(exprArg(a, 0))
+		if err != nil {
+			return nil, err
+		}
+		digits := 0.0
+		if len(a) > 1 {
+			if digits, err = exprNumber(a[1]); err != nil {
+				return nil, err
+			}
+		}
+		scale := math.Pow(10, digits)
+		return math.Round(n*scale) / scale, nil
+	},
+	"if": func(a []any) (any, error) {
+		if len(a) != 3 {
+			return nil, errors.New("if expects 3 arguments")
+		}
+		if exprTruthy(a[0]) {
+			return a[1], nil
+		}
+		return a[2], nil
+	},
+}
+
+func (n exprLiteral) eval(func(string) any) (any, error) { return n.value, nil }
+
+func (n exprRef) eval(lookup func(string) any) (any, error) {
+	return exprNormalize(lookup(n.name)), nil
+}
+
+func (n exprUnary) eval(lookup func(string) any) (any, error) {
+	v, err := n.operand.eval(lookup)
+	if err != nil {
+		return nil, err
+	}
+	num, err := exprNumber(v)
+	if err != nil {
+		return nil, err
+	}
+	return -num, nil
+}
+
+func (n exprCall) eval(lookup func(string) any) (any, error) {
+	args := make([]any, len(n.args))
+	for i, arg := range n.args {
+		v, err := arg.eval(lookup)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = v
+	}
+	return exprFuncs[n.name](args)
+}
+
+func (n exprBinary) eval(lookup func(string) any) (any, error) {
+	left, err := n.left.eval(lookup)
+	if err != nil {
+		return nil, err
+	}
+	right, err := n.right.eval(lookup)
+	if err != nil {
+		return nil, err
+	}
+	ln, lerr := exprNumber(left)
+	rn, rerr := exprNumber(right)
+	numeric := lerr == nil && rerr == nil
+	switch n.op {
+	case "+":
+		if !numeric {
+			return exprConcat([]any{left, right}), nil
+		}
+		return ln + rn, nil
+	case "==":
+		if numeric {
+			return ln == rn, nil
+		}
+		return exprText(left) == exprText(right), nil
+	case "!=":
+		if numeric {
+			return ln != rn, nil
+		}
+		return exprText(left) != exprText(right), nil
+	case "<", "<=", ">", ">=":
+		cmp := 0
+		if numeric {
+			cmp = compareFloat(ln, rn)
+		} else {
+			cmp = strings.Compare(exprText(left), exprText(right))
+		}
+		switch n.op {
+		case "<":
+			return cmp < 0, nil
+		case "<=":
+			return cmp <= 0, nil
+		case ">":
+			return cmp > 0, nil
+		}
+		return cmp >= 0, nil
+	}
+	if lerr != nil {
+		return nil, lerr
+	}
+	if rerr != nil {
+		return nil, rerr
+	}
+	switch n.op {
+	case "-":
+		return ln - rn, nil
+	case "*":
+		return ln * rn, nil
+	case "/":
+		if rn == 0 {
+			return nil, errors.New("division by zero")
+		}
+		return ln / rn, nil
+	}
+	if rn == 0 {
+		return nil, errors.New("division by zero")
+	}
+	return math.Mod(ln, rn), nil
+}
+
+func compareFloat(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func exprArg(args []any, i int) any {
+	if i < len(args) {
+		return args[i]
+	}
+	return nil
+}
+
+// exprNormalize turns decoded JSON numbers into float64.
+func exprNormalize(v any) any {
+	if num, ok := v.(json.Number); ok {
+		if f, err := num.Float64(); err == nil {
+			return f
+		}
+		return num.String()
+	}
+	return v
+}
+
+func exprNumber(v any) (float64, error) {
+	switch val := exprNormalize(v).(type) {
+	case float64:
+		return val, nil
+	case bool:
+		if val {
+			return 1, nil
+		}
+		return 0, nil
+	case string:
+		if f, err := strconv.ParseFloat(strings.TrimSpace(val), 64); err == nil {
+			return f, nil
+		}
+		return 0, fmt.Errorf("%q is not a number", val)
+	case nil:
+		return 0, errors.New("null is not a number")
+	}
+	return 0, fmt.Errorf("%v is not a number", v)
+}
+
+func exprText(v any) string {
+	switch val := exprNormalize(v).(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	default:
+		return fmt.Sprint(val)
+	}
+}
+
+func exprConcat(args []any) string {
+	var b strings.Builder
+	for _, a := range args {
+		b.WriteString(exprText(a))
+	}
+	return b.String()
+}
+
+func exprTruthy(v any) bool {
+	switch val := exprNormalize(v).(type) {
+	case nil:
+		return false
+	case bool:
+		return val
+	case float64:
+		return val != 0
+	case string:
+		return val != ""
+	}
+	return true
+}
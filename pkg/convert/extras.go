@@ -6,9 +6,10 @@ import (
 	"math/big"
 	"math/bits"
 	"net"
-	"regexp"
 	"strconv"
 	"strings"
+
+	"github.com/linzeyan/transform-go/pkg/common"
 )
 
 var ipv4RangeReplacer = strings.NewReplacer(" ", "", "->", "-", "—", "-", "–", "-")
@@ -227,18 +228,49 @@ func maskSize(mask net.IPMask) (int, error) {
 	return ones, nil
 }
 
+var (
+	markdownOrderedRe  = common.LazyRegexp(`^(\d{1,9})[.)]\s+(.*)$`)
+	markdownRuleRe     = common.LazyRegexp(`^(?:(?:-[ \t]*){3,}|(?:\*[ \t]*){3,}|(?:_[ \t]*){3,})$`)
+	markdownTableSepRe = common.LazyRegexp(`^\|?[ \t]*:?-+:?[ \t]*(?:\|[ \t]*:?-+:?[ \t]*)*\|?$`)
+)
+
+// MarkdownToHTML renders headings, paragraphs, code fences, bullet and
+// ordered lists (nested by indentation), blockquotes, horizontal rules and
+// GitHub-flavored tables.
 func MarkdownToHTML(input string) (string, error) {
-	lines := strings.Split(strings.ReplaceAll(input, "\r\n", "\n"), "\n")
+	lines, _ := markdownBodyLines(input)
+	return renderMarkdownBlocks(lines), nil
+}
+
+// markdownList is an open list level; its last <li> stays open so a deeper
+// list can be nested inside it.
+type markdownList struct {
+	tag    string
+	indent int
+}
+
+func renderMarkdownBlocks(lines []string) string {
 	var builder strings.Builder
-	inList := false
+	var lists []markdownList
+	popList := func() {
+		builder.WriteString("</li>\n</" + lists[len(lists)-1].tag + ">\n")
+		lists = lists[:len(lists)-1]
+	}
+	closeLists := func() {
+		for len(lists) > 0 {
+			popList()
+		}
+	}
 	inCodeBlock := false
-	for i, line := range lines {
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
 		trim := strings.TrimSpace(line)
 		if strings.HasPrefix(trim, "```") {
 			if inCodeBlock {
 				builder.WriteString("</code></pre>\n")
 				inCodeBlock = false
 			} else {
+				closeLists()
 				builder.WriteString("<pre><code>")
 				inCodeBlock = true
 			}
@@ -250,26 +282,74 @@ func MarkdownToHTML(input string) (string, error) {
 			continue
 		}
 		if trim == "" {
-			if inList {
-				builder.WriteString("</ul>\n")
-				inList = false
-			}
+			closeLists()
 			continue
 		}
-		if strings.HasPrefix(trim, "- ") || strings.HasPrefix(trim, "* ") {
-			if !inList {
-				builder.WriteString("<ul>\n")
-				inList = true
+		if markdownRuleRe().MatchString(trim) {
+			closeLists()
+			builder.WriteString("<hr>\n")
+			continue
+		}
+		if tag, start, item, ok := markdownListItem(trim); ok {
+			indent := markdownIndent(line)
+			for len(lists) > 0 && lists[len(lists)-1].indent > indent {
+				popList()
+			}
+			if len(lists) > 0 && lists[len(lists)-1].indent == indent {
+				if lists[len(lists)-1].tag == tag {
+					builder.WriteString("</li>\n")
+				} else {
+					popList()
+				}
+			}
+			if len(lists) == 0 || lists[len(lists)-1].indent < indent {
+				if len(lists) > 0 {
+					builder.WriteString("\n")
+				}
+				if tag == "ol" && start != 1 {
+					builder.WriteString(fmt.Sprintf("<ol start=\"%d\">\n", start))
+				} else {
+					builder.WriteString("<" + tag + ">\n")
+				}
+				lists = append(lists, markdownList{tag: tag, indent: indent})
 			}
-			item := strings.TrimSpace(trim[2:])
 			builder.WriteString("<li>")
 			builder.WriteString(applyInlineMarkdown(item))
-			builder.WriteString("</li>\n")
 			continue
 		}
-		if inList {
-			builder.WriteString("</ul>\n")
-			inList = false
+		closeLists()
+		if strings.HasPrefix(trim, ">") {
+			var quoted []string
+			for ; i < len(lines); i++ {
+				next := strings.TrimSpace(lines[i])
+				if !strings.HasPrefix(next, ">") {
+					break
+				}
+				next = strings.TrimPrefix(next, ">")
+				quoted = append(quoted, strings.TrimPrefix(next, " "))
+			}
+			i--
+			builder.WriteString("<blockquote>\n")
+			builder.WriteString(renderMarkdownBlocks(quoted))
+			builder.WriteString("</blockquote>\n")
+			continue
+		}
+		if i+1 < len(lines) && strings.Contains(trim, "|") && markdownTableSepRe().MatchString(strings.TrimSpace(lines[i+1])) {
+			header := splitMarkdownRow(trim)
+			aligns := markdownTableAligns(splitMarkdownRow(strings.TrimSpace(lines[i+1])))
+			if len(aligns) == len(header) {
+				var rows [][]string
+				for i += 2; i < len(lines); i++ {
+					next := strings.TrimSpace(lines[i])
+					if next == "" || !strings.Contains(next, "|") {
+						break
+					}
+					rows = append(rows, splitMarkdownRow(next))
+				}
+				i--
+				renderMarkdownTable(&builder, header, aligns, rows)
+				continue
+			}
 		}
 		if headingLevel := markdownHeadingLevel(trim); headingLevel > 0 {
 			content := strings.TrimSpace(trim[headingLevel:])
@@ -285,13 +365,112 @@ func MarkdownToHTML(input string) (string, error) {
 			builder.WriteString("\n")
 		}
 	}
-	if inList {
-		builder.WriteString("</ul>\n")
-	}
+	closeLists()
 	if inCodeBlock {
 		builder.WriteString("</code></pre>\n")
 	}
-	return builder.String(), nil
+	return builder.String()
+}
+
+// markdownListItem recognises "- ", "* ", "+ " and "1. " / "1) " items.
+func markdownListItem(trim string) (tag string, start int, item string, ok bool) {
+	if len(trim) > 1 && strings.ContainsRune("-*+", rune(trim[0])) && trim[1] == ' ' {
+		return "ul", 0, strings.TrimSpace(trim[2:]), true
+	}
+	if m := markdownOrderedRe().FindStringSubmatch(trim); m != nil {
+		start, _ = strconv.Atoi(m[1])
+		return "ol", start, strings.TrimSpace(m[2]), true
+	}
+	return "", 0, "", false
+}
+
+// markdownIndent measures leading whitespace, counting a tab as four spaces.
+func markdownIndent(line string) int {
+	indent := 0
+	for _, ch := range line {
+		switch ch {
+		case ' ':
+			indent++
+		case '\t':
+			indent += 4
+		default:
+			return indent
+		}
+	}
+	return indent
+}
+
+// splitMarkdownRow splits a table row on unescaped pipes, dropping the
+// optional outer pipes.
+func splitMarkdownRow(row string) []string {
+	row = strings.TrimPrefix(row, "|")
+	if strings.HasSuffix(row, "|") && !strings.HasSuffix(row, `\|`) {
+		row = row[:len(row)-1]
+	}
+	var cells []string
+	var cell strings.Builder
+	for i := 0; i < len(row); i++ {
+		switch {
+		case row[i] == '\\' && i+1 < len(row) && row[i+1] == '|':
+			cell.WriteByte('|')
+			i++
+		case row[i] == '|':
+			cells = append(cells, strings.TrimSpace(cell.String()))
+			cell.Reset()
+		default:
+			cell.WriteByte(row[i])
+		}
+	}
+	return append(cells, strings.TrimSpace(cell.String()))
+}
+
+func markdownTableAligns(cells []string) []string {
+	aligns := make([]string, len(cells))
+	for i, cell := range cells {
+		left, right := strings.HasPrefix(cell, ":"), strings.HasSuffix(cell, ":")
+		switch {
+		case left && right:
+			aligns[i] = "center"
+		case right:
+			aligns[i] = "right"
+		case left:
+			aligns[i] = "left"
+		}
+	}
+	return aligns
+}
+
+// renderMarkdownTable pads or truncates body rows to the header width as
+// GitHub does.
+func renderMarkdownTable(builder *strings.Builder, header, aligns []string, rows [][]string) {
+	writeRow := func(cells []string, tag string) {
+		builder.WriteString("<tr>")
+		for i := range header {
+			cell := ""
+			if i < len(cells) {
+				cell = cells[i]
+			}
+			if aligns[i] != "" {
+				builder.WriteString(fmt.Sprintf("<%s style=\"text-align: %s\">", tag, aligns[i]))
+			} else {
+				builder.WriteString("<" + tag + ">")
+			}
+			builder.WriteString(applyInlineMarkdown(cell))
+			builder.WriteString("</" + tag + ">")
+		}
+		builder.WriteString("</tr>\n")
+	}
+	builder.WriteString("<table>\n<thead>\n")
+	writeRow(header, "th")
+	builder.WriteString("</thead>\n")
+	if len(rows) > 0 {
+		builder.WriteString("<tbody>\n")
+		for _, row := range rows {
+			writeRow(row, "td")
+		}
+		builder.WriteString("</tbody>\n")
+	}
+	builder.WriteString("</table>\n")
 }
 
 func markdownHeadingLevel(line string) int {
@@ -428,36 +607,86 @@ func ipRangeToCIDRs(start, end uint32) []string {
 }
 
 var (
-	reScript     = regexp.MustCompile(`(?is)<script[^>]*>.*?</script>`)
-	reStyle      = regexp.MustCompile(`(?is)<style[^>]*>.*?</style>`)
-	reHeading    = regexp.MustCompile(`(?is)<h([1-6])[^>]*>(.*?)</h[1-6]>`)
-	reParagraph  = regexp.MustCompile(`(?is)<p[^>]*>(.*?)</p>`)
-	reDiv        = regexp.MustCompile(`(?is)<div[^>]*>(.*?)</div>`)
-	reListItem   = regexp.MustCompile(`(?is)<li[^>]*>(.*?)</li>`)
-	reStrong     = regexp.MustCompile(`(?is)<(?:strong|b)[^>]*>(.*?)</(?:strong|b)>`)
-	reEm         = regexp.MustCompile(`(?is)<(?:em|i)[^>]*>(.*?)</(?:em|i)>`)
-	reCodeBlock  = regexp.MustCompile(`(?is)<pre[^>]*><code[^>]*>(.*?)</code></pre>`)
-	reInlineCode = regexp.MustCompile(`(?is)<code[^>]*>(.*?)</code>`)
-	reLink       = regexp.MustCompile(`(?is)<a[^>]*href=["'](.*?)["'][^>]*>(.*?)</a>`)
-	reBreak      = regexp.MustCompile(`(?is)<br\s*/?>`)
-	reTag        = regexp.MustCompile(`(?is)<[^>]+>`)
+	reScript       = common.LazyRegexp(`(?is)<script[^>]*>.*?</script>`)
+	reStyle        = common.LazyRegexp(`(?is)<style[^>]*>.*?</style>`)
+	reHeading      = common.LazyRegexp(`(?is)<h([1-6])[^>]*>(.*?)</h[1-6]>`)
+	reParagraph    = common.LazyRegexp(`(?is)<p[^>]*>(.*?)</p>`)
+	reDiv          = common.LazyRegexp(`(?is)<div[^>]*>(.*?)</div>`)
+	reListTag      = common.LazyRegexp(`(?i)<(/?)(ul|ol|li)\b([^>]*)>`)
+	reListStart    = common.LazyRegexp(`(?i)\bstart=["']?(\d+)`)
+	reStrong       = common.LazyRegexp(`(?is)<(?:strong|b)[^>]*>(.*?)</(?:strong|b)>`)
+	reEm           = common.LazyRegexp(`(?is)<(?:em|i)[^>]*>(.*?)</(?:em|i)>`)
+	reCodeBlock    = common.LazyRegexp(`(?is)<pre[^>]*><code[^>]*>(.*?)</code></pre>`)
+	reInlineCode   = common.LazyRegexp(`(?is)<code[^>]*>(.*?)</code>`)
+	reLink         = common.LazyRegexp(`(?is)<a[^>]*href=["'](.*?)["'][^>]*>(.*?)</a>`)
+	reBreak        = common.LazyRegexp(`(?is)<br\s*/?>`)
+	reRule         = common.LazyRegexp(`(?i)<hr\b[^>]*>`)
+	reTable        = common.LazyRegexp(`(?is)<table[^>]*>.*?</table>`)
+	reTableRow     = common.LazyRegexp(`(?is)<tr[^>]*>(.*?)</tr>`)
+	reTableCell    = common.LazyRegexp(`(?is)<(th|td)([^>]*)>(.*?)</(?:th|td)>`)
+	reCellAlign    = common.LazyRegexp(`(?i)(?:text-align:\s*|align=["']?)(left|right|center)`)
+	reQuoteOpen    = common.LazyRegexp(`(?i)<blockquote[^>]*>`)
+	reQuoteClose   = common.LazyRegexp(`(?i)</blockquote>`)
+	reTag          = common.LazyRegexp(`(?is)<[^>]+>`)
+	reBlockPointer = common.LazyRegexp("\x00(\\d+)\x00")
 )
 
+// HTMLToMarkdown converts HTML back to Markdown, including nested and
+// ordered lists, blockquotes, horizontal rules and tables (as GFM tables).
 func HTMLToMarkdown(input string) (string, error) {
+	var blocks []string
+	return restoreMarkdownBlocks(htmlToMarkdown(input, &blocks), blocks), nil
+}
+
+// htmlToMarkdown converts input, parking finished code blocks, tables and
+// blockquotes in blocks behind placeholders so later passes leave them alone.
+func htmlToMarkdown(input string, blocks *[]string) string {
+	park := func(block string) string {
+		*blocks = append(*blocks, block)
+		return fmt.Sprintf("\n\n\x00%d\x00\n\n", len(*blocks)-1)
+	}
 	text := strings.ReplaceAll(input, "\r\n", "\n")
-	text = reScript.ReplaceAllString(text, "")
-	text = reStyle.ReplaceAllString(text, "")
-	text = reCodeBlock.ReplaceAllStringFunc(text, func(match string) string {
-		sub := reCodeBlock.FindStringSubmatch(match)
+	text = reScript().ReplaceAllString(text, "")
+	text = reStyle().ReplaceAllString(text, "")
+	text = reCodeBlock().ReplaceAllStringFunc(text, func(match string) string {
+		sub := reCodeBlock().FindStringSubmatch(match)
 		if len(sub) < 2 {
 			return match
 		}
-		content := strings.TrimSpace(htmlUnescape(sub[1]))
-		return "```\n" + content + "\n```\n\n"
+		content := strings.Trim(htmlUnescape(sub[1]), "\n")
+		return park("```\n" + content + "\n```")
 	})
-	text = reBreak.ReplaceAllString(text, "\n")
-	text = reHeading.ReplaceAllStringFunc(text, func(match string) string {
-		sub := reHeading.FindStringSubmatch(match)
+	text = reTable().ReplaceAllStringFunc(text, func(match string) string {
+		return park(htmlTableToMarkdown(match))
+	})
+	// Innermost blockquotes first: the last opening tag is closed by the
+	// first closing tag after it.
+	for {
+		opens := reQuoteOpen().FindAllStringIndex(text, -1)
+		if len(opens) == 0 {
+			break
+		}
+		open := opens[len(opens)-1]
+		end := len(text)
+		closeEnd := len(text)
+		if loc := reQuoteClose().FindStringIndex(text[open[1]:]); loc != nil {
+			end, closeEnd = open[1]+loc[0], open[1]+loc[1]
+		}
+		inner := restoreMarkdownBlocks(htmlToMarkdown(text[open[1]:end], blocks), *blocks)
+		quoted := strings.Split(inner, "\n")
+		for i, line := range quoted {
+			if line == "" {
+				quoted[i] = ">"
+			} else {
+				quoted[i] = "> " + line
+			}
+		}
+		text = text[:open[0]] + park(strings.Join(quoted, "\n")) + text[closeEnd:]
+	}
+	text = reBreak().ReplaceAllString(text, "\n")
+	text = reRule().ReplaceAllString(text, "\n\n---\n\n")
+	text = reHeading().ReplaceAllStringFunc(text, func(match string) string {
+		sub := reHeading().FindStringSubmatch(match)
 		if len(sub) < 3 {
 			return match
 		}
@@ -471,24 +700,11 @@ func HTMLToMarkdown(input string) (string, error) {
 		content := strings.TrimSpace(htmlUnescape(sub[2]))
 		return strings.Repeat("#", level) + " " + content + "\n\n"
 	})
-	text = reParagraph.ReplaceAllString(text, "\n$1\n\n")
-	text = reDiv.ReplaceAllString(text, "\n$1\n")
-	text = reListItem.ReplaceAllString(text, "\n- $1")
-	text = strings.ReplaceAll(text, "</ul>", "\n\n")
-	text = strings.ReplaceAll(text, "</ol>", "\n\n")
-	text = reStrong.ReplaceAllString(text, "**$1**")
-	text = reEm.ReplaceAllString(text, "*$1*")
-	text = reInlineCode.ReplaceAllString(text, "`$1`")
-	text = reLink.ReplaceAllStringFunc(text, func(match string) string {
-		sub := reLink.FindStringSubmatch(match)
-		if len(sub) < 3 {
-			return match
-		}
-		href := htmlUnescape(sub[1])
-		label := htmlUnescape(stripTags(sub[2]))
-		return fmt.Sprintf("[%s](%s)", label, href)
-	})
-	text = reTag.ReplaceAllString(text, "")
+	text = reParagraph().ReplaceAllString(text, "\n$1\n\n")
+	text = reDiv().ReplaceAllString(text, "\n$1\n")
+	text = htmlListsToMarkdown(text)
+	text = htmlInlineToMarkdown(text)
+	text = reTag().ReplaceAllString(text, "")
 	lines := strings.Split(htmlUnescape(text), "\n")
 	var compact []string
 	for _, line := range lines {
@@ -502,5 +718,159 @@ func HTMLToMarkdown(input string) (string, error) {
 		}
 		compact = append(compact, line)
 	}
-	return strings.TrimSpace(strings.Join(compact, "\n")), nil
+	return strings.TrimSpace(strings.Join(compact, "\n"))
+}
+
+// restoreMarkdownBlocks swaps placeholders back for their blocks; a block
+// only refers to blocks parked before it.
+func restoreMarkdownBlocks(text string, blocks []string) string {
+	for reBlockPointer().MatchString(text) {
+		text = reBlockPointer().ReplaceAllStringFunc(text, func(match string) string {
+			i, _ := strconv.Atoi(strings.Trim(match, "\x00"))
+			return blocks[i]
+		})
+	}
+	return text
+}
+
+func htmlInlineToMarkdown(text string) string {
+	text = reStrong().ReplaceAllString(text, "**$1**")
+	text = reEm().ReplaceAllString(text, "*$1*")
+	text = reInlineCode().ReplaceAllString(text, "`$1`")
+	return reLink().ReplaceAllStringFunc(text, func(match string) string {
+		sub := reLink().FindStringSubmatch(match)
+		if len(sub) < 3 {
+			return match
+		}
+		href := htmlUnescape(sub[1])
+		label := htmlUnescape(stripTags(sub[2]))
+		return fmt.Sprintf("[%s](%s)", label, href)
+	})
+}
+
+// htmlListsToMarkdown walks ul, ol and li tags in order so nested lists are
+// indented under their parent item and ordered items keep their numbers.
+func htmlListsToMarkdown(text string) string {
+	type level struct {
+		ordered bool
+		next    int
+		indent  string
+		width   int
+	}
+	var stack []level
+	var builder strings.Builder
+	last := 0
+	for _, loc := range reListTag().FindAllStringSubmatchIndex(text, -1) {
+		segment := text[last:loc[0]]
+		if len(stack) > 0 {
+			segment = strings.Join(strings.Fields(segment), " ")
+		}
+		builder.WriteString(segment)
+		last = loc[1]
+		closing := loc[3] > loc[2]
+		tag := strings.ToLower(text[loc[4]:loc[5]])
+		switch {
+		case tag == "li":
+			if closing || len(stack) == 0 {
+				continue
+			}
+			top := &stack[len(stack)-1]
+			marker := "- "
+			if top.ordered {
+				marker = strconv.Itoa(top.next) + ". "
+				top.next++
+			}
+			top.width = len(marker)
+			builder.WriteString("\n" + top.indent + marker)
+		case closing:
+			if len(stack) == 0 {
+				continue
+			}
+			stack = stack[:len(stack)-1]
+			if len(stack) == 0 {
+				builder.WriteString("\n\n")
+			}
+		default:
+			lvl := level{ordered: tag == "ol", next: 1}
+			if m := reListStart().FindStringSubmatch(text[loc[6]:loc[7]]); m != nil {
+				lvl.next, _ = strconv.Atoi(m[1])
+			}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				lvl.indent = parent.indent + strings.Repeat(" ", parent.width)
+			} else {
+				builder.WriteString("\n\n")
+			}
+			stack = append(stack, lvl)
+		}
+	}
+	builder.WriteString(text[last:])
+	return builder.String()
+}
+
+// htmlTableToMarkdown turns the first row into the header and reads column
+// alignment from its align attributes or text-align styles.
+func htmlTableToMarkdown(table string) string {
+	var rows [][]string
+	var aligns []string
+	width := 0
+	for _, row := range reTableRow().FindAllStringSubmatch(table, -1) {
+		var cells []string
+		for _, cell := range reTableCell().FindAllStringSubmatch(row[1], -1) {
+			if rows == nil {
+				align := ""
+				if m := reCellAlign().FindStringSubmatch(cell[2]); m != nil {
+					align = strings.ToLower(m[1])
+				}
+				aligns = append(aligns, align)
+			}
+			cells = append(cells, htmlCellToMarkdown(cell[3]))
+		}
+		rows = append(rows, cells)
+		width = max(width, len(cells))
+	}
+	if width == 0 {
+		return ""
+	}
+	var builder strings.Builder
+	writeRow := func(cells []string) {
+		builder.WriteString("|")
+		for i := range width {
+			cell := ""
+			if i < len(cells) {
+				cell = cells[i]
+			}
+			builder.WriteString(" " + cell + " |")
+		}
+		builder.WriteString("\n")
+	}
+	writeRow(rows[0])
+	separators := make([]string, width)
+	for i := range separators {
+		align := ""
+		if i < len(aligns) {
+			align = aligns[i]
+		}
+		switch align {
+		case "left":
+			separators[i] = ":---"
+		case "right":
+			separators[i] = "---:"
+		case "center":
+			separators[i] = ":---:"
+		default:
+			separators[i] = "---"
+		}
+	}
+	writeRow(separators)
+	for _, row := range rows[1:] {
+		writeRow(row)
+	}
+	return strings.TrimSuffix(builder.String(), "\n")
+}
+
+func htmlCellToMarkdown(cell string) string {
+	text := htmlInlineToMarkdown(reBreak().ReplaceAllString(cell, " "))
+	text = htmlUnescape(reTag().ReplaceAllString(text, ""))
+	return strings.ReplaceAll(strings.Join(strings.Fields(text), " "), "|", `\|`)
 }
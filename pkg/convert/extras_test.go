@@ -39,6 +39,31 @@ func TestMarkdownHTML(t *testing.T) {
 	require.Contains(t, md2, "Line")
 }
 
+func TestMarkdownBlocks(t *testing.T) {
+	html, err := MarkdownToHTML("1. one\n2. two\n   - nested\n3. three\n\n> quote\n> > deeper\n\n***\n\n| Name | Qty |\n|:-----|----:|\n| a \\| b | 1 |\n| c |")
+	require.NoError(t, err)
+	require.Contains(t, html, "<ol>\n<li>one</li>\n<li>two\n<ul>\n<li>nested</li>\n</ul>\n</li>\n<li>three</li>\n</ol>\n")
+	require.Contains(t, html, "<blockquote>\nquote\n<blockquote>\ndeeper\n</blockquote>\n</blockquote>\n<hr>\n")
+	require.Contains(t, html, `<tr><th style="text-align: left">Name</th><th style="text-align: right">Qty</th></tr>`)
+	require.Contains(t, html, `<tr><td style="text-align: left">a | b</td><td style="text-align: right">1</td></tr>`)
+	require.Contains(t, html, `<tr><td style="text-align: left">c</td><td style="text-align: right"></td></tr>`)
+
+	html, err = MarkdownToHTML("3) third\n4) fourth\n- - -")
+	require.NoError(t, err)
+	require.Equal(t, "<ol start=\"3\">\n<li>third</li>\n<li>fourth</li>\n</ol>\n<hr>\n", html)
+
+	md, err := HTMLToMarkdown(html)
+	require.NoError(t, err)
+	require.Equal(t, "3. third\n4. fourth\n\n---", md)
+
+	md, err = HTMLToMarkdown(`<ul><li>a<ol><li>b</li><li>c</li></ol></li><li>d</li></ul>` +
+		`<blockquote><p>quote <em>x</em></p><blockquote>deeper</blockquote></blockquote>` +
+		`<table><tr><th>Name</th><th align="right">Qty</th></tr><tr><td><b>a|b</b></td><td>1</td></tr></table>` +
+		`<pre><code>&lt;div&gt;</code></pre>`)
+	require.NoError(t, err)
+	require.Equal(t, "- a\n  1. b\n  2. c\n- d\n\n> quote *x*\n>\n> > deeper\n\n| Name | Qty |\n| --- | ---: |\n| **a\\|b** | 1 |\n\n```\n<div>\n```", md)
+}
+
 func TestIPv4Info(t *testing.T) {
 	res, err := IPv4Info("1.1.1.1")
 	require.NoError(t, err)
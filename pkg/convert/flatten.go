@@ -0,0 +1,265 @@
+package convert
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	FlattenArraysDot      = "dot"
+	FlattenArraysBrackets = "brackets"
+)
+
+// FlattenOptions sets how FlattenJSON writes paths and UnflattenJSON reads
+// them. Separator (default ".") joins object keys. Arrays is "dot" (the
+// default), where an element is one more key as in items.0.name, or
+// "brackets", where it is an index as in items[0].name; keys holding the
+// separator or a bracket are then quoted as in a["b.c"].
+type FlattenOptions struct {
+	Separator string `json:"separator,omitempty"`
+	Arrays    string `json:"arrays,omitempty"`
+}
+
+func (o FlattenOptions) normalize() (FlattenOptions, error) {
+	if o.Separator == "" {
+		o.Separator = "."
+	}
+	switch o.Arrays {
+	case "":
+		o.Arrays = FlattenArraysDot
+	case FlattenArraysDot, FlattenArraysBrackets:
+	default:
+		return o, fmt.Errorf("unsupported array notation %q", o.Arrays)
+	}
+	return o, nil
+}
+
+// FlattenJSON turns a nested JSON object or array into one object mapping
+// each path to its value, in document order, for CSV columns, environment
+// variables or key-value stores. Scalars keep their type and empty objects
+// and arrays are kept as values, so UnflattenJSON with the same options
+// gives the document back. Paths that come out the same, such as {"a.b": 1}
+// and {"a": {"b": 2}} with dots, are an error.
+func FlattenJSON(input string, opts FlattenOptions) (string, error) {
+	o, err := opts.normalize()
+	if err != nil {
+		return "", err
+	}
+	doc, err := parseJSONDoc(input)
+	if err != nil {
+		return "", err
+	}
+	if doc.kind != docObject && doc.kind != docArray {
+		return "", errors.New("only an object or an array can be flattened")
+	}
+	flat := &docNode{kind: docObject}
+	seen := map[string]bool{}
+	var walk func(n *docNode, path string) error
+	walk = func(n *docNode, path string) error {
+		if n != doc && (n.kind != docObject && n.kind != docArray || len(n.items) == 0) {
+			if seen[path] {
+				return fmt.Errorf("more than one value flattens to %q", path)
+			}
+			seen[path] = true
+			flat.keys = append(flat.keys, docKey{name: path})
+			flat.items = append(flat.items, n)
+			return nil
+		}
+		for i, item := range n.items {
+			var child string
+			if n.kind == docObject {
+				child = o.joinKey(path, n.keys[i].name, n == doc)
+			} else {
+				child = o.joinIndex(path, i, n == doc)
+			}
+			if err := walk(item, child); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := walk(doc, ""); err != nil {
+		return "", err
+	}
+	return docToJSON(flat, "  ")
+}
+
+// joinKey appends key to path, which is the root's when top is set.
+func (o FlattenOptions) joinKey(path, key string, top bool) string {
+	if o.Arrays == FlattenArraysBrackets && (key == "" || strings.Contains(key, o.Separator) || strings.ContainsAny(key, "[]")) {
+		quoted, _ := json.Marshal(key)
+		return path + "[" + string(quoted) + "]"
+	}
+	if top {
+		return key
+	}
+	return path + o.Separator + key
+}
+
+func (o FlattenOptions) joinIndex(path string, i int, top bool) string {
+	if o.Arrays == FlattenArraysBrackets {
+		return path + "[" + strconv.Itoa(i) + "]"
+	}
+	return o.joinKey(path, strconv.Itoa(i), top)
+}
+
+// flatSegment is one step of a flattened path: a key, or with index set an
+// array element.
+type flatSegment struct {
+	name  string
+	index bool
+}
+
+// split reads a flattened path back into its segments. With dots, a
+// segment that is a whole number may be an array index.
+func (o FlattenOptions) split(path string) ([]flatSegment, error) {
+	if o.Arrays == FlattenArraysDot {
+		var segments []flatSegment
+		for _, name := range strings.Split(path, o.Separator) {
+			segments = append(segments, flatSegment{name, isArrayIndex(name)})
+		}
+		return segments, nil
+	}
+	var segments []flatSegment
+	rest, name := path, true
+	for {
+		switch {
+		case strings.HasPrefix(rest, "["):
+			seg, n, err := splitBracket(rest)
+			if err != nil {
+				return nil, fmt.Errorf("path %q: %w", path, err)
+			}
+			segments = append(segments, seg)
+			rest, name = rest[n:], false
+		case name:
+			end := len(rest)
+			if i := strings.IndexAny(rest, "[]"); i >= 0 {
+				end = i
+			}
+			if i := strings.Index(rest[:end], o.Separator); i >= 0 {
+				end = i
+			}
+			segments = append(segments, flatSegment{name: rest[:end]})
+			rest, name = rest[end:], false
+		case rest == "":
+			return segments, nil
+		case strings.HasPrefix(rest, o.Separator):
+			rest, name = rest[len(o.Separator):], true
+		default:
+			return nil, fmt.Errorf("path %q: unexpected %q", path, rest[:1])
+		}
+	}
+}
+
+// splitBracket reads the [n] or ["key"] that s starts with and returns it
+// with its length.
+func splitBracket(s string) (flatSegment, int, error) {
+	if strings.HasPrefix(s, `["`) {
+		dec := json.NewDecoder(strings.NewReader(s[1:]))
+		var key string
+		if err := dec.Decode(&key); err != nil {
+			return flatSegment{}, 0, errors.New("bad quoted key")
+		}
+		end := 1 + int(dec.InputOffset())
+		if end >= len(s) || s[end] != ']' {
+			return flatSegment{}, 0, errors.New("] expected after quoted key")
+		}
+		return flatSegment{name: key}, end + 1, nil
+	}
+	end := strings.IndexByte(s, ']')
+	if end < 0 || !isArrayIndex(s[1:end]) {
+		return flatSegment{}, 0, errors.New("bad array index")
+	}
+	return flatSegment{s[1:end], true}, end + 1, nil
+}
+
+func isArrayIndex(s string) bool {
+	if s == "" || len(s) > 1 && s[0] == '0' {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// UnflattenJSON nests an object of flattened paths, as FlattenJSON writes
+// them, back into a document. Objects whose keys are all array indexes
+// from 0 up become arrays; with dots every whole-number key counts, with
+// brackets only [n]. A path that runs through another path's value is an
+// error.
+func UnflattenJSON(input string, opts FlattenOptions) (string, error) {
+	o, err := opts.normalize()
+	if err != nil {
+		return "", err
+	}
+	flat, err := parseJSONDoc(input)
+	if err != nil {
+		return "", err
+	}
+	if flat.kind != docObject {
+		return "", errors.New("a flattened document must be an object")
+	}
+	root := &docNode{kind: docObject}
+	// indexed holds the nodes built here, true while every key is an index.
+	indexed := map[*docNode]bool{root: true}
+	for i, k := range flat.keys {
+		segments, err := o.split(k.name)
+		if err != nil {
+			return "", err
+		}
+		node := root
+		for j, seg := range segments {
+			if _, built := indexed[node]; !built {
+				return "", fmt.Errorf("%q is inside the value of another path", k.name)
+			}
+			indexed[node] = indexed[node] && seg.index
+			at := node.memberIndex(seg.name)
+			if j == len(segments)-1 {
+				if at >= 0 {
+					return "", fmt.Errorf("%q holds more than one value", k.name)
+				}
+				node.keys = append(node.keys, docKey{name: seg.name})
+				node.items = append(node.items, flat.items[i])
+				break
+			}
+			if at < 0 {
+				child := &docNode{kind: docObject}
+				indexed[child] = true
+				node.keys = append(node.keys, docKey{name: seg.name})
+				node.items = append(node.items, child)
+				at = len(node.items) - 1
+			}
+			node = node.items[at]
+		}
+	}
+	var arrays func(n *docNode) *docNode
+	arrays = func(n *docNode) *docNode {
+		all, built := indexed[n]
+		if !built {
+			return n
+		}
+		for i, item := range n.items {
+			n.items[i] = arrays(item)
+		}
+		if !all || len(n.keys) == 0 {
+			return n
+		}
+		items := make([]*docNode, len(n.items))
+		for i, k := range n.keys {
+			at, _ := strconv.Atoi(k.name)
+			if at >= len(items) || items[at] != nil {
+				return n
+			}
+			items[at] = n.items[i]
+		}
+		return &docNode{kind: docArray, items: slices.Clip(items)}
+	}
+	return docToJSON(arrays(root), "  ")
+}
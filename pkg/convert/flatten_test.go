@@ -0,0 +1,84 @@
+package convert
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFlattenJSON(t *testing.T) {
+	doc := `{"name": "demo", "db": {"host": "localhost", "port": 5432}, "tags": ["a", {"b": null}], "big": 12345678901234567890, "empty": {}, "none": []}`
+
+	got, err := FlattenJSON(doc, FlattenOptions{})
+	require.NoError(t, err)
+	require.Equal(t, `{
+  "name": "demo",
+  "db.host": "localhost",
+  "db.port": 5432,
+  "tags.0": "a",
+  "tags.1.b": null,
+  "big": 12345678901234567890,
+  "empty": {},
+  "none": []
+}`, got)
+	back, err := UnflattenJSON(got, FlattenOptions{})
+	require.NoError(t, err)
+	require.JSONEq(t, doc, back)
+
+	opts := FlattenOptions{Separator: "__", Arrays: FlattenArraysBrackets}
+	got, err = FlattenJSON(`{"db": {"hosts": [{"name": "a"}, {"name": "b"}]}, "a__b": {"c[1]": true}}`, opts)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"db__hosts[0]__name": "a", "db__hosts[1]__name": "b", "[\"a__b\"][\"c[1]\"]": true}`, got)
+	back, err = UnflattenJSON(got, opts)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"db": {"hosts": [{"name": "a"}, {"name": "b"}]}, "a__b": {"c[1]": true}}`, back)
+
+	got, err = FlattenJSON(`[{"a": 1}, [2]]`, FlattenOptions{Arrays: FlattenArraysBrackets})
+	require.NoError(t, err)
+	require.JSONEq(t, `{"[0].a": 1, "[1][0]": 2}`, got)
+	back, err = UnflattenJSON(got, FlattenOptions{Arrays: FlattenArraysBrackets})
+	require.NoError(t, err)
+	require.JSONEq(t, `[{"a": 1}, [2]]`, back)
+
+	_, err = FlattenJSON(`{"a.b": 1, "a": {"b": 2}}`, FlattenOptions{})
+	require.EqualError(t, err, `more than one value flattens to "a.b"`)
+	_, err = FlattenJSON(`1`, FlattenOptions{})
+	require.Error(t, err)
+	_, err = FlattenJSON(`{}`, FlattenOptions{Arrays: "nope"})
+	require.Error(t, err)
+}
+
+func TestUnflattenJSON(t *testing.T) {
+	for _, c := range []struct {
+		flat string
+		opts FlattenOptions
+		want string
+	}{
+		// Whole-number keys become arrays only when they count from 0 up.
+		{`{"a.1": "x", "a.0": "y", "b.1": "z", "c.01": 1}`, FlattenOptions{}, `{"a": ["y", "x"], "b": {"1": "z"}, "c": {"01": 1}}`},
+		{`{"a.0": 1, "a[1]": 2}`, FlattenOptions{Arrays: FlattenArraysBrackets}, `{"a": {"0": 1, "1": 2}}`},
+		{`{"a[0]": 1, "a[1]": 2}`, FlattenOptions{Arrays: FlattenArraysBrackets}, `{"a": [1, 2]}`},
+		{`{"DB_HOST": "x", "DB_PORT": 1}`, FlattenOptions{Separator: "_"}, `{"DB": {"HOST": "x", "PORT": 1}}`},
+		{`{}`, FlattenOptions{}, `{}`},
+	} {
+		got, err := UnflattenJSON(c.flat, c.opts)
+		require.NoError(t, err, c.flat)
+		require.JSONEq(t, c.want, got, c.flat)
+	}
+
+	for _, c := range []struct {
+		flat string
+		opts FlattenOptions
+		err  string
+	}{
+		{`{"a": 1, "a.b": 2}`, FlattenOptions{}, `"a.b" is inside the value of another path`},
+		{`{"a.b": 2, "a": 1}`, FlattenOptions{}, `"a" holds more than one value`},
+		{`{"a[x]": 1}`, FlattenOptions{Arrays: FlattenArraysBrackets}, `path "a[x]": bad array index`},
+		{`{"a[\"b]": 1}`, FlattenOptions{Arrays: FlattenArraysBrackets}, `path "a[\"b]": bad quoted key`},
+		{`{"a[0]b": 1}`, FlattenOptions{Arrays: FlattenArraysBrackets}, `path "a[0]b": unexpected "b"`},
+		{`[1]`, FlattenOptions{}, `a flattened document must be an object`},
+	} {
+		_, err := UnflattenJSON(c.flat, c.opts)
+		require.EqualError(t, err, c.err, c.flat)
+	}
+}
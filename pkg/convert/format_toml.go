@@ -0,0 +1,191 @@
+package convert
+
+import (
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"github.com/pelletier/go-toml/v2/unstable"
+)
+
+// formatTOMLDocument reformats TOML in place from its syntax tree, so
+// comments, blank lines between entries and the order of keys and tables
+// stay as written. Spacing is normalized: no indentation before keys and
+// headers, one space around = and before a trailing comment, and inline
+// arrays and tables written as [a, b] and { k = v }. Arrays that span lines
+// or hold comments put each element on its own line. Scalars keep their
+// literal text, so quoting, number bases and datetimes are untouched.
+func formatTOMLDocument(input string, o ConvertOptions) (string, error) {
+	// The syntax tree does not catch redefined keys and tables.
+	if err := toml.Unmarshal([]byte(input), &map[string]any{}); err != nil {
+		return "", err
+	}
+	f := &tomlFormatter{
+		lines:  strings.Split(input, "\n"),
+		indent: o.indentString(),
+	}
+	f.parser.KeepComments = true
+	f.parser.Reset([]byte(input))
+	var out []string
+	for f.parser.NextExpression() {
+		expr := f.parser.Expression()
+		line := f.line(expr)
+		if len(out) > 0 && f.blankBefore(line) {
+			out = append(out, "")
+		}
+		var text string
+		switch expr.Kind {
+		case unstable.Comment:
+			text = f.comment(expr)
+		case unstable.Table:
+			text = "[" + f.key(expr.Key()) + "]"
+		case unstable.ArrayTable:
+			text = "[[" + f.key(expr.Key()) + "]]"
+		case unstable.KeyValue:
+			text = f.keyValue(expr, line, 0)
+		}
+		if next := expr.Next(); next != nil && next.Kind == unstable.Comment {
+			text += " " + f.comment(next)
+		}
+		out = append(out, text)
+	}
+	if err := f.parser.Error(); err != nil {
+		return "", err
+	}
+	if len(out) == 0 {
+		return "", nil
+	}
+	return strings.Join(out, "\n") + "\n", nil
+}
+
+type tomlFormatter struct {
+	parser unstable.Parser
+	lines  []string
+	indent string
+}
+
+// line returns the 1-based source line a node starts on, or 0 when it has
+// no text of its own, as an empty array.
+func (f *tomlFormatter) line(n *unstable.Node) int {
+	switch n.Kind {
+	case unstable.Comment, unstable.String:
+		return f.parser.Shape(n.Raw).Start.Line
+	case unstable.KeyValue, unstable.Table, unstable.ArrayTable:
+		if key := n.Key(); key.Next() {
+			return f.parser.Shape(key.Node().Raw).Start.Line
+		}
+	case unstable.Array, unstable.InlineTable:
+		if it := n.Children(); it.Next() {
+			return f.line(it.Node())
+		}
+	default:
+		return f.parser.Shape(f.parser.Range(n.Data)).Start.Line
+	}
+	return 0
+}
+
+// blankBefore reports whether the source line before line is blank.
+func (f *tomlFormatter) blankBefore(line int) bool {
+	return line > 1 && line-2 < len(f.lines) && strings.TrimSpace(f.lines[line-2]) == ""
+}
+
+func (f *tomlFormatter) comment(n *unstable.Node) string {
+	return strings.TrimRight(string(n.Data), " \t\r")
+}
+
+// key joins the parts of a dotted key as written, quotes included.
+func (f *tomlFormatter) key(it unstable.Iterator) string {
+	var parts []string
+	for it.Next() {
+		parts = append(parts, string(f.parser.Raw(it.Node().Raw)))
+	}
+	return strings.Join(parts, ".")
+}
+
+func (f *tomlFormatter) keyValue(kv *unstable.Node, line, depth int) string {
+	return f.key(kv.Key()) + " = " + f.value(kv.Value(), line, depth)
+}
+
+// value writes a value that starts on line, depth arrays deep.
+func (f *tomlFormatter) value(n *unstable.Node, line, depth int) string {
+	switch n.Kind {
+	case unstable.String:
+		return string(f.parser.Raw(n.Raw))
+	case unstable.InlineTable:
+		var fields []string
+		for it := n.Children(); it.Next(); {
+			fields = append(fields, f.keyValue(it.Node(), line, depth))
+		}
+		if len(fields) == 0 {
+			return "{}"
+		}
+		return "{ " + strings.Join(fields, ", ") + " }"
+	case unstable.Array:
+		return f.array(n, line, depth)
+	}
+	return string(n.Data)
+}
+
+func (f *tomlFormatter) array(n *unstable.Node, line, depth int) string {
+	var items []*unstable.Node
+	multiline := false
+	for it := n.Children(); it.Next(); {
+		item := it.Node()
+		items = append(items, item)
+		if l := f.line(item); item.Kind == unstable.Comment || l != 0 && l != line {
+			multiline = true
+		}
+	}
+	if !multiline {
+		values := make([]string, len(items))
+		for i, item := range items {
+			values[i] = f.value(item, f.line(item), depth+1)
+		}
+		return "[" + strings.Join(values, ", ") + "]"
+	}
+	indent := strings.Repeat(f.indent, depth+1)
+	var b strings.Builder
+	b.WriteString("[")
+	last := line
+	for _, item := range items {
+		comments := []*unstable.Node{item}
+		if item.Kind == unstable.Comment {
+			// A run of comments is the first with the rest as children.
+			for it := item.Children(); it.Next(); {
+				comments = append(comments, it.Node())
+			}
+		} else {
+			comments = nil
+		}
+		for _, c := range comments {
+			l := f.line(c)
+			if l == last && b.Len() > 1 {
+				b.WriteString(" " + f.comment(c))
+				continue
+			}
+			f.newline(&b, l, last, indent)
+			b.WriteString(f.comment(c))
+			last = l
+		}
+		if comments != nil {
+			continue
+		}
+		l := f.line(item)
+		if l == 0 {
+			l = last
+		}
+		f.newline(&b, l, last, indent)
+		b.WriteString(f.value(item, l, depth+1) + ",")
+		last = l
+	}
+	b.WriteString("\n" + strings.Repeat(f.indent, depth) + "]")
+	return b.String()
+}
+
+// newline starts a line in a multiline array, keeping a blank line that
+// separated the element from the one before.
+func (f *tomlFormatter) newline(b *strings.Builder, line, last int, indent string) {
+	if line > last+1 && f.blankBefore(line) {
+		b.WriteString("\n")
+	}
+	b.WriteString("\n" + indent)
+}
@@ -0,0 +1,59 @@
+package convert
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatTOMLDocument(t *testing.T) {
+	input := `# Service settings
+title   =   "api"   # public name
+
+
+  [server]
+  port = 0x1F90
+  started = 1979-05-27T07:32:00Z
+  hosts = [ 'a',
+    # the backup
+    'b',   # after b
+  ]
+  limits = {cpu=2,tags=[1,2]}
+
+[[workers]]
+name = "w1"
+`
+	out, err := FormatContent(formatTOML, input, false)
+	require.NoError(t, err)
+	require.Equal(t, `# Service settings
+title = "api" # public name
+
+[server]
+port = 0x1F90
+started = 1979-05-27T07:32:00Z
+hosts = [
+  'a',
+  # the backup
+  'b', # after b
+]
+limits = { cpu = 2, tags = [1, 2] }
+
+[[workers]]
+name = "w1"
+`, out)
+
+	out, err = FormatContentWithOptions(formatTOML, "a = [\n1,\n\n[2, 3], []]\n", false, WithIndent(4))
+	require.NoError(t, err)
+	require.Equal(t, "a = [\n    1,\n\n    [2, 3],\n    [],\n]\n", out)
+
+	out, err = FormatContent(formatTOML, "a = [1, [], {}]\n", false)
+	require.NoError(t, err)
+	require.Equal(t, "a = [1, [], {}]\n", out)
+
+	out, err = FormatContent(formatTOML, "", false)
+	require.NoError(t, err)
+	require.Equal(t, "", out)
+
+	_, err = FormatContent(formatTOML, "a = 1\na = 2\n", false)
+	require.Error(t, err)
+}
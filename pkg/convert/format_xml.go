@@ -0,0 +1,227 @@
+package convert
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/linzeyan/transform-go/pkg/common"
+)
+
+var xmlPseudoAttrRe = common.LazyRegexp(`([A-Za-z]+)\s*=\s*(?:"([^"]*)"|'([^']*)')`)
+
+// xmlNode is a node of the tree formatXMLDocument prints. An element has
+// start set; anything else is raw source: text, a comment, a processing
+// instruction or a directive.
+type xmlNode struct {
+	start    *xml.StartElement
+	raw      string
+	text     bool
+	selfEnd  bool   // written as <a/>
+	content  string // source between the start and end tags
+	children []*xmlNode
+}
+
+// formatXMLDocument indents XML from its token stream, so elements,
+// attributes, namespace prefixes, comments and processing instructions
+// stay as written. Elements holding only text stay on one line, and mixed
+// content or xml:space="preserve" is kept exactly. Attribute values are
+// re-escaped; text, CDATA sections and entities are copied from the source.
+func formatXMLDocument(input string, o ConvertOptions) (string, error) {
+	// RawToken does not check that end tags match.
+	check := xml.NewDecoder(strings.NewReader(input))
+	for {
+		if _, err := check.Token(); err == io.EOF {
+			break
+		} else if err != nil {
+			return "", xmlSyntaxError(input, check, err)
+		}
+	}
+	dec := xml.NewDecoder(strings.NewReader(input))
+	root := &xmlNode{}
+	stack := []*xmlNode{root}
+	// begin is where the content of each open element starts.
+	begin := []int{0}
+	decl, hasDecl := "", false
+	for {
+		from := int(dec.InputOffset())
+		tok, err := dec.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", xmlSyntaxError(input, dec, err)
+		}
+		to := int(dec.InputOffset())
+		parent := stack[len(stack)-1]
+		switch t := tok.(type) {
+		case xml.StartElement:
+			n := &xmlNode{start: &t, selfEnd: strings.HasSuffix(input[from:to], "/>")}
+			parent.children = append(parent.children, n)
+			stack = append(stack, n)
+			begin = append(begin, to)
+		case xml.EndElement:
+			parent.content = input[begin[len(begin)-1]:from]
+			stack, begin = stack[:len(stack)-1], begin[:len(begin)-1]
+		case xml.ProcInst:
+			if t.Target == "xml" && parent == root {
+				decl, hasDecl = string(t.Inst), true
+				continue
+			}
+			parent.children = append(parent.children, &xmlNode{raw: input[from:to]})
+		case xml.CharData:
+			parent.children = append(parent.children, &xmlNode{raw: input[from:to], text: true})
+		default:
+			parent.children = append(parent.children, &xmlNode{raw: input[from:to]})
+		}
+	}
+	var lines []string
+	header, err := o.xmlDeclaration(decl, hasDecl)
+	if err != nil {
+		return "", err
+	}
+	if header != "" {
+		lines = append(lines, header)
+	}
+	p := xmlPrinter{indent: o.indentString(), wrap: o.XMLWrapAttributes}
+	for _, n := range root.children {
+		if s := p.node(n, 0); s != "" {
+			lines = append(lines, s)
+		}
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// xmlDeclaration writes the XML declaration for output whose source has
+// the one with pseudo-attributes inst, when found, as XMLDeclaration and
+// XMLStandalone say; "" means none.
+func (o ConvertOptions) xmlDeclaration(inst string, found bool) (string, error) {
+	switch o.XMLDeclaration {
+	case "", XMLDeclarationKeep:
+	case XMLDeclarationAlways:
+		found = true
+	case XMLDeclarationOmit:
+		return "", nil
+	default:
+		return "", fmt.Errorf("unsupported XML declaration mode %q", o.XMLDeclaration)
+	}
+	switch o.XMLStandalone {
+	case "":
+	case "yes", "no":
+		found = true
+	default:
+		return "", fmt.Errorf("unsupported XML standalone value %q", o.XMLStandalone)
+	}
+	if !found {
+		return "", nil
+	}
+	attrs := map[string]string{"version": "1.0", "encoding": "UTF-8"}
+	if inst != "" {
+		delete(attrs, "encoding")
+	}
+	for _, m := range xmlPseudoAttrRe().FindAllStringSubmatch(inst, -1) {
+		attrs[m[1]] = m[2] + m[3]
+	}
+	if o.XMLStandalone != "" {
+		attrs["standalone"] = o.XMLStandalone
+	}
+	decl := "<?xml"
+	for _, name := range []string{"version", "encoding", "standalone"} {
+		if v, ok := attrs[name]; ok {
+			decl += " " + name + `="` + v + `"`
+		}
+	}
+	return decl + "?>", nil
+}
+
+type xmlPrinter struct {
+	indent string
+	wrap   int
+}
+
+// node writes n at depth, or "" for text that is only whitespace.
+func (p xmlPrinter) node(n *xmlNode, depth int) string {
+	prefix := strings.Repeat(p.indent, depth)
+	if n.start == nil {
+		raw := n.raw
+		if n.text {
+			raw = strings.TrimSpace(raw)
+		}
+		if raw == "" {
+			return ""
+		}
+		return prefix + raw
+	}
+	name := xmlName(n.start.Name)
+	tag := prefix + p.startTag(n.start, depth)
+	if n.selfEnd {
+		return tag + "/>"
+	}
+	end := "</" + name + ">"
+	hasText, hasElems := false, false
+	for _, c := range n.children {
+		hasText = hasText || c.text && strings.TrimSpace(c.raw) != ""
+		hasElems = hasElems || c.start != nil
+	}
+	switch {
+	case hasText && hasElems || xmlPreservesSpace(n.start):
+		return tag + ">" + n.content + end
+	case !hasElems:
+		return tag + ">" + strings.TrimSpace(n.content) + end
+	}
+	lines := []string{tag + ">"}
+	for _, c := range n.children {
+		if s := p.node(c, depth+1); s != "" {
+			lines = append(lines, s)
+		}
+	}
+	return strings.Join(append(lines, prefix+end), "\n")
+}
+
+// startTag writes a start tag without its closing > or />, with each
+// attribute on its own line when there are more than wrap.
+func (p xmlPrinter) startTag(start *xml.StartElement, depth int) string {
+	var b strings.Builder
+	b.WriteString("<" + xmlName(start.Name))
+	sep := " "
+	if p.wrap > 0 && len(start.Attr) > p.wrap {
+		sep = "\n" + strings.Repeat(p.indent, depth+1)
+	}
+	for _, a := range start.Attr {
+		b.WriteString(sep + xmlName(a.Name) + `="` + escapeXMLAttr(a.Value) + `"`)
+	}
+	return b.String()
+}
+
+// xmlName spells a name RawToken read with its prefix, if any.
+func xmlName(name xml.Name) string {
+	if name.Space == "" {
+		return name.Local
+	}
+	return name.Space + ":" + name.Local
+}
+
+func xmlPreservesSpace(start *xml.StartElement) bool {
+	for _, a := range start.Attr {
+		if a.Name.Space == "xml" && a.Name.Local == "space" {
+			return a.Value == "preserve"
+		}
+	}
+	return false
+}
+
+var xmlAttrEscaper = strings.NewReplacer(
+	"&", "&amp;",
+	"<", "&lt;",
+	`"`, "&quot;",
+	"\t", "&#x9;",
+	"\n", "&#xA;",
+	"\r", "&#xD;",
+)
+
+// escapeXMLAttr escapes an attribute value for double quotes, keeping the
+// whitespace characters that attribute normalization would turn to spaces.
+func escapeXMLAttr(s string) string {
+	return xmlAttrEscaper.Replace(s)
+}
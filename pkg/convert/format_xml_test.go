@@ -0,0 +1,56 @@
+package convert
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatXMLDocument(t *testing.T) {
+	input := `<?xml version='1.0'?>
+<!-- catalog -->
+<c:catalog xmlns:c="urn:catalog" note="a &amp; &quot;b&quot;"><c:book id="1" lang="en" format="paper"><title><![CDATA[<Go>]]></title></c:book>
+<p>Read <b>this</b> first</p><empty></empty><self/>   <pre xml:space="preserve">  a
+  b </pre>
+<!-- end --><t>  &lt;x&gt;  </t></c:catalog>
+`
+	out, err := FormatContent(formatXML, input, false)
+	require.NoError(t, err)
+	require.Equal(t, `<?xml version="1.0"?>
+<!-- catalog -->
+<c:catalog xmlns:c="urn:catalog" note="a &amp; &quot;b&quot;">
+  <c:book id="1" lang="en" format="paper">
+    <title><![CDATA[<Go>]]></title>
+  </c:book>
+  <p>Read <b>this</b> first</p>
+  <empty></empty>
+  <self/>
+  <pre xml:space="preserve">  a
+  b </pre>
+  <!-- end -->
+  <t>&lt;x&gt;</t>
+</c:catalog>`, out)
+
+	out, err = FormatContentWithOptions(formatXML, `<a x="1" y="2"><b z="3"/></a>`, false, WithIndent(4), WithXMLWrapAttributes(1))
+	require.NoError(t, err)
+	require.Equal(t, "<a\n    x=\"1\"\n    y=\"2\">\n    <b z=\"3\"/>\n</a>", out)
+
+	out, err = FormatContentWithOptions(formatXML, `<a/>`, false, WithXMLDeclaration(XMLDeclarationAlways))
+	require.NoError(t, err)
+	require.Equal(t, "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<a/>", out)
+	out, err = FormatContentWithOptions(formatXML, "<?xml version=\"1.0\" encoding=\"utf-8\"?><a/>", false, WithXMLStandalone("no"))
+	require.NoError(t, err)
+	require.Equal(t, "<?xml version=\"1.0\" encoding=\"utf-8\" standalone=\"no\"?>\n<a/>", out)
+	out, err = FormatContentWithOptions(formatXML, "<?xml version=\"1.0\"?><a/>", false, WithXMLDeclaration(XMLDeclarationOmit))
+	require.NoError(t, err)
+	require.Equal(t, "<a/>", out)
+
+	out, err = ConvertFormatsWithOptions(formatJSON, formatXML, `{"a": 1}`, WithXMLStandalone("yes"))
+	require.NoError(t, err)
+	require.Equal(t, "<?xml version=\"1.0\" encoding=\"UTF-8\" standalone=\"yes\"?>\n<root>\n  <a>1</a>\n</root>\n", out)
+
+	_, err = FormatContentWithOptions(formatXML, `<a/>`, false, WithXMLDeclaration("sometimes"))
+	require.Error(t, err)
+	_, err = FormatContent(formatXML, "<a>\n  <b></c>\n</a>", false)
+	require.ErrorContains(t, err, "line 2")
+}
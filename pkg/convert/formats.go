@@ -7,77 +7,107 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"sort"
+	"net/url"
 	"strings"
 
 	"github.com/linzeyan/transform-go/pkg/common"
 	"github.com/pelletier/go-toml/v2"
-	"gopkg.in/yaml.v3"
 )
 
 func JSONToYAML(input string) (string, error) {
-	data, err := decodeJSONValue(input)
+	return jsonToYAMLWithOptions(input, NewConvertOptions())
+}
+
+func jsonToYAMLWithOptions(input string, o ConvertOptions) (string, error) {
+	doc, err := parseJSONDoc(input)
 	if err != nil {
 		return "", err
 	}
-	return common.EncodeYAML(common.NormalizeJSONNumbers(data))
+	if o.YAMLDocuments != YAMLDocumentsStream || doc.kind != docArray {
+		return docYAML(doc, o)
+	}
+	parts := make([]string, len(doc.items))
+	for i, item := range doc.items {
+		if parts[i], err = docYAML(item, o); err != nil {
+			return "", err
+		}
+	}
+	return strings.Join(parts, "\n---\n"), nil
 }
 
-func YAMLToJSON(input string) (string, error) {
-	var data interface{}
-	if err := yaml.Unmarshal([]byte(input), &data); err != nil {
-		return "", err
+// docYAML writes doc as YAML with the indent and key order o asks for.
+func docYAML(doc *docNode, o ConvertOptions) (string, error) {
+	indent := o.Indent
+	if indent <= 0 {
+		indent = 2
 	}
-	normalized := common.NormalizeYAML(data)
-	buf := &bytes.Buffer{}
-	enc := json.NewEncoder(buf)
-	enc.SetEscapeHTML(false)
-	enc.SetIndent("", "  ")
-	if err := enc.Encode(normalized); err != nil {
-		return "", err
+	if !o.SortKeys {
+		node, err := docToYAML(doc, nil)
+		if err != nil {
+			return "", err
+		}
+		return common.EncodeYAMLIndent(node, indent)
 	}
-	return buf.String(), nil
+	return common.EncodeYAMLIndent(common.NormalizeJSONNumbers(doc.value()), indent)
+}
+
+// YAMLToJSON reads YAML into JSON with keys in document order. Numbers
+// keep the digits they are written with where a float64 would round them.
+func YAMLToJSON(input string) (string, error) {
+	return yamlToJSONWithOptions(input, NewConvertOptions())
 }
 
 func JSONToTOML(input string) (string, error) {
-	data, err := decodeJSONValue(input)
+	return jsonToTOMLWithOptions(input, NewConvertOptions())
+}
+
+func jsonToTOMLWithOptions(input string, o ConvertOptions) (string, error) {
+	doc, err := parseJSONDoc(input)
 	if err != nil {
 		return "", err
 	}
-	obj, ok := data.(map[string]any)
-	if !ok {
+	if doc.kind != docObject {
 		return "", errors.New("TOML root must be an object")
 	}
-	out, err := toml.Marshal(common.NormalizeJSONNumbers(obj).(map[string]any))
+	buf := &bytes.Buffer{}
+	enc := toml.NewEncoder(buf)
+	enc.SetArraysMultiline(o.MultilineArrays)
+	numbers := newTOMLNumbers(o.SortKeys)
+	value, err := numbers.value(doc)
 	if err != nil {
 		return "", err
 	}
-	return string(out), nil
+	if err := enc.Encode(value); err != nil {
+		return "", err
+	}
+	return numbers.replace(buf.String()), nil
 }
 
+// TOMLToJSON reads TOML into JSON with keys in document order. Floats keep
+// the digits they are written with where a float64 would round them, and
+// datetimes read as {"$datetime": "..."}, which JSONToTOML writes back.
 func TOMLToJSON(input string) (string, error) {
-	var data map[string]any
-	if err := toml.Unmarshal([]byte(input), &data); err != nil {
-		return "", err
-	}
-	buf := &bytes.Buffer{}
-	enc := json.NewEncoder(buf)
-	enc.SetEscapeHTML(false)
-	enc.SetIndent("", "  ")
-	if err := enc.Encode(data); err != nil {
-		return "", err
-	}
-	return buf.String(), nil
+	return tomlToJSONWithOptions(input, NewConvertOptions())
 }
 
 func JSONToXML(input string) (string, error) {
-	var data any
-	if err := json.Unmarshal([]byte(input), &data); err != nil {
+	return jsonToXMLWithOptions(input, NewConvertOptions())
+}
+
+func jsonToXMLWithOptions(input string, o ConvertOptions) (string, error) {
+	data, err := decodeJSONValue(input)
+	if err != nil {
+		return "", err
+	}
+	header, err := o.xmlDeclaration(`version="1.0" encoding="UTF-8"`, true)
+	if err != nil {
 		return "", err
 	}
 	builder := &strings.Builder{}
-	builder.WriteString(xml.Header)
-	buildXML(builder, "root", common.NormalizeJSONNumbers(data), 0)
+	if header != "" {
+		builder.WriteString(header + "\n")
+	}
+	buildXML(builder, "root", common.NormalizeJSONNumbers(data), 0, o.indentString(), o.sourceOrder(input), "")
 	return builder.String(), nil
 }
 
@@ -86,44 +116,45 @@ func XMLToJSON(input string) (string, error) {
 	if err != nil {
 		return "", err
 	}
-	value := elementToValue(root)
-	buf := &bytes.Buffer{}
-	enc := json.NewEncoder(buf)
-	enc.SetEscapeHTML(false)
-	enc.SetIndent("", "  ")
-	if err := enc.Encode(value); err != nil {
-		return "", err
-	}
-	return strings.TrimSpace(buf.String()), nil
+	return docToJSON(elementToDoc(&docBuilder{}, root), "  ")
 }
 
 func JSONToSchema(input string) (string, error) {
+	return JSONToSchemaWithOptions(input)
+}
+
+// JSONToSchemaWithOptions infers a JSON Schema from a sample document.
+// SchemaDraft adds the matching $schema header and SchemaFormats adds
+// format hints and tells integers from numbers. Properties and required
+// keys follow the order of the document unless SortKeys is set.
+func JSONToSchemaWithOptions(input string, opts ...ConvertOption) (string, error) {
+	o := NewConvertOptions(opts...)
 	data, err := decodeJSONValue(input)
 	if err != nil {
 		return "", err
 	}
-	schema := buildSchema(data)
-	formatted, err := json.MarshalIndent(schema, "", "  ")
+	b := schemaBuilder{opts: o, order: o.sourceOrder(input), layout: map[string][]string{}}
+	schema := b.build(data, "", "")
+	if o.SchemaDraft != "" {
+		uri, ok := schemaDraftURIs[o.SchemaDraft]
+		if !ok {
+			return "", fmt.Errorf("unsupported schema draft %q", o.SchemaDraft)
+		}
+		schema["$schema"] = uri
+	}
+	doc, err := docFromValue(&docArena{}, schema, "", b.layout)
 	if err != nil {
 		return "", err
 	}
-	return string(formatted), nil
+	return docToJSON(doc, o.indentString())
 }
 
 func SchemaToJSON(input string) (string, error) {
-	schema, err := decodeJSONValue(input)
+	schema, err := parseJSONDoc(input)
 	if err != nil {
 		return "", err
 	}
-	sample := sampleFromSchema(schema)
-	buf := &bytes.Buffer{}
-	enc := json.NewEncoder(buf)
-	enc.SetEscapeHTML(false)
-	enc.SetIndent("", "  ")
-	if err := enc.Encode(sample); err != nil {
-		return "", err
-	}
-	return buf.String(), nil
+	return encodeDoc(sampleFromSchema(&docBuilder{}, schema))
 }
 
 func SchemaToGoStruct(input string) (string, error) {
@@ -143,7 +174,7 @@ func YAMLToGoStruct(input string) (string, error) {
 }
 
 func TOMLToGoStruct(input string) (string, error) {
-	jsonStr, err := TOMLToJSON(input)
+	jsonStr, err := tomlToPlainJSON(input)
 	if err != nil {
 		return "", err
 	}
@@ -174,24 +205,23 @@ func GoStructToSchema(src string) (string, error) {
 	return JSONToSchema(jsonStr)
 }
 
-func buildXML(builder *strings.Builder, name string, value any, indent int) {
-	indentation := strings.Repeat("  ", indent)
+// buildXML writes value, found at path, as elements called name, with the
+// keys of objects in order.
+func buildXML(builder *strings.Builder, name string, value any, indent int, unit string, order sourceOrder, path string) {
+	indentation := strings.Repeat(unit, indent)
 	switch val := value.(type) {
 	case map[string]any:
 		builder.WriteString(fmt.Sprintf("%s<%s>\n", indentation, name))
-		keys := make([]string, 0, len(val))
-		for k := range val {
-			keys = append(keys, k)
-		}
-		sort.Strings(keys)
-		for _, k := range keys {
-			buildXML(builder, k, val[k], indent+1)
+		for _, k := range order.keys(val, path) {
+			buildXML(builder, k, val[k], indent+1, unit, order, keyPath(path, k))
 		}
 		builder.WriteString(fmt.Sprintf("%s</%s>\n", indentation, name))
 	case []any:
 		for _, item := range val {
-			buildXML(builder, name, item, indent)
+			buildXML(builder, name, item, indent, unit, order, path+"[]")
 		}
+	case nil:
+		builder.WriteString(fmt.Sprintf("%s<%s></%s>\n", indentation, name, name))
 	default:
 		text := fmt.Sprint(val)
 		builder.WriteString(fmt.Sprintf("%s<%s>%s</%s>\n", indentation, name, xmlEscape(text), name))
@@ -220,7 +250,7 @@ func parseXML(src string) (*xmlElement, error) {
 			if err == io.EOF {
 				break
 			}
-			return nil, err
+			return nil, xmlSyntaxError(src, decoder, err)
 		}
 		switch t := tok.(type) {
 		case xml.StartElement:
@@ -254,55 +284,71 @@ func parseXML(src string) (*xmlElement, error) {
 	return root, nil
 }
 
-func elementToValue(el *xmlElement) any {
+// elementToDoc reads el as an object of its children in document order,
+// with repeated children gathered into an array where the first one is.
+func elementToDoc(b *docBuilder, el *xmlElement) *docNode {
 	if len(el.Children) == 0 {
-		return el.Value
+		return b.str(el.Value)
 	}
-	result := map[string]any{}
+	obj := b.object()
+	repeated := map[string]*docNode{}
 	for _, child := range el.Children {
-		val := elementToValue(child)
-		if existing, ok := result[child.Name]; ok {
-			switch arr := existing.(type) {
-			case []any:
-				result[child.Name] = append(arr, val)
-			default:
-				result[child.Name] = []any{arr, val}
-			}
+		val := elementToDoc(b, child)
+		if arr := repeated[child.Name]; arr != nil {
+			arr.items = append(arr.items, val)
+		} else if first := b.get(obj, child.Name); first != nil {
+			arr := b.array(first, val)
+			repeated[child.Name] = arr
+			b.set(obj, child.Name, arr)
 		} else {
-			result[child.Name] = val
+			b.set(obj, child.Name, val)
 		}
 	}
-	return result
+	return obj
 }
 
+// decodeJSONValue reads the first JSON value in input, with numbers as
+// json.Number.
 func decodeJSONValue(input string) (any, error) {
-	dec := json.NewDecoder(strings.NewReader(input))
-	dec.UseNumber()
-	var data any
-	if err := dec.Decode(&data); err != nil {
+	doc, err := parseJSONDoc(input)
+	if err != nil {
 		return nil, err
 	}
-	return data, nil
+	return doc.value(), nil
+}
+
+// schemaBuilder infers the schema of a document. The keywords of each
+// schema are written sorted; layout lists, by the path docFromValue gives
+// it, the order of each properties object, which is that of the document.
+type schemaBuilder struct {
+	opts   ConvertOptions
+	order  sourceOrder
+	layout map[string][]string
 }
 
-func buildSchema(v any) map[string]any {
+// build returns the schema of v, the value at path in the document and at
+// at in the schema.
+func (b *schemaBuilder) build(v any, path, at string) map[string]any {
+	o := b.opts
 	switch val := v.(type) {
 	case map[string]any:
 		props := make(map[string]any, len(val))
-		keys := make([]string, 0, len(val))
-		for k := range val {
-			keys = append(keys, k)
-		}
-		sort.Strings(keys)
+		keys := b.order.keys(val, path)
+		propsAt := orderPath(at, "properties")
+		b.layout[propsAt] = keys
 		for _, k := range keys {
-			props[k] = buildSchema(val[k])
+			props[k] = b.build(val[k], keyPath(path, k), orderPath(propsAt, k))
 		}
 		schema := map[string]any{
 			"type":       "object",
 			"properties": props,
 		}
 		if len(keys) > 0 {
-			schema["required"] = keys
+			required := make([]any, len(keys))
+			for i, k := range keys {
+				required[i] = k
+			}
+			schema["required"] = required
 		}
 		return schema
 	case []any:
@@ -322,13 +368,20 @@ func buildSchema(v any) map[string]any {
 		if sample == nil {
 			schema["items"] = map[string]any{"type": "string"}
 		} else {
-			schema["items"] = buildSchema(sample)
+			schema["items"] = b.build(sample, path+"[]", orderPath(at, "items"))
 		}
 		return schema
 	case json.Number:
+		if o.SchemaFormats && common.LooksInteger(val) {
+			return map[string]any{"type": "integer"}
+		}
 		return map[string]any{"type": "number"}
 	case string:
-		return map[string]any{"type": "string"}
+		schema := map[string]any{"type": "string"}
+		if format := inferSchemaFormat(val); o.SchemaFormats && format != "" {
+			schema["format"] = format
+		}
+		return schema
 	case bool:
 		return map[string]any{"type": "boolean"}
 	case nil:
@@ -338,59 +391,77 @@ func buildSchema(v any) map[string]any {
 	}
 }
 
-func sampleFromSchema(schema any) any {
-	switch s := schema.(type) {
-	case map[string]any:
-		switch schemaType(s) {
+// inferSchemaFormat names the format a sample string satisfies. URIs need
+// a host, so "note: hi" or "a:b" are not mistaken for one.
+func inferSchemaFormat(s string) string {
+	for _, format := range []string{"date-time", "uuid", "email", "ipv4"} {
+		if schemaFormatMatches(format, s) {
+			return format
+		}
+	}
+	if u, err := url.Parse(s); err == nil && u.Scheme != "" && u.Host != "" {
+		return "uri"
+	}
+	return ""
+}
+
+// sampleFromSchema makes up a document schema describes, with the
+// properties of objects in the order the schema lists them.
+func sampleFromSchema(b *docBuilder, schema *docNode) *docNode {
+	switch schema.kind {
+	case docObject:
+		var kind string
+		if t := b.get(schema, "type"); t != nil {
+			kind = schemaType(map[string]any{"type": t.value()})
+		} else {
+			kind = schemaType(nil)
+		}
+		def := b.get(schema, "default")
+		switch kind {
 		case "array":
-			items, ok := s["items"]
-			if !ok {
-				return []any{}
+			items := b.get(schema, "items")
+			if items == nil {
+				return b.array()
 			}
-			return []any{sampleFromSchema(items)}
+			return b.array(sampleFromSchema(b, items))
 		case "string":
-			if def, ok := s["default"]; ok {
+			if def != nil {
 				return def
 			}
-			if enums, ok := s["enum"].([]any); ok && len(enums) > 0 {
-				return enums[0]
+			if enums := b.get(schema, "enum"); enums != nil && enums.kind == docArray && len(enums.items) > 0 {
+				return enums.items[0]
 			}
-			return ""
+			return b.str("")
 		case "number", "integer":
-			if def, ok := s["default"]; ok {
+			if def != nil {
 				return def
 			}
-			return 0.0
+			return b.number("0")
 		case "boolean":
-			if def, ok := s["default"]; ok {
+			if def != nil {
 				return def
 			}
-			return false
+			return b.boolean(false)
 		case "null":
-			return nil
+			return b.null()
 		case "object":
-			props := map[string]any{}
-			if m, ok := s["properties"].(map[string]any); ok {
-				keys := make([]string, 0, len(m))
-				for k := range m {
-					keys = append(keys, k)
-				}
-				sort.Strings(keys)
-				for _, k := range keys {
-					props[k] = sampleFromSchema(m[k])
+			obj := b.object()
+			if props := b.get(schema, "properties"); props != nil && props.kind == docObject {
+				for i, k := range props.keys {
+					b.set(obj, k.name, sampleFromSchema(b, props.items[i]))
 				}
 			}
-			return props
+			return obj
 		default:
-			return map[string]any{}
+			return b.object()
 		}
-	case []any:
-		if len(s) == 0 {
-			return nil
+	case docArray:
+		if len(schema.items) == 0 {
+			return b.null()
 		}
-		return sampleFromSchema(s[0])
+		return sampleFromSchema(b, schema.items[0])
 	default:
-		return nil
+		return b.null()
 	}
 }
 
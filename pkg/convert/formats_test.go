@@ -1,6 +1,8 @@
 package convert
 
 import (
+	"bytes"
+	"encoding/json"
 	"strings"
 	"testing"
 
@@ -44,6 +46,115 @@ func Fuzz_JSONYAMLRoundTrip(f *testing.F) {
 	})
 }
 
+func Test_TOMLDatetimes(t *testing.T) {
+	input := `odt = 1979-05-27 07:32:00.5-07:00
+ldt = 1979-05-27T07:32:00
+ld = 1979-05-27
+lt = 07:32:00
+
+[[fruits]]
+name = "apple"
+picked = [1979-05-27, 1979-05-28]
+
+[[fruits.varieties]]
+name = "red delicious"
+
+[[fruits]]
+name = "banana"
+`
+	out, err := ConvertFormatsWithOptions(formatTOML, formatJSON, input, WithTOMLDatetimes(TOMLDatetimesString))
+	require.NoError(t, err)
+	require.Contains(t, out, `"ld": "1979-05-27"`)
+	// Formats that cannot read the tags back get strings by default.
+	out, err = ConvertFormats(formatTOML, formatYAML, input)
+	require.NoError(t, err)
+	require.Contains(t, out, `ld: "1979-05-27"`)
+
+	opts := []ConvertOption{WithSortKeys(false)}
+	out, err = ConvertFormatsWithOptions(formatTOML, formatJSON, input, opts...)
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"odt": {"$datetime": "1979-05-27T07:32:00.5-07:00"},
+		"ldt": {"$datetime": "1979-05-27T07:32:00"},
+		"ld": {"$datetime": "1979-05-27"},
+		"lt": {"$datetime": "07:32:00"},
+		"fruits": [
+			{"name": "apple", "picked": [{"$datetime": "1979-05-27"}, {"$datetime": "1979-05-28"}], "varieties": [{"name": "red delicious"}]},
+			{"name": "banana"}
+		]
+	}`, out)
+
+	back, err := ConvertFormatsWithOptions(formatJSON, formatTOML, out, opts...)
+	require.NoError(t, err)
+	require.Equal(t, `odt = 1979-05-27T07:32:00.5-07:00
+ldt = 1979-05-27T07:32:00
+ld = 1979-05-27
+lt = 07:32:00
+
+[[fruits]]
+name = 'apple'
+picked = [1979-05-27, 1979-05-28]
+
+[[fruits.varieties]]
+name = 'red delicious'
+
+[[fruits]]
+name = 'banana'
+`, back)
+
+	// The default path round-trips too.
+	out, err = TOMLToJSON(input)
+	require.NoError(t, err)
+	back, err = JSONToTOML(out)
+	require.NoError(t, err)
+	require.Contains(t, back, "odt = 1979-05-27T07:32:00.5-07:00\nldt = 1979-05-27T07:32:00\nld = 1979-05-27\nlt = 07:32:00\n")
+	many, err := ConvertToMany(formatTOML, []string{formatTOML, formatJSON}, input)
+	require.NoError(t, err)
+	require.Contains(t, many[formatJSON].Output, `"$datetime": "1979-05-27"`)
+
+	// Only a single $datetime holding a TOML datetime is one.
+	out, err = JSONToTOML(`{"a": {"$datetime": "soon"}, "b": {"$datetime": "1979-05-27", "c": 1}}`)
+	require.NoError(t, err)
+	require.NotContains(t, out, "= 1979")
+
+	_, err = ConvertFormatsWithOptions(formatTOML, formatJSON, input, WithTOMLDatetimes("nope"))
+	require.Error(t, err)
+}
+
+func Test_YAMLMultiDocument(t *testing.T) {
+	stream := "# first\nid: 1\nkind: A\n---\nid: 2\nkind: B\n---\n"
+
+	out, err := ConvertFormats(formatYAML, formatJSON, stream)
+	require.NoError(t, err)
+	require.JSONEq(t, `[{"kind": "A", "id": 1}, {"kind": "B", "id": 2}]`, out)
+	out, err = ConvertFormatsWithOptions(formatYAML, formatJSON, stream, WithYAMLDocuments(YAMLDocumentsFirst))
+	require.NoError(t, err)
+	require.JSONEq(t, `{"kind": "A", "id": 1}`, out)
+	out, err = ConvertFormatsWithOptions(formatYAML, formatJSON, "kind: A\n", WithYAMLDocuments(YAMLDocumentsStream))
+	require.NoError(t, err)
+	require.JSONEq(t, `[{"kind": "A"}]`, out)
+	out, err = ConvertFormats(formatYAML, formatJSON, "---\nkind: A\n")
+	require.NoError(t, err)
+	require.JSONEq(t, `{"kind": "A"}`, out)
+
+	out, err = ConvertFormatsWithOptions(formatJSON, formatYAML, `[{"kind": "A"}, {"kind": "B"}, 3]`, WithYAMLDocuments(YAMLDocumentsStream))
+	require.NoError(t, err)
+	require.Equal(t, "kind: A\n---\nkind: B\n---\n3", out)
+	out, err = ConvertFormats(formatJSON, formatYAML, `[{"kind": "A"}]`)
+	require.NoError(t, err)
+	require.Equal(t, "- kind: A", out)
+
+	formatted, err := FormatContent(formatYAML, stream, false)
+	require.NoError(t, err)
+	require.Equal(t, "# first\nid: 1\nkind: A\n---\nid: 2\nkind: B", formatted)
+
+	_, err = ConvertFormats(formatYAML, formatJSON, "a: 1\n---\nb: [\n")
+	require.ErrorContains(t, err, "line 3")
+	require.Error(t, Validate(formatYAML, "a: 1\n---\nb: [\n"))
+	_, err = ConvertFormatsWithOptions(formatYAML, formatJSON, "a: 1", WithYAMLDocuments("nope"))
+	require.Error(t, err)
+}
+
 func Test_JSONTOMLConversions(t *testing.T) {
 	tomlOut, err := JSONToTOML(sampleJSON)
 	require.NoError(t, err)
@@ -62,6 +173,69 @@ func Benchmark_JSONTOMLConversions(b *testing.B) {
 	}
 }
 
+// bigNumbersJSON holds numbers a float64 rounds: an ID above int64, one
+// above 2^53, one below int64 and a long decimal.
+const bigNumbersJSON = `{"dec":0.10000000000000000000001,"id":12345678901234567890,"js":9007199254740993,"neg":-9223372036854775809}`
+
+func Test_BigNumbers(t *testing.T) {
+	for _, format := range []string{formatYAML, formatTOON} {
+		t.Run(format, func(t *testing.T) {
+			out, err := ConvertFormats(formatJSON, format, bigNumbersJSON)
+			require.NoError(t, err)
+			require.Contains(t, out, "0.10000000000000000000001")
+			require.Contains(t, out, "12345678901234567890")
+			require.Contains(t, out, "9007199254740993")
+			require.Contains(t, out, "-9223372036854775809")
+			back, err := ConvertFormats(format, formatJSON, out)
+			require.NoError(t, err)
+			require.Equal(t, bigNumbersJSON, compactJSONNumbers(t, back))
+		})
+	}
+
+	// TOML integers are 64-bit, so wider ones cannot be written.
+	out, err := JSONToTOML(`{"dec":0.10000000000000000000001,"js":9007199254740993}`)
+	require.NoError(t, err)
+	require.Equal(t, "dec = 0.10000000000000000000001\njs = 9007199254740993\n", out)
+	_, err = JSONToTOML(`{"id":12345678901234567890}`)
+	require.ErrorContains(t, err, "integer 12345678901234567890 does not fit in a 64-bit TOML integer")
+	_, err = ConvertFormats(formatYAML, formatTOML, "a: [-9223372036854775809]")
+	require.ErrorContains(t, err, "-9223372036854775809")
+	out, err = JSONToYAML(bigNumbersJSON)
+	require.NoError(t, err)
+	require.Equal(t, "dec: 0.10000000000000000000001\nid: 12345678901234567890\njs: 9007199254740993\nneg: !!int -9223372036854775809", out)
+
+	// yaml.v3 reads a plain integer beyond 64 bits as a string; YAML 1.2
+	// says it is an integer.
+	out, err = YAMLToJSON("a: -9223372036854775809\nb: '-9223372036854775809'\nc: 0x10\nd: 1.50\n")
+	require.NoError(t, err)
+	require.JSONEq(t, `{"a":-9223372036854775809,"b":"-9223372036854775809","c":16,"d":1.5}`, out)
+	require.Contains(t, out, `"a": -9223372036854775809`)
+
+	out, err = TOMLToJSON("a = 1_000.000_000_000_000_000_000_1\nb = [+0.5, 0.30000000000000000000001]\n[[t]]\nc = 1e-400\n")
+	require.NoError(t, err)
+	require.Equal(t, `{"a":1000.0000000000000000001,"b":[0.5,0.30000000000000000000001],"t":[{"c":1e-400}]}`, compactJSONNumbers(t, out))
+
+	// MsgPack holds integers up to uint64 exactly.
+	packed, err := JSONToMsgPack(`{"id":12345678901234567890}`)
+	require.NoError(t, err)
+	out, err = MsgPackToJSON(packed)
+	require.NoError(t, err)
+	require.Contains(t, out, "12345678901234567890")
+
+	out, err = JSONToXML(bigNumbersJSON)
+	require.NoError(t, err)
+	require.Contains(t, out, "<js>9007199254740993</js>")
+	require.Contains(t, out, "<dec>0.10000000000000000000001</dec>")
+}
+
+// compactJSONNumbers compacts JSON without reading its numbers as floats.
+func compactJSONNumbers(t *testing.T, s string) string {
+	t.Helper()
+	var buf bytes.Buffer
+	require.NoError(t, json.Compact(&buf, []byte(s)))
+	return buf.String()
+}
+
 func Test_JSONSchemaRoundTrip(t *testing.T) {
 	schema, err := JSONToSchema(sampleNestedJSON)
 	require.NoError(t, err)
@@ -71,6 +245,42 @@ func Test_JSONSchemaRoundTrip(t *testing.T) {
 	require.Contains(t, sample, "name")
 }
 
+func Test_JSONToSchemaWithOptions(t *testing.T) {
+	input := `{"id":7,"price":9.5,"created":"2024-01-02T03:04:05Z","email":"a@example.com",` +
+		`"ref":"123e4567-e89b-12d3-a456-426614174000","site":"https://example.com/x","ip":"10.0.0.1","note":"todo: x"}`
+	out, err := JSONToSchemaWithOptions(input, WithSchemaDraft(SchemaDraft2020), WithSchemaFormats(true))
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"type": "object",
+		"required": ["id", "price", "created", "email", "ref", "site", "ip", "note"],
+		"properties": {
+			"created": {"type": "string", "format": "date-time"},
+			"email": {"type": "string", "format": "email"},
+			"id": {"type": "integer"},
+			"ip": {"type": "string", "format": "ipv4"},
+			"note": {"type": "string"},
+			"price": {"type": "number"},
+			"ref": {"type": "string", "format": "uuid"},
+			"site": {"type": "string", "format": "uri"}
+		}
+	}`, out)
+
+	var schema any
+	require.NoError(t, json.Unmarshal([]byte(out), &schema))
+	var doc any
+	require.NoError(t, json.Unmarshal([]byte(input), &doc))
+	require.Empty(t, validateSchema(schema, doc, ""))
+
+	out, err = ConvertFormatsWithOptions(formatJSON, formatSchema, `{"n":1}`, WithSchemaDraft(SchemaDraft07))
+	require.NoError(t, err)
+	require.Contains(t, out, `"$schema": "http://json-schema.org/draft-07/schema#"`)
+	require.Contains(t, out, `"type": "number"`)
+
+	_, err = JSONToSchemaWithOptions(`{}`, WithSchemaDraft("draft-04"))
+	require.Error(t, err)
+}
+
 func Benchmark_JSONSchemaRoundTrip(b *testing.B) {
 	b.ReportAllocs()
 	b.ResetTimer()
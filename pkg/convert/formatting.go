@@ -5,14 +5,17 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"encoding/xml"
+	"errors"
 	"fmt"
 	"go/format"
 	"io"
-	"regexp"
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/linzeyan/transform-go/pkg/common"
 	"github.com/ugorji/go/codec"
 )
 
@@ -27,133 +30,470 @@ const (
 	formatProtobuf = "Protobuf"
 	formatTOON     = "TOON"
 	formatMsgPack  = "MsgPack"
+	formatCSV      = "CSV"
+	formatPlist    = "Plist"
+	formatBPlist   = "Binary Plist"
+	formatPropsXML = "Properties XML"
+	formatNDJSON   = "NDJSON"
+	formatReg      = "Windows Registry"
 )
 
-type formatAdapter struct {
+// FormatAdapter converts a format to and from JSON, which every conversion
+// goes through. Either direction may be nil for one-way formats.
+type FormatAdapter struct {
 	ToJSON   func(string) (string, error)
 	FromJSON func(string) (string, error)
+	// ToJSONWithOptions, when set, is used by ConvertFormatsWithOptions
+	// and FormatContentWithOptions instead of ToJSON.
+	ToJSONWithOptions func(string, ConvertOptions) (string, error)
+	// FromJSONWithOptions, when set, is used by ConvertFormatsWithOptions
+	// and FormatContentWithOptions instead of FromJSON.
+	FromJSONWithOptions func(string, ConvertOptions) (string, error)
+	// Detect, when set, scores input between 0 and 1 for DetectFormat.
+	Detect func(string) float64
+}
+
+var (
+	adaptersMu sync.RWMutex
+	adapters   = map[string]FormatAdapter{
+		formatJSON: {
+			ToJSON:   func(s string) (string, error) { return s, nil },
+			FromJSON: func(s string) (string, error) { return s, nil },
+		},
+		formatGoStruct: {
+			ToJSON:   GoStructToJSON,
+			FromJSON: JSONToGoStruct,
+			FromJSONWithOptions: func(s string, o ConvertOptions) (string, error) {
+				return JSONToGoStructWithOptions(s, WithOptions(o))
+			},
+		},
+		formatYAML: {
+			ToJSON:              YAMLToJSON,
+			ToJSONWithOptions:   yamlToJSONWithOptions,
+			FromJSON:            JSONToYAML,
+			FromJSONWithOptions: jsonToYAMLWithOptions,
+		},
+		formatTOML: {
+			ToJSON:              TOMLToJSON,
+			ToJSONWithOptions:   tomlToJSONWithOptions,
+			FromJSON:            JSONToTOML,
+			FromJSONWithOptions: jsonToTOMLWithOptions,
+		},
+		formatXML: {
+			ToJSON:              XMLToJSON,
+			FromJSON:            JSONToXML,
+			FromJSONWithOptions: jsonToXMLWithOptions,
+		},
+		formatSchema: {
+			ToJSON:   SchemaToJSON,
+			FromJSON: JSONToSchema,
+			FromJSONWithOptions: func(s string, o ConvertOptions) (string, error) {
+				return JSONToSchemaWithOptions(s, WithOptions(o))
+			},
+		},
+		formatGraphQL: {
+			ToJSON:   GraphQLToJSON,
+			FromJSON: JSONToGraphQL,
+			FromJSONWithOptions: func(s string, o ConvertOptions) (string, error) {
+				return JSONToGraphQLWithOptions(s, WithOptions(o))
+			},
+		},
+		formatProtobuf: {
+			ToJSON:   ProtoToJSON,
+			FromJSON: JSONToProto,
+			FromJSONWithOptions: func(s string, o ConvertOptions) (string, error) {
+				return JSONToProtoWithOptions(s, WithOptions(o))
+			},
+		},
+		formatTOON: {
+			ToJSON:              TOONToJSON,
+			ToJSONWithOptions:   toonToJSONWithOptions,
+			FromJSON:            JSONToTOON,
+			FromJSONWithOptions: jsonToTOONWithOptions,
+		},
+		formatMsgPack: {
+			ToJSON:              MsgPackToJSON,
+			FromJSON:            JSONToMsgPack,
+			FromJSONWithOptions: jsonToMsgPackWithOptions,
+		},
+		formatCSV: {
+			ToJSON:   CSVToJSON,
+			FromJSON: JSONToCSV,
+			FromJSONWithOptions: func(s string, o ConvertOptions) (string, error) {
+				return JSONToCSVWithOptions(s, CSVOptions{Header: CSVHeaderYes, SortKeys: o.SortKeys})
+			},
+		},
+		formatPlist: {
+			ToJSON:              PlistToJSON,
+			FromJSON:            JSONToPlist,
+			FromJSONWithOptions: jsonToPlistWithOptions,
+		},
+		formatBPlist: {
+			ToJSON:              PlistToJSON,
+			FromJSON:            JSONToBinaryPlist,
+			FromJSONWithOptions: jsonToBinaryPlistWithOptions,
+		},
+		formatPropsXML: {
+			ToJSON:              PropertiesXMLToJSON,
+			FromJSON:            JSONToPropertiesXML,
+			FromJSONWithOptions: jsonToPropertiesXMLWithOptions,
+		},
+		formatProperties: {
+			ToJSON:              PropertiesToJSON,
+			FromJSON:            JSONToProperties,
+			FromJSONWithOptions: jsonToPropertiesWithOptions,
+		},
+		formatDotenv: {
+			ToJSON:              DotenvToJSON,
+			FromJSON:            JSONToDotenv,
+			FromJSONWithOptions: jsonToDotenvWithOptions,
+		},
+		formatQueryString: {
+			ToJSON:   QueryStringToJSON,
+			FromJSON: JSONToQueryString,
+		},
+		formatHTMLTable: {
+			ToJSON:              HTMLTableToJSON,
+			FromJSON:            JSONToHTMLTable,
+			FromJSONWithOptions: jsonToHTMLTableWithOptions,
+		},
+		formatMarkdownTable: {
+			ToJSON:   MarkdownTableToJSON,
+			FromJSON: JSONToMarkdownTable,
+			FromJSONWithOptions: func(s string, o ConvertOptions) (string, error) {
+				return JSONToMarkdownTableWithOptions(s, MarkdownTableOptions{SortKeys: o.SortKeys})
+			},
+		},
+		formatNDJSON: {
+			ToJSON:              JSONLinesToJSON,
+			FromJSON:            JSONToJSONLines,
+			FromJSONWithOptions: jsonToJSONLinesWithOptions,
+		},
+		formatReg: {
+			ToJSON:   RegToJSON,
+			FromJSON: JSONToReg,
+		},
+		formatAvro: {
+			ToJSON:   AvroToJSON,
+			FromJSON: JSONToAvro,
+			FromJSONWithOptions: func(s string, o ConvertOptions) (string, error) {
+				return JSONToAvroWithOptions(s, WithOptions(o))
+			},
+		},
+		formatHCL: {
+			ToJSON:   HCLToJSON,
+			FromJSON: JSONToHCL,
+			FromJSONWithOptions: func(s string, o ConvertOptions) (string, error) {
+				return JSONToHCLWithOptions(s, WithOptions(o))
+			},
+		},
+		formatXSD: {
+			FromJSON: func(s string) (string, error) {
+				return jsonToXSDWithOptions(s, NewConvertOptions())
+			},
+			FromJSONWithOptions: jsonToXSDWithOptions,
+		},
+		formatSQL: {
+			ToJSON:   SQLToJSON,
+			FromJSON: JSONToSQL,
+			FromJSONWithOptions: func(s string, o ConvertOptions) (string, error) {
+				return JSONToSQLWithOptions(s, WithOptions(o))
+			},
+		},
+	}
+)
+
+// RegisterFormat adds a format under name so that ConvertFormats,
+// FormatContent, DetectFormat and ListFormats know it. Like
+// database/sql.Register it is meant for init functions and panics when
+// name is empty or taken, or the adapter has no conversion at all.
+func RegisterFormat(name string, adapter FormatAdapter) {
+	if name == "" {
+		panic("convert: RegisterFormat with empty name")
+	}
+	if adapter.ToJSON == nil && adapter.FromJSON == nil && adapter.FromJSONWithOptions == nil {
+		panic("convert: RegisterFormat " + name + " without conversions")
+	}
+	adaptersMu.Lock()
+	defer adaptersMu.Unlock()
+	if _, dup := adapters[name]; dup {
+		panic("convert: RegisterFormat called twice for " + name)
+	}
+	adapters[name] = adapter
 }
 
-var adapters = map[string]formatAdapter{
-	formatJSON: {
-		ToJSON:   func(s string) (string, error) { return s, nil },
-		FromJSON: func(s string) (string, error) { return s, nil },
-	},
-	formatGoStruct: {
-		ToJSON:   GoStructToJSON,
-		FromJSON: JSONToGoStruct,
-	},
-	formatYAML: {
-		ToJSON:   YAMLToJSON,
-		FromJSON: JSONToYAML,
-	},
-	formatTOML: {
-		ToJSON:   TOMLToJSON,
-		FromJSON: JSONToTOML,
-	},
-	formatXML: {
-		ToJSON:   XMLToJSON,
-		FromJSON: JSONToXML,
-	},
-	formatSchema: {
-		ToJSON:   SchemaToJSON,
-		FromJSON: JSONToSchema,
-	},
-	formatGraphQL: {
-		ToJSON:   GraphQLToJSON,
-		FromJSON: JSONToGraphQL,
-	},
-	formatProtobuf: {
-		ToJSON:   ProtoToJSON,
-		FromJSON: JSONToProto,
-	},
-	formatTOON: {
-		ToJSON:   TOONToJSON,
-		FromJSON: JSONToTOON,
-	},
-	formatMsgPack: {
-		ToJSON:   MsgPackToJSON,
-		FromJSON: JSONToMsgPack,
-	},
+// FormatInfo describes what a format supports: converting to JSON (and so
+// to every other format), being generated from JSON, FormatContent and its
+// minify flag.
+type FormatInfo struct {
+	Name     string `json:"name"`
+	ToJSON   bool   `json:"toJSON"`
+	FromJSON bool   `json:"fromJSON"`
+	Format   bool   `json:"format"`
+	Minify   bool   `json:"minify"`
+}
+
+// ListFormats returns every known format with its capabilities, sorted by
+// name, so a UI can build its menus from it.
+func ListFormats() []FormatInfo {
+	adaptersMu.RLock()
+	defer adaptersMu.RUnlock()
+	infos := make([]FormatInfo, 0, len(adapters))
+	for name, adapter := range adapters {
+		info := FormatInfo{
+			Name:     name,
+			ToJSON:   adapter.ToJSON != nil,
+			FromJSON: adapter.FromJSON != nil || adapter.FromJSONWithOptions != nil,
+		}
+		info.Format = info.ToJSON && info.FromJSON
+		switch name {
+		case formatJSON, formatXML:
+			info.Format, info.Minify = true, true
+		case formatGoStruct:
+			info.Format = true
+		}
+		infos = append(infos, info)
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	return infos
+}
+
+// registeredDetectors copies the Detect functions so they run without the
+// lock held.
+func registeredDetectors() map[string]func(string) float64 {
+	adaptersMu.RLock()
+	defer adaptersMu.RUnlock()
+	detectors := map[string]func(string) float64{}
+	for name, adapter := range adapters {
+		if adapter.Detect != nil {
+			detectors[name] = adapter.Detect
+		}
+	}
+	return detectors
+}
+
+func lookupAdapter(name string) (FormatAdapter, bool) {
+	adaptersMu.RLock()
+	defer adaptersMu.RUnlock()
+	adapter, ok := adapters[name]
+	return adapter, ok
 }
 
 func ConvertFormats(from, to, input string) (string, error) {
+	return ConvertFormatsWithOptions(from, to, input)
+}
+
+// ConvertFormatsWithOptions converts between formats like ConvertFormats,
+// applying opts to the generated output where the target supports them.
+func ConvertFormatsWithOptions(from, to, input string, opts ...ConvertOption) (string, error) {
+	o := NewConvertOptions(opts...)
+	if err := o.checkProfile(); err != nil {
+		return "", err
+	}
+	start := time.Now()
+	out, err := convertFormats(from, to, input, o)
+	recordConversion(from, to, start, len(input), err)
+	if err != nil {
+		return "", withPosition(from, input, err)
+	}
+	return o.finish(o.addProvenance(from, to, out, time.Now())), nil
+}
+
+func convertFormats(from, to, input string, o ConvertOptions) (string, error) {
+	o = o.tomlDatetimesFor(to)
+	if o.Script != "" {
+		return scriptConvert(from, to, input, o)
+	}
+	if direct := directConversion(from, to); direct != nil {
+		return direct(input, o)
+	}
+	if _, ok := lookupAdapter(from); !ok {
+		return "", fmt.Errorf("unsupported source format: %s", from)
+	}
+	if _, ok := lookupAdapter(to); !ok {
+		return "", fmt.Errorf("unsupported target format: %s", to)
+	}
+	mid, err := readToJSON(from, input, o)
+	if err != nil {
+		return "", err
+	}
+	return writeFromJSON(to, mid, o)
+}
+
+// directConversion returns the converter for pairs that skip JSON, such as
+// Go structs to GraphQL, or nil for every other pair.
+func directConversion(from, to string) func(string, ConvertOptions) (string, error) {
 	switch {
 	case from == to:
-		return input, nil
+		return func(s string, _ ConvertOptions) (string, error) { return s, nil }
 	case from == formatGoStruct && to == formatGraphQL:
-		return GoStructToGraphQL(input)
+		return func(s string, o ConvertOptions) (string, error) {
+			return GoStructToGraphQLWithOptions(s, WithOptions(o))
+		}
 	case from == formatGraphQL && to == formatGoStruct:
-		return GraphQLToGoStruct(input)
+		return func(s string, _ ConvertOptions) (string, error) { return GraphQLToGoStruct(s) }
 	case from == formatGoStruct && to == formatProtobuf:
-		return GoStructToProto(input)
+		return func(s string, o ConvertOptions) (string, error) { return GoStructToProtoWithOptions(s, WithOptions(o)) }
 	case from == formatProtobuf && to == formatGoStruct:
-		return ProtoToGoStruct(input)
+		return func(s string, _ ConvertOptions) (string, error) { return ProtoToGoStruct(s) }
+	case from == formatGoStruct && to == formatSQL:
+		return func(s string, o ConvertOptions) (string, error) { return GoStructToSQLWithOptions(s, WithOptions(o)) }
+	case from == formatSQL && to == formatGoStruct:
+		return func(s string, o ConvertOptions) (string, error) { return SQLToGoStructWithOptions(s, WithOptions(o)) }
+	case from == formatSQL && to == formatSchema:
+		return func(s string, o ConvertOptions) (string, error) { return SQLToSchemaWithOptions(s, WithOptions(o)) }
+	case from == formatXML && to == formatXSD:
+		return func(s string, o ConvertOptions) (string, error) { return XMLToXSDWithOptions(s, WithOptions(o)) }
+	case from == formatXSD && to == formatGoStruct:
+		return func(s string, _ ConvertOptions) (string, error) { return XSDToGoStruct(s) }
 	}
-	fromAdapter, ok := adapters[from]
+	return nil
+}
+
+// readToJSON parses input in the from format into the JSON every
+// conversion goes through; its objects keep the input's key order when
+// the reader can and SortKeys is off.
+func readToJSON(from, input string, o ConvertOptions) (string, error) {
+	if from == formatJSON {
+		return input, nil
+	}
+	adapter, ok := lookupAdapter(from)
 	if !ok {
 		return "", fmt.Errorf("unsupported source format: %s", from)
 	}
-	toAdapter, ok := adapters[to]
+	if adapter.ToJSON == nil {
+		return "", fmt.Errorf("format %s cannot convert to JSON", from)
+	}
+	start := time.Now()
+	mid, err := adapter.toJSON(input, o)
+	recordStage(from, metricsParse, start, len(input), err)
+	return mid, err
+}
+
+// writeFromJSON generates the to format from mid, the JSON that
+// readToJSON returned.
+func writeFromJSON(to, mid string, o ConvertOptions) (string, error) {
+	if to == formatJSON {
+		return normalizeJSONOutputWithOptions(mid, false, o)
+	}
+	adapter, ok := lookupAdapter(to)
 	if !ok {
 		return "", fmt.Errorf("unsupported target format: %s", to)
 	}
-	var mid string
-	var err error
-	if from == formatJSON {
-		mid = input
-	} else if fromAdapter.ToJSON != nil {
-		mid, err = fromAdapter.ToJSON(input)
-		if err != nil {
-			return "", err
-		}
-	} else {
-		return "", fmt.Errorf("format %s cannot convert to JSON", from)
+	if adapter.FromJSON == nil && adapter.FromJSONWithOptions == nil {
+		return "", fmt.Errorf("format %s cannot be generated from JSON", to)
+	}
+	start := time.Now()
+	out, err := adapter.fromJSON(mid, o)
+	recordStage(to, metricsEncode, start, len(mid), err)
+	return out, err
+}
+
+// scriptConvert runs the Script option over the document in JSON form, so
+// it skips the direct paths between type formats.
+func scriptConvert(from, to, input string, o ConvertOptions) (string, error) {
+	mid, err := scriptJSON(from, input, o)
+	if err != nil {
+		return "", err
+	}
+	return writeScriptOutput(to, mid, o)
+}
+
+// scriptJSON reads input into JSON and runs the Script option over it.
+func scriptJSON(from, input string, o ConvertOptions) (string, error) {
+	plain := o
+	plain.Script = ""
+	mid, err := convertFormats(from, formatJSON, input, plain)
+	if err != nil {
+		return "", err
+	}
+	if mid, err = RunScript(o.Script, mid, o.ScriptLimits); err != nil {
+		return "", fmt.Errorf("script: %w", err)
 	}
+	return mid, nil
+}
+
+// writeScriptOutput generates to from the JSON a script returned.
+func writeScriptOutput(to, mid string, o ConvertOptions) (string, error) {
 	if to == formatJSON {
-		return mid, nil
+		return normalizeJSONOutputWithOptions(mid, false, o)
 	}
-	if toAdapter.FromJSON == nil {
-		return "", fmt.Errorf("format %s cannot be generated from JSON", to)
+	plain := o
+	plain.Script = ""
+	return convertFormats(formatJSON, to, mid, plain)
+}
+
+func (a FormatAdapter) toJSON(input string, o ConvertOptions) (string, error) {
+	if a.ToJSONWithOptions != nil {
+		return a.ToJSONWithOptions(input, o)
+	}
+	return a.ToJSON(input)
+}
+
+func (a FormatAdapter) fromJSON(input string, o ConvertOptions) (string, error) {
+	if a.FromJSONWithOptions != nil {
+		return a.FromJSONWithOptions(input, o)
 	}
-	return toAdapter.FromJSON(mid)
+	return a.FromJSON(input)
 }
 
 func FormatContent(formatName, input string, minify bool) (string, error) {
+	return FormatContentWithOptions(formatName, input, minify)
+}
+
+// FormatContentWithOptions reformats input in place; opts control indent
+// and key order for formats that support them.
+func FormatContentWithOptions(formatName, input string, minify bool, opts ...ConvertOption) (string, error) {
+	o := NewConvertOptions(opts...)
+	if err := o.checkProfile(); err != nil {
+		return "", err
+	}
+	out, err := formatContent(formatName, input, minify, o)
+	if err != nil {
+		return "", withPosition(formatName, input, err)
+	}
+	return o.finish(out), nil
+}
+
+func formatContent(formatName, input string, minify bool, o ConvertOptions) (string, error) {
 	switch formatName {
 	case formatGoStruct:
 		return formatGoSource(input)
 	case formatJSON:
-		return normalizeJSONOutput(input, minify)
+		return normalizeJSONOutputWithOptions(input, minify, o)
 	case formatXML:
 		if minify {
 			return compactXML(input)
 		}
-		jsonStr, err := XMLToJSON(input)
-		if err != nil {
-			return "", err
-		}
-		return JSONToXML(jsonStr)
+		return formatXMLDocument(input, o)
+	case formatYAML:
+		return formatYAMLDocument(input, o)
+	case formatTOML:
+		return formatTOMLDocument(input, o)
 	}
-	adapter, ok := adapters[formatName]
+	adapter, ok := lookupAdapter(formatName)
 	if !ok {
 		return "", fmt.Errorf("unsupported format: %s", formatName)
 	}
 	if adapter.ToJSON == nil || adapter.FromJSON == nil {
 		return "", fmt.Errorf("format %s cannot be formatted", formatName)
 	}
-	jsonStr, err := adapter.ToJSON(input)
+	jsonStr, err := adapter.toJSON(input, o)
 	if err != nil {
 		return "", err
 	}
-	normalized, err := normalizeJSONOutput(jsonStr, minify)
+	normalized, err := normalizeJSONOutputWithOptions(jsonStr, minify, o)
 	if err != nil {
 		return "", err
 	}
-	return adapter.FromJSON(normalized)
+	return adapter.fromJSON(normalized, o)
 }
 
 func normalizeJSONOutput(input string, minify bool) (string, error) {
+	return normalizeJSONOutputWithOptions(input, minify, NewConvertOptions())
+}
+
+func normalizeJSONOutputWithOptions(input string, minify bool, o ConvertOptions) (string, error) {
 	if minify {
 		var buf bytes.Buffer
 		if err := json.Compact(&buf, []byte(input)); err != nil {
@@ -161,18 +501,19 @@ func normalizeJSONOutput(input string, minify bool) (string, error) {
 		}
 		return buf.String(), nil
 	}
-	var v any
-	if err := json.Unmarshal([]byte(input), &v); err != nil {
-		return "", err
+	if !o.SortKeys {
+		var buf bytes.Buffer
+		if err := json.Indent(&buf, []byte(strings.TrimSpace(input)), "", o.indentString()); err != nil {
+			return "", err
+		}
+		return buf.String(), nil
 	}
-	buf := &bytes.Buffer{}
-	enc := json.NewEncoder(buf)
-	enc.SetEscapeHTML(false)
-	enc.SetIndent("", "  ")
-	if err := enc.Encode(v); err != nil {
+	doc, err := parseJSONDoc(input)
+	if err != nil {
 		return "", err
 	}
-	return strings.TrimRight(buf.String(), "\n"), nil
+	doc.sortKeys(sortedNames)
+	return docToJSON(doc, o.indentString())
 }
 
 func formatGoSource(src string) (string, error) {
@@ -187,7 +528,7 @@ func formatGoSource(src string) (string, error) {
 	}
 	formatted, err := format.Source([]byte(prefixed))
 	if err != nil {
-		return "", err
+		return "", goSyntaxError(src, prefixed[:len(prefixed)-len(trimmed)], err)
 	}
 	out := string(formatted)
 	if !hasPackage {
@@ -208,7 +549,7 @@ func compactXML(src string) (string, error) {
 			if err == io.EOF {
 				break
 			}
-			return "", err
+			return "", xmlSyntaxError(src, decoder, err)
 		}
 		switch t := tok.(type) {
 		case xml.CharData:
@@ -239,6 +580,12 @@ func init() {
 
 // JSONToMsgPack encodes JSON into MsgPack and returns a base64 string.
 func JSONToMsgPack(input string) (string, error) {
+	return jsonToMsgPackWithOptions(input, NewConvertOptions())
+}
+
+// jsonToMsgPackWithOptions writes map keys in the order of the input unless
+// SortKeys is set.
+func jsonToMsgPackWithOptions(input string, o ConvertOptions) (string, error) {
 	var data any
 	dec := json.NewDecoder(strings.NewReader(input))
 	dec.UseNumber()
@@ -247,267 +594,127 @@ func JSONToMsgPack(input string) (string, error) {
 	}
 	buf := make([]byte, 0, 512)
 	enc := codec.NewEncoderBytes(&buf, &msgpackHandle)
-	if err := enc.Encode(data); err != nil {
+	if err := enc.Encode(msgPackNumbers(data, o.sourceOrder(input), "")); err != nil {
 		return "", err
 	}
 	return base64.StdEncoding.EncodeToString(buf), nil
 }
 
-// MsgPackToJSON decodes a base64 MsgPack payload into pretty JSON.
-func MsgPackToJSON(input string) (string, error) {
-	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(input))
-	if err != nil {
-		return "", err
-	}
-	var data any
-	dec := codec.NewDecoderBytes(raw, &msgpackHandle)
-	if err := dec.Decode(&data); err != nil {
-		return "", err
-	}
-	data = normalizeMsgPackValue(data)
-	pretty, err := json.MarshalIndent(data, "", "  ")
-	if err != nil {
-		return "", err
-	}
-	return string(pretty), nil
-}
+// msgPackMap is an object as alternating keys and values, which codec
+// writes as a map in that order.
+type msgPackMap []any
 
-func normalizeMsgPackValue(v any) any {
+func (msgPackMap) MapBySlice() {}
+
+// msgPackNumbers is common.NormalizeJSONNumbers for MsgPack, which has no
+// number wider than 64 bits: integers up to uint64 stay exact and anything
+// else becomes the nearest float64 instead of a string. Objects become
+// msgPackMaps with their keys in order; path is the path of v.
+func msgPackNumbers(v any, order sourceOrder, path string) any {
 	switch val := v.(type) {
 	case map[string]any:
-		for k, inner := range val {
-			val[k] = normalizeMsgPackValue(inner)
-		}
-		return val
-	case map[interface{}]interface{}:
-		out := make(map[string]any, len(val))
-		for k, inner := range val {
-			out[fmt.Sprint(k)] = normalizeMsgPackValue(inner)
+		out := make(msgPackMap, 0, 2*len(val))
+		for _, k := range order.keys(val, path) {
+			out = append(out, k, msgPackNumbers(val[k], order, keyPath(path, k)))
 		}
 		return out
 	case []any:
-		for i, inner := range val {
-			val[i] = normalizeMsgPackValue(inner)
+		for i, item := range val {
+			val[i] = msgPackNumbers(item, order, path+"[]")
 		}
 		return val
-	default:
-		return val
-	}
-}
-
-const toonIndent = "  "
-
-// JSONToTOON encodes JSON into TOON text.
-func JSONToTOON(input string) (string, error) {
-	var data any
-	dec := json.NewDecoder(strings.NewReader(input))
-	dec.UseNumber()
-	if err := dec.Decode(&data); err != nil {
-		return "", err
-	}
-	builder := &strings.Builder{}
-	if err := writeTOON(builder, "", data, 0, ','); err != nil {
-		return "", err
+	case json.Number:
+		n := common.NumberValue(val)
+		if num, ok := n.(json.Number); ok {
+			f, _ := strconv.ParseFloat(num.String(), 64)
+			return f
+		}
+		return n
 	}
-	return strings.TrimRight(builder.String(), "\n"), nil
+	return v
 }
 
-// TOONToJSON decodes TOON text back into JSON.
-func TOONToJSON(input string) (string, error) {
-	parser := newToonParser(input)
-	value, err := parser.parse()
+// MsgPackToJSON decodes a base64 MsgPack payload into pretty JSON.
+func MsgPackToJSON(input string) (string, error) {
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(input))
 	if err != nil {
 		return "", err
 	}
-	out, err := json.MarshalIndent(value, "", "  ")
+	if len(raw) == 0 {
+		return "", io.EOF
+	}
+	doc, err := msgPackDoc(&docBuilder{}, raw, 0)
 	if err != nil {
 		return "", err
 	}
-	return string(out), nil
-}
-
-func writeTOON(b *strings.Builder, key string, value any, depth int, docDelim rune) error {
-	switch v := value.(type) {
-	case map[string]any:
-		if key != "" {
-			writeIndent(b, depth)
-			fmt.Fprintf(b, "%s:\n", key)
-			depth++
-		}
-		keys := orderedKeys(v)
-		for _, k := range keys {
-			if err := writeTOON(b, k, v[k], depth, docDelim); err != nil {
-				return err
-			}
-		}
-	case []any:
-		return writeTOONArray(b, key, v, depth, docDelim)
-	default:
-		writeIndent(b, depth)
-		if key != "" {
-			fmt.Fprintf(b, "%s: %s\n", key, formatPrimitive(v, docDelim))
-		} else {
-			fmt.Fprintf(b, "%s\n", formatPrimitive(v, docDelim))
-		}
-	}
-	return nil
+	return docToJSON(doc, "  ")
 }
 
-func writeTOONArray(b *strings.Builder, key string, arr []any, depth int, docDelim rune) error {
-	length := len(arr)
-	fields, rows, ok := detectTabular(arr)
-	indent := strings.Repeat(toonIndent, depth)
-	delimiter := ','
-	if key == "" {
-		if ok {
-			fmt.Fprintf(b, "%s[%d]{%s}:\n", indent, length, strings.Join(fields, ","))
-		} else if allPrimitives(arr) {
-			vals := joinPrimitiveArray(arr, delimiter)
-			fmt.Fprintf(b, "%s[%d]: %s\n", indent, length, vals)
-		} else {
-			fmt.Fprintf(b, "%s[%d]:\n", indent, length)
-		}
-	} else {
-		if ok {
-			fmt.Fprintf(b, "%s%s[%d]{%s}:\n", indent, key, length, strings.Join(fields, ","))
-		} else if allPrimitives(arr) {
-			vals := joinPrimitiveArray(arr, delimiter)
-			fmt.Fprintf(b, "%s%s[%d]: %s\n", indent, key, length, vals)
-		} else {
-			fmt.Fprintf(b, "%s%s[%d]:\n", indent, key, length)
-		}
-	}
+// msgPackPairs reads a map as alternating keys and values, undecoded, so
+// that their order survives.
+type msgPackPairs []codec.Raw
 
-	if ok {
-		for _, row := range rows {
-			writeIndent(b, depth+1)
-			values := make([]string, len(fields))
-			for idx, field := range fields {
-				values[idx] = formatPrimitive(row[field], delimiter)
-			}
-			fmt.Fprintf(b, "%s\n", strings.Join(values, ","))
-		}
-		return nil
-	}
+func (msgPackPairs) MapBySlice() {}
 
-	if allPrimitives(arr) {
-		return nil
+// msgPackDoc reads the MessagePack value raw into the document model with
+// map keys in stream order; codec decodes every value, so containers are
+// read as raw elements and taken apart one level at a time.
+func msgPackDoc(b *docBuilder, raw []byte, depth int) (*docNode, error) {
+	if depth > docMaxDepth {
+		return nil, errors.New("MsgPack nesting too deep")
 	}
-	return writeListEntries(b, arr, depth, docDelim)
-}
-
-func joinPrimitiveArray(arr []any, delim rune) string {
-	parts := make([]string, len(arr))
-	for i, v := range arr {
-		parts[i] = formatPrimitive(v, delim)
+	if len(raw) == 0 {
+		// codec leaves a nil element empty.
+		return b.null(), nil
 	}
-	sep := string(delim)
-	return strings.Join(parts, sep)
-}
-
-func writeListEntries(b *strings.Builder, items []any, depth int, docDelim rune) error {
-	for _, item := range items {
-		writeIndent(b, depth+1)
-		switch val := item.(type) {
-		case map[string]any:
-			if err := writeListObject(b, val, depth, docDelim); err != nil {
-				return err
-			}
-		case []any:
-			if err := writeArrayListItem(b, val, depth, docDelim); err != nil {
-				return err
-			}
-		default:
-			fmt.Fprintf(b, "- %s\n", formatPrimitive(val, docDelim))
+	switch m := raw[0]; {
+	case m&0xf0 == 0x80 || m == 0xde || m == 0xdf:
+		var pairs msgPackPairs
+		if err := codec.NewDecoderBytes(raw, &msgpackHandle).Decode(&pairs); err != nil {
+			return nil, err
 		}
-	}
-	return nil
-}
-
-func writeListObject(b *strings.Builder, obj map[string]any, depth int, docDelim rune) error {
-	fmt.Fprint(b, "-")
-	if len(obj) == 0 {
-		fmt.Fprint(b, "\n")
-		return nil
-	}
-	keys := orderedKeys(obj)
-	first := true
-	for _, k := range keys {
-		val := obj[k]
-		if first {
-			fmt.Fprint(b, " ")
-			if err := writeInlineField(b, k, val, depth, docDelim); err != nil {
-				return err
+		obj := b.object()
+		for i := 0; i+1 < len(pairs); i += 2 {
+			var key any
+			if err := codec.NewDecoderBytes(pairs[i], &msgpackHandle).Decode(&key); err != nil {
+				return nil, err
+			}
+			val, err := msgPackDoc(b, pairs[i+1], depth+1)
+			if err != nil {
+				return nil, err
 			}
-			first = false
-			continue
+			b.set(obj, fmt.Sprint(key), val)
 		}
-		fmt.Fprint(b, "\n")
-		if err := writeTOON(b, k, val, depth+2, docDelim); err != nil {
-			return err
+		return obj, nil
+	case m&0xf0 == 0x90 || m == 0xdc || m == 0xdd:
+		var items []codec.Raw
+		if err := codec.NewDecoderBytes(raw, &msgpackHandle).Decode(&items); err != nil {
+			return nil, err
 		}
-	}
-	fmt.Fprint(b, "\n")
-	return nil
-}
-
-func writeInlineField(b *strings.Builder, key string, value any, depth int, docDelim rune) error {
-	switch val := value.(type) {
-	case map[string]any:
-		fmt.Fprintf(b, "%s:\n", key)
-		return writeTOON(b, "", val, depth+2, docDelim)
-	case []any:
-		return writeFieldArrayInline(b, key, val, depth+1, docDelim)
-	default:
-		fmt.Fprintf(b, "%s: %s", key, formatPrimitive(val, docDelim))
-		return nil
-	}
-}
-
-func writeFieldArrayInline(b *strings.Builder, key string, arr []any, depth int, docDelim rune) error {
-	fields, rows, ok := detectTabular(arr)
-	delimiter := ','
-	if ok {
-		fmt.Fprintf(b, "%s[%d]{%s}:\n", key, len(arr), strings.Join(fields, ","))
-		for _, row := range rows {
-			writeIndent(b, depth+1)
-			values := make([]string, len(fields))
-			for idx, field := range fields {
-				values[idx] = formatPrimitive(row[field], delimiter)
+		arr := b.array(make([]*docNode, len(items))...)
+		for i, item := range items {
+			val, err := msgPackDoc(b, item, depth+1)
+			if err != nil {
+				return nil, err
 			}
-			fmt.Fprintf(b, "%s\n", strings.Join(values, ","))
+			arr.items[i] = val
 		}
-		return nil
+		return arr, nil
 	}
-	if allPrimitives(arr) {
-		fmt.Fprintf(b, "%s[%d]: %s", key, len(arr), joinPrimitiveArray(arr, delimiter))
-		return nil
+	var v any
+	if err := codec.NewDecoderBytes(raw, &msgpackHandle).Decode(&v); err != nil {
+		return nil, err
 	}
-	fmt.Fprintf(b, "%s[%d]:\n", key, len(arr))
-	return writeListEntries(b, arr, depth, docDelim)
-}
-
-func writeArrayListItem(b *strings.Builder, arr []any, depth int, docDelim rune) error {
-	fields, rows, ok := detectTabular(arr)
-	delimiter := ','
-	if ok {
-		fmt.Fprintf(b, "- [%d]{%s}:\n", len(arr), strings.Join(fields, ","))
-		for _, row := range rows {
-			writeIndent(b, depth+2)
-			values := make([]string, len(fields))
-			for idx, field := range fields {
-				values[idx] = formatPrimitive(row[field], delimiter)
-			}
-			fmt.Fprintf(b, "%s\n", strings.Join(values, ","))
-		}
-		return nil
+	switch v.(type) {
+	case nil, bool, int64, uint64, float64, string:
+		return docScalar(&b.arena, v, docPos{})
 	}
-	if allPrimitives(arr) {
-		fmt.Fprintf(b, "- [%d]: %s\n", len(arr), joinPrimitiveArray(arr, delimiter))
-		return nil
+	// Binary data, timestamps and extensions as encoding/json writes them.
+	text, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
 	}
-	fmt.Fprintf(b, "- [%d]:\n", len(arr))
-	return writeListEntries(b, arr, depth+1, docDelim)
+	return parseJSONDoc(string(text))
 }
 
 func orderedKeys(m map[string]any) []string {
@@ -519,390 +726,4 @@ func orderedKeys(m map[string]any) []string {
 	return keys
 }
 
-func allPrimitives(arr []any) bool {
-	for _, v := range arr {
-		switch v.(type) {
-		case map[string]any, []any:
-			return false
-		}
-	}
-	return true
-}
-
-func detectTabular(arr []any) ([]string, []map[string]any, bool) {
-	if len(arr) == 0 {
-		return nil, nil, false
-	}
-	first, ok := arr[0].(map[string]any)
-	if !ok {
-		return nil, nil, false
-	}
-	fields := orderedKeys(first)
-	rows := make([]map[string]any, 0, len(arr))
-	rows = append(rows, first)
-	for i := 1; i < len(arr); i++ {
-		obj, ok := arr[i].(map[string]any)
-		if !ok {
-			return nil, nil, false
-		}
-		if !sameFieldSet(fields, obj) {
-			return nil, nil, false
-		}
-		rows = append(rows, obj)
-	}
-	for _, row := range rows {
-		for _, f := range fields {
-			if _, ok := row[f]; !ok {
-				return nil, nil, false
-			}
-			switch row[f].(type) {
-			case map[string]any, []any:
-				return nil, nil, false
-			}
-		}
-	}
-	return fields, rows, true
-}
-
-func sameFieldSet(fields []string, obj map[string]any) bool {
-	if len(fields) != len(obj) {
-		return false
-	}
-	for _, f := range fields {
-		if _, ok := obj[f]; !ok {
-			return false
-		}
-	}
-	return true
-}
-
-func writeIndent(b *strings.Builder, depth int) {
-	for i := 0; i < depth; i++ {
-		b.WriteString(toonIndent)
-	}
-}
-
-var numberPattern = regexp.MustCompile(`^-?(?:0|[1-9]\d*)(?:\.\d+)?(?:[eE][+-]?\d+)?$`)
-
-func formatPrimitive(value any, delim rune) string {
-	switch v := value.(type) {
-	case nil:
-		return "null"
-	case bool:
-		if v {
-			return "true"
-		}
-		return "false"
-	case json.Number:
-		return v.String()
-	case float64:
-		return strconv.FormatFloat(v, 'f', -1, 64)
-	case string:
-		if needsQuote(v, delim) {
-			return quoteString(v)
-		}
-		return v
-	default:
-		return fmt.Sprintf("%v", v)
-	}
-}
-
-func needsQuote(s string, delim rune) bool {
-	if s == "" || strings.TrimSpace(s) != s {
-		return true
-	}
-	switch s {
-	case "true", "false", "null":
-		return true
-	}
-	if numberPattern.MatchString(s) {
-		return true
-	}
-	if strings.ContainsAny(s, ":\"\\[]{}") {
-		return true
-	}
-	if strings.ContainsRune(s, '\n') || strings.ContainsRune(s, '\r') || strings.ContainsRune(s, '\t') {
-		return true
-	}
-	if strings.ContainsRune(s, delim) {
-		return true
-	}
-	if strings.HasPrefix(s, "-") {
-		return true
-	}
-	return false
-}
-
-func quoteString(s string) string {
-	replacer := strings.NewReplacer(
-		`"`, `\"`,
-		`\`, `\\`,
-		"\n", `\n`,
-		"\r", `\r`,
-		"\t", `\t`,
-	)
-	return `"` + replacer.Replace(s) + `"`
-}
-
-// --------- Minimal parser ----------
-
-type toonParser struct {
-	lines []toonLine
-	idx   int
-}
-
-type toonLine struct {
-	depth  int
-	text   string
-	number int
-}
-
-func newToonParser(input string) *toonParser {
-	raw := strings.Split(strings.ReplaceAll(input, "\r\n", "\n"), "\n")
-	lines := make([]toonLine, 0, len(raw))
-	for i, line := range raw {
-		if strings.TrimSpace(line) == "" {
-			continue
-		}
-		depth := countIndent(line)
-		text := strings.TrimSpace(line)
-		lines = append(lines, toonLine{depth: depth, text: text, number: i + 1})
-	}
-	return &toonParser{lines: lines}
-}
-
-func countIndent(line string) int {
-	count := 0
-	for strings.HasPrefix(line[count:], "  ") {
-		count += 2
-	}
-	return count / 2
-}
-
-func (p *toonParser) parse() (any, error) {
-	if len(p.lines) == 0 {
-		return map[string]any{}, nil
-	}
-	line := p.lines[0]
-	if strings.HasPrefix(line.text, "[") {
-		return p.parseHeader(0)
-	}
-	if !strings.Contains(line.text, ":") && len(p.lines) == 1 {
-		return parsePrimitiveToken(line.text), nil
-	}
-	return p.parseObject(0)
-}
-
-func (p *toonParser) parseObject(depth int) (map[string]any, error) {
-	result := map[string]any{}
-	for p.idx < len(p.lines) {
-		line := p.lines[p.idx]
-		if line.depth < depth {
-			break
-		}
-		if line.depth > depth {
-			return nil, fmt.Errorf("unexpected indentation near line %d", line.number)
-		}
-		if strings.HasPrefix(line.text, "[") {
-			break
-		}
-		key, rest, ok := strings.Cut(line.text, ":")
-		if !ok {
-			return nil, fmt.Errorf("expected key on line %d", line.number)
-		}
-		key = strings.TrimSpace(key)
-		value := strings.TrimSpace(rest)
-		p.idx++
-		switch {
-		case headerRegex.MatchString(line.text):
-			p.idx--
-			arr, err := p.parseHeader(depth)
-			if err != nil {
-				return nil, err
-			}
-			result[key] = arr
-		case value == "":
-			nested, err := p.parseObject(depth + 1)
-			if err != nil {
-				return nil, err
-			}
-			result[key] = nested
-		default:
-			result[key] = parsePrimitiveToken(value)
-		}
-	}
-	return result, nil
-}
-
-var headerRegex = regexp.MustCompile(`^[A-Za-z0-9._"]*\[\d+[|\t]?\](?:\{.*\})?:`)
-
-func (p *toonParser) parseHeader(depth int) (any, error) {
-	line := p.lines[p.idx]
-	p.idx++
-	text := line.text
-	beforeColon, inline, _ := strings.Cut(text, ":")
-	inline = strings.TrimSpace(inline)
-	header := beforeColon
-	bracketStart := strings.Index(header, "[")
-	if bracketStart == -1 {
-		return nil, fmt.Errorf("invalid header on line %d", line.number)
-	}
-	bracket := header[bracketStart:]
-	brace := ""
-	if idx := strings.Index(bracket, "{"); idx != -1 {
-		brace = bracket[idx:]
-		bracket = bracket[:idx]
-	}
-	lengthText := strings.Trim(bracket, "[]\t|")
-	length, err := strconv.Atoi(lengthText)
-	if err != nil {
-		return nil, fmt.Errorf("invalid length on line %d", line.number)
-	}
-	delimiter := ','
-	if strings.Contains(header, "\t") {
-		delimiter = '\t'
-	} else if strings.Contains(header, "|") {
-		delimiter = '|'
-	}
-	if inline != "" {
-		values := splitDelimited(inline, delimiter)
-		arr := make([]any, 0, len(values))
-		for _, v := range values {
-			arr = append(arr, parsePrimitiveToken(v))
-		}
-		return arr, nil
-	}
-	if brace != "" {
-		fieldList := strings.Trim(brace, "{}")
-		fields := splitDelimited(fieldList, delimiter)
-		rows := make([]map[string]any, 0, length)
-		for i := 0; i < length && p.idx < len(p.lines); i++ {
-			rowLine := p.lines[p.idx]
-			if rowLine.depth != depth+1 {
-				return nil, fmt.Errorf("expected row at line %d", rowLine.number)
-			}
-			values := splitDelimited(rowLine.text, delimiter)
-			if len(values) != len(fields) {
-				return nil, fmt.Errorf("row width mismatch near line %d", rowLine.number)
-			}
-			row := map[string]any{}
-			for idx, field := range fields {
-				row[field] = parsePrimitiveToken(values[idx])
-			}
-			rows = append(rows, row)
-			p.idx++
-		}
-		arr := make([]any, len(rows))
-		for i, row := range rows {
-			arr[i] = row
-		}
-		return arr, nil
-	}
-	items := make([]any, 0, length)
-	for p.idx < len(p.lines) {
-		itemLine := p.lines[p.idx]
-		if itemLine.depth < depth+1 {
-			break
-		}
-		if itemLine.depth > depth+1 {
-			return nil, fmt.Errorf("unexpected indentation in list near line %d", itemLine.number)
-		}
-		if !strings.HasPrefix(itemLine.text, "-") {
-			break
-		}
-		content := strings.TrimSpace(strings.TrimPrefix(itemLine.text, "-"))
-		p.idx++
-		if content == "" {
-			items = append(items, map[string]any{})
-			continue
-		}
-		if strings.Contains(content, ":") {
-			subKey, rest, _ := strings.Cut(content, ":")
-			subKey = strings.TrimSpace(subKey)
-			rest = strings.TrimSpace(rest)
-			if rest == "" {
-				obj, err := p.parseObject(depth + 2)
-				if err != nil {
-					return nil, err
-				}
-				items = append(items, map[string]any{subKey: obj})
-			} else {
-				items = append(items, map[string]any{subKey: parsePrimitiveToken(rest)})
-			}
-		} else if headerRegex.MatchString(content) {
-			p.idx--
-			arr, err := p.parseHeader(depth + 1)
-			if err != nil {
-				return nil, err
-			}
-			items = append(items, arr)
-		} else {
-			items = append(items, parsePrimitiveToken(content))
-		}
-	}
-	return items, nil
-}
-
-func splitDelimited(input string, delim rune) []string {
-	var result []string
-	current := strings.Builder{}
-	inQuotes := false
-	escaped := false
-	for _, ch := range input {
-		switch {
-		case escaped:
-			current.WriteRune(ch)
-			escaped = false
-		case ch == '\\':
-			escaped = true
-		case ch == '"':
-			inQuotes = !inQuotes
-			current.WriteRune(ch)
-		case ch == delim && !inQuotes:
-			result = append(result, strings.TrimSpace(current.String()))
-			current.Reset()
-		default:
-			current.WriteRune(ch)
-		}
-	}
-	if current.Len() > 0 {
-		result = append(result, strings.TrimSpace(current.String()))
-	}
-	return result
-}
-
-func parsePrimitiveToken(token string) any {
-	token = strings.TrimSpace(token)
-	if token == "" {
-		return ""
-	}
-	if strings.HasPrefix(token, "\"") && strings.HasSuffix(token, "\"") {
-		unquoted, err := strconv.Unquote(token)
-		if err == nil {
-			return unquoted
-		}
-		return token
-	}
-	switch token {
-	case "true":
-		return true
-	case "false":
-		return false
-	case "null":
-		return nil
-	}
-	if numberPattern.MatchString(token) {
-		if strings.ContainsAny(token, ".eE") {
-			f, err := strconv.ParseFloat(token, 64)
-			if err == nil {
-				return f
-			}
-		} else {
-			i, err := strconv.ParseInt(token, 10, 64)
-			if err == nil {
-				return i
-			}
-		}
-	}
-	return token
-}
+var numberPattern = common.LazyRegexp(`^-?(?:0|[1-9]\d*)(?:\.\d+)?(?:[eE][+-]?\d+)?$`)
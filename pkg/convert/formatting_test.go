@@ -1,6 +1,9 @@
 package convert
 
 import (
+	"encoding/json"
+	"sort"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -15,7 +18,7 @@ func Test_FormatContent_JSON(t *testing.T) {
 
 	minified, err := FormatContent("JSON", pretty, true)
 	require.NoError(t, err)
-	require.Equal(t, `{"age":27,"name":"Ricky"}`, minified)
+	require.Equal(t, `{"name":"Ricky","age":27}`, minified)
 }
 
 func Test_FormatContent_GoStruct(t *testing.T) {
@@ -80,6 +83,7 @@ func TestMsgPackRoundTrip(t *testing.T) {
 	back, err := MsgPackToJSON(mp)
 	require.NoError(t, err)
 	require.Contains(t, back, `"name": "Alice"`)
+	require.Contains(t, back, `"id": 1`)
 }
 
 func TestTOONRoundTrip(t *testing.T) {
@@ -90,4 +94,80 @@ func TestTOONRoundTrip(t *testing.T) {
 	back, err := TOONToJSON(toon)
 	require.NoError(t, err)
 	require.Contains(t, back, `"count": 2`)
+	require.JSONEq(t, jsonInput, back)
+}
+
+func TestListFormats(t *testing.T) {
+	formats := ListFormats()
+	byName := map[string]FormatInfo{}
+	for i, info := range formats {
+		if i > 0 {
+			require.Less(t, formats[i-1].Name, info.Name)
+		}
+		byName[info.Name] = info
+	}
+	require.Equal(t, FormatInfo{Name: formatJSON, ToJSON: true, FromJSON: true, Format: true, Minify: true}, byName[formatJSON])
+	require.Equal(t, FormatInfo{Name: formatYAML, ToJSON: true, FromJSON: true, Format: true}, byName[formatYAML])
+	require.True(t, byName[formatGoStruct].Format)
+	require.False(t, byName[formatGoStruct].Minify)
+}
+
+func TestRegisterFormat(t *testing.T) {
+	const name = "Test Pairs"
+	RegisterFormat(name, FormatAdapter{
+		ToJSON: func(s string) (string, error) {
+			obj := map[string]any{}
+			for _, line := range strings.Split(strings.TrimSpace(s), "\n") {
+				k, v, _ := strings.Cut(line, "=")
+				obj[strings.TrimSpace(k)] = strings.TrimSpace(v)
+			}
+			return encodeJSON(obj)
+		},
+		FromJSON: func(s string) (string, error) {
+			var obj map[string]string
+			if err := json.Unmarshal([]byte(s), &obj); err != nil {
+				return "", err
+			}
+			lines := make([]string, 0, len(obj))
+			for k, v := range obj {
+				lines = append(lines, k+" = "+v)
+			}
+			sort.Strings(lines)
+			return strings.Join(lines, "\n"), nil
+		},
+		Detect: func(s string) float64 {
+			if strings.HasPrefix(s, "pairs:") {
+				return 2
+			}
+			return 0
+		},
+	})
+	t.Cleanup(func() {
+		adaptersMu.Lock()
+		delete(adapters, name)
+		adaptersMu.Unlock()
+	})
+
+	require.Contains(t, ListFormats(), FormatInfo{Name: name, ToJSON: true, FromJSON: true, Format: true})
+
+	out, err := ConvertFormats(name, formatYAML, "b = 2\na = 1")
+	require.NoError(t, err)
+	require.Equal(t, "a: \"1\"\nb: \"2\"", out)
+
+	out, err = ConvertFormats(formatJSON, name, `{"b":"2","a":"1"}`)
+	require.NoError(t, err)
+	require.Equal(t, "a = 1\nb = 2", out)
+
+	out, err = FormatContent(name, "b=2\n a =1", false)
+	require.NoError(t, err)
+	require.Equal(t, "a = 1\nb = 2", out)
+
+	format, confidence, err := DetectFormat("pairs: yes")
+	require.NoError(t, err)
+	require.Equal(t, name, format)
+	require.Equal(t, 1.0, confidence)
+
+	require.Panics(t, func() { RegisterFormat(name, FormatAdapter{ToJSON: YAMLToJSON}) })
+	require.Panics(t, func() { RegisterFormat("Empty", FormatAdapter{}) })
+	require.Panics(t, func() { RegisterFormat("", FormatAdapter{ToJSON: YAMLToJSON}) })
 }
@@ -0,0 +1,156 @@
+package convert
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const defaultFrequencyTop = 20
+
+// FrequencyEntry is one term with its count and share of all terms of the
+// same kind.
+type FrequencyEntry struct {
+	Term    string  `json:"term"`
+	Count   int     `json:"count"`
+	Percent float64 `json:"percent"`
+}
+
+// TextFrequencyResult summarizes word, n-gram and character usage.
+type TextFrequencyResult struct {
+	Words       int              `json:"words"`
+	UniqueWords int              `json:"uniqueWords"`
+	Characters  int              `json:"characters"`
+	TopWords    []FrequencyEntry `json:"topWords"`
+	Bigrams     []FrequencyEntry `json:"bigrams"`
+	Trigrams    []FrequencyEntry `json:"trigrams"`
+	CharCounts  []FrequencyEntry `json:"charCounts"`
+}
+
+// TextFrequency counts case-folded words, word bigrams and trigrams and
+// non-space characters, keeping the n most frequent of each (20 when n <= 0).
+// Han, Hiragana and Katakana characters count as one word each.
+func TextFrequency(input string, n int) (TextFrequencyResult, error) {
+	if n <= 0 {
+		n = defaultFrequencyTop
+	}
+	words := frequencyWords(input)
+	if len(words) == 0 {
+		return TextFrequencyResult{}, errors.New("no words found")
+	}
+	chars := map[string]int{}
+	totalChars := 0
+	for _, r := range strings.ToLower(input) {
+		if unicode.IsSpace(r) || unicode.IsControl(r) {
+			continue
+		}
+		chars[string(r)]++
+		totalChars++
+	}
+	wordCounts := countTerms(words, 1)
+	return TextFrequencyResult{
+		Words:       len(words),
+		UniqueWords: len(wordCounts),
+		Characters:  totalChars,
+		TopWords:    topTerms(wordCounts, len(words), n),
+		Bigrams:     topTerms(countTerms(words, 2), len(words)-1, n),
+		Trigrams:    topTerms(countTerms(words, 3), len(words)-2, n),
+		CharCounts:  topTerms(chars, totalChars, n),
+	}, nil
+}
+
+// TextFrequencyCSV renders TextFrequency as kind,term,count,percent rows.
+func TextFrequencyCSV(input string, n int) (string, error) {
+	res, err := TextFrequency(input, n)
+	if err != nil {
+		return "", err
+	}
+	buf := &bytes.Buffer{}
+	w := csv.NewWriter(buf)
+	_ = w.Write([]string{"kind", "term", "count", "percent"})
+	for _, group := range []struct {
+		kind    string
+		entries []FrequencyEntry
+	}{
+		{"word", res.TopWords},
+		{"bigram", res.Bigrams},
+		{"trigram", res.Trigrams},
+		{"char", res.CharCounts},
+	} {
+		for _, e := range group.entries {
+			_ = w.Write([]string{group.kind, e.Term, strconv.Itoa(e.Count), strconv.FormatFloat(e.Percent, 'f', -1, 64)})
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return "", err
+	}
+	return strings.TrimRight(buf.String(), "\n"), nil
+}
+
+// frequencyWords splits input into lower-case words. Apostrophes and
+// hyphens inside a word are kept ("don't", "e-mail").
+func frequencyWords(input string) []string {
+	var words []string
+	var current []rune
+	flush := func() {
+		word := strings.Trim(string(current), "'-")
+		if word != "" {
+			words = append(words, word)
+		}
+		current = current[:0]
+	}
+	for _, r := range strings.ToLower(input) {
+		switch {
+		case unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana):
+			flush()
+			words = append(words, string(r))
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r):
+			current = append(current, r)
+		case (r == '\'' || r == '’' || r == '-') && len(current) > 0:
+			if r == '’' {
+				r = '\''
+			}
+			current = append(current, r)
+		default:
+			flush()
+		}
+	}
+	flush()
+	return words
+}
+
+func countTerms(words []string, size int) map[string]int {
+	counts := map[string]int{}
+	for i := 0; i+size <= len(words); i++ {
+		counts[strings.Join(words[i:i+size], " ")]++
+	}
+	return counts
+}
+
+// topTerms sorts by count, then term, and keeps the first n.
+func topTerms(counts map[string]int, total, n int) []FrequencyEntry {
+	entries := make([]FrequencyEntry, 0, len(counts))
+	for term, count := range counts {
+		entries = append(entries, FrequencyEntry{
+			Term:    term,
+			Count:   count,
+			Percent: math.Round(float64(count)/float64(total)*10000) / 100,
+		})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Count != entries[j].Count {
+			return entries[i].Count > entries[j].Count
+		}
+		return entries[i].Term < entries[j].Term
+	})
+	if len(entries) > n {
+		entries = entries[:n]
+	}
+	return entries
+}
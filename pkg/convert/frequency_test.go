@@ -0,0 +1,32 @@
+package convert
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTextFrequency(t *testing.T) {
+	res, err := TextFrequency("The cat saw the cat. The END, don't-stop!", 2)
+	require.NoError(t, err)
+	require.Equal(t, 8, res.Words)
+	require.Equal(t, 5, res.UniqueWords)
+	require.Equal(t, []FrequencyEntry{{Term: "the", Count: 3, Percent: 37.5}, {Term: "cat", Count: 2, Percent: 25}}, res.TopWords)
+	require.Equal(t, FrequencyEntry{Term: "the cat", Count: 2, Percent: 28.57}, res.Bigrams[0])
+	require.Equal(t, "cat saw the", res.Trigrams[0].Term)
+	require.Equal(t, "t", res.CharCounts[0].Term)
+	require.Len(t, res.CharCounts, 2)
+
+	res, err = TextFrequency("我愛 Go，我愛編程", 0)
+	require.NoError(t, err)
+	require.Equal(t, []FrequencyEntry{{Term: "愛", Count: 2, Percent: 28.57}, {Term: "我", Count: 2, Percent: 28.57}}, res.TopWords[:2])
+
+	_, err = TextFrequency(" ... ", 5)
+	require.Error(t, err)
+}
+
+func TestTextFrequencyCSV(t *testing.T) {
+	out, err := TextFrequencyCSV("a b a", 1)
+	require.NoError(t, err)
+	require.Equal(t, "kind,term,count,percent\nword,a,2,66.67\nbigram,a b,1,50\ntrigram,a b a,1,100\nchar,a,2,66.67", out)
+}
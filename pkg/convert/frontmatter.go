@@ -0,0 +1,144 @@
+package convert
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	FrontMatterYAML = "yaml"
+	FrontMatterTOML = "toml"
+)
+
+// frontMatter is a leading YAML (---) or TOML (+++) block of a Markdown
+// document. Lines counts the lines the block occupies, delimiters included.
+type frontMatter struct {
+	Format string
+	Raw    string
+	Body   string
+	Lines  int
+}
+
+// splitFrontMatter detects front matter at the very start of input. A YAML
+// block may also be closed with "...".
+func splitFrontMatter(input string) (frontMatter, bool) {
+	text := strings.TrimPrefix(strings.ReplaceAll(input, "\r\n", "\n"), "\ufeff")
+	lines := strings.Split(text, "\n")
+	if len(lines) < 2 {
+		return frontMatter{}, false
+	}
+	var format string
+	switch strings.TrimRight(lines[0], " \t") {
+	case "---":
+		format = FrontMatterYAML
+	case "+++":
+		format = FrontMatterTOML
+	default:
+		return frontMatter{}, false
+	}
+	for i := 1; i < len(lines); i++ {
+		line := strings.TrimRight(lines[i], " \t")
+		if line != strings.TrimRight(lines[0], " \t") && (format != FrontMatterYAML || line != "...") {
+			continue
+		}
+		return frontMatter{
+			Format: format,
+			Raw:    strings.Join(lines[1:i], "\n"),
+			Body:   strings.TrimLeft(strings.Join(lines[i+1:], "\n"), "\n"),
+			Lines:  i + 1,
+		}, true
+	}
+	return frontMatter{}, false
+}
+
+// markdownBodyLines returns the lines after any front matter together with
+// the number of lines skipped, so callers can report document line numbers.
+func markdownBodyLines(input string) ([]string, int) {
+	lines := strings.Split(strings.ReplaceAll(input, "\r\n", "\n"), "\n")
+	if fm, ok := splitFrontMatter(input); ok {
+		return lines[fm.Lines:], fm.Lines
+	}
+	return lines, 0
+}
+
+// FrontMatterToJSON extracts YAML or TOML front matter as JSON.
+func FrontMatterToJSON(input string) (string, error) {
+	fm, ok := splitFrontMatter(input)
+	if !ok {
+		return "", errors.New("no front matter found")
+	}
+	return frontMatterJSON(fm)
+}
+
+// ConvertFrontMatter rewrites the front matter as YAML or TOML and keeps the
+// document body untouched.
+func ConvertFrontMatter(input, to string) (string, error) {
+	fm, ok := splitFrontMatter(input)
+	if !ok {
+		return "", errors.New("no front matter found")
+	}
+	data, err := frontMatterJSON(fm)
+	if err != nil {
+		return "", err
+	}
+	return renderFrontMatter(strings.ToLower(strings.TrimSpace(to)), data, fm.Body)
+}
+
+// MergeFrontMatter replaces the document front matter with the JSON object
+// in data, keeping the existing format (YAML when the document has none).
+// An empty object removes the front matter.
+func MergeFrontMatter(input, data string) (string, error) {
+	format, body := FrontMatterYAML, strings.ReplaceAll(input, "\r\n", "\n")
+	if fm, ok := splitFrontMatter(input); ok {
+		format, body = fm.Format, fm.Body
+	}
+	value, err := decodeJSONValue(data)
+	if err != nil {
+		return "", err
+	}
+	obj, ok := value.(map[string]any)
+	if !ok {
+		return "", errors.New("front matter must be a JSON object")
+	}
+	if len(obj) == 0 {
+		return body, nil
+	}
+	return renderFrontMatter(format, data, body)
+}
+
+func frontMatterJSON(fm frontMatter) (string, error) {
+	if strings.TrimSpace(fm.Raw) == "" {
+		return "{}\n", nil
+	}
+	if fm.Format == FrontMatterTOML {
+		return tomlToPlainJSON(fm.Raw)
+	}
+	return YAMLToJSON(fm.Raw)
+}
+
+func renderFrontMatter(format, data, body string) (string, error) {
+	var (
+		raw   string
+		fence string
+		err   error
+	)
+	switch format {
+	case FrontMatterYAML:
+		raw, err = JSONToYAML(data)
+		fence = "---"
+	case FrontMatterTOML:
+		raw, err = JSONToTOML(data)
+		fence = "+++"
+	default:
+		return "", fmt.Errorf("unsupported front matter format %q", format)
+	}
+	if err != nil {
+		return "", err
+	}
+	out := fence + "\n" + strings.TrimRight(raw, "\n") + "\n" + fence + "\n"
+	if body != "" {
+		out += "\n" + body
+	}
+	return out, nil
+}
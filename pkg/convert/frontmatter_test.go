@@ -0,0 +1,68 @@
+package convert
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const sampleFrontMatterDoc = "---\ntitle: Hello\n# draft posts are hidden\ntags:\n  - go\n---\n\n# Post\n\nBody text.\n"
+
+func TestFrontMatterToJSON(t *testing.T) {
+	out, err := FrontMatterToJSON(sampleFrontMatterDoc)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"title":"Hello","tags":["go"]}`, out)
+
+	out, err = FrontMatterToJSON("+++\ntitle = \"Hi\"\n+++\nbody")
+	require.NoError(t, err)
+	require.JSONEq(t, `{"title":"Hi"}`, out)
+
+	_, err = FrontMatterToJSON("# No front matter")
+	require.Error(t, err)
+}
+
+func TestConvertFrontMatter(t *testing.T) {
+	out, err := ConvertFrontMatter(sampleFrontMatterDoc, "TOML")
+	require.NoError(t, err)
+	require.Equal(t, "+++\ntitle = 'Hello'\ntags = ['go']\n+++\n\n# Post\n\nBody text.\n", out)
+
+	back, err := ConvertFrontMatter(out, "yaml")
+	require.NoError(t, err)
+	require.Equal(t, "---\ntitle: Hello\ntags:\n  - go\n---\n\n# Post\n\nBody text.\n", back)
+
+	_, err = ConvertFrontMatter(sampleFrontMatterDoc, "xml")
+	require.Error(t, err)
+}
+
+func TestMergeFrontMatter(t *testing.T) {
+	out, err := MergeFrontMatter(sampleFrontMatterDoc, `{"title":"Edited"}`)
+	require.NoError(t, err)
+	require.Equal(t, "---\ntitle: Edited\n---\n\n# Post\n\nBody text.\n", out)
+
+	out, err = MergeFrontMatter("# Post\n", `{"draft":true}`)
+	require.NoError(t, err)
+	require.Equal(t, "---\ndraft: true\n---\n\n# Post\n", out)
+
+	out, err = MergeFrontMatter(sampleFrontMatterDoc, `{}`)
+	require.NoError(t, err)
+	require.Equal(t, "# Post\n\nBody text.\n", out)
+
+	_, err = MergeFrontMatter(sampleFrontMatterDoc, `[1]`)
+	require.Error(t, err)
+}
+
+func TestMarkdownToolsSkipFrontMatter(t *testing.T) {
+	html, err := MarkdownToHTML(sampleFrontMatterDoc)
+	require.NoError(t, err)
+	require.NotContains(t, html, "title")
+	require.Contains(t, html, "<h1>Post</h1>")
+
+	report, err := CheckMarkdownLinks(sampleFrontMatterDoc)
+	require.NoError(t, err)
+	require.Equal(t, []MarkdownHeading{{Level: 1, Text: "Post", Anchor: "post", Line: 8}}, report.Headings)
+
+	shifted, err := ShiftMarkdownHeadings(sampleFrontMatterDoc, 1)
+	require.NoError(t, err)
+	require.Contains(t, shifted, "\n# draft posts are hidden\n")
+	require.Contains(t, shifted, "\n## Post\n")
+}
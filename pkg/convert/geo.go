@@ -0,0 +1,409 @@
+package convert
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/linzeyan/transform-go/pkg/common"
+)
+
+const (
+	earthRadiusMeters = 6371008.8
+	wgs84A            = 6378137.0
+	wgs84F            = 1 / 298.257223563
+	utmK0             = 0.9996
+	geohashAlphabet   = "0123456789bcdefghjkmnpqrstuvwxyz"
+	utmBands          = "CDEFGHJKLMNPQRSTUVWX"
+)
+
+var (
+	decimalCoordRe = common.LazyRegexp(`^(-?\d+(?:\.\d+)?)\s*[,;\s]\s*(-?\d+(?:\.\d+)?)$`)
+	wktPointRe     = common.LazyRegexp(`(?i)^(?:SRID=\d+;)?POINT\s*\(\s*(-?\d+(?:\.\d+)?)\s+(-?\d+(?:\.\d+)?)\s*\)$`)
+	dmsSuffixRe    = common.LazyRegexp(`(-?\d+(?:\.\d+)?)\s*°\s*(?:(\d+(?:\.\d+)?)\s*['′]\s*)?(?:(\d+(?:\.\d+)?)\s*(?:"|″|''|′′)\s*)?([NSEWnsew])?`)
+	dmsPrefixRe    = common.LazyRegexp(`([NSEWnsew])\s*(-?\d+(?:\.\d+)?)\s*°\s*(?:(\d+(?:\.\d+)?)\s*['′]\s*)?(?:(\d+(?:\.\d+)?)\s*(?:"|″|''|′′)\s*)?`)
+	utmRe          = common.LazyRegexp(`(?i)^(\d{1,2})\s*([C-HJ-NP-X])\s+(\d+(?:\.\d+)?)\s*m?\s*E?\s+(\d+(?:\.\d+)?)\s*m?\s*N?$`)
+	geohashRe      = common.LazyRegexp(`^[0-9b-hjkmnp-z]{1,12}$`)
+	wkbHexRe       = common.LazyRegexp(`^(?i:[0-9a-f]{42,})$`)
+)
+
+// CoordinateResult shows one point in every supported notation.
+type CoordinateResult struct {
+	Input     string  `json:"input"`
+	Format    string  `json:"format"`
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Decimal   string  `json:"decimal"`
+	DMS       string  `json:"dms"`
+	GeoHash   string  `json:"geohash"`
+	UTM       string  `json:"utm,omitempty"`
+	WKB       string  `json:"wkb"`
+	WKT       string  `json:"wkt"`
+}
+
+// DistanceResult is the great-circle distance and bearings between points.
+type DistanceResult struct {
+	From           CoordinateResult `json:"from"`
+	To             CoordinateResult `json:"to"`
+	Meters         float64          `json:"meters"`
+	Kilometers     float64          `json:"kilometers"`
+	Miles          float64          `json:"miles"`
+	NauticalMiles  float64          `json:"nauticalMiles"`
+	InitialBearing float64          `json:"initialBearing"`
+	FinalBearing   float64          `json:"finalBearing"`
+}
+
+// CoordinateInfo parses decimal degrees, DMS, geohash, UTM, WKT or hex WKB
+// (including EWKB) and renders the point in all of them.
+func CoordinateInfo(input string) (CoordinateResult, error) {
+	trimmed := strings.TrimSpace(input)
+	res := CoordinateResult{Input: trimmed}
+	if trimmed == "" {
+		return res, errors.New("input is empty")
+	}
+	lat, lon, format, err := parseCoordinate(trimmed)
+	if err != nil {
+		return res, err
+	}
+	if lat < -90 || lat > 90 || lon < -180 || lon > 180 {
+		return res, fmt.Errorf("coordinate out of range: %g, %g", lat, lon)
+	}
+	res.Format = format
+	res.Latitude = roundTo(lat, 8)
+	res.Longitude = roundTo(lon, 8)
+	res.Decimal = fmt.Sprintf("%s, %s", formatDegrees(lat), formatDegrees(lon))
+	res.DMS = formatDMS(lat, "N", "S") + " " + formatDMS(lon, "E", "W")
+	res.GeoHash = encodeGeohash(lat, lon, 10)
+	if zone, band, east, north, ok := latLonToUTM(lat, lon); ok {
+		res.UTM = fmt.Sprintf("%d%c %.0f %.0f", zone, band, east, north)
+	}
+	res.WKB = encodePointWKB(lon, lat)
+	res.WKT = fmt.Sprintf("POINT(%s %s)", formatDegrees(lon), formatDegrees(lat))
+	return res, nil
+}
+
+// CoordinateDistance returns the haversine distance and bearings between two
+// points given in any notation CoordinateInfo accepts.
+func CoordinateDistance(from, to string) (DistanceResult, error) {
+	var res DistanceResult
+	a, err := CoordinateInfo(from)
+	if err != nil {
+		return res, fmt.Errorf("from: %w", err)
+	}
+	b, err := CoordinateInfo(to)
+	if err != nil {
+		return res, fmt.Errorf("to: %w", err)
+	}
+	res.From, res.To = a, b
+	meters := haversine(a.Latitude, a.Longitude, b.Latitude, b.Longitude)
+	res.Meters = roundTo(meters, 3)
+	res.Kilometers = roundTo(meters/1000, 6)
+	res.Miles = roundTo(meters/1609.344, 6)
+	res.NauticalMiles = roundTo(meters/1852, 6)
+	res.InitialBearing = roundTo(bearing(a.Latitude, a.Longitude, b.Latitude, b.Longitude), 4)
+	res.FinalBearing = roundTo(math.Mod(bearing(b.Latitude, b.Longitude, a.Latitude, a.Longitude)+180, 360), 4)
+	return res, nil
+}
+
+func parseCoordinate(s string) (float64, float64, string, error) {
+	if wkbHexRe().MatchString(s) {
+		lon, lat, err := decodePointWKB(s)
+		return lat, lon, "wkb", err
+	}
+	if m := wktPointRe().FindStringSubmatch(s); m != nil {
+		lon, _ := strconv.ParseFloat(m[1], 64)
+		lat, _ := strconv.ParseFloat(m[2], 64)
+		return lat, lon, "wkt", nil
+	}
+	if m := utmRe().FindStringSubmatch(s); m != nil {
+		zone, _ := strconv.Atoi(m[1])
+		east, _ := strconv.ParseFloat(m[3], 64)
+		north, _ := strconv.ParseFloat(m[4], 64)
+		if zone < 1 || zone > 60 {
+			return 0, 0, "", fmt.Errorf("invalid UTM zone %d", zone)
+		}
+		lat, lon := utmToLatLon(zone, strings.ToUpper(m[2])[0], east, north)
+		return lat, lon, "utm", nil
+	}
+	if strings.Contains(s, "°") {
+		lat, lon, err := parseDMS(s)
+		return lat, lon, "dms", err
+	}
+	if m := decimalCoordRe().FindStringSubmatch(s); m != nil {
+		lat, _ := strconv.ParseFloat(m[1], 64)
+		lon, _ := strconv.ParseFloat(m[2], 64)
+		return lat, lon, "decimal", nil
+	}
+	if geohashRe().MatchString(s) {
+		lat, lon := decodeGeohash(s)
+		return lat, lon, "geohash", nil
+	}
+	return 0, 0, "", fmt.Errorf("unrecognized coordinate: %s", s)
+}
+
+// parseDMS accepts hemisphere letters either before every component
+// ("N 25° 2'") or after it ("25°2'N"); without letters the order is lat, lon.
+func parseDMS(s string) (float64, float64, error) {
+	type part struct {
+		hemi          string
+		deg, min, sec string
+	}
+	var parts []part
+	if strings.ContainsAny(s[:1], "NSEWnsew") {
+		for _, m := range dmsPrefixRe().FindAllStringSubmatch(s, -1) {
+			parts = append(parts, part{m[1], m[2], m[3], m[4]})
+		}
+	} else {
+		for _, m := range dmsSuffixRe().FindAllStringSubmatch(s, -1) {
+			parts = append(parts, part{m[4], m[1], m[2], m[3]})
+		}
+	}
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("expected latitude and longitude in DMS: %s", s)
+	}
+	var lat, lon float64
+	var haveLat, haveLon bool
+	for i, p := range parts {
+		deg, _ := strconv.ParseFloat(p.deg, 64)
+		min, _ := strconv.ParseFloat(p.min, 64)
+		sec, _ := strconv.ParseFloat(p.sec, 64)
+		if min >= 60 || sec >= 60 {
+			return 0, 0, fmt.Errorf("invalid DMS minutes or seconds in %s", s)
+		}
+		value := math.Abs(deg) + min/60 + sec/3600
+		hemi := strings.ToUpper(p.hemi)
+		if hemi == "S" || hemi == "W" || (hemi == "" && deg < 0) {
+			value = -value
+		}
+		if hemi == "N" || hemi == "S" || (hemi == "" && i == 0) {
+			lat, haveLat = value, true
+		} else {
+			lon, haveLon = value, true
+		}
+	}
+	if !haveLat || !haveLon {
+		return 0, 0, errors.New("DMS needs one latitude and one longitude")
+	}
+	return lat, lon, nil
+}
+
+func formatDegrees(v float64) string {
+	return strconv.FormatFloat(roundTo(v, 8), 'f', -1, 64)
+}
+
+func formatDMS(v float64, pos, neg string) string {
+	hemi := pos
+	if v < 0 {
+		hemi = neg
+		v = -v
+	}
+	totalSec := math.Round(v*3600*100) / 100
+	deg := math.Floor(totalSec / 3600)
+	min := math.Floor((totalSec - deg*3600) / 60)
+	sec := totalSec - deg*3600 - min*60
+	return fmt.Sprintf("%.0f°%02.0f'%05.2f\"%s", deg, min, sec, hemi)
+}
+
+func roundTo(v float64, places int) float64 {
+	p := math.Pow10(places)
+	return math.Round(v*p) / p
+}
+
+func encodeGeohash(lat, lon float64, precision int) string {
+	latRange := [2]float64{-90, 90}
+	lonRange := [2]float64{-180, 180}
+	var out strings.Builder
+	bit, ch, even := 0, 0, true
+	for out.Len() < precision {
+		rng, val := &latRange, lat
+		if even {
+			rng, val = &lonRange, lon
+		}
+		mid := (rng[0] + rng[1]) / 2
+		ch <<= 1
+		if val >= mid {
+			ch |= 1
+			rng[0] = mid
+		} else {
+			rng[1] = mid
+		}
+		even = !even
+		if bit++; bit == 5 {
+			out.WriteByte(geohashAlphabet[ch])
+			bit, ch = 0, 0
+		}
+	}
+	return out.String()
+}
+
+func decodeGeohash(hash string) (float64, float64) {
+	latRange := [2]float64{-90, 90}
+	lonRange := [2]float64{-180, 180}
+	even := true
+	for _, c := range hash {
+		idx := strings.IndexRune(geohashAlphabet, c)
+		for mask := 16; mask > 0; mask >>= 1 {
+			rng := &latRange
+			if even {
+				rng = &lonRange
+			}
+			mid := (rng[0] + rng[1]) / 2
+			if idx&mask != 0 {
+				rng[0] = mid
+			} else {
+				rng[1] = mid
+			}
+			even = !even
+		}
+	}
+	return (latRange[0] + latRange[1]) / 2, (lonRange[0] + lonRange[1]) / 2
+}
+
+func encodePointWKB(x, y float64) string {
+	buf := make([]byte, 21)
+	buf[0] = 1
+	binary.LittleEndian.PutUint32(buf[1:], 1)
+	binary.LittleEndian.PutUint64(buf[5:], math.Float64bits(x))
+	binary.LittleEndian.PutUint64(buf[13:], math.Float64bits(y))
+	return strings.ToUpper(hex.EncodeToString(buf))
+}
+
+// decodePointWKB reads an ISO or EWKB point, ignoring SRID, Z and M.
+func decodePointWKB(s string) (float64, float64, error) {
+	raw, err := hex.DecodeString(s)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid WKB hex: %w", err)
+	}
+	var order binary.ByteOrder
+	switch raw[0] {
+	case 0:
+		order = binary.BigEndian
+	case 1:
+		order = binary.LittleEndian
+	default:
+		return 0, 0, errors.New("invalid WKB byte order")
+	}
+	typ := order.Uint32(raw[1:5])
+	pos := 5
+	if typ&0x20000000 != 0 {
+		pos += 4
+	}
+	base := typ & 0x0fffffff
+	if base%1000 != 1 {
+		return 0, 0, fmt.Errorf("WKB geometry type %d is not a point", base)
+	}
+	if len(raw) < pos+16 {
+		return 0, 0, errors.New("WKB point is truncated")
+	}
+	x := math.Float64frombits(order.Uint64(raw[pos:]))
+	y := math.Float64frombits(order.Uint64(raw[pos+8:]))
+	if math.IsNaN(x) || math.IsNaN(y) {
+		return 0, 0, errors.New("WKB point is empty")
+	}
+	return x, y, nil
+}
+
+func utmZone(lat, lon float64) int {
+	zone := int(math.Floor((lon+180)/6)) + 1
+	if zone > 60 {
+		zone = 60
+	}
+	switch {
+	case lat >= 56 && lat < 64 && lon >= 3 && lon < 12:
+		return 32
+	case lat >= 72 && lat < 84 && lon >= 0 && lon < 42:
+		switch {
+		case lon < 9:
+			return 31
+		case lon < 21:
+			return 33
+		case lon < 33:
+			return 35
+		}
+		return 37
+	}
+	return zone
+}
+
+func latLonToUTM(lat, lon float64) (int, byte, float64, float64, bool) {
+	if lat < -80 || lat > 84 {
+		return 0, 0, 0, 0, false
+	}
+	zone := utmZone(lat, lon)
+	bandIdx := int(math.Floor((lat + 80) / 8))
+	if bandIdx >= len(utmBands) {
+		bandIdx = len(utmBands) - 1
+	}
+	e2 := wgs84F * (2 - wgs84F)
+	ep2 := e2 / (1 - e2)
+	phi := lat * math.Pi / 180
+	lambda0 := float64((zone-1)*6-180+3) * math.Pi / 180
+	n := wgs84A / math.Sqrt(1-e2*math.Sin(phi)*math.Sin(phi))
+	t := math.Tan(phi) * math.Tan(phi)
+	c := ep2 * math.Cos(phi) * math.Cos(phi)
+	a := math.Cos(phi) * (lon*math.Pi/180 - lambda0)
+	m := meridionalArc(phi, e2)
+	east := utmK0*n*(a+(1-t+c)*math.Pow(a, 3)/6+(5-18*t+t*t+72*c-58*ep2)*math.Pow(a, 5)/120) + 500000
+	north := utmK0 * (m + n*math.Tan(phi)*(a*a/2+(5-t+9*c+4*c*c)*math.Pow(a, 4)/24+(61-58*t+t*t+600*c-330*ep2)*math.Pow(a, 6)/720))
+	if lat < 0 {
+		north += 10000000
+	}
+	return zone, utmBands[bandIdx], east, north, true
+}
+
+func meridionalArc(phi, e2 float64) float64 {
+	e4, e6 := e2*e2, e2*e2*e2
+	return wgs84A * ((1-e2/4-3*e4/64-5*e6/256)*phi -
+		(3*e2/8+3*e4/32+45*e6/1024)*math.Sin(2*phi) +
+		(15*e4/256+45*e6/1024)*math.Sin(4*phi) -
+		(35*e6/3072)*math.Sin(6*phi))
+}
+
+func utmToLatLon(zone int, band byte, east, north float64) (float64, float64) {
+	e2 := wgs84F * (2 - wgs84F)
+	ep2 := e2 / (1 - e2)
+	e1 := (1 - math.Sqrt(1-e2)) / (1 + math.Sqrt(1-e2))
+	x := east - 500000
+	y := north
+	if band < 'N' {
+		y -= 10000000
+	}
+	mu := y / utmK0 / (wgs84A * (1 - e2/4 - 3*e2*e2/64 - 5*e2*e2*e2/256))
+	phi1 := mu + (3*e1/2-27*math.Pow(e1, 3)/32)*math.Sin(2*mu) +
+		(21*e1*e1/16-55*math.Pow(e1, 4)/32)*math.Sin(4*mu) +
+		(151*math.Pow(e1, 3)/96)*math.Sin(6*mu) +
+		(1097*math.Pow(e1, 4)/512)*math.Sin(8*mu)
+	sin1 := math.Sin(phi1)
+	n1 := wgs84A / math.Sqrt(1-e2*sin1*sin1)
+	t1 := math.Tan(phi1) * math.Tan(phi1)
+	c1 := ep2 * math.Cos(phi1) * math.Cos(phi1)
+	r1 := wgs84A * (1 - e2) / math.Pow(1-e2*sin1*sin1, 1.5)
+	d := x / (n1 * utmK0)
+	lat := phi1 - (n1*math.Tan(phi1)/r1)*(d*d/2-
+		(5+3*t1+10*c1-4*c1*c1-9*ep2)*math.Pow(d, 4)/24+
+		(61+90*t1+298*c1+45*t1*t1-252*ep2-3*c1*c1)*math.Pow(d, 6)/720)
+	lambda0 := float64((zone-1)*6-180+3) * math.Pi / 180
+	lon := lambda0 + (d-(1+2*t1+c1)*math.Pow(d, 3)/6+
+		(5-2*c1+28*t1-3*c1*c1+8*ep2+24*t1*t1)*math.Pow(d, 5)/120)/math.Cos(phi1)
+	return lat * 180 / math.Pi, lon * 180 / math.Pi
+}
+
+func haversine(lat1, lon1, lat2, lon2 float64) float64 {
+	p1, p2 := lat1*math.Pi/180, lat2*math.Pi/180
+	dp := p2 - p1
+	dl := (lon2 - lon1) * math.Pi / 180
+	h := math.Sin(dp/2)*math.Sin(dp/2) + math.Cos(p1)*math.Cos(p2)*math.Sin(dl/2)*math.Sin(dl/2)
+	return 2 * earthRadiusMeters * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+func bearing(lat1, lon1, lat2, lon2 float64) float64 {
+	p1, p2 := lat1*math.Pi/180, lat2*math.Pi/180
+	dl := (lon2 - lon1) * math.Pi / 180
+	y := math.Sin(dl) * math.Cos(p2)
+	x := math.Cos(p1)*math.Sin(p2) - math.Sin(p1)*math.Cos(p2)*math.Cos(dl)
+	return math.Mod(math.Atan2(y, x)*180/math.Pi+360, 360)
+}
@@ -0,0 +1,90 @@
+package convert
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCoordinateInfo(t *testing.T) {
+	res, err := CoordinateInfo("57.64911, 10.40744")
+	require.NoError(t, err)
+	require.Equal(t, "decimal", res.Format)
+	require.Equal(t, "u4pruydqqv", res.GeoHash)
+	require.Equal(t, `57°38'56.80"N 10°24'26.78"E`, res.DMS)
+	require.Equal(t, "POINT(10.40744 57.64911)", res.WKT)
+
+	dms, err := CoordinateInfo(res.DMS)
+	require.NoError(t, err)
+	require.Equal(t, "dms", dms.Format)
+	require.InDelta(t, 57.64911, dms.Latitude, 1e-5)
+	require.InDelta(t, 10.40744, dms.Longitude, 1e-5)
+
+	prefixed, err := CoordinateInfo(`W 0° 7' 40" N 51° 30' 26"`)
+	require.NoError(t, err)
+	require.InDelta(t, 51.5072, prefixed.Latitude, 1e-3)
+	require.InDelta(t, -0.1278, prefixed.Longitude, 1e-3)
+
+	gh, err := CoordinateInfo("ezs42")
+	require.NoError(t, err)
+	require.Equal(t, "geohash", gh.Format)
+	require.InDelta(t, 42.605, gh.Latitude, 0.03)
+	require.InDelta(t, -5.603, gh.Longitude, 0.03)
+
+	wkb, err := CoordinateInfo("0101000000000000000000F03F0000000000000040")
+	require.NoError(t, err)
+	require.Equal(t, "wkb", wkb.Format)
+	require.Equal(t, 2.0, wkb.Latitude)
+	require.Equal(t, 1.0, wkb.Longitude)
+	require.Equal(t, "0101000000000000000000F03F0000000000000040", wkb.WKB)
+
+	// Big-endian EWKB with SRID 4326.
+	ewkb, err := CoordinateInfo("0020000001000010E63FF00000000000004000000000000000")
+	require.NoError(t, err)
+	require.Equal(t, 2.0, ewkb.Latitude)
+
+	wkt, err := CoordinateInfo("SRID=4326;POINT(121.5654 25.033)")
+	require.NoError(t, err)
+	require.Equal(t, 25.033, wkt.Latitude)
+
+	_, err = CoordinateInfo("91, 0")
+	require.Error(t, err)
+	_, err = CoordinateInfo("not a place")
+	require.Error(t, err)
+	_, err = CoordinateInfo("0102000000000000000000F03F0000000000000040")
+	require.Error(t, err)
+}
+
+func TestCoordinateInfoUTM(t *testing.T) {
+	res, err := CoordinateInfo("48.8583, 2.2945")
+	require.NoError(t, err)
+	require.Equal(t, "31U 448252 5411944", res.UTM)
+
+	back, err := CoordinateInfo(res.UTM)
+	require.NoError(t, err)
+	require.Equal(t, "utm", back.Format)
+	require.InDelta(t, 48.8583, back.Latitude, 1e-5)
+	require.InDelta(t, 2.2945, back.Longitude, 1e-5)
+
+	south, err := CoordinateInfo("-33.8568, 151.2153")
+	require.NoError(t, err)
+	require.Equal(t, "56H 334901 6252289", south.UTM)
+	back, err = CoordinateInfo(south.UTM)
+	require.NoError(t, err)
+	require.InDelta(t, -33.8568, back.Latitude, 1e-5)
+
+	polar, err := CoordinateInfo("-85, 0")
+	require.NoError(t, err)
+	require.Empty(t, polar.UTM)
+}
+
+func TestCoordinateDistance(t *testing.T) {
+	res, err := CoordinateDistance("51.5074, -0.1278", "48.8566, 2.3522")
+	require.NoError(t, err)
+	require.InDelta(t, 343.5, res.Kilometers, 0.5)
+	require.InDelta(t, 148.1, res.InitialBearing, 0.2)
+	require.InDelta(t, 150.0, res.FinalBearing, 0.5)
+
+	_, err = CoordinateDistance("bad", "0,0")
+	require.ErrorContains(t, err, "from")
+}
@@ -0,0 +1,95 @@
+package convert
+
+import (
+	"fmt"
+	"strings"
+)
+
+// JSONToGoStructNamed is like JSONToGoStruct but lifts nested objects into
+// top-level named types. Objects with identical shapes share one type.
+func JSONToGoStructNamed(input string) (string, error) {
+	return JSONToGoStructWithOptions(input, WithNamedStructs(true))
+}
+
+// namedElement merges object elements into one shape so arrays of records
+// with optional keys still produce a single named type.
+func (r *goStructRenderer) namedElement(name string, items []*docNode, path string) string {
+	var merged *docNode
+	var index map[string]int
+	var elementType string
+	for _, item := range items {
+		if item.kind == docNull {
+			continue
+		}
+		if item.kind == docObject {
+			if merged == nil {
+				merged = r.arena.node(docObject, item.pos)
+				index = map[string]int{}
+			}
+			for i, k := range item.keys {
+				if at, ok := index[k.name]; !ok {
+					index[k.name] = len(merged.keys)
+					merged.keys = append(merged.keys, k)
+					merged.items = append(merged.items, item.items[i])
+				} else if merged.items[at].kind == docNull {
+					merged.items[at] = item.items[i]
+				}
+			}
+			continue
+		}
+		var t string
+		if item.kind == docArray {
+			t = "[]" + r.namedElement(name, item.items, path+"[]")
+		} else {
+			t = r.renderType(item, path)
+		}
+		if elementType == "" {
+			elementType = t
+		} else if elementType = commonElementType(elementType, t); elementType == "interface{}" {
+			return elementType
+		}
+	}
+	if merged != nil {
+		if elementType != "" {
+			return "interface{}"
+		}
+		return r.namedStruct(name, merged, path)
+	}
+	if elementType == "" {
+		return "interface{}"
+	}
+	return elementType
+}
+
+func (r *goStructRenderer) namedStruct(name string, n *docNode, path string) string {
+	body := r.renderStruct(n, path)
+	if existing, ok := r.byBody[body]; ok {
+		return existing
+	}
+	if name == "" {
+		name = "Type"
+	}
+	unique := name
+	for i := 2; r.typeUsed[unique]; i++ {
+		unique = fmt.Sprintf("%s%d", name, i)
+	}
+	r.typeUsed[unique] = true
+	r.byBody[body] = unique
+	r.defs[unique] = body
+	r.order = append(r.order, unique)
+	return unique
+}
+
+func singularTypeName(name string) string {
+	switch {
+	case strings.HasSuffix(name, "ies") && len(name) > 3:
+		return name[:len(name)-3] + "y"
+	case strings.HasSuffix(name, "ss"):
+		return name + "Item"
+	case strings.HasSuffix(name, "s") && len(name) > 1:
+		return name[:len(name)-1]
+	case name == "":
+		return "Item"
+	}
+	return name + "Item"
+}
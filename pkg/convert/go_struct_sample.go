@@ -2,32 +2,58 @@ package convert
 
 import (
 	"bytes"
+	"cmp"
 	"encoding/json"
 	"errors"
-	"fmt"
 	"go/ast"
 	"go/format"
 	"go/parser"
 	"go/token"
+	"net/url"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/linzeyan/transform-go/pkg/common"
 )
 
 func JSONToGoStruct(input string) (string, error) {
-	data, err := decodeJSONValue(input)
+	return JSONToGoStructWithOptions(input)
+}
+
+// JSONToGoStructWithOptions generates Go types from a JSON sample, honouring
+// tag case, omitempty, pointer fields, number type, key order and named
+// sub-struct settings.
+func JSONToGoStructWithOptions(input string, opts ...ConvertOption) (string, error) {
+	doc, err := parseJSONDoc(input)
 	if err != nil {
 		return "", err
 	}
+	r := newGoStructRenderer(NewConvertOptions(opts...))
+	if !r.opts.SortKeys {
+		r.keyOrder = doc.keyOrder()
+	}
 
-	var sb strings.Builder
-	sb.WriteString("package main\n\n")
-	sb.WriteString("type AutoGenerated ")
-	sb.WriteString(renderType(data))
-	sb.WriteString("\n")
+	var buf bytes.Buffer
+	buf.WriteString("package main\n\ntype AutoGenerated ")
+	if doc.kind == docArray && r.opts.NamedStructs {
+		buf.WriteString("[]" + r.namedElement("AutoGeneratedItem", doc.items, "[]"))
+	} else {
+		r.writeType(&buf, doc, "")
+	}
+	buf.WriteString("\n")
+	for _, name := range r.order {
+		buf.WriteString("\ntype " + name + " " + r.defs[name] + "\n")
+	}
 
-	formatted, err := format.Source([]byte(sb.String()))
+	src := buf.Bytes()
+	if r.opts.SemanticTypes {
+		decls := string(src[len("package main\n\n"):])
+		src = []byte("package main\n\n" + goImportBlock(decls) + decls)
+	}
+	formatted, err := format.Source(src)
 	if err != nil {
 		return "", err
 	}
@@ -35,76 +61,369 @@ func JSONToGoStruct(input string) (string, error) {
 	return strings.TrimSpace(result), nil
 }
 
-func renderType(v any) string {
-	switch val := v.(type) {
-	case map[string]any:
-		return renderStruct(val)
-	case []any:
-		return "[]" + renderArrayElement(val)
-	case json.Number:
-		if common.LooksInteger(val) {
-			return "int"
+// goStructRenderer writes Go types straight from the document model into
+// one buffer. Field names and tags are worked out once per distinct key,
+// since large documents repeat the same keys in every record.
+type goStructRenderer struct {
+	opts     ConvertOptions
+	keyOrder map[string][]string
+	keyRank  map[string]map[string]int
+	paths    map[[3]string]string
+	fields   map[string]goFieldNames
+	// indexes and names are stacks shared by the structs being written.
+	indexes []int
+	names   []string
+	arena   docArena
+
+	// Named sub-struct state, used when opts.NamedStructs is set.
+	byBody   map[string]string
+	typeUsed map[string]bool
+	defs     map[string]string
+	order    []string
+}
+
+// goFieldNames is what a JSON key becomes: its exported name (empty when
+// the key has no letters or digits) and its tag literal.
+type goFieldNames struct {
+	export string
+	tags   string
+}
+
+func newGoStructRenderer(opts ConvertOptions) *goStructRenderer {
+	return &goStructRenderer{
+		opts:   opts,
+		fields: map[string]goFieldNames{},
+		byBody: map[string]string{},
+		// Reserved up front so a nested "autoGenerated" key cannot take it.
+		typeUsed: map[string]bool{"AutoGenerated": true},
+		defs:     map[string]string{},
+	}
+}
+
+func (r *goStructRenderer) field(key string) goFieldNames {
+	f, ok := r.fields[key]
+	if !ok {
+		f = goFieldNames{export: common.ExportName(key), tags: r.opts.structTags(key)}
+		r.fields[key] = f
+	}
+	return f
+}
+
+// keyPath and itemPath extend a path for keyOrder lookups. With sorted
+// keys no path is needed, so none is built; otherwise each is built once,
+// as every record of an array walks the same paths.
+func (r *goStructRenderer) keyPath(path, key string) string {
+	if r.keyOrder == nil {
+		return ""
+	}
+	if path == "" {
+		return key
+	}
+	return r.path(path, ".", key)
+}
+
+func (r *goStructRenderer) itemPath(path string) string {
+	if r.keyOrder == nil {
+		return ""
+	}
+	return r.path(path, "[]", "")
+}
+
+func (r *goStructRenderer) path(parent, sep, key string) string {
+	k := [3]string{parent, sep, key}
+	p, ok := r.paths[k]
+	if !ok {
+		p = parent + sep + key
+		if r.paths == nil {
+			r.paths = map[[3]string]string{}
 		}
-		return "float64"
-	case string:
-		return "string"
-	case bool:
-		return "bool"
-	case nil:
-		return "interface{}"
+		r.paths[k] = p
+	}
+	return p
+}
+
+func (r *goStructRenderer) renderType(n *docNode, path string) string {
+	var buf bytes.Buffer
+	r.writeType(&buf, n, path)
+	return buf.String()
+}
+
+func (r *goStructRenderer) writeType(buf *bytes.Buffer, n *docNode, path string) {
+	switch n.kind {
+	case docObject:
+		r.writeStruct(buf, n, path)
+	case docArray:
+		buf.WriteString("[]")
+		r.writeArrayElement(buf, n.items, r.itemPath(path))
+	case docNumber:
+		switch {
+		case r.opts.NumberType == NumberTypeFloat64:
+			buf.WriteString("float64")
+		case !common.LooksInteger(json.Number(n.text)):
+			// IDs above int64 still fit an unsigned 64-bit integer.
+			if isUint64(n.text) {
+				buf.WriteString("uint64")
+			} else {
+				buf.WriteString("float64")
+			}
+		case r.opts.NumberType == NumberTypeInt64:
+			buf.WriteString("int64")
+		default:
+			buf.WriteString("int")
+		}
+	case docString:
+		if r.opts.SemanticTypes {
+			buf.WriteString(semanticStringType(n.text))
+		} else {
+			buf.WriteString("string")
+		}
+	case docBool:
+		buf.WriteString("bool")
 	default:
-		return "interface{}"
+		buf.WriteString("interface{}")
+	}
+}
+
+// semanticGoTypes maps the package qualifiers generated types may use to
+// their import paths.
+var semanticGoTypes = map[string]string{
+	"json": "encoding/json",
+	"time": "time",
+	"url":  "net/url",
+	"uuid": "github.com/google/uuid",
+	"xml":  "encoding/xml",
+}
+
+func semanticStringType(s string) string {
+	switch {
+	case schemaFormatMatches("date-time", s):
+		return "time.Time"
+	case schemaFormatMatches("uuid", s):
+		return "uuid.UUID"
+	}
+	return "string"
+}
+
+// semanticStringNote names the type a string field holds when that type
+// does not decode from a JSON string itself: encoding/json reads
+// time.Duration as nanoseconds and url.URL as an object, so such fields
+// stay strings and get a comment saying how to parse them.
+func semanticStringNote(s string) string {
+	if _, err := time.ParseDuration(s); err == nil && s != "0" {
+		return "a time.Duration; parse with time.ParseDuration"
+	}
+	if u, err := url.Parse(s); err == nil && u.Scheme != "" && u.Host != "" {
+		return "a url.URL; parse with url.Parse"
+	}
+	return ""
+}
+
+// commonElementType unifies the types of two array elements; strings that
+// only some elements give a semantic type stay strings.
+func commonElementType(a, b string) string {
+	if a == b {
+		return a
+	}
+	stringLike := func(t string) bool {
+		return t == "string" || t == "time.Time" || t == "uuid.UUID"
 	}
+	if stringLike(a) && stringLike(b) {
+		return "string"
+	}
+	return "interface{}"
 }
 
-func renderStruct(obj map[string]any) string {
-	var buf strings.Builder
+// goImportBlock lists the imports the generated declarations use, standard
+// library first, by parsing them rather than guessing from the text.
+func goImportBlock(decls string) string {
+	file, err := parser.ParseFile(token.NewFileSet(), "", "package main\n\n"+decls, 0)
+	if err != nil {
+		return ""
+	}
+	used := map[string]bool{}
+	ast.Inspect(file, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if id, ok := sel.X.(*ast.Ident); ok {
+				if path, ok := semanticGoTypes[id.Name]; ok {
+					used[path] = true
+				}
+			}
+		}
+		return true
+	})
+	var std, external []string
+	for path := range used {
+		if strings.Contains(path, ".") {
+			external = append(external, path)
+		} else {
+			std = append(std, path)
+		}
+	}
+	sort.Strings(std)
+	sort.Strings(external)
+	switch len(std) + len(external) {
+	case 0:
+		return ""
+	case 1:
+		return "import \"" + append(std, external...)[0] + "\"\n\n"
+	}
+	var b strings.Builder
+	b.WriteString("import (\n")
+	for _, path := range std {
+		b.WriteString("\t\"" + path + "\"\n")
+	}
+	if len(std) > 0 && len(external) > 0 {
+		b.WriteString("\n")
+	}
+	for _, path := range external {
+		b.WriteString("\t\"" + path + "\"\n")
+	}
+	b.WriteString(")\n\n")
+	return b.String()
+}
+
+func (r *goStructRenderer) renderStruct(n *docNode, path string) string {
+	var buf bytes.Buffer
+	r.writeStruct(&buf, n, path)
+	return buf.String()
+}
+
+func (r *goStructRenderer) writeStruct(buf *bytes.Buffer, n *docNode, path string) {
 	buf.WriteString("struct {\n")
-	keys := make([]string, 0, len(obj))
-	for k := range obj {
-		keys = append(keys, k)
-	}
-	sort.Strings(keys)
-	seen := map[string]int{}
-	for _, key := range keys {
-		fieldName := common.ExportName(key)
+	base := len(r.names)
+	order := r.keyIndexes(n, path)
+	var seen map[string]int
+	for _, i := range order {
+		key := n.keys[i].name
+		child := n.items[i]
+		f := r.field(key)
+		fieldName := f.export
 		if fieldName == "" {
 			fieldName = "Field"
 		}
-		if count := seen[fieldName]; count > 0 {
-			fieldName = fieldName + fmt.Sprintf("%d", count+1)
+		// Count earlier fields with the same name; wide structs use a map.
+		count := 0
+		if len(order) <= 32 {
+			for _, name := range r.names[base:] {
+				if name == fieldName {
+					count++
+				}
+			}
+			r.names = append(r.names, fieldName)
+		} else {
+			if seen == nil {
+				seen = make(map[string]int, len(order))
+			}
+			count = seen[fieldName]
+			seen[fieldName]++
 		}
-		seen[fieldName]++
-		fieldType := renderType(obj[key])
 		buf.WriteString("\t")
 		buf.WriteString(fieldName)
+		if count > 0 {
+			buf.WriteString(strconv.Itoa(count + 1))
+		}
+		buf.WriteString(" ")
+		if child.kind == docObject && r.opts.PointerFields {
+			buf.WriteString("*")
+		}
+		childPath := r.keyPath(path, key)
+		switch {
+		case child.kind == docObject && r.opts.NamedStructs:
+			buf.WriteString(r.namedStruct(f.export, child, childPath))
+		case child.kind == docArray && r.opts.NamedStructs:
+			buf.WriteString("[]" + r.namedElement(singularTypeName(f.export), child.items, childPath+"[]"))
+		default:
+			r.writeType(buf, child, childPath)
+		}
 		buf.WriteString(" ")
-		buf.WriteString(fieldType)
-		buf.WriteString(fmt.Sprintf(" `json:\"%s\"`\n", key))
+		buf.WriteString(f.tags)
+		if r.opts.SemanticTypes && child.kind == docString {
+			if note := semanticStringNote(child.text); note != "" {
+				buf.WriteString(" // " + note)
+			}
+		}
+		buf.WriteString("\n")
 	}
+	r.names = r.names[:base]
+	r.indexes = r.indexes[:len(r.indexes)-len(order)]
 	buf.WriteString("}")
-	return buf.String()
 }
 
-func renderArrayElement(arr []any) string {
-	var elementType string
-	for _, item := range arr {
-		if item == nil {
+// keyIndexes pushes the indexes of n's keys onto r.indexes in output
+// order: sorted, or as recorded in keyOrder with unknown keys sorted
+// after. The caller pops them when done.
+func (r *goStructRenderer) keyIndexes(n *docNode, path string) []int {
+	base := len(r.indexes)
+	for i := range n.keys {
+		r.indexes = append(r.indexes, i)
+	}
+	idx := r.indexes[base:]
+	if r.keyOrder == nil {
+		slices.SortFunc(idx, func(a, b int) int { return strings.Compare(n.keys[a].name, n.keys[b].name) })
+		return idx
+	}
+	rank := r.rank(path)
+	position := func(i int) int {
+		if p, ok := rank[n.keys[i].name]; ok {
+			return p
+		}
+		return len(rank)
+	}
+	slices.SortFunc(idx, func(a, b int) int {
+		if c := cmp.Compare(position(a), position(b)); c != 0 {
+			return c
+		}
+		return strings.Compare(n.keys[a].name, n.keys[b].name)
+	})
+	return idx
+}
+
+func (r *goStructRenderer) rank(path string) map[string]int {
+	if rank, ok := r.keyRank[path]; ok {
+		return rank
+	}
+	if r.keyRank == nil {
+		r.keyRank = map[string]map[string]int{}
+	}
+	rank := make(map[string]int, len(r.keyOrder[path]))
+	for i, k := range r.keyOrder[path] {
+		rank[k] = i
+	}
+	r.keyRank[path] = rank
+	return rank
+}
+
+// writeArrayElement writes the element type of an array. Each element is
+// written after the type so far and compared in place, so identical
+// records cost no allocation.
+func (r *goStructRenderer) writeArrayElement(buf *bytes.Buffer, items []*docNode, path string) {
+	start := buf.Len()
+	written := false
+	for _, item := range items {
+		if item.kind == docNull {
 			continue
 		}
-		t := renderType(item)
-		if elementType == "" {
-			elementType = t
+		if !written {
+			r.writeType(buf, item, path)
+			written = true
 			continue
 		}
-		if elementType != t {
-			return "interface{}"
+		mark := buf.Len()
+		r.writeType(buf, item, path)
+		if bytes.Equal(buf.Bytes()[start:mark], buf.Bytes()[mark:]) {
+			buf.Truncate(mark)
+			continue
+		}
+		t := commonElementType(string(buf.Bytes()[start:mark]), string(buf.Bytes()[mark:]))
+		buf.Truncate(start)
+		buf.WriteString(t)
+		if t == "interface{}" {
+			return
 		}
 	}
-	if elementType == "" {
-		return "interface{}"
+	if !written {
+		buf.WriteString("interface{}")
 	}
-	return elementType
 }
 
 func GoStructToJSON(src string) (string, error) {
@@ -112,28 +431,29 @@ func GoStructToJSON(src string) (string, error) {
 	if err != nil {
 		return "", err
 	}
-	return encodeJSON(value)
+	return encodeDoc(value)
 }
 
-func parseGoStructValue(src string) (any, string, error) {
+func parseGoStructValue(src string) (*docNode, string, error) {
 	source := strings.TrimSpace(src)
 	if source == "" {
 		return nil, "", errors.New("empty input")
 	}
+	prefix := ""
 	if !strings.Contains(source, "package ") {
-		source = "package main\n" + source
+		prefix = "package main\n"
 	}
 	fileSet := token.NewFileSet()
-	file, err := parser.ParseFile(fileSet, "input.go", source, parser.AllErrors)
+	file, err := parser.ParseFile(fileSet, "input.go", prefix+source, parser.AllErrors)
 	if err != nil {
-		return nil, "", err
+		return nil, "", goSyntaxError(src, prefix, err)
 	}
 	typeMap := collectTypeSpecs(file)
 	spec := firstTypeSpec(file)
 	if spec == nil {
 		return nil, "", errors.New("no type declarations found")
 	}
-	value := sampleValue(spec.Type, typeMap, map[string]int{}, 0)
+	value := sampleValue(&docBuilder{}, spec.Type, typeMap, map[string]int{}, 0)
 	return value, spec.Name.Name, nil
 }
 
@@ -181,52 +501,50 @@ func firstTypeSpec(file *ast.File) *ast.TypeSpec {
 	return nil
 }
 
-func sampleValue(expr ast.Expr, types map[string]ast.Expr, seen map[string]int, depth int) any {
+// sampleValue makes up a value of the type expr, with the fields of
+// structs in declaration order.
+func sampleValue(b *docBuilder, expr ast.Expr, types map[string]ast.Expr, seen map[string]int, depth int) *docNode {
 	if depth > 8 {
-		return nil
+		return b.null()
 	}
 	switch t := expr.(type) {
 	case *ast.StructType:
-		obj := make(map[string]any)
+		obj := b.object()
 		for _, field := range t.Fields.List {
 			name := common.JSONFieldName(field)
 			if name == "" {
 				continue
 			}
-			val := sampleValue(field.Type, types, seen, depth+1)
-			obj[name] = val
+			b.set(obj, name, sampleValue(b, field.Type, types, seen, depth+1))
 		}
 		return obj
 	case *ast.ArrayType:
-		elem := sampleValue(t.Elt, types, seen, depth+1)
-		return []any{elem}
+		return b.array(sampleValue(b, t.Elt, types, seen, depth+1))
 	case *ast.StarExpr:
-		return sampleValue(t.X, types, seen, depth+1)
+		return sampleValue(b, t.X, types, seen, depth+1)
 	case *ast.Ident:
 		if basic := basicSample(t.Name); basic != nil {
-			return basic
+			return b.sample(basic)
 		}
 		if expr, ok := types[t.Name]; ok {
 			seen[t.Name]++
 			if seen[t.Name] > 2 {
-				return nil
+				return b.null()
 			}
-			return sampleValue(expr, types, seen, depth+1)
+			return sampleValue(b, expr, types, seen, depth+1)
 		}
-		return map[string]any{}
+		return b.object()
 	case *ast.MapType:
-		return map[string]any{}
-	case *ast.InterfaceType:
-		return any(nil)
+		return b.object()
 	case *ast.SelectorExpr:
 		if t.Sel != nil {
 			if basic := basicSample(t.Sel.Name); basic != nil {
-				return basic
+				return b.sample(basic)
 			}
 		}
-		return nil
+		return b.null()
 	default:
-		return nil
+		return b.null()
 	}
 }
 
@@ -248,3 +566,13 @@ func basicSample(name string) any {
 	}
 	return nil
 }
+
+// isUint64 reports whether the number literal s is an integer in uint64's
+// range.
+func isUint64(s string) bool {
+	if strings.ContainsAny(s, "-.eE") {
+		return false
+	}
+	_, err := strconv.ParseUint(s, 10, 64)
+	return err == nil
+}
@@ -1,6 +1,8 @@
 package convert
 
 import (
+	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -14,6 +16,13 @@ func Test_JSONToGoStruct(t *testing.T) {
 	require.Contains(t, out, "Age  int")
 }
 
+func Test_JSONToGoStruct_Uint64(t *testing.T) {
+	out, err := JSONToGoStruct(`{"id":12345678901234567890,"neg":-9223372036854775809}`)
+	require.NoError(t, err)
+	require.Contains(t, out, "Id  uint64")
+	require.Contains(t, out, "Neg float64")
+}
+
 func Benchmark_JSONToGoStruct(b *testing.B) {
 	b.ReportAllocs()
 	b.ResetTimer()
@@ -50,3 +59,101 @@ func Fuzz_GoStructToJSON(f *testing.F) {
 		_, _ = GoStructToJSON(input)
 	})
 }
+
+func Test_JSONToGoStructNamed(t *testing.T) {
+	src := `{
+		"name": "Alice",
+		"home": {"street": "Main", "city": "Taipei"},
+		"work": {"street": "Side", "city": "Tainan"},
+		"companies": [{"id": 1}, {"id": 2, "public": true}],
+		"profile": {"address": {"zip": "100"}}
+	}`
+	out, err := JSONToGoStructNamed(src)
+	require.NoError(t, err)
+	require.Contains(t, out, "Home      Home")
+	require.Contains(t, out, "Work      Home")
+	require.Contains(t, out, "Companies []Company")
+	require.Contains(t, out, "type Company struct {\n\tId     int  `json:\"id\"`\n\tPublic bool `json:\"public\"`\n}")
+	require.Contains(t, out, "type Profile struct {\n\tAddress Address `json:\"address\"`\n}")
+	require.NotContains(t, out, "type Work struct")
+	require.Equal(t, 1, strings.Count(out, "type AutoGenerated struct"))
+
+	arr, err := JSONToGoStructNamed(`[{"autoGenerated": {"a": 1}}]`)
+	require.NoError(t, err)
+	require.Contains(t, arr, "type AutoGenerated []AutoGeneratedItem")
+	require.Contains(t, arr, "AutoGenerated AutoGenerated2")
+}
+
+func Test_JSONToGoStructFieldCollision(t *testing.T) {
+	out, err := JSONToGoStruct(`{"a_b": 1, "aB": 2, "a-b": 3}`)
+	require.NoError(t, err)
+	require.Contains(t, out, "AB  int `json:\"a_b\"`")
+	require.Contains(t, out, "AB2 int `json:\"aB\"`")
+	require.Contains(t, out, "AB3 int `json:\"a-b\"`")
+}
+
+func Fuzz_JSONToGoStructNamed(f *testing.F) {
+	f.Add(sampleNestedJSON)
+	f.Fuzz(func(t *testing.T, input string) {
+		_, _ = JSONToGoStructNamed(input)
+	})
+}
+
+// largeRecordsJSON is an array of n records of about 300 bytes, so n = 4500
+// passes 1MB.
+func largeRecordsJSON(n int) string {
+	var b strings.Builder
+	b.WriteString("[")
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			b.WriteString(",\n")
+		}
+		fmt.Fprintf(&b, `{"id":%d,"user_name":"user %d","email":"u%d@example.com","active":%t,"score":%d.5,`+
+			`"created_at":"2024-01-02T03:04:05Z","tags":["a","b","c"],"address":{"street_name":"%d Main St",`+
+			`"city":"Springfield","zip_code":"12345"},"orders":[{"order_id":%d,"total":9.99,"items":[{"sku":"x","qty":1}]}]}`,
+			i, i, i, i%2 == 0, i, i, i)
+	}
+	b.WriteString("]")
+	return b.String()
+}
+
+var largeGoStructCases = []struct {
+	name string
+	opts []ConvertOption
+	// maxAllocs guards against regressions; before the generator worked on
+	// the document model these inputs took 280k to 1.1M allocations.
+	maxAllocs float64
+}{
+	{"sorted", []ConvertOption{WithSortKeys(true)}, 5000},
+	{"ordered", nil, 5000},
+	{"named", []ConvertOption{WithNamedStructs(true), WithExtraTags(map[string]string{"yaml": TagCaseCamel})}, 5000},
+}
+
+func Test_JSONToGoStruct_LargeAllocs(t *testing.T) {
+	if testing.Short() {
+		t.Skip("large input")
+	}
+	input := largeRecordsJSON(4500)
+	for _, tc := range largeGoStructCases {
+		t.Run(tc.name, func(t *testing.T) {
+			allocs := testing.AllocsPerRun(1, func() {
+				_, err := JSONToGoStructWithOptions(input, tc.opts...)
+				require.NoError(t, err)
+			})
+			require.LessOrEqual(t, allocs, tc.maxAllocs)
+		})
+	}
+}
+
+func Benchmark_JSONToGoStruct_Large(b *testing.B) {
+	input := largeRecordsJSON(4500)
+	for _, bc := range largeGoStructCases {
+		b.Run(bc.name, func(b *testing.B) {
+			b.SetBytes(int64(len(input)))
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				_, _ = JSONToGoStructWithOptions(input, bc.opts...)
+			}
+		})
+	}
+}
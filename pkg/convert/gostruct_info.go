@@ -31,13 +31,14 @@ func parseGoStructDefinitions(src string) ([]StructDefinition, error) {
 	if source == "" {
 		return nil, errors.New("empty input")
 	}
+	prefix := ""
 	if !strings.Contains(source, "package ") {
-		source = "package main\n" + source
+		prefix = "package main\n"
 	}
 	fileSet := token.NewFileSet()
-	file, err := parser.ParseFile(fileSet, "input.go", source, parser.ParseComments)
+	file, err := parser.ParseFile(fileSet, "input.go", prefix+source, parser.ParseComments)
 	if err != nil {
-		return nil, err
+		return nil, goSyntaxError(src, prefix, err)
 	}
 	var defs []StructDefinition
 	for _, decl := range file.Decls {
@@ -5,21 +5,30 @@ import (
 	"errors"
 	"fmt"
 	"go/ast"
-	"regexp"
-	"sort"
+	"go/token"
+	"reflect"
 	"strings"
 
 	"github.com/linzeyan/transform-go/pkg/common"
 )
 
-var graphqlTypeDeclRe = regexp.MustCompile(`type\s+([A-Za-z0-9_]+)\s*\{`)
+var graphqlTypeDeclRe = common.LazyRegexp(`\b(type|enum)\s+([A-Za-z0-9_]+)\s*\{`)
 
 func JSONToGraphQL(input string) (string, error) {
+	return JSONToGraphQLWithOptions(input)
+}
+
+// JSONToGraphQLWithOptions infers object types from a JSON sample, or from
+// a JSON Schema document whose string enums become enum types and whose
+// required properties are non-null. GraphQLInputs and GraphQLOperations
+// add input types and sample Query/Mutation blocks.
+func JSONToGraphQLWithOptions(input string, opts ...ConvertOption) (string, error) {
 	data, err := decodeJSONValue(input)
 	if err != nil {
 		return "", err
 	}
-	return buildGraphQLSchema("AutoGenerated", data)
+	o := NewConvertOptions(opts...)
+	return buildGraphQLSchema("AutoGenerated", data, o, o.sourceOrder(input))
 }
 
 func GraphQLToJSON(input string) (string, error) {
@@ -27,29 +36,35 @@ func GraphQLToJSON(input string) (string, error) {
 	if len(schema.order) == 0 {
 		return "", errors.New("no GraphQL type definition found")
 	}
-	root := schema.order[0]
-	val := schema.sampleType(root, map[string]int{})
-	return encodeJSON(val)
+	val := schema.sampleType(&docBuilder{}, schema.root(), map[string]int{})
+	return encodeDoc(val)
 }
 
 func GoStructToGraphQL(src string) (string, error) {
+	return GoStructToGraphQLWithOptions(src)
+}
+
+// GoStructToGraphQLWithOptions renders each struct as an object type.
+// String fields with an `enum:"a,b"` or `validate:"oneof=a b"` tag become
+// enum types; GraphQLInputs and GraphQLOperations work as for JSON.
+func GoStructToGraphQLWithOptions(src string, opts ...ConvertOption) (string, error) {
 	defs, err := parseGoStructDefinitions(src)
 	if err != nil {
 		return "", err
 	}
-	var blocks []string
-	seen := map[string]bool{}
+	builder := newGraphQLBuilder(NewConvertOptions(opts...))
+	var roots []string
 	for _, def := range defs {
-		if seen[def.Name] {
+		if _, ok := builder.defs[def.Name]; ok {
 			continue
 		}
-		blocks = append(blocks, renderGraphQLType(def))
-		seen[def.Name] = true
+		builder.goObject(def)
+		roots = append(roots, def.Name)
 	}
-	if len(blocks) == 0 {
+	if len(roots) == 0 {
 		return "", errors.New("no struct definition found")
 	}
-	return strings.Join(blocks, "\n\n"), nil
+	return builder.schema(roots), nil
 }
 
 func GraphQLToGoStruct(input string) (string, error) {
@@ -57,8 +72,13 @@ func GraphQLToGoStruct(input string) (string, error) {
 	if len(schema.order) == 0 {
 		return "", errors.New("no GraphQL type definition found")
 	}
-	var blocks []string
+	// The root goes first, where GoStructToJSON looks for it.
+	root := schema.root()
+	blocks := []string{schema.renderGoStruct(schema.types[root])}
 	for _, name := range schema.order {
+		if name == root {
+			continue
+		}
 		typ := schema.types[name]
 		if typ == nil {
 			continue
@@ -68,23 +88,45 @@ func GraphQLToGoStruct(input string) (string, error) {
 	return strings.Join(blocks, "\n\n"), nil
 }
 
-func buildGraphQLSchema(root string, data any) (string, error) {
-	builder := newGraphQLBuilder()
-	builder.addType(root, data)
-	defs := builder.definitions()
-	if len(defs) == 0 {
+// buildGraphQLSchema infers the type called root from data, with fields in
+// the order keys gives their keys.
+func buildGraphQLSchema(root string, data any, opts ConvertOptions, keys sourceOrder) (string, error) {
+	builder := newGraphQLBuilder(opts)
+	builder.keys = keys
+	var rootType string
+	if obj, ok := data.(map[string]any); ok && isJSONSchemaDocument(obj) {
+		name := root
+		if title, ok := obj["title"].(string); ok && title != "" {
+			name = title
+		}
+		rootType = builder.schemaObject(sanitizeTypeName(name), obj, "")
+	} else {
+		rootType = builder.addType(root, data, "")
+	}
+	if len(builder.defs) == 0 {
 		return fmt.Sprintf("type %s {\n  value: %s\n}", root, scalarGraphQLType(data)), nil
 	}
-	return strings.Join(defs, "\n\n"), nil
+	return builder.schema([]string{strings.Trim(rootType, "[]")}), nil
 }
 
 type graphQLBuilder struct {
 	defs  map[string]string
 	order []string
+	// objects keeps the fields of each object type for its input type.
+	objects map[string][]graphQLFieldLine
+	opts    ConvertOptions
+	// keys is the key order of the JSON input fields follow.
+	keys sourceOrder
+}
+
+type graphQLFieldLine struct {
+	comment  string
+	name     string
+	typeExpr string
 }
 
-func newGraphQLBuilder() *graphQLBuilder {
-	return &graphQLBuilder{defs: make(map[string]string)}
+func newGraphQLBuilder(opts ConvertOptions) *graphQLBuilder {
+	return &graphQLBuilder{defs: make(map[string]string), objects: make(map[string][]graphQLFieldLine), opts: opts}
 }
 
 func (b *graphQLBuilder) definitions() []string {
@@ -97,51 +139,140 @@ func (b *graphQLBuilder) definitions() []string {
 	return res
 }
 
-func (b *graphQLBuilder) addType(name string, v any) string {
+// schema renders every definition, then the optional input types and the
+// Query/Mutation blocks for roots. Mutations take input types, so
+// GraphQLOperations implies GraphQLInputs.
+func (b *graphQLBuilder) schema(roots []string) string {
+	defs := b.definitions()
+	if b.opts.GraphQLInputs || b.opts.GraphQLOperations {
+		for _, name := range b.order {
+			if fields, ok := b.objects[name]; ok {
+				inputs := make([]graphQLFieldLine, len(fields))
+				for i, f := range fields {
+					inputs[i] = graphQLFieldLine{name: f.name, typeExpr: b.inputTypeExpr(f.typeExpr)}
+				}
+				defs = append(defs, renderGraphQLDefinition("input", name+"Input", inputs))
+			}
+		}
+	}
+	if b.opts.GraphQLOperations {
+		var queries, mutations []graphQLFieldLine
+		for _, root := range roots {
+			if _, ok := b.objects[root]; !ok {
+				continue
+			}
+			field := common.LowerFirst(root)
+			queries = append(queries,
+				graphQLFieldLine{name: field + "(id: ID!)", typeExpr: root},
+				graphQLFieldLine{name: field + "List(limit: Int, offset: Int)", typeExpr: "[" + root + "!]!"},
+			)
+			mutations = append(mutations,
+				graphQLFieldLine{name: "create" + root + "(input: " + root + "Input!)", typeExpr: root},
+				graphQLFieldLine{name: "update" + root + "(id: ID!, input: " + root + "Input!)", typeExpr: root},
+				graphQLFieldLine{name: "delete" + root + "(id: ID!)", typeExpr: "Boolean!"},
+			)
+		}
+		if len(queries) > 0 {
+			defs = append(defs, renderGraphQLDefinition("type", "Query", queries), renderGraphQLDefinition("type", "Mutation", mutations))
+		}
+	}
+	return strings.Join(defs, "\n\n")
+}
+
+// inputTypeExpr points object references at their input types; scalars and
+// enums are valid input types as they are.
+func (b *graphQLBuilder) inputTypeExpr(expr string) string {
+	base := strings.Trim(expr, "[]!")
+	if _, ok := b.objects[base]; !ok {
+		return expr
+	}
+	return strings.Replace(expr, base, base+"Input", 1)
+}
+
+func renderGraphQLDefinition(kind, name string, fields []graphQLFieldLine) string {
+	var lines []string
+	for _, field := range fields {
+		for _, line := range strings.Split(field.comment, "\n") {
+			line = strings.TrimSpace(strings.TrimPrefix(line, "//"))
+			if line != "" {
+				lines = append(lines, "  # "+line)
+			}
+		}
+		lines = append(lines, fmt.Sprintf("  %s: %s", field.name, field.typeExpr))
+	}
+	return fmt.Sprintf("%s %s {\n%s\n}", kind, name, strings.Join(lines, "\n"))
+}
+
+func (b *graphQLBuilder) define(name, def string) {
+	if _, ok := b.defs[name]; !ok {
+		b.order = append(b.order, name)
+	}
+	b.defs[name] = def
+}
+
+func (b *graphQLBuilder) defineObject(name string, fields []graphQLFieldLine) {
+	b.objects[name] = fields
+	b.define(name, renderGraphQLDefinition("type", name, fields))
+}
+
+// defineEnum adds an enum type with values in UPPER_SNAKE case.
+func (b *graphQLBuilder) defineEnum(name string, values []string) string {
+	if _, ok := b.defs[name]; ok {
+		return name
+	}
+	var lines []string
+	seen := map[string]bool{}
+	for _, v := range values {
+		value := enumValueName(v)
+		if value != "" && !seen[value] {
+			seen[value] = true
+			lines = append(lines, "  "+value)
+		}
+	}
+	if len(lines) == 0 {
+		return "String"
+	}
+	b.define(name, fmt.Sprintf("enum %s {\n%s\n}", name, strings.Join(lines, "\n")))
+	return name
+}
+
+func (b *graphQLBuilder) addType(name string, v any, path string) string {
 	typeName := sanitizeTypeName(name)
 	if typeName == "" {
 		typeName = "Type"
 	}
 	switch val := v.(type) {
 	case map[string]any:
-		return b.buildObject(typeName, val)
+		return b.buildObject(typeName, val, path)
 	case []any:
-		return "[" + b.arrayType(typeName+"Item", val) + "]"
+		return "[" + b.arrayType(typeName+"Item", val, path+"[]") + "]"
 	default:
 		return scalarGraphQLType(val)
 	}
 }
 
-func (b *graphQLBuilder) buildObject(name string, obj map[string]any) string {
+// buildObject defines the object type name for obj, the object at path.
+func (b *graphQLBuilder) buildObject(name string, obj map[string]any, path string) string {
 	if _, ok := b.defs[name]; ok {
 		return name
 	}
-	keys := make([]string, 0, len(obj))
-	for k := range obj {
-		keys = append(keys, k)
-	}
-	sort.Strings(keys)
-	var lines []string
-	for _, key := range keys {
+	var fields []graphQLFieldLine
+	for _, key := range b.keys.keys(obj, path) {
 		fieldName := graphQLFieldName(key)
 		if fieldName == "" {
 			continue
 		}
-		fieldType := b.fieldType(name, key, obj[key])
-		lines = append(lines, fmt.Sprintf("  %s: %s", fieldName, fieldType))
+		fields = append(fields, graphQLFieldLine{name: fieldName, typeExpr: b.fieldType(name, key, obj[key], keyPath(path, key))})
 	}
-	if len(lines) == 0 {
-		lines = append(lines, "  dummy: String")
+	if len(fields) == 0 {
+		fields = append(fields, graphQLFieldLine{name: "dummy", typeExpr: "String"})
 	}
-	def := fmt.Sprintf("type %s {\n%s\n}", name, strings.Join(lines, "\n"))
-	if _, ok := b.defs[name]; !ok {
-		b.order = append(b.order, name)
-	}
-	b.defs[name] = def
+	b.defineObject(name, fields)
 	return name
 }
 
-func (b *graphQLBuilder) arrayType(name string, arr []any) string {
+// arrayType returns the element type of arr, whose elements are at path.
+func (b *graphQLBuilder) arrayType(name string, arr []any, path string) string {
 	var sample any
 	for _, item := range arr {
 		if item != nil {
@@ -157,25 +288,89 @@ func (b *graphQLBuilder) arrayType(name string, arr []any) string {
 	}
 	switch sample := sample.(type) {
 	case map[string]any:
-		return b.buildObject(sanitizeTypeName(name), sample)
+		return b.buildObject(sanitizeTypeName(name), sample, path)
 	case []any:
-		return "[" + b.arrayType(name+"Item", sample) + "]"
+		return "[" + b.arrayType(name+"Item", sample, path+"[]") + "]"
 	default:
 		return scalarGraphQLType(sample)
 	}
 }
 
-func (b *graphQLBuilder) fieldType(parentName, field string, v any) string {
+func (b *graphQLBuilder) fieldType(parentName, field string, v any, path string) string {
 	switch val := v.(type) {
 	case map[string]any:
-		return b.buildObject(parentName+common.ExportName(field), val)
+		return b.buildObject(parentName+common.ExportName(field), val, path)
 	case []any:
-		return "[" + b.arrayType(parentName+common.ExportName(field)+"Item", val) + "]"
+		return "[" + b.arrayType(parentName+common.ExportName(field)+"Item", val, path+"[]") + "]"
 	default:
 		return scalarGraphQLType(val)
 	}
 }
 
+// schemaObject builds an object type from a JSON Schema object; required
+// properties are non-null. path is the path of schema.
+func (b *graphQLBuilder) schemaObject(name string, schema map[string]any, path string) string {
+	if _, ok := b.defs[name]; ok {
+		return name
+	}
+	props, _ := schema["properties"].(map[string]any)
+	required := map[string]bool{}
+	if list, ok := schema["required"].([]any); ok {
+		for _, r := range list {
+			if key, ok := r.(string); ok {
+				required[key] = true
+			}
+		}
+	}
+	propsPath := keyPath(path, "properties")
+	var fields []graphQLFieldLine
+	for _, key := range b.keys.keys(props, propsPath) {
+		fieldName := graphQLFieldName(key)
+		if fieldName == "" {
+			continue
+		}
+		prop, _ := props[key].(map[string]any)
+		typeExpr := b.schemaFieldType(name+common.ExportName(key), prop, keyPath(propsPath, key))
+		if required[key] {
+			typeExpr += "!"
+		}
+		comment, _ := prop["description"].(string)
+		fields = append(fields, graphQLFieldLine{comment: comment, name: fieldName, typeExpr: typeExpr})
+	}
+	if len(fields) == 0 {
+		fields = append(fields, graphQLFieldLine{name: "dummy", typeExpr: "String"})
+	}
+	b.defineObject(name, fields)
+	return name
+}
+
+func (b *graphQLBuilder) schemaFieldType(typeName string, schema map[string]any, path string) string {
+	switch schemaType(schema) {
+	case "array":
+		items, _ := schema["items"].(map[string]any)
+		return "[" + b.schemaFieldType(typeName+"Item", items, keyPath(path, "items")) + "]"
+	case "object":
+		if props, _ := schema["properties"].(map[string]any); len(props) > 0 {
+			return b.schemaObject(typeName, schema, path)
+		}
+	case "string":
+		if enum, ok := schema["enum"].([]any); ok && len(enum) > 0 {
+			values := make([]string, len(enum))
+			for i, v := range enum {
+				values[i] = fmt.Sprint(v)
+			}
+			return b.defineEnum(typeName, values)
+		}
+	case "integer":
+		return "Int"
+	case "number":
+		return "Float"
+	case "boolean":
+		return "Boolean"
+	}
+	return "String"
+}
+
 func scalarGraphQLType(v any) string {
 	switch val := v.(type) {
 	case json.Number:
@@ -215,6 +410,8 @@ func graphQLFieldName(name string) string {
 type gqlSchema struct {
 	order []string
 	types map[string]*gqlType
+	// enums holds the values of each enum type; they map to Go strings.
+	enums map[string][]string
 }
 
 type gqlType struct {
@@ -233,14 +430,16 @@ func parseGraphQLSchema(src string) *gqlSchema {
 	schema := &gqlSchema{
 		order: []string{},
 		types: make(map[string]*gqlType),
+		enums: make(map[string][]string),
 	}
 	idx := 0
 	for idx < len(src) {
-		loc := graphqlTypeDeclRe.FindStringSubmatchIndex(src[idx:])
+		loc := graphqlTypeDeclRe().FindStringSubmatchIndex(src[idx:])
 		if loc == nil {
 			break
 		}
-		name := src[idx+loc[2] : idx+loc[3]]
+		kind := src[idx+loc[2] : idx+loc[3]]
+		name := src[idx+loc[4] : idx+loc[5]]
 		bodyStart := idx + loc[1] - 1
 		open := strings.Index(src[bodyStart:], "{")
 		if open == -1 {
@@ -252,7 +451,14 @@ func parseGraphQLSchema(src string) *gqlSchema {
 			break
 		}
 		body := src[openIdx+1 : closeIdx]
-		schema.addType(name, body)
+		switch {
+		case kind == "enum":
+			schema.addEnum(name, body)
+		case name == "Query" || name == "Mutation" || name == "Subscription":
+			// Operation roots describe the API, not the data.
+		default:
+			schema.addType(name, body)
+		}
 		idx = closeIdx + 1
 	}
 	return schema
@@ -304,29 +510,66 @@ func (s *gqlSchema) addType(name, body string) {
 	s.order = append(s.order, name)
 }
 
-func (s *gqlSchema) sampleType(name string, seen map[string]int) any {
+// root is the first type no other type refers to; nested types are
+// written before the type that holds them.
+func (s *gqlSchema) root() string {
+	used := map[string]bool{}
+	for _, typ := range s.types {
+		for _, f := range typ.Fields {
+			if f.TypeName != typ.Name {
+				used[f.TypeName] = true
+			}
+		}
+	}
+	for _, name := range s.order {
+		if !used[name] {
+			return name
+		}
+	}
+	return s.order[0]
+}
+
+func (s *gqlSchema) addEnum(name, body string) {
+	for _, line := range strings.Split(body, "\n") {
+		line, _, _ = strings.Cut(line, "#")
+		for _, value := range strings.Fields(strings.ReplaceAll(line, ",", " ")) {
+			if !strings.HasPrefix(value, "@") {
+				s.enums[name] = append(s.enums[name], value)
+			}
+		}
+	}
+}
+
+// sampleType returns nil, rather than a null node, once a type has been
+// expanded too often on one path.
+func (s *gqlSchema) sampleType(b *docBuilder, name string, seen map[string]int) *docNode {
 	if seen[name] > 2 {
 		return nil
 	}
 	if typ, ok := s.types[name]; ok {
 		seen[name]++
-		obj := make(map[string]any)
+		obj := b.object()
 		for _, field := range typ.Fields {
-			val := s.sampleField(field, seen)
-			obj[field.Name] = val
+			b.set(obj, field.Name, s.sampleField(b, field, seen))
 		}
 		return obj
 	}
-	return sampleGraphQLScalarByName(name)
+	if values := s.enums[name]; len(values) > 0 {
+		return b.str(values[0])
+	}
+	return b.sample(sampleGraphQLScalarByName(name))
 }
 
-func (s *gqlSchema) sampleField(field gqlField, seen map[string]int) any {
-	value := s.sampleType(field.TypeName, seen)
+func (s *gqlSchema) sampleField(b *docBuilder, field gqlField, seen map[string]int) *docNode {
+	value := s.sampleType(b, field.TypeName, seen)
 	if field.List {
 		if value == nil {
-			return []any{}
+			return b.array()
 		}
-		return []any{value}
+		return b.array(value)
+	}
+	if value == nil {
+		return b.null()
 	}
 	return value
 }
@@ -363,7 +606,11 @@ func (s *gqlSchema) renderGoStruct(typ *gqlType) string {
 		if goName == "" {
 			goName = "Field"
 		}
-		goType := graphQLTypeToGo(field.TypeName, field.List)
+		typeName := field.TypeName
+		if _, ok := s.enums[typeName]; ok {
+			typeName = "String"
+		}
+		goType := graphQLTypeToGo(typeName, field.List)
 		tag := fmt.Sprintf("`json:\"%s\"`", common.LowerFirst(field.Name))
 		buf.WriteString(fmt.Sprintf("\t%s %s %s\n", goName, goType, tag))
 	}
@@ -371,29 +618,42 @@ func (s *gqlSchema) renderGoStruct(typ *gqlType) string {
 	return buf.String()
 }
 
-func renderGraphQLType(def StructDefinition) string {
-	var lines []string
+func (b *graphQLBuilder) goObject(def StructDefinition) {
+	var fields []graphQLFieldLine
 	for _, field := range def.Fields {
-		if field.Comment != "" {
-			for _, line := range strings.Split(field.Comment, "\n") {
-				line = strings.TrimSpace(strings.TrimPrefix(line, "//"))
-				if line == "" {
-					continue
-				}
-				lines = append(lines, "  # "+line)
-			}
-		}
 		fieldName := common.LowerFirst(field.GoName)
 		if fieldName == "" {
 			fieldName = "field"
 		}
-		typeName := goTypeToGraphQL(field.TypeExpr, false)
-		lines = append(lines, fmt.Sprintf("  %s: %s", fieldName, typeName))
+		typeName := b.goFieldType(def.Name, field.GoName, field.TypeExpr)
+		tag := reflect.StructTag(strings.Trim(field.Tag, "`"))
+		if values := goEnumHint(tag); len(values) > 0 && typeName == "String" {
+			typeName = b.defineEnum(def.Name+sanitizeTypeName(field.GoName), values)
+		}
+		fields = append(fields, graphQLFieldLine{comment: field.Comment, name: fieldName, typeExpr: typeName})
 	}
-	if len(lines) == 0 {
-		lines = append(lines, "  value: String")
+	if len(fields) == 0 {
+		fields = append(fields, graphQLFieldLine{name: "value", typeExpr: "String"})
+	}
+	b.defineObject(def.Name, fields)
+}
+
+// goFieldType is goTypeToGraphQL that also defines an object type for an
+// anonymous struct, named after its parent and field.
+func (b *graphQLBuilder) goFieldType(parent, goName string, expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return b.goFieldType(parent, goName, t.X)
+	case *ast.ArrayType:
+		return "[" + b.goFieldType(parent, goName, t.Elt) + "]"
+	case *ast.StructType:
+		name := parent + sanitizeTypeName(goName)
+		if _, ok := b.defs[name]; !ok {
+			b.goObject(buildStructDefinition(name, t, token.NewFileSet()))
+		}
+		return name
 	}
-	return fmt.Sprintf("type %s {\n%s\n}", def.Name, strings.Join(lines, "\n"))
+	return goTypeToGraphQL(expr, false)
 }
 
 func graphQLTypeToGo(typeName string, list bool) string {
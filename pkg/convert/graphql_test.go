@@ -13,6 +13,73 @@ func Test_JSONToGraphQL(t *testing.T) {
 	require.Contains(t, gql, "type AutoGenerated")
 }
 
+func Test_JSONToGraphQL_Operations(t *testing.T) {
+	out, err := JSONToGraphQLWithOptions(`{"id":1,"address":{"city":"x"}}`, WithGraphQLOperations(true))
+	require.NoError(t, err)
+	require.Equal(t, `type AutoGeneratedAddress {
+  city: String
+}
+
+type AutoGenerated {
+  id: Int
+  address: AutoGeneratedAddress
+}
+
+input AutoGeneratedAddressInput {
+  city: String
+}
+
+input AutoGeneratedInput {
+  id: Int
+  address: AutoGeneratedAddressInput
+}
+
+type Query {
+  autoGenerated(id: ID!): AutoGenerated
+  autoGeneratedList(limit: Int, offset: Int): [AutoGenerated!]!
+}
+
+type Mutation {
+  createAutoGenerated(input: AutoGeneratedInput!): AutoGenerated
+  updateAutoGenerated(id: ID!, input: AutoGeneratedInput!): AutoGenerated
+  deleteAutoGenerated(id: ID!): Boolean!
+}`, out)
+
+	sample, err := GraphQLToJSON(out)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"address":{"city":""},"id":0}`, sample)
+}
+
+func Test_JSONToGraphQL_SchemaEnums(t *testing.T) {
+	out, err := JSONToGraphQL(`{
+		"$schema": "http://json-schema.org/draft-07/schema#",
+		"title": "order",
+		"type": "object",
+		"required": ["status"],
+		"properties": {
+			"status": {"type": "string", "enum": ["pending", "in-progress"], "description": "current state"},
+			"items": {"type": "array", "items": {"type": "object", "properties": {"sku": {"type": "string"}}}},
+			"total": {"type": "number"}
+		}
+	}`)
+	require.NoError(t, err)
+	require.Equal(t, `enum OrderStatus {
+  PENDING
+  IN_PROGRESS
+}
+
+type OrderItemsItem {
+  sku: String
+}
+
+type Order {
+  # current state
+  status: OrderStatus!
+  items: [OrderItemsItem]
+  total: Float
+}`, out)
+}
+
 func Benchmark_JSONToGraphQL(b *testing.B) {
 	b.ReportAllocs()
 	b.ResetTimer()
@@ -74,6 +141,42 @@ type GuessSetting struct {
 		strings.Index(out, "userRef: String"))
 }
 
+func Test_GoStructToGraphQL_EnumsAndInputs(t *testing.T) {
+	src := "type User struct {\n" +
+		"\tID int `json:\"id\"`\n" +
+		"\tRole string `json:\"role\" validate:\"oneof=admin viewer\"`\n" +
+		"}"
+	out, err := GoStructToGraphQLWithOptions(src, WithGraphQLInputs(true))
+	require.NoError(t, err)
+	require.Equal(t, `enum UserRole {
+  ADMIN
+  VIEWER
+}
+
+type User {
+  id: Int
+  role: UserRole
+}
+
+input UserInput {
+  id: Int
+  role: UserRole
+}`, out)
+
+	out, err = ConvertFormatsWithOptions(formatGoStruct, formatGraphQL, src, WithGraphQLOperations(true))
+	require.NoError(t, err)
+	require.Contains(t, out, "createUser(input: UserInput!): User")
+
+	sample, err := GraphQLToJSON(out)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"id":0,"role":"ADMIN"}`, sample)
+
+	structs, err := GraphQLToGoStruct(out)
+	require.NoError(t, err)
+	require.Contains(t, structs, "Role string `json:\"role\"`")
+	require.NotContains(t, structs, "Mutation")
+}
+
 func Benchmark_GoStructToGraphQL(b *testing.B) {
 	b.ReportAllocs()
 	b.ResetTimer()
@@ -0,0 +1,552 @@
+package convert
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/linzeyan/transform-go/pkg/common"
+)
+
+const formatHCL = "HCL"
+
+var (
+	hclIdentRe = common.LazyRegexp(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+	hclBlockRe = common.LazyRegexp(`(?m)^\s*((resource|data|variable|output|module|provider|terraform|locals)\b[^\n{=]*|[A-Za-z_][\w-]*(\s+"[^"\n]*")+\s*)\{`)
+
+	// hclTopBlocks are the Terraform block types and their label counts;
+	// JSONToHCL writes these keys as blocks at the top level.
+	hclTopBlocks = map[string]int{
+		"resource": 2, "data": 2,
+		"provider": 1, "variable": 1, "output": 1, "module": 1,
+		"terraform": 0, "locals": 0,
+	}
+	// hclNestedBlocks are block types written as blocks inside any block.
+	hclNestedBlocks = map[string]int{
+		"backend": 1, "provisioner": 1, "dynamic": 1,
+		"required_providers": 0, "lifecycle": 0, "connection": 0,
+		"content": 0, "validation": 0, "cloud": 0,
+	}
+
+	// hclTemplateEscaper escapes the literal text of a template, so that
+	// it does not read as an interpolation or directive.
+	hclTemplateEscaper = strings.NewReplacer("${", "$${", "%{", "%%{")
+)
+
+// HCLToJSON converts HCL, such as Terraform configuration or .tfvars, to
+// JSON laid out as Terraform's JSON syntax does: a block becomes an object
+// nested under its type and labels, and repeated blocks become an array.
+// Expressions other than literals become "${...}" template strings, and
+// quoted templates and heredocs keep their interpolations and directives
+// as written. Keys keep their order.
+func HCLToJSON(input string) (string, error) {
+	r := hclReader{src: []byte(input)}
+	file, diags := hclsyntax.ParseConfig(r.src, "", hcl.InitialPos)
+	if diags.HasErrors() {
+		return "", r.diagnostic(diags)
+	}
+	body, err := r.body(file.Body.(*hclsyntax.Body))
+	if err != nil {
+		return "", err
+	}
+	return encodeDoc(body)
+}
+
+// hclReader turns the syntax tree of src into the document model.
+type hclReader struct {
+	src []byte
+	b   docBuilder
+}
+
+func (r *hclReader) errorf(rng hcl.Range, format string, args ...any) error {
+	msg := fmt.Sprintf("hcl: line %d, column %d: %s", rng.Start.Line, rng.Start.Column, fmt.Sprintf(format, args...))
+	return newSyntaxError(string(r.src), rng.Start.Byte, msg, nil)
+}
+
+// diagnostic reports the first error of diags.
+func (r *hclReader) diagnostic(diags hcl.Diagnostics) error {
+	for _, d := range diags {
+		if d.Severity != hcl.DiagError {
+			continue
+		}
+		rng := hcl.Range{Start: hcl.InitialPos}
+		if d.Subject != nil {
+			rng = *d.Subject
+		}
+		if d.Detail == "" {
+			return r.errorf(rng, "%s", d.Summary)
+		}
+		return r.errorf(rng, "%s; %s", d.Summary, d.Detail)
+	}
+	return errors.New(diags.Error())
+}
+
+func (r *hclReader) source(rng hcl.Range) string {
+	return string(r.src[rng.Start.Byte:rng.End.Byte])
+}
+
+// body reads the attributes and blocks of body in the order they are
+// written.
+func (r *hclReader) body(body *hclsyntax.Body) (*docNode, error) {
+	type item struct {
+		start int
+		attr  *hclsyntax.Attribute
+		block *hclsyntax.Block
+	}
+	items := make([]item, 0, len(body.Attributes)+len(body.Blocks))
+	for _, attr := range body.Attributes {
+		items = append(items, item{start: attr.SrcRange.Start.Byte, attr: attr})
+	}
+	for _, block := range body.Blocks {
+		items = append(items, item{start: block.TypeRange.Start.Byte, block: block})
+	}
+	slices.SortFunc(items, func(a, b item) int { return a.start - b.start })
+
+	out := r.b.object()
+	for _, it := range items {
+		if it.attr != nil {
+			r.b.set(out, it.attr.Name, r.expr(it.attr.Expr))
+			continue
+		}
+		if _, ok := body.Attributes[it.block.Type]; ok {
+			return nil, r.errorf(it.block.TypeRange, "%q is both an attribute and a block", it.block.Type)
+		}
+		block, err := r.body(it.block.Body)
+		if err != nil {
+			return nil, err
+		}
+		if err := r.addBlock(out, append([]string{it.block.Type}, it.block.Labels...), block); err != nil {
+			return nil, r.errorf(it.block.TypeRange, "%v", err)
+		}
+	}
+	return out, nil
+}
+
+// addBlock nests block under its type and labels; a second block with the
+// same type and labels turns the entry into an array.
+func (r *hclReader) addBlock(parent *docNode, keys []string, block *docNode) error {
+	for _, key := range keys[:len(keys)-1] {
+		child := r.b.get(parent, key)
+		if child == nil {
+			child = r.b.object()
+			r.b.set(parent, key, child)
+		}
+		if child.kind != docObject {
+			return fmt.Errorf("block %s conflicts with an earlier block", strings.Join(keys, " "))
+		}
+		parent = child
+	}
+	last := keys[len(keys)-1]
+	switch prev := r.b.get(parent, last); {
+	case prev == nil:
+		r.b.set(parent, last, block)
+	case prev.kind == docObject:
+		r.b.set(parent, last, r.b.array(prev, block))
+	default:
+		prev.items = append(prev.items, block)
+	}
+	return nil
+}
+
+// expr converts an expression. Literals, templates, tuples and objects
+// become JSON values; anything else is kept as source text in a "${...}"
+// string.
+func (r *hclReader) expr(e hclsyntax.Expression) *docNode {
+	switch e := e.(type) {
+	case *hclsyntax.LiteralValueExpr:
+		return r.literal(e.Val, e.SrcRange)
+	case *hclsyntax.TemplateExpr:
+		return r.b.str(r.template(e.Parts))
+	case *hclsyntax.TemplateWrapExpr:
+		return r.b.str("${" + r.source(e.Wrapped.Range()) + "}")
+	case *hclsyntax.TupleConsExpr:
+		arr := r.b.array()
+		for _, item := range e.Exprs {
+			arr.items = append(arr.items, r.expr(item))
+		}
+		return arr
+	case *hclsyntax.ObjectConsExpr:
+		obj := r.b.object()
+		for _, item := range e.Items {
+			r.b.set(obj, r.key(item.KeyExpr), r.expr(item.ValueExpr))
+		}
+		return obj
+	case *hclsyntax.UnaryOpExpr:
+		if lit, ok := e.Val.(*hclsyntax.LiteralValueExpr); ok && e.Op == hclsyntax.OpNegate && lit.Val.Type() == cty.Number {
+			return r.literal(lit.Val.Negate(), e.SrcRange)
+		}
+	}
+	return r.b.str("${" + r.source(e.Range()) + "}")
+}
+
+func (r *hclReader) literal(v cty.Value, rng hcl.Range) *docNode {
+	switch {
+	case v.IsNull():
+		return r.b.null()
+	case v.Type() == cty.Bool:
+		return r.b.boolean(v.True())
+	case v.Type() == cty.Number:
+		if text := r.source(rng); json.Valid([]byte(text)) {
+			return r.b.number(text)
+		}
+		// Leading zeros are valid HCL but not JSON.
+		return r.b.number(v.AsBigFloat().Text('g', -1))
+	case v.Type() == cty.String:
+		return r.b.str(v.AsString())
+	}
+	return r.b.str("${" + r.source(rng) + "}")
+}
+
+// template writes the parts of a quoted template or heredoc back as one
+// string: literal text with its escapes decoded, interpolations as
+// "${...}" and directives as written.
+func (r *hclReader) template(parts []hclsyntax.Expression) string {
+	var b strings.Builder
+	for _, part := range parts {
+		src := r.source(part.Range())
+		if lit, ok := part.(*hclsyntax.LiteralValueExpr); ok && lit.Val.Type() == cty.String {
+			b.WriteString(hclTemplateEscaper.Replace(lit.Val.AsString()))
+		} else if strings.HasPrefix(src, "%{") {
+			b.WriteString(src)
+		} else {
+			b.WriteString("${" + src + "}")
+		}
+	}
+	return b.String()
+}
+
+// key returns the name of an object key: identifiers and literals as
+// written, and other expressions as "${...}".
+func (r *hclReader) key(e hclsyntax.Expression) string {
+	if k, ok := e.(*hclsyntax.ObjectConsKeyExpr); ok {
+		if name := hcl.ExprAsKeyword(k.Wrapped); name != "" && !k.ForceNonLiteral {
+			return name
+		}
+		e = k.Wrapped
+		if paren, ok := e.(*hclsyntax.ParenthesesExpr); ok {
+			e = paren.Expression
+		}
+	}
+	if n := r.expr(e); n.kind == docString || n.kind == docNumber || n.kind == docBool {
+		return n.text
+	}
+	return "${" + r.source(e.Range()) + "}"
+}
+
+func JSONToHCL(input string) (string, error) {
+	return JSONToHCLWithOptions(input)
+}
+
+// JSONToHCLWithOptions renders JSON as HCL, formatted as terraform fmt
+// does. Terraform block types (resource, variable, lifecycle, ...) whose
+// values fit are written as blocks with their labels, other keys as
+// attributes, and "${...}" strings that hold a single expression as that
+// bare expression.
+func JSONToHCLWithOptions(input string, opts ...ConvertOption) (string, error) {
+	o := NewConvertOptions(opts...)
+	doc, err := parseJSONDoc(input)
+	if err != nil {
+		return "", err
+	}
+	if doc.kind != docObject {
+		return "", errors.New("HCL root must be an object")
+	}
+	if o.SortKeys {
+		doc.sortKeys(sortedNames)
+	}
+	f := hclwrite.NewEmptyFile()
+	if err := hclBody(f.Body(), doc, hclTopBlocks); err != nil {
+		return "", err
+	}
+	return hclIndent(hclwrite.Format(f.Bytes()), o.indentString()), nil
+}
+
+// hclItem is one attribute or block of a body being written.
+type hclItem struct {
+	name   string
+	value  *docNode
+	labels int
+	block  bool
+}
+
+func hclBody(body *hclwrite.Body, n *docNode, blocks map[string]int) error {
+	items := make([]hclItem, len(n.keys))
+	for i, key := range n.keys {
+		if !hclIdentRe().MatchString(key.name) {
+			return fmt.Errorf("hcl: %q is not a valid attribute or block name", key.name)
+		}
+		labels, ok := blocks[key.name]
+		items[i] = hclItem{name: key.name, value: n.items[i], labels: labels, block: ok && hclBlockFits(n.items[i], labels)}
+	}
+	for i, item := range items {
+		// A blank line sets blocks apart from each other and from runs of
+		// attributes.
+		if i > 0 && (item.block || items[i-1].block) {
+			body.AppendNewline()
+		}
+		if item.block {
+			hclBlock(body, item.name, nil, item.value, item.labels)
+			continue
+		}
+		body.SetAttributeRaw(item.name, hclTokens(item.value, true))
+	}
+	return nil
+}
+
+// hclBlockFits reports whether n can be written as blocks with the given
+// number of labels and read back as the same JSON: an object per label,
+// then one body or an array of at least two bodies.
+func hclBlockFits(n *docNode, labels int) bool {
+	if labels > 0 {
+		if n.kind != docObject || len(n.keys) == 0 {
+			return false
+		}
+		for _, item := range n.items {
+			if !hclBlockFits(item, labels-1) {
+				return false
+			}
+		}
+		return true
+	}
+	if n.kind == docArray {
+		if len(n.items) < 2 {
+			return false
+		}
+		for _, item := range n.items {
+			if !hclBodyFits(item) {
+				return false
+			}
+		}
+		return true
+	}
+	return hclBodyFits(n)
+}
+
+func hclBodyFits(n *docNode) bool {
+	if n.kind != docObject {
+		return false
+	}
+	for _, key := range n.keys {
+		if !hclIdentRe().MatchString(key.name) {
+			return false
+		}
+	}
+	return true
+}
+
+func hclBlock(body *hclwrite.Body, name string, labels []string, n *docNode, remaining int) {
+	if remaining > 0 {
+		for i, key := range n.keys {
+			if i > 0 {
+				body.AppendNewline()
+			}
+			hclBlock(body, name, append(labels, key.name), n.items[i], remaining-1)
+		}
+		return
+	}
+	bodies := []*docNode{n}
+	if n.kind == docArray {
+		bodies = n.items
+	}
+	for i, item := range bodies {
+		if i > 0 {
+			body.AppendNewline()
+		}
+		block := body.AppendNewBlock(name, labels)
+		// Bodies were checked by hclBodyFits, so hclBody cannot fail.
+		_ = hclBody(block.Body(), item, hclNestedBlocks)
+	}
+}
+
+// hclTokens writes the value n; a string may become a heredoc when
+// heredoc is set, which holds where the value ends its line.
+func hclTokens(n *docNode, heredoc bool) hclwrite.Tokens {
+	switch n.kind {
+	case docBool:
+		return hclwrite.TokensForValue(cty.BoolVal(n.text == "true"))
+	case docNumber:
+		return hclwrite.Tokens{{Type: hclsyntax.TokenNumberLit, Bytes: []byte(n.text)}}
+	case docString:
+		return hclString(n.text, heredoc)
+	case docArray:
+		items := make([]hclwrite.Tokens, len(n.items))
+		nested := false
+		for i, item := range n.items {
+			items[i] = hclTokens(item, false)
+			nested = nested || item.kind == docArray || item.kind == docObject
+		}
+		if !nested {
+			return hclwrite.TokensForTuple(items)
+		}
+		// Tuples of tuples and objects take a line per element.
+		tokens := hclwrite.Tokens{{Type: hclsyntax.TokenOBrack, Bytes: []byte("[")}, {Type: hclsyntax.TokenNewline, Bytes: []byte("\n")}}
+		for _, item := range items {
+			tokens = append(tokens, item...)
+			tokens = append(tokens, &hclwrite.Token{Type: hclsyntax.TokenComma, Bytes: []byte(",")}, &hclwrite.Token{Type: hclsyntax.TokenNewline, Bytes: []byte("\n")})
+		}
+		return append(tokens, &hclwrite.Token{Type: hclsyntax.TokenCBrack, Bytes: []byte("]")})
+	case docObject:
+		if len(n.keys) == 0 {
+			return hclwrite.Tokens{{Type: hclsyntax.TokenOBrace, Bytes: []byte("{")}, {Type: hclsyntax.TokenCBrace, Bytes: []byte("}")}}
+		}
+		attrs := make([]hclwrite.ObjectAttrTokens, len(n.keys))
+		for i, key := range n.keys {
+			attrs[i] = hclwrite.ObjectAttrTokens{Name: hclKey(key.name), Value: hclTokens(n.items[i], true)}
+		}
+		return hclwrite.TokensForObject(attrs)
+	}
+	return hclwrite.TokensForValue(cty.NullVal(cty.DynamicPseudoType))
+}
+
+// hclKeywords are quoted as object keys, where HCL would otherwise read
+// them as values or a for expression.
+var hclKeywords = map[string]bool{"true": true, "false": true, "null": true, "for": true}
+
+func hclKey(name string) hclwrite.Tokens {
+	if hclIdentRe().MatchString(name) && !hclKeywords[name] {
+		return hclwrite.TokensForIdentifier(name)
+	}
+	if tokens, ok := hclQuoted(name); ok {
+		return tokens
+	}
+	return hclwrite.TokensForValue(cty.StringVal(name))
+}
+
+// hclString writes s as a bare expression when it is exactly "${expr}",
+// as a heredoc when it is several lines, and otherwise as a quoted
+// template. A string that is not a valid template is escaped in full.
+func hclString(s string, heredoc bool) hclwrite.Tokens {
+	if tokens, ok := hclBareExpression(s); ok {
+		return tokens
+	}
+	if heredoc {
+		if tokens, ok := hclHeredoc(s); ok {
+			return tokens
+		}
+	}
+	if tokens, ok := hclQuoted(s); ok {
+		return tokens
+	}
+	return hclwrite.TokensForValue(cty.StringVal(s))
+}
+
+func hclBareExpression(s string) (hclwrite.Tokens, bool) {
+	if len(s) < 4 || !strings.HasPrefix(s, "${") || !strings.HasSuffix(s, "}") || strings.Contains(s, "\n") {
+		return nil, false
+	}
+	return hclExpression(s[2:len(s)-1], s)
+}
+
+// hclHeredoc writes text of several lines ending in a newline as a
+// heredoc, picking a marker that no line matches.
+func hclHeredoc(s string) (hclwrite.Tokens, bool) {
+	if !strings.HasSuffix(s, "\n") || strings.Count(s, "\n") < 2 || strings.Contains(s, "\r") {
+		return nil, false
+	}
+	lines := strings.Split(s, "\n")
+	marker := "EOT"
+	for i := 2; slices.ContainsFunc(lines, func(line string) bool { return strings.TrimSpace(line) == marker }); i++ {
+		marker = "EOT" + strconv.Itoa(i)
+	}
+	// The closing marker must end its line.
+	return hclExpression("<<"+marker+"\n"+s+marker+"\n", s)
+}
+
+// hclQuoted writes s as a quoted template. Interpolations and directives
+// are kept as they are, so "${var.name}" stays a reference; the literal
+// text between them is escaped.
+func hclQuoted(s string) (hclwrite.Tokens, bool) {
+	tmpl, diags := hclsyntax.ParseTemplate([]byte(s), "", hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, false
+	}
+	parts := []hclsyntax.Expression{tmpl}
+	if t, ok := tmpl.(*hclsyntax.TemplateExpr); ok {
+		parts = t.Parts
+	}
+	var b strings.Builder
+	b.WriteByte('"')
+	at := 0
+	for _, part := range parts {
+		lit, ok := part.(*hclsyntax.LiteralValueExpr)
+		if !ok {
+			continue
+		}
+		quoted := hclwrite.TokensForValue(lit.Val).Bytes()
+		b.WriteString(s[at:lit.SrcRange.Start.Byte])
+		b.Write(quoted[1 : len(quoted)-1])
+		at = lit.SrcRange.End.Byte
+	}
+	b.WriteString(s[at:])
+	b.WriteByte('"')
+	return hclExpression(b.String(), s)
+}
+
+// hclExpression returns the tokens of the expression src, formatted, when
+// they read back as the string s.
+func hclExpression(src, s string) (hclwrite.Tokens, bool) {
+	formatted := hclwrite.Format([]byte(src))
+	e, diags := hclsyntax.ParseExpression(formatted, "", hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, false
+	}
+	r := hclReader{src: formatted}
+	if n := r.expr(e); n.kind != docString || n.text != s {
+		return nil, false
+	}
+	lexed, _ := hclsyntax.LexExpression(formatted, "", hcl.InitialPos)
+	tokens := make(hclwrite.Tokens, 0, len(lexed))
+	end := 0
+	for _, t := range lexed {
+		if t.Type == hclsyntax.TokenEOF || t.Type == hclsyntax.TokenNewline && t.Range.End.Byte == len(formatted) {
+			break
+		}
+		tokens = append(tokens, &hclwrite.Token{Type: t.Type, Bytes: t.Bytes, SpacesBefore: t.Range.Start.Byte - end})
+		end = t.Range.End.Byte
+	}
+	return tokens, true
+}
+
+// hclIndent replaces the two-space indentation hclwrite.Format writes with
+// indent, leaving the text of heredocs alone.
+func hclIndent(src []byte, indent string) string {
+	if indent == "  " {
+		return string(src)
+	}
+	tokens, _ := hclsyntax.LexConfig(src, "", hcl.InitialPos)
+	var heredocs [][2]int
+	for _, t := range tokens {
+		switch t.Type {
+		case hclsyntax.TokenOHeredoc:
+			heredocs = append(heredocs, [2]int{t.Range.End.Byte, len(src)})
+		case hclsyntax.TokenCHeredoc:
+			heredocs[len(heredocs)-1][1] = t.Range.Start.Byte
+		}
+	}
+	var b strings.Builder
+	for start := 0; start < len(src); {
+		end := len(src)
+		if i := bytes.IndexByte(src[start:], '\n'); i >= 0 {
+			end = start + i + 1
+		}
+		line := src[start:end]
+		inHeredoc := slices.ContainsFunc(heredocs, func(h [2]int) bool { return start >= h[0] && start < h[1] })
+		if !inHeredoc {
+			text := bytes.TrimLeft(line, " ")
+			b.WriteString(strings.Repeat(indent, (len(line)-len(text))/2))
+			line = text
+		}
+		b.Write(line)
+		start = end
+	}
+	return b.String()
+}
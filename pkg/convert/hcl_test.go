@@ -0,0 +1,170 @@
+package convert
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const sampleTerraform = `# Web tier
+terraform {
+  required_providers {
+    aws = {
+      source  = "hashicorp/aws"
+      version = "~> 5.0"
+    }
+  }
+}
+
+variable "region" {
+  type    = string
+  default = "us-east-1"
+}
+
+resource "aws_instance" "web" {
+  ami           = data.aws_ami.ubuntu.id // looked up below
+  instance_type = "t3.micro"
+  count         = length(var.zones) > 0 ? 2 : 1
+  tags = {
+    Name                 = "${var.prefix}-web"
+    "kubernetes.io/role" = "web"
+  }
+  user_data = <<-EOT
+    #!/bin/bash
+    echo "hi"
+  EOT
+
+  ebs_block_device {
+    device_name = "/dev/sdb"
+  }
+  ebs_block_device {
+    device_name = "/dev/sdc"
+  }
+  /* replaced in place */
+  lifecycle {
+    create_before_destroy = true
+  }
+}
+`
+
+func TestHCLToJSON(t *testing.T) {
+	out, err := HCLToJSON(sampleTerraform)
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"terraform": {"required_providers": {"aws": {"source": "hashicorp/aws", "version": "~> 5.0"}}},
+		"variable": {"region": {"type": "${string}", "default": "us-east-1"}},
+		"resource": {"aws_instance": {"web": {
+			"ami": "${data.aws_ami.ubuntu.id}",
+			"instance_type": "t3.micro",
+			"count": "${length(var.zones) > 0 ? 2 : 1}",
+			"tags": {"Name": "${var.prefix}-web", "kubernetes.io/role": "web"},
+			"user_data": "#!/bin/bash\necho \"hi\"\n",
+			"ebs_block_device": [{"device_name": "/dev/sdb"}, {"device_name": "/dev/sdc"}],
+			"lifecycle": {"create_before_destroy": true}
+		}}}
+	}`, out)
+
+	out, err = HCLToJSON("region = \"eu-west-1\"\nzones  = [\"a\", \"b\",\n  \"c\"]\nsize = 007\nratio = -1.5e2\nmissing = null\nlabels = {env: \"prod\", (var.key) = 1}\n")
+	require.NoError(t, err)
+	require.JSONEq(t, `{"region":"eu-west-1","zones":["a","b","c"],"size":7,"ratio":-1.5e2,"missing":null,
+		"labels":{"env":"prod","${var.key}":1}}`, out)
+
+	out, err = HCLToJSON(`names = [for s in var.list : upper(s)]` + "\n" + `greeting = "tab\t${join(", ", ["a", "b"])}é"`)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"names":"${[for s in var.list : upper(s)]}","greeting":"tab\t${join(\", \", [\"a\", \"b\"])}é"}`, out)
+
+	out, err = HCLToJSON("a = \"$${x} ${y} %{ if z }q%{ endif }\"\nb = <<-EOT\n    x ${y}\n  EOT\n")
+	require.NoError(t, err)
+	require.JSONEq(t, `{"a":"$${x} ${y} %{ if z }q%{ endif }","b":"x ${y}\n"}`, out)
+
+	for input, msg := range map[string]string{
+		"a = 1\na = 2":      "line 2, column 1: Attribute redefined",
+		"a = \nb = 1":       "line 1, column 5: Invalid expression",
+		"a {\n  b = 1":      "line 1, column 3: Unclosed configuration block",
+		"a = \"x\nb = 1":    "line 1, column 7: Invalid multi-line string",
+		"a = (1 + 2":        "Unbalanced parentheses",
+		"a = 1, b = 2":      "Unexpected comma after argument",
+		"a = 1\na \"x\" {}": `line 2, column 1: "a" is both an attribute and a block`,
+	} {
+		_, err := HCLToJSON(input)
+		require.ErrorContains(t, err, msg, input)
+	}
+}
+
+func TestJSONToHCL(t *testing.T) {
+	out, err := JSONToHCLWithOptions(`{
+		"resource": {"aws_s3_bucket": {"logs": {"bucket": "${var.name}-logs", "acl": "private",
+			"lifecycle": {"prevent_destroy": true}, "rule": [{"id": 1}, {"id": 2}]}}},
+		"variable": {"name": {"default": "app"}},
+		"region": "eu-west-1",
+		"zones": ["a", "b"],
+		"script": "line 1\nline 2\n",
+		"tags": {"Name": "x", "kubernetes.io/role": "web", "null": null}
+	}`, WithSortKeys(false))
+	require.NoError(t, err)
+	require.Equal(t, `resource "aws_s3_bucket" "logs" {
+  bucket = "${var.name}-logs"
+  acl    = "private"
+
+  lifecycle {
+    prevent_destroy = true
+  }
+
+  rule = [
+    {
+      id = 1
+    },
+    {
+      id = 2
+    },
+  ]
+}
+
+variable "name" {
+  default = "app"
+}
+
+region = "eu-west-1"
+zones  = ["a", "b"]
+script = <<EOT
+line 1
+line 2
+EOT
+tags = {
+  Name                 = "x"
+  "kubernetes.io/role" = "web"
+  "null"               = null
+}
+`, out)
+
+	out, err = JSONToHCL(`{"id": "${aws_instance.web.id}", "msg": "say \"${upper(\"hi\")}\"", "n": "${1}", "ok": true}`)
+	require.NoError(t, err)
+	require.Equal(t, "id  = aws_instance.web.id\nmsg = \"say \\\"${upper(\"hi\")}\\\"\"\nn   = \"${1}\"\nok  = true\n", out)
+	back, err := HCLToJSON(out)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"id": "${aws_instance.web.id}", "msg": "say \"${upper(\"hi\")}\"", "n": "${1}", "ok": true}`, back)
+
+	// Heredoc text keeps its own indentation.
+	out, err = JSONToHCLWithOptions(`{"locals": {"script": "a\n  b\n", "n": -1}}`, WithIndent(4))
+	require.NoError(t, err)
+	require.Equal(t, "locals {\n    script = <<EOT\na\n  b\nEOT\n    n      = -1\n}\n", out)
+
+	_, err = JSONToHCL(`[1]`)
+	require.EqualError(t, err, "HCL root must be an object")
+	_, err = JSONToHCL(`{"a b": 1}`)
+	require.EqualError(t, err, `hcl: "a b" is not a valid attribute or block name`)
+}
+
+func TestHCLRoundTrip(t *testing.T) {
+	js, err := HCLToJSON(sampleTerraform)
+	require.NoError(t, err)
+	out, err := ConvertFormats(formatJSON, formatHCL, js)
+	require.NoError(t, err)
+	back, err := ConvertFormats(formatHCL, formatJSON, out)
+	require.NoError(t, err)
+	require.JSONEq(t, js, back)
+
+	format, _, err := DetectFormat(sampleTerraform)
+	require.NoError(t, err)
+	require.Equal(t, formatHCL, format)
+}
@@ -0,0 +1,263 @@
+package convert
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+const formatHTMLTable = "HTML Table"
+
+// maxHTMLTableSpan bounds colspan and rowspan, as browsers do, so that a
+// stray colspan="1000000" cannot blow up the output.
+const maxHTMLTableSpan = 1000
+
+// HTMLTableOptions controls which table is read and how. Header takes the
+// CSVHeader* values; auto uses the rows in <thead>, or leading rows of <th>
+// cells, and otherwise guesses as CSV does.
+type HTMLTableOptions struct {
+	// Index picks the table, counting from 0 in document order.
+	Index  int
+	Header string
+}
+
+// HTMLTableToJSON reads the first table in input. See
+// HTMLTableToJSONWithOptions.
+func HTMLTableToJSON(input string) (string, error) {
+	return HTMLTableToJSONWithOptions(input, HTMLTableOptions{Header: CSVHeaderAuto})
+}
+
+// HTMLTableToJSONWithOptions reads a table out of an HTML page or fragment
+// into an array of objects keyed by header, or an array of arrays when the
+// table has no header. Cell text has its whitespace collapsed and is typed
+// as CSV cells are; colspan and rowspan repeat a cell into every slot it
+// covers. Several header rows, as under a grouping colspan, are joined per
+// column: "Price" over "Min" gives "Price Min".
+func HTMLTableToJSONWithOptions(input string, opts HTMLTableOptions) (string, error) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(input))
+	if err != nil {
+		return "", err
+	}
+	tables := doc.Find("table")
+	switch {
+	case tables.Length() == 0:
+		return "", errors.New("no <table> found")
+	case opts.Index < 0 || opts.Index >= tables.Length():
+		return "", fmt.Errorf("no table at index %d; found %d", opts.Index, tables.Length())
+	}
+	records, headRows := htmlTableRecords(tables.Eq(opts.Index))
+	if len(records) == 0 {
+		return "", errors.New("table has no rows")
+	}
+
+	var header []string
+	switch opts.Header {
+	case CSVHeaderYes:
+		headRows = max(headRows, 1)
+	case CSVHeaderNo:
+		headRows = 0
+	default:
+		if headRows == 0 && looksLikeCSVHeader(records) {
+			headRows = 1
+		}
+	}
+	if headRows > 0 {
+		header = htmlTableHeader(records[:headRows])
+	}
+	body := records[headRows:]
+	if header == nil {
+		rows := make([]any, len(body))
+		for i, rec := range body {
+			row := make([]any, len(rec))
+			for j, cell := range rec {
+				row[j] = csvCellValue(cell)
+			}
+			rows[i] = row
+		}
+		return encodeJSON(rows)
+	}
+	rows := make([]map[string]any, 0, len(body))
+	for _, rec := range body {
+		obj := make(map[string]any, len(header))
+		for j, name := range header {
+			if j < len(rec) {
+				obj[name] = csvCellValue(rec[j])
+			} else {
+				obj[name] = nil
+			}
+		}
+		rows = append(rows, obj)
+	}
+	return tableJSON(header, rows)
+}
+
+// htmlTableRecords lays the rows of table, not of tables nested in it, out
+// on a grid, and counts the leading rows that are header rows: those in
+// <thead> or, without one, rows of <th> cells only.
+func htmlTableRecords(table *goquery.Selection) (records [][]string, headRows int) {
+	type span struct {
+		text string
+		left int
+	}
+	spans := map[int]*span{}
+	hasHead := false
+	table.Children().Each(func(_ int, section *goquery.Selection) {
+		rows := section.ChildrenFiltered("tr")
+		inHead := goquery.NodeName(section) == "thead"
+		switch goquery.NodeName(section) {
+		case "tr":
+			rows = section
+		case "thead", "tbody", "tfoot":
+		default:
+			return
+		}
+		hasHead = hasHead || inHead
+		rows.Each(func(_ int, tr *goquery.Selection) {
+			var row []string
+			allTH := true
+			fill := func() {
+				for s := spans[len(row)]; s != nil && s.left > 0; s = spans[len(row)] {
+					s.left--
+					row = append(row, s.text)
+				}
+			}
+			tr.ChildrenFiltered("td, th").Each(func(_ int, cell *goquery.Selection) {
+				fill()
+				allTH = allTH && goquery.NodeName(cell) == "th"
+				text := htmlCellText(cell)
+				rowspan := htmlTableSpan(cell, "rowspan")
+				for range htmlTableSpan(cell, "colspan") {
+					if rowspan > 1 {
+						spans[len(row)] = &span{text: text, left: rowspan - 1}
+					}
+					row = append(row, text)
+				}
+			})
+			fill()
+			if len(row) == 0 {
+				return
+			}
+			if headRows == len(records) && (inHead || !hasHead && allTH) {
+				headRows++
+			}
+			records = append(records, row)
+		})
+	})
+	return records, headRows
+}
+
+func htmlTableSpan(cell *goquery.Selection, attr string) int {
+	n, err := strconv.Atoi(strings.TrimSpace(cell.AttrOr(attr, "1")))
+	if err != nil || n < 1 {
+		return 1
+	}
+	return min(n, maxHTMLTableSpan)
+}
+
+// htmlCellText is the text of a cell with line breaks and block elements
+// set apart by spaces and runs of whitespace collapsed.
+func htmlCellText(cell *goquery.Selection) string {
+	cell = cell.Clone()
+	cell.Find("script, style").Remove()
+	cell.Find("br").ReplaceWithHtml(" ")
+	cell.Find("p, div, li, table, tr, td, th").BeforeHtml(" ").AfterHtml(" ")
+	return strings.Join(strings.Fields(cell.Text()), " ")
+}
+
+// htmlTableHeader joins header rows per column, dropping the repeats a
+// colspan leaves, and names empty or duplicate columns so that no cell is
+// lost.
+func htmlTableHeader(rows [][]string) []string {
+	width := 0
+	for _, row := range rows {
+		width = max(width, len(row))
+	}
+	header := make([]string, width)
+	seen := map[string]int{}
+	for i := range header {
+		var parts []string
+		for _, row := range rows {
+			if i < len(row) && row[i] != "" && (len(parts) == 0 || parts[len(parts)-1] != row[i]) {
+				parts = append(parts, row[i])
+			}
+		}
+		name := strings.Join(parts, " ")
+		if name == "" {
+			name = "column" + strconv.Itoa(i+1)
+		}
+		if seen[name]++; seen[name] > 1 {
+			name += "_" + strconv.Itoa(seen[name])
+		}
+		header[i] = name
+	}
+	return header
+}
+
+// JSONToHTMLTable writes an array of objects as a table headed by the
+// union of their keys, or an array of arrays as a table without a header.
+// Nested values are written as compact JSON, as in CSV.
+func JSONToHTMLTable(input string) (string, error) {
+	return jsonToHTMLTableWithOptions(input, NewConvertOptions())
+}
+
+// jsonToHTMLTableWithOptions heads the columns in the order keys first
+// appear unless SortKeys is set.
+func jsonToHTMLTableWithOptions(input string, o ConvertOptions) (string, error) {
+	data, err := decodeJSONValue(input)
+	if err != nil {
+		return "", err
+	}
+	var items []any
+	switch val := data.(type) {
+	case []any:
+		items = val
+	case map[string]any:
+		items = []any{val}
+	default:
+		return "", errors.New("HTML tables require an array of objects or arrays")
+	}
+	var b strings.Builder
+	writeRow := func(cells []string, tag string) {
+		b.WriteString("<tr>")
+		for _, cell := range cells {
+			b.WriteString("<" + tag + ">" + htmlEscape(cell) + "</" + tag + ">")
+		}
+		b.WriteString("</tr>\n")
+	}
+	b.WriteString("<table>\n")
+	header := csvHeaderFor(items, tableKeyOrder(input, data, o.SortKeys))
+	if header != nil {
+		b.WriteString("<thead>\n")
+		writeRow(header, "th")
+		b.WriteString("</thead>\n")
+	}
+	if len(items) > 0 {
+		b.WriteString("<tbody>\n")
+	}
+	for _, item := range items {
+		var record []string
+		switch row := item.(type) {
+		case map[string]any:
+			record = make([]string, len(header))
+			for j, key := range header {
+				record[j] = csvCellString(row[key])
+			}
+		case []any:
+			record = make([]string, len(row))
+			for j, cell := range row {
+				record[j] = csvCellString(cell)
+			}
+		default:
+			record = []string{csvCellString(row)}
+		}
+		writeRow(record, "td")
+	}
+	if len(items) > 0 {
+		b.WriteString("</tbody>\n")
+	}
+	b.WriteString("</table>")
+	return b.String(), nil
+}
@@ -0,0 +1,111 @@
+package convert
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTMLTableToJSON(t *testing.T) {
+	page := `<!DOCTYPE html><html><body>
+<p>Team</p>
+<table>
+  <thead><tr><th>Name</th><th>Age</th><th>Note</th></tr></thead>
+  <tbody>
+    <tr><td>Ricky</td><td>27</td><td>likes <b>Go</b><br>and
+        wasm</td></tr>
+    <tr><td>Alice</td><td>30</td></tr>
+  </tbody>
+</table>
+</body></html>`
+	out, err := HTMLTableToJSON(page)
+	require.NoError(t, err)
+	require.JSONEq(t, `[
+		{"Age":27,"Name":"Ricky","Note":"likes Go and wasm"},
+		{"Age":30,"Name":"Alice","Note":null}
+	]`, out)
+}
+
+func TestHTMLTableToJSON_Spans(t *testing.T) {
+	input := `<table><thead>
+<tr><th rowspan="2">Item</th><th colspan="2">Price</th></tr>
+<tr><th>Min</th><th>Max</th></tr>
+</thead><tbody>
+<tr><td>apple</td><td rowspan="2">1</td><td>2</td></tr>
+<tr><td>pear</td><td>3</td></tr>
+</tbody></table>`
+	out, err := HTMLTableToJSON(input)
+	require.NoError(t, err)
+	require.JSONEq(t, `[
+		{"Item":"apple","Price Max":2,"Price Min":1},
+		{"Item":"pear","Price Max":3,"Price Min":1}
+	]`, out)
+
+	// Without <thead>, leading rows of <th> cells are the header; empty and
+	// repeated names are made unique.
+	out, err = HTMLTableToJSON(`<table>
+<tr><th>Item</th><th></th><th>Item</th></tr>
+<tr><td>apple</td><td>red</td><td>x</td></tr>
+</table>`)
+	require.NoError(t, err)
+	require.JSONEq(t, `[{"Item":"apple","column2":"red","Item_2":"x"}]`, out)
+}
+
+func TestHTMLTableToJSON_Options(t *testing.T) {
+	input := `<table><tr><td>a</td><td>b</td></tr><tr><td>1</td><td>2</td></tr></table>
+<table><tr><td>outer<table><tr><td>inner</td></tr></table></td></tr></table>`
+
+	out, err := HTMLTableToJSON(input)
+	require.NoError(t, err)
+	require.JSONEq(t, `[{"a":1,"b":2}]`, out)
+
+	out, err = HTMLTableToJSONWithOptions(input, HTMLTableOptions{Header: CSVHeaderNo})
+	require.NoError(t, err)
+	require.JSONEq(t, `[["a","b"],[1,2]]`, out)
+
+	// Nested tables count in document order but keep their rows apart.
+	out, err = HTMLTableToJSONWithOptions(input, HTMLTableOptions{Index: 1, Header: CSVHeaderNo})
+	require.NoError(t, err)
+	require.JSONEq(t, `[["outer inner"]]`, out)
+	out, err = HTMLTableToJSONWithOptions(input, HTMLTableOptions{Index: 2, Header: CSVHeaderNo})
+	require.NoError(t, err)
+	require.JSONEq(t, `[["inner"]]`, out)
+
+	_, err = HTMLTableToJSONWithOptions(input, HTMLTableOptions{Index: 3})
+	require.EqualError(t, err, "no table at index 3; found 3")
+	_, err = HTMLTableToJSON("<p>no tables</p>")
+	require.EqualError(t, err, "no <table> found")
+	_, err = HTMLTableToJSON("<table></table>")
+	require.EqualError(t, err, "table has no rows")
+}
+
+func TestJSONToHTMLTable(t *testing.T) {
+	out, err := JSONToHTMLTable(`[{"name":"R&D <x>","age":27,"tags":["a"]},{"name":"Alice"}]`)
+	require.NoError(t, err)
+	require.Equal(t, `<table>
+<thead>
+<tr><th>name</th><th>age</th><th>tags</th></tr>
+</thead>
+<tbody>
+<tr><td>R&amp;D &lt;x&gt;</td><td>27</td><td>["a"]</td></tr>
+<tr><td>Alice</td><td></td><td></td></tr>
+</tbody>
+</table>`, out)
+
+	out, err = JSONToHTMLTable(`[[1,2],[3,4]]`)
+	require.NoError(t, err)
+	require.Equal(t, "<table>\n<tbody>\n<tr><td>1</td><td>2</td></tr>\n<tr><td>3</td><td>4</td></tr>\n</tbody>\n</table>", out)
+
+	back, err := HTMLTableToJSONWithOptions(out, HTMLTableOptions{Header: CSVHeaderNo})
+	require.NoError(t, err)
+	require.JSONEq(t, `[[1,2],[3,4]]`, back)
+
+	_, err = JSONToHTMLTable(`"text"`)
+	require.Error(t, err)
+}
+
+func TestConvertFormats_HTMLTableToCSV(t *testing.T) {
+	out, err := ConvertFormats(formatHTMLTable, formatCSV, `<table><tr><th>id</th><th>name</th></tr><tr><td>1</td><td>alpha</td></tr></table>`)
+	require.NoError(t, err)
+	require.Equal(t, "id,name\n1,alpha", out)
+}
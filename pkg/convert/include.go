@@ -0,0 +1,188 @@
+package convert
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"slices"
+	"strings"
+	"testing/fstest"
+
+	"github.com/linzeyan/transform-go/pkg/common"
+	"gopkg.in/yaml.v3"
+)
+
+// includeTag is the YAML tag that inlines another file, as in
+// "database: !include db.yaml".
+const includeTag = "!include"
+
+// ResolveIncludes reads entry from fsys and inlines every file it
+// references, then converts the result to the target format (JSON when
+// empty). In JSON, YAML and TOML an object {"$ref": "file#/pointer"} is
+// replaced by that file, or the part the pointer selects, with any sibling
+// keys deep-merged on top; YAML may also write "!include file". Paths are
+// relative to the including file. Local "#/..." and URL references are
+// left alone and include cycles are an error.
+func ResolveIncludes(fsys fs.FS, entry, to string) (string, error) {
+	r := &includeResolver{fsys: fsys}
+	data, err := r.file(path.Clean(strings.TrimPrefix(entry, "/")))
+	if err != nil {
+		return "", err
+	}
+	out, err := encodeJSON(data)
+	if err != nil {
+		return "", err
+	}
+	if to == "" || to == formatJSON {
+		return out, nil
+	}
+	return ConvertFormats(formatJSON, to, out)
+}
+
+// ResolveIncludeFiles runs ResolveIncludes over in-memory files keyed by
+// slash-separated path, for callers without a filesystem such as the
+// browser build.
+func ResolveIncludeFiles(files map[string]string, entry, to string) (string, error) {
+	fsys := fstest.MapFS{}
+	for name, content := range files {
+		fsys[path.Clean(strings.TrimPrefix(name, "/"))] = &fstest.MapFile{Data: []byte(content)}
+	}
+	return ResolveIncludes(fsys, entry, to)
+}
+
+type includeResolver struct {
+	fsys  fs.FS
+	stack []string
+}
+
+// file decodes one file and resolves its references; stack holds the
+// files being resolved so a cycle can be reported as a chain.
+func (r *includeResolver) file(name string) (any, error) {
+	if slices.Contains(r.stack, name) {
+		return nil, fmt.Errorf("include cycle: %s", strings.Join(append(r.stack, name), " -> "))
+	}
+	raw, err := fs.ReadFile(r.fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	data, err := decodeIncludeFile(name, string(raw))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	r.stack = append(r.stack, name)
+	defer func() { r.stack = r.stack[:len(r.stack)-1] }()
+	return r.walk(data, path.Dir(name))
+}
+
+func (r *includeResolver) walk(v any, dir string) (any, error) {
+	var err error
+	switch val := v.(type) {
+	case map[string]any:
+		if ref, ok := val["$ref"].(string); ok && isFileReference(ref) {
+			target, err := r.reference(ref, dir)
+			if err != nil {
+				return nil, err
+			}
+			delete(val, "$ref")
+			if len(val) == 0 {
+				return target, nil
+			}
+			siblings, err := r.walk(val, dir)
+			if err != nil {
+				return nil, err
+			}
+			return mergeValues(target, siblings, MergeDeep), nil
+		}
+		for k, item := range val {
+			if val[k], err = r.walk(item, dir); err != nil {
+				return nil, err
+			}
+		}
+	case []any:
+		for i, item := range val {
+			if val[i], err = r.walk(item, dir); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return v, nil
+}
+
+func (r *includeResolver) reference(ref, dir string) (any, error) {
+	file, pointer, _ := strings.Cut(ref, "#")
+	name := path.Join(dir, file)
+	if path.IsAbs(file) {
+		name = path.Clean(strings.TrimPrefix(file, "/"))
+	}
+	if !fs.ValidPath(name) {
+		return nil, fmt.Errorf("reference %s is outside the root", ref)
+	}
+	data, err := r.file(name)
+	if err != nil {
+		return nil, err
+	}
+	node, ok := followJSONPointer(data, pointer)
+	if !ok {
+		return nil, fmt.Errorf("unresolved reference %s", ref)
+	}
+	return node, nil
+}
+
+func isFileReference(ref string) bool {
+	return ref != "" && !strings.HasPrefix(ref, "#") && !strings.Contains(ref, "://")
+}
+
+// decodeIncludeFile picks the decoder by extension, falling back to
+// detection; YAML goes through decodeYAMLIncludes to keep !include tags.
+func decodeIncludeFile(name, content string) (any, error) {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".yaml", ".yml":
+		return decodeYAMLIncludes(content)
+	case ".toml":
+		out, err := tomlToPlainJSON(content)
+		if err != nil {
+			return nil, err
+		}
+		return decodeJSONValue(out)
+	}
+	if !json.Valid([]byte(strings.TrimSpace(content))) {
+		if format, _, err := DetectFormat(content); err == nil && format == formatYAML {
+			return decodeYAMLIncludes(content)
+		}
+	}
+	return decodeDocument(content)
+}
+
+// decodeYAMLIncludes decodes YAML with every "!include file" scalar turned
+// into a {"$ref": "file"} object, which also lets "<<: !include base.yaml"
+// merge keys work.
+func decodeYAMLIncludes(content string) (any, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal([]byte(content), &root); err != nil {
+		return nil, err
+	}
+	markYAMLIncludes(&root)
+	var data any
+	if err := root.Decode(&data); err != nil {
+		return nil, err
+	}
+	out, err := encodeJSON(common.NormalizeYAML(data))
+	if err != nil {
+		return nil, err
+	}
+	return decodeJSONValue(out)
+}
+
+func markYAMLIncludes(n *yaml.Node) {
+	if n.Kind == yaml.ScalarNode && n.Tag == includeTag {
+		*n = yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Content: []*yaml.Node{
+			{Kind: yaml.ScalarNode, Tag: "!!str", Value: "$ref"},
+			{Kind: yaml.ScalarNode, Tag: "!!str", Value: n.Value},
+		}}
+		return
+	}
+	for _, child := range n.Content {
+		markYAMLIncludes(child)
+	}
+}
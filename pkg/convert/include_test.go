@@ -0,0 +1,47 @@
+package convert
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveIncludes(t *testing.T) {
+	fsys := fstest.MapFS{
+		"app.yaml":         {Data: []byte("name: app\ndatabase: !include conf/db.yaml\nlimits:\n  $ref: conf/limits.json#/prod\n  cpu: 2\nschema:\n  $ref: '#/definitions/x'\n")},
+		"conf/db.yaml":     {Data: []byte("<<: !include base.toml\nhost: db.local\n")},
+		"conf/base.toml":   {Data: []byte("port = 5432\nhost = \"localhost\"\n")},
+		"conf/limits.json": {Data: []byte(`{"prod":{"cpu":1,"memory":"1Gi"},"dev":{}}`)},
+	}
+	out, err := ResolveIncludes(fsys, "app.yaml", "")
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"name": "app",
+		"database": {"host": "db.local", "port": 5432},
+		"limits": {"cpu": 2, "memory": "1Gi"},
+		"schema": {"$ref": "#/definitions/x"}
+	}`, out)
+
+	out, err = ResolveIncludeFiles(map[string]string{
+		"/main.json": `{"items":[{"$ref":"item.json"},{"$ref":"item.json"}]}`,
+		"item.json":  `{"id":1}`,
+	}, "main.json", formatYAML)
+	require.NoError(t, err)
+	require.Equal(t, "items:\n  - id: 1\n  - id: 1", out)
+}
+
+func TestResolveIncludes_Errors(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a.json":    {Data: []byte(`{"b":{"$ref":"b.yaml"}}`)},
+		"b.yaml":    {Data: []byte("a: !include a.json\n")},
+		"up.json":   {Data: []byte(`{"x":{"$ref":"../secret.json"}}`)},
+		"miss.json": {Data: []byte(`{"x":{"$ref":"nope.json"}}`)},
+	}
+	_, err := ResolveIncludes(fsys, "a.json", "")
+	require.ErrorContains(t, err, "include cycle: a.json -> b.yaml -> a.json")
+	_, err = ResolveIncludes(fsys, "up.json", "")
+	require.ErrorContains(t, err, "outside the root")
+	_, err = ResolveIncludes(fsys, "miss.json", "")
+	require.Error(t, err)
+}
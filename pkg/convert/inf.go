@@ -0,0 +1,164 @@
+package convert
+
+import (
+	"fmt"
+	"strings"
+)
+
+// INFToJSON parses a Windows setup information (.inf) file into an object of
+// sections. Each section is a list of entries with an optional key and its
+// comma-separated values; %token% references are resolved from [Strings].
+func INFToJSON(input string) (string, error) {
+	type entry struct {
+		key    string
+		hasKey bool
+		values []string
+	}
+	var order []string
+	sections := map[string][]entry{}
+	current := ""
+	for i, raw := range strings.Split(strings.ReplaceAll(decodeRegText(input), "\r\n", "\n"), "\n") {
+		line := strings.TrimSpace(stripINFComment(raw))
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			if !strings.HasSuffix(line, "]") {
+				return "", fmt.Errorf("line %d: unterminated section", i+1)
+			}
+			current = strings.TrimSpace(line[1 : len(line)-1])
+			if _, ok := sections[current]; !ok {
+				order = append(order, current)
+				sections[current] = nil
+			}
+			continue
+		}
+		if current == "" {
+			return "", fmt.Errorf("line %d: entry outside of a section", i+1)
+		}
+		var e entry
+		if key, value, ok := cutINFKey(line); ok {
+			e.key, e.hasKey = key, true
+			line = value
+		}
+		e.values = splitINFValues(line)
+		sections[current] = append(sections[current], e)
+	}
+
+	strs := map[string]string{}
+	for name, entries := range sections {
+		if !strings.EqualFold(name, "Strings") {
+			continue
+		}
+		for _, e := range entries {
+			if e.hasKey && len(e.values) > 0 {
+				strs[strings.ToLower(e.key)] = e.values[0]
+			}
+		}
+	}
+	out := make(map[string]any, len(order))
+	for _, name := range order {
+		list := make([]any, 0, len(sections[name]))
+		for _, e := range sections[name] {
+			values := make([]any, len(e.values))
+			for j, v := range e.values {
+				values[j] = expandINFStrings(v, strs)
+			}
+			item := map[string]any{"values": values}
+			if e.hasKey {
+				item["key"] = expandINFStrings(e.key, strs)
+			}
+			list = append(list, item)
+		}
+		out[name] = list
+	}
+	return encodeJSON(out)
+}
+
+func stripINFComment(line string) string {
+	inQuote := false
+	for i, r := range line {
+		switch r {
+		case '"':
+			inQuote = !inQuote
+		case ';':
+			if !inQuote {
+				return line[:i]
+			}
+		}
+	}
+	return line
+}
+
+func cutINFKey(line string) (string, string, bool) {
+	inQuote := false
+	for i, r := range line {
+		switch r {
+		case '"':
+			inQuote = !inQuote
+		case '=':
+			if !inQuote {
+				return unquoteINF(strings.TrimSpace(line[:i])), strings.TrimSpace(line[i+1:]), true
+			}
+		}
+	}
+	return "", line, false
+}
+
+func splitINFValues(line string) []string {
+	values := []string{}
+	var cur strings.Builder
+	inQuote := false
+	for _, r := range line {
+		switch {
+		case r == '"':
+			inQuote = !inQuote
+			cur.WriteRune(r)
+		case r == ',' && !inQuote:
+			values = append(values, unquoteINF(strings.TrimSpace(cur.String())))
+			cur.Reset()
+		default:
+			cur.WriteRune(r)
+		}
+	}
+	if last := strings.TrimSpace(cur.String()); last != "" || len(values) > 0 {
+		values = append(values, unquoteINF(last))
+	}
+	return values
+}
+
+func unquoteINF(s string) string {
+	if len(s) >= 2 && strings.HasPrefix(s, `"`) && strings.HasSuffix(s, `"`) {
+		return strings.ReplaceAll(s[1:len(s)-1], `""`, `"`)
+	}
+	return s
+}
+
+func expandINFStrings(s string, strs map[string]string) string {
+	if !strings.Contains(s, "%") {
+		return s
+	}
+	var b strings.Builder
+	for {
+		start := strings.Index(s, "%")
+		if start < 0 {
+			break
+		}
+		end := strings.Index(s[start+1:], "%")
+		if end < 0 {
+			break
+		}
+		token := s[start+1 : start+1+end]
+		b.WriteString(s[:start])
+		if val, ok := strs[strings.ToLower(token)]; ok {
+			b.WriteString(val)
+		} else if token == "" {
+			b.WriteString("%")
+		} else {
+			b.WriteString("%" + token + "%")
+		}
+		s = s[start+end+2:]
+	}
+	b.WriteString(s)
+	return b.String()
+}
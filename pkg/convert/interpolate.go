@@ -0,0 +1,103 @@
+package convert
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/linzeyan/transform-go/pkg/common"
+)
+
+var placeholderRe = common.LazyRegexp(`\$\$([{(])|\$\{([A-Za-z_][A-Za-z0-9_]*)(?:(:?-)([^}]*))?\}|\$\(([A-Za-z_][A-Za-z0-9_]*)\)`)
+
+// Placeholder is one ${VAR}, ${VAR:-default}, ${VAR-default} or $(VAR)
+// occurrence. Line and Column are 1-based; Column counts bytes.
+type Placeholder struct {
+	Name       string `json:"name"`
+	Text       string `json:"text"`
+	Default    string `json:"default,omitempty"`
+	HasDefault bool   `json:"hasDefault"`
+	Line       int    `json:"line"`
+	Column     int    `json:"column"`
+}
+
+// PlaceholderReport lists every placeholder in order and the distinct
+// variable names, sorted.
+type PlaceholderReport struct {
+	Placeholders []Placeholder `json:"placeholders"`
+	Names        []string      `json:"names"`
+}
+
+// InterpolationResult is the expanded text, how many placeholders were
+// replaced and the variables that were missing and had no default; those
+// placeholders are left as they are.
+type InterpolationResult struct {
+	Text     string   `json:"text"`
+	Replaced int      `json:"replaced"`
+	Missing  []string `json:"missing"`
+}
+
+// InterpolateEnv expands placeholders in a document from vars, working on
+// the text so any format keeps its layout. ${VAR:-x} falls back to x when
+// VAR is unset or empty and ${VAR-x} only when it is unset. $${VAR} and
+// $$(VAR) are escapes that produce the placeholder literally.
+func InterpolateEnv(input string, vars map[string]string) InterpolationResult {
+	res := InterpolationResult{Missing: []string{}}
+	missing := map[string]bool{}
+	res.Text = placeholderRe().ReplaceAllStringFunc(input, func(m string) string {
+		p, op := parsePlaceholder(m)
+		if p == nil {
+			return m[1:]
+		}
+		value, ok := vars[p.Name]
+		switch {
+		case p.HasDefault && (!ok || value == "" && op == ":-"):
+			value = p.Default
+		case !ok:
+			if !missing[p.Name] {
+				missing[p.Name] = true
+				res.Missing = append(res.Missing, p.Name)
+			}
+			return m
+		}
+		res.Replaced++
+		return value
+	})
+	return res
+}
+
+// ExtractPlaceholders reports the placeholders InterpolateEnv would
+// expand, for checking which variables a config template needs.
+func ExtractPlaceholders(input string) PlaceholderReport {
+	report := PlaceholderReport{Placeholders: []Placeholder{}, Names: []string{}}
+	seen := map[string]bool{}
+	for _, loc := range placeholderRe().FindAllStringIndex(input, -1) {
+		p, _ := parsePlaceholder(input[loc[0]:loc[1]])
+		if p == nil {
+			continue
+		}
+		lineStart := strings.LastIndexByte(input[:loc[0]], '\n') + 1
+		p.Line = strings.Count(input[:loc[0]], "\n") + 1
+		p.Column = loc[0] - lineStart + 1
+		report.Placeholders = append(report.Placeholders, *p)
+		if !seen[p.Name] {
+			seen[p.Name] = true
+			report.Names = append(report.Names, p.Name)
+		}
+	}
+	sort.Strings(report.Names)
+	return report
+}
+
+// parsePlaceholder decodes one match of placeholderRe with its default
+// operator (":-", "-" or ""), or returns nil for an escaped $$.
+func parsePlaceholder(m string) (*Placeholder, string) {
+	sub := placeholderRe().FindStringSubmatch(m)
+	switch {
+	case sub[1] != "":
+		return nil, ""
+	case sub[2] != "":
+		return &Placeholder{Name: sub[2], Text: m, Default: sub[4], HasDefault: sub[3] != ""}, sub[3]
+	default:
+		return &Placeholder{Name: sub[5], Text: m}, ""
+	}
+}
@@ -0,0 +1,27 @@
+package convert
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInterpolateEnv(t *testing.T) {
+	input := "host: ${DB_HOST}\nport: ${DB_PORT:-5432}\nuser: ${DB_USER-admin}\npass: $(DB_PASS)\n" +
+		"empty: ${EMPTY:-fallback}|${EMPTY-kept}\nliteral: $${DB_HOST} $$(X)\nmissing: ${TOKEN}\n"
+	res := InterpolateEnv(input, map[string]string{"DB_HOST": "db.local", "DB_PASS": "s3cret", "EMPTY": ""})
+	require.Equal(t, "host: db.local\nport: 5432\nuser: admin\npass: s3cret\n"+
+		"empty: fallback|\nliteral: ${DB_HOST} $(X)\nmissing: ${TOKEN}\n", res.Text)
+	require.Equal(t, 6, res.Replaced)
+	require.Equal(t, []string{"TOKEN"}, res.Missing)
+}
+
+func TestExtractPlaceholders(t *testing.T) {
+	report := ExtractPlaceholders("a: ${B:-x}\nc: $(A) $${SKIP} ${B}")
+	require.Equal(t, []Placeholder{
+		{Name: "B", Text: "${B:-x}", Default: "x", HasDefault: true, Line: 1, Column: 4},
+		{Name: "A", Text: "$(A)", Line: 2, Column: 4},
+		{Name: "B", Text: "${B}", Line: 2, Column: 18},
+	}, report.Placeholders)
+	require.Equal(t, []string{"A", "B"}, report.Names)
+}
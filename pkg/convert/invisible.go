@@ -0,0 +1,130 @@
+package convert
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	InvisibleBOM       = "bom"
+	InvisibleZeroWidth = "zero-width"
+	InvisibleNBSP      = "nbsp"
+	InvisibleBidi      = "bidi"
+)
+
+type invisibleRune struct {
+	kind string
+	name string
+}
+
+var invisibleRunes = map[rune]invisibleRune{
+	'\ufeff': {InvisibleZeroWidth, "ZERO WIDTH NO-BREAK SPACE"},
+	'\u200b': {InvisibleZeroWidth, "ZERO WIDTH SPACE"},
+	'\u200c': {InvisibleZeroWidth, "ZERO WIDTH NON-JOINER"},
+	'\u200d': {InvisibleZeroWidth, "ZERO WIDTH JOINER"},
+	'\u2060': {InvisibleZeroWidth, "WORD JOINER"},
+	'\u180e': {InvisibleZeroWidth, "MONGOLIAN VOWEL SEPARATOR"},
+	'\u00ad': {InvisibleZeroWidth, "SOFT HYPHEN"},
+	'\u00a0': {InvisibleNBSP, "NO-BREAK SPACE"},
+	'\u2007': {InvisibleNBSP, "FIGURE SPACE"},
+	'\u202f': {InvisibleNBSP, "NARROW NO-BREAK SPACE"},
+	'\u200e': {InvisibleBidi, "LEFT-TO-RIGHT MARK"},
+	'\u200f': {InvisibleBidi, "RIGHT-TO-LEFT MARK"},
+	'\u061c': {InvisibleBidi, "ARABIC LETTER MARK"},
+	'\u202a': {InvisibleBidi, "LEFT-TO-RIGHT EMBEDDING"},
+	'\u202b': {InvisibleBidi, "RIGHT-TO-LEFT EMBEDDING"},
+	'\u202c': {InvisibleBidi, "POP DIRECTIONAL FORMATTING"},
+	'\u202d': {InvisibleBidi, "LEFT-TO-RIGHT OVERRIDE"},
+	'\u202e': {InvisibleBidi, "RIGHT-TO-LEFT OVERRIDE"},
+	'\u2066': {InvisibleBidi, "LEFT-TO-RIGHT ISOLATE"},
+	'\u2067': {InvisibleBidi, "RIGHT-TO-LEFT ISOLATE"},
+	'\u2068': {InvisibleBidi, "FIRST STRONG ISOLATE"},
+	'\u2069': {InvisibleBidi, "POP DIRECTIONAL ISOLATE"},
+}
+
+// InvisibleChar is one hidden character. Offset is in bytes; Line and
+// Column are 1-based, with Column counted in characters.
+type InvisibleChar struct {
+	Offset    int    `json:"offset"`
+	Line      int    `json:"line"`
+	Column    int    `json:"column"`
+	CodePoint string `json:"codePoint"`
+	Name      string `json:"name"`
+	Kind      string `json:"kind"`
+}
+
+// InvisibleReport lists hidden characters in order with a count per kind.
+type InvisibleReport struct {
+	Chars  []InvisibleChar `json:"chars"`
+	Counts map[string]int  `json:"counts"`
+}
+
+// FindInvisibleChars reports byte order marks, zero-width characters,
+// non-breaking spaces and bidi controls, which look like nothing (or like a
+// plain space) but make JSON, YAML and the other parsers fail. A zero
+// width joiner inside an emoji sequence is legitimate and not reported.
+func FindInvisibleChars(input string) InvisibleReport {
+	report := InvisibleReport{Chars: []InvisibleChar{}, Counts: map[string]int{}}
+	line, col := 1, 0
+	for offset, r := range input {
+		col++
+		if r == '\n' {
+			line, col = line+1, 0
+			continue
+		}
+		info, ok := invisibleLookup(input, offset, r)
+		if !ok {
+			continue
+		}
+		report.Chars = append(report.Chars, InvisibleChar{
+			Offset:    offset,
+			Line:      line,
+			Column:    col,
+			CodePoint: fmt.Sprintf("U+%04X", r),
+			Name:      info.name,
+			Kind:      info.kind,
+		})
+		report.Counts[info.kind]++
+	}
+	return report
+}
+
+// StripInvisibleChars removes the characters FindInvisibleChars reports,
+// except that non-breaking spaces become plain spaces so words stay apart.
+// Unlike StripControlChars it leaves other format characters and emoji
+// sequences intact.
+func StripInvisibleChars(input string) string {
+	var out strings.Builder
+	out.Grow(len(input))
+	for offset, r := range input {
+		info, ok := invisibleLookup(input, offset, r)
+		switch {
+		case !ok:
+			out.WriteRune(r)
+		case info.kind == InvisibleNBSP:
+			out.WriteByte(' ')
+		}
+	}
+	return out.String()
+}
+
+func invisibleLookup(input string, offset int, r rune) (invisibleRune, bool) {
+	info, ok := invisibleRunes[r]
+	if !ok {
+		return info, false
+	}
+	switch r {
+	case '\ufeff':
+		if offset == 0 {
+			info = invisibleRune{InvisibleBOM, "BYTE ORDER MARK"}
+		}
+	case '\u200d':
+		prev, _ := utf8.DecodeLastRuneInString(input[:offset])
+		next, _ := utf8.DecodeRuneInString(input[offset+utf8.RuneLen(r):])
+		if isEmojiRune(prev) && isEmojiRune(next) {
+			return info, false
+		}
+	}
+	return info, true
+}
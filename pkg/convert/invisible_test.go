@@ -0,0 +1,33 @@
+package convert
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindInvisibleChars(t *testing.T) {
+	input := "\ufeff{\"a\":\u00a01,\n \"b\u200b\": \"\u202ex\"}"
+	report := FindInvisibleChars(input)
+	require.Equal(t, []InvisibleChar{
+		{Offset: 0, Line: 1, Column: 1, CodePoint: "U+FEFF", Name: "BYTE ORDER MARK", Kind: InvisibleBOM},
+		{Offset: 8, Line: 1, Column: 7, CodePoint: "U+00A0", Name: "NO-BREAK SPACE", Kind: InvisibleNBSP},
+		{Offset: 16, Line: 2, Column: 4, CodePoint: "U+200B", Name: "ZERO WIDTH SPACE", Kind: InvisibleZeroWidth},
+		{Offset: 23, Line: 2, Column: 9, CodePoint: "U+202E", Name: "RIGHT-TO-LEFT OVERRIDE", Kind: InvisibleBidi},
+	}, report.Chars)
+	require.Equal(t, map[string]int{InvisibleBOM: 1, InvisibleNBSP: 1, InvisibleZeroWidth: 1, InvisibleBidi: 1}, report.Counts)
+
+	require.False(t, json.Valid([]byte(input)))
+	clean := StripInvisibleChars(input)
+	require.Equal(t, "{\"a\": 1,\n \"b\": \"x\"}", clean)
+	require.True(t, json.Valid([]byte(clean)))
+	require.Empty(t, FindInvisibleChars(clean).Chars)
+}
+
+func TestFindInvisibleChars_EmojiJoiner(t *testing.T) {
+	family := "\U0001F468\u200d\U0001F469\u200d\U0001F467"
+	require.Empty(t, FindInvisibleChars(family).Chars)
+	require.Equal(t, family, StripInvisibleChars(family))
+	require.Len(t, FindInvisibleChars("a\u200db").Chars, 1)
+}
@@ -0,0 +1,216 @@
+package convert
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"net/netip"
+	"strconv"
+	"strings"
+)
+
+var (
+	nat64Prefix          = netip.MustParsePrefix("64:ff9b::/96")
+	ipv6DocumentationNet = netip.MustParsePrefix("2001:db8::/32")
+)
+
+type IPv6Result struct {
+	Type         string `json:"type"`
+	Input        string `json:"input"`
+	Expanded     string `json:"expanded,omitempty"`
+	Compressed   string `json:"compressed,omitempty"`
+	CIDR         string `json:"cidr,omitempty"`
+	Prefix       int    `json:"prefix,omitempty"`
+	RangeStart   string `json:"rangeStart,omitempty"`
+	RangeEnd     string `json:"rangeEnd,omitempty"`
+	Total        string `json:"total,omitempty"`
+	Integer      string `json:"integer,omitempty"`
+	Scope        string `json:"scope,omitempty"`
+	Zone         string `json:"zone,omitempty"`
+	EmbeddedIPv4 string `json:"embeddedIPv4,omitempty"`
+	EUI64        bool   `json:"eui64"`
+	MAC          string `json:"mac,omitempty"`
+}
+
+// IPv6Info describes a single IPv6 address, a prefix (2001:db8::/32) or a
+// range (start - end). Address counts use big integers since a /0 holds
+// 2^128 addresses.
+func IPv6Info(input string) (IPv6Result, error) {
+	trimmed := strings.TrimSpace(input)
+	res := IPv6Result{Input: trimmed}
+	if trimmed == "" {
+		return res, errors.New("input is empty")
+	}
+	if looksLikeRange(trimmed) {
+		return ipv6Range(trimmed)
+	}
+	if strings.Contains(trimmed, "/") {
+		return ipv6WithPrefix(trimmed)
+	}
+	addr, err := parseIPv6(trimmed)
+	if err != nil {
+		return res, err
+	}
+	res.Type = "single"
+	res.Zone = addr.Zone()
+	addr = addr.WithZone("")
+	describeIPv6(&res, addr)
+	res.CIDR = addr.String() + "/128"
+	res.RangeStart = addr.String()
+	res.RangeEnd = addr.String()
+	res.Total = "1"
+	res.Integer = ipv6ToBig(addr).String()
+	return res, nil
+}
+
+func ipv6WithPrefix(input string) (IPv6Result, error) {
+	res := IPv6Result{Input: input, Type: "network"}
+	left, right, _ := strings.Cut(input, "/")
+	addr, err := parseIPv6(left)
+	if err != nil {
+		return res, err
+	}
+	bits, err := strconv.Atoi(strings.TrimSpace(right))
+	if err != nil || bits < 0 || bits > 128 {
+		return res, fmt.Errorf("invalid prefix length: %s", right)
+	}
+	prefix := netip.PrefixFrom(addr.WithZone(""), bits).Masked()
+	start := prefix.Addr()
+	describeIPv6(&res, start)
+	res.CIDR = prefix.String()
+	res.Prefix = bits
+	res.RangeStart = start.String()
+	size := new(big.Int).Lsh(big.NewInt(1), uint(128-bits))
+	res.RangeEnd = bigToIPv6(new(big.Int).Add(ipv6ToBig(start), new(big.Int).Sub(size, big.NewInt(1)))).String()
+	res.Total = size.String()
+	return res, nil
+}
+
+func ipv6Range(input string) (IPv6Result, error) {
+	res := IPv6Result{Input: input, Type: "range"}
+	parts := strings.Split(ipv4RangeReplacer.Replace(input), "-")
+	if len(parts) != 2 {
+		return res, errors.New("range must be in start-end format")
+	}
+	start, err := parseIPv6(parts[0])
+	if err != nil {
+		return res, err
+	}
+	end, err := parseIPv6(parts[1])
+	if err != nil {
+		return res, err
+	}
+	start, end = start.WithZone(""), end.WithZone("")
+	if start.Compare(end) > 0 {
+		return res, errors.New("start IP must be less than or equal to end IP")
+	}
+	describeIPv6(&res, start)
+	res.RangeStart = start.String()
+	res.RangeEnd = end.String()
+	startInt, endInt := ipv6ToBig(start), ipv6ToBig(end)
+	total := new(big.Int).Sub(endInt, startInt)
+	res.Total = total.Add(total, big.NewInt(1)).String()
+	res.CIDR = strings.Join(ipv6RangeToCIDRs(startInt, endInt), ", ")
+	return res, nil
+}
+
+// parseIPv6 accepts plain, bracketed ([::1]) and zoned (fe80::1%eth0)
+// addresses, including embedded dotted IPv4 (::ffff:192.0.2.1).
+func parseIPv6(value string) (netip.Addr, error) {
+	clean := strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(value), "["), "]")
+	addr, err := netip.ParseAddr(clean)
+	if err != nil || !addr.Is6() {
+		return netip.Addr{}, fmt.Errorf("invalid IPv6 address: %s", strings.TrimSpace(value))
+	}
+	return addr, nil
+}
+
+func describeIPv6(res *IPv6Result, addr netip.Addr) {
+	raw := addr.As16()
+	groups := make([]string, 8)
+	for i := range groups {
+		groups[i] = fmt.Sprintf("%02x%02x", raw[i*2], raw[i*2+1])
+	}
+	res.Expanded = strings.Join(groups, ":")
+	res.Compressed = addr.String()
+	res.Scope = ipv6Scope(addr)
+	switch {
+	case addr.Is4In6():
+		res.EmbeddedIPv4 = addr.Unmap().String()
+	case nat64Prefix.Contains(addr):
+		res.EmbeddedIPv4 = netip.AddrFrom4([4]byte(raw[12:])).String()
+	case isZero(raw[:12]) && !addr.IsUnspecified() && !addr.IsLoopback():
+		// IPv4-compatible (deprecated ::a.b.c.d).
+		res.EmbeddedIPv4 = netip.AddrFrom4([4]byte(raw[12:])).String()
+	}
+	// Modified EUI-64 interface identifiers carry ff:fe in the middle of the
+	// low 64 bits; flipping the universal/local bit recovers the MAC.
+	if raw[11] == 0xff && raw[12] == 0xfe {
+		res.EUI64 = true
+		res.MAC = net.HardwareAddr{raw[8] ^ 0x02, raw[9], raw[10], raw[13], raw[14], raw[15]}.String()
+	}
+}
+
+func ipv6Scope(addr netip.Addr) string {
+	switch {
+	case addr.IsUnspecified():
+		return "unspecified"
+	case addr.IsLoopback():
+		return "loopback"
+	case addr.Is4In6():
+		return "ipv4-mapped"
+	case addr.IsLinkLocalUnicast():
+		return "link-local"
+	case addr.IsMulticast():
+		return "multicast"
+	case addr.IsPrivate():
+		return "unique-local"
+	case ipv6DocumentationNet.Contains(addr):
+		return "documentation"
+	case addr.IsGlobalUnicast():
+		return "global"
+	}
+	return "reserved"
+}
+
+func isZero(b []byte) bool {
+	for _, v := range b {
+		if v != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func ipv6ToBig(addr netip.Addr) *big.Int {
+	raw := addr.As16()
+	return new(big.Int).SetBytes(raw[:])
+}
+
+func bigToIPv6(v *big.Int) netip.Addr {
+	var raw [16]byte
+	v.FillBytes(raw[:])
+	return netip.AddrFrom16(raw)
+}
+
+// ipv6RangeToCIDRs splits [start, end] into the fewest aligned prefixes.
+func ipv6RangeToCIDRs(start, end *big.Int) []string {
+	var cidrs []string
+	one := big.NewInt(1)
+	cur := new(big.Int).Set(start)
+	for cur.Cmp(end) <= 0 {
+		hostBits := 128
+		if cur.Sign() != 0 {
+			hostBits = int(cur.TrailingZeroBits())
+		}
+		remaining := new(big.Int).Sub(end, cur)
+		remaining.Add(remaining, one)
+		for hostBits > 0 && new(big.Int).Lsh(one, uint(hostBits)).Cmp(remaining) > 0 {
+			hostBits--
+		}
+		cidrs = append(cidrs, fmt.Sprintf("%s/%d", bigToIPv6(cur), 128-hostBits))
+		cur.Add(cur, new(big.Int).Lsh(one, uint(hostBits)))
+	}
+	return cidrs
+}
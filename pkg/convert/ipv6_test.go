@@ -0,0 +1,56 @@
+package convert
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIPv6Info(t *testing.T) {
+	res, err := IPv6Info("2001:DB8:0:0:0:0:0:1")
+	require.NoError(t, err)
+	require.Equal(t, "single", res.Type)
+	require.Equal(t, "2001:0db8:0000:0000:0000:0000:0000:0001", res.Expanded)
+	require.Equal(t, "2001:db8::1", res.Compressed)
+	require.Equal(t, "documentation", res.Scope)
+	require.Equal(t, "42540766411282592856903984951653826561", res.Integer)
+
+	res, err = IPv6Info("fe80::0211:22ff:fe33:4455%eth0")
+	require.NoError(t, err)
+	require.Equal(t, "link-local", res.Scope)
+	require.Equal(t, "eth0", res.Zone)
+	require.True(t, res.EUI64)
+	require.Equal(t, "00:11:22:33:44:55", res.MAC)
+
+	res, err = IPv6Info("::ffff:192.0.2.1")
+	require.NoError(t, err)
+	require.Equal(t, "192.0.2.1", res.EmbeddedIPv4)
+	require.Equal(t, "ipv4-mapped", res.Scope)
+
+	res, err = IPv6Info("64:ff9b::c000:201")
+	require.NoError(t, err)
+	require.Equal(t, "192.0.2.1", res.EmbeddedIPv4)
+
+	res, err = IPv6Info("2001:db8:abcd:12::1/64")
+	require.NoError(t, err)
+	require.Equal(t, "network", res.Type)
+	require.Equal(t, "2001:db8:abcd:12::/64", res.CIDR)
+	require.Equal(t, "2001:db8:abcd:12::", res.RangeStart)
+	require.Equal(t, "2001:db8:abcd:12:ffff:ffff:ffff:ffff", res.RangeEnd)
+	require.Equal(t, "18446744073709551616", res.Total)
+
+	res, err = IPv6Info("::/0")
+	require.NoError(t, err)
+	require.Equal(t, "340282366920938463463374607431768211456", res.Total)
+
+	res, err = IPv6Info("2001:db8::1 - 2001:db8::4")
+	require.NoError(t, err)
+	require.Equal(t, "range", res.Type)
+	require.Equal(t, "4", res.Total)
+	require.Equal(t, "2001:db8::1/128, 2001:db8::2/127, 2001:db8::4/128", res.CIDR)
+
+	for _, bad := range []string{"", "1.2.3.4", "2001:db8::g", "2001:db8::/129", "2001:db8::2 - 2001:db8::1"} {
+		_, err = IPv6Info(bad)
+		require.Error(t, err, bad)
+	}
+}
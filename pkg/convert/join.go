@@ -0,0 +1,160 @@
+package convert
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"strconv"
+	"strings"
+)
+
+const (
+	JoinInner = "inner"
+	JoinLeft  = "left"
+)
+
+// JoinDocuments joins two arrays of objects on the values at onLeftPath and
+// onRightPath (dotted paths such as "user.id" or "tags.0"). Either side may
+// be any format ConvertFormats reads into JSON; it is detected automatically.
+// Matching right fields are merged into each left record; a right field whose
+// name is taken by a different left value is stored as "<name>_right".
+// joinType is "inner" (default) or "left", which keeps unmatched left
+// records as they are. Keys compare by their text, so 1 matches "1".
+func JoinDocuments(left, right, onLeftPath, onRightPath, joinType string) (string, error) {
+	if onRightPath == "" {
+		onRightPath = onLeftPath
+	}
+	if onLeftPath == "" {
+		return "", errors.New("join path is required")
+	}
+	joinType = strings.ToLower(strings.TrimSpace(joinType))
+	if joinType == "" {
+		joinType = JoinInner
+	}
+	if joinType != JoinInner && joinType != JoinLeft {
+		return "", fmt.Errorf("unsupported join type %q", joinType)
+	}
+	leftRows, err := joinRecords(left, "left")
+	if err != nil {
+		return "", err
+	}
+	rightRows, err := joinRecords(right, "right")
+	if err != nil {
+		return "", err
+	}
+	index := map[string][]map[string]any{}
+	for _, row := range rightRows {
+		if key, ok := lookupPath(row, onRightPath); ok && key != nil {
+			id := exprText(key)
+			index[id] = append(index[id], row)
+		}
+	}
+	out := make([]any, 0, len(leftRows))
+	for _, row := range leftRows {
+		var matches []map[string]any
+		if key, ok := lookupPath(row, onLeftPath); ok && key != nil {
+			matches = index[exprText(key)]
+		}
+		if len(matches) == 0 {
+			if joinType == JoinLeft {
+				out = append(out, row)
+			}
+			continue
+		}
+		for _, match := range matches {
+			merged := maps.Clone(row)
+			for k, v := range match {
+				existing, taken := merged[k]
+				if taken && exprText(existing) != exprText(v) {
+					k += "_right"
+				}
+				merged[k] = v
+			}
+			out = append(out, merged)
+		}
+	}
+	return encodeJSON(out)
+}
+
+// joinRecords reads a document as an array of objects.
+func joinRecords(input, side string) ([]map[string]any, error) {
+	data, err := decodeDocument(input)
+	if err != nil {
+		return nil, fmt.Errorf("%s document: %w", side, err)
+	}
+	items, ok := data.([]any)
+	if !ok {
+		return nil, fmt.Errorf("%s document must be an array of objects", side)
+	}
+	rows := make([]map[string]any, len(items))
+	for i, item := range items {
+		obj, ok := item.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("%s document: item %d is not an object", side, i+1)
+		}
+		rows[i] = obj
+	}
+	return rows, nil
+}
+
+// decodeDocument decodes JSON, or any format ConvertFormats reads after
+// detecting it, into a generic value with json.Number numbers.
+func decodeDocument(input string) (any, error) {
+	_, doc, err := decodeDocumentNode(input)
+	if err != nil {
+		return nil, err
+	}
+	return doc.value(), nil
+}
+
+// decodeDocumentNode is decodeDocument keeping key order, and also returns
+// the format the input was read from.
+func decodeDocumentNode(input string) (string, *docNode, error) {
+	trimmed := strings.TrimSpace(input)
+	if trimmed == "" {
+		return "", nil, errors.New("document is empty")
+	}
+	format := formatJSON
+	if !json.Valid([]byte(trimmed)) {
+		var err error
+		if format, _, err = DetectFormat(trimmed); err != nil {
+			return "", nil, err
+		}
+		adapter, ok := lookupAdapter(format)
+		if !ok || adapter.ToJSON == nil {
+			return "", nil, fmt.Errorf("%s cannot convert to JSON", format)
+		}
+		if trimmed, err = adapter.ToJSON(trimmed); err != nil {
+			return "", nil, err
+		}
+	}
+	doc, err := parseJSONDoc(trimmed)
+	if err != nil {
+		return "", nil, err
+	}
+	return format, doc, nil
+}
+
+// lookupPath follows a dotted path through objects and arrays.
+func lookupPath(v any, path string) (any, bool) {
+	for _, part := range strings.Split(path, ".") {
+		switch node := v.(type) {
+		case map[string]any:
+			next, ok := node[part]
+			if !ok {
+				return nil, false
+			}
+			v = next
+		case []any:
+			i, err := strconv.Atoi(part)
+			if err != nil || i < 0 || i >= len(node) {
+				return nil, false
+			}
+			v = node[i]
+		default:
+			return nil, false
+		}
+	}
+	return v, true
+}
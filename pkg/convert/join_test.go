@@ -0,0 +1,46 @@
+package convert
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestJoinDocuments(t *testing.T) {
+	users := `[{"id":1,"name":"Ann"},{"id":2,"name":"Bob"},{"id":3,"name":"Cy"}]`
+	orders := "order,user,name\nA1,1,lamp\nA2,1,desk\nB1,2,chair\n"
+
+	out, err := JoinDocuments(users, orders, "id", "user", "")
+	require.NoError(t, err)
+	require.JSONEq(t, `[
+		{"id":1,"name":"Ann","order":"A1","user":1,"name_right":"lamp"},
+		{"id":1,"name":"Ann","order":"A2","user":1,"name_right":"desk"},
+		{"id":2,"name":"Bob","order":"B1","user":2,"name_right":"chair"}
+	]`, out)
+
+	out, err = JoinDocuments(users, "- profile: {uid: 3}\n  city: Oslo\n", "id", "profile.uid", JoinLeft)
+	require.NoError(t, err)
+	require.JSONEq(t, `[
+		{"id":1,"name":"Ann"},
+		{"id":2,"name":"Bob"},
+		{"id":3,"name":"Cy","profile":{"uid":3},"city":"Oslo"}
+	]`, out)
+
+	_, err = JoinDocuments(users, orders, "id", "user", "outer")
+	require.Error(t, err)
+	_, err = JoinDocuments(`{"id":1}`, orders, "id", "user", "")
+	require.Error(t, err)
+	_, err = JoinDocuments(users, orders, "", "", "")
+	require.Error(t, err)
+}
+
+func TestLookupPath(t *testing.T) {
+	doc := map[string]any{"a": map[string]any{"b": []any{"x", "y"}}}
+	v, ok := lookupPath(doc, "a.b.1")
+	require.True(t, ok)
+	require.Equal(t, "y", v)
+	_, ok = lookupPath(doc, "a.c")
+	require.False(t, ok)
+	_, ok = lookupPath(doc, "a.b.5")
+	require.False(t, ok)
+}
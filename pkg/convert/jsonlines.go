@@ -0,0 +1,64 @@
+package convert
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// JSONLinesToJSON collects newline-delimited JSON records into an array,
+// keeping the key order of each. Blank lines are skipped.
+func JSONLinesToJSON(input string) (string, error) {
+	records := &docNode{kind: docArray}
+	sc := bufio.NewScanner(strings.NewReader(input))
+	sc.Buffer(make([]byte, 0, 64*1024), len(input)+1)
+	line := 0
+	for sc.Scan() {
+		line++
+		text := strings.TrimSpace(sc.Text())
+		if text == "" {
+			continue
+		}
+		dec := json.NewDecoder(strings.NewReader(text))
+		record, err := decodeJSONRecord(dec)
+		if err != nil {
+			return "", fmt.Errorf("line %d: %w", line, err)
+		}
+		if dec.More() {
+			return "", fmt.Errorf("line %d: multiple values on one line", line)
+		}
+		records.items = append(records.items, record)
+	}
+	if err := sc.Err(); err != nil {
+		return "", err
+	}
+	return encodeDoc(records)
+}
+
+// JSONToJSONLines writes each element of a JSON array as one compact line.
+// Any other value becomes a single line.
+func JSONToJSONLines(input string) (string, error) {
+	return jsonToJSONLinesWithOptions(input, NewConvertOptions())
+}
+
+// jsonToJSONLinesWithOptions keeps the key order of the input unless
+// SortKeys is set.
+func jsonToJSONLinesWithOptions(input string, o ConvertOptions) (string, error) {
+	doc, err := parseJSONDoc(input)
+	if err != nil {
+		return "", err
+	}
+	if o.SortKeys {
+		doc.sortKeys(sortedNames)
+	}
+	items := []*docNode{doc}
+	if doc.kind == docArray {
+		items = doc.items
+	}
+	lines := make([]string, len(items))
+	for i, item := range items {
+		lines[i] = string(item.appendJSON(nil))
+	}
+	return strings.Join(lines, "\n"), nil
+}
@@ -0,0 +1,33 @@
+package convert
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONLinesToJSON(t *testing.T) {
+	out, err := JSONLinesToJSON("{\"level\":\"info\",\"n\":1}\n\n{\"level\":\"warn\",\"n\":12345678901234567890}\n")
+	require.NoError(t, err)
+	require.JSONEq(t, `[{"level":"info","n":1},{"level":"warn","n":12345678901234567890}]`, out)
+	require.Contains(t, out, "12345678901234567890")
+
+	_, err = JSONLinesToJSON("{\"a\":1}\n{bad}")
+	require.ErrorContains(t, err, "line 2")
+	_, err = JSONLinesToJSON(`{"a":1} {"b":2}`)
+	require.Error(t, err)
+}
+
+func TestJSONToJSONLines(t *testing.T) {
+	out, err := JSONToJSONLines(`[{"b":2,"a":"<x>"},[1,2],3]`)
+	require.NoError(t, err)
+	require.Equal(t, "{\"b\":2,\"a\":\"<x>\"}\n[1,2]\n3", out)
+
+	single, err := JSONToJSONLines(`{"a": {"b": 1}}`)
+	require.NoError(t, err)
+	require.Equal(t, `{"a":{"b":1}}`, single)
+
+	back, err := ConvertFormats("NDJSON", "JSON", out)
+	require.NoError(t, err)
+	require.JSONEq(t, `[{"a":"<x>","b":2},[1,2],3]`, back)
+}
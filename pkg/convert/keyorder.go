@@ -0,0 +1,284 @@
+package convert
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/linzeyan/transform-go/pkg/common"
+	"github.com/pelletier/go-toml/v2"
+	"github.com/pelletier/go-toml/v2/unstable"
+)
+
+// yamlToJSONWithOptions reads YAML into JSON, keeping the document's key
+// order unless SortKeys is set; YAMLDocuments says how a stream of several
+// documents is read.
+func yamlToJSONWithOptions(input string, o ConvertOptions) (string, error) {
+	var arena docArena
+	doc, err := docFromYAMLStream(&arena, input, o)
+	if err != nil {
+		return "", err
+	}
+	if o.SortKeys {
+		doc.sortKeys(sortedNames)
+	}
+	out, err := docToJSON(doc, "  ")
+	if err != nil {
+		return "", err
+	}
+	return out + "\n", nil
+}
+
+// tomlToJSONWithOptions reads TOML into JSON, keeping the document's key
+// order unless SortKeys is set: keys come in the order they first appear,
+// whether in a key/value pair, a dotted key or a table header.
+func tomlToJSONWithOptions(input string, o ConvertOptions) (string, error) {
+	switch o.TOMLDatetimes {
+	case "", TOMLDatetimesString, TOMLDatetimesTagged:
+	default:
+		return "", fmt.Errorf("unsupported TOML datetimes mode %q", o.TOMLDatetimes)
+	}
+	data := map[string]any{}
+	if err := toml.Unmarshal([]byte(input), &data); err != nil {
+		return "", err
+	}
+	if o.TOMLDatetimes != TOMLDatetimesString {
+		tagTOMLDatetimes(data)
+	}
+	order, floats, err := tomlLayout([]byte(input))
+	if err != nil {
+		return "", err
+	}
+	if o.SortKeys {
+		order = nil
+	}
+	var arena docArena
+	doc, err := docFromValue(&arena, data, "", order)
+	if err != nil {
+		return "", err
+	}
+	doc.setNumbers("", floats)
+	out, err := docToJSON(doc, "  ")
+	if err != nil {
+		return "", err
+	}
+	return out + "\n", nil
+}
+
+// tomlToPlainJSON reads TOML into JSON with datetimes as strings, for
+// JSON that is read as plain values rather than written out again.
+func tomlToPlainJSON(input string) (string, error) {
+	return tomlToJSONWithOptions(input, NewConvertOptions(WithTOMLDatetimes(TOMLDatetimesString)))
+}
+
+// tomlLayout lists the keys of every table of a TOML document in the
+// order they first appear, by the paths docFromValue looks them up by, and
+// the literals of floats a float64 would round, by the same paths.
+func tomlLayout(input []byte) (order map[string][]string, floats map[string]string, err error) {
+	order = map[string][]string{}
+	floats = map[string]string{}
+	seen := map[string]bool{}
+	tables := map[string]int{} // elements of each array of tables so far
+	add := func(path, key string) string {
+		child := orderPath(path, key)
+		if !seen[child] {
+			seen[child] = true
+			order[path] = append(order[path], key)
+		}
+		// A header or dotted key through an array of tables goes into its
+		// last element.
+		if n := tables[child]; n > 0 {
+			return orderIndex(child, n-1)
+		}
+		return child
+	}
+	var value func(path string, v *unstable.Node)
+	keyValue := func(path string, kv *unstable.Node) {
+		for key := kv.Key(); key.Next(); {
+			path = add(path, string(key.Node().Data))
+		}
+		value(path, kv.Value())
+	}
+	value = func(path string, v *unstable.Node) {
+		switch v.Kind {
+		case unstable.Float:
+			lit := strings.TrimPrefix(strings.ReplaceAll(string(v.Data), "_", ""), "+")
+			if numberPattern().MatchString(lit) && !common.ExactNumber(json.Number(lit)) {
+				floats[path] = lit
+			}
+		case unstable.InlineTable:
+			for it := v.Children(); it.Next(); {
+				keyValue(path, it.Node())
+			}
+		case unstable.Array:
+			i := 0
+			for it := v.Children(); it.Next(); {
+				if it.Node().Kind != unstable.Comment {
+					value(orderIndex(path, i), it.Node())
+					i++
+				}
+			}
+		}
+	}
+
+	var p unstable.Parser
+	p.Reset(input)
+	current := ""
+	for p.NextExpression() {
+		expr := p.Expression()
+		switch expr.Kind {
+		case unstable.KeyValue:
+			keyValue(current, expr)
+		case unstable.Table:
+			current = ""
+			for key := expr.Key(); key.Next(); {
+				current = add(current, string(key.Node().Data))
+			}
+		case unstable.ArrayTable:
+			current = ""
+			for key := expr.Key(); key.Next(); {
+				name := string(key.Node().Data)
+				if key.IsLast() {
+					add(current, name)
+					current = orderPath(current, name)
+					tables[current]++
+					current = orderIndex(current, tables[current]-1)
+				} else {
+					current = add(current, name)
+				}
+			}
+		}
+	}
+	return order, floats, p.Error()
+}
+
+// setNumbers puts back the literals of numbers, by the paths docFromValue
+// builds n with, that decoding rounded.
+func (n *docNode) setNumbers(path string, literals map[string]string) {
+	if len(literals) == 0 {
+		return
+	}
+	switch n.kind {
+	case docNumber:
+		if lit, ok := literals[path]; ok {
+			n.text = lit
+		}
+	case docArray:
+		for i, item := range n.items {
+			item.setNumbers(orderIndex(path, i), literals)
+		}
+	case docObject:
+		for i, k := range n.keys {
+			n.items[i].setNumbers(orderPath(path, k.name), literals)
+		}
+	}
+}
+
+// tomlNumbers builds the value go-toml writes for a document. go-toml
+// sorts the keys of maps but writes struct fields in declaration order, so
+// unless the keys are to be sorted objects become structs tagged with
+// their key names; objects with keys a tag cannot spell stay maps. It
+// cannot write a number a float64 would round, so those go in as marker
+// strings that replace swaps for their literals afterwards. TOML integers
+// are 64-bit, so a wider one is an error rather than a float that reads
+// back as a different type.
+type tomlNumbers struct {
+	sorted   bool // objects may stay maps
+	nonce    string
+	literals []string
+}
+
+func newTOMLNumbers(sorted bool) *tomlNumbers {
+	return &tomlNumbers{sorted: sorted, nonce: strconv.FormatUint(rand.Uint64(), 36)}
+}
+
+func (t *tomlNumbers) marker(i int) string {
+	return "transform-go-number-" + t.nonce + "-" + strconv.Itoa(i)
+}
+
+func (t *tomlNumbers) value(n *docNode) (any, error) {
+	switch n.kind {
+	case docArray:
+		out := make([]any, len(n.items))
+		for i, item := range n.items {
+			v, err := t.value(item)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = v
+		}
+		return out, nil
+	case docObject:
+		if v, ok := tomlDatetime(n); ok {
+			return v, nil
+		}
+		values := make([]any, len(n.items))
+		for i, item := range n.items {
+			v, err := t.value(item)
+			if err != nil {
+				return nil, err
+			}
+			values[i] = v
+		}
+		if t.sorted {
+			return tomlMap(n.keys, values), nil
+		}
+		fields := make([]reflect.StructField, 0, len(n.keys))
+		for i, k := range n.keys {
+			if k.name == "" || k.name == "-" || strings.Contains(k.name, ",") {
+				return tomlMap(n.keys, values), nil
+			}
+			typ := reflect.TypeOf(values[i])
+			if typ == nil {
+				typ = reflect.TypeFor[any]()
+			}
+			fields = append(fields, reflect.StructField{
+				Name: "F" + strconv.Itoa(i),
+				Type: typ,
+				Tag:  reflect.StructTag("toml:" + strconv.Quote(k.name)),
+			})
+		}
+		v := reflect.New(reflect.StructOf(fields)).Elem()
+		for i, value := range values {
+			if value != nil {
+				v.Field(i).Set(reflect.ValueOf(value))
+			}
+		}
+		return v.Interface(), nil
+	case docNumber:
+		switch v := common.NumberValue(json.Number(n.text)).(type) {
+		case int64, float64:
+			return v, nil
+		}
+		if !strings.ContainsAny(n.text, ".eE") {
+			return nil, fmt.Errorf("integer %s does not fit in a 64-bit TOML integer", n.text)
+		}
+		t.literals = append(t.literals, n.text)
+		return t.marker(len(t.literals) - 1), nil
+	}
+	return n.value(), nil
+}
+
+// replace swaps the markers in go-toml's output for their literals.
+func (t *tomlNumbers) replace(out string) string {
+	if len(t.literals) == 0 {
+		return out
+	}
+	pairs := make([]string, 0, 4*len(t.literals))
+	for i, lit := range t.literals {
+		m := t.marker(i)
+		pairs = append(pairs, "'"+m+"'", lit, `"`+m+`"`, lit)
+	}
+	return strings.NewReplacer(pairs...).Replace(out)
+}
+
+func tomlMap(keys []docKey, values []any) map[string]any {
+	out := make(map[string]any, len(keys))
+	for i, k := range keys {
+		out[k.name] = values[i]
+	}
+	return out
+}
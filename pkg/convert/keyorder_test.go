@@ -0,0 +1,193 @@
+package convert
+
+import (
+	"testing"
+
+	"github.com/linzeyan/transform-go/pkg/convert/transformtest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvertFormats_KeepsKeyOrder(t *testing.T) {
+	yamlInput := "zeta: 1\nalpha:\n  y: 2\n  b: 3\nlist:\n  - {q: 1, a: 2}\n"
+	out, err := ConvertFormatsWithOptions(formatYAML, formatJSON, yamlInput, WithSortKeys(false))
+	require.NoError(t, err)
+	require.Equal(t, `{
+  "zeta": 1,
+  "alpha": {
+    "y": 2,
+    "b": 3
+  },
+  "list": [
+    {
+      "q": 1,
+      "a": 2
+    }
+  ]
+}`, out)
+
+	out, err = ConvertFormatsWithOptions(formatYAML, formatTOML, yamlInput, WithSortKeys(false))
+	require.NoError(t, err)
+	require.Equal(t, "zeta = 1\n\n[alpha]\ny = 2\nb = 3\n\n[[list]]\nq = 1\na = 2\n", out)
+
+	// Sorting is opt-in.
+	out, err = ConvertFormatsWithOptions(formatYAML, formatTOML, yamlInput, WithSortKeys(true))
+	require.NoError(t, err)
+	require.Equal(t, "zeta = 1\n\n[alpha]\nb = 3\ny = 2\n\n[[list]]\na = 2\nq = 1\n", out)
+}
+
+func TestTOMLToJSON_KeyOrder(t *testing.T) {
+	input := `zeta = 1
+site.name = 'x'
+site.age = 2
+inline = {k = 1, c = [{z = 1, a = 2}]}
+
+[alpha]
+y = 2
+b = 3
+
+[[list]]
+q = 1
+a = 2
+
+[list.sub]
+k = 1
+c = 2
+
+[[list]]
+z = 1
+`
+	out, err := tomlToJSONWithOptions(input, NewConvertOptions(WithSortKeys(false)))
+	require.NoError(t, err)
+	require.Equal(t, `{"zeta":1,"site":{"name":"x","age":2},"inline":{"k":1,"c":[{"z":1,"a":2}]},`+
+		`"alpha":{"y":2,"b":3},"list":[{"q":1,"a":2,"sub":{"k":1,"c":2}},{"z":1}]}`, minifyJSON(t, out))
+
+	// Written back, only the dotted keys and inline tables change shape.
+	back, err := jsonToTOMLWithOptions(out, NewConvertOptions(WithSortKeys(false)))
+	require.NoError(t, err)
+	require.Equal(t, `zeta = 1
+
+[site]
+name = 'x'
+age = 2
+
+[inline]
+k = 1
+
+[[inline.c]]
+z = 1
+a = 2
+
+[alpha]
+y = 2
+b = 3
+
+[[list]]
+q = 1
+a = 2
+
+[list.sub]
+k = 1
+c = 2
+
+[[list]]
+z = 1
+`, back)
+}
+
+func TestJSONToTOML_OrderedKeysNeedingMaps(t *testing.T) {
+	// Keys a struct tag cannot spell fall back to sorted order.
+	out, err := jsonToTOMLWithOptions(`{"b":{"z,y":1,"a":2},"a":"x\"y"}`, NewConvertOptions(WithSortKeys(false)))
+	require.NoError(t, err)
+	require.Equal(t, "a = 'x\"y'\n\n[b]\na = 2\n'z,y' = 1\n", out)
+
+	_, err = jsonToTOMLWithOptions(`[1]`, NewConvertOptions(WithSortKeys(false)))
+	require.ErrorContains(t, err, "TOML root must be an object")
+}
+
+func TestDocToJSON_Escapes(t *testing.T) {
+	doc, err := parseJSONDoc(`{"html":"<a href=\"x\">&</a>","ctl":"\u0001\b\f\n\t\u2028","bad":null}`)
+	require.NoError(t, err)
+	out, err := docToJSON(doc, "")
+	require.NoError(t, err)
+	require.Equal(t, `{"html":"<a href=\"x\">&</a>","ctl":"\u0001\b\f\n\t\u2028","bad":null}`, minifyJSON(t, out))
+}
+
+func minifyJSON(t *testing.T, s string) string {
+	t.Helper()
+	out, err := normalizeJSONOutputWithOptions(s, true, NewConvertOptions())
+	require.NoError(t, err)
+	return out
+}
+
+func TestRoundTrip_UnsortedKeys(t *testing.T) {
+	unsorted := func(from, to string) func(string) (string, error) {
+		return func(s string) (string, error) { return ConvertFormatsWithOptions(from, to, s, WithSortKeys(false)) }
+	}
+	for _, name := range []string{formatYAML, formatTOML} {
+		t.Run(name, func(t *testing.T) {
+			transformtest.RoundTrip(t, unsorted(formatJSON, name), unsorted(name, formatJSON), roundTripConfigs[name])
+		})
+	}
+}
+
+func TestReaders_KeepKeyOrder(t *testing.T) {
+	nested := `{"b":"1","a":{"d":"x","c":"y"}}`
+	for _, name := range []string{
+		formatXML, formatTOON, formatProperties, formatQueryString, formatPlist, formatBPlist,
+		formatMsgPack, formatGoStruct, formatGraphQL, formatProtobuf, formatSchema,
+	} {
+		t.Run(name, func(t *testing.T) {
+			out, err := ConvertFormats(formatJSON, name, nested)
+			require.NoError(t, err)
+			back, err := ConvertFormats(name, formatJSON, out)
+			require.NoError(t, err)
+			order := jsonKeyOrder(back)
+			require.Equal(t, []string{"b", "a"}, order[""])
+			require.Equal(t, []string{"d", "c"}, order["a"])
+		})
+	}
+	flat := `{"b":"1","a":"2"}`
+	for _, name := range []string{formatDotenv, formatPropsXML, formatSQL} {
+		t.Run(name, func(t *testing.T) {
+			out, err := ConvertFormats(formatJSON, name, flat)
+			require.NoError(t, err)
+			back, err := ConvertFormats(name, formatJSON, out)
+			require.NoError(t, err)
+			require.Equal(t, []string{"b", "a"}, jsonKeyOrder(back)[""])
+		})
+	}
+	rows := `[{"b":"1","a":"2"},{"b":"3","a":"4"}]`
+	for _, name := range []string{formatCSV, formatMarkdownTable, formatHTMLTable, formatNDJSON, formatAvro} {
+		t.Run(name, func(t *testing.T) {
+			out, err := ConvertFormats(formatJSON, name, rows)
+			require.NoError(t, err)
+			back, err := ConvertFormats(name, formatJSON, out)
+			require.NoError(t, err)
+			require.Equal(t, []string{"b", "a"}, jsonKeyOrder(back)["[]"])
+		})
+	}
+
+	out, err := ConvertFormatsWithOptions(formatXML, formatJSON, "<root><b>1</b><a>2</a></root>", WithSortKeys(true))
+	require.NoError(t, err)
+	require.Equal(t, "{\n  \"a\": \"2\",\n  \"b\": \"1\"\n}", out)
+}
+
+func TestSchemaToJSON_PropertyOrder(t *testing.T) {
+	out, err := SchemaToJSON(`{"type":"object","properties":{"b":{"type":"string"},"a":{"type":"object","properties":{"d":{"type":"integer"},"c":{"type":"boolean"}}}}}`)
+	require.NoError(t, err)
+	require.Equal(t, "{\n  \"b\": \"\",\n  \"a\": {\n    \"d\": 0,\n    \"c\": false\n  }\n}\n", out)
+}
+
+func TestJSONToSchema_KeyOrder(t *testing.T) {
+	out, err := JSONToSchema(`{"b":1,"a":{"d":true,"c":"x"}}`)
+	require.NoError(t, err)
+	order := jsonKeyOrder(out)
+	require.Equal(t, []string{"properties", "required", "type"}, order[""])
+	require.Equal(t, []string{"b", "a"}, order["properties"])
+	require.Equal(t, []string{"d", "c"}, order["properties.a.properties"])
+	require.Contains(t, out, "\"required\": [\n    \"b\",\n    \"a\"\n  ]")
+
+	sorted, err := JSONToSchemaWithOptions(`{"b":1,"a":2}`, WithSortKeys(true))
+	require.NoError(t, err)
+	require.Equal(t, []string{"a", "b"}, jsonKeyOrder(sorted)["properties"])
+}
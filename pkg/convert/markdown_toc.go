@@ -0,0 +1,198 @@
+package convert
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/linzeyan/transform-go/pkg/common"
+)
+
+var (
+	markdownLinkRe       = common.LazyRegexp(`!?\[([^\]]*)\]\(\s*<?([^)\s>]*)>?(?:\s+"[^"]*")?\s*\)`)
+	markdownSchemeRe     = common.LazyRegexp(`^[a-zA-Z][a-zA-Z0-9+.-]*:`)
+	markdownInlineLinkRe = common.LazyRegexp(`!?\[([^\]]*)\]\([^)]*\)`)
+)
+
+// MarkdownHeading is an ATX heading with its GitHub-style anchor.
+type MarkdownHeading struct {
+	Level  int    `json:"level"`
+	Text   string `json:"text"`
+	Anchor string `json:"anchor"`
+	Line   int    `json:"line"`
+}
+
+// MarkdownLinkIssue is a link or heading that needs attention: a relative
+// link, an in-page link without a matching heading, or a duplicate anchor.
+type MarkdownLinkIssue struct {
+	Line   int    `json:"line"`
+	Kind   string `json:"kind"`
+	Text   string `json:"text,omitempty"`
+	Target string `json:"target"`
+}
+
+// MarkdownLinkReport lists the document headings and link issues.
+type MarkdownLinkReport struct {
+	Headings []MarkdownHeading   `json:"headings"`
+	Issues   []MarkdownLinkIssue `json:"issues"`
+}
+
+const (
+	MarkdownIssueRelative        = "relative"
+	MarkdownIssueMissingAnchor   = "missing-anchor"
+	MarkdownIssueDuplicateAnchor = "duplicate-anchor"
+)
+
+// MarkdownTOC renders a nested bullet list linking every heading, indented
+// relative to the shallowest heading in the document.
+func MarkdownTOC(input string) (string, error) {
+	headings := markdownHeadings(input)
+	if len(headings) == 0 {
+		return "", errors.New("no headings found")
+	}
+	minLevel := 6
+	for _, h := range headings {
+		minLevel = min(minLevel, h.Level)
+	}
+	var b strings.Builder
+	for _, h := range headings {
+		b.WriteString(strings.Repeat("  ", h.Level-minLevel))
+		fmt.Fprintf(&b, "- [%s](#%s)\n", h.Text, h.Anchor)
+	}
+	return b.String(), nil
+}
+
+// ShiftMarkdownHeadings promotes (negative delta) or demotes (positive
+// delta) every heading outside front matter and code blocks, clamped to
+// levels 1-6.
+func ShiftMarkdownHeadings(input string, delta int) (string, error) {
+	lines := strings.Split(strings.ReplaceAll(input, "\r\n", "\n"), "\n")
+	_, offset := markdownBodyLines(input)
+	inCodeBlock := false
+	for i := offset; i < len(lines); i++ {
+		line := lines[i]
+		trim := strings.TrimSpace(line)
+		if strings.HasPrefix(trim, "```") {
+			inCodeBlock = !inCodeBlock
+			continue
+		}
+		if inCodeBlock {
+			continue
+		}
+		level, text, ok := parseMarkdownHeading(trim)
+		if !ok {
+			continue
+		}
+		level = max(1, min(6, level+delta))
+		lines[i] = strings.TrimRight(strings.Repeat("#", level)+" "+text, " ")
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// CheckMarkdownLinks reports relative links, in-page links whose anchor
+// does not exist, and headings that share an anchor.
+func CheckMarkdownLinks(input string) (MarkdownLinkReport, error) {
+	report := MarkdownLinkReport{Headings: markdownHeadings(input), Issues: []MarkdownLinkIssue{}}
+	anchors := map[string]bool{}
+	seen := map[string]bool{}
+	for _, h := range report.Headings {
+		anchors[h.Anchor] = true
+		base := HeadingAnchor(h.Text)
+		if seen[base] {
+			report.Issues = append(report.Issues, MarkdownLinkIssue{
+				Line:   h.Line,
+				Kind:   MarkdownIssueDuplicateAnchor,
+				Text:   h.Text,
+				Target: "#" + base,
+			})
+		}
+		seen[base] = true
+	}
+	inCodeBlock := false
+	lines, offset := markdownBodyLines(input)
+	for i, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inCodeBlock = !inCodeBlock
+			continue
+		}
+		if inCodeBlock {
+			continue
+		}
+		for _, m := range markdownLinkRe().FindAllStringSubmatch(line, -1) {
+			target := m[2]
+			issue := MarkdownLinkIssue{Line: offset + i + 1, Text: m[1], Target: target}
+			switch {
+			case strings.HasPrefix(target, "#"):
+				if anchors[strings.ToLower(target[1:])] {
+					continue
+				}
+				issue.Kind = MarkdownIssueMissingAnchor
+			case target == "" || markdownSchemeRe().MatchString(target) || strings.HasPrefix(target, "//"):
+				continue
+			default:
+				issue.Kind = MarkdownIssueRelative
+			}
+			report.Issues = append(report.Issues, issue)
+		}
+	}
+	return report, nil
+}
+
+// markdownHeadings collects ATX headings outside front matter and fenced
+// code blocks and assigns anchors the way GitHub does, suffixing repeats
+// with -1, -2, ...
+func markdownHeadings(input string) []MarkdownHeading {
+	var headings []MarkdownHeading
+	counts := map[string]int{}
+	lines, offset := markdownBodyLines(input)
+	inCodeBlock := false
+	for i, line := range lines {
+		trim := strings.TrimSpace(line)
+		if strings.HasPrefix(trim, "```") {
+			inCodeBlock = !inCodeBlock
+			continue
+		}
+		if inCodeBlock {
+			continue
+		}
+		level, text, ok := parseMarkdownHeading(trim)
+		if !ok || text == "" {
+			continue
+		}
+		text = plainMarkdownText(text)
+		anchor := HeadingAnchor(text)
+		if n := counts[anchor]; n > 0 {
+			counts[anchor] = n + 1
+			anchor = fmt.Sprintf("%s-%d", anchor, n)
+		} else {
+			counts[anchor] = 1
+		}
+		headings = append(headings, MarkdownHeading{Level: level, Text: text, Anchor: anchor, Line: offset + i + 1})
+	}
+	return headings
+}
+
+// parseMarkdownHeading accepts "## Title" and "## Title ##" but not
+// "#hashtag" or more than six hashes.
+func parseMarkdownHeading(line string) (int, string, bool) {
+	level := markdownHeadingLevel(line)
+	if level == 0 || strings.HasPrefix(line[level:], "#") {
+		return 0, "", false
+	}
+	rest := line[level:]
+	if rest != "" && rest[0] != ' ' && rest[0] != '\t' {
+		return 0, "", false
+	}
+	text := strings.TrimSpace(rest)
+	if trimmed := strings.TrimRight(text, "#"); trimmed == "" || strings.HasSuffix(trimmed, " ") {
+		text = strings.TrimSpace(trimmed)
+	}
+	return level, text, true
+}
+
+// plainMarkdownText drops link targets and emphasis markers from heading
+// text so it can be reused as a link label.
+func plainMarkdownText(text string) string {
+	text = markdownInlineLinkRe().ReplaceAllString(text, "$1")
+	return strings.NewReplacer("**", "", "__", "", "`", "").Replace(text)
+}
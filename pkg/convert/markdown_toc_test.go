@@ -0,0 +1,39 @@
+package convert
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const sampleMarkdownDoc = "# Guide\n\n## Install `cli`\n\n```\n# not a heading\n```\n\n### Usage ###\n\n## Usage\n\nSee [usage](#usage), [setup](#setup), [docs](docs/README.md) and [site](https://example.com).\n"
+
+func TestMarkdownTOC(t *testing.T) {
+	out, err := MarkdownTOC(sampleMarkdownDoc)
+	require.NoError(t, err)
+	require.Equal(t, "- [Guide](#guide)\n  - [Install cli](#install-cli)\n    - [Usage](#usage)\n  - [Usage](#usage-1)\n", out)
+
+	_, err = MarkdownTOC("no headings\n#hashtag")
+	require.Error(t, err)
+}
+
+func TestShiftMarkdownHeadings(t *testing.T) {
+	out, err := ShiftMarkdownHeadings("# A\n```\n# code\n```\n###### B ##\ntext", 1)
+	require.NoError(t, err)
+	require.Equal(t, "## A\n```\n# code\n```\n###### B\ntext", out)
+
+	out, err = ShiftMarkdownHeadings("## A\n# B", -1)
+	require.NoError(t, err)
+	require.Equal(t, "# A\n# B", out)
+}
+
+func TestCheckMarkdownLinks(t *testing.T) {
+	report, err := CheckMarkdownLinks(sampleMarkdownDoc)
+	require.NoError(t, err)
+	require.Len(t, report.Headings, 4)
+	require.Equal(t, []MarkdownLinkIssue{
+		{Line: 11, Kind: MarkdownIssueDuplicateAnchor, Text: "Usage", Target: "#usage"},
+		{Line: 13, Kind: MarkdownIssueMissingAnchor, Text: "setup", Target: "#setup"},
+		{Line: 13, Kind: MarkdownIssueRelative, Text: "docs", Target: "docs/README.md"},
+	}, report.Issues)
+}
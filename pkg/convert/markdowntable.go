@@ -0,0 +1,253 @@
+package convert
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"unicode/utf8"
+)
+
+const formatMarkdownTable = "Markdown Table"
+
+// MarkdownTableOptions controls how JSONToMarkdownTableWithOptions writes a
+// table.
+type MarkdownTableOptions struct {
+	// Aligns sets the alignment of columns by name: "left", "right",
+	// "center" or "" for none. Columns it does not name are right aligned
+	// when every value in them is a number.
+	Aligns map[string]string
+	// SortKeys heads the columns of an array of objects in sorted order
+	// rather than the order their keys first appear.
+	SortKeys bool
+}
+
+// MarkdownTableToJSON reads the first GitHub-flavored table in input, which
+// may be a whole Markdown document, into an array of objects keyed by its
+// header. Cells are typed as CSV cells are, with \| read as a pipe and <br>
+// as a line break; rows are padded or cut to the header's width as GitHub
+// renders them. Empty or duplicate header names are made unique as HTML
+// table headers are.
+func MarkdownTableToJSON(input string) (string, error) {
+	header, _, rows, err := markdownTable(input)
+	if err != nil {
+		return "", err
+	}
+	names := htmlTableHeader([][]string{header})
+	out := make([]map[string]any, len(rows))
+	for i, row := range rows {
+		obj := make(map[string]any, len(names))
+		for j, name := range names {
+			cell := ""
+			if j < len(row) {
+				cell = row[j]
+			}
+			obj[name] = csvCellValue(strings.ReplaceAll(cell, "<br>", "\n"))
+		}
+		out[i] = obj
+	}
+	return tableJSON(names, out)
+}
+
+// MarkdownTableAligns returns the alignment of each column of the first
+// table in input, by the names MarkdownTableToJSON gives them, for
+// JSONToMarkdownTableWithOptions to write the table back as it was.
+func MarkdownTableAligns(input string) (map[string]string, error) {
+	header, aligns, _, err := markdownTable(input)
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]string, len(header))
+	for i, name := range htmlTableHeader([][]string{header}) {
+		out[name] = aligns[i]
+	}
+	return out, nil
+}
+
+// markdownTable finds the first table outside fenced code blocks: a row
+// followed by a delimiter row with as many cells, then the rows up to the
+// first blank line or line without a pipe.
+func markdownTable(input string) (header, aligns []string, rows [][]string, err error) {
+	lines := strings.Split(strings.ReplaceAll(input, "\r\n", "\n"), "\n")
+	inFence := false
+	for i := 0; i+1 < len(lines); i++ {
+		trim := strings.TrimSpace(lines[i])
+		if strings.HasPrefix(trim, "```") || strings.HasPrefix(trim, "~~~") {
+			inFence = !inFence
+			continue
+		}
+		if inFence || !strings.Contains(trim, "|") || !markdownTableSepRe().MatchString(strings.TrimSpace(lines[i+1])) {
+			continue
+		}
+		header = splitMarkdownRow(trim)
+		aligns = markdownTableAligns(splitMarkdownRow(strings.TrimSpace(lines[i+1])))
+		if len(aligns) != len(header) {
+			continue
+		}
+		for _, line := range lines[i+2:] {
+			line = strings.TrimSpace(line)
+			if line == "" || !strings.Contains(line, "|") {
+				break
+			}
+			rows = append(rows, splitMarkdownRow(line))
+		}
+		return header, aligns, rows, nil
+	}
+	return nil, nil, nil, errors.New("no Markdown table found")
+}
+
+// JSONToMarkdownTable writes an array of objects as a GitHub-flavored table.
+// See JSONToMarkdownTableWithOptions.
+func JSONToMarkdownTable(input string) (string, error) {
+	return JSONToMarkdownTableWithOptions(input, MarkdownTableOptions{})
+}
+
+// JSONToMarkdownTableWithOptions writes an array of objects as a table
+// headed by the union of their keys, or an array of arrays as a table
+// headed by its first row, with the columns padded to line up. Nested
+// values are written as compact JSON, as in CSV; pipes are escaped and line
+// breaks become <br>.
+func JSONToMarkdownTableWithOptions(input string, opts MarkdownTableOptions) (string, error) {
+	data, err := decodeJSONValue(input)
+	if err != nil {
+		return "", err
+	}
+	var items []any
+	switch val := data.(type) {
+	case []any:
+		items = val
+	case map[string]any:
+		items = []any{val}
+	default:
+		return "", errors.New("Markdown tables require an array of objects or arrays")
+	}
+
+	header := csvHeaderFor(items, tableKeyOrder(input, data, opts.SortKeys))
+	var values [][]any
+	for _, item := range items {
+		switch row := item.(type) {
+		case map[string]any:
+			record := make([]any, len(header))
+			for j, key := range header {
+				record[j] = row[key]
+			}
+			values = append(values, record)
+		case []any:
+			values = append(values, row)
+		default:
+			values = append(values, []any{row})
+		}
+	}
+	if header == nil {
+		if len(values) == 0 {
+			return "", errors.New("Markdown tables need a header row")
+		}
+		for _, cell := range values[0] {
+			header = append(header, csvCellString(cell))
+		}
+		values = values[1:]
+	}
+
+	width := len(header)
+	for _, row := range values {
+		width = max(width, len(row))
+	}
+	cells := make([][]string, len(values)+1)
+	cells[0] = make([]string, width)
+	for j, name := range header {
+		cells[0][j] = markdownCell(name)
+	}
+	aligns := make([]string, width)
+	for j := range aligns {
+		numeric := len(values) > 0
+		for _, row := range values {
+			if j < len(row) && row[j] != nil && !isJSONNumber(row[j]) {
+				numeric = false
+			}
+		}
+		if numeric {
+			aligns[j] = "right"
+		}
+		if j < len(header) {
+			if align, ok := opts.Aligns[header[j]]; ok {
+				aligns[j] = align
+			}
+		}
+	}
+	for i, row := range values {
+		cells[i+1] = make([]string, width)
+		for j, cell := range row {
+			cells[i+1][j] = markdownCell(csvCellString(cell))
+		}
+	}
+
+	widths := make([]int, width)
+	for j := range widths {
+		widths[j] = 3
+		for _, row := range cells {
+			widths[j] = max(widths[j], utf8.RuneCountInString(row[j]))
+		}
+	}
+	var b strings.Builder
+	writeRow := func(row []string, aligned bool) {
+		b.WriteString("|")
+		for j, cell := range row {
+			pad := widths[j] - utf8.RuneCountInString(cell)
+			left := 0
+			if aligned {
+				switch aligns[j] {
+				case "right":
+					left = pad
+				case "center":
+					left = pad / 2
+				}
+			}
+			b.WriteString(" " + strings.Repeat(" ", left) + cell + strings.Repeat(" ", pad-left) + " |")
+		}
+		b.WriteString("\n")
+	}
+	writeRow(cells[0], false)
+	separators := make([]string, width)
+	for j, align := range aligns {
+		dashes := widths[j]
+		switch align {
+		case "left":
+			separators[j] = ":" + strings.Repeat("-", dashes-1)
+		case "right":
+			separators[j] = strings.Repeat("-", dashes-1) + ":"
+		case "center":
+			separators[j] = ":" + strings.Repeat("-", dashes-2) + ":"
+		default:
+			separators[j] = strings.Repeat("-", dashes)
+		}
+	}
+	writeRow(separators, false)
+	for _, row := range cells[1:] {
+		writeRow(row, true)
+	}
+	return strings.TrimSuffix(b.String(), "\n"), nil
+}
+
+func isJSONNumber(v any) bool {
+	switch v.(type) {
+	case json.Number, float64:
+		return true
+	}
+	return false
+}
+
+// markdownCell escapes what would end a cell or a row.
+func markdownCell(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	s = strings.ReplaceAll(s, "\r\n", "<br>")
+	return strings.ReplaceAll(s, "\n", "<br>")
+}
+
+// isMarkdownTable reports whether input opens with a table's header and
+// delimiter rows.
+func isMarkdownTable(input string) bool {
+	first, rest, _ := strings.Cut(input, "\n")
+	second, _, _ := strings.Cut(rest, "\n")
+	first, second = strings.TrimSpace(first), strings.TrimSpace(second)
+	return strings.Contains(first, "|") && strings.Contains(second, "-") && markdownTableSepRe().MatchString(second) &&
+		len(splitMarkdownRow(first)) == len(splitMarkdownRow(second))
+}
@@ -0,0 +1,76 @@
+package convert
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarkdownTableToJSON(t *testing.T) {
+	doc := "# Team\n\n```\n| not | a table |\n| --- | --- |\n```\n\n" +
+		"| Name | Age | Note |\n" +
+		"| :--- | --: | :---: |\n" +
+		"| Ricky | 27 | likes `a\\|b`<br>and wasm |\n" +
+		"| Alice | 30 |\n" +
+		"| Bob | 41 | x | extra |\n" +
+		"\nTrailing text | with a pipe\n"
+	out, err := MarkdownTableToJSON(doc)
+	require.NoError(t, err)
+	require.JSONEq(t, `[
+		{"Name":"Ricky","Age":27,"Note":"likes `+"`a|b`"+`\nand wasm"},
+		{"Name":"Alice","Age":30,"Note":""},
+		{"Name":"Bob","Age":41,"Note":"x"}
+	]`, out)
+
+	aligns, err := MarkdownTableAligns(doc)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"Name": "left", "Age": "right", "Note": "center"}, aligns)
+
+	out, err = MarkdownTableToJSON("id | | id\n-|-|-\n1 | 2 | 3")
+	require.NoError(t, err)
+	require.JSONEq(t, `[{"id":1,"column2":2,"id_2":3}]`, out)
+
+	_, err = MarkdownTableToJSON("no | table\nhere")
+	require.ErrorContains(t, err, "no Markdown table found")
+}
+
+func TestJSONToMarkdownTable(t *testing.T) {
+	input := `[{"name":"Ricky","age":27,"tags":["go"]},{"name":"Al|ice","note":"a\nb"}]`
+	out, err := JSONToMarkdownTable(input)
+	require.NoError(t, err)
+	require.Equal(t, "| name    | age | tags   | note   |\n"+
+		"| ------- | --: | ------ | ------ |\n"+
+		"| Ricky   |  27 | [\"go\"] |        |\n"+
+		"| Al\\|ice |     |        | a<br>b |", out)
+
+	out, err = JSONToMarkdownTableWithOptions(input, MarkdownTableOptions{SortKeys: true})
+	require.NoError(t, err)
+	require.Equal(t, "| age | name    | note   | tags   |\n"+
+		"| --: | ------- | ------ | ------ |\n"+
+		"|  27 | Ricky   |        | [\"go\"] |\n"+
+		"|     | Al\\|ice | a<br>b |        |", out)
+
+	out, err = JSONToMarkdownTable(`[["id","name"],[1,"alpha"]]`)
+	require.NoError(t, err)
+	require.Equal(t, "| id  | name  |\n| --: | ----- |\n|   1 | alpha |", out)
+
+	_, err = JSONToMarkdownTable(`"text"`)
+	require.Error(t, err)
+	_, err = JSONToMarkdownTable(`[]`)
+	require.ErrorContains(t, err, "header")
+}
+
+func TestMarkdownTable_AlignmentRoundTrip(t *testing.T) {
+	table := "| Name  | Age | City   |\n" +
+		"| :---- | --: | :----: |\n" +
+		"| Ricky |  27 | Taipei |\n" +
+		"| Al    |   3 |  Oslo  |"
+	data, err := MarkdownTableToJSON(table)
+	require.NoError(t, err)
+	aligns, err := MarkdownTableAligns(table)
+	require.NoError(t, err)
+	out, err := JSONToMarkdownTableWithOptions(data, MarkdownTableOptions{Aligns: aligns})
+	require.NoError(t, err)
+	// Columns come back in their order, each with its alignment.
+	require.Equal(t, table, out)
+}
@@ -0,0 +1,89 @@
+package convert
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	MergeDeep   = "deep"
+	MergeAppend = "append"
+	MergePatch  = "merge-patch"
+)
+
+// MergeDocuments overlays docs from left to right, the way Helm layers
+// values files, and returns JSON. Each document may be JSON, YAML, TOML or
+// any format ConvertFormats reads. strategy is one of:
+//
+//   - deep (default): objects merge key by key, anything else is replaced
+//   - append: like deep, but arrays are concatenated
+//   - merge-patch: RFC 7386 JSON Merge Patch, where null deletes a key
+func MergeDocuments(strategy string, docs ...string) (string, error) {
+	return MergeDocumentsTo(strategy, formatJSON, docs...)
+}
+
+// MergeDocumentsTo merges like MergeDocuments and converts the result to
+// the target format.
+func MergeDocumentsTo(strategy, to string, docs ...string) (string, error) {
+	strategy = strings.ToLower(strings.TrimSpace(strategy))
+	if strategy == "" {
+		strategy = MergeDeep
+	}
+	if strategy != MergeDeep && strategy != MergeAppend && strategy != MergePatch {
+		return "", fmt.Errorf("unsupported merge strategy %q", strategy)
+	}
+	if len(docs) == 0 {
+		return "", errors.New("no documents to merge")
+	}
+	var merged any
+	for i, doc := range docs {
+		data, err := decodeDocument(doc)
+		if err != nil {
+			return "", fmt.Errorf("document %d: %w", i+1, err)
+		}
+		if i == 0 {
+			if strategy == MergePatch {
+				data = mergeValues(nil, data, strategy)
+			}
+			merged = data
+			continue
+		}
+		merged = mergeValues(merged, data, strategy)
+	}
+	out, err := encodeJSON(merged)
+	if err != nil {
+		return "", err
+	}
+	if to == "" || to == formatJSON {
+		return out, nil
+	}
+	return ConvertFormats(formatJSON, to, out)
+}
+
+// mergeValues overlays patch on base. Maps are merged in place.
+func mergeValues(base, patch any, strategy string) any {
+	patchObj, ok := patch.(map[string]any)
+	if !ok {
+		if strategy == MergeAppend {
+			if baseArr, ok := base.([]any); ok {
+				if patchArr, ok := patch.([]any); ok {
+					return append(baseArr, patchArr...)
+				}
+			}
+		}
+		return patch
+	}
+	baseObj, ok := base.(map[string]any)
+	if !ok {
+		baseObj = map[string]any{}
+	}
+	for k, v := range patchObj {
+		if v == nil && strategy == MergePatch {
+			delete(baseObj, k)
+			continue
+		}
+		baseObj[k] = mergeValues(baseObj[k], v, strategy)
+	}
+	return baseObj
+}
@@ -0,0 +1,36 @@
+package convert
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeDocuments(t *testing.T) {
+	base := `{"name":"app","image":{"repo":"nginx","tag":"1.0"},"ports":[80],"debug":true}`
+	overlay := "image:\n  tag: \"2.0\"\nports: [443]\ndebug: null\n"
+	tomlOverlay := "replicas = 3\n"
+
+	out, err := MergeDocuments(MergeDeep, base, overlay, tomlOverlay)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"name":"app","image":{"repo":"nginx","tag":"2.0"},"ports":[443],"debug":null,"replicas":3}`, out)
+
+	out, err = MergeDocuments(MergeAppend, base, overlay)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"name":"app","image":{"repo":"nginx","tag":"2.0"},"ports":[80,443],"debug":null}`, out)
+
+	out, err = MergeDocuments(MergePatch, base, overlay)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"name":"app","image":{"repo":"nginx","tag":"2.0"},"ports":[443]}`, out)
+
+	out, err = MergeDocumentsTo("", formatYAML, `{"a":{"b":1}}`, `{"a":{"c":2}}`)
+	require.NoError(t, err)
+	require.Equal(t, "a:\n  b: 1\n  c: 2", out)
+
+	_, err = MergeDocuments("shallow", base)
+	require.Error(t, err)
+	_, err = MergeDocuments(MergeDeep)
+	require.Error(t, err)
+	_, err = MergeDocuments(MergeDeep, base, "{bad")
+	require.ErrorContains(t, err, "document 2")
+}
@@ -0,0 +1,190 @@
+package convert
+
+import (
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// metricsSlowest is how many of the slowest conversions a report keeps.
+const metricsSlowest = 10
+
+// MetricsStage sums one step over every call: how often it ran and
+// failed, how long it took and how many input bytes it read.
+type MetricsStage struct {
+	Count    int     `json:"count"`
+	Errors   int     `json:"errors"`
+	TotalMs  float64 `json:"totalMs"`
+	MaxMs    float64 `json:"maxMs"`
+	Bytes    int64   `json:"bytes"`
+	MaxBytes int     `json:"maxBytes"`
+}
+
+// FormatMetrics times a format's parser (to JSON) and encoder (from JSON).
+type FormatMetrics struct {
+	Parse  MetricsStage `json:"parse"`
+	Encode MetricsStage `json:"encode"`
+}
+
+// MetricsSample is one conversion among the slowest recorded.
+type MetricsSample struct {
+	From   string  `json:"from"`
+	To     string  `json:"to"`
+	Bytes  int     `json:"bytes"`
+	Ms     float64 `json:"ms"`
+	Failed bool    `json:"failed,omitempty"`
+}
+
+// MetricsReport is what the opt-in metrics mode has recorded since it was
+// enabled or reset, keyed by format and by "from -> to" pair. It holds
+// sizes and timings only, never document content or error messages, and
+// stays on the machine unless the user shares it.
+type MetricsReport struct {
+	Version     string                    `json:"version"`
+	Go          string                    `json:"go"`
+	Platform    string                    `json:"platform"`
+	Since       time.Time                 `json:"since"`
+	Conversions map[string]*MetricsStage  `json:"conversions"`
+	Formats     map[string]*FormatMetrics `json:"formats"`
+	Slowest     []MetricsSample           `json:"slowest"`
+}
+
+var metrics struct {
+	on     atomic.Bool
+	mu     sync.Mutex
+	report MetricsReport
+}
+
+// EnableMetrics switches local metrics on or off. While on, every
+// ConvertFormatsWithOptions call records its timings and input sizes; the
+// mode is off by default and turning it on starts a fresh report.
+func EnableMetrics(on bool) {
+	if on && !metrics.on.Load() {
+		ResetMetrics()
+	}
+	metrics.on.Store(on)
+}
+
+// MetricsEnabled reports whether EnableMetrics turned metrics on.
+func MetricsEnabled() bool { return metrics.on.Load() }
+
+// ResetMetrics clears the recorded metrics.
+func ResetMetrics() {
+	metrics.mu.Lock()
+	defer metrics.mu.Unlock()
+	metrics.report = MetricsReport{
+		Since:       time.Now().UTC(),
+		Conversions: map[string]*MetricsStage{},
+		Formats:     map[string]*FormatMetrics{},
+	}
+}
+
+// Metrics returns a copy of the report recorded so far.
+func Metrics() MetricsReport {
+	metrics.mu.Lock()
+	defer metrics.mu.Unlock()
+	r := metrics.report
+	r.Version, r.Go, r.Platform = Version, runtime.Version(), runtime.GOOS+"/"+runtime.GOARCH
+	r.Conversions = make(map[string]*MetricsStage, len(metrics.report.Conversions))
+	for k, v := range metrics.report.Conversions {
+		stage := *v
+		r.Conversions[k] = &stage
+	}
+	r.Formats = make(map[string]*FormatMetrics, len(metrics.report.Formats))
+	for k, v := range metrics.report.Formats {
+		f := *v
+		r.Formats[k] = &f
+	}
+	r.Slowest = append([]MetricsSample{}, metrics.report.Slowest...)
+	return r
+}
+
+// WriteMetrics saves the report as indented JSON at path, replacing the
+// file in one step so readers never see half a report.
+func WriteMetrics(path string) error {
+	data, err := json.MarshalIndent(Metrics(), "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".metrics-*.json")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+const (
+	metricsParse  = "parse"
+	metricsEncode = "encode"
+)
+
+// recordStage adds one parse or encode call of format to the report.
+func recordStage(format, stage string, start time.Time, size int, err error) {
+	if !metrics.on.Load() {
+		return
+	}
+	ms := metricsMs(time.Since(start))
+	metrics.mu.Lock()
+	defer metrics.mu.Unlock()
+	f := metrics.report.Formats[format]
+	if f == nil {
+		f = &FormatMetrics{}
+		metrics.report.Formats[format] = f
+	}
+	s := &f.Parse
+	if stage == metricsEncode {
+		s = &f.Encode
+	}
+	s.add(ms, size, err)
+}
+
+// recordConversion adds one whole conversion to the report.
+func recordConversion(from, to string, start time.Time, size int, err error) {
+	if !metrics.on.Load() {
+		return
+	}
+	ms := metricsMs(time.Since(start))
+	metrics.mu.Lock()
+	defer metrics.mu.Unlock()
+	key := from + " -> " + to
+	s := metrics.report.Conversions[key]
+	if s == nil {
+		s = &MetricsStage{}
+		metrics.report.Conversions[key] = s
+	}
+	s.add(ms, size, err)
+
+	sample := MetricsSample{From: from, To: to, Bytes: size, Ms: ms, Failed: err != nil}
+	slowest := append(metrics.report.Slowest, sample)
+	sort.SliceStable(slowest, func(i, j int) bool { return slowest[i].Ms > slowest[j].Ms })
+	metrics.report.Slowest = slowest[:min(len(slowest), metricsSlowest)]
+}
+
+func (s *MetricsStage) add(ms float64, size int, err error) {
+	s.Count++
+	if err != nil {
+		s.Errors++
+	}
+	s.TotalMs = math.Round((s.TotalMs+ms)*1000) / 1000
+	s.MaxMs = max(s.MaxMs, ms)
+	s.Bytes += int64(size)
+	s.MaxBytes = max(s.MaxBytes, size)
+}
+
+func metricsMs(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
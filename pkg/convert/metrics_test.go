@@ -0,0 +1,55 @@
+package convert
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetrics(t *testing.T) {
+	_, err := ConvertFormats(formatJSON, formatYAML, `{"a":1}`)
+	require.NoError(t, err)
+	require.False(t, MetricsEnabled())
+	require.Empty(t, Metrics().Conversions)
+
+	EnableMetrics(true)
+	defer EnableMetrics(false)
+	_, err = ConvertFormats(formatYAML, formatTOML, "a: 1\nb: two\n")
+	require.NoError(t, err)
+	_, err = ConvertFormats(formatYAML, formatTOML, "a: [")
+	require.Error(t, err)
+	_, err = ConvertFormats(formatGoStruct, formatGraphQL, "type A struct{ ID int }")
+	require.NoError(t, err)
+
+	report := Metrics()
+	require.Equal(t, Version, report.Version)
+	require.NotEmpty(t, report.Platform)
+	pair := report.Conversions["YAML -> TOML"]
+	require.Equal(t, 2, pair.Count)
+	require.Equal(t, 1, pair.Errors)
+	require.Equal(t, int64(len("a: 1\nb: two\n")+len("a: [")), pair.Bytes)
+	require.Equal(t, len("a: 1\nb: two\n"), pair.MaxBytes)
+	require.Equal(t, 2, report.Formats[formatYAML].Parse.Count)
+	require.Equal(t, 1, report.Formats[formatTOML].Encode.Count)
+	require.Zero(t, report.Formats[formatTOML].Parse.Count)
+	require.Equal(t, 1, report.Conversions[formatGoStruct+" -> "+formatGraphQL].Count)
+	require.Len(t, report.Slowest, 3)
+	for i := 1; i < len(report.Slowest); i++ {
+		require.GreaterOrEqual(t, report.Slowest[i-1].Ms, report.Slowest[i].Ms)
+	}
+
+	path := filepath.Join(t.TempDir(), "metrics.json")
+	require.NoError(t, WriteMetrics(path))
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var saved MetricsReport
+	require.NoError(t, json.Unmarshal(data, &saved))
+	require.Equal(t, 2, saved.Conversions["YAML -> TOML"].Count)
+	require.NotContains(t, string(data), "two")
+
+	ResetMetrics()
+	require.Empty(t, Metrics().Conversions)
+}
@@ -0,0 +1,162 @@
+package convert
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	LineEndingLF   = "lf"
+	LineEndingCRLF = "crlf"
+	LineEndingCR   = "cr"
+
+	IndentTabs   = "tabs"
+	IndentSpaces = "spaces"
+)
+
+var lineEndings = map[string]string{
+	LineEndingLF:   "\n",
+	LineEndingCRLF: "\r\n",
+	LineEndingCR:   "\r",
+}
+
+// NormalizeOptions selects the clean-ups NormalizeText applies; the zero
+// value changes nothing. Indent only rewrites leading whitespace, so tabs
+// inside a line (in string literals or TSV data) are left alone.
+type NormalizeOptions struct {
+	// LineEnding is lf, crlf or cr; empty keeps each line's ending.
+	LineEnding string `json:"lineEnding,omitempty"`
+	// Indent is tabs or spaces; empty keeps indentation as it is.
+	Indent string `json:"indent,omitempty"`
+	// TabWidth is the number of columns per tab (default 4).
+	TabWidth     int  `json:"tabWidth,omitempty"`
+	TrimTrailing bool `json:"trimTrailing,omitempty"`
+	FinalNewline bool `json:"finalNewline,omitempty"`
+}
+
+// NormalizeResult is the normalized text with a count of what changed.
+// LineEndings counts the endings found in the input by kind.
+type NormalizeResult struct {
+	Text               string         `json:"text"`
+	Changed            bool           `json:"changed"`
+	Lines              int            `json:"lines"`
+	LineEndings        map[string]int `json:"lineEndings"`
+	LineEndingsChanged int            `json:"lineEndingsChanged"`
+	IndentsChanged     int            `json:"indentsChanged"`
+	TrailingTrimmed    int            `json:"trailingTrimmed"`
+	FinalNewlineAdded  bool           `json:"finalNewlineAdded"`
+}
+
+// NormalizeText converts line endings, re-indents with tabs or spaces,
+// strips trailing whitespace and ensures a final newline, as chosen by
+// opts. It is meant as a pre-step before diffing or formatting text.
+func NormalizeText(input string, opts NormalizeOptions) (NormalizeResult, error) {
+	res := NormalizeResult{LineEndings: map[string]int{}}
+	target := ""
+	if opts.LineEnding != "" {
+		var ok bool
+		if target, ok = lineEndings[strings.ToLower(opts.LineEnding)]; !ok {
+			return res, fmt.Errorf("unsupported line ending %q", opts.LineEnding)
+		}
+	}
+	indent := strings.ToLower(opts.Indent)
+	if indent != "" && indent != IndentTabs && indent != IndentSpaces {
+		return res, fmt.Errorf("unsupported indent %q", opts.Indent)
+	}
+	width := opts.TabWidth
+	if width <= 0 {
+		width = 4
+	}
+
+	var out strings.Builder
+	out.Grow(len(input))
+	lastEnding := "\n"
+	rest := input
+	for rest != "" {
+		line, ending := splitLineEnding(rest)
+		rest = rest[len(line)+len(ending):]
+		res.Lines++
+
+		if indent != "" {
+			if reindented := reindentLine(line, indent, width); reindented != line {
+				line = reindented
+				res.IndentsChanged++
+			}
+		}
+		if opts.TrimTrailing {
+			if trimmed := strings.TrimRight(line, " \t\f\v"); trimmed != line {
+				line = trimmed
+				res.TrailingTrimmed++
+			}
+		}
+		out.WriteString(line)
+		if ending == "" {
+			continue
+		}
+		res.LineEndings[lineEndingName(ending)]++
+		if target != "" && ending != target {
+			ending = target
+			res.LineEndingsChanged++
+		}
+		out.WriteString(ending)
+		lastEnding = ending
+	}
+	text := out.String()
+	if opts.FinalNewline && text != "" && !strings.HasSuffix(text, "\n") && !strings.HasSuffix(text, "\r") {
+		if target != "" {
+			lastEnding = target
+		}
+		text += lastEnding
+		res.FinalNewlineAdded = true
+	}
+	res.Text = text
+	res.Changed = text != input
+	return res, nil
+}
+
+// splitLineEnding returns the first line of s and the ending after it,
+// which is empty for the last line without one.
+func splitLineEnding(s string) (string, string) {
+	i := strings.IndexAny(s, "\r\n")
+	if i < 0 {
+		return s, ""
+	}
+	if s[i] == '\r' && i+1 < len(s) && s[i+1] == '\n' {
+		return s[:i], "\r\n"
+	}
+	return s[:i], s[i : i+1]
+}
+
+func lineEndingName(ending string) string {
+	for name, e := range lineEndings {
+		if e == ending {
+			return name
+		}
+	}
+	return ""
+}
+
+// reindentLine rewrites the leading tabs and spaces of line as the same
+// visual width in tabs (plus spaces for any remainder) or in spaces.
+func reindentLine(line, indent string, width int) string {
+	end := 0
+	col := 0
+	for end < len(line) && (line[end] == ' ' || line[end] == '\t') {
+		if line[end] == '\t' {
+			col += width - col%width
+		} else {
+			col++
+		}
+		end++
+	}
+	if end == 0 {
+		return line
+	}
+	var lead string
+	if indent == IndentTabs {
+		lead = strings.Repeat("\t", col/width) + strings.Repeat(" ", col%width)
+	} else {
+		lead = strings.Repeat(" ", col)
+	}
+	return lead + line[end:]
+}
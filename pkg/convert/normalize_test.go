@@ -0,0 +1,47 @@
+package convert
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeText(t *testing.T) {
+	input := "a  \r\n\tb\t\rc\n    d"
+	res, err := NormalizeText(input, NormalizeOptions{
+		LineEnding:   LineEndingLF,
+		Indent:       IndentSpaces,
+		TabWidth:     2,
+		TrimTrailing: true,
+		FinalNewline: true,
+	})
+	require.NoError(t, err)
+	require.Equal(t, NormalizeResult{
+		Text:               "a\n  b\nc\n    d\n",
+		Changed:            true,
+		Lines:              4,
+		LineEndings:        map[string]int{LineEndingCRLF: 1, LineEndingCR: 1, LineEndingLF: 1},
+		LineEndingsChanged: 2,
+		IndentsChanged:     1,
+		TrailingTrimmed:    2,
+		FinalNewlineAdded:  true,
+	}, res)
+
+	res, err = NormalizeText("  x\n      y\r\n", NormalizeOptions{Indent: IndentTabs})
+	require.NoError(t, err)
+	require.Equal(t, "  x\n\t  y\r\n", res.Text)
+	require.Equal(t, 1, res.IndentsChanged)
+
+	res, err = NormalizeText("x\r\ny", NormalizeOptions{FinalNewline: true})
+	require.NoError(t, err)
+	require.Equal(t, "x\r\ny\r\n", res.Text)
+
+	res, err = NormalizeText("clean\n", NormalizeOptions{LineEnding: "LF", TrimTrailing: true, FinalNewline: true})
+	require.NoError(t, err)
+	require.False(t, res.Changed)
+
+	_, err = NormalizeText("x", NormalizeOptions{LineEnding: "unix"})
+	require.Error(t, err)
+	_, err = NormalizeText("x", NormalizeOptions{Indent: "mixed"})
+	require.Error(t, err)
+}
@@ -0,0 +1,450 @@
+package convert
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/linzeyan/transform-go/pkg/common"
+)
+
+const (
+	TagCaseOriginal = ""
+	TagCaseSnake    = "snake"
+	TagCaseCamel    = "camel"
+	TagCasePascal   = "pascal"
+	TagCaseKebab    = "kebab"
+
+	NumberTypeInt     = "int"
+	NumberTypeInt64   = "int64"
+	NumberTypeFloat64 = "float64"
+
+	SchemaDraft07   = "draft-07"
+	SchemaDraft2020 = "2020-12"
+
+	SQLDialectPostgres = "postgresql"
+	SQLDialectMySQL    = "mysql"
+	SQLDialectSQLite   = "sqlite"
+
+	YAMLDocumentsAuto   = "auto"
+	YAMLDocumentsFirst  = "first"
+	YAMLDocumentsStream = "stream"
+
+	YAMLAliasesResolve = "resolve"
+	YAMLAliasesStrict  = "strict"
+	YAMLAliasesForbid  = "forbid"
+
+	XMLDeclarationKeep   = "keep"
+	XMLDeclarationAlways = "always"
+	XMLDeclarationOmit   = "omit"
+
+	TOMLDatetimesString = "string"
+	TOMLDatetimesTagged = "tagged"
+
+	TOONDelimiterComma = "comma"
+	TOONDelimiterTab   = "tab"
+	TOONDelimiterPipe  = "pipe"
+
+	// DefaultYAMLAliasLimit is the number of values the aliases of a YAML
+	// stream may expand into when YAMLAliasLimit is 0.
+	DefaultYAMLAliasLimit = 100000
+)
+
+var schemaDraftURIs = map[string]string{
+	SchemaDraft07:   "http://json-schema.org/draft-07/schema#",
+	SchemaDraft2020: "https://json-schema.org/draft/2020-12/schema",
+}
+
+// ConvertOptions tunes generated output. The zero value of every field is
+// its default, so ConvertOptions{} matches NewConvertOptions() and
+// ConvertFormats matches ConvertFormatsWithOptions without options.
+type ConvertOptions struct {
+	// Indent is the number of spaces for JSON, YAML and XML output, and for
+	// multiline arrays in formatted TOML; 0 keeps each format's default.
+	Indent int
+	// SortKeys orders object keys alphabetically. By default every encoder
+	// writes keys in the order the JSON, YAML or TOML input gave them.
+	SortKeys bool
+	// TagCase rewrites json tag names (snake, camel, pascal, kebab).
+	TagCase string
+	// PointerFields emits nested struct fields as pointers.
+	PointerFields bool
+	// OmitEmpty adds ",omitempty" to generated tags.
+	OmitEmpty bool
+	// ExtraTags adds struct tags after json, mapping each tag name (yaml,
+	// toml, xml, mapstructure, db or any other) to its own tag case.
+	ExtraTags map[string]string
+	// NumberType selects the Go type for integers: int (the default), int64
+	// or float64 (which also applies to fractional numbers, as always).
+	NumberType string
+	// NamedStructs lifts nested objects into top-level named types.
+	NamedStructs bool
+	// SemanticTypes types Go struct fields whose sample strings are RFC 3339
+	// timestamps or UUIDs as time.Time or uuid.UUID (github.com/google/uuid)
+	// and adds the imports. Fields holding Go durations or absolute URLs stay
+	// strings, which is how encoding/json can read them, with a comment
+	// naming time.Duration or url.URL.
+	SemanticTypes bool
+	// GraphQLInputs adds an input type for every generated GraphQL object.
+	GraphQLInputs bool
+	// GraphQLOperations appends sample Query and Mutation blocks for the
+	// root GraphQL type, together with the input types they use.
+	GraphQLOperations bool
+	// ProtoService appends a <Message>Service with Get, List, Create, Update
+	// and Delete RPCs for each root Protobuf message, plus the request and
+	// response messages they use.
+	ProtoService bool
+	// ProtoHTTP adds google.api.http annotations to those RPCs, mapping
+	// them to REST routes under /v1; it implies ProtoService.
+	ProtoHTTP bool
+	// SchemaDraft adds the $schema header of draft-07 or 2020-12 to
+	// generated JSON Schemas.
+	SchemaDraft string
+	// SchemaFormats infers format hints (date-time, email, uuid, uri, ipv4)
+	// and the integer type in generated JSON Schemas.
+	SchemaFormats bool
+	// SQLDialect selects the SQL DDL dialect: postgresql (the default),
+	// mysql or sqlite.
+	SQLDialect string
+	// YAMLDocuments sets how YAML streams of documents split by --- are
+	// read and written. auto (the default) reads several documents into an
+	// array and a single one as itself; first reads only the first
+	// document; stream always reads an array, and writes a JSON array as
+	// one document per element.
+	YAMLDocuments string
+	// YAMLAliases sets how YAML aliases are read. resolve (the default)
+	// expands aliases and << merge keys, reading an alias inside the node
+	// it names as null; strict rejects such recursive aliases; forbid
+	// rejects every alias, for input that should not use them.
+	YAMLAliases string
+	// YAMLAliasLimit caps the values aliases may expand into across a YAML
+	// stream, so nested aliases cannot blow up memory; 0 means
+	// DefaultYAMLAliasLimit and a negative limit turns the cap off.
+	YAMLAliasLimit int
+	// XMLDeclaration sets whether XML output starts with <?xml ...?>: keep
+	// (the default) writes one where the source had one, as generated XML
+	// always does; always adds one; omit drops it.
+	XMLDeclaration string
+	// XMLStandalone, yes or no, sets standalone in the XML declaration and
+	// so writes one unless XMLDeclaration is omit.
+	XMLStandalone string
+	// XMLWrapAttributes puts each attribute of a formatted XML start tag
+	// on its own line when the tag has more than this many; 0 never wraps.
+	XMLWrapAttributes int
+	// TOMLDatetimes sets how TOML datetimes are read into JSON. tagged
+	// writes {"$datetime": "..."}, which TOML output writes back as an
+	// unquoted offset or local datetime, local date or local time; string
+	// writes them as strings. Left empty, datetimes are tagged when they
+	// are written as JSON or TOML, which read the tags back, and are
+	// strings in every other format.
+	TOMLDatetimes string
+	// TOONDelimiter separates the values of TOON arrays and tabular rows:
+	// comma (the default), tab or pipe. Array headers name the delimiter,
+	// so TOON reads the same whichever one it uses.
+	TOONDelimiter string
+	// TOONKeyFolding writes chains of objects holding a single key in TOON
+	// as one dotted key, a.b.c: 1, quoting keys that hold dots of their own,
+	// and reads unquoted dotted keys back into nested objects.
+	TOONKeyFolding bool
+	// MultilineArrays puts every element of a generated TOML array on its
+	// own line.
+	MultilineArrays bool
+	// FinalNewline ends converted and formatted output with one newline.
+	FinalNewline bool
+	// Script is a transform script (see RunScript) run over the document
+	// between the source and target formats, within ScriptLimits.
+	Script string
+	// ScriptLimits bound Script; zero fields take the values of
+	// DefaultScriptLimits. Options decoded from JSON never set them.
+	ScriptLimits ScriptLimits `json:"-"`
+	// Provenance heads generated output with a comment naming the source
+	// format, tool version, time and options hash, for formats that allow
+	// comments; StripProvenance removes it.
+	Provenance bool
+	// Profile names a house style from ListProfiles; it overrides the
+	// settings it bundles.
+	Profile string
+}
+
+type ConvertOption func(*ConvertOptions)
+
+// NewConvertOptions applies opts on top of the defaults.
+func NewConvertOptions(opts ...ConvertOption) ConvertOptions {
+	var o ConvertOptions
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&o)
+		}
+	}
+	if p, ok := lookupProfile(o.Profile); ok {
+		p.apply(&o)
+	}
+	return o
+}
+
+func WithIndent(spaces int) ConvertOption {
+	return func(o *ConvertOptions) { o.Indent = spaces }
+}
+
+func WithSortKeys(sortKeys bool) ConvertOption {
+	return func(o *ConvertOptions) { o.SortKeys = sortKeys }
+}
+
+func WithTagCase(tagCase string) ConvertOption {
+	return func(o *ConvertOptions) { o.TagCase = tagCase }
+}
+
+func WithPointerFields(pointers bool) ConvertOption {
+	return func(o *ConvertOptions) { o.PointerFields = pointers }
+}
+
+func WithOmitEmpty(omitEmpty bool) ConvertOption {
+	return func(o *ConvertOptions) { o.OmitEmpty = omitEmpty }
+}
+
+func WithExtraTags(tags map[string]string) ConvertOption {
+	return func(o *ConvertOptions) { o.ExtraTags = tags }
+}
+
+func WithNumberType(numberType string) ConvertOption {
+	return func(o *ConvertOptions) { o.NumberType = numberType }
+}
+
+func WithNamedStructs(named bool) ConvertOption {
+	return func(o *ConvertOptions) { o.NamedStructs = named }
+}
+
+func WithSemanticTypes(semantic bool) ConvertOption {
+	return func(o *ConvertOptions) { o.SemanticTypes = semantic }
+}
+
+func WithGraphQLInputs(inputs bool) ConvertOption {
+	return func(o *ConvertOptions) { o.GraphQLInputs = inputs }
+}
+
+func WithGraphQLOperations(operations bool) ConvertOption {
+	return func(o *ConvertOptions) { o.GraphQLOperations = operations }
+}
+
+func WithProtoService(service bool) ConvertOption {
+	return func(o *ConvertOptions) { o.ProtoService = service }
+}
+
+func WithProtoHTTP(http bool) ConvertOption {
+	return func(o *ConvertOptions) { o.ProtoHTTP = http }
+}
+
+func WithSchemaDraft(draft string) ConvertOption {
+	return func(o *ConvertOptions) { o.SchemaDraft = draft }
+}
+
+func WithSchemaFormats(formats bool) ConvertOption {
+	return func(o *ConvertOptions) { o.SchemaFormats = formats }
+}
+
+func WithSQLDialect(dialect string) ConvertOption {
+	return func(o *ConvertOptions) { o.SQLDialect = dialect }
+}
+
+func WithYAMLDocuments(mode string) ConvertOption {
+	return func(o *ConvertOptions) { o.YAMLDocuments = mode }
+}
+
+func WithYAMLAliases(mode string) ConvertOption {
+	return func(o *ConvertOptions) { o.YAMLAliases = mode }
+}
+
+func WithYAMLAliasLimit(limit int) ConvertOption {
+	return func(o *ConvertOptions) { o.YAMLAliasLimit = limit }
+}
+
+func WithXMLDeclaration(mode string) ConvertOption {
+	return func(o *ConvertOptions) { o.XMLDeclaration = mode }
+}
+
+func WithXMLStandalone(standalone string) ConvertOption {
+	return func(o *ConvertOptions) { o.XMLStandalone = standalone }
+}
+
+func WithXMLWrapAttributes(count int) ConvertOption {
+	return func(o *ConvertOptions) { o.XMLWrapAttributes = count }
+}
+
+func WithTOMLDatetimes(mode string) ConvertOption {
+	return func(o *ConvertOptions) { o.TOMLDatetimes = mode }
+}
+
+func WithTOONDelimiter(delimiter string) ConvertOption {
+	return func(o *ConvertOptions) { o.TOONDelimiter = delimiter }
+}
+
+func WithTOONKeyFolding(folding bool) ConvertOption {
+	return func(o *ConvertOptions) { o.TOONKeyFolding = folding }
+}
+
+func WithMultilineArrays(multiline bool) ConvertOption {
+	return func(o *ConvertOptions) { o.MultilineArrays = multiline }
+}
+
+func WithFinalNewline(newline bool) ConvertOption {
+	return func(o *ConvertOptions) { o.FinalNewline = newline }
+}
+
+func WithScript(script string) ConvertOption {
+	return func(o *ConvertOptions) { o.Script = script }
+}
+
+func WithScriptLimits(limits ScriptLimits) ConvertOption {
+	return func(o *ConvertOptions) { o.ScriptLimits = limits }
+}
+
+func WithProvenance(provenance bool) ConvertOption {
+	return func(o *ConvertOptions) { o.Provenance = provenance }
+}
+
+func WithProfile(name string) ConvertOption {
+	return func(o *ConvertOptions) { o.Profile = name }
+}
+
+// WithOptions replaces all settings with o, for callers that already hold a
+// ConvertOptions value (such as decoded request parameters). Fields o leaves
+// zero take their defaults.
+func WithOptions(o ConvertOptions) ConvertOption {
+	return func(dst *ConvertOptions) { *dst = o }
+}
+
+func (o ConvertOptions) indentString() string {
+	if o.Indent <= 0 {
+		return "  "
+	}
+	return strings.Repeat(" ", o.Indent)
+}
+
+// knownStructTags orders extra tags; any other tag names follow sorted.
+var knownStructTags = []string{"yaml", "toml", "xml", "mapstructure", "db"}
+
+// structTags renders the tag literal for a field named key: json first,
+// then every extra tag with its own case. db tags take no omitempty, as
+// database/sql mappers do not know the option.
+func (o ConvertOptions) structTags(key string) string {
+	names := make([]string, 0, len(o.ExtraTags))
+	for _, name := range knownStructTags {
+		if _, ok := o.ExtraTags[name]; ok {
+			names = append(names, name)
+		}
+	}
+	var others []string
+	for name := range o.ExtraTags {
+		if name != "json" && !slices.Contains(knownStructTags, name) {
+			others = append(others, name)
+		}
+	}
+	sort.Strings(others)
+	names = append(names, others...)
+
+	tag := func(name, tagCase string) string {
+		value := applyTagCase(key, tagCase)
+		if o.OmitEmpty && name != "db" {
+			value += ",omitempty"
+		}
+		return fmt.Sprintf("%s:\"%s\"", name, value)
+	}
+	parts := []string{tag("json", o.TagCase)}
+	for _, name := range names {
+		parts = append(parts, tag(name, o.ExtraTags[name]))
+	}
+	return "`" + strings.Join(parts, " ") + "`"
+}
+
+func applyTagCase(key, tagCase string) string {
+	if tagCase == TagCaseOriginal {
+		return key
+	}
+	exported := common.ExportName(key)
+	if exported == "" {
+		return key
+	}
+	switch tagCase {
+	case TagCaseCamel:
+		return common.LowerFirst(exported)
+	case TagCasePascal:
+		return exported
+	case TagCaseSnake, TagCaseKebab:
+		sep := "_"
+		if tagCase == TagCaseKebab {
+			sep = "-"
+		}
+		words := common.SplitWords(exported)
+		for i, w := range words {
+			words[i] = strings.ToLower(w)
+		}
+		return strings.Join(words, sep)
+	}
+	return key
+}
+
+// jsonKeyOrder records the source order of object keys, indexed by path
+// ("" for the root, "a.b" for nested objects and "a[]" for array elements).
+// Keys from all elements of an array are merged in first-seen order.
+func jsonKeyOrder(input string) map[string][]string {
+	doc, err := parseJSONDoc(input)
+	if err != nil {
+		return map[string][]string{}
+	}
+	return doc.keyOrder()
+}
+
+// keysInOrder returns obj's keys following order when given, falling back to
+// sorted order for keys order does not know about.
+func keysInOrder(obj map[string]any, order []string) []string {
+	keys := orderedKeys(obj)
+	if len(order) == 0 {
+		return keys
+	}
+	out := make([]string, 0, len(keys))
+	used := map[string]bool{}
+	for _, k := range order {
+		if _, ok := obj[k]; ok && !used[k] {
+			out = append(out, k)
+			used[k] = true
+		}
+	}
+	for _, k := range keys {
+		if !used[k] {
+			out = append(out, k)
+		}
+	}
+	return out
+}
+
+// sourceOrder holds the key order of a JSON document by path, as
+// jsonKeyOrder records it. A nil sourceOrder sorts keys.
+type sourceOrder map[string][]string
+
+// sourceOrder returns the key order encoders of input follow: the order of
+// input itself, or nil when SortKeys is set.
+func (o ConvertOptions) sourceOrder(input string) sourceOrder {
+	if o.SortKeys {
+		return nil
+	}
+	return jsonKeyOrder(input)
+}
+
+// keys returns the keys of obj, the object at path, in source order or
+// sorted.
+func (s sourceOrder) keys(obj map[string]any, path string) []string {
+	if s == nil {
+		return orderedKeys(obj)
+	}
+	return keysInOrder(obj, s[path])
+}
+
+// keyPath returns the path of key inside the object at path, as
+// jsonKeyOrder indexes it.
+func keyPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
@@ -0,0 +1,187 @@
+package convert
+
+import (
+	"encoding/json"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONToGoStructWithOptions(t *testing.T) {
+	src := `{"zeta": 1, "userName": "a", "home": {"zip": "1"}, "ratio": 2}`
+
+	def, err := JSONToGoStructWithOptions(src)
+	require.NoError(t, err)
+	plain, err := JSONToGoStruct(src)
+	require.NoError(t, err)
+	require.Equal(t, plain, def)
+
+	out, err := JSONToGoStructWithOptions(src,
+		WithTagCase(TagCaseSnake),
+		WithOmitEmpty(true),
+		WithPointerFields(true),
+		WithNumberType(NumberTypeInt64),
+		WithSortKeys(false),
+	)
+	require.NoError(t, err)
+	require.Contains(t, out, "`json:\"user_name,omitempty\"`")
+	require.Contains(t, out, "Zeta     int64")
+	require.Contains(t, out, "Home     *struct {")
+	require.Less(t, strings.Index(out, "Zeta"), strings.Index(out, "UserName"))
+	require.Less(t, strings.Index(out, "UserName"), strings.Index(out, "Home"))
+
+	named, err := JSONToGoStructWithOptions(src, WithNamedStructs(true), WithPointerFields(true), WithNumberType(NumberTypeFloat64))
+	require.NoError(t, err)
+	require.Contains(t, named, "Home     *Home")
+	require.Contains(t, named, "Ratio    float64")
+}
+
+func TestJSONToGoStructExtraTags(t *testing.T) {
+	out, err := JSONToGoStructWithOptions(`{"userName": "a"}`,
+		WithTagCase(TagCaseCamel),
+		WithOmitEmpty(true),
+		WithExtraTags(map[string]string{
+			"db":           TagCaseSnake,
+			"yaml":         TagCaseKebab,
+			"mapstructure": TagCaseOriginal,
+			"env":          TagCasePascal,
+		}),
+	)
+	require.NoError(t, err)
+	require.Contains(t, out, "UserName string `json:\"userName,omitempty\" yaml:\"user-name,omitempty\" "+
+		"mapstructure:\"userName,omitempty\" db:\"user_name\" env:\"UserName,omitempty\"`")
+}
+
+func TestApplyTagCase(t *testing.T) {
+	require.Equal(t, "user_id", applyTagCase("userID", TagCaseSnake))
+	require.Equal(t, "user-id", applyTagCase("user_id", TagCaseKebab))
+	require.Equal(t, "userId", applyTagCase("user_id", TagCaseCamel))
+	require.Equal(t, "UserId", applyTagCase("user_id", TagCasePascal))
+	require.Equal(t, "user_id", applyTagCase("user_id", TagCaseOriginal))
+}
+
+func TestConvertFormatsWithOptions(t *testing.T) {
+	yamlOut, err := ConvertFormatsWithOptions("JSON", "YAML", `{"a": {"b": 1}}`, WithIndent(4))
+	require.NoError(t, err)
+	require.Equal(t, "a:\n    b: 1", yamlOut)
+
+	xmlOut, err := ConvertFormatsWithOptions("JSON", "XML", `{"a": {"b": 1}}`, WithIndent(4))
+	require.NoError(t, err)
+	require.Contains(t, xmlOut, "\n        <b>1</b>")
+
+	jsonOut, err := ConvertFormatsWithOptions("YAML", "JSON", "b: 1\na: 2", WithIndent(3))
+	require.NoError(t, err)
+	require.Equal(t, "{\n   \"b\": 1,\n   \"a\": 2\n}", jsonOut)
+
+	goOut, err := ConvertFormatsWithOptions("YAML", "Go Struct", "user_id: 1", WithTagCase(TagCaseCamel))
+	require.NoError(t, err)
+	require.Contains(t, goOut, "`json:\"userId\"`")
+
+	plain, err := ConvertFormats("YAML", "JSON", "b: 1")
+	require.NoError(t, err)
+	withNone, err := ConvertFormatsWithOptions("YAML", "JSON", "b: 1")
+	require.NoError(t, err)
+	require.Equal(t, plain, withNone)
+}
+
+func TestConvertOptionsZeroValue(t *testing.T) {
+	require.Equal(t, ConvertOptions{}, NewConvertOptions())
+	require.Equal(t, NewConvertOptions(WithIndent(4)), NewConvertOptions(WithOptions(ConvertOptions{Indent: 4})))
+
+	out, err := ConvertFormatsWithOptions("YAML", "JSON", "b: 1\na: 2", WithOptions(ConvertOptions{Indent: 4}))
+	require.NoError(t, err)
+	require.Equal(t, "{\n    \"b\": 1,\n    \"a\": 2\n}", out)
+
+	goOut, err := ConvertFormatsWithOptions("JSON", "Go Struct", `{"n": 1}`, WithOptions(ConvertOptions{}))
+	require.NoError(t, err)
+	require.Contains(t, goOut, "N int `json:\"n\"`")
+}
+
+func TestFormatContentWithOptions(t *testing.T) {
+	out, err := FormatContentWithOptions("JSON", `{"b":1,"a":[1]}`, false, WithSortKeys(false), WithIndent(4))
+	require.NoError(t, err)
+	require.Equal(t, "{\n    \"b\": 1,\n    \"a\": [\n        1\n    ]\n}", out)
+
+	sorted, err := FormatContentWithOptions("JSON", `{"b":1,"a":2}`, false, WithSortKeys(true))
+	require.NoError(t, err)
+	require.Equal(t, "{\n  \"a\": 2,\n  \"b\": 1\n}", sorted)
+}
+
+func TestJSONKeyOrder(t *testing.T) {
+	order := jsonKeyOrder(`{"z": {"y": 1, "x": 2}, "a": [{"q": 1}, {"p": 2, "q": 3}]}`)
+	require.Equal(t, []string{"z", "a"}, order[""])
+	require.Equal(t, []string{"y", "x"}, order["z"])
+	require.Equal(t, []string{"q", "p"}, order["a[]"])
+}
+
+func TestJSONToGoStructSemanticTypes(t *testing.T) {
+	input := `{"id":"3f1c2b9e-8d7a-4c6b-9e5f-1a2b3c4d5e6f","createdAt":"2024-05-01T08:00:00Z","timeout":"1m30s","homepage":"https://example.com/a","name":"x","tags":["https://a.example","plain"]}`
+	out, err := JSONToGoStructWithOptions(input, WithSemanticTypes(true), WithSortKeys(false))
+	require.NoError(t, err)
+	require.Equal(t, `import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type AutoGenerated struct {
+	Id        uuid.UUID `+"`json:\"id\"`"+`
+	CreatedAt time.Time `+"`json:\"createdAt\"`"+`
+	Timeout   string    `+"`json:\"timeout\"`"+`  // a time.Duration; parse with time.ParseDuration
+	Homepage  string    `+"`json:\"homepage\"`"+` // a url.URL; parse with url.Parse
+	Name      string    `+"`json:\"name\"`"+`
+	Tags      []string  `+"`json:\"tags\"`"+`
+}`, out)
+
+	// The sample decodes into the types generated for it.
+	input = `{"createdAt":"2024-05-01T08:00:00Z","timeout":"1h30m","homepage":"https://example.com/a","ports":[80,443]}`
+	out, err = JSONToGoStructWithOptions(input, WithSemanticTypes(true))
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal([]byte(input), reflect.New(goStructOf(t, out)).Interface()))
+
+	out, err = JSONToGoStructWithOptions(`{"at":"2024-05-01T08:00:00Z"}`, WithSemanticTypes(true))
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(out, "import \"time\"\n\ntype AutoGenerated struct"), out)
+
+	out, err = JSONToGoStruct(`{"at":"2024-05-01T08:00:00Z"}`)
+	require.NoError(t, err)
+	require.NotContains(t, out, "import")
+	require.Contains(t, out, "At string")
+}
+
+// goStructOf builds the AutoGenerated struct of generated Go source with
+// reflect, for fields of the types named in goTypes.
+func goStructOf(t *testing.T, src string) reflect.Type {
+	t.Helper()
+	goTypes := map[string]reflect.Type{
+		"string":    reflect.TypeFor[string](),
+		"int":       reflect.TypeFor[int](),
+		"[]int":     reflect.TypeFor[[]int](),
+		"time.Time": reflect.TypeFor[time.Time](),
+	}
+	file, err := parser.ParseFile(token.NewFileSet(), "", "package main\n\n"+src, 0)
+	require.NoError(t, err)
+	var fields []reflect.StructField
+	ast.Inspect(file, func(n ast.Node) bool {
+		spec, ok := n.(*ast.TypeSpec)
+		if !ok || spec.Name.Name != "AutoGenerated" {
+			return true
+		}
+		for _, f := range spec.Type.(*ast.StructType).Fields.List {
+			typ, ok := goTypes[types.ExprString(f.Type)]
+			require.True(t, ok, types.ExprString(f.Type))
+			tag := strings.Trim(f.Tag.Value, "`")
+			fields = append(fields, reflect.StructField{Name: f.Names[0].Name, Type: typ, Tag: reflect.StructTag(tag)})
+		}
+		return false
+	})
+	require.NotEmpty(t, fields)
+	return reflect.StructOf(fields)
+}
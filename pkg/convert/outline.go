@@ -0,0 +1,261 @@
+package convert
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// outlineMaxKeys caps the keys listed under one object, so maps keyed
+	// by IDs do not swamp the outline; the rest are counted in Truncated.
+	outlineMaxKeys = 100
+	// outlineMermaidNodes caps the Mermaid chart, which renders poorly past
+	// a few hundred boxes.
+	outlineMermaidNodes = 200
+)
+
+// OutlineNode is one entry of a StructureOutline. The elements of an array
+// are merged into its single child "[*]", so an array of a million records
+// outlines as one record. Count is the number of values merged into the
+// node; Children sums their keys or elements and Size their bytes as
+// compact JSON. Type is object, array, string, number, boolean or null,
+// or several joined by "|" when the merged values differ.
+type OutlineNode struct {
+	Path      string         `json:"path"`
+	Name      string         `json:"name"`
+	Type      string         `json:"type"`
+	Count     int            `json:"count"`
+	Children  int            `json:"children,omitempty"`
+	Size      int            `json:"size"`
+	Nodes     []*OutlineNode `json:"nodes,omitempty"`
+	Truncated int            `json:"truncated,omitempty"`
+
+	types []string
+	index map[string]*OutlineNode
+}
+
+// StructureOutlineResult is the outline of a document in the format it was
+// read from, with the number of nodes and the same tree as a Mermaid
+// flowchart.
+type StructureOutlineResult struct {
+	Format  string       `json:"format"`
+	Root    *OutlineNode `json:"root"`
+	Nodes   int          `json:"nodes"`
+	Mermaid string       `json:"mermaid"`
+}
+
+// StructureOutline describes the shape of a JSON document (or any format
+// ConvertFormats can read) as a tree of paths, types, child counts and
+// sizes, small enough to browse even when the document is not. Keys keep
+// their document order.
+func StructureOutline(input string) (StructureOutlineResult, error) {
+	format, doc, err := decodeDocumentNode(input)
+	if err != nil {
+		return StructureOutlineResult{}, err
+	}
+	root := &OutlineNode{Path: "$", Name: "$"}
+	root.add(doc)
+	nodes := root.finish()
+	return StructureOutlineResult{
+		Format:  format,
+		Root:    root,
+		Nodes:   nodes,
+		Mermaid: root.mermaid(nodes),
+	}, nil
+}
+
+// add merges n into the outline and returns its size as compact JSON.
+func (o *OutlineNode) add(n *docNode) int {
+	o.Count++
+	kind := outlineType(n)
+	if !slices.Contains(o.types, kind) {
+		o.types = append(o.types, kind)
+	}
+	size := 0
+	switch n.kind {
+	case docObject:
+		o.Children += len(n.keys)
+		size = 2 + max(len(n.keys)-1, 0)
+		for i, k := range n.keys {
+			size += jsonStringSize(k.name) + 1
+			child := o.child(k.name, jsonPathChild(o.Path, k.name))
+			if child == nil {
+				size += jsonSize(n.items[i])
+				continue
+			}
+			size += child.add(n.items[i])
+		}
+	case docArray:
+		o.Children += len(n.items)
+		size = 2 + max(len(n.items)-1, 0)
+		if len(n.items) > 0 {
+			child := o.child("[*]", o.Path+"[*]")
+			for _, item := range n.items {
+				size += child.add(item)
+			}
+		}
+	default:
+		size = jsonSize(n)
+	}
+	o.Size += size
+	return size
+}
+
+// child finds or adds the child called name, or returns nil once the node
+// already lists outlineMaxKeys children.
+func (o *OutlineNode) child(name, path string) *OutlineNode {
+	if c, ok := o.index[name]; ok {
+		return c
+	}
+	if len(o.Nodes) >= outlineMaxKeys {
+		o.Truncated++
+		return nil
+	}
+	if o.index == nil {
+		o.index = map[string]*OutlineNode{}
+	}
+	c := &OutlineNode{Path: path, Name: name}
+	o.index[name] = c
+	o.Nodes = append(o.Nodes, c)
+	return c
+}
+
+// finish sets the Type of every node and returns the number of nodes.
+func (o *OutlineNode) finish() int {
+	o.Type = strings.Join(o.types, "|")
+	o.types, o.index = nil, nil
+	n := 1
+	for _, c := range o.Nodes {
+		n += c.finish()
+	}
+	return n
+}
+
+func outlineType(n *docNode) string {
+	switch n.kind {
+	case docObject:
+		return "object"
+	case docArray:
+		return "array"
+	case docString:
+		return "string"
+	case docNumber:
+		return "number"
+	case docBool:
+		return "boolean"
+	}
+	return "null"
+}
+
+// jsonSize returns the length of n encoded as compact JSON.
+func jsonSize(n *docNode) int {
+	switch n.kind {
+	case docObject:
+		size := 2 + max(len(n.keys)-1, 0)
+		for i, k := range n.keys {
+			size += jsonStringSize(k.name) + 1 + jsonSize(n.items[i])
+		}
+		return size
+	case docArray:
+		size := 2 + max(len(n.items)-1, 0)
+		for _, item := range n.items {
+			size += jsonSize(item)
+		}
+		return size
+	case docString:
+		return jsonStringSize(n.text)
+	case docNumber, docBool:
+		return len(n.text)
+	}
+	return len("null")
+}
+
+// jsonStringSize returns the length of s as a quoted JSON string, escaped
+// as encodeJSON does.
+func jsonStringSize(s string) int {
+	size := 2
+	for i := 0; i < len(s); {
+		r, width := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case r == '"' || r == '\\' || r == '\n' || r == '\r' || r == '\t' || r == '\b' || r == '\f':
+			size += 2
+		case r < 0x20 || r == '\u2028' || r == '\u2029' || r == utf8.RuneError && width == 1:
+			size += 6
+		default:
+			size += width
+		}
+		i += width
+	}
+	return size
+}
+
+// mermaid renders the outline as a left-to-right flowchart, breadth first
+// so that a chart cut at outlineMermaidNodes keeps the upper levels.
+func (o *OutlineNode) mermaid(total int) string {
+	var b strings.Builder
+	b.WriteString("flowchart LR\n")
+	type item struct {
+		node   *OutlineNode
+		id     int
+		parent int
+	}
+	queue := []item{{node: o, parent: -1}}
+	ids := 1
+	for len(queue) > 0 {
+		it := queue[0]
+		queue = queue[1:]
+		if it.id >= outlineMermaidNodes {
+			fmt.Fprintf(&b, "  more[\"%s not shown\"]\n", plural(total-it.id, "more node"))
+			break
+		}
+		label := fmt.Sprintf("  n%d[\"%s\"]", it.id, mermaidLabel(it.node))
+		if it.parent >= 0 {
+			label = fmt.Sprintf("  n%d --> %s", it.parent, strings.TrimSpace(label))
+		}
+		b.WriteString(label + "\n")
+		for _, c := range it.node.Nodes {
+			queue = append(queue, item{node: c, id: ids, parent: it.id})
+			ids++
+		}
+	}
+	return b.String()
+}
+
+func mermaidLabel(o *OutlineNode) string {
+	parts := []string{o.Name, o.Type}
+	switch {
+	case strings.Contains(o.Type, "object"):
+		keys := plural(len(o.Nodes), "key")
+		if o.Truncated > 0 {
+			keys += "+"
+		}
+		parts = append(parts, keys)
+	case strings.Contains(o.Type, "array"):
+		parts = append(parts, plural(o.Children, "item"))
+	}
+	if o.Count > 1 {
+		parts = append(parts, "×"+strconv.Itoa(o.Count))
+	}
+	parts = append(parts, outlineBytes(o.Size))
+	return strings.NewReplacer(`"`, "#quot;", "\n", " ").Replace(strings.Join(parts, " · "))
+}
+
+func plural(n int, word string) string {
+	if n == 1 {
+		return "1 " + word
+	}
+	return strconv.Itoa(n) + " " + word + "s"
+}
+
+func outlineBytes(n int) string {
+	switch {
+	case n < 1000:
+		return strconv.Itoa(n) + " B"
+	case n < 1000*1000:
+		return strconv.FormatFloat(float64(n)/1000, 'f', 1, 64) + " KB"
+	}
+	return strconv.FormatFloat(float64(n)/1000/1000, 'f', 1, 64) + " MB"
+}
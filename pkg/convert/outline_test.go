@@ -0,0 +1,85 @@
+package convert
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStructureOutline(t *testing.T) {
+	const input = `{"name": "demo", "users": [
+		{"id": 1, "email": "a@example.com", "tags": ["x"]},
+		{"id": 2, "email": null, "admin": true, "note": "line\n\"quoted\" é\u2028"}
+	], "empty": {}}`
+	res, err := StructureOutline(input)
+	require.NoError(t, err)
+	require.Equal(t, formatJSON, res.Format)
+	require.Equal(t, 11, res.Nodes)
+
+	var compact bytes.Buffer
+	require.NoError(t, json.Compact(&compact, []byte(input)))
+	root := res.Root
+	require.Equal(t, "object", root.Type)
+	require.Equal(t, compact.Len(), root.Size)
+	require.Equal(t, []string{"name", "users", "empty"}, outlineNames(root))
+
+	users := root.Nodes[1]
+	require.Equal(t, OutlineNode{Path: "$.users", Name: "users", Type: "array", Count: 1, Children: 2, Size: users.Size, Nodes: users.Nodes}, *users)
+	record := users.Nodes[0]
+	require.Equal(t, "$.users[*]", record.Path)
+	require.Equal(t, 2, record.Count)
+	require.Equal(t, []string{"id", "email", "tags", "admin", "note"}, outlineNames(record))
+	require.Equal(t, "string|null", record.Nodes[1].Type)
+	require.Equal(t, 2, record.Nodes[1].Count)
+	require.Equal(t, "$.users[*].tags[*]", record.Nodes[2].Nodes[0].Path)
+	require.Equal(t, len(`"line\n\"quoted\" é\u2028"`), record.Nodes[4].Size)
+
+	require.Equal(t, "flowchart LR\n"+
+		"  n0[\"$ · object · 3 keys · 152 B\"]\n"+
+		"  n0 --> n1[\"name · string · 6 B\"]\n"+
+		"  n0 --> n2[\"users · array · 2 items · 117 B\"]\n"+
+		"  n0 --> n3[\"empty · object · 0 keys · 2 B\"]\n"+
+		"  n2 --> n4[\"[*] · object · 5 keys · ×2 · 114 B\"]\n",
+		res.Mermaid[:strings.Index(res.Mermaid, "  n4 -->")])
+
+	res, err = StructureOutline("server:\n  port: 80\n")
+	require.NoError(t, err)
+	require.Equal(t, formatYAML, res.Format)
+	require.Equal(t, "$.server.port", res.Root.Nodes[0].Nodes[0].Path)
+
+	_, err = StructureOutline("  ")
+	require.EqualError(t, err, "document is empty")
+}
+
+func TestStructureOutline_Limits(t *testing.T) {
+	var b strings.Builder
+	b.WriteString("{")
+	for i := range outlineMaxKeys + 5 {
+		if i > 0 {
+			b.WriteString(",")
+		}
+		fmt.Fprintf(&b, `"id%d":{"v":[%d,%d]}`, i, i, i)
+	}
+	b.WriteString("}")
+	res, err := StructureOutline(b.String())
+	require.NoError(t, err)
+	require.Len(t, res.Root.Nodes, outlineMaxKeys)
+	require.Equal(t, 5, res.Root.Truncated)
+	require.Equal(t, outlineMaxKeys+5, res.Root.Children)
+	require.Equal(t, len(b.String()), res.Root.Size)
+	require.Equal(t, 1+3*outlineMaxKeys, res.Nodes)
+	require.Contains(t, res.Mermaid, `n0["$ · object · 100 keys+ · `)
+	require.True(t, strings.HasSuffix(res.Mermaid, fmt.Sprintf("  more[\"%d more nodes not shown\"]\n", res.Nodes-outlineMermaidNodes)), res.Mermaid[len(res.Mermaid)-100:])
+}
+
+func outlineNames(o *OutlineNode) []string {
+	names := make([]string, len(o.Nodes))
+	for i, c := range o.Nodes {
+		names[i] = c.Name
+	}
+	return names
+}
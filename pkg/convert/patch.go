@@ -0,0 +1,491 @@
+package convert
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Patch kinds for ApplyPatch and DiffPatch.
+const (
+	PatchJSON  = "json-patch"
+	PatchMerge = MergePatch
+)
+
+// ApplyPatch applies patch to doc and returns the result as JSON, with
+// numbers, and the key order of JSON input, as written. kind is PatchJSON,
+// an RFC 6902 array of add, remove, replace, move, copy and test
+// operations applied in turn, or PatchMerge, an RFC 7386 merge patch. doc
+// and patch may be JSON or any format ConvertFormats reads.
+func ApplyPatch(kind, doc, patch string) (string, error) {
+	_, target, err := decodeDocumentNode(doc)
+	if err != nil {
+		return "", fmt.Errorf("document: %w", err)
+	}
+	_, p, err := decodeDocumentNode(patch)
+	if err != nil {
+		return "", fmt.Errorf("patch: %w", err)
+	}
+	switch kind {
+	case PatchJSON:
+		if target, err = applyJSONPatch(target, p); err != nil {
+			return "", err
+		}
+	case PatchMerge:
+		target = applyMergePatch(target, p)
+	default:
+		return "", fmt.Errorf("unsupported patch kind %q", kind)
+	}
+	return docToJSON(target, "  ")
+}
+
+// DiffPatch returns the patch of kind that turns from into to, as JSON.
+// A JSON Patch adds, removes and replaces values, with arrays compared
+// element by element; a merge patch replaces arrays whole and cannot set a
+// member to null, so that difference is an error.
+func DiffPatch(kind, from, to string) (string, error) {
+	_, a, err := decodeDocumentNode(from)
+	if err != nil {
+		return "", fmt.Errorf("from: %w", err)
+	}
+	_, b, err := decodeDocumentNode(to)
+	if err != nil {
+		return "", fmt.Errorf("to: %w", err)
+	}
+	var arena docArena
+	var patch *docNode
+	switch kind {
+	case PatchJSON:
+		patch = arena.node(docArray, docPos{})
+		for _, c := range diffDocNodes(a, b, "", nil) {
+			op := map[string]string{changeAdded: "add", changeRemoved: "remove", changeChanged: "replace"}[c.Kind]
+			patch.items = append(patch.items, patchOperation(&arena, op, c.Path, c.to))
+		}
+	case PatchMerge:
+		if patch, err = mergePatchDiff(&arena, a, b, ""); err != nil {
+			return "", err
+		}
+	default:
+		return "", fmt.Errorf("unsupported patch kind %q", kind)
+	}
+	return docToJSON(patch, "  ")
+}
+
+// Kinds of docChange.
+const (
+	changeAdded   = "added"
+	changeRemoved = "removed"
+	changeChanged = "changed"
+)
+
+// docChange is one difference between two documents at a JSON Pointer:
+// from is nil for an added value and to for a removed one.
+type docChange struct {
+	Kind     string
+	Path     string
+	from, to *docNode
+}
+
+// diffDocNodes appends the differences between a and b at path to out:
+// objects by key, in a's order and then b's, and arrays by index, with
+// extra elements of a removed from the last so that applying the changes
+// in order is valid.
+func diffDocNodes(a, b *docNode, path string, out []docChange) []docChange {
+	switch {
+	case a.kind == docObject && b.kind == docObject:
+		for i, k := range a.keys {
+			child := path + "/" + escapePointer(k.name)
+			if j := b.memberIndex(k.name); j >= 0 {
+				out = diffDocNodes(a.items[i], b.items[j], child, out)
+			} else {
+				out = append(out, docChange{Kind: changeRemoved, Path: child, from: a.items[i]})
+			}
+		}
+		for j, k := range b.keys {
+			if a.memberIndex(k.name) < 0 {
+				out = append(out, docChange{Kind: changeAdded, Path: path + "/" + escapePointer(k.name), to: b.items[j]})
+			}
+		}
+	case a.kind == docArray && b.kind == docArray:
+		common := min(len(a.items), len(b.items))
+		for i := range common {
+			out = diffDocNodes(a.items[i], b.items[i], path+"/"+strconv.Itoa(i), out)
+		}
+		for i := common; i < len(b.items); i++ {
+			out = append(out, docChange{Kind: changeAdded, Path: path + "/" + strconv.Itoa(i), to: b.items[i]})
+		}
+		for i := len(a.items) - 1; i >= common; i-- {
+			out = append(out, docChange{Kind: changeRemoved, Path: path + "/" + strconv.Itoa(i), from: a.items[i]})
+		}
+	case !docEqual(a, b) || a.kind == docNumber && a.text != b.text && !numbersEqual(a.text, b.text):
+		out = append(out, docChange{Kind: changeChanged, Path: path, from: a, to: b})
+	}
+	return out
+}
+
+// numbersEqual compares two number literals exactly, so integers too
+// large for a float64 still differ.
+func numbersEqual(a, b string) bool {
+	x, okX := new(big.Rat).SetString(a)
+	y, okY := new(big.Rat).SetString(b)
+	return okX && okY && x.Cmp(y) == 0
+}
+
+func patchOperation(a *docArena, op, path string, value *docNode) *docNode {
+	n := a.node(docObject, docPos{})
+	opNode := a.node(docString, docPos{})
+	opNode.text = op
+	pathNode := a.node(docString, docPos{})
+	pathNode.text = path
+	n.keys = []docKey{{name: "op"}, {name: "path"}}
+	n.items = []*docNode{opNode, pathNode}
+	if value != nil {
+		n.keys = append(n.keys, docKey{name: "value"})
+		n.items = append(n.items, value)
+	}
+	return n
+}
+
+// mergePatchDiff builds the merge patch from a to b.
+func mergePatchDiff(arena *docArena, a, b *docNode, path string) (*docNode, error) {
+	if a.kind != docObject || b.kind != docObject {
+		if err := checkNoNullMembers(b, path); err != nil {
+			return nil, err
+		}
+		return b, nil
+	}
+	patch := arena.node(docObject, docPos{})
+	for _, k := range a.keys {
+		if b.memberIndex(k.name) < 0 {
+			patch.keys = append(patch.keys, docKey{name: k.name})
+			patch.items = append(patch.items, arena.node(docNull, docPos{}))
+		}
+	}
+	for j, k := range b.keys {
+		child := path + "/" + escapePointer(k.name)
+		value := b.items[j]
+		if i := a.memberIndex(k.name); i >= 0 {
+			if len(diffDocNodes(a.items[i], value, "", nil)) == 0 {
+				continue
+			}
+			var err error
+			if value, err = mergePatchDiff(arena, a.items[i], value, child); err != nil {
+				return nil, err
+			}
+		} else if err := checkNoNullMembers(value, child); err != nil {
+			return nil, err
+		}
+		patch.keys = append(patch.keys, docKey{name: k.name})
+		patch.items = append(patch.items, value)
+	}
+	return patch, nil
+}
+
+// checkNoNullMembers reports a null object member, which a merge patch
+// would read as a deletion. Arrays are replaced whole, so nulls in them
+// are fine.
+func checkNoNullMembers(n *docNode, path string) error {
+	if n.kind == docNull && path != "" {
+		return fmt.Errorf("a merge patch cannot set %s to null", path)
+	}
+	if n.kind != docObject {
+		return nil
+	}
+	for i, k := range n.keys {
+		if err := checkNoNullMembers(n.items[i], path+"/"+escapePointer(k.name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyMergePatch merges patch into target as RFC 7386 describes.
+func applyMergePatch(target, patch *docNode) *docNode {
+	if patch.kind != docObject {
+		return patch
+	}
+	if target == nil || target.kind != docObject {
+		target = &docNode{kind: docObject}
+	} else {
+		target = target.shallowCopy()
+	}
+	for j, k := range patch.keys {
+		i := target.memberIndex(k.name)
+		if patch.items[j].kind == docNull {
+			if i >= 0 {
+				target.deleteItem(i)
+			}
+			continue
+		}
+		if i >= 0 {
+			target.items[i] = applyMergePatch(target.items[i], patch.items[j])
+		} else {
+			target.keys = append(target.keys, docKey{name: k.name})
+			target.items = append(target.items, applyMergePatch(nil, patch.items[j]))
+		}
+	}
+	return target
+}
+
+// applyJSONPatch runs the operations of an RFC 6902 patch on doc.
+func applyJSONPatch(doc, patch *docNode) (*docNode, error) {
+	if patch.kind != docArray {
+		return nil, errors.New("a JSON Patch must be an array of operations")
+	}
+	for i, op := range patch.items {
+		var err error
+		if doc, err = applyPatchOperation(doc, op); err != nil {
+			return nil, fmt.Errorf("operation %d: %w", i, err)
+		}
+	}
+	return doc, nil
+}
+
+func applyPatchOperation(doc, op *docNode) (*docNode, error) {
+	if op.kind != docObject {
+		return nil, errors.New("operation must be an object")
+	}
+	member := func(name string) (*docNode, bool) {
+		if i := op.memberIndex(name); i >= 0 {
+			return op.items[i], true
+		}
+		return nil, false
+	}
+	pointer := func(name string) ([]string, error) {
+		v, ok := member(name)
+		if !ok || v.kind != docString {
+			return nil, fmt.Errorf("%q must be a string", name)
+		}
+		return parsePointer(v.text)
+	}
+	kind, _ := member("op")
+	if kind == nil || kind.kind != docString {
+		return nil, errors.New(`"op" must be a string`)
+	}
+	path, err := pointer("path")
+	if err != nil {
+		return nil, err
+	}
+	value, hasValue := member("value")
+	switch kind.text {
+	case "add", "replace", "test":
+		if !hasValue {
+			return nil, fmt.Errorf("%s needs a value", kind.text)
+		}
+	}
+	switch kind.text {
+	case "add":
+		return docAdd(doc, path, value.clone())
+	case "remove":
+		// RFC 6902 has no document without a value.
+		if len(path) == 0 {
+			return nil, errors.New("cannot remove the whole document")
+		}
+		doc, _, err = docRemove(doc, path)
+		return doc, err
+	case "replace":
+		if doc, _, err = docRemove(doc, path); err != nil {
+			return nil, err
+		}
+		return docAdd(doc, path, value.clone())
+	case "move", "copy":
+		from, err := pointer("from")
+		if err != nil {
+			return nil, err
+		}
+		if kind.text == "move" {
+			if len(path) > len(from) && slicesHavePrefix(path, from) {
+				return nil, errors.New("cannot move a value into itself")
+			}
+			if doc, value, err = docRemove(doc, from); err != nil {
+				return nil, err
+			}
+		} else {
+			if value, err = docGet(doc, from); err != nil {
+				return nil, err
+			}
+			value = value.clone()
+		}
+		return docAdd(doc, path, value)
+	case "test":
+		got, err := docGet(doc, path)
+		if err != nil {
+			return nil, err
+		}
+		if !docEqual(got, value) {
+			return nil, fmt.Errorf("test failed: %s is %s", pointerString(path), got.appendJSON(nil))
+		}
+		return doc, nil
+	}
+	return nil, fmt.Errorf("unknown op %q", kind.text)
+}
+
+func slicesHavePrefix(s, prefix []string) bool {
+	for i := range prefix {
+		if s[i] != prefix[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// parsePointer splits an RFC 6901 JSON Pointer into unescaped tokens.
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if pointer[0] != '/' {
+		return nil, fmt.Errorf("pointer %q must start with /", pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, t := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(t, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+func escapePointer(token string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1")
+}
+
+func pointerString(tokens []string) string {
+	var b strings.Builder
+	for _, t := range tokens {
+		b.WriteString("/" + escapePointer(t))
+	}
+	return b.String()
+}
+
+// arrayIndex reads an array index token, which has no sign or leading
+// zeros; "-" is the index past the end when end is set.
+func arrayIndex(token string, n int, end bool) (int, error) {
+	if end && token == "-" {
+		return n, nil
+	}
+	i, err := strconv.Atoi(token)
+	if err != nil || token[0] == '+' || token[0] == '-' || len(token) > 1 && token[0] == '0' {
+		return 0, fmt.Errorf("%q is not an array index", token)
+	}
+	limit := n - 1
+	if end {
+		limit = n
+	}
+	if i > limit {
+		return 0, fmt.Errorf("index %d is out of range", i)
+	}
+	return i, nil
+}
+
+// docGet returns the value at path.
+func docGet(doc *docNode, path []string) (*docNode, error) {
+	n := doc
+	for i, token := range path {
+		switch n.kind {
+		case docObject:
+			j := n.memberIndex(token)
+			if j < 0 {
+				return nil, fmt.Errorf("%s does not exist", pointerString(path[:i+1]))
+			}
+			n = n.items[j]
+		case docArray:
+			j, err := arrayIndex(token, len(n.items), false)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", pointerString(path[:i+1]), err)
+			}
+			n = n.items[j]
+		default:
+			return nil, fmt.Errorf("%s does not exist", pointerString(path[:i+1]))
+		}
+	}
+	return n, nil
+}
+
+// docAdd sets the member or inserts the element at path to value, and
+// returns the document, which is value itself for the root.
+func docAdd(doc *docNode, path []string, value *docNode) (*docNode, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	parent, err := docGet(doc, path[:len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+	last := path[len(path)-1]
+	switch parent.kind {
+	case docObject:
+		if i := parent.memberIndex(last); i >= 0 {
+			parent.items[i] = value
+		} else {
+			parent.keys = append(parent.keys, docKey{name: last})
+			parent.items = append(parent.items, value)
+		}
+	case docArray:
+		i, err := arrayIndex(last, len(parent.items), true)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", pointerString(path), err)
+		}
+		items := make([]*docNode, 0, len(parent.items)+1)
+		items = append(append(append(items, parent.items[:i]...), value), parent.items[i:]...)
+		parent.items = items
+	default:
+		return nil, fmt.Errorf("%s does not exist", pointerString(path[:len(path)-1]))
+	}
+	return doc, nil
+}
+
+// docRemove deletes the value at path and returns the document and the
+// value; removing the root, as replace and move do, leaves null.
+func docRemove(doc *docNode, path []string) (*docNode, *docNode, error) {
+	if len(path) == 0 {
+		return &docNode{kind: docNull}, doc, nil
+	}
+	parent, err := docGet(doc, path[:len(path)-1])
+	if err != nil {
+		return nil, nil, err
+	}
+	last := path[len(path)-1]
+	i := -1
+	switch parent.kind {
+	case docObject:
+		i = parent.memberIndex(last)
+	case docArray:
+		if i, err = arrayIndex(last, len(parent.items), false); err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", pointerString(path), err)
+		}
+	}
+	if i < 0 {
+		return nil, nil, fmt.Errorf("%s does not exist", pointerString(path))
+	}
+	removed := parent.items[i]
+	parent.deleteItem(i)
+	return doc, removed, nil
+}
+
+// deleteItem drops the element, or member, at i without writing into
+// slices the node may share.
+func (n *docNode) deleteItem(i int) {
+	n.items = append(n.items[:i:i], n.items[i+1:]...)
+	if n.kind == docObject {
+		n.keys = append(n.keys[:i:i], n.keys[i+1:]...)
+	}
+}
+
+// shallowCopy returns a copy of n whose keys and items can be changed
+// without touching n.
+func (n *docNode) shallowCopy() *docNode {
+	c := *n
+	c.keys = append([]docKey(nil), n.keys...)
+	c.items = append([]*docNode(nil), n.items...)
+	return &c
+}
+
+// clone copies n and everything under it.
+func (n *docNode) clone() *docNode {
+	c := n.shallowCopy()
+	for i, item := range c.items {
+		c.items[i] = item.clone()
+	}
+	return c
+}
@@ -0,0 +1,116 @@
+package convert
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyPatch(t *testing.T) {
+	for _, c := range []struct {
+		doc, patch, want string
+	}{
+		{`{"foo": "bar"}`, `[{"op": "add", "path": "/baz", "value": "qux"}]`, `{"foo": "bar", "baz": "qux"}`},
+		{`{"foo": ["bar", "baz"]}`, `[{"op": "add", "path": "/foo/1", "value": "qux"}]`, `{"foo": ["bar", "qux", "baz"]}`},
+		{`{"foo": ["bar"]}`, `[{"op": "add", "path": "/foo/-", "value": ["abc", "def"]}]`, `{"foo": ["bar", ["abc", "def"]]}`},
+		{`{"baz": "qux", "foo": "bar"}`, `[{"op": "remove", "path": "/baz"}]`, `{"foo": "bar"}`},
+		{`{"foo": ["bar", "qux", "baz"]}`, `[{"op": "remove", "path": "/foo/1"}]`, `{"foo": ["bar", "baz"]}`},
+		{`{"baz": "qux", "foo": "bar"}`, `[{"op": "replace", "path": "/baz", "value": "boo"}]`, `{"baz": "boo", "foo": "bar"}`},
+		{`{"foo": {"bar": "baz", "waldo": "fred"}, "qux": {"corge": "grault"}}`,
+			`[{"op": "move", "from": "/foo/waldo", "path": "/qux/thud"}]`,
+			`{"foo": {"bar": "baz"}, "qux": {"corge": "grault", "thud": "fred"}}`},
+		{`{"foo": ["all", "grass", "cows", "eat"]}`, `[{"op": "move", "from": "/foo/1", "path": "/foo/3"}]`, `{"foo": ["all", "cows", "eat", "grass"]}`},
+		{`{"a": {"b": 1}}`, `[{"op": "copy", "from": "/a", "path": "/c"}, {"op": "replace", "path": "/c/b", "value": 2}]`, `{"a": {"b": 1}, "c": {"b": 2}}`},
+		{`{"baz": "qux", "foo": ["a", 2, "c"]}`,
+			`[{"op": "test", "path": "/baz", "value": "qux"}, {"op": "test", "path": "/foo/1", "value": 2.0}]`,
+			`{"baz": "qux", "foo": ["a", 2, "c"]}`},
+		{`{"/": 0, "m~n": 1}`, `[{"op": "replace", "path": "/~1", "value": 2}, {"op": "remove", "path": "/m~0n"}]`, `{"/": 2}`},
+		{`{"a": 1}`, `[{"op": "replace", "path": "", "value": [1]}]`, `[1]`},
+	} {
+		got, err := ApplyPatch(PatchJSON, c.doc, c.patch)
+		require.NoError(t, err, c.patch)
+		require.JSONEq(t, c.want, got, c.patch)
+	}
+
+	// Key order and numbers come through as written.
+	got, err := ApplyPatch(PatchJSON, `{"b": 12345678901234567890, "a": 1}`, `[{"op": "add", "path": "/c", "value": 1.50}]`)
+	require.NoError(t, err)
+	require.Equal(t, "{\n  \"b\": 12345678901234567890,\n  \"a\": 1,\n  \"c\": 1.50\n}", got)
+
+	for _, c := range []struct {
+		doc, patch, err string
+	}{
+		{`{"baz": "qux"}`, `[{"op": "test", "path": "/baz", "value": "bar"}]`, `operation 0: test failed: /baz is "qux"`},
+		{`{"foo": "bar"}`, `[{"op": "add", "path": "/baz/bat", "value": "qux"}]`, `operation 0: /baz does not exist`},
+		{`{"a": [1]}`, `[{"op": "add", "path": "/a/01", "value": 2}]`, `operation 0: /a/01: "01" is not an array index`},
+		{`{"a": [1]}`, `[{"op": "remove", "path": "/a/1"}]`, `operation 0: /a/1: index 1 is out of range`},
+		{`{"a": 1}`, `[{"op": "remove", "path": ""}]`, `operation 0: cannot remove the whole document`},
+		{`{"a": {"b": 1}}`, `[{"op": "move", "from": "/a", "path": "/a/b/c"}]`, `operation 0: cannot move a value into itself`},
+		{`{}`, `[{"op": "add", "path": "/a"}]`, `operation 0: add needs a value`},
+		{`{}`, `[{"op": "nope", "path": ""}]`, `operation 0: unknown op "nope"`},
+		{`{}`, `[{"op": "remove", "path": "a"}]`, `operation 0: pointer "a" must start with /`},
+		{`{}`, `{"op": "remove"}`, `a JSON Patch must be an array of operations`},
+	} {
+		_, err := ApplyPatch(PatchJSON, c.doc, c.patch)
+		require.EqualError(t, err, c.err, c.patch)
+	}
+	_, err = ApplyPatch("nope", `{}`, `{}`)
+	require.Error(t, err)
+}
+
+func TestApplyMergePatch(t *testing.T) {
+	// The examples of RFC 7386, appendix A.
+	for _, c := range [][3]string{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	} {
+		got, err := ApplyPatch(PatchMerge, c[0], c[1])
+		require.NoError(t, err, c[1])
+		require.JSONEq(t, c[2], got, c[1])
+	}
+}
+
+func TestDiffPatch(t *testing.T) {
+	from := `{"name": "demo", "tags": ["a", "b", "c"], "meta": {"x": 1, "y": 2}, "id": 12345678901234567890}`
+	to := `{"name": "demo", "tags": ["a", "z"], "meta": {"x": 1.0, "z": null}, "id": 12345678901234567891, "a/b": true}`
+
+	patch, err := DiffPatch(PatchJSON, from, to)
+	require.NoError(t, err)
+	require.JSONEq(t, `[
+		{"op": "replace", "path": "/tags/1", "value": "z"},
+		{"op": "remove", "path": "/tags/2"},
+		{"op": "remove", "path": "/meta/y"},
+		{"op": "add", "path": "/meta/z", "value": null},
+		{"op": "replace", "path": "/id", "value": 12345678901234567891},
+		{"op": "add", "path": "/a~1b", "value": true}
+	]`, patch)
+	got, err := ApplyPatch(PatchJSON, from, patch)
+	require.NoError(t, err)
+	require.JSONEq(t, to, got)
+
+	patch, err = DiffPatch(PatchJSON, `{"a": 1}`, `{"a": 1}`)
+	require.NoError(t, err)
+	require.Equal(t, "[]", patch)
+
+	patch, err = DiffPatch(PatchMerge, `{"a": {"b": 1, "c": 2}, "d": [1, 2], "e": 1}`, `{"a": {"b": 1, "c": 3}, "d": [1], "f": {"g": [null]}}`)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"e": null, "a": {"c": 3}, "d": [1], "f": {"g": [null]}}`, patch)
+	_, err = DiffPatch(PatchMerge, from, to)
+	require.EqualError(t, err, "a merge patch cannot set /meta/z to null")
+
+	// YAML reads like JSON.
+	patch, err = DiffPatch(PatchMerge, "a: 1\nb: 2\n", "a: 1\nb: 3\n")
+	require.NoError(t, err)
+	require.JSONEq(t, `{"b": 3}`, patch)
+}
@@ -0,0 +1,232 @@
+package convert
+
+import (
+	"fmt"
+	"math/big"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/linzeyan/transform-go/pkg/common"
+)
+
+const (
+	PIIEmail      = "email"
+	PIIPhone      = "phone"
+	PIICreditCard = "credit-card"
+	PIIIBAN       = "iban"
+	PIIUSSSN      = "us-ssn"
+	PIITaiwanID   = "tw-national-id"
+)
+
+var (
+	piiEmailRe  = common.LazyRegexp(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+	piiCardRe   = common.LazyRegexp(`\b(?:\d[ -]?){12,18}\d\b`)
+	piiIBANRe   = common.LazyRegexp(`\b[A-Z]{2}\d{2}(?: ?[A-Z0-9]{4}){2,7}(?: ?[A-Z0-9]{1,4})?\b`)
+	piiSSNRe    = common.LazyRegexp(`\b\d{3}-\d{2}-\d{4}\b`)
+	piiTWIDRe   = common.LazyRegexp(`\b[A-Z][12]\d{8}\b`)
+	piiPhoneRe  = common.LazyRegexp(`(?:\+\d{1,3}[ .-]?)?(?:\(\d{1,4}\)[ .-]?)?\d{2,4}(?:[ .-]?\d{2,4}){1,3}`)
+	piiDigitsRe = common.LazyRegexp(`\d`)
+	piiDateRe   = common.LazyRegexp(`^\d{4}[-./]\d{1,2}[-./]\d{1,2}$`)
+)
+
+// PIIFinding is one value that looks like personal data. Path is a
+// JSONPath-style location ($.users[0].email) and Masked hides all but the
+// last few characters of the match.
+type PIIFinding struct {
+	Path   string `json:"path"`
+	Kind   string `json:"kind"`
+	Masked string `json:"masked"`
+}
+
+// PIIReport lists findings in document order with a count per kind.
+type PIIReport struct {
+	Findings []PIIFinding   `json:"findings"`
+	Counts   map[string]int `json:"counts"`
+}
+
+// ScanPII walks a JSON document (or any format ConvertFormats can read) and
+// flags string and number values containing email addresses, phone numbers,
+// Luhn-valid card numbers, IBANs, US social security numbers and Taiwanese
+// national IDs.
+func ScanPII(input string) (PIIReport, error) {
+	report := PIIReport{Findings: []PIIFinding{}, Counts: map[string]int{}}
+	data, err := decodeDocument(input)
+	if err != nil {
+		return report, err
+	}
+	scanPIIValue(data, "$", &report)
+	return report, nil
+}
+
+func scanPIIValue(v any, path string, report *PIIReport) {
+	switch val := v.(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			scanPIIValue(val[k], jsonPathChild(path, k), report)
+		}
+	case []any:
+		for i, item := range val {
+			scanPIIValue(item, fmt.Sprintf("%s[%d]", path, i), report)
+		}
+	case string, fmt.Stringer:
+		for _, m := range findPII(fmt.Sprint(val)) {
+			report.Findings = append(report.Findings, PIIFinding{Path: path, Kind: m.kind, Masked: maskPII(m.text)})
+			report.Counts[m.kind]++
+		}
+	}
+}
+
+func jsonPathChild(path, key string) string {
+	for _, r := range key {
+		if !(r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9') {
+			return fmt.Sprintf("%s[%s]", path, strconv.Quote(key))
+		}
+	}
+	if key == "" || key[0] >= '0' && key[0] <= '9' {
+		return fmt.Sprintf("%s[%s]", path, strconv.Quote(key))
+	}
+	return path + "." + key
+}
+
+type piiMatch struct {
+	kind       string
+	text       string
+	start, end int
+}
+
+// findPII runs the detectors from most to least specific; a later match
+// that overlaps an earlier one is dropped, so a card number is not also
+// reported as a phone number.
+func findPII(s string) []piiMatch {
+	detectors := []struct {
+		kind  string
+		re    *regexp.Regexp
+		valid func(string) bool
+	}{
+		{PIIEmail, piiEmailRe(), nil},
+		{PIIIBAN, piiIBANRe(), validIBAN},
+		{PIICreditCard, piiCardRe(), validLuhn},
+		{PIIUSSSN, piiSSNRe(), validSSN},
+		{PIITaiwanID, piiTWIDRe(), validTaiwanID},
+		{PIIPhone, piiPhoneRe(), plausiblePhone},
+	}
+	var matches []piiMatch
+	for _, d := range detectors {
+		for _, loc := range d.re.FindAllStringIndex(s, -1) {
+			text := s[loc[0]:loc[1]]
+			if d.valid != nil && !d.valid(text) {
+				continue
+			}
+			overlaps := false
+			for _, m := range matches {
+				if loc[0] < m.end && m.start < loc[1] {
+					overlaps = true
+					break
+				}
+			}
+			if !overlaps {
+				matches = append(matches, piiMatch{kind: d.kind, text: text, start: loc[0], end: loc[1]})
+			}
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool { return matches[i].start < matches[j].start })
+	return matches
+}
+
+func validLuhn(s string) bool {
+	digits := piiDigitsRe().FindAllString(s, -1)
+	if len(digits) < 13 || len(digits) > 19 {
+		return false
+	}
+	sum := 0
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := int(digits[i][0] - '0')
+		if (len(digits)-1-i)%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+	}
+	return sum%10 == 0
+}
+
+// validIBAN applies the ISO 13616 mod-97 check.
+func validIBAN(s string) bool {
+	compact := strings.ReplaceAll(s, " ", "")
+	if len(compact) < 15 || len(compact) > 34 {
+		return false
+	}
+	var digits strings.Builder
+	for _, r := range compact[4:] + compact[:4] {
+		if r >= 'A' && r <= 'Z' {
+			digits.WriteString(strconv.Itoa(int(r-'A') + 10))
+		} else {
+			digits.WriteRune(r)
+		}
+	}
+	n, ok := new(big.Int).SetString(digits.String(), 10)
+	return ok && new(big.Int).Mod(n, big.NewInt(97)).Int64() == 1
+}
+
+// validSSN rejects the area, group and serial numbers the SSA never issues.
+func validSSN(s string) bool {
+	area, group, serial := s[:3], s[4:6], s[7:]
+	return area != "000" && area != "666" && area[0] != '9' && group != "00" && serial != "0000"
+}
+
+// validTaiwanID checks the letter-weighted checksum of a national ID card
+// number such as A123456789.
+func validTaiwanID(s string) bool {
+	const letters = "ABCDEFGHJKLMNPQRSTUVXYWZIO"
+	code := strings.IndexByte(letters, s[0]) + 10
+	if code < 10 {
+		return false
+	}
+	sum := code/10 + code%10*9
+	for i := 1; i < 9; i++ {
+		sum += int(s[i]-'0') * (9 - i)
+	}
+	sum += int(s[9] - '0')
+	return sum%10 == 0
+}
+
+// plausiblePhone keeps 8 to 15 digit numbers written the way phone numbers
+// usually are: with a country code, separators or a leading zero. Dates and
+// IPv4 addresses are not phone numbers.
+func plausiblePhone(s string) bool {
+	digits := len(piiDigitsRe().FindAllString(s, -1))
+	if digits < 8 || digits > 15 || piiDateRe().MatchString(s) || parseIPv4(s) != nil {
+		return false
+	}
+	return strings.HasPrefix(s, "+") || strings.ContainsAny(s, " .-()") || (s[0] == '0' && digits == 10)
+}
+
+// maskPII keeps separators and the last four letters or digits.
+func maskPII(s string) string {
+	if at := strings.IndexByte(s, '@'); at > 0 {
+		return s[:1] + strings.Repeat("*", at-1) + s[at:]
+	}
+	runes := []rune(s)
+	keep := 4
+	for i := len(runes) - 1; i >= 0; i-- {
+		r := runes[i]
+		if r == ' ' || r == '-' || r == '.' || r == '(' || r == ')' || r == '+' {
+			continue
+		}
+		if keep > 0 {
+			keep--
+			continue
+		}
+		runes[i] = '*'
+	}
+	return string(runes)
+}
@@ -0,0 +1,46 @@
+package convert
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestScanPII(t *testing.T) {
+	report, err := ScanPII(`{
+		"users": [
+			{"email": "jane.doe@example.com", "card": "4111 1111 1111 1111", "phone": "+1 (555) 123-4567"},
+			{"notes": "SSN 123-45-6789, id A123456789, iban GB82 WEST 1234 5698 7654 32", "card": 4111111111111112}
+		],
+		"meta": {"created": "2024-01-02", "ip": "192.168.100.200", "order id": 12345678}
+	}`)
+	require.NoError(t, err)
+	require.Equal(t, []PIIFinding{
+		{Path: "$.users[0].card", Kind: PIICreditCard, Masked: "**** **** **** 1111"},
+		{Path: "$.users[0].email", Kind: PIIEmail, Masked: "j*******@example.com"},
+		{Path: "$.users[0].phone", Kind: PIIPhone, Masked: "+* (***) ***-4567"},
+		{Path: "$.users[1].notes", Kind: PIIUSSSN, Masked: "***-**-6789"},
+		{Path: "$.users[1].notes", Kind: PIITaiwanID, Masked: "******6789"},
+		{Path: "$.users[1].notes", Kind: PIIIBAN, Masked: "**** **** **** **** **54 32"},
+	}, report.Findings)
+	require.Equal(t, map[string]int{PIICreditCard: 1, PIIEmail: 1, PIIPhone: 1, PIIUSSSN: 1, PIITaiwanID: 1, PIIIBAN: 1}, report.Counts)
+
+	report, err = ScanPII("contact:\n  mobile: \"0912345678\"\n  \"e-mail\": a@b.co\n")
+	require.NoError(t, err)
+	require.Len(t, report.Findings, 2)
+	require.Equal(t, `$.contact["e-mail"]`, report.Findings[0].Path)
+	require.Equal(t, PIIPhone, report.Findings[1].Kind)
+
+	_, err = ScanPII("")
+	require.Error(t, err)
+}
+
+func TestPIIValidators(t *testing.T) {
+	require.True(t, validLuhn("4012-8888-8888-1881"))
+	require.False(t, validLuhn("4012-8888-8888-1882"))
+	require.False(t, validSSN("000-12-3456"))
+	require.False(t, validSSN("900-12-3456"))
+	require.False(t, validTaiwanID("A123456788"))
+	require.True(t, validIBAN("DE89370400440532013000"))
+	require.False(t, validIBAN("DE89370400440532013001"))
+}
@@ -0,0 +1,232 @@
+package convert
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	PivotFirst = "first"
+	PivotLast  = "last"
+	PivotSum   = "sum"
+	PivotCount = "count"
+	PivotAvg   = "avg"
+	PivotMin   = "min"
+	PivotMax   = "max"
+	PivotList  = "list"
+)
+
+// PivotOptions turns long records into wide ones: every distinct value of
+// Key becomes a column holding Value. Rows are grouped by Index (default:
+// every other column) and Aggregate (default "first") combines collisions.
+type PivotOptions struct {
+	Index     []string `json:"index,omitempty"`
+	Key       string   `json:"key"`
+	Value     string   `json:"value"`
+	Aggregate string   `json:"aggregate,omitempty"`
+}
+
+// UnpivotOptions turns wide records into long ones: each of Columns
+// (default: every column not in Index) becomes a row with the column name
+// in Key (default "key") and the cell in Value (default "value").
+type UnpivotOptions struct {
+	Index   []string `json:"index,omitempty"`
+	Columns []string `json:"columns,omitempty"`
+	Key     string   `json:"key,omitempty"`
+	Value   string   `json:"value,omitempty"`
+	// KeepEmpty keeps rows whose value is null or empty.
+	KeepEmpty bool `json:"keepEmpty,omitempty"`
+}
+
+// PivotJSON pivots a JSON array of objects from long to wide format.
+func PivotJSON(input string, opts PivotOptions) (string, error) {
+	table, err := jsonTable(input, false)
+	if err != nil {
+		return "", err
+	}
+	if table, err = table.pivot(opts); err != nil {
+		return "", err
+	}
+	return table.json()
+}
+
+// PivotCSV pivots CSV with a header row from long to wide format.
+func PivotCSV(input string, opts PivotOptions, csvOpts CSVOptions) (string, error) {
+	table, err := readCSVTable(input, csvOpts.Delimiter)
+	if err != nil {
+		return "", err
+	}
+	if table, err = table.pivot(opts); err != nil {
+		return "", err
+	}
+	return table.csv(csvOpts.Delimiter)
+}
+
+// UnpivotJSON melts a JSON array of objects from wide to long format.
+func UnpivotJSON(input string, opts UnpivotOptions) (string, error) {
+	table, err := jsonTable(input, false)
+	if err != nil {
+		return "", err
+	}
+	if table, err = table.unpivot(opts); err != nil {
+		return "", err
+	}
+	return table.json()
+}
+
+// UnpivotCSV melts CSV with a header row from wide to long format.
+func UnpivotCSV(input string, opts UnpivotOptions, csvOpts CSVOptions) (string, error) {
+	table, err := readCSVTable(input, csvOpts.Delimiter)
+	if err != nil {
+		return "", err
+	}
+	if table, err = table.unpivot(opts); err != nil {
+		return "", err
+	}
+	return table.csv(csvOpts.Delimiter)
+}
+
+func (t *csvTable) pivot(opts PivotOptions) (*csvTable, error) {
+	if opts.Key == "" || opts.Value == "" {
+		return nil, errors.New("pivot needs key and value columns")
+	}
+	if err := t.require(opts.Key, opts.Value); err != nil {
+		return nil, err
+	}
+	index := opts.Index
+	if len(index) == 0 {
+		for _, col := range t.Columns {
+			if col != opts.Key && col != opts.Value {
+				index = append(index, col)
+			}
+		}
+	} else if err := t.require(index...); err != nil {
+		return nil, err
+	}
+	aggregate := strings.ToLower(opts.Aggregate)
+	if aggregate == "" {
+		aggregate = PivotFirst
+	}
+	if !slices.Contains([]string{PivotFirst, PivotLast, PivotSum, PivotCount, PivotAvg, PivotMin, PivotMax, PivotList}, aggregate) {
+		return nil, fmt.Errorf("unsupported aggregate %q", opts.Aggregate)
+	}
+
+	out := &csvTable{Columns: slices.Clone(index)}
+	groups := map[string]map[string][]any{}
+	var order []string
+	for _, row := range t.Rows {
+		parts := make([]string, len(index))
+		for i, col := range index {
+			parts[i] = csvCellString(row[col])
+		}
+		id := strings.Join(parts, "\x00")
+		cells, ok := groups[id]
+		if !ok {
+			cells = map[string][]any{}
+			groups[id] = cells
+			order = append(order, id)
+			wide := make(map[string]any, len(index))
+			for _, col := range index {
+				wide[col] = row[col]
+			}
+			out.Rows = append(out.Rows, wide)
+		}
+		key := csvCellString(row[opts.Key])
+		if slices.Contains(index, key) {
+			return nil, fmt.Errorf("pivoted column %q collides with an index column", key)
+		}
+		out.addColumn(key)
+		cells[key] = append(cells[key], row[opts.Value])
+	}
+	for i, id := range order {
+		for key, values := range groups[id] {
+			v, err := pivotAggregate(aggregate, values)
+			if err != nil {
+				return nil, fmt.Errorf("column %q: %w", key, err)
+			}
+			out.Rows[i][key] = v
+		}
+	}
+	return out, nil
+}
+
+func pivotAggregate(aggregate string, values []any) (any, error) {
+	switch aggregate {
+	case PivotFirst:
+		return values[0], nil
+	case PivotLast:
+		return values[len(values)-1], nil
+	case PivotCount:
+		return json.Number(strconv.Itoa(len(values))), nil
+	case PivotList:
+		return slices.Clone(values), nil
+	}
+	var result float64
+	for i, v := range values {
+		n, err := exprNumber(v)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case i == 0:
+			result = n
+		case aggregate == PivotMin:
+			result = min(result, n)
+		case aggregate == PivotMax:
+			result = max(result, n)
+		default:
+			result += n
+		}
+	}
+	if aggregate == PivotAvg {
+		result /= float64(len(values))
+	}
+	return json.Number(strconv.FormatFloat(result, 'f', -1, 64)), nil
+}
+
+func (t *csvTable) unpivot(opts UnpivotOptions) (*csvTable, error) {
+	keyName, valueName := opts.Key, opts.Value
+	if keyName == "" {
+		keyName = "key"
+	}
+	if valueName == "" {
+		valueName = "value"
+	}
+	if err := t.require(opts.Index...); err != nil {
+		return nil, err
+	}
+	columns := opts.Columns
+	if len(columns) == 0 {
+		for _, col := range t.Columns {
+			if !slices.Contains(opts.Index, col) {
+				columns = append(columns, col)
+			}
+		}
+	} else if err := t.require(columns...); err != nil {
+		return nil, err
+	}
+	if slices.Contains(opts.Index, keyName) || slices.Contains(opts.Index, valueName) {
+		return nil, errors.New("key and value names must differ from the index columns")
+	}
+	out := &csvTable{Columns: append(slices.Clone(opts.Index), keyName, valueName)}
+	for _, row := range t.Rows {
+		for _, col := range columns {
+			v := row[col]
+			if !opts.KeepEmpty && (v == nil || v == "") {
+				continue
+			}
+			long := make(map[string]any, len(out.Columns))
+			for _, idx := range opts.Index {
+				long[idx] = row[idx]
+			}
+			long[keyName] = col
+			long[valueName] = v
+			out.Rows = append(out.Rows, long)
+		}
+	}
+	return out, nil
+}
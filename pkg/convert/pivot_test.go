@@ -0,0 +1,54 @@
+package convert
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const sampleLongCSV = "city,month,metric,value\nOslo,jan,temp,-4\nOslo,jan,rain,49\nRome,jan,temp,8\nRome,jan,temp,10\n"
+
+func TestPivotCSV(t *testing.T) {
+	out, err := PivotCSV(sampleLongCSV, PivotOptions{Key: "metric", Value: "value"}, CSVOptions{})
+	require.NoError(t, err)
+	require.Equal(t, "city,month,temp,rain\nOslo,jan,-4,49\nRome,jan,8,", out)
+
+	out, err = PivotCSV(sampleLongCSV, PivotOptions{Index: []string{"city"}, Key: "metric", Value: "value", Aggregate: "avg"}, CSVOptions{})
+	require.NoError(t, err)
+	require.Equal(t, "city,temp,rain\nOslo,-4,49\nRome,9,", out)
+
+	_, err = PivotCSV(sampleLongCSV, PivotOptions{Key: "metric"}, CSVOptions{})
+	require.Error(t, err)
+	_, err = PivotCSV(sampleLongCSV, PivotOptions{Key: "metric", Value: "value", Aggregate: "median"}, CSVOptions{})
+	require.Error(t, err)
+	_, err = PivotCSV(sampleLongCSV, PivotOptions{Key: "city", Value: "metric", Aggregate: "sum"}, CSVOptions{})
+	require.Error(t, err)
+}
+
+func TestPivotJSON(t *testing.T) {
+	input := `[{"id":1,"k":"a","v":2},{"id":1,"k":"a","v":5},{"id":2,"k":"b","v":1}]`
+	for aggregate, want := range map[string]string{
+		PivotLast:  `[{"id":1,"a":5,"b":null},{"id":2,"a":null,"b":1}]`,
+		PivotSum:   `[{"id":1,"a":7,"b":null},{"id":2,"a":null,"b":1}]`,
+		PivotMax:   `[{"id":1,"a":5,"b":null},{"id":2,"a":null,"b":1}]`,
+		PivotCount: `[{"id":1,"a":2,"b":null},{"id":2,"a":null,"b":1}]`,
+		PivotList:  `[{"id":1,"a":[2,5],"b":null},{"id":2,"a":null,"b":[1]}]`,
+	} {
+		out, err := PivotJSON(input, PivotOptions{Key: "k", Value: "v", Aggregate: aggregate})
+		require.NoError(t, err, aggregate)
+		require.JSONEq(t, want, out, aggregate)
+	}
+}
+
+func TestUnpivot(t *testing.T) {
+	out, err := UnpivotCSV("city,temp,rain\nOslo,-4,49\nRome,9,\n", UnpivotOptions{Index: []string{"city"}, Key: "metric"}, CSVOptions{})
+	require.NoError(t, err)
+	require.Equal(t, "city,metric,value\nOslo,temp,-4\nOslo,rain,49\nRome,temp,9", out)
+
+	out, err = UnpivotJSON(`[{"id":1,"a":2,"b":null}]`, UnpivotOptions{Index: []string{"id"}, KeepEmpty: true})
+	require.NoError(t, err)
+	require.JSONEq(t, `[{"id":1,"key":"a","value":2},{"id":1,"key":"b","value":null}]`, out)
+
+	_, err = UnpivotJSON(`[{"id":1}]`, UnpivotOptions{Index: []string{"missing"}})
+	require.Error(t, err)
+}
@@ -0,0 +1,642 @@
+package convert
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+
+	"github.com/linzeyan/transform-go/pkg/common"
+)
+
+const bplistMagic = "bplist00"
+
+var plistEpoch = time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// PlistToJSON decodes an XML plist, or a binary plist given as raw bytes or
+// base64, into JSON with dict keys in the order they are stored. <data>
+// becomes base64 and <date> becomes RFC 3339.
+func PlistToJSON(input string) (string, error) {
+	trimmed := strings.TrimSpace(input)
+	var value *docNode
+	var err error
+	switch {
+	case strings.HasPrefix(trimmed, bplistMagic):
+		value, err = decodeBinaryPlist([]byte(trimmed))
+	case strings.HasPrefix(trimmed, "<"):
+		value, err = decodeXMLPlist(trimmed)
+	default:
+		raw, decErr := base64.StdEncoding.DecodeString(trimmed)
+		if decErr != nil || !bytes.HasPrefix(raw, []byte(bplistMagic)) {
+			return "", errors.New("input is neither an XML plist nor a base64 binary plist")
+		}
+		value, err = decodeBinaryPlist(raw)
+	}
+	if err != nil {
+		return "", err
+	}
+	return encodeDoc(value)
+}
+
+// JSONToPlist renders JSON as an Apple XML property list.
+func JSONToPlist(input string) (string, error) {
+	return jsonToPlistWithOptions(input, NewConvertOptions())
+}
+
+// jsonToPlistWithOptions writes dict keys in the order of the input unless
+// SortKeys is set.
+func jsonToPlistWithOptions(input string, o ConvertOptions) (string, error) {
+	data, err := decodeJSONValue(input)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	b.WriteString(`<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">` + "\n")
+	b.WriteString(`<plist version="1.0">` + "\n")
+	writeXMLPlistValue(&b, data, 0, o.sourceOrder(input), "")
+	b.WriteString("</plist>")
+	return b.String(), nil
+}
+
+// JSONToBinaryPlist renders JSON as a bplist00 document encoded in base64.
+func JSONToBinaryPlist(input string) (string, error) {
+	return jsonToBinaryPlistWithOptions(input, NewConvertOptions())
+}
+
+// jsonToBinaryPlistWithOptions writes dict keys in the order of the input
+// unless SortKeys is set.
+func jsonToBinaryPlistWithOptions(input string, o ConvertOptions) (string, error) {
+	data, err := decodeJSONValue(input)
+	if err != nil {
+		return "", err
+	}
+	raw, err := encodeBinaryPlist(data, o.sourceOrder(input))
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(raw), nil
+}
+
+// writeXMLPlistValue writes v, found at path, with dict keys in order.
+func writeXMLPlistValue(b *strings.Builder, v any, depth int, order sourceOrder, path string) {
+	indent := strings.Repeat("\t", depth)
+	switch val := v.(type) {
+	case map[string]any:
+		if len(val) == 0 {
+			b.WriteString(indent + "<dict/>\n")
+			return
+		}
+		b.WriteString(indent + "<dict>\n")
+		for _, k := range order.keys(val, path) {
+			b.WriteString(indent + "\t<key>" + xmlEscape(k) + "</key>\n")
+			writeXMLPlistValue(b, val[k], depth+1, order, keyPath(path, k))
+		}
+		b.WriteString(indent + "</dict>\n")
+	case []any:
+		if len(val) == 0 {
+			b.WriteString(indent + "<array/>\n")
+			return
+		}
+		b.WriteString(indent + "<array>\n")
+		for _, item := range val {
+			writeXMLPlistValue(b, item, depth+1, order, path+"[]")
+		}
+		b.WriteString(indent + "</array>\n")
+	case json.Number:
+		if common.LooksInteger(val) {
+			b.WriteString(indent + "<integer>" + val.String() + "</integer>\n")
+		} else {
+			b.WriteString(indent + "<real>" + val.String() + "</real>\n")
+		}
+	case bool:
+		if val {
+			b.WriteString(indent + "<true/>\n")
+		} else {
+			b.WriteString(indent + "<false/>\n")
+		}
+	case nil:
+		b.WriteString(indent + "<string></string>\n")
+	default:
+		b.WriteString(indent + "<string>" + xmlEscape(fmt.Sprint(val)) + "</string>\n")
+	}
+}
+
+func decodeXMLPlist(src string) (*docNode, error) {
+	dec := xml.NewDecoder(strings.NewReader(src))
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			if err == io.EOF {
+				return nil, errors.New("plist has no value")
+			}
+			return nil, err
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok || start.Name.Local == "plist" {
+			continue
+		}
+		return readXMLPlistValue(&docBuilder{}, dec, start)
+	}
+}
+
+func readXMLPlistValue(b *docBuilder, dec *xml.Decoder, start xml.StartElement) (*docNode, error) {
+	switch start.Name.Local {
+	case "dict":
+		obj := b.object()
+		var key string
+		haveKey := false
+		for {
+			tok, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			switch t := tok.(type) {
+			case xml.StartElement:
+				if t.Name.Local == "key" {
+					text, err := readXMLText(dec)
+					if err != nil {
+						return nil, err
+					}
+					key, haveKey = text, true
+					continue
+				}
+				if !haveKey {
+					return nil, fmt.Errorf("plist dict value <%s> without key", t.Name.Local)
+				}
+				val, err := readXMLPlistValue(b, dec, t)
+				if err != nil {
+					return nil, err
+				}
+				b.set(obj, key, val)
+				haveKey = false
+			case xml.EndElement:
+				return obj, nil
+			}
+		}
+	case "array":
+		arr := b.array()
+		for {
+			tok, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			switch t := tok.(type) {
+			case xml.StartElement:
+				val, err := readXMLPlistValue(b, dec, t)
+				if err != nil {
+					return nil, err
+				}
+				arr.items = append(arr.items, val)
+			case xml.EndElement:
+				return arr, nil
+			}
+		}
+	case "true", "false":
+		if err := dec.Skip(); err != nil {
+			return nil, err
+		}
+		return b.boolean(start.Name.Local == "true"), nil
+	}
+	text, err := readXMLText(dec)
+	if err != nil {
+		return nil, err
+	}
+	switch start.Name.Local {
+	case "string":
+		return b.str(text), nil
+	case "integer", "real":
+		num := strings.TrimSpace(text)
+		if _, err := strconv.ParseFloat(num, 64); err != nil {
+			return nil, fmt.Errorf("invalid plist number %q", num)
+		}
+		return b.number(num), nil
+	case "date":
+		return b.str(strings.TrimSpace(text)), nil
+	case "data":
+		raw, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(text), ""))
+		if err != nil {
+			return nil, fmt.Errorf("invalid plist data: %w", err)
+		}
+		return b.str(base64.StdEncoding.EncodeToString(raw)), nil
+	}
+	return nil, fmt.Errorf("unsupported plist element <%s>", start.Name.Local)
+}
+
+func readXMLText(dec *xml.Decoder) (string, error) {
+	var b strings.Builder
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return "", err
+		}
+		switch t := tok.(type) {
+		case xml.CharData:
+			b.Write(t)
+		case xml.EndElement:
+			return b.String(), nil
+		case xml.StartElement:
+			return "", fmt.Errorf("unexpected <%s> in text element", t.Name.Local)
+		}
+	}
+}
+
+type bplistReader struct {
+	data       []byte
+	offsets    []uint64
+	refSize    int
+	depthGuard int
+	b          *docBuilder
+}
+
+func decodeBinaryPlist(data []byte) (*docNode, error) {
+	if len(data) < len(bplistMagic)+32 || !bytes.HasPrefix(data, []byte(bplistMagic)) {
+		return nil, errors.New("invalid binary plist")
+	}
+	trailer := data[len(data)-32:]
+	offsetSize := int(trailer[6])
+	refSize := int(trailer[7])
+	numObjects := binary.BigEndian.Uint64(trailer[8:16])
+	top := binary.BigEndian.Uint64(trailer[16:24])
+	tableOffset := binary.BigEndian.Uint64(trailer[24:32])
+	if offsetSize < 1 || offsetSize > 8 || refSize < 1 || refSize > 8 || numObjects > uint64(len(data)) ||
+		tableOffset+numObjects*uint64(offsetSize) > uint64(len(data)-32) || top >= numObjects {
+		return nil, errors.New("corrupt binary plist trailer")
+	}
+	r := &bplistReader{data: data, refSize: refSize, offsets: make([]uint64, numObjects), b: &docBuilder{}}
+	for i := range r.offsets {
+		start := tableOffset + uint64(i*offsetSize)
+		r.offsets[i] = readBigEndian(data[start : start+uint64(offsetSize)])
+	}
+	return r.object(top)
+}
+
+func readBigEndian(b []byte) uint64 {
+	var v uint64
+	for _, c := range b {
+		v = v<<8 | uint64(c)
+	}
+	return v
+}
+
+func (r *bplistReader) object(ref uint64) (*docNode, error) {
+	if ref >= uint64(len(r.offsets)) {
+		return nil, errors.New("binary plist reference out of range")
+	}
+	r.depthGuard++
+	defer func() { r.depthGuard-- }()
+	if r.depthGuard > 512 {
+		return nil, errors.New("binary plist nesting too deep")
+	}
+	off := r.offsets[ref]
+	if off >= uint64(len(r.data)) {
+		return nil, errors.New("binary plist offset out of range")
+	}
+	marker := r.data[off]
+	kind, info := marker>>4, int(marker&0x0f)
+	pos := off + 1
+	switch kind {
+	case 0x0:
+		switch marker {
+		case 0x08:
+			return r.b.boolean(false), nil
+		case 0x09:
+			return r.b.boolean(true), nil
+		}
+		return r.b.null(), nil
+	case 0x1:
+		if info > 4 {
+			return nil, fmt.Errorf("invalid binary plist integer marker 0x%02x", marker)
+		}
+		size := uint64(1) << info
+		b, err := r.slice(pos, size)
+		if err != nil {
+			return nil, err
+		}
+		if size == 16 {
+			return r.b.number(new(big.Int).SetBytes(b).String()), nil
+		}
+		v := readBigEndian(b)
+		if size == 8 {
+			return r.b.number(strconv.FormatInt(int64(v), 10)), nil
+		}
+		return r.b.number(strconv.FormatUint(v, 10)), nil
+	case 0x2:
+		if info != 2 && info != 3 {
+			return nil, fmt.Errorf("invalid binary plist real marker 0x%02x", marker)
+		}
+		size := uint64(1) << info
+		b, err := r.slice(pos, size)
+		if err != nil {
+			return nil, err
+		}
+		var f float64
+		if size == 4 {
+			f = float64(math.Float32frombits(binary.BigEndian.Uint32(b)))
+		} else {
+			f = math.Float64frombits(binary.BigEndian.Uint64(b))
+		}
+		return r.b.number(strconv.FormatFloat(f, 'g', -1, 64)), nil
+	case 0x3:
+		b, err := r.slice(pos, 8)
+		if err != nil {
+			return nil, err
+		}
+		secs := math.Float64frombits(binary.BigEndian.Uint64(b))
+		return r.b.str(plistEpoch.Add(time.Duration(secs * float64(time.Second))).Format(time.RFC3339)), nil
+	}
+	count, pos, err := r.length(info, pos)
+	if err != nil {
+		return nil, err
+	}
+	switch kind {
+	case 0x4:
+		b, err := r.slice(pos, count)
+		if err != nil {
+			return nil, err
+		}
+		return r.b.str(base64.StdEncoding.EncodeToString(b)), nil
+	case 0x5:
+		b, err := r.slice(pos, count)
+		if err != nil {
+			return nil, err
+		}
+		return r.b.str(string(b)), nil
+	case 0x6:
+		b, err := r.slice(pos, count*2)
+		if err != nil {
+			return nil, err
+		}
+		units := make([]uint16, count)
+		for i := range units {
+			units[i] = binary.BigEndian.Uint16(b[i*2:])
+		}
+		return r.b.str(string(utf16.Decode(units))), nil
+	case 0xA:
+		refs, err := r.refs(pos, count)
+		if err != nil {
+			return nil, err
+		}
+		arr := r.b.array(make([]*docNode, len(refs))...)
+		for i, ref := range refs {
+			if arr.items[i], err = r.object(ref); err != nil {
+				return nil, err
+			}
+		}
+		return arr, nil
+	case 0xD:
+		refs, err := r.refs(pos, count*2)
+		if err != nil {
+			return nil, err
+		}
+		obj := r.b.object()
+		for i := uint64(0); i < count; i++ {
+			key, err := r.object(refs[i])
+			if err != nil {
+				return nil, err
+			}
+			val, err := r.object(refs[count+i])
+			if err != nil {
+				return nil, err
+			}
+			r.b.set(obj, key.text, val)
+		}
+		return obj, nil
+	}
+	return nil, fmt.Errorf("unsupported binary plist marker 0x%02x", marker)
+}
+
+func (r *bplistReader) slice(pos, size uint64) ([]byte, error) {
+	if pos+size > uint64(len(r.data)) || pos+size < pos {
+		return nil, errors.New("binary plist object out of range")
+	}
+	return r.data[pos : pos+size], nil
+}
+
+func (r *bplistReader) length(info int, pos uint64) (uint64, uint64, error) {
+	if info != 0x0f {
+		return uint64(info), pos, nil
+	}
+	b, err := r.slice(pos, 1)
+	if err != nil {
+		return 0, 0, err
+	}
+	if b[0]>>4 != 0x1 {
+		return 0, 0, errors.New("invalid binary plist length marker")
+	}
+	size := uint64(1) << (b[0] & 0x0f)
+	v, err := r.slice(pos+1, size)
+	if err != nil {
+		return 0, 0, err
+	}
+	n := readBigEndian(v)
+	if n > uint64(len(r.data)) {
+		return 0, 0, errors.New("binary plist length out of range")
+	}
+	return n, pos + 1 + size, nil
+}
+
+func (r *bplistReader) refs(pos, count uint64) ([]uint64, error) {
+	b, err := r.slice(pos, count*uint64(r.refSize))
+	if err != nil {
+		return nil, err
+	}
+	out := make([]uint64, count)
+	for i := range out {
+		out[i] = readBigEndian(b[i*r.refSize : (i+1)*r.refSize])
+	}
+	return out, nil
+}
+
+type bplistWriter struct {
+	objects [][]byte
+	refSize int
+	order   sourceOrder
+}
+
+// encodeBinaryPlist writes v as a bplist00 document with dict keys in
+// order.
+func encodeBinaryPlist(v any, order sourceOrder) ([]byte, error) {
+	count := countPlistObjects(v)
+	w := &bplistWriter{refSize: 1, order: order}
+	for count > 1<<(8*w.refSize)-1 && w.refSize < 8 {
+		w.refSize *= 2
+	}
+	if _, err := w.add(v, ""); err != nil {
+		return nil, err
+	}
+	buf := bytes.NewBufferString(bplistMagic)
+	offsets := make([]uint64, len(w.objects))
+	for i, obj := range w.objects {
+		offsets[i] = uint64(buf.Len())
+		buf.Write(obj)
+	}
+	tableOffset := uint64(buf.Len())
+	offsetSize := minBytes(tableOffset)
+	for _, off := range offsets {
+		buf.Write(bigEndianBytes(off, offsetSize))
+	}
+	trailer := make([]byte, 32)
+	trailer[6] = byte(offsetSize)
+	trailer[7] = byte(w.refSize)
+	binary.BigEndian.PutUint64(trailer[8:], uint64(len(w.objects)))
+	binary.BigEndian.PutUint64(trailer[16:], 0)
+	binary.BigEndian.PutUint64(trailer[24:], tableOffset)
+	buf.Write(trailer)
+	return buf.Bytes(), nil
+}
+
+func countPlistObjects(v any) int {
+	switch val := v.(type) {
+	case map[string]any:
+		n := 1
+		for _, inner := range val {
+			n += 1 + countPlistObjects(inner)
+		}
+		return n
+	case []any:
+		n := 1
+		for _, inner := range val {
+			n += countPlistObjects(inner)
+		}
+		return n
+	}
+	return 1
+}
+
+func (w *bplistWriter) reserve() int {
+	w.objects = append(w.objects, nil)
+	return len(w.objects) - 1
+}
+
+// add writes v, found at path, and returns its object reference.
+func (w *bplistWriter) add(v any, path string) (int, error) {
+	idx := w.reserve()
+	var buf bytes.Buffer
+	switch v.(type) {
+	case nil, bool, json.Number, string, []any, map[string]any:
+	default:
+		v = fmt.Sprint(v)
+	}
+	switch val := v.(type) {
+	case nil:
+		buf.WriteByte(0x00)
+	case bool:
+		if val {
+			buf.WriteByte(0x09)
+		} else {
+			buf.WriteByte(0x08)
+		}
+	case json.Number:
+		if i, err := val.Int64(); err == nil && common.LooksInteger(val) {
+			buf.WriteByte(0x13)
+			buf.Write(bigEndianBytes(uint64(i), 8))
+		} else {
+			f, err := val.Float64()
+			if err != nil {
+				return 0, err
+			}
+			buf.WriteByte(0x23)
+			buf.Write(bigEndianBytes(math.Float64bits(f), 8))
+		}
+	case string:
+		if isASCII(val) {
+			writePlistHeader(&buf, 0x5, uint64(len(val)))
+			buf.WriteString(val)
+		} else {
+			units := utf16.Encode([]rune(val))
+			writePlistHeader(&buf, 0x6, uint64(len(units)))
+			for _, u := range units {
+				buf.Write(bigEndianBytes(uint64(u), 2))
+			}
+		}
+	case []any:
+		refs := make([]int, len(val))
+		for i, item := range val {
+			ref, err := w.add(item, path+"[]")
+			if err != nil {
+				return 0, err
+			}
+			refs[i] = ref
+		}
+		writePlistHeader(&buf, 0xA, uint64(len(refs)))
+		for _, ref := range refs {
+			buf.Write(bigEndianBytes(uint64(ref), w.refSize))
+		}
+	case map[string]any:
+		keys := w.order.keys(val, path)
+		keyRefs := make([]int, len(keys))
+		valRefs := make([]int, len(keys))
+		for i, k := range keys {
+			ref, err := w.add(k, "")
+			if err != nil {
+				return 0, err
+			}
+			keyRefs[i] = ref
+			if valRefs[i], err = w.add(val[k], keyPath(path, k)); err != nil {
+				return 0, err
+			}
+		}
+		writePlistHeader(&buf, 0xD, uint64(len(keys)))
+		for _, ref := range append(keyRefs, valRefs...) {
+			buf.Write(bigEndianBytes(uint64(ref), w.refSize))
+		}
+	}
+	w.objects[idx] = buf.Bytes()
+	return idx, nil
+}
+
+func writePlistHeader(buf *bytes.Buffer, kind byte, count uint64) {
+	if count < 0x0f {
+		buf.WriteByte(kind<<4 | byte(count))
+		return
+	}
+	buf.WriteByte(kind<<4 | 0x0f)
+	size := minBytes(count)
+	exp := byte(0)
+	for 1<<exp < size {
+		exp++
+	}
+	buf.WriteByte(0x10 | exp)
+	buf.Write(bigEndianBytes(count, 1<<exp))
+}
+
+func minBytes(v uint64) int {
+	switch {
+	case v <= math.MaxUint8:
+		return 1
+	case v <= math.MaxUint16:
+		return 2
+	case v <= math.MaxUint32:
+		return 4
+	}
+	return 8
+}
+
+func bigEndianBytes(v uint64, size int) []byte {
+	out := make([]byte, size)
+	for i := size - 1; i >= 0; i-- {
+		out[i] = byte(v)
+		v >>= 8
+	}
+	return out
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= 0x80 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,99 @@
+package convert
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const samplePlistXML = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>CFBundleName</key>
+	<string>Demo</string>
+	<key>Build</key>
+	<integer>42</integer>
+	<key>Scale</key>
+	<real>1.5</real>
+	<key>Enabled</key>
+	<true/>
+	<key>Icon</key>
+	<data>aGk=</data>
+	<key>Released</key>
+	<date>2024-01-02T03:04:05Z</date>
+	<key>Devices</key>
+	<array>
+		<string>iphone</string>
+		<string>ipad</string>
+	</array>
+</dict>
+</plist>`
+
+func TestPlistToJSON(t *testing.T) {
+	out, err := PlistToJSON(samplePlistXML)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"CFBundleName":"Demo","Build":42,"Scale":1.5,"Enabled":true,"Icon":"aGk=","Released":"2024-01-02T03:04:05Z","Devices":["iphone","ipad"]}`, out)
+
+	// Generated with Python's plistlib.dumps(..., fmt=FMT_BINARY).
+	binary := "YnBsaXN0MDDWAQIDBAUGBwgJCgsMU2FnZVRibG9iVG5hbWVSb2tScGlUdGFncxAeQmhpVUFsaWNlCSM/+AAAAAAAAKINDlFhYQDpCBUZHiMmKS4wMzk6Q0ZIAAAAAAAAAQEAAAAAAAAADwAAAAAAAAAAAAAAAAAAAEs="
+	out, err = PlistToJSON(binary)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"name":"Alice","age":30,"tags":["a","é"],"ok":true,"pi":1.5,"blob":"aGk="}`, out)
+
+	_, err = PlistToJSON("not a plist")
+	require.Error(t, err)
+	_, err = PlistToJSON(base64.StdEncoding.EncodeToString([]byte("bplist00short")))
+	require.Error(t, err)
+}
+
+func TestJSONToPlist(t *testing.T) {
+	src := `{"name":"Demo","count":3,"ratio":0.25,"on":false,"list":[1,"two"],"nested":{"k":"<v>"}}`
+	out, err := JSONToPlist(src)
+	require.NoError(t, err)
+	require.Contains(t, out, "<key>count</key>")
+	require.Contains(t, out, "<integer>3</integer>")
+	require.Contains(t, out, "<real>0.25</real>")
+	require.Contains(t, out, "<string>&lt;v&gt;</string>")
+
+	back, err := PlistToJSON(out)
+	require.NoError(t, err)
+	require.JSONEq(t, src, back)
+
+	bin, err := JSONToBinaryPlist(src)
+	require.NoError(t, err)
+	back, err = PlistToJSON(bin)
+	require.NoError(t, err)
+	require.JSONEq(t, src, back)
+}
+
+func TestPropertiesXML(t *testing.T) {
+	src := `<?xml version="1.0" encoding="UTF-8" standalone="no"?>
+<!DOCTYPE properties SYSTEM "http://java.sun.com/dtd/properties.dtd">
+<properties>
+<comment>app settings</comment>
+<entry key="db.url">jdbc:h2:mem:test</entry>
+<entry key="db.pool">10</entry>
+</properties>`
+	out, err := PropertiesXMLToJSON(src)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"db.url":"jdbc:h2:mem:test","db.pool":"10"}`, out)
+
+	xmlOut, err := JSONToPropertiesXML(`{"db":{"url":"a&b","hosts":["h1","h2"]},"debug":true}`)
+	require.NoError(t, err)
+	require.Contains(t, xmlOut, `<entry key="db.url">a&amp;b</entry>`)
+	require.Contains(t, xmlOut, `<entry key="db.hosts.1">h2</entry>`)
+	require.Contains(t, xmlOut, `<entry key="debug">true</entry>`)
+
+	_, err = PropertiesXMLToJSON("<properties><entry>x</entry></properties>")
+	require.Error(t, err)
+}
+
+func FuzzPlistToJSON(f *testing.F) {
+	f.Add(samplePlistXML)
+	f.Add("YnBsaXN0MDDWAQIDBAUGBwgJCgsMU2FnZVRibG9iVG5hbWVSb2tScGlUdGFncxAeQmhpVUFsaWNlCSM/+AAAAAAAAKINDlFhYQDpCBUZHiMmKS4wMzk6Q0ZIAAAAAAAAAQEAAAAAAAAADwAAAAAAAAAAAAAAAAAAAEs=")
+	f.Fuzz(func(t *testing.T, input string) {
+		_, _ = PlistToJSON(input)
+	})
+}
@@ -0,0 +1,407 @@
+package convert
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/linzeyan/transform-go/pkg/common"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	policyKindIAM  = "iam"
+	policyKindRBAC = "rbac"
+)
+
+// PermissionEntry is a single row of a permission matrix.
+type PermissionEntry struct {
+	Principal  string   `json:"principal"`
+	Effect     string   `json:"effect"`
+	Resources  []string `json:"resources"`
+	Actions    []string `json:"actions"`
+	Conditions []string `json:"conditions,omitempty"`
+	GrantedTo  []string `json:"grantedTo,omitempty"`
+	// Wildcards lists the action patterns in Actions whose matches were
+	// added from a built-in catalogue of common actions. The catalogue is
+	// partial, so the pattern still grants actions that are not listed.
+	Wildcards []string `json:"wildcards,omitempty"`
+}
+
+// PermissionMatrix is the normalized view of an IAM policy or RBAC manifest.
+type PermissionMatrix struct {
+	Kind    string            `json:"kind"`
+	Entries []PermissionEntry `json:"entries"`
+}
+
+// AnalyzePermissions parses an IAM policy ("iam") or Kubernetes RBAC manifest
+// ("rbac") and renders the permission matrix as "markdown" or "json".
+func AnalyzePermissions(kind, input, output string) (string, error) {
+	var matrix PermissionMatrix
+	var err error
+	switch strings.ToLower(strings.TrimSpace(kind)) {
+	case policyKindIAM:
+		matrix, err = AnalyzeIAMPolicy(input)
+	case policyKindRBAC:
+		matrix, err = AnalyzeRBAC(input)
+	default:
+		return "", fmt.Errorf("unsupported policy kind %s", kind)
+	}
+	if err != nil {
+		return "", err
+	}
+	switch strings.ToLower(strings.TrimSpace(output)) {
+	case "", "markdown":
+		return PermissionMatrixToMarkdown(matrix), nil
+	case "json":
+		return encodeJSON(matrix)
+	default:
+		return "", fmt.Errorf("unsupported output %s", output)
+	}
+}
+
+// AnalyzeIAMPolicy expands an AWS IAM policy document into a permission matrix.
+func AnalyzeIAMPolicy(input string) (PermissionMatrix, error) {
+	matrix := PermissionMatrix{Kind: policyKindIAM}
+	data, err := decodeJSONValue(input)
+	if err != nil {
+		return matrix, err
+	}
+	doc, ok := data.(map[string]any)
+	if !ok {
+		return matrix, errors.New("IAM policy must be a JSON object")
+	}
+	var statements []any
+	switch st := doc["Statement"].(type) {
+	case []any:
+		statements = st
+	case map[string]any:
+		statements = []any{st}
+	default:
+		return matrix, errors.New("IAM policy has no Statement")
+	}
+	for i, raw := range statements {
+		stmt, ok := raw.(map[string]any)
+		if !ok {
+			return matrix, fmt.Errorf("statement %d is not an object", i+1)
+		}
+		matrix.Entries = append(matrix.Entries, iamStatementEntry(i, stmt))
+	}
+	return matrix, nil
+}
+
+func iamStatementEntry(index int, stmt map[string]any) PermissionEntry {
+	entry := PermissionEntry{
+		Principal: fmt.Sprintf("Statement %d", index+1),
+		Effect:    "Allow",
+	}
+	if sid, ok := stmt["Sid"].(string); ok && sid != "" {
+		entry.Principal = sid
+	}
+	if effect, ok := stmt["Effect"].(string); ok && effect != "" {
+		entry.Effect = effect
+	}
+	if principal := iamPrincipals(stmt["Principal"]); len(principal) > 0 {
+		entry.GrantedTo = principal
+	}
+	for _, action := range stringList(stmt["Action"]) {
+		expanded := expandIAMAction(action)
+		if len(expanded) > 1 {
+			entry.Wildcards = append(entry.Wildcards, expanded[0])
+		}
+		entry.Actions = append(entry.Actions, expanded...)
+	}
+	for _, action := range stringList(stmt["NotAction"]) {
+		expanded := expandIAMAction(action)
+		if len(expanded) > 1 {
+			entry.Wildcards = append(entry.Wildcards, "NOT "+expanded[0])
+		}
+		for _, a := range expanded {
+			entry.Actions = append(entry.Actions, "NOT "+a)
+		}
+	}
+	entry.Actions = uniqueSorted(entry.Actions)
+	if entry.Wildcards != nil {
+		entry.Wildcards = uniqueSorted(entry.Wildcards)
+	}
+	entry.Resources = stringList(stmt["Resource"])
+	for _, res := range stringList(stmt["NotResource"]) {
+		entry.Resources = append(entry.Resources, "NOT "+res)
+	}
+	if cond, ok := stmt["Condition"].(map[string]any); ok {
+		for _, op := range orderedKeys(cond) {
+			keys, ok := cond[op].(map[string]any)
+			if !ok {
+				continue
+			}
+			for _, key := range orderedKeys(keys) {
+				values := stringList(keys[key])
+				entry.Conditions = append(entry.Conditions, fmt.Sprintf("%s %s %s", op, key, strings.Join(values, ",")))
+			}
+		}
+	}
+	return entry
+}
+
+func iamPrincipals(v any) []string {
+	switch p := v.(type) {
+	case string:
+		return []string{p}
+	case map[string]any:
+		var out []string
+		for _, kind := range orderedKeys(p) {
+			for _, id := range stringList(p[kind]) {
+				out = append(out, kind+":"+id)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+// expandIAMAction returns an action pattern followed by the catalogued
+// actions it matches, if any. The pattern is kept because the catalogue
+// is partial.
+func expandIAMAction(pattern string) []string {
+	pattern = strings.TrimSpace(pattern)
+	if pattern == "" {
+		return nil
+	}
+	if pattern == "*" {
+		return []string{"*"}
+	}
+	service, action, ok := strings.Cut(pattern, ":")
+	if !ok || !strings.ContainsAny(pattern, "*?") {
+		return []string{pattern}
+	}
+	service = strings.ToLower(service)
+	var out []string
+	for svc, actions := range iamActionIndex {
+		if !globMatch(service, svc) {
+			continue
+		}
+		for _, candidate := range actions {
+			if globMatch(strings.ToLower(action), strings.ToLower(candidate)) {
+				out = append(out, svc+":"+candidate)
+			}
+		}
+	}
+	sort.Strings(out)
+	return append([]string{pattern}, out...)
+}
+
+// globMatch reports whether s matches pattern, where '*' matches any run of
+// characters and '?' matches exactly one.
+func globMatch(pattern, s string) bool {
+	p, n := 0, 0
+	star, mark := -1, 0
+	for n < len(s) {
+		switch {
+		case p < len(pattern) && (pattern[p] == '?' || pattern[p] == s[n]):
+			p++
+			n++
+		case p < len(pattern) && pattern[p] == '*':
+			star = p
+			mark = n
+			p++
+		case star != -1:
+			p = star + 1
+			mark++
+			n = mark
+		default:
+			return false
+		}
+	}
+	for p < len(pattern) && pattern[p] == '*' {
+		p++
+	}
+	return p == len(pattern)
+}
+
+// AnalyzeRBAC expands Kubernetes Role/ClusterRole manifests (and their
+// bindings) from a multi-document YAML stream into a permission matrix.
+func AnalyzeRBAC(input string) (PermissionMatrix, error) {
+	matrix := PermissionMatrix{Kind: policyKindRBAC}
+	bindings := map[string][]string{}
+	dec := yaml.NewDecoder(strings.NewReader(input))
+	for {
+		var raw any
+		if err := dec.Decode(&raw); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return matrix, err
+		}
+		doc, ok := common.NormalizeYAML(raw).(map[string]any)
+		if !ok {
+			continue
+		}
+		docs := []any{doc}
+		if items, ok := doc["items"].([]any); ok {
+			docs = items
+		}
+		for _, item := range docs {
+			obj, ok := item.(map[string]any)
+			if !ok {
+				continue
+			}
+			collectRBACObject(obj, &matrix, bindings)
+		}
+	}
+	if len(matrix.Entries) == 0 {
+		return matrix, errors.New("no Role or ClusterRole found")
+	}
+	for i := range matrix.Entries {
+		matrix.Entries[i].GrantedTo = uniqueSorted(bindings[matrix.Entries[i].Principal])
+	}
+	return matrix, nil
+}
+
+func collectRBACObject(obj map[string]any, matrix *PermissionMatrix, bindings map[string][]string) {
+	kind, _ := obj["kind"].(string)
+	meta, _ := obj["metadata"].(map[string]any)
+	name, _ := meta["name"].(string)
+	namespace, _ := meta["namespace"].(string)
+	switch kind {
+	case "Role", "ClusterRole":
+		principal := rbacPrincipal(kind, namespace, name)
+		rules, _ := obj["rules"].([]any)
+		for _, raw := range rules {
+			rule, ok := raw.(map[string]any)
+			if !ok {
+				continue
+			}
+			matrix.Entries = append(matrix.Entries, rbacRuleEntry(principal, rule))
+		}
+	case "RoleBinding", "ClusterRoleBinding":
+		ref, _ := obj["roleRef"].(map[string]any)
+		refKind, _ := ref["kind"].(string)
+		refName, _ := ref["name"].(string)
+		refNamespace := ""
+		if refKind == "Role" {
+			refNamespace = namespace
+		}
+		principal := rbacPrincipal(refKind, refNamespace, refName)
+		subjects, _ := obj["subjects"].([]any)
+		for _, raw := range subjects {
+			subject, ok := raw.(map[string]any)
+			if !ok {
+				continue
+			}
+			subjectKind, _ := subject["kind"].(string)
+			subjectName, _ := subject["name"].(string)
+			label := subjectKind + ":" + subjectName
+			if ns, ok := subject["namespace"].(string); ok && ns != "" {
+				label = subjectKind + ":" + ns + "/" + subjectName
+			}
+			bindings[principal] = append(bindings[principal], label)
+		}
+	}
+}
+
+func rbacPrincipal(kind, namespace, name string) string {
+	if namespace != "" {
+		return kind + "/" + namespace + "/" + name
+	}
+	return kind + "/" + name
+}
+
+func rbacRuleEntry(principal string, rule map[string]any) PermissionEntry {
+	entry := PermissionEntry{Principal: principal, Effect: "Allow"}
+	groups := stringList(rule["apiGroups"])
+	if len(groups) == 0 {
+		groups = []string{""}
+	}
+	names := stringList(rule["resourceNames"])
+	for _, group := range groups {
+		for _, res := range stringList(rule["resources"]) {
+			qualified := res
+			if group != "" {
+				qualified = res + "." + group
+			}
+			if len(names) == 0 {
+				entry.Resources = append(entry.Resources, qualified)
+				continue
+			}
+			for _, n := range names {
+				entry.Resources = append(entry.Resources, qualified+"["+n+"]")
+			}
+		}
+	}
+	entry.Resources = append(entry.Resources, stringList(rule["nonResourceURLs"])...)
+	for _, verb := range stringList(rule["verbs"]) {
+		if verb == "*" {
+			entry.Wildcards = []string{verb}
+			entry.Actions = append(entry.Actions, rbacVerbs...)
+		}
+		entry.Actions = append(entry.Actions, verb)
+	}
+	entry.Actions = uniqueSorted(entry.Actions)
+	return entry
+}
+
+// PermissionMatrixToMarkdown renders the matrix as a GitHub-flavored table.
+func PermissionMatrixToMarkdown(m PermissionMatrix) string {
+	var b strings.Builder
+	b.WriteString("| Principal | Effect | Resources | Actions | Conditions | Granted To |\n")
+	b.WriteString("| --- | --- | --- | --- | --- | --- |\n")
+	for _, e := range m.Entries {
+		actions := make([]string, len(e.Actions))
+		for i, a := range e.Actions {
+			actions[i] = a
+			if slices.Contains(e.Wildcards, a) {
+				actions[i] += " (partial expansion follows)"
+			}
+		}
+		cells := []string{
+			e.Principal,
+			e.Effect,
+			strings.Join(e.Resources, "<br>"),
+			strings.Join(actions, "<br>"),
+			strings.Join(e.Conditions, "<br>"),
+			strings.Join(e.GrantedTo, "<br>"),
+		}
+		for i, cell := range cells {
+			cells[i] = strings.ReplaceAll(cell, "|", `\|`)
+		}
+		b.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+func stringList(v any) []string {
+	switch val := v.(type) {
+	case string:
+		return []string{val}
+	case []any:
+		out := make([]string, 0, len(val))
+		for _, item := range val {
+			out = append(out, fmt.Sprint(item))
+		}
+		return out
+	case nil:
+		return nil
+	default:
+		return []string{fmt.Sprint(val)}
+	}
+}
+
+func uniqueSorted(in []string) []string {
+	if len(in) == 0 {
+		return nil
+	}
+	seen := make(map[string]bool, len(in))
+	out := make([]string, 0, len(in))
+	for _, s := range in {
+		if seen[s] {
+			continue
+		}
+		seen[s] = true
+		out = append(out, s)
+	}
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,75 @@
+package convert
+
+// iamActionIndex is a trimmed catalogue of common AWS actions used to expand
+// wildcards such as "s3:Get*" into the concrete actions they grant.
+var iamActionIndex = map[string][]string{
+	"cloudwatch": {
+		"DeleteAlarms", "DescribeAlarms", "GetDashboard", "GetMetricData",
+		"GetMetricStatistics", "ListDashboards", "ListMetrics", "PutDashboard",
+		"PutMetricAlarm", "PutMetricData",
+	},
+	"dynamodb": {
+		"BatchGetItem", "BatchWriteItem", "CreateTable", "DeleteItem",
+		"DeleteTable", "DescribeTable", "GetItem", "ListTables", "PutItem",
+		"Query", "Scan", "UpdateItem", "UpdateTable",
+	},
+	"ec2": {
+		"AllocateAddress", "AttachVolume", "AuthorizeSecurityGroupIngress",
+		"CreateSecurityGroup", "CreateTags", "CreateVolume", "DeleteSecurityGroup",
+		"DeleteVolume", "DescribeImages", "DescribeInstances", "DescribeSecurityGroups",
+		"DescribeVolumes", "RebootInstances", "RunInstances", "StartInstances",
+		"StopInstances", "TerminateInstances",
+	},
+	"iam": {
+		"AttachRolePolicy", "AttachUserPolicy", "CreateAccessKey", "CreatePolicy",
+		"CreateRole", "CreateUser", "DeleteAccessKey", "DeleteRole", "DeleteUser",
+		"GetPolicy", "GetRole", "GetUser", "ListAttachedRolePolicies", "ListPolicies",
+		"ListRoles", "ListUsers", "PassRole", "PutRolePolicy", "UpdateAssumeRolePolicy",
+	},
+	"kms": {
+		"CreateGrant", "CreateKey", "Decrypt", "DescribeKey", "Encrypt",
+		"GenerateDataKey", "GetKeyPolicy", "ListKeys", "PutKeyPolicy", "ScheduleKeyDeletion",
+	},
+	"lambda": {
+		"CreateFunction", "DeleteFunction", "GetFunction", "GetFunctionConfiguration",
+		"InvokeFunction", "ListFunctions", "PublishVersion", "UpdateFunctionCode",
+		"UpdateFunctionConfiguration",
+	},
+	"logs": {
+		"CreateLogGroup", "CreateLogStream", "DeleteLogGroup", "DescribeLogGroups",
+		"DescribeLogStreams", "FilterLogEvents", "GetLogEvents", "PutLogEvents",
+		"PutRetentionPolicy",
+	},
+	"s3": {
+		"AbortMultipartUpload", "CreateBucket", "DeleteBucket", "DeleteBucketPolicy",
+		"DeleteObject", "DeleteObjectVersion", "GetBucketAcl", "GetBucketLocation",
+		"GetBucketPolicy", "GetBucketVersioning", "GetObject", "GetObjectAcl",
+		"GetObjectVersion", "ListAllMyBuckets", "ListBucket", "ListBucketVersions",
+		"ListMultipartUploadParts", "PutBucketAcl", "PutBucketPolicy",
+		"PutBucketVersioning", "PutObject", "PutObjectAcl",
+	},
+	"secretsmanager": {
+		"CreateSecret", "DeleteSecret", "DescribeSecret", "GetSecretValue",
+		"ListSecrets", "PutSecretValue", "RotateSecret", "UpdateSecret",
+	},
+	"sns": {
+		"CreateTopic", "DeleteTopic", "GetTopicAttributes", "ListSubscriptions",
+		"ListTopics", "Publish", "SetTopicAttributes", "Subscribe", "Unsubscribe",
+	},
+	"sqs": {
+		"ChangeMessageVisibility", "CreateQueue", "DeleteMessage", "DeleteQueue",
+		"GetQueueAttributes", "GetQueueUrl", "ListQueues", "PurgeQueue",
+		"ReceiveMessage", "SendMessage", "SetQueueAttributes",
+	},
+	"sts": {
+		"AssumeRole", "AssumeRoleWithSAML", "AssumeRoleWithWebIdentity",
+		"GetCallerIdentity", "GetFederationToken", "GetSessionToken",
+	},
+}
+
+// rbacVerbs lists the verbs a Kubernetes "*" verb is shown to grant.
+// Resources may define verbs of their own, which "*" grants as well.
+var rbacVerbs = []string{
+	"get", "list", "watch", "create", "update", "patch", "delete", "deletecollection",
+	"approve", "bind", "escalate", "impersonate", "sign", "use",
+}
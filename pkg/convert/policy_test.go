@@ -0,0 +1,103 @@
+package convert
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const sampleIAMPolicy = `{
+  "Version": "2012-10-17",
+  "Statement": [
+    {"Sid": "ReadBucket", "Effect": "Allow", "Action": ["s3:Get*", "s3:ListBucket"], "Resource": "arn:aws:s3:::demo/*"},
+    {"Effect": "Deny", "Action": "iam:*User", "Resource": "*",
+     "Condition": {"Bool": {"aws:MultiFactorAuthPresent": "false"}}}
+  ]
+}`
+
+const sampleRBAC = `apiVersion: rbac.authorization.k8s.io/v1
+kind: Role
+metadata:
+  name: reader
+  namespace: dev
+rules:
+  - apiGroups: [""]
+    resources: ["pods"]
+    verbs: ["get", "list"]
+  - apiGroups: ["apps"]
+    resources: ["deployments"]
+    verbs: ["*"]
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: RoleBinding
+metadata:
+  name: read-pods
+  namespace: dev
+subjects:
+  - kind: User
+    name: jane
+roleRef:
+  kind: Role
+  name: reader
+`
+
+func TestAnalyzeIAMPolicy(t *testing.T) {
+	m, err := AnalyzeIAMPolicy(sampleIAMPolicy)
+	require.NoError(t, err)
+	require.Len(t, m.Entries, 2)
+	require.Equal(t, "ReadBucket", m.Entries[0].Principal)
+	require.Contains(t, m.Entries[0].Actions, "s3:GetObject")
+	require.Contains(t, m.Entries[0].Actions, "s3:ListBucket")
+	require.NotContains(t, m.Entries[0].Actions, "s3:PutObject")
+	// The wildcard stays, as the catalogue of actions is partial.
+	require.Equal(t, "s3:Get*", m.Entries[0].Actions[0])
+	require.Equal(t, []string{"s3:Get*"}, m.Entries[0].Wildcards)
+	require.Equal(t, "Deny", m.Entries[1].Effect)
+	require.Contains(t, m.Entries[1].Actions, "iam:*User")
+	require.Contains(t, m.Entries[1].Actions, "iam:CreateUser")
+	require.Contains(t, m.Entries[1].Conditions[0], "aws:MultiFactorAuthPresent")
+
+	_, err = AnalyzeIAMPolicy(`{"Version":"2012-10-17"}`)
+	require.Error(t, err)
+}
+
+func TestAnalyzeRBAC(t *testing.T) {
+	m, err := AnalyzeRBAC(sampleRBAC)
+	require.NoError(t, err)
+	require.Len(t, m.Entries, 2)
+	require.Equal(t, "Role/dev/reader", m.Entries[0].Principal)
+	require.Equal(t, []string{"pods"}, m.Entries[0].Resources)
+	require.Equal(t, []string{"User:jane"}, m.Entries[0].GrantedTo)
+	require.Contains(t, m.Entries[1].Actions, "deletecollection")
+	require.Contains(t, m.Entries[1].Actions, "escalate")
+	// "*" also grants custom verbs, so it stays.
+	require.Equal(t, "*", m.Entries[1].Actions[0])
+	require.Equal(t, []string{"*"}, m.Entries[1].Wildcards)
+	require.Nil(t, m.Entries[0].Wildcards)
+	require.Equal(t, []string{"deployments.apps"}, m.Entries[1].Resources)
+}
+
+func TestAnalyzePermissions(t *testing.T) {
+	md, err := AnalyzePermissions("iam", sampleIAMPolicy, "markdown")
+	require.NoError(t, err)
+	require.Contains(t, md, "| Principal | Effect |")
+	require.Contains(t, md, "ReadBucket")
+	require.Contains(t, md, "s3:Get* (partial expansion follows)<br>s3:GetBucketAcl")
+
+	js, err := AnalyzePermissions("rbac", sampleRBAC, "json")
+	require.NoError(t, err)
+	require.Contains(t, js, `"kind": "rbac"`)
+	md, err = AnalyzePermissions("rbac", sampleRBAC, "markdown")
+	require.NoError(t, err)
+	require.Contains(t, md, "* (partial expansion follows)<br>approve")
+
+	_, err = AnalyzePermissions("acl", "{}", "json")
+	require.Error(t, err)
+}
+
+func TestGlobMatch(t *testing.T) {
+	require.True(t, globMatch("get*", "getobject"))
+	require.True(t, globMatch("*user", "createuser"))
+	require.True(t, globMatch("s?", "s3"))
+	require.False(t, globMatch("put*", "getobject"))
+}
@@ -0,0 +1,84 @@
+package convert
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Profile is a named house style: a bundle of ConvertOptions meant for
+// output in Format. Settings describes what it changes, for display.
+type Profile struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Format      string `json:"format"`
+	Settings    string `json:"settings"`
+
+	apply func(*ConvertOptions)
+}
+
+var profiles = map[string]Profile{
+	"kubernetes-yaml": {
+		Description: "Kubernetes manifests: keys in source order so apiVersion and kind lead, no anchors, YAML 1.1 booleans such as yes and on quoted",
+		Format:      formatYAML,
+		Settings:    "indent 2, source key order, final newline",
+		apply: func(o *ConvertOptions) {
+			o.Indent = 2
+			o.SortKeys = false
+			o.FinalNewline = true
+		},
+	},
+	"prettier-json": {
+		Description: "JSON as Prettier writes it",
+		Format:      formatJSON,
+		Settings:    "indent 2, source key order, final newline",
+		apply: func(o *ConvertOptions) {
+			o.Indent = 2
+			o.SortKeys = false
+			o.FinalNewline = true
+		},
+	},
+	"black-toml": {
+		Description: "TOML in the spirit of Black: one array element per line",
+		Format:      formatTOML,
+		Settings:    "multiline arrays, final newline",
+		apply: func(o *ConvertOptions) {
+			o.MultilineArrays = true
+			o.FinalNewline = true
+		},
+	},
+}
+
+// ListProfiles returns every profile sorted by name.
+func ListProfiles() []Profile {
+	out := make([]Profile, 0, len(profiles))
+	for name, p := range profiles {
+		p.Name = name
+		out = append(out, p)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+func lookupProfile(name string) (Profile, bool) {
+	p, ok := profiles[strings.ToLower(strings.TrimSpace(name))]
+	return p, ok
+}
+
+func (o ConvertOptions) checkProfile() error {
+	if o.Profile == "" {
+		return nil
+	}
+	if _, ok := lookupProfile(o.Profile); !ok {
+		return fmt.Errorf("unknown profile: %s", o.Profile)
+	}
+	return nil
+}
+
+// finish applies the settings that act on the final text.
+func (o ConvertOptions) finish(out string) string {
+	if o.FinalNewline && out != "" {
+		return strings.TrimRight(out, "\n") + "\n"
+	}
+	return out
+}
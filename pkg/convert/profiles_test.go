@@ -0,0 +1,40 @@
+package convert
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestListProfiles(t *testing.T) {
+	list := ListProfiles()
+	require.NotEmpty(t, list)
+	names := make([]string, len(list))
+	for i, p := range list {
+		names[i] = p.Name
+		require.NotEmpty(t, p.Description)
+		require.NotEmpty(t, p.Format)
+	}
+	require.IsIncreasing(t, names)
+	require.Contains(t, names, "kubernetes-yaml")
+}
+
+func TestProfiles(t *testing.T) {
+	manifest := `{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"app"},"data":{"enabled":"yes","ports":[{"port":80}]}}`
+	out, err := ConvertFormatsWithOptions(formatJSON, formatYAML, manifest, WithProfile("kubernetes-yaml"))
+	require.NoError(t, err)
+	require.Equal(t, "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: app\ndata:\n  enabled: \"yes\"\n  ports:\n    - port: 80\n", out)
+
+	out, err = FormatContentWithOptions(formatJSON, `{"b":1,"a":[1]}`, false, WithProfile("Prettier-JSON"), WithIndent(4))
+	require.NoError(t, err)
+	require.Equal(t, "{\n  \"b\": 1,\n  \"a\": [\n    1\n  ]\n}\n", out)
+
+	out, err = ConvertFormatsWithOptions(formatJSON, formatTOML, `{"tags":["a","b"]}`, WithProfile("black-toml"))
+	require.NoError(t, err)
+	require.Equal(t, "tags = [\n  'a',\n  'b'\n]\n", out)
+
+	_, err = ConvertFormatsWithOptions(formatJSON, formatYAML, "{}", WithProfile("google-yaml"))
+	require.ErrorContains(t, err, "unknown profile")
+	_, err = FormatContentWithOptions(formatJSON, "{}", false, WithProfile("google-yaml"))
+	require.Error(t, err)
+}
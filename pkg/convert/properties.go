@@ -0,0 +1,245 @@
+package convert
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+const formatProperties = "Properties"
+
+// PropertiesToJSON reads a Java .properties file, with the syntax of
+// java.util.Properties.load: # and ! comments, =, : or whitespace between
+// key and value, backslash line continuations and \uXXXX escapes. Dotted
+// keys become nested objects and objects keyed 0, 1, ... arrays, undoing
+// JSONToProperties; a key that is also the prefix of others keeps them as
+// dotted names beside it. Keys keep the order they are first written in.
+// Values stay strings; a repeated key keeps its last value, as Java does.
+func PropertiesToJSON(input string) (string, error) {
+	flat := map[string]string{}
+	var keys []string
+	lines := strings.Split(strings.ReplaceAll(strings.ReplaceAll(input, "\r\n", "\n"), "\r", "\n"), "\n")
+	for i := 0; i < len(lines); i++ {
+		lineNo := i + 1
+		line := strings.TrimLeft(lines[i], " \t\f")
+		if line == "" || line[0] == '#' || line[0] == '!' {
+			continue
+		}
+		for continuesProperty(line) && i+1 < len(lines) {
+			i++
+			line = line[:len(line)-1] + strings.TrimLeft(lines[i], " \t\f")
+		}
+		if continuesProperty(line) {
+			line = line[:len(line)-1]
+		}
+		key, value := splitProperty(line)
+		k, err := unescapeProperty(key)
+		if err != nil {
+			return "", fmt.Errorf("properties: line %d: %w", lineNo, err)
+		}
+		v, err := unescapeProperty(value)
+		if err != nil {
+			return "", fmt.Errorf("properties: line %d: %w", lineNo, err)
+		}
+		if _, ok := flat[k]; !ok {
+			keys = append(keys, k)
+		}
+		flat[k] = v
+	}
+	return encodeDoc(unflattenProperties(flat, keys))
+}
+
+// continuesProperty reports whether line ends in an odd number of
+// backslashes, which joins the next line onto it.
+func continuesProperty(line string) bool {
+	n := 0
+	for n < len(line) && line[len(line)-1-n] == '\\' {
+		n++
+	}
+	return n%2 == 1
+}
+
+// splitProperty cuts a logical line at the first unescaped =, : or
+// whitespace, skipping whitespace and one = or : around it.
+func splitProperty(line string) (key, value string) {
+	end := len(line)
+	for i := 0; i < len(line); i++ {
+		if line[i] == '\\' {
+			i++
+			continue
+		}
+		if strings.IndexByte("=: \t\f", line[i]) >= 0 {
+			end = i
+			break
+		}
+	}
+	rest := strings.TrimLeft(line[end:], " \t\f")
+	if rest != "" && (rest[0] == '=' || rest[0] == ':') {
+		rest = strings.TrimLeft(rest[1:], " \t\f")
+	}
+	return line[:end], rest
+}
+
+func unescapeProperty(s string) (string, error) {
+	if !strings.Contains(s, `\`) {
+		return s, nil
+	}
+	var b strings.Builder
+	var units []uint16
+	flush := func() {
+		b.WriteString(string(utf16.Decode(units)))
+		units = units[:0]
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c != '\\' || i+1 == len(s) {
+			flush()
+			b.WriteByte(c)
+			continue
+		}
+		i++
+		if s[i] == 'u' {
+			if i+5 > len(s) {
+				return "", errors.New(`malformed \uxxxx encoding`)
+			}
+			n, err := strconv.ParseUint(s[i+1:i+5], 16, 16)
+			if err != nil {
+				return "", errors.New(`malformed \uxxxx encoding`)
+			}
+			units = append(units, uint16(n))
+			i += 4
+			continue
+		}
+		flush()
+		switch s[i] {
+		case 't':
+			b.WriteByte('\t')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 'f':
+			b.WriteByte('\f')
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	flush()
+	return b.String(), nil
+}
+
+// unflattenProperties nests flat dotted keys, taking them in the order keys
+// lists. A key stops splitting at the first prefix that has a value of its
+// own, so {"a": "1", "a.b": "2"} stays two keys rather than losing one.
+func unflattenProperties(flat map[string]string, keys []string) *docNode {
+	b := &docBuilder{}
+	root := b.object()
+	for _, key := range keys {
+		segments := strings.Split(key, ".")
+		if strings.Contains("."+key+".", "..") {
+			// Empty segments have no object to go into.
+			segments = []string{key}
+		}
+		cut := len(segments) - 1
+		for i := 1; i < len(segments); i++ {
+			if _, ok := flat[strings.Join(segments[:i], ".")]; ok {
+				cut = i - 1
+				break
+			}
+		}
+		node := root
+		for _, seg := range segments[:cut] {
+			child := b.get(node, seg)
+			if child == nil || child.kind != docObject {
+				child = b.object()
+				b.set(node, seg, child)
+			}
+			node = child
+		}
+		b.set(node, strings.Join(segments[cut:], "."), b.str(flat[key]))
+	}
+	for i, item := range root.items {
+		root.items[i] = propertiesArrays(b, item)
+	}
+	return root
+}
+
+// propertiesArrays turns objects keyed 0 to n-1 back into arrays.
+func propertiesArrays(b *docBuilder, n *docNode) *docNode {
+	if n.kind != docObject {
+		return n
+	}
+	for i, item := range n.items {
+		n.items[i] = propertiesArrays(b, item)
+	}
+	arr := b.array()
+	arr.items = make([]*docNode, len(n.keys))
+	for j, k := range n.keys {
+		i, err := strconv.Atoi(k.name)
+		if err != nil || i < 0 || i >= len(n.keys) || strconv.Itoa(i) != k.name {
+			return n
+		}
+		arr.items[i] = n.items[j]
+	}
+	return arr
+}
+
+// JSONToProperties writes JSON as a Java .properties file in the style of
+// java.util.Properties.store: key=value lines in the order of the input,
+// nested keys joined with dots and array elements with their index, and
+// everything outside printable ASCII as \uXXXX escapes.
+func JSONToProperties(input string) (string, error) {
+	return jsonToPropertiesWithOptions(input, NewConvertOptions())
+}
+
+// jsonToPropertiesWithOptions sorts the lines when SortKeys is set.
+func jsonToPropertiesWithOptions(input string, o ConvertOptions) (string, error) {
+	data, err := decodeJSONValue(input)
+	if err != nil {
+		return "", err
+	}
+	if _, ok := data.(map[string]any); !ok {
+		return "", errors.New("Properties root must be an object")
+	}
+	flat := flattenProperties(data, ".", o.sourceOrder(input))
+	var b strings.Builder
+	for _, k := range flat.keys {
+		b.WriteString(escapeProperty(k, true) + "=" + escapeProperty(flat.values[k], false) + "\n")
+	}
+	return b.String(), nil
+}
+
+// escapeProperty escapes s as Properties.store does; spaces are escaped
+// everywhere in keys but only in front of values.
+func escapeProperty(s string, key bool) string {
+	var b strings.Builder
+	for i, r := range s {
+		switch {
+		case r == ' ':
+			if key || i == 0 {
+				b.WriteByte('\\')
+			}
+			b.WriteByte(' ')
+		case r == '\t':
+			b.WriteString(`\t`)
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\r':
+			b.WriteString(`\r`)
+		case r == '\f':
+			b.WriteString(`\f`)
+		case strings.ContainsRune(`\=:#!`, r):
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < 0x20 || r > 0x7e:
+			for _, u := range utf16.Encode([]rune{r}) {
+				fmt.Fprintf(&b, `\u%04X`, u)
+			}
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
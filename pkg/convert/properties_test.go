@@ -0,0 +1,63 @@
+package convert
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPropertiesToJSON(t *testing.T) {
+	out, err := PropertiesToJSON("# database\n" +
+		"! legacy comment\n" +
+		"db.url = jdbc:postgresql://localhost/app\n" +
+		"db.hosts.0=h1\n" +
+		"db.hosts.1:h2\n" +
+		"greeting   Hello, \\\n" +
+		"           world\n" +
+		"path\\ with\\ spaces=C:\\\\temp\\tdir\n" +
+		"unicode=caf\\u00e9 \\uD83D\\uDE00\n" +
+		"empty\n" +
+		"log.appender=console\n" +
+		"log.appender.layout=json\n" +
+		"ports.0=80\r\nports.2=443\r\n")
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"db": {"url": "jdbc:postgresql://localhost/app", "hosts": ["h1", "h2"]},
+		"greeting": "Hello, world",
+		"path with spaces": "C:\\temp\tdir",
+		"unicode": "café 😀",
+		"empty": "",
+		"log": {"appender": "console", "appender.layout": "json"},
+		"ports": {"0": "80", "2": "443"}
+	}`, out)
+
+	out, err = PropertiesToJSON("a=1\na=2\n..b=3\n")
+	require.NoError(t, err)
+	require.JSONEq(t, `{"a": "2", "..b": "3"}`, out)
+
+	_, err = PropertiesToJSON("ok=1\nbad=\\u12g4\n")
+	require.EqualError(t, err, `properties: line 2: malformed \uxxxx encoding`)
+}
+
+func TestJSONToProperties(t *testing.T) {
+	out, err := JSONToProperties(`{"db": {"url": "jdbc:h2:mem", "hosts": ["h1", "h2"], "pass": null},
+		"greeting": " hello world\n", "key=with:odd#chars": "café 😀", "debug": true, "n": 1.50}`)
+	require.NoError(t, err)
+	require.Equal(t, `db.url=jdbc\:h2\:mem
+db.hosts.0=h1
+db.hosts.1=h2
+db.pass=
+greeting=\ hello world\n
+key\=with\:odd\#chars=caf\u00E9 \uD83D\uDE00
+debug=true
+n=1.50
+`, out)
+
+	back, err := PropertiesToJSON(out)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"db": {"url": "jdbc:h2:mem", "hosts": ["h1", "h2"], "pass": ""},
+		"greeting": " hello world\n", "key=with:odd#chars": "café 😀", "debug": "true", "n": "1.50"}`, back)
+
+	_, err = JSONToProperties(`["a"]`)
+	require.EqualError(t, err, "Properties root must be an object")
+}
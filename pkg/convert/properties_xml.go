@@ -0,0 +1,114 @@
+package convert
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const propertiesDoctype = `<!DOCTYPE properties SYSTEM "http://java.sun.com/dtd/properties.dtd">`
+
+type javaPropertiesXML struct {
+	XMLName xml.Name `xml:"properties"`
+	Comment string   `xml:"comment"`
+	Entries []struct {
+		Key   string `xml:"key,attr"`
+		Value string `xml:",chardata"`
+	} `xml:"entry"`
+}
+
+// PropertiesXMLToJSON reads the java.util.Properties XML format into a flat
+// JSON object of string values, in the order the entries are written.
+func PropertiesXMLToJSON(input string) (string, error) {
+	var doc javaPropertiesXML
+	dec := xml.NewDecoder(strings.NewReader(input))
+	dec.Strict = false
+	if err := dec.Decode(&doc); err != nil {
+		return "", fmt.Errorf("invalid properties XML: %w", err)
+	}
+	b := &docBuilder{}
+	obj := b.object()
+	for _, e := range doc.Entries {
+		if e.Key == "" {
+			return "", errors.New("properties entry without key")
+		}
+		b.set(obj, e.Key, b.str(e.Value))
+	}
+	return encodeDoc(obj)
+}
+
+// JSONToPropertiesXML writes JSON as java.util.Properties XML. Nested keys
+// are flattened with dots and array elements with their index.
+func JSONToPropertiesXML(input string) (string, error) {
+	return jsonToPropertiesXMLWithOptions(input, NewConvertOptions())
+}
+
+// jsonToPropertiesXMLWithOptions writes the entries in the order of the
+// JSON input unless SortKeys is set.
+func jsonToPropertiesXMLWithOptions(input string, o ConvertOptions) (string, error) {
+	data, err := decodeJSONValue(input)
+	if err != nil {
+		return "", err
+	}
+	flat := flattenProperties(data, ".", o.sourceOrder(input))
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="no"?>` + "\n")
+	b.WriteString(propertiesDoctype + "\n")
+	b.WriteString("<properties>\n")
+	for _, k := range flat.keys {
+		b.WriteString(`  <entry key="` + xmlEscape(k) + `">` + xmlEscape(flat.values[k]) + "</entry>\n")
+	}
+	b.WriteString("</properties>")
+	return b.String(), nil
+}
+
+// flatProperties are the scalars of a document by their flattened keys,
+// with the keys in the order they are written.
+type flatProperties struct {
+	values map[string]string
+	keys   []string
+}
+
+// flattenProperties collects every scalar below v under its path, with
+// keys and array indexes joined by sep; null becomes the empty string. The
+// keys follow order, or are sorted when order is nil.
+func flattenProperties(v any, sep string, order sourceOrder) *flatProperties {
+	f := &flatProperties{values: map[string]string{}}
+	f.add("", "", sep, v, order)
+	if order == nil {
+		sort.Strings(f.keys)
+	}
+	return f
+}
+
+// add collects the scalars below v, found at path and flattened to prefix.
+func (f *flatProperties) add(prefix, path, sep string, v any, order sourceOrder) {
+	join := func(key string) string {
+		if prefix == "" {
+			return key
+		}
+		return prefix + sep + key
+	}
+	switch val := v.(type) {
+	case map[string]any:
+		for _, k := range order.keys(val, path) {
+			f.add(join(k), keyPath(path, k), sep, val[k], order)
+		}
+	case []any:
+		for i, inner := range val {
+			f.add(join(strconv.Itoa(i)), path+"[]", sep, inner, order)
+		}
+	default:
+		if _, ok := f.values[prefix]; !ok {
+			f.keys = append(f.keys, prefix)
+		}
+		if val == nil {
+			f.values[prefix] = ""
+		} else {
+			f.values[prefix] = fmt.Sprint(val)
+		}
+	}
+}
@@ -5,22 +5,47 @@ import (
 	"errors"
 	"fmt"
 	"go/ast"
-	"regexp"
+	"go/token"
+	"math"
+	"reflect"
 	"sort"
+	"strconv"
 	"strings"
+	"time"
 	"unicode"
 
 	"github.com/linzeyan/transform-go/pkg/common"
 )
 
-var protoMessageDeclRe = regexp.MustCompile(`message\s+([A-Za-z0-9_]+)\s*\{`)
+var (
+	protoMessageDeclRe = common.LazyRegexp(`message\s+([A-Za-z0-9_]+)\s*\{`)
+	protoEnumDeclRe    = common.LazyRegexp(`enum\s+([A-Za-z0-9_]+)\s*\{`)
+	protoNestedDeclRe  = common.LazyRegexp(`(?:message|enum)\s+[A-Za-z0-9_]+\s*\{`)
+	protoOneofDeclRe   = common.LazyRegexp(`oneof\s+[A-Za-z0-9_]+\s*\{`)
+)
+
+const (
+	protoTimestamp       = "google.protobuf.Timestamp"
+	protoTimestampImport = `import "google/protobuf/timestamp.proto";`
+)
 
 func JSONToProto(input string) (string, error) {
+	return JSONToProtoWithOptions(input)
+}
+
+// JSONToProtoWithOptions infers messages from a JSON sample, nesting the
+// messages of inner objects in their parent and typing RFC 3339 strings as
+// google.protobuf.Timestamp. A JSON Schema document is read as a schema
+// instead: string enums become enum blocks and oneOf/anyOf properties
+// become oneof groups. ProtoService and ProtoHTTP add a CRUD service for
+// the root message.
+func JSONToProtoWithOptions(input string, opts ...ConvertOption) (string, error) {
 	data, err := decodeJSONValue(input)
 	if err != nil {
 		return "", err
 	}
-	return buildProtoSchema("AutoGenerated", data)
+	o := NewConvertOptions(opts...)
+	return buildProtoSchema("AutoGenerated", data, o, o.sourceOrder(input))
 }
 
 func ProtoToJSON(input string) (string, error) {
@@ -29,28 +54,39 @@ func ProtoToJSON(input string) (string, error) {
 		return "", errors.New("no protobuf message found")
 	}
 	root := schema.order[0]
-	val := schema.sampleMessage(root, map[string]int{})
-	return encodeJSON(val)
+	val := schema.sampleMessage(&docBuilder{}, root, map[string]int{})
+	return encodeDoc(val)
 }
 
 func GoStructToProto(src string) (string, error) {
+	return GoStructToProtoWithOptions(src)
+}
+
+// GoStructToProtoWithOptions renders each struct as a message. Anonymous
+// struct fields become nested messages, time.Time becomes
+// google.protobuf.Timestamp, string fields with an `enum:"a,b"` or
+// `validate:"oneof=a b"` tag get an enum, and fields sharing a
+// `oneof:"name"` tag are grouped in a oneof. ProtoService and ProtoHTTP
+// work as for JSON, with a service per struct.
+func GoStructToProtoWithOptions(src string, opts ...ConvertOption) (string, error) {
 	defs, err := parseGoStructDefinitions(src)
 	if err != nil {
 		return "", err
 	}
-	var messages []string
+	b := newProtoBuilder(NewConvertOptions(opts...))
+	var messages []*protoMessageDef
 	seen := map[string]bool{}
 	for _, def := range defs {
 		if seen[def.Name] {
 			continue
 		}
-		messages = append(messages, renderProtoMessage(def))
+		messages = append(messages, b.goMessage(def))
 		seen[def.Name] = true
 	}
 	if len(messages) == 0 {
 		return "", errors.New("no struct definition found")
 	}
-	return strings.Join(messages, "\n\n"), nil
+	return b.render(messages), nil
 }
 
 func ProtoToGoStruct(input string) (string, error) {
@@ -69,132 +105,379 @@ func ProtoToGoStruct(input string) (string, error) {
 	return strings.Join(blocks, "\n\n"), nil
 }
 
-type protoBuilder struct {
-	defs  map[string]string
-	order []string
+// protoMessageDef is a message being generated; its enums and nested
+// messages are rendered inside it.
+type protoMessageDef struct {
+	name   string
+	enums  []protoEnumDef
+	nested []*protoMessageDef
+	fields []protoFieldLine
 }
 
-func newProtoBuilder() *protoBuilder {
-	return &protoBuilder{defs: make(map[string]string)}
+type protoEnumDef struct {
+	name   string
+	values []string
 }
 
-func buildProtoSchema(root string, data any) (string, error) {
-	builder := newProtoBuilder()
-	builder.addMessage(root, data)
-	defs := builder.definitions()
-	if len(defs) == 0 {
-		return fmt.Sprintf("message %s {\n  string value = 1;\n}", root), nil
-	}
-	return strings.Join(defs, "\n\n"), nil
+type protoFieldLine struct {
+	comment  string
+	repeated bool
+	typeName string
+	name     string
+	oneof    string
 }
 
-func (b *protoBuilder) definitions() []string {
-	res := make([]string, 0, len(b.defs))
-	for _, name := range b.order {
-		if def, ok := b.defs[name]; ok {
-			res = append(res, def)
+// child returns the nested message called name, creating it when missing;
+// filled reports whether it already existed.
+func (m *protoMessageDef) child(name string) (msg *protoMessageDef, filled bool) {
+	for _, n := range m.nested {
+		if n.name == name {
+			return n, true
 		}
 	}
-	return res
+	msg = &protoMessageDef{name: name}
+	m.nested = append(m.nested, msg)
+	return msg, false
 }
 
-func (b *protoBuilder) addMessage(name string, v any) string {
-	typeName := sanitizeTypeName(name)
-	switch val := v.(type) {
-	case map[string]any:
-		return b.buildMessage(typeName, val)
-	case []any:
-		return b.repeatedType(typeName+"Item", val).typeName
-	default:
-		return protoScalarType(val)
+// addEnum declares a nested enum with the zero value proto3 requires and
+// returns its type name.
+func (m *protoMessageDef) addEnum(name string, values []string) string {
+	for _, e := range m.enums {
+		if e.name == name {
+			return name
+		}
 	}
+	prefix := enumValueName(name)
+	enum := protoEnumDef{name: name, values: []string{prefix + "_UNSPECIFIED"}}
+	seen := map[string]bool{}
+	for i, v := range values {
+		value := enumValueName(v)
+		if value == "" {
+			value = fmt.Sprintf("VALUE_%d", i+1)
+		}
+		if !seen[value] {
+			seen[value] = true
+			enum.values = append(enum.values, prefix+"_"+value)
+		}
+	}
+	m.enums = append(m.enums, enum)
+	return name
 }
 
-type protoType struct {
-	typeName string
-	repeated bool
-}
-
-func (b *protoBuilder) buildMessage(name string, obj map[string]any) string {
-	if _, ok := b.defs[name]; ok {
-		return name
+func (m *protoMessageDef) render(indent string) string {
+	inner := indent + "  "
+	var blocks []string
+	for _, e := range m.enums {
+		lines := []string{fmt.Sprintf("%senum %s {", inner, e.name)}
+		for i, v := range e.values {
+			lines = append(lines, fmt.Sprintf("%s  %s = %d;", inner, v, i))
+		}
+		blocks = append(blocks, strings.Join(append(lines, inner+"}"), "\n"))
 	}
-	keys := make([]string, 0, len(obj))
-	for k := range obj {
-		keys = append(keys, k)
+	for _, n := range m.nested {
+		blocks = append(blocks, n.render(inner))
 	}
-	sort.Strings(keys)
-	fieldNum := 1
 	var lines []string
-	for _, key := range keys {
-		field := b.typeForField(name, key, obj[key])
-		fieldName := protoFieldName(key)
-		if fieldName == "" {
-			fieldName = fmt.Sprintf("field_%d", fieldNum)
+	num := 1
+	grouped := map[string]bool{}
+	for i, f := range m.fields {
+		if f.oneof == "" {
+			lines = append(lines, f.render(inner, num)...)
+			num++
+			continue
 		}
-		prefix := ""
-		if field.repeated {
-			prefix = "repeated "
+		if grouped[f.oneof] {
+			continue
+		}
+		grouped[f.oneof] = true
+		lines = append(lines, fmt.Sprintf("%soneof %s {", inner, f.oneof))
+		for _, member := range m.fields[i:] {
+			if member.oneof == f.oneof {
+				lines = append(lines, member.render(inner+"  ", num)...)
+				num++
+			}
 		}
-		lines = append(lines, fmt.Sprintf("  %s%s %s = %d;", prefix, field.typeName, fieldName, fieldNum))
-		fieldNum++
+		lines = append(lines, inner+"}")
 	}
 	if len(lines) == 0 {
-		lines = append(lines, "  string placeholder = 1;")
+		lines = append(lines, inner+"string placeholder = 1;")
+	}
+	blocks = append(blocks, strings.Join(lines, "\n"))
+	return fmt.Sprintf("%smessage %s {\n%s\n%s}", indent, m.name, strings.Join(blocks, "\n\n"), indent)
+}
+
+func (f protoFieldLine) render(indent string, num int) []string {
+	var lines []string
+	for _, line := range strings.Split(f.comment, "\n") {
+		line = strings.TrimSpace(strings.TrimPrefix(line, "//"))
+		if line != "" {
+			lines = append(lines, indent+"// "+line)
+		}
 	}
-	def := fmt.Sprintf("message %s {\n%s\n}", name, strings.Join(lines, "\n"))
-	if _, ok := b.defs[name]; !ok {
-		b.order = append(b.order, name)
+	prefix := ""
+	if f.repeated {
+		prefix = "repeated "
 	}
-	b.defs[name] = def
-	return name
+	return append(lines, fmt.Sprintf("%s%s%s %s = %d;", indent, prefix, f.typeName, f.name, num))
 }
 
-func (b *protoBuilder) typeForField(parent, field string, v any) protoType {
-	switch val := v.(type) {
+type protoBuilder struct {
+	opts    ConvertOptions
+	imports map[string]bool
+	// order is the key order of the JSON input fields follow.
+	order sourceOrder
+}
+
+func newProtoBuilder(o ConvertOptions) *protoBuilder {
+	return &protoBuilder{opts: o, imports: make(map[string]bool)}
+}
+
+// render joins top-level messages after the imports they need, followed by
+// the services for them when ProtoService or ProtoHTTP is set.
+func (b *protoBuilder) render(messages []*protoMessageDef) string {
+	var services []string
+	if b.opts.ProtoService || b.opts.ProtoHTTP {
+		var extra []*protoMessageDef
+		extra, services = b.services(messages)
+		messages = append(messages, extra...)
+		if b.opts.ProtoHTTP {
+			b.imports[protoAnnotationsImport] = true
+		}
+	}
+	var blocks []string
+	if len(b.imports) > 0 {
+		imports := make([]string, 0, len(b.imports))
+		for imp := range b.imports {
+			imports = append(imports, imp)
+		}
+		sort.Strings(imports)
+		blocks = append(blocks, strings.Join(imports, "\n"))
+	}
+	for _, msg := range messages {
+		blocks = append(blocks, msg.render(""))
+	}
+	blocks = append(blocks, services...)
+	return strings.Join(blocks, "\n\n")
+}
+
+func (b *protoBuilder) timestamp() string {
+	b.imports[protoTimestampImport] = true
+	return protoTimestamp
+}
+
+// buildProtoSchema infers the message called root from data, numbering
+// fields in the order order gives their keys.
+func buildProtoSchema(root string, data any, o ConvertOptions, order sourceOrder) (string, error) {
+	builder := newProtoBuilder(o)
+	builder.order = order
+	name := sanitizeTypeName(root)
+	var msg *protoMessageDef
+	switch val := data.(type) {
 	case map[string]any:
-		typeName := b.buildMessage(parent+common.ExportName(field), val)
-		return protoType{typeName: typeName}
+		if isJSONSchemaDocument(val) {
+			if title, ok := val["title"].(string); ok && title != "" {
+				name = sanitizeTypeName(title)
+			}
+			msg = &protoMessageDef{name: name}
+			builder.fillSchemaMessage(msg, val, "")
+		} else {
+			msg = &protoMessageDef{name: name}
+			builder.fillMessage(msg, val, "")
+		}
 	case []any:
-		t := b.repeatedType(parent+common.ExportName(field)+"Item", val)
-		t.repeated = true
-		return t
-	default:
-		return protoType{typeName: protoScalarType(val)}
+		var v any = val
+		path := ""
+		for {
+			arr, ok := v.([]any)
+			if !ok {
+				break
+			}
+			name += "Item"
+			v = protoSample(arr)
+			path += "[]"
+		}
+		if obj, ok := v.(map[string]any); ok {
+			msg = &protoMessageDef{name: name}
+			builder.fillMessage(msg, obj, path)
+		}
 	}
+	if msg == nil {
+		msg = &protoMessageDef{name: root, fields: []protoFieldLine{{typeName: "string", name: "value"}}}
+	}
+	return builder.render([]*protoMessageDef{msg}), nil
 }
 
-func (b *protoBuilder) repeatedType(name string, arr []any) protoType {
-	var sample any
+// protoSample is the first non-null element of arr.
+func protoSample(arr []any) any {
 	for _, item := range arr {
 		if item != nil {
-			sample = item
-			break
+			return item
+		}
+	}
+	return nil
+}
+
+// fillMessage adds a field to msg for every key of obj, the object at path.
+func (b *protoBuilder) fillMessage(msg *protoMessageDef, obj map[string]any, path string) {
+	for i, key := range b.order.keys(obj, path) {
+		field := protoFieldLine{name: protoFieldName(key)}
+		if field.name == "" {
+			field.name = fmt.Sprintf("field_%d", i+1)
 		}
+		typeName := sanitizeTypeName(key)
+		v, vPath := obj[key], keyPath(path, key)
+		for {
+			arr, ok := v.([]any)
+			if !ok {
+				break
+			}
+			// proto3 has no nested repeated fields; use the innermost item.
+			field.repeated = true
+			typeName += "Item"
+			v = protoSample(arr)
+			vPath += "[]"
+		}
+		switch val := v.(type) {
+		case map[string]any:
+			child, filled := msg.child(typeName)
+			if !filled {
+				b.fillMessage(child, val, vPath)
+			}
+			field.typeName = typeName
+		case string:
+			field.typeName = "string"
+			if protoLooksTimestamp(val) {
+				field.typeName = b.timestamp()
+			}
+		default:
+			field.typeName = protoScalarType(val)
+		}
+		msg.fields = append(msg.fields, field)
 	}
-	if sample == nil && len(arr) > 0 {
-		sample = arr[0]
+}
+
+func protoLooksTimestamp(s string) bool {
+	_, err := time.Parse(time.RFC3339Nano, s)
+	return err == nil
+}
+
+// isJSONSchemaDocument tells a JSON Schema apart from a data sample.
+func isJSONSchemaDocument(obj map[string]any) bool {
+	if _, ok := obj["$schema"]; ok {
+		return true
 	}
-	if sample == nil {
-		return protoType{typeName: "string"}
+	props, ok := obj["properties"].(map[string]any)
+	return ok && len(props) > 0 && obj["type"] == "object"
+}
+
+// fillSchemaMessage adds a field to msg for every property of schema, the
+// schema at path.
+func (b *protoBuilder) fillSchemaMessage(msg *protoMessageDef, schema map[string]any, path string) {
+	props, _ := schema["properties"].(map[string]any)
+	propsPath := keyPath(path, "properties")
+	for i, key := range b.order.keys(props, propsPath) {
+		propPath := keyPath(propsPath, key)
+		prop, _ := props[key].(map[string]any)
+		name := protoFieldName(key)
+		if name == "" {
+			name = fmt.Sprintf("field_%d", i+1)
+		}
+		comment, _ := prop["description"].(string)
+		alternatives, _ := prop["oneOf"].([]any)
+		if len(alternatives) == 0 {
+			alternatives, _ = prop["anyOf"].([]any)
+		}
+		if len(alternatives) == 0 {
+			typeName, repeated := b.schemaFieldType(msg, sanitizeTypeName(key), prop, propPath)
+			msg.fields = append(msg.fields, protoFieldLine{comment: comment, name: name, typeName: typeName, repeated: repeated})
+			continue
+		}
+		altPath := keyPath(propPath, "oneOf") + "[]"
+		if _, ok := prop["oneOf"]; !ok {
+			altPath = keyPath(propPath, "anyOf") + "[]"
+		}
+		for n, alt := range alternatives {
+			altSchema, _ := alt.(map[string]any)
+			label, _ := altSchema["title"].(string)
+			if label == "" {
+				label = fmt.Sprintf("option%d", n+1)
+			}
+			typeName, repeated := b.schemaFieldType(msg, sanitizeTypeName(key)+sanitizeTypeName(label), altSchema, altPath)
+			if repeated {
+				// oneof members cannot be repeated.
+				continue
+			}
+			msg.fields = append(msg.fields, protoFieldLine{
+				comment:  comment,
+				name:     name + "_" + protoFieldName(label),
+				typeName: typeName,
+				oneof:    name,
+			})
+			comment = ""
+		}
 	}
-	switch val := sample.(type) {
-	case map[string]any:
-		typeName := b.buildMessage(sanitizeTypeName(name), val)
-		return protoType{typeName: typeName}
-	case []any:
-		t := b.repeatedType(name+"Item", val)
-		return protoType{typeName: t.typeName}
-	default:
-		return protoType{typeName: protoScalarType(sample)}
+}
+
+// schemaFieldType maps a property schema to a proto type, declaring nested
+// messages and enums on msg as needed. path is the path of schema.
+func (b *protoBuilder) schemaFieldType(msg *protoMessageDef, typeName string, schema map[string]any, path string) (string, bool) {
+	switch schemaType(schema) {
+	case "array":
+		items, _ := schema["items"].(map[string]any)
+		inner, _ := b.schemaFieldType(msg, typeName+"Item", items, keyPath(path, "items"))
+		return inner, true
+	case "object":
+		props, _ := schema["properties"].(map[string]any)
+		if len(props) == 0 {
+			return "string", false
+		}
+		child, filled := msg.child(typeName)
+		if !filled {
+			b.fillSchemaMessage(child, schema, path)
+		}
+		return typeName, false
+	case "string":
+		if enum, ok := schema["enum"].([]any); ok && len(enum) > 0 {
+			values := make([]string, len(enum))
+			for i, v := range enum {
+				values[i] = fmt.Sprint(v)
+			}
+			return msg.addEnum(typeName, values), false
+		}
+		if format, _ := schema["format"].(string); format == "date-time" {
+			return b.timestamp(), false
+		}
+		if format, _ := schema["format"].(string); format == "byte" || format == "binary" {
+			return "bytes", false
+		}
+		return "string", false
+	case "integer":
+		if format, _ := schema["format"].(string); format == "int64" {
+			return "int64", false
+		}
+		return "int32", false
+	case "number":
+		return "double", false
+	case "boolean":
+		return "bool", false
 	}
+	return "string", false
 }
 
 func protoScalarType(v any) string {
 	switch val := v.(type) {
 	case json.Number:
-		if common.LooksInteger(val) {
+		// The narrowest integer type that holds the sample, as 64-bit IDs
+		// do not fit an int32.
+		switch n := common.NumberValue(val).(type) {
+		case int64:
+			if n < math.MinInt32 || n > math.MaxInt32 {
+				return "int64"
+			}
 			return "int32"
+		case uint64:
+			return "uint64"
 		}
 		return "double"
 	case string:
@@ -210,51 +493,67 @@ func protoScalarType(v any) string {
 	}
 }
 
-func renderProtoMessage(def StructDefinition) string {
-	var lines []string
-	fieldNum := 1
+func (b *protoBuilder) goMessage(def StructDefinition) *protoMessageDef {
+	msg := &protoMessageDef{name: def.Name}
 	for _, field := range def.Fields {
-		if field.Comment != "" {
-			for _, line := range strings.Split(field.Comment, "\n") {
-				line = strings.TrimSpace(strings.TrimPrefix(line, "//"))
-				if line == "" {
-					continue
-				}
-				lines = append(lines, "  // "+line)
-			}
-		}
 		fieldName := field.JSONName
 		if fieldName == "" {
 			fieldName = protoFieldName(field.GoName)
 		}
-		repeated, protoType := goTypeToProto(field.TypeExpr)
-		prefix := ""
-		if repeated {
-			prefix = "repeated "
+		tag := reflect.StructTag(strings.Trim(field.Tag, "`"))
+		line := protoFieldLine{comment: field.Comment, name: fieldName}
+		line.repeated, line.typeName = b.goFieldType(msg, field.GoName, field.TypeExpr)
+		if values := goEnumHint(tag); len(values) > 0 && line.typeName == "string" {
+			line.typeName = msg.addEnum(sanitizeTypeName(field.GoName), values)
 		}
-		lines = append(lines, fmt.Sprintf("  %s%s %s = %d;", prefix, protoType, fieldName, fieldNum))
-		fieldNum++
+		if group := tag.Get("oneof"); group != "" && !line.repeated {
+			line.oneof = protoFieldName(group)
+		}
+		msg.fields = append(msg.fields, line)
 	}
-	if len(lines) == 0 {
-		lines = append(lines, "  string placeholder = 1;")
+	return msg
+}
+
+// goEnumHint reads allowed values from an `enum:"a,b"` tag or a
+// validator-style `validate:"oneof=a b"` / `binding:"oneof=a b"` rule.
+func goEnumHint(tag reflect.StructTag) []string {
+	if enum := tag.Get("enum"); enum != "" {
+		return strings.Split(enum, ",")
 	}
-	return fmt.Sprintf("message %s {\n%s\n}", def.Name, strings.Join(lines, "\n"))
+	for _, key := range []string{"validate", "binding"} {
+		for _, rule := range strings.Split(tag.Get(key), ",") {
+			if values, ok := strings.CutPrefix(rule, "oneof="); ok {
+				return strings.Fields(values)
+			}
+		}
+	}
+	return nil
 }
 
-func goTypeToProto(expr ast.Expr) (bool, string) {
+func (b *protoBuilder) goFieldType(msg *protoMessageDef, goName string, expr ast.Expr) (bool, string) {
 	switch t := expr.(type) {
 	case *ast.ArrayType:
-		_, inner := goTypeToProto(t.Elt)
+		if ident, ok := t.Elt.(*ast.Ident); ok && (ident.Name == "byte" || ident.Name == "uint8") {
+			return false, "bytes"
+		}
+		_, inner := b.goFieldType(msg, goName, t.Elt)
 		return true, inner
 	case *ast.StarExpr:
-		return goTypeToProto(t.X)
+		return b.goFieldType(msg, goName, t.X)
 	case *ast.Ident:
 		return false, identProtoType(t.Name)
 	case *ast.SelectorExpr:
-		if t.Sel != nil {
-			return false, identProtoType(t.Sel.Name)
+		if pkg, ok := t.X.(*ast.Ident); ok && pkg.Name == "time" && t.Sel.Name == "Time" {
+			return false, b.timestamp()
 		}
-		return false, "string"
+		return false, identProtoType(t.Sel.Name)
+	case *ast.StructType:
+		name := sanitizeTypeName(goName)
+		child, filled := msg.child(name)
+		if !filled {
+			*child = *b.goMessage(buildStructDefinition(name, t, token.NewFileSet()))
+		}
+		return false, name
 	default:
 		return false, "string"
 	}
@@ -278,6 +577,7 @@ func identProtoType(name string) string {
 type protoFieldDef struct {
 	Name     string
 	TypeName string
+	Number   int
 	Repeated bool
 	Comment  string
 }
@@ -290,21 +590,81 @@ type protoMessage struct {
 type protoSchema struct {
 	order    []string
 	messages map[string]*protoMessage
+	enums    map[string][]string
+	// enumNames maps the numbers of each enum to their value names.
+	enumNames map[string]map[uint64]string
+	// nested lists the messages declared in each message, in order, with
+	// the top-level messages under "".
+	nested map[string][]string
 }
 
 func parseProtoSchema(src string) *protoSchema {
 	ps := &protoSchema{
-		order:    []string{},
-		messages: make(map[string]*protoMessage),
+		order:     []string{},
+		messages:  make(map[string]*protoMessage),
+		enums:     make(map[string][]string),
+		enumNames: make(map[string]map[uint64]string),
+		nested:    make(map[string][]string),
+	}
+	for _, loc := range protoEnumDeclRe().FindAllStringSubmatchIndex(src, -1) {
+		openIdx := loc[1] - 1
+		closeIdx := common.FindMatchingBrace(src, openIdx)
+		if closeIdx == -1 {
+			continue
+		}
+		var values []string
+		names := map[uint64]string{}
+		for _, stmt := range strings.Split(src[openIdx+1:closeIdx], ";") {
+			if name, num, ok := strings.Cut(strings.TrimSpace(stmt), "="); ok {
+				name = strings.TrimSpace(name)
+				values = append(values, name)
+				num, _, _ = strings.Cut(num, "[")
+				if n, err := strconv.ParseInt(strings.TrimSpace(num), 0, 32); err == nil {
+					if _, dup := names[uint64(n)]; !dup {
+						names[uint64(n)] = name
+					}
+				}
+			}
+		}
+		ps.enums[src[loc[2]:loc[3]]] = values
+		ps.enumNames[src[loc[2]:loc[3]]] = names
 	}
-	parseProtoSection(src, ps)
+	parseProtoSection(src, ps, "")
 	return ps
 }
 
-func parseProtoSection(src string, ps *protoSchema) {
+// protoOwnFields drops nested message and enum blocks from a message body
+// and unwraps oneof groups, leaving only the message's own fields.
+func protoOwnFields(body string) string {
+	for {
+		loc := protoNestedDeclRe().FindStringIndex(body)
+		if loc == nil {
+			break
+		}
+		closeIdx := common.FindMatchingBrace(body, loc[1]-1)
+		if closeIdx == -1 {
+			return body[:loc[0]]
+		}
+		body = body[:loc[0]] + body[closeIdx+1:]
+	}
+	for {
+		loc := protoOneofDeclRe().FindStringIndex(body)
+		if loc == nil {
+			break
+		}
+		closeIdx := common.FindMatchingBrace(body, loc[1]-1)
+		if closeIdx == -1 {
+			return body[:loc[0]]
+		}
+		body = body[:loc[0]] + body[loc[1]:closeIdx] + "\n" + body[closeIdx+1:]
+	}
+	return body
+}
+
+func parseProtoSection(src string, ps *protoSchema, parent string) {
 	idx := 0
 	for idx < len(src) {
-		loc := protoMessageDeclRe.FindStringSubmatchIndex(src[idx:])
+		loc := protoMessageDeclRe().FindStringSubmatchIndex(src[idx:])
 		if loc == nil {
 			break
 		}
@@ -317,7 +677,8 @@ func parseProtoSection(src string, ps *protoSchema) {
 		}
 		body := src[openIdx+1 : closeIdx]
 		ps.addMessage(name, body)
-		parseProtoSection(body, ps)
+		ps.nested[parent] = append(ps.nested[parent], strings.TrimSpace(name))
+		parseProtoSection(body, ps, strings.TrimSpace(name))
 		idx = closeIdx + 1
 	}
 }
@@ -329,7 +690,7 @@ func (ps *protoSchema) addMessage(name, body string) {
 	}
 	msg := &protoMessage{Name: name}
 	var pending []string
-	lines := strings.Split(body, "\n")
+	lines := strings.Split(protoOwnFields(body), "\n")
 	for _, raw := range lines {
 		line := strings.TrimSpace(raw)
 		if line == "" {
@@ -352,23 +713,34 @@ func (ps *protoSchema) addMessage(name, body string) {
 			repeated = true
 			line = strings.TrimSpace(line[len("repeated "):])
 		}
+		line = strings.TrimSpace(strings.TrimPrefix(line, "optional "))
 		line = strings.TrimSuffix(line, ";")
 		eqIdx := strings.Index(line, "=")
 		if eqIdx == -1 {
 			continue
 		}
 		left := strings.TrimSpace(line[:eqIdx])
+		if strings.HasPrefix(left, "map<") {
+			// map<string, int32> counts: drop the space so the type is
+			// one field.
+			if end := strings.IndexByte(left, '>'); end > 0 {
+				left = strings.ReplaceAll(left[:end], " ", "") + left[end:]
+			}
+		}
 		parts := strings.Fields(left)
 		if len(parts) < 2 {
 			continue
 		}
 		fieldType := parts[0]
 		fieldName := parts[1]
+		num, _, _ := strings.Cut(line[eqIdx+1:], "[")
+		number, _ := strconv.Atoi(strings.TrimSpace(num))
 		comment := strings.Join(pending, "\n")
 		pending = nil
 		msg.Fields = append(msg.Fields, protoFieldDef{
 			Name:     fieldName,
 			TypeName: fieldType,
+			Number:   number,
 			Repeated: repeated,
 			Comment:  comment,
 		})
@@ -377,35 +749,46 @@ func (ps *protoSchema) addMessage(name, body string) {
 	ps.order = append(ps.order, name)
 }
 
-func (ps *protoSchema) sampleMessage(name string, seen map[string]int) any {
+// sampleMessage returns nil, rather than a null node, once a message has
+// been expanded too often on one path.
+func (ps *protoSchema) sampleMessage(b *docBuilder, name string, seen map[string]int) *docNode {
 	if seen[name] > 2 {
 		return nil
 	}
 	msg, ok := ps.messages[name]
 	if !ok {
-		return protoScalarValue(name)
+		return b.sample(protoScalarValue(name))
 	}
 	seen[name]++
-	obj := make(map[string]any)
+	obj := b.object()
 	for _, field := range msg.Fields {
-		val := ps.sampleField(field, seen)
-		obj[field.Name] = val
+		b.set(obj, field.Name, ps.sampleField(b, field, seen))
 	}
 	return obj
 }
 
-func (ps *protoSchema) sampleField(field protoFieldDef, seen map[string]int) any {
-	var value any
-	if ps.messages[field.TypeName] != nil {
-		value = ps.sampleMessage(field.TypeName, seen)
+func (ps *protoSchema) sampleField(b *docBuilder, field protoFieldDef, seen map[string]int) *docNode {
+	var value *docNode
+	if _, valueType, ok := protoMapTypes(field.TypeName); ok {
+		value = b.object()
+		b.set(value, "key", ps.sampleField(b, protoFieldDef{TypeName: valueType}, seen))
+	} else if ps.messages[field.TypeName] != nil {
+		value = ps.sampleMessage(b, field.TypeName, seen)
+	} else if values := ps.enums[field.TypeName]; len(values) > 0 {
+		value = b.str(values[0])
+	} else if field.TypeName == protoTimestamp {
+		value = b.str(time.Unix(0, 0).UTC().Format(time.RFC3339))
 	} else {
-		value = protoScalarValue(field.TypeName)
+		value = b.sample(protoScalarValue(field.TypeName))
 	}
 	if field.Repeated {
 		if value == nil {
-			return []any{}
+			return b.array()
 		}
-		return []any{value}
+		return b.array(value)
+	}
+	if value == nil {
+		return b.null()
 	}
 	return value
 }
@@ -445,6 +828,9 @@ func (ps *protoSchema) renderGoStruct(msg *protoMessage) string {
 			goName = "Field"
 		}
 		goType := protoTypeToGo(field.TypeName, field.Repeated)
+		if _, isEnum := ps.enums[field.TypeName]; isEnum {
+			goType = protoTypeToGo("string", field.Repeated)
+		}
 		jsonTag := fmt.Sprintf("`json:\"%s\"`", field.Name)
 		buf.WriteString(fmt.Sprintf("\t%s %s %s\n", goName, goType, jsonTag))
 	}
@@ -453,6 +839,9 @@ func (ps *protoSchema) renderGoStruct(msg *protoMessage) string {
 }
 
 func protoTypeToGo(typeName string, repeated bool) string {
+	if key, value, ok := protoMapTypes(typeName); ok {
+		return "map[" + protoTypeToGo(key, false) + "]" + protoTypeToGo(value, false)
+	}
 	var base string
 	switch typeName {
 	case "string", "bytes":
@@ -467,6 +856,8 @@ func protoTypeToGo(typeName string, repeated bool) string {
 		base = "int32"
 	case "int64", "uint64", "sint64", "fixed64":
 		base = "int64"
+	case protoTimestamp:
+		base = "time.Time"
 	default:
 		base = typeName
 	}
@@ -476,6 +867,15 @@ func protoTypeToGo(typeName string, repeated bool) string {
 	return base
 }
 
+// protoMapTypes splits a map<K,V> field type into K and V.
+func protoMapTypes(typeName string) (key, value string, ok bool) {
+	inner, found := strings.CutPrefix(typeName, "map<")
+	if !found || !strings.HasSuffix(inner, ">") {
+		return "", "", false
+	}
+	return strings.Cut(strings.TrimSuffix(inner, ">"), ",")
+}
+
 func protoFieldName(name string) string {
 	name = common.ExportName(name)
 	if name == "" {
@@ -490,3 +890,9 @@ func protoFieldName(name string) string {
 	}
 	return buf.String()
 }
+
+// enumValueName turns a value such as "in-progress" or "inProgress"
+// into IN_PROGRESS.
+func enumValueName(value string) string {
+	return strings.ToUpper(strings.Join(common.SplitWords(common.ExportName(value)), "_"))
+}
@@ -0,0 +1,274 @@
+package convert
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// protoMaxDepth bounds message nesting while decoding, as the official
+// decoders do.
+const protoMaxDepth = 100
+
+var errProtoTruncated = errors.New("protobuf: unexpected end of data")
+
+// Protobuf wire types.
+const (
+	protoVarint  = 0
+	protoFixed64 = 1
+	protoBytes   = 2
+	protoFixed32 = 5
+)
+
+// DecodeProtobuf decodes a message in the protobuf wire format using the
+// named message of a .proto schema, or its first message when message is
+// empty, and returns it as JSON keyed by field name. Enums decode to their
+// value names, bytes to base64 and Timestamps to RFC 3339. Fields the
+// schema does not list are kept under their field number, and fields left
+// at their default are absent, as they are on the wire.
+func DecodeProtobuf(schema, message string, data []byte) (string, error) {
+	ps := parseProtoSchema(schema)
+	if message == "" {
+		if len(ps.order) == 0 {
+			return "", errors.New("protobuf: schema has no message")
+		}
+		message = ps.order[0]
+	}
+	message = protoShortName(message)
+	if ps.messages[message] == nil {
+		return "", fmt.Errorf("protobuf: schema has no message %q", message)
+	}
+	v, err := ps.decodeFields(ps.messages[message].Fields, data, 0)
+	if err != nil {
+		return "", err
+	}
+	return encodeJSON(v)
+}
+
+// protoShortName drops the package and parent messages from a type name,
+// as parseProtoSchema keys messages by their own name.
+func protoShortName(name string) string {
+	return name[strings.LastIndex(name, ".")+1:]
+}
+
+// decodeFields decodes one message whose fields are defs.
+func (ps *protoSchema) decodeFields(defs []protoFieldDef, data []byte, depth int) (map[string]any, error) {
+	if depth > protoMaxDepth {
+		return nil, errors.New("protobuf: messages nested too deeply")
+	}
+	fields := make(map[int]protoFieldDef, len(defs))
+	for _, f := range defs {
+		fields[f.Number] = f
+	}
+	obj := map[string]any{}
+	for len(data) > 0 {
+		key, n := binary.Uvarint(data)
+		if n <= 0 {
+			return nil, errProtoTruncated
+		}
+		data = data[n:]
+		num, wire := int(key>>3), int(key&7)
+		if num == 0 {
+			return nil, errors.New("protobuf: field number 0")
+		}
+		var v uint64
+		var raw []byte
+		switch wire {
+		case protoVarint:
+			if v, n = binary.Uvarint(data); n <= 0 {
+				return nil, errProtoTruncated
+			}
+			data = data[n:]
+		case protoFixed64:
+			if len(data) < 8 {
+				return nil, errProtoTruncated
+			}
+			v, data = binary.LittleEndian.Uint64(data), data[8:]
+		case protoFixed32:
+			if len(data) < 4 {
+				return nil, errProtoTruncated
+			}
+			v, data = uint64(binary.LittleEndian.Uint32(data)), data[4:]
+		case protoBytes:
+			size, n := binary.Uvarint(data)
+			if n <= 0 || size > uint64(len(data)-n) {
+				return nil, errProtoTruncated
+			}
+			raw, data = data[n:n+int(size)], data[n+int(size):]
+		default:
+			return nil, fmt.Errorf("protobuf: unsupported wire type %d in field %d", wire, num)
+		}
+
+		field, known := fields[num]
+		if !known {
+			field = protoFieldDef{Name: strconv.Itoa(num)}
+		}
+		values, err := ps.decodeField(field, wire, v, raw, depth)
+		if err != nil {
+			return nil, err
+		}
+		if key, _, ok := protoMapTypes(field.TypeName); ok {
+			entries, _ := obj[field.Name].(map[string]any)
+			if entries == nil {
+				entries = map[string]any{}
+			}
+			entry, _ := values[0].(map[string]any)
+			k := entry["key"]
+			if k == nil {
+				k = protoZero(key)
+			}
+			entries[fmt.Sprint(k)] = entry["value"]
+			obj[field.Name] = entries
+			continue
+		}
+		switch prev, seen := obj[field.Name]; {
+		case field.Repeated:
+			list, _ := prev.([]any)
+			obj[field.Name] = append(list, values...)
+		case !known && seen:
+			// An unknown field seen twice is most likely repeated.
+			list, ok := prev.([]any)
+			if !ok {
+				list = []any{prev}
+			}
+			obj[field.Name] = append(list, values...)
+		default:
+			obj[field.Name] = values[len(values)-1]
+		}
+	}
+	return obj, nil
+}
+
+// decodeField returns the values of one field record: one, or several for
+// a packed repeated scalar.
+func (ps *protoSchema) decodeField(field protoFieldDef, wire int, v uint64, raw []byte, depth int) ([]any, error) {
+	typeName := field.TypeName
+	if key, value, ok := protoMapTypes(typeName); ok {
+		if wire != protoBytes {
+			return nil, fmt.Errorf("protobuf: field %q has wire type %d", field.Name, wire)
+		}
+		e, err := ps.decodeFields([]protoFieldDef{
+			{Name: "key", TypeName: key, Number: 1},
+			{Name: "value", TypeName: value, Number: 2},
+		}, raw, depth+1)
+		return []any{e}, err
+	}
+	short := protoShortName(typeName)
+	if wire == protoBytes {
+		switch {
+		case typeName == "" || typeName == "bytes":
+			return []any{base64.StdEncoding.EncodeToString(raw)}, nil
+		case typeName == "string":
+			return []any{string(raw)}, nil
+		case typeName == protoTimestamp:
+			return ps.decodeTimestamp(raw, depth)
+		case ps.messages[short] != nil:
+			m, err := ps.decodeFields(ps.messages[short].Fields, raw, depth+1)
+			return []any{m}, err
+		}
+		// A packed run of scalars, or a message the schema imports.
+		wire = protoWireType(typeName, ps.enumNames[short] != nil)
+		if wire == protoBytes {
+			return []any{base64.StdEncoding.EncodeToString(raw)}, nil
+		}
+		var values []any
+		for len(raw) > 0 {
+			switch wire {
+			case protoVarint:
+				x, n := binary.Uvarint(raw)
+				if n <= 0 {
+					return nil, errProtoTruncated
+				}
+				v, raw = x, raw[n:]
+			case protoFixed64:
+				if len(raw) < 8 {
+					return nil, errProtoTruncated
+				}
+				v, raw = binary.LittleEndian.Uint64(raw), raw[8:]
+			case protoFixed32:
+				if len(raw) < 4 {
+					return nil, errProtoTruncated
+				}
+				v, raw = uint64(binary.LittleEndian.Uint32(raw)), raw[4:]
+			}
+			values = append(values, ps.scalar(typeName, v))
+		}
+		return values, nil
+	}
+	if typeName != "" && protoWireType(typeName, ps.enumNames[short] != nil) != wire {
+		return nil, fmt.Errorf("protobuf: field %q of type %s has wire type %d", field.Name, typeName, wire)
+	}
+	return []any{ps.scalar(typeName, v)}, nil
+}
+
+func (ps *protoSchema) scalar(typeName string, v uint64) any {
+	switch typeName {
+	case "int32":
+		return int32(v)
+	case "int64", "sfixed64":
+		return int64(v)
+	case "sint32", "sint64":
+		return int64(v>>1) ^ -int64(v&1)
+	case "sfixed32":
+		return int32(uint32(v))
+	case "bool":
+		return v != 0
+	case "float":
+		return avroFloat(float64(math.Float32frombits(uint32(v))), 32)
+	case "double":
+		return avroFloat(math.Float64frombits(v), 64)
+	case "uint32", "uint64", "fixed32", "fixed64", "":
+		return v
+	}
+	if name, ok := ps.enumNames[protoShortName(typeName)][v]; ok {
+		return name
+	}
+	return int32(v)
+}
+
+func (ps *protoSchema) decodeTimestamp(raw []byte, depth int) ([]any, error) {
+	obj, err := ps.decodeFields([]protoFieldDef{
+		{Name: "seconds", TypeName: "int64", Number: 1},
+		{Name: "nanos", TypeName: "int32", Number: 2},
+	}, raw, depth+1)
+	if err != nil {
+		return nil, err
+	}
+	secs, _ := obj["seconds"].(int64)
+	nanos, _ := obj["nanos"].(int32)
+	return []any{time.Unix(secs, int64(nanos)).UTC().Format(time.RFC3339Nano)}, nil
+}
+
+// protoWireType returns the wire type a scalar or enum field is written
+// with.
+func protoWireType(typeName string, enum bool) int {
+	switch typeName {
+	case "double", "fixed64", "sfixed64":
+		return protoFixed64
+	case "float", "fixed32", "sfixed32":
+		return protoFixed32
+	case "int32", "int64", "uint32", "uint64", "sint32", "sint64", "bool":
+		return protoVarint
+	}
+	if enum {
+		return protoVarint
+	}
+	return protoBytes
+}
+
+// protoZero is the value of a map key left out of its entry.
+func protoZero(typeName string) any {
+	switch typeName {
+	case "string":
+		return ""
+	case "bool":
+		return false
+	}
+	return json.Number("0")
+}
@@ -0,0 +1,116 @@
+package convert
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const sampleProtoOrder = `syntax = "proto3";
+package shop;
+
+import "google/protobuf/timestamp.proto";
+
+message Order {
+  enum Status {
+    PENDING = 0;
+    SHIPPED = 2;
+  }
+  message Line {
+    string sku = 1;
+    sint32 qty = 2;
+  }
+  int64 id = 1;
+  string customer = 2;
+  repeated Line lines = 3;
+  Status status = 4;
+  repeated int32 codes = 5 [packed = true];
+  map<string, double> prices = 6;
+  google.protobuf.Timestamp created = 7;
+  optional bool gift = 8;
+  bytes note = 9;
+  float weight = 10;
+  fixed64 checksum = 11;
+}
+
+message Ping {}
+`
+
+func protoTag(b []byte, num, wire int) []byte {
+	return binary.AppendUvarint(b, uint64(num<<3|wire))
+}
+
+func protoLen(b []byte, num int, data []byte) []byte {
+	b = protoTag(b, num, protoBytes)
+	return append(binary.AppendUvarint(b, uint64(len(data))), data...)
+}
+
+func TestDecodeProtobuf(t *testing.T) {
+	line := func(sku string, qty int64) []byte {
+		l := protoLen(nil, 1, []byte(sku))
+		l = protoTag(l, 2, protoVarint)
+		return binary.AppendUvarint(l, uint64(qty<<1^qty>>63))
+	}
+	entry := protoLen(nil, 1, []byte("a"))
+	entry = protoTag(entry, 2, protoFixed64)
+	entry = binary.LittleEndian.AppendUint64(entry, math.Float64bits(9.5))
+	created := protoTag(nil, 1, protoVarint)
+	created = binary.AppendUvarint(created, 1700000000)
+	created = protoTag(created, 2, protoVarint)
+	created = binary.AppendUvarint(created, 5000000)
+
+	var b []byte
+	b = protoTag(b, 1, protoVarint)
+	b = binary.AppendUvarint(b, 1001)
+	b = protoLen(b, 2, []byte("Ricky"))
+	b = protoLen(b, 3, line("x", 2))
+	b = protoLen(b, 3, line("y", -1))
+	b = protoTag(b, 4, protoVarint)
+	b = binary.AppendUvarint(b, 2)
+	b = protoLen(b, 5, []byte{1, 2, 0x96, 0x01})
+	b = protoTag(b, 5, protoVarint)
+	b = binary.AppendUvarint(b, 3)
+	b = protoLen(b, 6, entry)
+	b = protoLen(b, 7, created)
+	b = protoTag(b, 8, protoVarint)
+	b = binary.AppendUvarint(b, 1)
+	b = protoLen(b, 9, []byte("hi"))
+	b = protoTag(b, 10, protoFixed32)
+	b = binary.LittleEndian.AppendUint32(b, math.Float32bits(1.25))
+	b = protoTag(b, 11, protoFixed64)
+	b = binary.LittleEndian.AppendUint64(b, math.MaxUint64)
+	b = protoTag(b, 99, protoVarint)
+	b = binary.AppendUvarint(b, 7)
+	b = protoTag(b, 99, protoVarint)
+	b = binary.AppendUvarint(b, 8)
+
+	out, err := DecodeProtobuf(sampleProtoOrder, "", b)
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"id": 1001, "customer": "Ricky",
+		"lines": [{"sku": "x", "qty": 2}, {"sku": "y", "qty": -1}],
+		"status": "SHIPPED", "codes": [1, 2, 150, 3], "prices": {"a": 9.5},
+		"created": "2023-11-14T22:13:20.005Z", "gift": true, "note": "aGk=",
+		"weight": 1.25, "checksum": 18446744073709551615, "99": [7, 8]
+	}`, out)
+
+	out, err = DecodeProtobuf(sampleProtoOrder, "shop.Order.Line", line("z", 3))
+	require.NoError(t, err)
+	require.JSONEq(t, `{"sku": "z", "qty": 3}`, out)
+
+	_, err = DecodeProtobuf(sampleProtoOrder, "Missing", nil)
+	require.EqualError(t, err, `protobuf: schema has no message "Missing"`)
+	_, err = DecodeProtobuf(sampleProtoOrder, "", protoLen(nil, 2, []byte("Ricky"))[:4])
+	require.ErrorIs(t, err, errProtoTruncated)
+	_, err = DecodeProtobuf(sampleProtoOrder, "", binary.AppendUvarint(protoTag(nil, 2, protoVarint), 1))
+	require.EqualError(t, err, `protobuf: field "customer" of type string has wire type 0`)
+}
+
+func TestProtoToGoStruct_Maps(t *testing.T) {
+	out, err := ProtoToGoStruct(sampleProtoOrder)
+	require.NoError(t, err)
+	require.Contains(t, out, "Prices map[string]float64 `json:\"prices\"`")
+	require.Contains(t, out, "Gift bool `json:\"gift\"`")
+}
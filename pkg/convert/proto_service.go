@@ -0,0 +1,104 @@
+package convert
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	protoEmpty             = "google.protobuf.Empty"
+	protoEmptyImport       = `import "google/protobuf/empty.proto";`
+	protoAnnotationsImport = `import "google/api/annotations.proto";`
+)
+
+// protoRPC is one generated method: its name, request and response types
+// and, for HTTP annotations, the verb, path and body field.
+type protoRPC struct {
+	name, request, response string
+	verb, path, body        string
+}
+
+// services builds a <Name>Service with Get/List/Create/Update/Delete RPCs
+// for every root message, together with the request and response messages
+// they use. A generated message whose name is already taken is skipped.
+func (b *protoBuilder) services(roots []*protoMessageDef) ([]*protoMessageDef, []string) {
+	taken := map[string]bool{}
+	for _, msg := range roots {
+		taken[msg.name] = true
+	}
+	var messages []*protoMessageDef
+	define := func(name string, fields ...protoFieldLine) {
+		if !taken[name] {
+			taken[name] = true
+			messages = append(messages, &protoMessageDef{name: name, fields: fields})
+		}
+	}
+	var services []string
+	for _, msg := range roots {
+		name := msg.name
+		plural := protoPlural(name)
+		field := protoFieldName(name)
+		path := "/v1/" + protoFieldName(plural)
+		id := protoFieldLine{typeName: "string", name: "id"}
+		resource := protoFieldLine{typeName: name, name: field}
+
+		define("Get"+name+"Request", id)
+		define("List"+plural+"Request",
+			protoFieldLine{typeName: "int32", name: "page_size"},
+			protoFieldLine{typeName: "string", name: "page_token"},
+		)
+		define("List"+plural+"Response",
+			protoFieldLine{repeated: true, typeName: name, name: protoFieldName(plural)},
+			protoFieldLine{typeName: "string", name: "next_page_token"},
+		)
+		define("Create"+name+"Request", resource)
+		define("Update"+name+"Request", id, resource)
+		define("Delete"+name+"Request", id)
+
+		rpcs := []protoRPC{
+			{"Get" + name, "Get" + name + "Request", name, "get", path + "/{id}", ""},
+			{"List" + plural, "List" + plural + "Request", "List" + plural + "Response", "get", path, ""},
+			{"Create" + name, "Create" + name + "Request", name, "post", path, field},
+			{"Update" + name, "Update" + name + "Request", name, "patch", path + "/{id}", field},
+			{"Delete" + name, "Delete" + name + "Request", b.empty(), "delete", path + "/{id}", ""},
+		}
+		services = append(services, b.renderService(name+"Service", rpcs))
+	}
+	return messages, services
+}
+
+func (b *protoBuilder) renderService(name string, rpcs []protoRPC) string {
+	lines := []string{fmt.Sprintf("service %s {", name)}
+	for _, rpc := range rpcs {
+		sig := fmt.Sprintf("  rpc %s(%s) returns (%s)", rpc.name, rpc.request, rpc.response)
+		if !b.opts.ProtoHTTP {
+			lines = append(lines, sig+";")
+			continue
+		}
+		lines = append(lines, sig+" {", "    option (google.api.http) = {",
+			fmt.Sprintf("      %s: %q", rpc.verb, rpc.path))
+		if rpc.body != "" {
+			lines = append(lines, fmt.Sprintf("      body: %q", rpc.body))
+		}
+		lines = append(lines, "    };", "  }")
+	}
+	return strings.Join(append(lines, "}"), "\n")
+}
+
+func (b *protoBuilder) empty() string {
+	b.imports[protoEmptyImport] = true
+	return protoEmpty
+}
+
+// protoPlural is the English plural used for List RPCs and HTTP paths.
+func protoPlural(name string) string {
+	lower := strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(lower, "s"), strings.HasSuffix(lower, "x"), strings.HasSuffix(lower, "z"),
+		strings.HasSuffix(lower, "ch"), strings.HasSuffix(lower, "sh"):
+		return name + "es"
+	case len(lower) > 1 && strings.HasSuffix(lower, "y") && !strings.ContainsRune("aeiou", rune(lower[len(lower)-2])):
+		return name[:len(name)-1] + "ies"
+	}
+	return name + "s"
+}
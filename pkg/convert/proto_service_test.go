@@ -0,0 +1,102 @@
+package convert
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONToProto_Service(t *testing.T) {
+	out, err := JSONToProtoWithOptions(`{"name":"x"}`, WithProtoService(true))
+	require.NoError(t, err)
+	require.Equal(t, `import "google/protobuf/empty.proto";
+
+message AutoGenerated {
+  string name = 1;
+}
+
+message GetAutoGeneratedRequest {
+  string id = 1;
+}
+
+message ListAutoGeneratedsRequest {
+  int32 page_size = 1;
+  string page_token = 2;
+}
+
+message ListAutoGeneratedsResponse {
+  repeated AutoGenerated auto_generateds = 1;
+  string next_page_token = 2;
+}
+
+message CreateAutoGeneratedRequest {
+  AutoGenerated auto_generated = 1;
+}
+
+message UpdateAutoGeneratedRequest {
+  string id = 1;
+  AutoGenerated auto_generated = 2;
+}
+
+message DeleteAutoGeneratedRequest {
+  string id = 1;
+}
+
+service AutoGeneratedService {
+  rpc GetAutoGenerated(GetAutoGeneratedRequest) returns (AutoGenerated);
+  rpc ListAutoGenerateds(ListAutoGeneratedsRequest) returns (ListAutoGeneratedsResponse);
+  rpc CreateAutoGenerated(CreateAutoGeneratedRequest) returns (AutoGenerated);
+  rpc UpdateAutoGenerated(UpdateAutoGeneratedRequest) returns (AutoGenerated);
+  rpc DeleteAutoGenerated(DeleteAutoGeneratedRequest) returns (google.protobuf.Empty);
+}`, out)
+
+	// The root message still comes first, where ProtoToJSON looks for it.
+	sample, err := ProtoToJSON(out)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"name":""}`, sample)
+}
+
+func TestGoStructToProto_HTTP(t *testing.T) {
+	src := "type Category struct {\n\tName string `json:\"name\"`\n}\n\ntype Box struct {\n\tID int `json:\"id\"`\n}"
+	out, err := ConvertFormatsWithOptions(formatGoStruct, formatProtobuf, src, WithProtoHTTP(true))
+	require.NoError(t, err)
+	require.Contains(t, out, `import "google/api/annotations.proto";
+import "google/protobuf/empty.proto";
+
+message Category {`)
+	require.Contains(t, out, `service CategoryService {
+  rpc GetCategory(GetCategoryRequest) returns (Category) {
+    option (google.api.http) = {
+      get: "/v1/categories/{id}"
+    };
+  }
+  rpc ListCategories(ListCategoriesRequest) returns (ListCategoriesResponse) {
+    option (google.api.http) = {
+      get: "/v1/categories"
+    };
+  }
+  rpc CreateCategory(CreateCategoryRequest) returns (Category) {
+    option (google.api.http) = {
+      post: "/v1/categories"
+      body: "category"
+    };
+  }
+  rpc UpdateCategory(UpdateCategoryRequest) returns (Category) {
+    option (google.api.http) = {
+      patch: "/v1/categories/{id}"
+      body: "category"
+    };
+  }
+  rpc DeleteCategory(DeleteCategoryRequest) returns (google.protobuf.Empty) {
+    option (google.api.http) = {
+      delete: "/v1/categories/{id}"
+    };
+  }
+}`)
+	require.Contains(t, out, "service BoxService {\n  rpc GetBox(GetBoxRequest)")
+	require.Contains(t, out, "message ListBoxesResponse {\n  repeated Box boxes = 1;")
+
+	plain, err := GoStructToProto(src)
+	require.NoError(t, err)
+	require.NotContains(t, plain, "service")
+}
@@ -13,6 +13,70 @@ func Test_JSONToProto(t *testing.T) {
 	require.Contains(t, out, "message AutoGenerated")
 }
 
+func Test_JSONToProto_IntegerWidth(t *testing.T) {
+	out, err := JSONToProto(`{"a":1,"b":4294967296,"c":12345678901234567890,"d":-9223372036854775809,"e":1.5}`)
+	require.NoError(t, err)
+	require.Contains(t, out, "int32 a = 1;")
+	require.Contains(t, out, "int64 b = 2;")
+	require.Contains(t, out, "uint64 c = 3;")
+	require.Contains(t, out, "double d = 4;")
+	require.Contains(t, out, "double e = 5;")
+}
+
+func Test_JSONToProto_NestedAndTimestamp(t *testing.T) {
+	out, err := JSONToProto(`{"id":1,"created_at":"2024-01-02T03:04:05Z","address":{"city":"x"},"tags":[{"name":"a"}]}`)
+	require.NoError(t, err)
+	require.Equal(t, `import "google/protobuf/timestamp.proto";
+
+message AutoGenerated {
+  message Address {
+    string city = 1;
+  }
+
+  message TagsItem {
+    string name = 1;
+  }
+
+  int32 id = 1;
+  google.protobuf.Timestamp created_at = 2;
+  Address address = 3;
+  repeated TagsItem tags = 4;
+}`, out)
+}
+
+func Test_JSONToProto_Schema(t *testing.T) {
+	out, err := JSONToProto(`{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"title": "order",
+		"type": "object",
+		"properties": {
+			"status": {"type": "string", "enum": ["pending", "in-progress"]},
+			"payment": {"oneOf": [
+				{"title": "card", "type": "object", "properties": {"number": {"type": "string"}}},
+				{"title": "iban", "type": "string"}
+			]}
+		}
+	}`)
+	require.NoError(t, err)
+	require.Equal(t, `message Order {
+  enum Status {
+    STATUS_UNSPECIFIED = 0;
+    STATUS_PENDING = 1;
+    STATUS_IN_PROGRESS = 2;
+  }
+
+  message PaymentCard {
+    string number = 1;
+  }
+
+  Status status = 1;
+  oneof payment {
+    PaymentCard payment_card = 2;
+    string payment_iban = 3;
+  }
+}`, out)
+}
+
 func Benchmark_JSONToProto(b *testing.B) {
 	b.ReportAllocs()
 	b.ResetTimer()
@@ -75,6 +139,50 @@ type GuessSetting struct {
 		strings.Index(out, "user_ids = 2"))
 }
 
+func Test_GoStructToProto_EnumsOneofNested(t *testing.T) {
+	src := "type User struct {\n" +
+		"\tRole string `json:\"role\" validate:\"required,oneof=admin viewer\"`\n" +
+		"\tCreated time.Time `json:\"created\"`\n" +
+		"\tEmail string `json:\"email\" oneof:\"contact\"`\n" +
+		"\tPhone string `json:\"phone\" oneof:\"contact\"`\n" +
+		"\tAddress struct {\n\t\tCity string `json:\"city\"`\n\t} `json:\"address\"`\n" +
+		"}"
+	out, err := GoStructToProto(src)
+	require.NoError(t, err)
+	require.Equal(t, `import "google/protobuf/timestamp.proto";
+
+message User {
+  enum Role {
+    ROLE_UNSPECIFIED = 0;
+    ROLE_ADMIN = 1;
+    ROLE_VIEWER = 2;
+  }
+
+  message Address {
+    string city = 1;
+  }
+
+  Role role = 1;
+  google.protobuf.Timestamp created = 2;
+  oneof contact {
+    string email = 3;
+    string phone = 4;
+  }
+  Address address = 5;
+}`, out)
+
+	sample, err := ProtoToJSON(out)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"role":"ROLE_UNSPECIFIED","created":"1970-01-01T00:00:00Z","email":"","phone":"","address":{"city":""}}`, sample)
+
+	structs, err := ProtoToGoStruct(out)
+	require.NoError(t, err)
+	require.Contains(t, structs, "Role string `json:\"role\"`")
+	require.Contains(t, structs, "Created time.Time")
+	require.Contains(t, structs, "type Address struct")
+	require.NotContains(t, structs, "City string `json:\"city\"`\n\tRole")
+}
+
 func Benchmark_GoStructToProto(b *testing.B) {
 	b.ReportAllocs()
 	b.ResetTimer()
@@ -0,0 +1,81 @@
+package convert
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/linzeyan/transform-go/pkg/common"
+)
+
+// Version is written into provenance headers; release builds set it with
+// -ldflags "-X github.com/linzeyan/transform-go/pkg/convert.Version=v1.2.3".
+var Version = "dev"
+
+const provenanceMarker = "Code generated by transform-go; DO NOT EDIT."
+
+var provenanceLineRe = common.LazyRegexp(`^\s*(?:#|//|--|<!--)\s*(?:` + regexp.QuoteMeta(provenanceMarker) + `|Provenance: source=.*?)\s*(?:-->)?\s*$`)
+
+// provenanceComments maps output formats that allow comments to the line
+// prefix and suffix of a comment.
+var provenanceComments = map[string][2]string{
+	formatYAML:       {"# ", ""},
+	formatTOML:       {"# ", ""},
+	formatGraphQL:    {"# ", ""},
+	formatHCL:        {"# ", ""},
+	formatProperties: {"# ", ""},
+	formatDotenv:     {"# ", ""},
+	formatGoStruct:   {"// ", ""},
+	formatProtobuf:   {"// ", ""},
+	formatSQL:        {"-- ", ""},
+	formatXML:        {"<!-- ", " -->"},
+	formatPlist:      {"<!-- ", " -->"},
+	formatPropsXML:   {"<!-- ", " -->"},
+	formatXSD:        {"<!-- ", " -->"},
+}
+
+// addProvenance puts a header naming the source format, tool version,
+// time and options hash in front of out, after any XML declaration.
+// Formats without comments are returned unchanged.
+func (o ConvertOptions) addProvenance(from, to, out string, now time.Time) string {
+	style, ok := provenanceComments[to]
+	if !o.Provenance || !ok {
+		return out
+	}
+	header := style[0] + provenanceMarker + style[1] + "\n" +
+		fmt.Sprintf("%sProvenance: source=%q version=%s generated=%s options=%s%s\n",
+			style[0], from, Version, now.UTC().Format(time.RFC3339), o.hash(), style[1])
+	if strings.HasPrefix(out, "<?xml") {
+		if end := strings.Index(out, "?>"); end >= 0 {
+			decl, rest := out[:end+2], strings.TrimPrefix(out[end+2:], "\n")
+			return decl + "\n" + header + rest
+		}
+	}
+	return header + out
+}
+
+// hash fingerprints the settings that shape output, so two files made with
+// the same options carry the same value.
+func (o ConvertOptions) hash() string {
+	o.Provenance = false
+	data, _ := json.Marshal(o)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:6])
+}
+
+// StripProvenance removes the header the Provenance option adds, in any
+// of its comment styles; other comments are kept.
+func StripProvenance(input string) string {
+	lines := strings.SplitAfter(input, "\n")
+	out := make([]string, 0, len(lines))
+	for _, line := range lines {
+		if !provenanceLineRe().MatchString(strings.TrimRight(line, "\r\n")) {
+			out = append(out, line)
+		}
+	}
+	return strings.Join(out, "")
+}
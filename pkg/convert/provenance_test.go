@@ -0,0 +1,31 @@
+package convert
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestProvenance(t *testing.T) {
+	out, err := ConvertFormatsWithOptions(formatJSON, formatYAML, `{"a":1}`, WithProvenance(true))
+	require.NoError(t, err)
+	require.Regexp(t, `^# Code generated by transform-go; DO NOT EDIT\.\n# Provenance: source="JSON" version=dev generated=\S+Z options=[0-9a-f]{12}\na: 1$`, out)
+	require.Equal(t, "a: 1", StripProvenance(out))
+
+	out, err = ConvertFormatsWithOptions(formatJSON, formatCSV, `[{"a":1}]`, WithProvenance(true))
+	require.NoError(t, err)
+	require.NotContains(t, out, "transform-go")
+
+	now := time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)
+	o := NewConvertOptions(WithProvenance(true))
+	xml := o.addProvenance(formatGoStruct, formatXML, "<?xml version=\"1.0\"?>\n<root/>", now)
+	require.Equal(t, "<?xml version=\"1.0\"?>\n<!-- Code generated by transform-go; DO NOT EDIT. -->\n<!-- Provenance: source=\"Go Struct\" version=dev generated=2024-05-01T08:00:00Z options="+o.hash()+" -->\n<root/>", xml)
+	require.Equal(t, "<?xml version=\"1.0\"?>\n<root/>", StripProvenance(xml))
+
+	require.Equal(t, o.hash(), NewConvertOptions().hash())
+	require.NotEqual(t, o.hash(), NewConvertOptions(WithIndent(4)).hash())
+
+	kept := "// regular comment\npackage main\n"
+	require.Equal(t, kept, StripProvenance(kept))
+}
@@ -0,0 +1,879 @@
+package convert
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// QueryJSON selects values from a document, JSON or any format
+// ConvertFormats reads, and returns them as an indented JSON array in
+// document order. Numbers come out as written, and so does the key order
+// of JSON input.
+//
+// The expression is JSONPath (RFC 9535): $ is the document, .name and
+// ['name'] select a member, [0] and [-1] an element, [1:5:2] a slice, *
+// every child, .. every descendant, [a, b] several at once and [?expr]
+// the children a filter admits. Filters compare @ (the child), $ and
+// literals with == != < <= > >=, combine with && || ! and parentheses, and
+// call length(), count(), value(), match() and search(); a bare query such
+// as [?@.isbn] tests that it selects something. A jq-style path is read
+// as well: .users[].name is $.users[*].name.
+func QueryJSON(input, expression string) (string, error) {
+	query, err := compileQuery(expression)
+	if err != nil {
+		return "", err
+	}
+	_, doc, err := decodeDocumentNode(input)
+	if err != nil {
+		return "", err
+	}
+	var arena docArena
+	out := arena.node(docArray, docPos{})
+	out.items = query.eval(doc, doc)
+	return docToJSON(out, "  ")
+}
+
+// jsonQuery is a compiled JSONPath query: segments applied in turn to the
+// nodes the previous ones selected.
+type jsonQuery struct {
+	relative bool // starts at @ rather than $
+	segments []querySegment
+}
+
+type querySegment struct {
+	descendant bool
+	selectors  []querySelector
+}
+
+type selectorKind uint8
+
+const (
+	selectName selectorKind = iota
+	selectWildcard
+	selectIndex
+	selectSlice
+	selectFilter
+)
+
+type querySelector struct {
+	kind   selectorKind
+	name   string
+	index  int
+	slice  [3]*int // start, end, step
+	filter filterExpr
+}
+
+func (q *jsonQuery) eval(node, root *docNode) []*docNode {
+	nodes := []*docNode{node}
+	for _, seg := range q.segments {
+		var next []*docNode
+		for _, n := range nodes {
+			if seg.descendant {
+				n.walk(func(d *docNode) { next = seg.apply(next, d, root) })
+			} else {
+				next = seg.apply(next, n, root)
+			}
+		}
+		nodes = next
+	}
+	return nodes
+}
+
+// singular reports whether q selects at most one node, as comparisons
+// need.
+func (q *jsonQuery) singular() bool {
+	for _, seg := range q.segments {
+		if seg.descendant || len(seg.selectors) != 1 {
+			return false
+		}
+		if k := seg.selectors[0].kind; k != selectName && k != selectIndex {
+			return false
+		}
+	}
+	return true
+}
+
+// walk calls fn for n and each of its descendants, parents first.
+func (n *docNode) walk(fn func(*docNode)) {
+	fn(n)
+	for _, item := range n.items {
+		item.walk(fn)
+	}
+}
+
+func (seg querySegment) apply(out []*docNode, n, root *docNode) []*docNode {
+	for _, sel := range seg.selectors {
+		out = sel.apply(out, n, root)
+	}
+	return out
+}
+
+func (sel querySelector) apply(out []*docNode, n, root *docNode) []*docNode {
+	switch sel.kind {
+	case selectName:
+		if n.kind == docObject {
+			for i, k := range n.keys {
+				if k.name == sel.name {
+					out = append(out, n.items[i])
+				}
+			}
+		}
+	case selectWildcard:
+		if n.kind == docArray || n.kind == docObject {
+			out = append(out, n.items...)
+		}
+	case selectIndex:
+		if n.kind == docArray {
+			i := sel.index
+			if i < 0 {
+				i += len(n.items)
+			}
+			if i >= 0 && i < len(n.items) {
+				out = append(out, n.items[i])
+			}
+		}
+	case selectSlice:
+		if n.kind == docArray {
+			start, end, step := sliceBounds(sel.slice, len(n.items))
+			for i := start; step > 0 && i < end || step < 0 && i > end; i += step {
+				out = append(out, n.items[i])
+			}
+		}
+	case selectFilter:
+		if n.kind == docArray || n.kind == docObject {
+			for _, item := range n.items {
+				if sel.filter.test(item, root) {
+					out = append(out, item)
+				}
+			}
+		}
+	}
+	return out
+}
+
+// sliceBounds normalizes a slice as RFC 9535 does: negative positions
+// count from the end and a negative step walks backwards; a zero step
+// selects nothing.
+func sliceBounds(slice [3]*int, n int) (start, end, step int) {
+	step = 1
+	if slice[2] != nil {
+		step = *slice[2]
+	}
+	if step == 0 {
+		return 0, 0, 0
+	}
+	normal := func(i int) int {
+		if i < 0 {
+			return i + n
+		}
+		return i
+	}
+	if step > 0 {
+		start, end = 0, n
+		if slice[0] != nil {
+			start = min(max(normal(*slice[0]), 0), n)
+		}
+		if slice[1] != nil {
+			end = min(max(normal(*slice[1]), 0), n)
+		}
+		return start, end, step
+	}
+	start, end = n-1, -1
+	if slice[0] != nil {
+		start = min(max(normal(*slice[0]), -1), n-1)
+	}
+	if slice[1] != nil {
+		end = min(max(normal(*slice[1]), -1), n-1)
+	}
+	return start, end, step
+}
+
+// compileQuery parses a JSONPath expression, or a jq-style path starting
+// with a dot.
+func compileQuery(expression string) (*jsonQuery, error) {
+	src := strings.TrimSpace(expression)
+	if src == "" {
+		return nil, errors.New("query is empty")
+	}
+	p := &queryParser{src: src}
+	if src[0] == '.' {
+		// jq: "." is the document, ".a" its member and ".[0]" its element.
+		p.jq = true
+		if src == "." {
+			return &jsonQuery{}, nil
+		}
+		p.src, p.shift = "$"+src, 1
+		if src[1] == '[' {
+			p.src, p.shift = "$"+src[1:], 0
+		}
+	}
+	if p.peek() != '$' {
+		return nil, p.errorf("query must start with $")
+	}
+	q, err := p.query()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if p.pos < len(p.src) {
+		return nil, p.errorf("unexpected %q", p.src[p.pos:])
+	}
+	return q, nil
+}
+
+type queryParser struct {
+	src   string
+	pos   int
+	jq    bool
+	shift int // bytes added to a jq path, left out of error offsets
+}
+
+func (p *queryParser) errorf(format string, args ...any) error {
+	return fmt.Errorf("invalid query at offset %d: %s", max(p.pos-p.shift, 0), fmt.Sprintf(format, args...))
+}
+
+func (p *queryParser) peek() byte {
+	if p.pos < len(p.src) {
+		return p.src[p.pos]
+	}
+	return 0
+}
+
+func (p *queryParser) skipSpace() {
+	for p.pos < len(p.src) && strings.IndexByte(" \t\r\n", p.src[p.pos]) >= 0 {
+		p.pos++
+	}
+}
+
+// query reads $ or @ and the segments after it.
+func (p *queryParser) query() (*jsonQuery, error) {
+	q := &jsonQuery{relative: p.peek() == '@'}
+	p.pos++
+	for {
+		var seg querySegment
+		switch {
+		case strings.HasPrefix(p.src[p.pos:], ".."):
+			p.pos += 2
+			seg.descendant = true
+			if p.peek() != '[' {
+				sel, err := p.shorthand()
+				if err != nil {
+					return nil, err
+				}
+				seg.selectors = []querySelector{sel}
+				break
+			}
+			sels, err := p.bracket()
+			if err != nil {
+				return nil, err
+			}
+			seg.selectors = sels
+		case p.peek() == '.':
+			p.pos++
+			sel, err := p.shorthand()
+			if err != nil {
+				return nil, err
+			}
+			seg.selectors = []querySelector{sel}
+		case p.peek() == '[':
+			sels, err := p.bracket()
+			if err != nil {
+				return nil, err
+			}
+			seg.selectors = sels
+		default:
+			return q, nil
+		}
+		q.segments = append(q.segments, seg)
+	}
+}
+
+// shorthand reads the * or member name after a dot.
+func (p *queryParser) shorthand() (querySelector, error) {
+	if p.peek() == '*' {
+		p.pos++
+		return querySelector{kind: selectWildcard}, nil
+	}
+	start := p.pos
+	for p.pos < len(p.src) {
+		r, size := utf8.DecodeRuneInString(p.src[p.pos:])
+		if !(r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= 0x80 || p.pos > start && r >= '0' && r <= '9') {
+			break
+		}
+		p.pos += size
+	}
+	if p.pos == start {
+		return querySelector{}, p.errorf("expected a member name")
+	}
+	return querySelector{kind: selectName, name: p.src[start:p.pos]}, nil
+}
+
+// bracket reads [selector, ...]; jq's [] is [*].
+func (p *queryParser) bracket() ([]querySelector, error) {
+	p.pos++
+	p.skipSpace()
+	if p.jq && p.peek() == ']' {
+		p.pos++
+		return []querySelector{{kind: selectWildcard}}, nil
+	}
+	var sels []querySelector
+	for {
+		p.skipSpace()
+		sel, err := p.selector()
+		if err != nil {
+			return nil, err
+		}
+		sels = append(sels, sel)
+		p.skipSpace()
+		switch p.peek() {
+		case ',':
+			p.pos++
+		case ']':
+			p.pos++
+			return sels, nil
+		default:
+			return nil, p.errorf("expected , or ]")
+		}
+	}
+}
+
+func (p *queryParser) selector() (querySelector, error) {
+	switch c := p.peek(); {
+	case c == '\'' || c == '"':
+		name, err := p.stringLiteral()
+		return querySelector{kind: selectName, name: name}, err
+	case c == '*':
+		p.pos++
+		return querySelector{kind: selectWildcard}, nil
+	case c == '?':
+		p.pos++
+		f, err := p.filterOr()
+		return querySelector{kind: selectFilter, filter: f}, err
+	}
+	var sel querySelector
+	for part := 0; ; part++ {
+		p.skipSpace()
+		if n, ok := p.integer(); ok {
+			sel.slice[part] = &n
+		}
+		p.skipSpace()
+		if p.peek() != ':' || part == 2 {
+			break
+		}
+		p.pos++
+		sel.kind = selectSlice
+	}
+	if sel.kind == selectSlice {
+		return sel, nil
+	}
+	if sel.slice[0] == nil {
+		return sel, p.errorf("expected a selector")
+	}
+	return querySelector{kind: selectIndex, index: *sel.slice[0]}, nil
+}
+
+func (p *queryParser) integer() (int, bool) {
+	start := p.pos
+	if p.peek() == '-' {
+		p.pos++
+	}
+	for p.peek() >= '0' && p.peek() <= '9' {
+		p.pos++
+	}
+	n, err := strconv.Atoi(p.src[start:p.pos])
+	if err != nil {
+		p.pos = start
+		return 0, false
+	}
+	return n, true
+}
+
+// stringLiteral reads a 'single' or "double" quoted string with JSON-style
+// escapes.
+func (p *queryParser) stringLiteral() (string, error) {
+	q := p.src[p.pos]
+	var b strings.Builder
+	for i := p.pos + 1; i < len(p.src); i++ {
+		switch c := p.src[i]; c {
+		case q:
+			p.pos = i + 1
+			return b.String(), nil
+		case '\\':
+			if i+1 == len(p.src) {
+				break
+			}
+			i++
+			switch e := p.src[i]; e {
+			case 'b':
+				b.WriteByte('\b')
+			case 'f':
+				b.WriteByte('\f')
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			case 't':
+				b.WriteByte('\t')
+			case 'u':
+				if i+5 > len(p.src) {
+					p.pos = i
+					return "", p.errorf("bad \\u escape")
+				}
+				r, err := strconv.ParseUint(p.src[i+1:i+5], 16, 32)
+				if err != nil {
+					p.pos = i
+					return "", p.errorf("bad \\u escape")
+				}
+				b.WriteRune(rune(r))
+				i += 4
+			default:
+				b.WriteByte(e)
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", p.errorf("string not closed")
+}
+
+// filterExpr is a filter's logical expression; filterValue the operands
+// it compares, which come out as one node or none.
+type filterExpr interface {
+	test(node, root *docNode) bool
+}
+
+type filterValue interface {
+	value(node, root *docNode) (*docNode, bool)
+}
+
+type (
+	filterOr    []filterExpr
+	filterAnd   []filterExpr
+	filterNot   struct{ expr filterExpr }
+	filterExist struct{ query *jsonQuery }
+	filterTest  struct{ call *filterCall } // a function returning a logical value
+	filterCmp   struct {
+		op          string
+		left, right filterValue
+	}
+	filterLiteral struct{ node *docNode }
+	filterPath    struct{ query *jsonQuery }
+	filterCall    struct {
+		name string
+		args []any // filterValue or *jsonQuery for count() and value()
+		re   *regexp.Regexp
+	}
+)
+
+func (f filterOr) test(node, root *docNode) bool {
+	for _, e := range f {
+		if e.test(node, root) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f filterAnd) test(node, root *docNode) bool {
+	for _, e := range f {
+		if !e.test(node, root) {
+			return false
+		}
+	}
+	return true
+}
+
+func (f filterNot) test(node, root *docNode) bool { return !f.expr.test(node, root) }
+
+func (f filterExist) test(node, root *docNode) bool {
+	return len(f.query.evalFrom(node, root)) > 0
+}
+
+func (f filterTest) test(node, root *docNode) bool {
+	v, ok := f.call.value(node, root)
+	return ok && v.kind == docBool && v.text == "true"
+}
+
+func (f filterCmp) test(node, root *docNode) bool {
+	l, lok := f.left.value(node, root)
+	r, rok := f.right.value(node, root)
+	switch f.op {
+	case "==":
+		return lok == rok && (!lok || docEqual(l, r))
+	case "!=":
+		return lok != rok || lok && !docEqual(l, r)
+	}
+	if !lok || !rok {
+		return false
+	}
+	if f.op == "<=" || f.op == ">=" {
+		if docEqual(l, r) {
+			return true
+		}
+	}
+	if f.op == ">" || f.op == ">=" {
+		l, r = r, l
+	}
+	return docLess(l, r)
+}
+
+func (f filterLiteral) value(*docNode, *docNode) (*docNode, bool) { return f.node, true }
+
+func (f filterPath) value(node, root *docNode) (*docNode, bool) {
+	nodes := f.query.evalFrom(node, root)
+	if len(nodes) != 1 {
+		return nil, false
+	}
+	return nodes[0], true
+}
+
+func (q *jsonQuery) evalFrom(node, root *docNode) []*docNode {
+	if q.relative {
+		return q.eval(node, root)
+	}
+	return q.eval(root, root)
+}
+
+func (f *filterCall) value(node, root *docNode) (*docNode, bool) {
+	var arena docArena
+	number := func(n int) *docNode {
+		out := arena.node(docNumber, docPos{})
+		out.text = strconv.Itoa(n)
+		return out
+	}
+	switch f.name {
+	case "count":
+		return number(len(f.args[0].(*jsonQuery).evalFrom(node, root))), true
+	case "value":
+		nodes := f.args[0].(*jsonQuery).evalFrom(node, root)
+		if len(nodes) != 1 {
+			return nil, false
+		}
+		return nodes[0], true
+	case "length":
+		v, ok := f.args[0].(filterValue).value(node, root)
+		if !ok {
+			return nil, false
+		}
+		switch v.kind {
+		case docString:
+			return number(utf8.RuneCountInString(v.text)), true
+		case docArray, docObject:
+			return number(len(v.items)), true
+		}
+		return nil, false
+	}
+	// match and search
+	s, ok := f.args[0].(filterValue).value(node, root)
+	result := arena.node(docBool, docPos{})
+	result.text = "false"
+	if !ok || s.kind != docString {
+		return result, true
+	}
+	re := f.re
+	if re == nil {
+		pattern, ok := f.args[1].(filterValue).value(node, root)
+		if !ok || pattern.kind != docString {
+			return result, true
+		}
+		expr := pattern.text
+		if f.name == "match" {
+			expr = `\A(?:` + expr + `)\z`
+		}
+		if re, _ = regexp.Compile(expr); re == nil {
+			return result, true
+		}
+	}
+	if re.MatchString(s.text) {
+		result.text = "true"
+	}
+	return result, true
+}
+
+func (p *queryParser) filterOr() (filterExpr, error) {
+	var or filterOr
+	for {
+		and, err := p.filterAnd()
+		if err != nil {
+			return nil, err
+		}
+		or = append(or, and)
+		p.skipSpace()
+		if !strings.HasPrefix(p.src[p.pos:], "||") {
+			break
+		}
+		p.pos += 2
+	}
+	if len(or) == 1 {
+		return or[0], nil
+	}
+	return or, nil
+}
+
+func (p *queryParser) filterAnd() (filterExpr, error) {
+	var and filterAnd
+	for {
+		e, err := p.filterUnary()
+		if err != nil {
+			return nil, err
+		}
+		and = append(and, e)
+		p.skipSpace()
+		if !strings.HasPrefix(p.src[p.pos:], "&&") {
+			break
+		}
+		p.pos += 2
+	}
+	if len(and) == 1 {
+		return and[0], nil
+	}
+	return and, nil
+}
+
+func (p *queryParser) filterUnary() (filterExpr, error) {
+	p.skipSpace()
+	if p.peek() == '!' && !strings.HasPrefix(p.src[p.pos:], "!=") {
+		p.pos++
+		e, err := p.filterUnary()
+		return filterNot{e}, err
+	}
+	if p.peek() == '(' {
+		p.pos++
+		e, err := p.filterOr()
+		if err != nil {
+			return nil, err
+		}
+		p.skipSpace()
+		if p.peek() != ')' {
+			return nil, p.errorf("expected )")
+		}
+		p.pos++
+		return e, nil
+	}
+	start := p.pos
+	left, err := p.operand()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	op := ""
+	for _, o := range []string{"==", "!=", "<=", ">=", "<", ">"} {
+		if strings.HasPrefix(p.src[p.pos:], o) {
+			op = o
+			break
+		}
+	}
+	if op == "" {
+		switch v := left.(type) {
+		case queryOperand:
+			return filterExist{v.query}, nil
+		case *filterCall:
+			if v.name == "match" || v.name == "search" {
+				return filterTest{v}, nil
+			}
+		}
+		p.pos = start
+		return nil, p.errorf("expected a comparison or a query")
+	}
+	p.pos += len(op)
+	right, err := p.operand()
+	if err != nil {
+		return nil, err
+	}
+	l, err := p.comparable(left)
+	if err != nil {
+		return nil, err
+	}
+	r, err := p.comparable(right)
+	if err != nil {
+		return nil, err
+	}
+	return filterCmp{op: op, left: l, right: r}, nil
+}
+
+// queryOperand is a query in a filter before it is known whether it is
+// compared, which needs it to be singular, or tested for existence.
+type queryOperand struct{ query *jsonQuery }
+
+func (p *queryParser) comparable(operand any) (filterValue, error) {
+	switch v := operand.(type) {
+	case queryOperand:
+		if !v.query.singular() {
+			return nil, p.errorf("a compared query must select at most one value")
+		}
+		return filterPath{v.query}, nil
+	case *filterCall:
+		if v.name == "match" || v.name == "search" {
+			return nil, p.errorf("%s() cannot be compared", v.name)
+		}
+		return v, nil
+	}
+	return operand.(filterValue), nil
+}
+
+// operand reads a literal, a query or a function call.
+func (p *queryParser) operand() (any, error) {
+	p.skipSpace()
+	var arena docArena
+	switch c := p.peek(); {
+	case c == '@' || c == '$':
+		q, err := p.query()
+		return queryOperand{q}, err
+	case c == '\'' || c == '"':
+		s, err := p.stringLiteral()
+		n := arena.node(docString, docPos{})
+		n.text = s
+		return filterLiteral{n}, err
+	case c == '-' || c >= '0' && c <= '9':
+		start := p.pos
+		for p.pos < len(p.src) && strings.IndexByte("+-.eE0123456789", p.src[p.pos]) >= 0 {
+			p.pos++
+		}
+		text := p.src[start:p.pos]
+		if _, err := strconv.ParseFloat(text, 64); err != nil {
+			p.pos = start
+			return nil, p.errorf("bad number %q", text)
+		}
+		n := arena.node(docNumber, docPos{})
+		n.text = text
+		return filterLiteral{n}, nil
+	}
+	start := p.pos
+	for p.pos < len(p.src) && (p.src[p.pos] >= 'a' && p.src[p.pos] <= 'z' || p.src[p.pos] == '_') {
+		p.pos++
+	}
+	word := p.src[start:p.pos]
+	switch word {
+	case "true", "false":
+		n := arena.node(docBool, docPos{})
+		n.text = word
+		return filterLiteral{n}, nil
+	case "null":
+		return filterLiteral{arena.node(docNull, docPos{})}, nil
+	case "length", "count", "value", "match", "search":
+		return p.call(word)
+	}
+	p.pos = start
+	return nil, p.errorf("expected a value")
+}
+
+// call reads the arguments of a function whose name has been read.
+func (p *queryParser) call(name string) (*filterCall, error) {
+	p.skipSpace()
+	if p.peek() != '(' {
+		return nil, p.errorf("expected ( after %s", name)
+	}
+	p.pos++
+	f := &filterCall{name: name}
+	for {
+		arg, err := p.operand()
+		if err != nil {
+			return nil, err
+		}
+		if name == "count" || name == "value" {
+			q, ok := arg.(queryOperand)
+			if !ok {
+				return nil, p.errorf("%s() takes a query", name)
+			}
+			f.args = append(f.args, q.query)
+		} else {
+			v, err := p.comparable(arg)
+			if err != nil {
+				return nil, err
+			}
+			f.args = append(f.args, v)
+		}
+		p.skipSpace()
+		if p.peek() != ',' {
+			break
+		}
+		p.pos++
+	}
+	if p.peek() != ')' {
+		return nil, p.errorf("expected ) after the arguments of %s", name)
+	}
+	p.pos++
+	want := 1
+	if name == "match" || name == "search" {
+		want = 2
+	}
+	if len(f.args) != want {
+		return nil, p.errorf("%s() takes %d arguments", name, want)
+	}
+	if want == 2 {
+		// A literal pattern is compiled once.
+		if lit, ok := f.args[1].(filterLiteral); ok && lit.node.kind == docString {
+			expr := lit.node.text
+			if name == "match" {
+				expr = `\A(?:` + expr + `)\z`
+			}
+			re, err := regexp.Compile(expr)
+			if err != nil {
+				return nil, p.errorf("%s() pattern: %v", name, err)
+			}
+			f.re = re
+		}
+	}
+	return f, nil
+}
+
+// docEqual compares two values deeply; numbers by value.
+func docEqual(a, b *docNode) bool {
+	if a.kind != b.kind {
+		return false
+	}
+	switch a.kind {
+	case docNumber:
+		x, _ := strconv.ParseFloat(a.text, 64)
+		y, _ := strconv.ParseFloat(b.text, 64)
+		return x == y
+	case docArray:
+		if len(a.items) != len(b.items) {
+			return false
+		}
+		for i := range a.items {
+			if !docEqual(a.items[i], b.items[i]) {
+				return false
+			}
+		}
+		return true
+	case docObject:
+		if len(a.keys) != len(b.keys) {
+			return false
+		}
+		for i, k := range a.keys {
+			j := b.memberIndex(k.name)
+			if j < 0 || !docEqual(a.items[i], b.items[j]) {
+				return false
+			}
+		}
+		return true
+	}
+	return a.text == b.text
+}
+
+func (n *docNode) memberIndex(name string) int {
+	for i, k := range n.keys {
+		if k.name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// docLess orders two numbers or two strings; other values are not ordered.
+func docLess(a, b *docNode) bool {
+	switch {
+	case a.kind == docNumber && b.kind == docNumber:
+		x, _ := strconv.ParseFloat(a.text, 64)
+		y, _ := strconv.ParseFloat(b.text, 64)
+		return x < y
+	case a.kind == docString && b.kind == docString:
+		return a.text < b.text
+	}
+	return false
+}
@@ -0,0 +1,72 @@
+package convert
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const queryStore = `{"store": {
+  "book": [
+    {"category": "reference", "author": "Nigel Rees", "title": "Sayings of the Century", "price": 8.95},
+    {"category": "fiction", "author": "Evelyn Waugh", "title": "Sword of Honour", "price": 12.99},
+    {"category": "fiction", "author": "Herman Melville", "title": "Moby Dick", "isbn": "0-553-21311-3", "price": 8.99},
+    {"category": "fiction", "author": "J. R. R. Tolkien", "title": "The Lord of the Rings", "isbn": "0-395-19395-8", "price": 22.99}
+  ],
+  "bicycle": {"color": "red", "price": 399}
+}}`
+
+func TestQueryJSON(t *testing.T) {
+	for _, c := range []struct {
+		query, want string
+	}{
+		{`$.store.book[*].author`, `["Nigel Rees", "Evelyn Waugh", "Herman Melville", "J. R. R. Tolkien"]`},
+		{`$..author`, `["Nigel Rees", "Evelyn Waugh", "Herman Melville", "J. R. R. Tolkien"]`},
+		{`$.store.*.color`, `["red"]`},
+		{`$.store..price`, `[8.95, 12.99, 8.99, 22.99, 399]`},
+		{`$..book[2].title`, `["Moby Dick"]`},
+		{`$..book[-1].title`, `["The Lord of the Rings"]`},
+		{`$..book[0,1].price`, `[8.95, 12.99]`},
+		{`$..book[:2].price`, `[8.95, 12.99]`},
+		{`$..book[::-2].price`, `[22.99, 12.99]`},
+		{`$..book[1:3:0]`, `[]`},
+		{`$..book[?@.isbn].title`, `["Moby Dick", "The Lord of the Rings"]`},
+		{`$..book[?(@.price < 10)].price`, `[8.95, 8.99]`},
+		{`$..book[?@.price >= 12.99 && @.category == 'fiction'].title`, `["Sword of Honour", "The Lord of the Rings"]`},
+		{`$..book[?!@.isbn || @.author == "Herman Melville"].price`, `[8.95, 12.99, 8.99]`},
+		{`$..book[?@.price > $.store.bicycle.price]`, `[]`},
+		{`$..book[?match(@.author, 'J.*')].title`, `["The Lord of the Rings"]`},
+		{`$..book[?search(@.title, "of")].title`, `["Sayings of the Century", "Sword of Honour", "The Lord of the Rings"]`},
+		{`$..book[?length(@.title) == 9].title`, `["Moby Dick"]`},
+		{`$.store[?count(@.*) == 2].color`, `["red"]`},
+		{`$..book[?value(@..isbn) == "0-553-21311-3"].price`, `[8.99]`},
+		{`$.store.bicycle['color', "price"]`, `["red", 399]`},
+		{`$.missing`, `[]`},
+		{`.store.book[].price`, `[8.95, 12.99, 8.99, 22.99]`},
+		{`.store.book[1].author`, `["Evelyn Waugh"]`},
+		{`.store.bicycle`, `[{"color": "red", "price": 399}]`},
+	} {
+		got, err := QueryJSON(queryStore, c.query)
+		require.NoError(t, err, c.query)
+		require.JSONEq(t, c.want, got, c.query)
+	}
+
+	// Numbers, and the key order of JSON, come through as written.
+	got, err := QueryJSON(`{"items": [{"id": 12345678901234567890, "b": 1, "a": 2}]}`, "$.items[0]")
+	require.NoError(t, err)
+	require.Equal(t, "[\n  {\n    \"id\": 12345678901234567890,\n    \"b\": 1,\n    \"a\": 2\n  }\n]", got)
+	got, err = QueryJSON("items:\n  - name: a\n  - name: b\n", "$.items[*].name")
+	require.NoError(t, err)
+	require.JSONEq(t, `["a", "b"]`, got)
+	got, err = QueryJSON(`[1, 2]`, ".")
+	require.NoError(t, err)
+	require.JSONEq(t, `[[1, 2]]`, got)
+
+	for _, q := range []string{"", "store", "$.", "$[", "$[1", "$['a'", "$[?@.a ==]", "$[?@..a == 1]",
+		"$[?match(@.a)]", "$[?match(@.a, '(')]", "$[?foo(@)]", "$[?@.a] x", ".a[", "$[?1]"} {
+		_, err := QueryJSON(`{}`, q)
+		require.Error(t, err, q)
+	}
+	_, err = QueryJSON(`{`, "$")
+	require.Error(t, err)
+}
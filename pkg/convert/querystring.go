@@ -0,0 +1,254 @@
+package convert
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const formatQueryString = "Query String"
+
+// Array formats for QueryStringOptions.ArrayFormat.
+const (
+	QueryArrayIndices  = "indices"
+	QueryArrayBrackets = "brackets"
+	QueryArrayRepeat   = "repeat"
+)
+
+// QueryStringOptions controls query string parsing and generation. The
+// zero value reads every value as a string and writes arrays with indices.
+type QueryStringOptions struct {
+	// Numbers reads values that are JSON numbers as numbers.
+	Numbers bool `json:"numbers,omitempty"`
+	// Booleans reads true and false as booleans.
+	Booleans bool `json:"booleans,omitempty"`
+	// Nulls reads null, and keys without "=", as null.
+	Nulls bool `json:"nulls,omitempty"`
+	// ArrayFormat writes arrays of scalars as a[0]=x (indices, the
+	// default), a[]=x (brackets) or a=x&a=y (repeat). Arrays holding
+	// objects or arrays always use indices, the only form that reads back.
+	ArrayFormat string `json:"arrayFormat,omitempty"`
+}
+
+// QueryStringToJSON parses a query string with every value kept as a
+// string. See QueryStringToJSONWithOptions.
+func QueryStringToJSON(input string) (string, error) {
+	return QueryStringToJSONWithOptions(input, QueryStringOptions{})
+}
+
+// JSONToQueryString writes a JSON object as a query string with arrays in
+// indexed form. See JSONToQueryStringWithOptions.
+func JSONToQueryString(input string) (string, error) {
+	return JSONToQueryStringWithOptions(input, QueryStringOptions{})
+}
+
+// QueryStringToJSONWithOptions parses a query string, with or without its
+// URL, "?" and "#fragment", into a JSON object. A repeated key collects its
+// values into an array and bracket notation nests them: a[b][0]=x and
+// a[b][]=x both give {"a": {"b": ["x"]}}. Keys are decoded before their
+// brackets are read, so a%5Bb%5D=x nests too, and keep the order they are
+// first given in.
+func QueryStringToJSONWithOptions(input string, opts QueryStringOptions) (string, error) {
+	query := strings.TrimSpace(input)
+	if i := strings.IndexByte(query, '#'); i >= 0 {
+		query = query[:i]
+	}
+	if i := strings.IndexByte(query, '?'); i >= 0 && !strings.ContainsAny(query[:i], "=&") {
+		query = query[i+1:]
+	}
+	b := &docBuilder{}
+	root := b.object()
+	for _, pair := range strings.Split(query, "&") {
+		if pair == "" {
+			continue
+		}
+		rawKey, rawValue, hasValue := strings.Cut(pair, "=")
+		key, err := url.QueryUnescape(rawKey)
+		if err != nil {
+			return "", fmt.Errorf("query string: invalid escape in %q", rawKey)
+		}
+		value, err := url.QueryUnescape(rawValue)
+		if err != nil {
+			return "", fmt.Errorf("query string: invalid escape in %q", rawValue)
+		}
+		v, err := b.value(opts.value(value, hasValue))
+		if err != nil {
+			return "", err
+		}
+		name, path := queryKeyPath(key)
+		b.set(root, name, queryAssign(b, b.get(root, name), path, v))
+	}
+	for i, item := range root.items {
+		root.items[i] = queryArrays(b, item)
+	}
+	out, err := docToJSON(root, "  ")
+	if err != nil {
+		return "", err
+	}
+	return out + "\n", nil
+}
+
+func (o QueryStringOptions) value(s string, hasValue bool) any {
+	switch {
+	case o.Nulls && (!hasValue || s == "null"):
+		return nil
+	case o.Booleans && (s == "true" || s == "false"):
+		return s == "true"
+	case o.Numbers && numberPattern().MatchString(s):
+		return json.Number(s)
+	}
+	return s
+}
+
+// queryKeyPath splits a[b][] into "a" and ["b", ""]. A key whose brackets
+// do not pair up is taken whole.
+func queryKeyPath(key string) (string, []string) {
+	open := strings.IndexByte(key, '[')
+	if open <= 0 || !strings.HasSuffix(key, "]") {
+		return key, nil
+	}
+	var path []string
+	for rest := key[open:]; rest != ""; {
+		end := strings.IndexByte(rest, ']')
+		if rest[0] != '[' || end < 0 || strings.IndexByte(rest[1:end], '[') >= 0 {
+			return key, nil
+		}
+		path = append(path, rest[1:end])
+		rest = rest[end+1:]
+	}
+	return key[:open], path
+}
+
+// queryAssign stores v at path below cur, which is nil where nothing is
+// stored yet, and returns the updated value. Arrays stay objects keyed by
+// indexes until queryArrays turns them back; values meeting at the same
+// place are collected into an array, as a repeated key is.
+func queryAssign(b *docBuilder, cur *docNode, path []string, v *docNode) *docNode {
+	if len(path) == 0 {
+		switch {
+		case cur == nil:
+			return v
+		case cur.kind == docArray:
+			cur.items = append(cur.items, v)
+			return cur
+		}
+		return b.array(cur, v)
+	}
+	if path[0] == "" {
+		next := queryAssign(b, nil, path[1:], v)
+		switch {
+		case cur == nil:
+			return b.array(next)
+		case cur.kind == docArray:
+			cur.items = append(cur.items, next)
+			return cur
+		case cur.kind == docObject:
+			b.set(cur, strconv.Itoa(len(cur.keys)), next)
+			return cur
+		}
+		return b.array(cur, next)
+	}
+	var obj *docNode
+	switch {
+	case cur == nil:
+		obj = b.object()
+	case cur.kind == docObject:
+		obj = cur
+	case cur.kind == docArray:
+		obj = b.object()
+		for i, item := range cur.items {
+			b.set(obj, strconv.Itoa(i), item)
+		}
+	default:
+		return b.array(cur, queryAssign(b, nil, path, v))
+	}
+	b.set(obj, path[0], queryAssign(b, b.get(obj, path[0]), path[1:], v))
+	return obj
+}
+
+// queryArrays turns objects whose keys are all indexes into arrays, in
+// index order with gaps closed.
+func queryArrays(b *docBuilder, n *docNode) *docNode {
+	for i, item := range n.items {
+		n.items[i] = queryArrays(b, item)
+	}
+	if n.kind != docObject || len(n.keys) == 0 {
+		return n
+	}
+	indexes := make([]int, len(n.keys))
+	for j, k := range n.keys {
+		i, err := strconv.Atoi(k.name)
+		if err != nil || i < 0 || strconv.Itoa(i) != k.name {
+			return n
+		}
+		indexes[j] = i
+	}
+	arr := b.array(slices.Clone(n.items)...)
+	sort.Sort(byIndex{indexes, arr.items})
+	return arr
+}
+
+// byIndex sorts items by the indexes they were stored under.
+type byIndex struct {
+	indexes []int
+	items   []*docNode
+}
+
+func (x byIndex) Len() int           { return len(x.indexes) }
+func (x byIndex) Less(i, j int) bool { return x.indexes[i] < x.indexes[j] }
+func (x byIndex) Swap(i, j int) {
+	x.indexes[i], x.indexes[j] = x.indexes[j], x.indexes[i]
+	x.items[i], x.items[j] = x.items[j], x.items[i]
+}
+
+// JSONToQueryStringWithOptions writes a JSON object as a query string in
+// key order, nesting with bracket notation. null is written as a key
+// without a value and empty objects and arrays are left out.
+func JSONToQueryStringWithOptions(input string, opts QueryStringOptions) (string, error) {
+	switch opts.ArrayFormat {
+	case "", QueryArrayIndices, QueryArrayBrackets, QueryArrayRepeat:
+	default:
+		return "", fmt.Errorf("unsupported array format %q", opts.ArrayFormat)
+	}
+	doc, err := parseJSONDoc(input)
+	if err != nil {
+		return "", err
+	}
+	if doc.kind != docObject {
+		return "", errors.New("query string root must be an object")
+	}
+	var pairs []string
+	var walk func(key string, n *docNode)
+	walk = func(key string, n *docNode) {
+		switch n.kind {
+		case docObject:
+			for i, k := range n.keys {
+				walk(key+"["+url.QueryEscape(k.name)+"]", n.items[i])
+			}
+		case docArray:
+			for i, item := range n.items {
+				switch {
+				case item.kind == docObject || item.kind == docArray || opts.ArrayFormat == "" || opts.ArrayFormat == QueryArrayIndices:
+					walk(key+"["+strconv.Itoa(i)+"]", item)
+				case opts.ArrayFormat == QueryArrayBrackets:
+					walk(key+"[]", item)
+				default:
+					walk(key, item)
+				}
+			}
+		case docNull:
+			pairs = append(pairs, key)
+		default:
+			pairs = append(pairs, key+"="+url.QueryEscape(n.text))
+		}
+	}
+	for i, k := range doc.keys {
+		walk(url.QueryEscape(k.name), doc.items[i])
+	}
+	return strings.Join(pairs, "&"), nil
+}
@@ -0,0 +1,65 @@
+package convert
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryStringToJSON(t *testing.T) {
+	out, err := QueryStringToJSON("https://example.com/search?q=go+structs&tag=a&tag=b" +
+		"&user[name]=Ricky&user[langs][]=go&user[langs][]=rust&filter%5Bage%5D%5B0%5D=27" +
+		"&page=2&flag&empty=&odd]=1#results")
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"q": "go structs",
+		"tag": ["a", "b"],
+		"user": {"name": "Ricky", "langs": ["go", "rust"]},
+		"filter": {"age": ["27"]},
+		"page": "2",
+		"flag": "",
+		"empty": "",
+		"odd]": "1"
+	}`, out)
+
+	out, err = QueryStringToJSON("?items[1][id]=b&items[0][id]=a&items[0][n]=1")
+	require.NoError(t, err)
+	require.JSONEq(t, `{"items": [{"id": "a", "n": "1"}, {"id": "b"}]}`, out)
+
+	out, err = QueryStringToJSONWithOptions("n=42&f=-1.5e3&s=007&t=true&b=false&z=null&flag&str=abc",
+		QueryStringOptions{Numbers: true, Booleans: true, Nulls: true})
+	require.NoError(t, err)
+	require.JSONEq(t, `{"n": 42, "f": -1.5e3, "s": "007", "t": true, "b": false, "z": null, "flag": null, "str": "abc"}`, out)
+
+	out, err = QueryStringToJSON("")
+	require.NoError(t, err)
+	require.JSONEq(t, `{}`, out)
+
+	_, err = QueryStringToJSON("a=%zz")
+	require.EqualError(t, err, `query string: invalid escape in "%zz"`)
+}
+
+func TestJSONToQueryString(t *testing.T) {
+	const input = `{"q": "go structs", "tags": ["a", "b"], "user": {"name": "R&D", "pets": [{"id": 1}]},
+		"on": true, "none": null, "empty": [], "obj": {}}`
+	for format, want := range map[string]string{
+		"":                 "q=go+structs&tags[0]=a&tags[1]=b&user[name]=R%26D&user[pets][0][id]=1&on=true&none",
+		QueryArrayIndices:  "q=go+structs&tags[0]=a&tags[1]=b&user[name]=R%26D&user[pets][0][id]=1&on=true&none",
+		QueryArrayBrackets: "q=go+structs&tags[]=a&tags[]=b&user[name]=R%26D&user[pets][0][id]=1&on=true&none",
+		QueryArrayRepeat:   "q=go+structs&tags=a&tags=b&user[name]=R%26D&user[pets][0][id]=1&on=true&none",
+	} {
+		out, err := JSONToQueryStringWithOptions(input, QueryStringOptions{ArrayFormat: format})
+		require.NoError(t, err, format)
+		require.Equal(t, want, out, format)
+
+		back, err := QueryStringToJSONWithOptions(out, QueryStringOptions{Numbers: true, Booleans: true, Nulls: true})
+		require.NoError(t, err, format)
+		require.JSONEq(t, `{"q": "go structs", "tags": ["a", "b"], "user": {"name": "R&D", "pets": [{"id": 1}]},
+			"on": true, "none": null}`, back, format)
+	}
+
+	_, err := JSONToQueryString(`[1, 2]`)
+	require.EqualError(t, err, "query string root must be an object")
+	_, err = JSONToQueryStringWithOptions(`{}`, QueryStringOptions{ArrayFormat: "comma"})
+	require.EqualError(t, err, `unsupported array format "comma"`)
+}
@@ -0,0 +1,415 @@
+package convert
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+const regHeaderV5 = "Windows Registry Editor Version 5.00"
+
+var regHexTypes = map[string]string{
+	"0": "REG_NONE",
+	"1": "REG_SZ",
+	"2": "REG_EXPAND_SZ",
+	"3": "REG_BINARY",
+	"4": "REG_DWORD",
+	"5": "REG_DWORD_BIG_ENDIAN",
+	"7": "REG_MULTI_SZ",
+	"8": "REG_RESOURCE_LIST",
+	"b": "REG_QWORD",
+}
+
+// RegistryFile is the JSON model of a .reg export.
+type RegistryFile struct {
+	Version string        `json:"version"`
+	Keys    []RegistryKey `json:"keys"`
+}
+
+// RegistryKey is one [HIVE\path] section. Delete marks a [-HIVE\path] entry.
+type RegistryKey struct {
+	Path   string          `json:"path"`
+	Hive   string          `json:"hive"`
+	Delete bool            `json:"delete,omitempty"`
+	Values []RegistryValue `json:"values,omitempty"`
+}
+
+// RegistryValue holds a decoded value. Name "@" is the key's default value;
+// binary data is base64, REG_MULTI_SZ is a string array and DWORD/QWORD are numbers.
+type RegistryValue struct {
+	Name   string `json:"name"`
+	Type   string `json:"type,omitempty"`
+	Data   any    `json:"data,omitempty"`
+	Delete bool   `json:"delete,omitempty"`
+}
+
+// RegToJSON parses a Windows Registry export (.reg) into JSON.
+func RegToJSON(input string) (string, error) {
+	lines := joinRegLines(decodeRegText(input))
+	file := RegistryFile{Keys: []RegistryKey{}}
+	var current *RegistryKey
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line.text)
+		switch {
+		case trimmed == "" || strings.HasPrefix(trimmed, ";"):
+			continue
+		case file.Version == "" && current == nil && !strings.HasPrefix(trimmed, "["):
+			file.Version = trimmed
+		case strings.HasPrefix(trimmed, "["):
+			if !strings.HasSuffix(trimmed, "]") {
+				return "", fmt.Errorf("line %d: unterminated key", line.number)
+			}
+			path := trimmed[1 : len(trimmed)-1]
+			key := RegistryKey{}
+			if strings.HasPrefix(path, "-") {
+				key.Delete = true
+				path = path[1:]
+			}
+			key.Path = path
+			key.Hive, _, _ = strings.Cut(path, `\`)
+			file.Keys = append(file.Keys, key)
+			current = &file.Keys[len(file.Keys)-1]
+		default:
+			if current == nil {
+				return "", fmt.Errorf("line %d: value outside of a key", line.number)
+			}
+			val, err := parseRegValue(trimmed)
+			if err != nil {
+				return "", fmt.Errorf("line %d: %w", line.number, err)
+			}
+			current.Values = append(current.Values, val)
+		}
+	}
+	if file.Version == "" {
+		return "", errors.New("missing registry editor header")
+	}
+	return encodeJSON(file)
+}
+
+// JSONToReg renders the RegToJSON model back into a .reg export.
+func JSONToReg(input string) (string, error) {
+	dec := json.NewDecoder(strings.NewReader(input))
+	dec.UseNumber()
+	var file RegistryFile
+	if err := dec.Decode(&file); err != nil {
+		return "", err
+	}
+	if file.Version == "" {
+		file.Version = regHeaderV5
+	}
+	var b strings.Builder
+	b.WriteString(file.Version + "\r\n")
+	for _, key := range file.Keys {
+		if key.Path == "" {
+			return "", errors.New("registry key without path")
+		}
+		b.WriteString("\r\n[")
+		if key.Delete {
+			b.WriteString("-")
+		}
+		b.WriteString(key.Path + "]\r\n")
+		for _, val := range key.Values {
+			line, err := formatRegValue(val)
+			if err != nil {
+				return "", fmt.Errorf("%s: %w", key.Path, err)
+			}
+			b.WriteString(line + "\r\n")
+		}
+	}
+	return b.String(), nil
+}
+
+type regLine struct {
+	number int
+	text   string
+}
+
+// decodeRegText handles UTF-16LE exports pasted as raw bytes.
+func decodeRegText(input string) string {
+	if strings.HasPrefix(input, "\xff\xfe") && len(input)%2 == 0 {
+		raw := []byte(input[2:])
+		units := make([]uint16, len(raw)/2)
+		for i := range units {
+			units[i] = binary.LittleEndian.Uint16(raw[i*2:])
+		}
+		return string(utf16.Decode(units))
+	}
+	return strings.TrimPrefix(input, "\ufeff")
+}
+
+func joinRegLines(input string) []regLine {
+	var out []regLine
+	var pending strings.Builder
+	start := 0
+	for i, raw := range strings.Split(strings.ReplaceAll(input, "\r\n", "\n"), "\n") {
+		text := strings.TrimRight(raw, " \t")
+		if pending.Len() == 0 {
+			start = i + 1
+		} else {
+			text = strings.TrimLeft(text, " \t")
+		}
+		if strings.HasSuffix(text, `\`) && !strings.HasPrefix(strings.TrimSpace(pending.String()+text), "[") {
+			pending.WriteString(strings.TrimSuffix(text, `\`))
+			continue
+		}
+		pending.WriteString(text)
+		out = append(out, regLine{number: start, text: pending.String()})
+		pending.Reset()
+	}
+	if pending.Len() > 0 {
+		out = append(out, regLine{number: start, text: pending.String()})
+	}
+	return out
+}
+
+func parseRegValue(line string) (RegistryValue, error) {
+	var val RegistryValue
+	var rest string
+	if strings.HasPrefix(line, "@") {
+		val.Name = "@"
+		rest = line[1:]
+	} else {
+		name, n, err := readRegString(line)
+		if err != nil {
+			return val, err
+		}
+		val.Name = name
+		rest = line[n:]
+	}
+	rest = strings.TrimSpace(rest)
+	if !strings.HasPrefix(rest, "=") {
+		return val, errors.New("expected '=' after value name")
+	}
+	rest = strings.TrimSpace(rest[1:])
+	switch {
+	case rest == "-":
+		val.Delete = true
+	case strings.HasPrefix(rest, `"`):
+		s, n, err := readRegString(rest)
+		if err != nil {
+			return val, err
+		}
+		if strings.TrimSpace(rest[n:]) != "" {
+			return val, errors.New("unexpected data after string")
+		}
+		val.Type, val.Data = "REG_SZ", s
+	case strings.HasPrefix(rest, "dword:"):
+		n, err := strconv.ParseUint(strings.TrimPrefix(rest, "dword:"), 16, 32)
+		if err != nil {
+			return val, fmt.Errorf("invalid dword: %w", err)
+		}
+		val.Type, val.Data = "REG_DWORD", json.Number(strconv.FormatUint(n, 10))
+	case strings.HasPrefix(rest, "hex"):
+		kind, data, ok := strings.Cut(rest, ":")
+		if !ok {
+			return val, errors.New("invalid hex value")
+		}
+		code := "3"
+		if kind != "hex" {
+			if !strings.HasPrefix(kind, "hex(") || !strings.HasSuffix(kind, ")") {
+				return val, fmt.Errorf("invalid value type %s", kind)
+			}
+			code = strings.ToLower(kind[4 : len(kind)-1])
+		}
+		raw, err := parseRegHex(data)
+		if err != nil {
+			return val, err
+		}
+		val.Type, val.Data = decodeRegHex(code, raw)
+	default:
+		return val, fmt.Errorf("unsupported value %q", rest)
+	}
+	return val, nil
+}
+
+// readRegString reads a quoted string with \\ and \" escapes and returns the
+// number of bytes consumed.
+func readRegString(s string) (string, int, error) {
+	if !strings.HasPrefix(s, `"`) {
+		return "", 0, errors.New("expected quoted string")
+	}
+	var b strings.Builder
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			if i+1 < len(s) {
+				i++
+				b.WriteByte(s[i])
+			}
+		case '"':
+			return b.String(), i + 1, nil
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return "", 0, errors.New("unterminated string")
+}
+
+func parseRegHex(data string) ([]byte, error) {
+	data = strings.Join(strings.Fields(data), "")
+	if data == "" {
+		return []byte{}, nil
+	}
+	raw, err := hex.DecodeString(strings.ReplaceAll(data, ",", ""))
+	if err != nil {
+		return nil, fmt.Errorf("invalid hex data: %w", err)
+	}
+	return raw, nil
+}
+
+func decodeRegHex(code string, raw []byte) (string, any) {
+	typ, ok := regHexTypes[code]
+	if !ok {
+		typ = "hex(" + code + ")"
+	}
+	switch typ {
+	case "REG_SZ", "REG_EXPAND_SZ":
+		return typ, strings.TrimRight(utf16LEString(raw), "\x00")
+	case "REG_MULTI_SZ":
+		list := []string{}
+		for _, s := range strings.Split(utf16LEString(raw), "\x00") {
+			if s != "" {
+				list = append(list, s)
+			}
+		}
+		return typ, list
+	case "REG_DWORD":
+		if len(raw) == 4 {
+			return typ, json.Number(strconv.FormatUint(uint64(binary.LittleEndian.Uint32(raw)), 10))
+		}
+	case "REG_QWORD":
+		if len(raw) == 8 {
+			return typ, json.Number(strconv.FormatUint(binary.LittleEndian.Uint64(raw), 10))
+		}
+	}
+	return typ, base64.StdEncoding.EncodeToString(raw)
+}
+
+func utf16LEString(raw []byte) string {
+	units := make([]uint16, len(raw)/2)
+	for i := range units {
+		units[i] = binary.LittleEndian.Uint16(raw[i*2:])
+	}
+	return string(utf16.Decode(units))
+}
+
+func utf16LEBytes(s string) []byte {
+	units := utf16.Encode([]rune(s))
+	out := make([]byte, len(units)*2)
+	for i, u := range units {
+		binary.LittleEndian.PutUint16(out[i*2:], u)
+	}
+	return out
+}
+
+func formatRegValue(val RegistryValue) (string, error) {
+	name := "@"
+	if val.Name != "@" {
+		name = quoteRegString(val.Name)
+	}
+	if val.Delete {
+		return name + "=-", nil
+	}
+	typ := val.Type
+	if typ == "" {
+		typ = "REG_SZ"
+	}
+	switch typ {
+	case "REG_SZ":
+		s, ok := val.Data.(string)
+		if !ok && val.Data != nil {
+			s = fmt.Sprint(val.Data)
+		}
+		return name + "=" + quoteRegString(s), nil
+	case "REG_DWORD":
+		n, err := regNumber(val.Data, 32)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%s=dword:%08x", name, n), nil
+	case "REG_QWORD":
+		n, err := regNumber(val.Data, 64)
+		if err != nil {
+			return "", err
+		}
+		raw := make([]byte, 8)
+		binary.LittleEndian.PutUint64(raw, n)
+		return formatRegHex(name, "hex(b)", raw), nil
+	case "REG_EXPAND_SZ":
+		s, _ := val.Data.(string)
+		return formatRegHex(name, "hex(2)", append(utf16LEBytes(s), 0, 0)), nil
+	case "REG_MULTI_SZ":
+		var raw []byte
+		items, _ := val.Data.([]any)
+		for _, item := range items {
+			raw = append(raw, utf16LEBytes(fmt.Sprint(item))...)
+			raw = append(raw, 0, 0)
+		}
+		return formatRegHex(name, "hex(7)", append(raw, 0, 0)), nil
+	}
+	s, _ := val.Data.(string)
+	raw, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return "", fmt.Errorf("value %s: data must be base64: %w", val.Name, err)
+	}
+	kind := "hex"
+	if typ != "REG_BINARY" {
+		code := ""
+		for c, t := range regHexTypes {
+			if t == typ {
+				code = c
+			}
+		}
+		if strings.HasPrefix(typ, "hex(") {
+			code = typ[4 : len(typ)-1]
+		}
+		if code == "" {
+			return "", fmt.Errorf("unsupported value type %s", typ)
+		}
+		kind = "hex(" + code + ")"
+	}
+	return formatRegHex(name, kind, raw), nil
+}
+
+func quoteRegString(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+}
+
+func regNumber(v any, bits int) (uint64, error) {
+	s := fmt.Sprint(v)
+	if num, ok := v.(json.Number); ok {
+		s = num.String()
+	}
+	n, err := strconv.ParseUint(s, 10, bits)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %d-bit value %q", bits, s)
+	}
+	return n, nil
+}
+
+// formatRegHex wraps hex data the way regedit does, at roughly 80 columns.
+func formatRegHex(name, kind string, raw []byte) string {
+	var b strings.Builder
+	b.WriteString(name + "=" + kind + ":")
+	col := b.Len()
+	for i, c := range raw {
+		chunk := fmt.Sprintf("%02x", c)
+		if i < len(raw)-1 {
+			chunk += ","
+		}
+		if col+len(chunk) > 77 && i > 0 {
+			b.WriteString("\\\r\n  ")
+			col = 2
+		}
+		b.WriteString(chunk)
+		col += len(chunk)
+	}
+	return b.String()
+}
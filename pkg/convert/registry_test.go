@@ -0,0 +1,108 @@
+package convert
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const sampleReg = "Windows Registry Editor Version 5.00\r\n" +
+	"\r\n" +
+	"[HKEY_CURRENT_USER\\Software\\Demo]\r\n" +
+	"@=\"default\"\r\n" +
+	"\"Path\"=\"C:\\\\Program Files\\\\Demo\"\r\n" +
+	"\"Count\"=dword:0000001f\r\n" +
+	"\"Blob\"=hex:01,02,\\\r\n" +
+	"  03\r\n" +
+	"\"Big\"=hex(b):00,01,00,00,00,00,00,00\r\n" +
+	"\"Multi\"=hex(7):61,00,00,00,62,00,00,00,00,00\r\n" +
+	"\"Expand\"=hex(2):25,00,41,00,25,00,00,00\r\n" +
+	"\"Old\"=-\r\n" +
+	"\r\n" +
+	"[-HKEY_CURRENT_USER\\Software\\Legacy]\r\n"
+
+func TestRegToJSON(t *testing.T) {
+	out, err := RegToJSON(sampleReg)
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"version": "Windows Registry Editor Version 5.00",
+		"keys": [
+			{"path": "HKEY_CURRENT_USER\\Software\\Demo", "hive": "HKEY_CURRENT_USER", "values": [
+				{"name": "@", "type": "REG_SZ", "data": "default"},
+				{"name": "Path", "type": "REG_SZ", "data": "C:\\Program Files\\Demo"},
+				{"name": "Count", "type": "REG_DWORD", "data": 31},
+				{"name": "Blob", "type": "REG_BINARY", "data": "AQID"},
+				{"name": "Big", "type": "REG_QWORD", "data": 256},
+				{"name": "Multi", "type": "REG_MULTI_SZ", "data": ["a", "b"]},
+				{"name": "Expand", "type": "REG_EXPAND_SZ", "data": "%A%"},
+				{"name": "Old", "delete": true}
+			]},
+			{"path": "HKEY_CURRENT_USER\\Software\\Legacy", "hive": "HKEY_CURRENT_USER", "delete": true}
+		]
+	}`, out)
+
+	_, err = RegToJSON("Windows Registry Editor Version 5.00\n\"x\"=\"y\"")
+	require.ErrorContains(t, err, "outside of a key")
+	_, err = RegToJSON("REGEDIT4\n[HKEY_CURRENT_USER\\X]\n\"x\"=dword:zz")
+	require.ErrorContains(t, err, "line 3")
+}
+
+func TestJSONToReg(t *testing.T) {
+	out, err := RegToJSON(sampleReg)
+	require.NoError(t, err)
+	reg, err := JSONToReg(out)
+	require.NoError(t, err)
+	require.Contains(t, reg, "\"Count\"=dword:0000001f\r\n")
+	require.Contains(t, reg, "\"Path\"=\"C:\\\\Program Files\\\\Demo\"\r\n")
+	require.Contains(t, reg, "[-HKEY_CURRENT_USER\\Software\\Legacy]")
+
+	back, err := RegToJSON(reg)
+	require.NoError(t, err)
+	require.JSONEq(t, out, back)
+
+	long, err := JSONToReg(`{"keys":[{"path":"HKEY_LOCAL_MACHINE\\X","values":[{"name":"b","type":"REG_BINARY","data":"` +
+		"AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA" + `"}]}]}`)
+	require.NoError(t, err)
+	require.Contains(t, long, ",\\\r\n  00")
+	back, err = RegToJSON(long)
+	require.NoError(t, err)
+	require.Contains(t, back, "AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA")
+
+	_, err = JSONToReg(`{"keys":[{"path":"HKCU\\X","values":[{"name":"n","type":"REG_DWORD","data":"x"}]}]}`)
+	require.Error(t, err)
+}
+
+func TestINFToJSON(t *testing.T) {
+	src := `; sample driver
+[Version]
+Signature="$Windows NT$"
+Provider=%Mfg%
+
+[Manufacturer]
+%Mfg%=Models,NTamd64
+
+[DefaultInstall]
+CopyFiles=Demo.Copy
+demo.sys ; bare line
+
+[Strings]
+Mfg="Contoso; Ltd"
+`
+	out, err := INFToJSON(src)
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"Version": [
+			{"key": "Signature", "values": ["$Windows NT$"]},
+			{"key": "Provider", "values": ["Contoso; Ltd"]}
+		],
+		"Manufacturer": [{"key": "Contoso; Ltd", "values": ["Models", "NTamd64"]}],
+		"DefaultInstall": [
+			{"key": "CopyFiles", "values": ["Demo.Copy"]},
+			{"values": ["demo.sys"]}
+		],
+		"Strings": [{"key": "Mfg", "values": ["Contoso; Ltd"]}]
+	}`, out)
+
+	_, err = INFToJSON("orphan=1")
+	require.Error(t, err)
+}
@@ -0,0 +1,160 @@
+package convert
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ReplaceOptions drives ReplaceValues. Pattern is a regular expression
+// and Replacement may refer to its groups as $1 or ${name}. Paths limits
+// the replacement to the values at, or below, dotted paths such as
+// "users.*.email" or "tags.0", where * matches any key or index.
+type ReplaceOptions struct {
+	Pattern     string   `json:"pattern"`
+	Replacement string   `json:"replacement"`
+	Paths       []string `json:"paths,omitempty"`
+}
+
+// ReplaceChange is one rewritten value; Path is JSONPath-style like the
+// paths of ScanPII.
+type ReplaceChange struct {
+	Path string `json:"path"`
+	Old  any    `json:"old"`
+	New  any    `json:"new"`
+}
+
+// ReplaceResult holds the rewritten document as JSON and every change.
+type ReplaceResult struct {
+	Document string          `json:"document"`
+	Changes  []ReplaceChange `json:"changes"`
+}
+
+// ReplaceValues runs a regex find/replace over the values of a document
+// (JSON or any format ConvertFormats reads) and never touches keys. Numbers,
+// booleans and null are matched by their JSON text and keep their type
+// when the replacement is still a valid value of it; otherwise they
+// become strings.
+func ReplaceValues(input string, opts ReplaceOptions) (ReplaceResult, error) {
+	res := ReplaceResult{Changes: []ReplaceChange{}}
+	if opts.Pattern == "" {
+		return res, errors.New("pattern is required")
+	}
+	re, err := regexp.Compile(opts.Pattern)
+	if err != nil {
+		return res, fmt.Errorf("invalid pattern: %w", err)
+	}
+	data, err := decodeDocument(input)
+	if err != nil {
+		return res, err
+	}
+	r := &valueReplacer{re: re, replacement: opts.Replacement, result: &res}
+	for _, p := range opts.Paths {
+		if p = strings.Trim(strings.TrimPrefix(strings.TrimSpace(p), "$"), "."); p != "" {
+			r.paths = append(r.paths, strings.Split(p, "."))
+		} else {
+			r.paths = append(r.paths, nil)
+		}
+	}
+	data = r.walk(data, "$", nil)
+	if res.Document, err = encodeJSON(data); err != nil {
+		return res, err
+	}
+	return res, nil
+}
+
+type valueReplacer struct {
+	re          *regexp.Regexp
+	replacement string
+	paths       [][]string
+	result      *ReplaceResult
+}
+
+func (r *valueReplacer) walk(v any, path string, parts []string) any {
+	switch val := v.(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			val[k] = r.walk(val[k], jsonPathChild(path, k), append(parts, k))
+		}
+		return val
+	case []any:
+		for i, item := range val {
+			val[i] = r.walk(item, fmt.Sprintf("%s[%d]", path, i), append(parts, fmt.Sprint(i)))
+		}
+		return val
+	}
+	if !r.selected(parts) {
+		return v
+	}
+	var text string
+	switch val := v.(type) {
+	case json.Number:
+		text = val.String()
+	case nil:
+		text = "null"
+	default:
+		text = exprText(val)
+	}
+	if !r.re.MatchString(text) {
+		return v
+	}
+	replaced := r.re.ReplaceAllString(text, r.replacement)
+	if replaced == text {
+		return v
+	}
+	out := retypeReplacement(v, replaced)
+	r.result.Changes = append(r.result.Changes, ReplaceChange{Path: path, Old: v, New: out})
+	return out
+}
+
+// selected reports whether the value at parts is at or below one of the
+// requested paths; no paths select everything.
+func (r *valueReplacer) selected(parts []string) bool {
+	if len(r.paths) == 0 {
+		return true
+	}
+	for _, want := range r.paths {
+		if len(want) > len(parts) {
+			continue
+		}
+		match := true
+		for i, w := range want {
+			if w != "*" && w != parts[i] {
+				match = false
+				break
+			}
+		}
+		if match {
+			return true
+		}
+	}
+	return false
+}
+
+// retypeReplacement keeps the type of the original value when the new
+// text still reads as one.
+func retypeReplacement(original any, text string) any {
+	switch original.(type) {
+	case json.Number:
+		if _, err := json.Number(text).Float64(); err == nil && json.Valid([]byte(text)) {
+			return json.Number(text)
+		}
+	case bool:
+		if text == "true" || text == "false" {
+			return text == "true"
+		}
+	case nil:
+		if text == "null" {
+			return nil
+		}
+	}
+	return text
+}
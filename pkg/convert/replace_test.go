@@ -0,0 +1,39 @@
+package convert
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplaceValues(t *testing.T) {
+	input := `{"users":[{"email":"a@old.com","old.com":"key stays","port":8080,"active":true},{"email":"b@old.com","port":"8080"}],"note":null}`
+	res, err := ReplaceValues(input, ReplaceOptions{Pattern: `old\.com`, Replacement: "new.org"})
+	require.NoError(t, err)
+	require.JSONEq(t, `{"users":[{"email":"a@new.org","old.com":"key stays","port":8080,"active":true},{"email":"b@new.org","port":"8080"}],"note":null}`, res.Document)
+	require.Equal(t, []ReplaceChange{
+		{Path: "$.users[0].email", Old: "a@old.com", New: "a@new.org"},
+		{Path: "$.users[1].email", Old: "b@old.com", New: "b@new.org"},
+	}, res.Changes)
+
+	res, err = ReplaceValues(input, ReplaceOptions{Pattern: `^80(\d+)$`, Replacement: "90$1", Paths: []string{"users.*.port"}})
+	require.NoError(t, err)
+	require.JSONEq(t, `{"users":[{"email":"a@old.com","old.com":"key stays","port":9080,"active":true},{"email":"b@old.com","port":"9080"}],"note":null}`, res.Document)
+	require.Equal(t, json.Number("9080"), res.Changes[0].New)
+	require.Equal(t, "9080", res.Changes[1].New)
+
+	res, err = ReplaceValues(input, ReplaceOptions{Pattern: `^(true|null)$`, Replacement: "yes", Paths: []string{"users.0", "note"}})
+	require.NoError(t, err)
+	require.Len(t, res.Changes, 2)
+	require.Equal(t, ReplaceChange{Path: "$.note", Old: nil, New: "yes"}, res.Changes[0])
+
+	res, err = ReplaceValues("a: 1\nb: x\n", ReplaceOptions{Pattern: `1`, Replacement: "2"})
+	require.NoError(t, err)
+	require.JSONEq(t, `{"a":2,"b":"x"}`, res.Document)
+
+	_, err = ReplaceValues(input, ReplaceOptions{Pattern: `(`})
+	require.Error(t, err)
+	_, err = ReplaceValues(input, ReplaceOptions{})
+	require.Error(t, err)
+}
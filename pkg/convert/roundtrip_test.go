@@ -0,0 +1,125 @@
+package convert
+
+import (
+	"testing"
+
+	"github.com/linzeyan/transform-go/pkg/convert/transformtest"
+	"github.com/stretchr/testify/require"
+)
+
+// roundTripConfigs narrows the generated documents to what each two-way
+// format can hold.
+var roundTripConfigs = map[string]transformtest.Config{
+	formatYAML:          {},
+	formatMsgPack:       {},
+	formatTOON:          {},
+	formatNDJSON:        {Root: transformtest.RootRecords},
+	formatTOML:          {Root: transformtest.RootObject, NoNull: true},
+	formatHCL:           {Root: transformtest.RootObject},
+	formatPlist:         {NoNull: true},
+	formatBPlist:        {NoNull: true},
+	formatCSV:           {Root: transformtest.RootRecords, NoNull: true, NoEmpty: true},
+	formatHTMLTable:     {Root: transformtest.RootRecords, NoNull: true, NoEmpty: true},
+	formatMarkdownTable: {Root: transformtest.RootRecords, NoNull: true, NoEmpty: true},
+	formatXML:           {Root: transformtest.RootObject, NoArray: true, NoEmpty: true, Normalize: transformtest.Text},
+	formatPropsXML:      {Root: transformtest.RootObject, MaxDepth: 1, Normalize: transformtest.Text},
+	formatProperties:    {Root: transformtest.RootObject, NoEmpty: true, Normalize: transformtest.Text},
+	formatDotenv:        {Root: transformtest.RootObject, MaxDepth: 1, Normalize: transformtest.Text},
+	formatQueryString:   {Root: transformtest.RootObject, NoEmpty: true, Normalize: transformtest.Text},
+	formatGoStruct:      typeFormatConfig,
+	formatSchema:        typeFormatConfig,
+	formatGraphQL:       typeFormatConfig,
+	formatProtobuf:      typeFormatConfig,
+	formatAvro:          typeFormatConfig,
+	formatSQL:           {Root: transformtest.RootObject, MaxDepth: 1, NoNull: true, Normalize: transformtest.Kinds},
+}
+
+// typeFormatConfig fits formats that keep types, not values.
+var typeFormatConfig = transformtest.Config{
+	Root:          transformtest.RootObject,
+	NoNull:        true,
+	NoEmpty:       true,
+	NoNestedArray: true,
+	Uniform:       true,
+	Normalize:     transformtest.Kinds,
+}
+
+// roundTripSkipped are two-way formats with a fixed document layout that
+// random documents do not fit.
+var roundTripSkipped = map[string]bool{
+	formatJSON: true,
+	formatReg:  true,
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, info := range ListFormats() {
+		if info.ToJSON && info.FromJSON && !roundTripSkipped[info.Name] {
+			_, ok := roundTripConfigs[info.Name]
+			require.True(t, ok, "no round-trip config for %s", info.Name)
+		}
+	}
+	for name, c := range roundTripConfigs {
+		t.Run(name, func(t *testing.T) {
+			transformtest.RoundTrip(t, converter(formatJSON, name), converter(name, formatJSON), c)
+		})
+	}
+}
+
+func TestRoundTripPairs(t *testing.T) {
+	for a, ca := range roundTripConfigs {
+		for b, cb := range roundTripConfigs {
+			c, ok := combineConfigs(ca, cb)
+			if a == b || !ok || selfTestShapeOnly[a] && selfTestTextOnly[b] || selfTestTextOnly[a] && selfTestShapeOnly[b] {
+				// Types read back from text formats are all strings.
+				continue
+			}
+			c.Runs = 20
+			t.Run(a+"/"+b, func(t *testing.T) {
+				from := func(s string) (string, error) {
+					out, err := ConvertFormats(formatJSON, a, s)
+					if err != nil {
+						return "", err
+					}
+					return ConvertFormats(a, b, out)
+				}
+				to := func(s string) (string, error) {
+					out, err := ConvertFormats(b, a, s)
+					if err != nil {
+						return "", err
+					}
+					return ConvertFormats(a, formatJSON, out)
+				}
+				transformtest.RoundTrip(t, from, to, c)
+			})
+		}
+	}
+}
+
+func converter(from, to string) func(string) (string, error) {
+	return func(s string) (string, error) { return ConvertFormats(from, to, s) }
+}
+
+// combineConfigs generates what both a and b can hold; formats that need
+// different roots have nothing in common.
+func combineConfigs(a, b transformtest.Config) (transformtest.Config, bool) {
+	if a.Root != transformtest.RootAny && b.Root != transformtest.RootAny && a.Root != b.Root {
+		return a, false
+	}
+	c := a
+	c.Root = max(a.Root, b.Root)
+	if b.MaxDepth != 0 && (c.MaxDepth == 0 || b.MaxDepth < c.MaxDepth) {
+		c.MaxDepth = b.MaxDepth
+	}
+	c.NoNull = a.NoNull || b.NoNull
+	c.NoEmpty = a.NoEmpty || b.NoEmpty
+	c.NoArray = a.NoArray || b.NoArray
+	c.NoNestedArray = a.NoNestedArray || b.NoNestedArray
+	c.Uniform = a.Uniform || b.Uniform
+	switch {
+	case a.Normalize == nil:
+		c.Normalize = b.Normalize
+	case b.Normalize != nil:
+		c.Normalize = func(v any) any { return b.Normalize(a.Normalize(v)) }
+	}
+	return c, true
+}
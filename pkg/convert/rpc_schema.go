@@ -0,0 +1,337 @@
+package convert
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/ast"
+	"hash/fnv"
+	"sort"
+	"strings"
+
+	"github.com/linzeyan/transform-go/pkg/common"
+)
+
+var identPattern = common.LazyRegexp(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// rpcRecord is a format-neutral struct inferred from a JSON sample or Go
+// source, rendered by the Cap'n Proto and Bond generators.
+type rpcRecord struct {
+	Name   string
+	Fields []rpcField
+}
+
+type rpcField struct {
+	Key     string
+	Comment string
+	// Kind is one of string, int, float, bool, bytes or record (see Ref).
+	Kind  string
+	Ref   string
+	Lists int
+}
+
+type rpcRecordBuilder struct {
+	records map[string]*rpcRecord
+	order   []string
+}
+
+func newRPCRecordBuilder() *rpcRecordBuilder {
+	return &rpcRecordBuilder{records: make(map[string]*rpcRecord)}
+}
+
+func (b *rpcRecordBuilder) add(rec *rpcRecord) {
+	b.records[rec.Name] = rec
+	b.order = append(b.order, rec.Name)
+}
+
+// list returns records in dependency order: nested records first.
+func (b *rpcRecordBuilder) list() []*rpcRecord {
+	out := make([]*rpcRecord, 0, len(b.order))
+	for _, name := range b.order {
+		out = append(out, b.records[name])
+	}
+	return out
+}
+
+func inferRPCRecords(root string, data any) []*rpcRecord {
+	b := newRPCRecordBuilder()
+	switch val := data.(type) {
+	case map[string]any:
+		b.fromObject(sanitizeTypeName(root), val)
+	case []any:
+		field := b.fromValue(sanitizeTypeName(root), "items", val)
+		b.add(&rpcRecord{Name: sanitizeTypeName(root), Fields: []rpcField{field}})
+	default:
+		field := b.fromValue(sanitizeTypeName(root), "value", val)
+		b.add(&rpcRecord{Name: sanitizeTypeName(root), Fields: []rpcField{field}})
+	}
+	return b.list()
+}
+
+func (b *rpcRecordBuilder) fromObject(name string, obj map[string]any) string {
+	if _, ok := b.records[name]; ok {
+		return name
+	}
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	rec := &rpcRecord{Name: name}
+	for _, key := range keys {
+		rec.Fields = append(rec.Fields, b.fromValue(name, key, obj[key]))
+	}
+	b.add(rec)
+	return name
+}
+
+func (b *rpcRecordBuilder) fromValue(parent, key string, v any) rpcField {
+	field := rpcField{Key: key}
+	typeName := parent + common.ExportName(key)
+	for {
+		arr, ok := v.([]any)
+		if !ok {
+			break
+		}
+		field.Lists++
+		typeName += "Item"
+		v = nil
+		for _, item := range arr {
+			if item != nil {
+				v = item
+				break
+			}
+		}
+	}
+	switch val := v.(type) {
+	case map[string]any:
+		field.Kind = "record"
+		field.Ref = b.fromObject(sanitizeTypeName(typeName), val)
+	case json.Number:
+		field.Kind = "float"
+		if common.LooksInteger(val) {
+			field.Kind = "int"
+		}
+	case bool:
+		field.Kind = "bool"
+	default:
+		field.Kind = "string"
+	}
+	return field
+}
+
+func goRPCRecords(src string) ([]*rpcRecord, error) {
+	defs, err := parseGoStructDefinitions(src)
+	if err != nil {
+		return nil, err
+	}
+	b := newRPCRecordBuilder()
+	for _, def := range defs {
+		if _, ok := b.records[def.Name]; ok {
+			continue
+		}
+		rec := &rpcRecord{Name: def.Name}
+		for _, f := range def.Fields {
+			field := goTypeToRPC(f.TypeExpr)
+			field.Key = f.JSONName
+			if field.Key == "" {
+				field.Key = f.GoName
+			}
+			field.Comment = f.Comment
+			rec.Fields = append(rec.Fields, field)
+		}
+		b.add(rec)
+	}
+	if len(b.order) == 0 {
+		return nil, errors.New("no struct definition found")
+	}
+	return b.list(), nil
+}
+
+func goTypeToRPC(expr ast.Expr) rpcField {
+	switch t := expr.(type) {
+	case *ast.ArrayType:
+		if ident, ok := t.Elt.(*ast.Ident); ok && (ident.Name == "byte" || ident.Name == "uint8") {
+			return rpcField{Kind: "bytes"}
+		}
+		inner := goTypeToRPC(t.Elt)
+		inner.Lists++
+		return inner
+	case *ast.StarExpr:
+		return goTypeToRPC(t.X)
+	case *ast.SelectorExpr:
+		if t.Sel.Name == "Time" {
+			return rpcField{Kind: "string"}
+		}
+		return rpcField{Kind: "record", Ref: t.Sel.Name}
+	case *ast.Ident:
+		switch t.Name {
+		case "string":
+			return rpcField{Kind: "string"}
+		case "bool":
+			return rpcField{Kind: "bool"}
+		case "float32", "float64":
+			return rpcField{Kind: "float"}
+		case "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64":
+			return rpcField{Kind: "int"}
+		}
+		return rpcField{Kind: "record", Ref: t.Name}
+	}
+	return rpcField{Kind: "string"}
+}
+
+// JSONToCapnp infers a Cap'n Proto schema from a JSON sample.
+func JSONToCapnp(input string) (string, error) {
+	data, err := decodeJSONValue(input)
+	if err != nil {
+		return "", err
+	}
+	return renderCapnp(inferRPCRecords("AutoGenerated", data)), nil
+}
+
+// GoStructToCapnp renders Go struct definitions as a Cap'n Proto schema.
+func GoStructToCapnp(src string) (string, error) {
+	records, err := goRPCRecords(src)
+	if err != nil {
+		return "", err
+	}
+	return renderCapnp(records), nil
+}
+
+// JSONToBond infers a Microsoft Bond IDL schema from a JSON sample.
+func JSONToBond(input string) (string, error) {
+	data, err := decodeJSONValue(input)
+	if err != nil {
+		return "", err
+	}
+	return renderBond(inferRPCRecords("AutoGenerated", data)), nil
+}
+
+// GoStructToBond renders Go struct definitions as a Bond IDL schema.
+func GoStructToBond(src string) (string, error) {
+	records, err := goRPCRecords(src)
+	if err != nil {
+		return "", err
+	}
+	return renderBond(records), nil
+}
+
+func renderCapnp(records []*rpcRecord) string {
+	blocks := make([]string, 0, len(records))
+	for _, rec := range records {
+		var lines []string
+		used := map[string]bool{}
+		for i, field := range rec.Fields {
+			name := uniqueFieldName(graphQLFieldName(field.Key), i, used)
+			lines = append(lines, commentLines(field.Comment, "  #")...)
+			lines = append(lines, fmt.Sprintf("  %s @%d :%s;", name, i, capnpType(field)))
+		}
+		if len(lines) == 0 {
+			blocks = append(blocks, fmt.Sprintf("struct %s {}", rec.Name))
+			continue
+		}
+		blocks = append(blocks, fmt.Sprintf("struct %s {\n%s\n}", rec.Name, strings.Join(lines, "\n")))
+	}
+	body := strings.Join(blocks, "\n\n")
+	return fmt.Sprintf("@0x%016x;\n\n%s", capnpFileID(body), body)
+}
+
+// capnpFileID derives a stable file ID; Cap'n Proto requires the top bit set.
+func capnpFileID(body string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(body))
+	return h.Sum64() | 1<<63
+}
+
+func capnpType(field rpcField) string {
+	var base string
+	switch field.Kind {
+	case "int":
+		base = "Int64"
+	case "float":
+		base = "Float64"
+	case "bool":
+		base = "Bool"
+	case "bytes":
+		base = "Data"
+	case "record":
+		base = field.Ref
+	default:
+		base = "Text"
+	}
+	for i := 0; i < field.Lists; i++ {
+		base = "List(" + base + ")"
+	}
+	return base
+}
+
+func renderBond(records []*rpcRecord) string {
+	blocks := make([]string, 0, len(records)+1)
+	if len(records) > 0 {
+		blocks = append(blocks, "namespace "+records[len(records)-1].Name)
+	}
+	for _, rec := range records {
+		var lines []string
+		used := map[string]bool{}
+		for i, field := range rec.Fields {
+			name := field.Key
+			if !identPattern().MatchString(name) {
+				name = protoFieldName(name)
+			}
+			name = uniqueFieldName(name, i, used)
+			lines = append(lines, commentLines(field.Comment, "    //")...)
+			lines = append(lines, fmt.Sprintf("    %d: %s %s;", i, bondType(field), name))
+		}
+		if len(lines) == 0 {
+			blocks = append(blocks, fmt.Sprintf("struct %s\n{\n}", rec.Name))
+			continue
+		}
+		blocks = append(blocks, fmt.Sprintf("struct %s\n{\n%s\n}", rec.Name, strings.Join(lines, "\n")))
+	}
+	return strings.Join(blocks, "\n\n")
+}
+
+func bondType(field rpcField) string {
+	var base string
+	switch field.Kind {
+	case "int":
+		base = "int64"
+	case "float":
+		base = "double"
+	case "bool":
+		base = "bool"
+	case "bytes":
+		base = "blob"
+	case "record":
+		base = field.Ref
+	default:
+		base = "string"
+	}
+	for i := 0; i < field.Lists; i++ {
+		base = "vector<" + base + ">"
+	}
+	return base
+}
+
+func uniqueFieldName(name string, idx int, used map[string]bool) string {
+	if name == "" {
+		name = fmt.Sprintf("field%d", idx)
+	}
+	base := name
+	for n := 2; used[name]; n++ {
+		name = fmt.Sprintf("%s%d", base, n)
+	}
+	used[name] = true
+	return name
+}
+
+func commentLines(comment, prefix string) []string {
+	var out []string
+	for _, line := range strings.Split(comment, "\n") {
+		line = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), "//"))
+		if line != "" {
+			out = append(out, prefix+" "+line)
+		}
+	}
+	return out
+}
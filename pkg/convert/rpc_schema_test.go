@@ -0,0 +1,64 @@
+package convert
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const sampleRPCJSON = `{"user_id": 7, "name": "Alice", "score": 9.5, "tags": ["a"], "address": {"city": "Taipei"}, "matrix": [[1, 2]], "flags": {}}`
+
+func Test_JSONToCapnp(t *testing.T) {
+	out, err := JSONToCapnp(sampleRPCJSON)
+	require.NoError(t, err)
+	require.Regexp(t, `^@0x[89a-f][0-9a-f]{15};`, out)
+	require.Contains(t, out, "struct AutoGeneratedAddress {\n  city @0 :Text;\n}")
+	require.Contains(t, out, "struct AutoGeneratedFlags {}")
+	require.Contains(t, out, "  matrix @2 :List(List(Int64));")
+	require.Contains(t, out, "  score @4 :Float64;")
+	require.Contains(t, out, "  userId @6 :Int64;")
+	require.Less(t, strings.Index(out, "struct AutoGeneratedAddress"), strings.Index(out, "struct AutoGenerated {"))
+
+	again, err := JSONToCapnp(sampleRPCJSON)
+	require.NoError(t, err)
+	require.Equal(t, out, again)
+}
+
+func Test_GoStructToCapnp(t *testing.T) {
+	out, err := GoStructToCapnp(sampleGoStruct)
+	require.NoError(t, err)
+	require.Contains(t, out, "struct User {\n  name @0 :Text;\n  age @1 :Int64;\n}")
+
+	_, err = GoStructToCapnp("var x = 1")
+	require.Error(t, err)
+}
+
+func Test_JSONToBond(t *testing.T) {
+	out, err := JSONToBond(sampleRPCJSON)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(out, "namespace AutoGenerated\n\n"))
+	require.Contains(t, out, "struct AutoGeneratedAddress\n{\n    0: string city;\n}")
+	require.Contains(t, out, "    2: vector<vector<int64>> matrix;")
+	require.Contains(t, out, "    5: vector<string> tags;")
+	require.Contains(t, out, "    6: int64 user_id;")
+}
+
+func Test_GoStructToBond(t *testing.T) {
+	src := `type Item struct {
+	// Raw payload
+	Data []byte ` + "`json:\"data\"`" + `
+	Children []*Item ` + "`json:\"children\"`" + `
+}`
+	out, err := GoStructToBond(src)
+	require.NoError(t, err)
+	require.Contains(t, out, "    // Raw payload\n    0: blob data;")
+	require.Contains(t, out, "    1: vector<Item> children;")
+}
+
+func Fuzz_JSONToCapnp(f *testing.F) {
+	f.Add(sampleRPCJSON)
+	f.Fuzz(func(t *testing.T, input string) {
+		_, _ = JSONToCapnp(input)
+	})
+}
@@ -0,0 +1,201 @@
+package convert
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Selection picks records from a top-level array. Build one with Head,
+// Tail, Sample or Paginate and run it once with StreamSelect or
+// SelectRecords; Tail and Sample keep state between records.
+type Selection struct {
+	// take sees every record in order and reports whether to write it now
+	// and whether reading can stop.
+	take func(i int, rec any) (emit, stop bool)
+	// rest returns records held back until the input ends.
+	rest func() []any
+}
+
+// Head keeps the first n records and stops reading after them.
+func Head(n int) Selection {
+	return Selection{take: func(i int, _ any) (bool, bool) {
+		return i < n, i >= n-1
+	}}
+}
+
+// Paginate keeps page (1-based) of size records and stops reading after it.
+func Paginate(page, size int) Selection {
+	if page < 1 {
+		page = 1
+	}
+	start := (page - 1) * size
+	return Selection{take: func(i int, _ any) (bool, bool) {
+		return i >= start && i < start+size, i >= start+size-1
+	}}
+}
+
+// Tail keeps the last n records, holding at most n in memory.
+func Tail(n int) Selection {
+	var ring []any
+	pos := 0
+	return Selection{
+		take: func(_ int, rec any) (bool, bool) {
+			switch {
+			case n <= 0:
+			case len(ring) < n:
+				ring = append(ring, rec)
+			default:
+				ring[pos] = rec
+				pos = (pos + 1) % n
+			}
+			return false, false
+		},
+		rest: func() []any {
+			return slices.Concat(ring[pos:], ring[:pos])
+		},
+	}
+}
+
+// Sample keeps n records chosen uniformly at random (reservoir sampling),
+// in their original order. The same seed always picks the same records.
+func Sample(n int, seed uint64) Selection {
+	rng := rand.New(rand.NewPCG(seed, seed^0x9e3779b97f4a7c15))
+	type picked struct {
+		index int
+		rec   any
+	}
+	var reservoir []picked
+	return Selection{
+		take: func(i int, rec any) (bool, bool) {
+			switch {
+			case n <= 0:
+			case len(reservoir) < n:
+				reservoir = append(reservoir, picked{i, rec})
+			default:
+				if j := rng.IntN(i + 1); j < n {
+					reservoir[j] = picked{i, rec}
+				}
+			}
+			return false, false
+		},
+		rest: func() []any {
+			sort.Slice(reservoir, func(a, b int) bool { return reservoir[a].index < reservoir[b].index })
+			out := make([]any, len(reservoir))
+			for i, p := range reservoir {
+				out[i] = p.rec
+			}
+			return out
+		},
+	}
+}
+
+// StreamSelect is Stream with a Selection applied to the records of a
+// top-level array. JSON, NDJSON and YAML are processed record by record, so
+// Head and Paginate stop reading early and Tail and Sample only hold the
+// records they keep; other formats are converted in memory.
+func StreamSelect(from, to string, r io.Reader, w io.Writer, sel Selection, opts ...ConvertOption) error {
+	o := NewConvertOptions(opts...)
+	sink := newRecordSink(to, w, o)
+	var src *recordStream
+	if sink != nil {
+		src = openRecordStream(from, r, o)
+	}
+	if src == nil {
+		return selectBuffered(from, to, r, w, sel, o)
+	}
+	count := 0
+	emit := func(rec any) error {
+		count++
+		return sink.item(rec.(*docNode))
+	}
+	for i := 0; ; i++ {
+		rec, err := src.next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if !src.seq {
+			return fmt.Errorf("%s input is not an array", from)
+		}
+		keep, stop := sel.take(i, rec)
+		if keep {
+			if err := emit(rec); err != nil {
+				return err
+			}
+		}
+		if stop {
+			break
+		}
+	}
+	if sel.rest != nil {
+		for _, rec := range sel.rest() {
+			if err := emit(rec); err != nil {
+				return err
+			}
+		}
+	}
+	if count == 0 {
+		return sink.empty()
+	}
+	return sink.end()
+}
+
+// SelectRecords applies sel to input and returns the kept records in the
+// to format.
+func SelectRecords(from, to, input string, sel Selection, opts ...ConvertOption) (string, error) {
+	var b strings.Builder
+	if err := StreamSelect(from, to, strings.NewReader(input), &b, sel, opts...); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+func selectBuffered(from, to string, r io.Reader, w io.Writer, sel Selection, o ConvertOptions) error {
+	input, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	mid, err := ConvertFormatsWithOptions(from, formatJSON, string(input))
+	if err != nil {
+		return err
+	}
+	doc, err := parseJSONDoc(mid)
+	if err != nil {
+		return err
+	}
+	if doc.kind != docArray {
+		return fmt.Errorf("%s input is not an array", from)
+	}
+	kept := &docNode{kind: docArray}
+	for i, rec := range doc.items {
+		keep, stop := sel.take(i, rec)
+		if keep {
+			kept.items = append(kept.items, rec)
+		}
+		if stop {
+			break
+		}
+	}
+	if sel.rest != nil {
+		for _, rec := range sel.rest() {
+			kept.items = append(kept.items, rec.(*docNode))
+		}
+	}
+	encoded, err := docToJSON(kept, "  ")
+	if err != nil {
+		return err
+	}
+	out, err := ConvertFormatsWithOptions(formatJSON, to, encoded, WithOptions(o))
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, out)
+	return err
+}
@@ -0,0 +1,76 @@
+package convert
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// failAfterReader errors once its input is exhausted, proving the reader was
+// not drained.
+type failAfterReader struct{ r *strings.Reader }
+
+func (f failAfterReader) Read(p []byte) (int, error) {
+	if f.r.Len() == 0 {
+		return 0, errors.New("read past the selected records")
+	}
+	return f.r.Read(p)
+}
+
+func TestSelectRecords(t *testing.T) {
+	input := `[1,2,3,4,5,6,7]`
+	cases := []struct {
+		name string
+		sel  Selection
+		want string
+	}{
+		{"head", Head(2), "1\n2\n"},
+		{"head zero", Head(0), ""},
+		{"tail", Tail(3), "5\n6\n7\n"},
+		{"tail more than input", Tail(10), "1\n2\n3\n4\n5\n6\n7\n"},
+		{"page 2", Paginate(2, 3), "4\n5\n6\n"},
+		{"last page", Paginate(3, 3), "7\n"},
+		{"past the end", Paginate(9, 3), ""},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			out, err := SelectRecords(formatJSON, formatNDJSON, input, tc.sel)
+			require.NoError(t, err)
+			require.Equal(t, tc.want, out)
+		})
+	}
+
+	out, err := SelectRecords(formatCSV, formatCSV, "id,name\n1,a\n2,b\n3,c\n", Tail(1))
+	require.NoError(t, err)
+	require.Equal(t, "id,name\n3,c", out)
+
+	out, err = SelectRecords(formatYAML, formatJSON, "- a\n- b\n", Head(5))
+	require.NoError(t, err)
+	require.JSONEq(t, `["a","b"]`, out)
+
+	_, err = SelectRecords(formatJSON, formatJSON, `{"a":1}`, Head(1))
+	require.Error(t, err)
+}
+
+func TestSample(t *testing.T) {
+	input := `[0,1,2,3,4,5,6,7,8,9,10,11,12,13,14,15,16,17,18,19]`
+	first, err := SelectRecords(formatJSON, formatNDJSON, input, Sample(5, 42))
+	require.NoError(t, err)
+	again, err := SelectRecords(formatJSON, formatNDJSON, input, Sample(5, 42))
+	require.NoError(t, err)
+	require.Equal(t, first, again)
+	require.Len(t, strings.Fields(first), 5)
+
+	all, err := SelectRecords(formatJSON, formatNDJSON, `[3,1,2]`, Sample(10, 1))
+	require.NoError(t, err)
+	require.Equal(t, "3\n1\n2\n", all)
+}
+
+func TestStreamSelectStopsEarly(t *testing.T) {
+	var out strings.Builder
+	r := failAfterReader{strings.NewReader("{\"n\":1}\n{\"n\":2}\n")}
+	require.NoError(t, StreamSelect(formatNDJSON, formatNDJSON, r, &out, Head(1)))
+	require.Equal(t, "{\"n\":1}\n", out.String())
+}
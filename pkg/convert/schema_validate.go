@@ -0,0 +1,469 @@
+package convert
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/linzeyan/transform-go/pkg/common"
+)
+
+var schemaUUIDPattern = common.LazyRegexp(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// SchemaError is one JSON Schema violation. Path is a JSON Pointer to the
+// offending value ("" for the document root); Property names the missing
+// or unexpected property for required and additionalProperties errors.
+type SchemaError struct {
+	Path     string `json:"path"`
+	Keyword  string `json:"keyword"`
+	Message  string `json:"message"`
+	Property string `json:"property,omitempty"`
+}
+
+// ValidateAgainstSchema checks document against schema and returns every
+// violation, or an empty list when the document is valid. Either side may
+// be JSON or any format ConvertFormats reads into JSON, such as YAML.
+func ValidateAgainstSchema(schema, document string) ([]SchemaError, error) {
+	s, err := decodeDocument(schema)
+	if err != nil {
+		return nil, fmt.Errorf("schema: %w", err)
+	}
+	if _, ok := s.(map[string]any); !ok {
+		if _, ok := s.(bool); !ok {
+			return nil, errors.New("schema must be an object or a boolean")
+		}
+	}
+	doc, err := decodeDocument(document)
+	if err != nil {
+		return nil, fmt.Errorf("document: %w", err)
+	}
+	errs := validateSchema(s, doc, "")
+	if errs == nil {
+		errs = []SchemaError{}
+	}
+	return errs, nil
+}
+
+// validateSchema checks v (decoded with UseNumber) against schema and
+// returns every violation found. It covers the assertion keywords of the
+// common drafts: type, enum, const, numeric and string bounds, pattern,
+// format, required, properties, patternProperties, additionalProperties,
+// property counts, items, prefixItems and contains, the allOf, anyOf,
+// oneOf, not and if/then/else combinators, and local $ref pointers into
+// the same document.
+func validateSchema(schema any, v any, path string) []SchemaError {
+	sv := &schemaValidator{root: schema}
+	return sv.validate(schema, v, path)
+}
+
+// maxSchemaRefs stops a $ref cycle that never consumes any input.
+const maxSchemaRefs = 64
+
+type schemaValidator struct {
+	root any
+	refs int
+}
+
+func (sv *schemaValidator) validate(schema any, v any, path string) []SchemaError {
+	s, ok := schema.(map[string]any)
+	if !ok {
+		if b, isBool := schema.(bool); isBool && !b {
+			return []SchemaError{{Path: path, Keyword: "false", Message: "no value is allowed here"}}
+		}
+		return nil
+	}
+	var errs []SchemaError
+	fail := func(keyword, format string, args ...any) {
+		errs = append(errs, SchemaError{Path: path, Keyword: keyword, Message: fmt.Sprintf(format, args...)})
+	}
+	v = exprNormalize(v)
+
+	if ref, ok := s["$ref"].(string); ok {
+		target, err := sv.resolve(ref)
+		if err != nil {
+			fail("$ref", "%v", err)
+		} else {
+			sv.refs++
+			if sv.refs > maxSchemaRefs {
+				fail("$ref", "too many nested references at %s", ref)
+			} else {
+				errs = append(errs, sv.validate(target, v, path)...)
+			}
+			sv.refs--
+		}
+	}
+	errs = append(errs, sv.combinators(s, v, path)...)
+
+	if t, ok := s["type"]; ok && !schemaTypeMatches(t, v) {
+		fail("type", "expected %s, got %s", schemaTypeNames(t), schemaTypeOf(v))
+		return errs
+	}
+	if enum, ok := s["enum"].([]any); ok && !slices.ContainsFunc(enum, func(e any) bool { return schemaEqual(e, v) }) {
+		fail("enum", "value must be one of %s", schemaList(enum))
+	}
+	if c, ok := s["const"]; ok && !schemaEqual(c, v) {
+		fail("const", "value must be %s", schemaList([]any{c}))
+	}
+
+	switch val := v.(type) {
+	case float64:
+		if n, ok := schemaNumber(s["minimum"]); ok && val < n {
+			fail("minimum", "must be >= %v", n)
+		}
+		if n, ok := schemaNumber(s["maximum"]); ok && val > n {
+			fail("maximum", "must be <= %v", n)
+		}
+		if n, ok := schemaNumber(s["exclusiveMinimum"]); ok && val <= n {
+			fail("exclusiveMinimum", "must be > %v", n)
+		}
+		if n, ok := schemaNumber(s["exclusiveMaximum"]); ok && val >= n {
+			fail("exclusiveMaximum", "must be < %v", n)
+		}
+		if n, ok := schemaNumber(s["multipleOf"]); ok && n > 0 {
+			if q := val / n; math.Abs(q-math.Round(q)) > 1e-9 {
+				fail("multipleOf", "must be a multiple of %v", n)
+			}
+		}
+	case string:
+		length := float64(utf8.RuneCountInString(val))
+		if n, ok := schemaNumber(s["minLength"]); ok && length < n {
+			fail("minLength", "must be at least %v characters", n)
+		}
+		if n, ok := schemaNumber(s["maxLength"]); ok && length > n {
+			fail("maxLength", "must be at most %v characters", n)
+		}
+		if pattern, ok := s["pattern"].(string); ok {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				fail("pattern", "invalid pattern %q: %v", pattern, err)
+			} else if !re.MatchString(val) {
+				fail("pattern", "must match %s", pattern)
+			}
+		}
+		if format, ok := s["format"].(string); ok && !schemaFormatMatches(format, val) {
+			fail("format", "must be a valid %s", format)
+		}
+	case []any:
+		if n, ok := schemaNumber(s["minItems"]); ok && float64(len(val)) < n {
+			fail("minItems", "must have at least %v items", n)
+		}
+		if n, ok := schemaNumber(s["maxItems"]); ok && float64(len(val)) > n {
+			fail("maxItems", "must have at most %v items", n)
+		}
+		if unique, _ := s["uniqueItems"].(bool); unique {
+			for i := range val {
+				for j := i + 1; j < len(val); j++ {
+					if schemaEqual(val[i], val[j]) {
+						fail("uniqueItems", "items %d and %d are equal", i, j)
+					}
+				}
+			}
+		}
+		// prefixItems (2020-12) and an items array (draft-07) check items by
+		// position; items or additionalItems then apply to the rest.
+		prefix, _ := s["prefixItems"].([]any)
+		rest, hasRest := s["items"]
+		if tuple, ok := rest.([]any); ok {
+			prefix = tuple
+			rest, hasRest = s["additionalItems"]
+		}
+		for i, item := range val {
+			child := fmt.Sprintf("%s/%d", path, i)
+			switch {
+			case i < len(prefix):
+				errs = append(errs, sv.validate(prefix[i], item, child)...)
+			case hasRest:
+				errs = append(errs, sv.validate(rest, item, child)...)
+			}
+		}
+		if contains, ok := s["contains"]; ok {
+			matches := 0
+			for _, item := range val {
+				if sv.valid(contains, item) {
+					matches++
+				}
+			}
+			minContains, hasMin := schemaNumber(s["minContains"])
+			if !hasMin {
+				minContains = 1
+			}
+			if float64(matches) < minContains {
+				fail("contains", "must contain at least %v matching items, found %d", minContains, matches)
+			}
+			if n, ok := schemaNumber(s["maxContains"]); ok && float64(matches) > n {
+				fail("maxContains", "must contain at most %v matching items, found %d", n, matches)
+			}
+		}
+	case map[string]any:
+		if required, ok := s["required"].([]any); ok {
+			for _, r := range required {
+				if name, ok := r.(string); ok {
+					if _, present := val[name]; !present {
+						errs = append(errs, SchemaError{Path: path, Keyword: "required", Message: fmt.Sprintf("missing required property %q", name), Property: name})
+					}
+				}
+			}
+		}
+		if n, ok := schemaNumber(s["minProperties"]); ok && float64(len(val)) < n {
+			fail("minProperties", "must have at least %v properties", n)
+		}
+		if n, ok := schemaNumber(s["maxProperties"]); ok && float64(len(val)) > n {
+			fail("maxProperties", "must have at most %v properties", n)
+		}
+		props, _ := s["properties"].(map[string]any)
+		patterns, _ := s["patternProperties"].(map[string]any)
+		keys := make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			child := path + "/" + schemaPointerEscape(k)
+			matched := false
+			if sub, ok := props[k]; ok {
+				errs = append(errs, sv.validate(sub, val[k], child)...)
+				matched = true
+			}
+			for pattern, sub := range patterns {
+				if re, err := regexp.Compile(pattern); err == nil && re.MatchString(k) {
+					errs = append(errs, sv.validate(sub, val[k], child)...)
+					matched = true
+				}
+			}
+			if matched {
+				continue
+			}
+			switch extra := s["additionalProperties"].(type) {
+			case bool:
+				if !extra {
+					errs = append(errs, SchemaError{Path: child, Keyword: "additionalProperties", Message: fmt.Sprintf("property %q is not allowed", k), Property: k})
+				}
+			case map[string]any:
+				errs = append(errs, sv.validate(extra, val[k], child)...)
+			}
+		}
+	}
+	return errs
+}
+
+// combinators applies allOf, anyOf, oneOf, not and if/then/else. Only
+// allOf reports the errors of its branches; the others report one error
+// saying how many branches matched.
+func (sv *schemaValidator) combinators(s map[string]any, v any, path string) []SchemaError {
+	var errs []SchemaError
+	fail := func(keyword, format string, args ...any) {
+		errs = append(errs, SchemaError{Path: path, Keyword: keyword, Message: fmt.Sprintf(format, args...)})
+	}
+	if all, ok := s["allOf"].([]any); ok {
+		for _, sub := range all {
+			errs = append(errs, sv.validate(sub, v, path)...)
+		}
+	}
+	if anyOf, ok := s["anyOf"].([]any); ok && !slices.ContainsFunc(anyOf, func(sub any) bool { return sv.valid(sub, v) }) {
+		fail("anyOf", "must match at least one schema in anyOf")
+	}
+	if oneOf, ok := s["oneOf"].([]any); ok {
+		matches := 0
+		for _, sub := range oneOf {
+			if sv.valid(sub, v) {
+				matches++
+			}
+		}
+		if matches != 1 {
+			fail("oneOf", "must match exactly one schema in oneOf, matched %d", matches)
+		}
+	}
+	if not, ok := s["not"]; ok && sv.valid(not, v) {
+		fail("not", "must not match the schema in not")
+	}
+	if cond, ok := s["if"]; ok {
+		branch := "else"
+		if sv.valid(cond, v) {
+			branch = "then"
+		}
+		if sub, ok := s[branch]; ok {
+			errs = append(errs, sv.validate(sub, v, path)...)
+		}
+	}
+	return errs
+}
+
+func (sv *schemaValidator) valid(schema any, v any) bool {
+	return len(sv.validate(schema, v, "")) == 0
+}
+
+// resolve follows a local reference such as "#", "#/definitions/user" or
+// "#/$defs/user"; references to other documents are not fetched.
+func (sv *schemaValidator) resolve(ref string) (any, error) {
+	if !strings.HasPrefix(ref, "#") {
+		return nil, fmt.Errorf("cannot resolve external reference %s", ref)
+	}
+	node, ok := followJSONPointer(sv.root, strings.TrimPrefix(ref, "#"))
+	if !ok {
+		return nil, fmt.Errorf("unresolved reference %s", ref)
+	}
+	return node, nil
+}
+
+// followJSONPointer walks an RFC 6901 pointer such as "/a/0/b~1c"; the
+// empty pointer is the root.
+func followJSONPointer(root any, pointer string) (any, bool) {
+	if pointer == "" {
+		return root, true
+	}
+	node := root
+	for _, part := range strings.Split(strings.TrimPrefix(pointer, "/"), "/") {
+		if unescaped, err := url.PathUnescape(part); err == nil {
+			part = unescaped
+		}
+		part = strings.ReplaceAll(strings.ReplaceAll(part, "~1", "/"), "~0", "~")
+		var next any
+		switch n := node.(type) {
+		case map[string]any:
+			next = n[part]
+		case []any:
+			if i, err := strconv.Atoi(part); err == nil && i >= 0 && i < len(n) {
+				next = n[i]
+			}
+		}
+		if next == nil {
+			return nil, false
+		}
+		node = next
+	}
+	return node, true
+}
+
+func schemaTypeMatches(t any, v any) bool {
+	switch typ := t.(type) {
+	case string:
+		switch typ {
+		case "integer":
+			f, ok := v.(float64)
+			return ok && f == math.Trunc(f)
+		case "number":
+			_, ok := v.(float64)
+			return ok
+		}
+		return schemaTypeOf(v) == typ
+	case []any:
+		return slices.ContainsFunc(typ, func(one any) bool { return schemaTypeMatches(one, v) })
+	}
+	return true
+}
+
+func schemaTypeOf(v any) string {
+	switch val := exprNormalize(v).(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		if val == math.Trunc(val) {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+func schemaTypeNames(t any) string {
+	if list, ok := t.([]any); ok {
+		names := make([]string, len(list))
+		for i, one := range list {
+			names[i] = fmt.Sprint(one)
+		}
+		return strings.Join(names, " or ")
+	}
+	return fmt.Sprint(t)
+}
+
+func schemaNumber(v any) (float64, bool) {
+	f, ok := exprNormalize(v).(float64)
+	return f, ok
+}
+
+func schemaEqual(a, b any) bool {
+	a, b = exprNormalize(a), exprNormalize(b)
+	switch av := a.(type) {
+	case []any:
+		bv, ok := b.([]any)
+		return ok && slices.EqualFunc(av, bv, schemaEqual)
+	case map[string]any:
+		bv, ok := b.(map[string]any)
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for k, v := range av {
+			if other, ok := bv[k]; !ok || !schemaEqual(v, other) {
+				return false
+			}
+		}
+		return true
+	}
+	return a == b
+}
+
+func schemaList(values []any) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		if s, ok := v.(string); ok {
+			parts[i] = fmt.Sprintf("%q", s)
+		} else {
+			parts[i] = exprText(v)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// schemaFormatMatches checks the common string formats; unknown formats
+// are accepted as the specification allows.
+func schemaFormatMatches(format, s string) bool {
+	switch format {
+	case "email":
+		addr, err := mail.ParseAddress(s)
+		return err == nil && addr.Address == s
+	case "date":
+		_, err := time.Parse(time.DateOnly, s)
+		return err == nil
+	case "date-time":
+		_, err := time.Parse(time.RFC3339, s)
+		return err == nil
+	case "time":
+		_, err := time.Parse("15:04:05Z07:00", s)
+		if err != nil {
+			_, err = time.Parse(time.TimeOnly, s)
+		}
+		return err == nil
+	case "uri", "url":
+		u, err := url.Parse(s)
+		return err == nil && u.Scheme != ""
+	case "uuid":
+		return schemaUUIDPattern().MatchString(s)
+	case "ipv4":
+		return parseIPv4(s) != nil && !strings.Contains(s, ":")
+	case "ipv6":
+		_, err := parseIPv6(s)
+		return err == nil
+	}
+	return true
+}
+
+func schemaPointerEscape(key string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(key, "~", "~0"), "/", "~1")
+}
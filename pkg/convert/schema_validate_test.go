@@ -0,0 +1,85 @@
+package convert
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const sampleValidationSchema = `{
+	"$schema": "https://json-schema.org/draft/2020-12/schema",
+	"type": "object",
+	"required": ["id", "owner"],
+	"properties": {
+		"id": {"type": "integer", "minimum": 1},
+		"owner": {"$ref": "#/$defs/user"},
+		"tags": {"type": "array", "items": {"type": "string"}, "contains": {"const": "prod"}},
+		"point": {"prefixItems": [{"type": "number"}, {"type": "number"}], "items": false},
+		"contact": {"oneOf": [{"format": "email"}, {"pattern": "^\\+\\d+$"}]}
+	},
+	"patternProperties": {"^x-": {"type": "string"}},
+	"additionalProperties": false,
+	"$defs": {
+		"user": {
+			"type": "object",
+			"required": ["name"],
+			"properties": {"name": {"type": "string"}, "manager": {"$ref": "#/$defs/user"}},
+			"if": {"required": ["manager"]},
+			"then": {"minProperties": 3}
+		}
+	}
+}`
+
+func TestValidateAgainstSchema(t *testing.T) {
+	errs, err := ValidateAgainstSchema(sampleValidationSchema, `{
+		"id": 1,
+		"owner": {"name": "a", "manager": {"name": "b"}, "team": "x"},
+		"tags": ["prod", "eu"],
+		"point": [1.5, 2],
+		"contact": "a@example.com",
+		"x-trace": "abc"
+	}`)
+	require.NoError(t, err)
+	require.Empty(t, errs)
+
+	errs, err = ValidateAgainstSchema(sampleValidationSchema, `{
+		"id": 0,
+		"owner": {"manager": {"name": 5}},
+		"tags": ["dev"],
+		"point": [1, 2, 3],
+		"contact": "nobody",
+		"x-trace": 1,
+		"extra": true
+	}`)
+	require.NoError(t, err)
+	require.Equal(t, []SchemaError{
+		{Path: "/contact", Keyword: "oneOf", Message: "must match exactly one schema in oneOf, matched 0"},
+		{Path: "/extra", Keyword: "additionalProperties", Message: `property "extra" is not allowed`, Property: "extra"},
+		{Path: "/id", Keyword: "minimum", Message: "must be >= 1"},
+		{Path: "/owner", Keyword: "minProperties", Message: "must have at least 3 properties"},
+		{Path: "/owner", Keyword: "required", Message: `missing required property "name"`, Property: "name"},
+		{Path: "/owner/manager/name", Keyword: "type", Message: "expected string, got integer"},
+		{Path: "/point/2", Keyword: "false", Message: "no value is allowed here"},
+		{Path: "/tags", Keyword: "contains", Message: "must contain at least 1 matching items, found 0"},
+		{Path: "/x-trace", Keyword: "type", Message: "expected string, got integer"},
+	}, errs)
+}
+
+func TestValidateAgainstSchema_Inputs(t *testing.T) {
+	errs, err := ValidateAgainstSchema("type: object\nrequired: [name]\n", "name: x\n")
+	require.NoError(t, err)
+	require.Empty(t, errs)
+
+	errs, err = ValidateAgainstSchema(`{"$ref": "other.json#/a"}`, `{}`)
+	require.NoError(t, err)
+	require.Equal(t, "$ref", errs[0].Keyword)
+
+	errs, err = ValidateAgainstSchema(`{"$ref": "#"}`, `1`)
+	require.NoError(t, err)
+	require.Equal(t, "$ref", errs[0].Keyword)
+
+	_, err = ValidateAgainstSchema(`[1]`, `{}`)
+	require.Error(t, err)
+	_, err = ValidateAgainstSchema(`{}`, ``)
+	require.ErrorContains(t, err, "document")
+}
@@ -0,0 +1,204 @@
+package convert
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"go.starlark.net/starlark"
+	"go.starlark.net/syntax"
+)
+
+// ScriptLimits bound the work a transform script may do. MaxSteps counts
+// the steps of the Starlark interpreter, plus the values measured when an
+// operation forces a measurement of memory. MaxMemory approximates the
+// bytes held by the strings, lists and dicts the script can reach: it is
+// measured every few steps, and operations whose results grow with their
+// operands, such as repetition, concatenation, slicing, join, replace or
+// list, are checked against it before they allocate. Zero fields take the
+// values of DefaultScriptLimits.
+type ScriptLimits struct {
+	MaxSteps  int `json:"maxSteps"`
+	MaxMemory int `json:"maxMemory"`
+}
+
+// DefaultScriptLimits apply to the Script convert option.
+var DefaultScriptLimits = ScriptLimits{MaxSteps: 1_000_000, MaxMemory: 32 << 20}
+
+// RunScript runs a Starlark transform script over the JSON document input
+// and returns the result as JSON. The script must define transform(value),
+// which receives the decoded document and returns the new one; objects
+// keep their key order, and keys the script adds go last:
+//
+//	def transform(value):
+//	    for user in value["users"]:
+//	        user["name"] = user["name"].title()
+//	    return [u for u in value["users"] if u.get("active")]
+//
+// Scripts get the standard Starlark builtins and nothing else, so they
+// cannot read files, the network or the clock; load statements fail and
+// print is discarded. Dict keys must be strings in the result. A script
+// that runs past limits stops with an error, and an operation that would
+// take it past MaxMemory fails before it allocates.
+func RunScript(script, input string, limits ScriptLimits) (string, error) {
+	doc, err := parseJSONDoc(input)
+	if err != nil {
+		return "", err
+	}
+	if limits.MaxSteps <= 0 {
+		limits.MaxSteps = DefaultScriptLimits.MaxSteps
+	}
+	if limits.MaxMemory <= 0 {
+		limits.MaxMemory = DefaultScriptLimits.MaxMemory
+	}
+	thread := &starlark.Thread{Name: "transform"}
+	budget := &scriptBudget{limits: limits}
+	thread.OnMaxSteps = budget.check
+	thread.SetMaxExecutionSteps(scriptCheckSteps)
+
+	opts := &syntax.FileOptions{}
+	f, err := opts.Parse("transform.star", script, 0)
+	if err != nil {
+		return "", budget.wrap(err)
+	}
+	(&scriptRewriter{}).file(f)
+	predeclared := budget.builtins()
+	prog, err := starlark.FileProgram(f, predeclared.Has)
+	if err != nil {
+		return "", budget.wrap(err)
+	}
+	globals, err := prog.Init(thread, predeclared)
+	globals.Freeze()
+	if err != nil {
+		return "", budget.wrap(err)
+	}
+	fn, ok := globals["transform"].(starlark.Callable)
+	if !ok {
+		return "", errors.New("script must define transform(value)")
+	}
+	value, err := scriptFromDoc(doc)
+	if err != nil {
+		return "", err
+	}
+	out, err := starlark.Call(thread, fn, starlark.Tuple{value}, nil)
+	if err != nil {
+		return "", budget.wrap(err)
+	}
+	result, err := scriptToDoc(&docBuilder{}, out, 0)
+	if err != nil {
+		return "", err
+	}
+	return encodeDoc(result)
+}
+
+// scriptFromDoc converts a document into Starlark values: objects become
+// dicts in key order, integers ints and other numbers floats.
+func scriptFromDoc(n *docNode) (starlark.Value, error) {
+	switch n.kind {
+	case docBool:
+		return starlark.Bool(n.text == "true"), nil
+	case docNumber:
+		if !strings.ContainsAny(n.text, ".eE") {
+			if i, err := strconv.ParseInt(n.text, 10, 64); err == nil {
+				return starlark.MakeInt64(i), nil
+			}
+			if i, ok := new(big.Int).SetString(n.text, 10); ok {
+				return starlark.MakeBigInt(i), nil
+			}
+		}
+		f, err := strconv.ParseFloat(n.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("number %s: %w", n.text, err)
+		}
+		return starlark.Float(f), nil
+	case docString:
+		return starlark.String(n.text), nil
+	case docArray:
+		items := make([]starlark.Value, len(n.items))
+		for i, item := range n.items {
+			v, err := scriptFromDoc(item)
+			if err != nil {
+				return nil, err
+			}
+			items[i] = v
+		}
+		return starlark.NewList(items), nil
+	case docObject:
+		dict := starlark.NewDict(len(n.keys))
+		for i, k := range n.keys {
+			v, err := scriptFromDoc(n.items[i])
+			if err != nil {
+				return nil, err
+			}
+			if err := dict.SetKey(starlark.String(k.name), v); err != nil {
+				return nil, err
+			}
+		}
+		return dict, nil
+	}
+	return starlark.None, nil
+}
+
+var errScriptNesting = errors.New("value nests too deeply")
+
+// scriptToDoc converts the value a script returned into a document. Lists
+// that contain themselves nest too deeply.
+func scriptToDoc(b *docBuilder, v starlark.Value, depth int) (*docNode, error) {
+	if depth > docMaxDepth {
+		return nil, errScriptNesting
+	}
+	switch v := v.(type) {
+	case starlark.NoneType:
+		return b.null(), nil
+	case starlark.Bool:
+		return b.boolean(bool(v)), nil
+	case starlark.Int:
+		return b.number(v.String()), nil
+	case starlark.Float:
+		f := float64(v)
+		if math.IsInf(f, 0) || math.IsNaN(f) {
+			return nil, fmt.Errorf("cannot return %s", v)
+		}
+		return docScalar(&b.arena, f, docPos{})
+	case starlark.String:
+		return b.str(string(v)), nil
+	case starlark.Tuple:
+		return scriptItemsToDoc(b, v, depth)
+	case *starlark.List:
+		items := make([]starlark.Value, 0, v.Len())
+		for item := range v.Elements() {
+			items = append(items, item)
+		}
+		return scriptItemsToDoc(b, items, depth)
+	case *starlark.Dict:
+		obj := b.object()
+		for k, item := range v.Entries() {
+			key, ok := k.(starlark.String)
+			if !ok {
+				return nil, fmt.Errorf("dict keys must be strings, not %s", k.Type())
+			}
+			n, err := scriptToDoc(b, item, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			b.set(obj, string(key), n)
+		}
+		return obj, nil
+	}
+	return nil, fmt.Errorf("cannot return a %s", v.Type())
+}
+
+func scriptItemsToDoc(b *docBuilder, items []starlark.Value, depth int) (*docNode, error) {
+	arr := b.array()
+	for _, item := range items {
+		n, err := scriptToDoc(b, item, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		arr.items = append(arr.items, n)
+	}
+	return arr, nil
+}
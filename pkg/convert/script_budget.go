@@ -0,0 +1,644 @@
+package convert
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"go.starlark.net/resolve"
+	"go.starlark.net/starlark"
+	"go.starlark.net/syntax"
+)
+
+// scriptCheckSteps is the fewest steps between two checks of a script's
+// memory.
+const scriptCheckSteps = 16
+
+// scriptBudget enforces ScriptLimits on a running thread. The interpreter
+// counts steps; every so often check measures the values the thread can
+// reach, waiting longer after larger measurements so that measuring costs
+// about as much as running. In between, alloc admits each operation whose
+// result grows with its operands, which a single step could otherwise
+// make as large as it likes.
+type scriptBudget struct {
+	limits    ScriptLimits
+	measured  int // bytes reachable at the last measurement
+	allocated int // bytes admitted by alloc since then
+	work      int // values measured by alloc, charged as steps
+	err       error
+}
+
+func (b *scriptBudget) check(thread *starlark.Thread) {
+	if b.stepsExceeded(thread) {
+		b.stop(thread, b.stepsError())
+		return
+	}
+	m := b.measure(thread)
+	if m.size > b.limits.MaxMemory {
+		b.stop(thread, b.memoryError())
+		return
+	}
+	next := thread.ExecutionSteps() + uint64(max(scriptCheckSteps, m.values/4))
+	thread.SetMaxExecutionSteps(min(next, uint64(b.limits.MaxSteps-b.work)))
+}
+
+// alloc admits an operation about to allocate about n bytes. While the
+// bytes admitted since the last measurement fit in what it left over, it
+// only counts them; past that it measures again, so that garbage stops
+// counting, and fails when n does not fit.
+func (b *scriptBudget) alloc(thread *starlark.Thread, n int) error {
+	if b.err != nil || n <= 0 {
+		return b.err
+	}
+	b.allocated = scriptAdd(b.allocated, n)
+	if b.allocated <= b.limits.MaxMemory-b.measured {
+		return nil
+	}
+	m := b.measure(thread)
+	b.work += m.values
+	b.allocated = n
+	switch {
+	case n > b.limits.MaxMemory-m.size:
+		b.err = b.memoryError()
+	case b.stepsExceeded(thread):
+		b.err = b.stepsError()
+	}
+	return b.err
+}
+
+func (b *scriptBudget) measure(thread *starlark.Thread) scriptMeasure {
+	var m scriptMeasure
+	m.thread(thread)
+	b.measured, b.allocated = m.size, 0
+	return m
+}
+
+func (b *scriptBudget) stepsExceeded(thread *starlark.Thread) bool {
+	return b.work >= b.limits.MaxSteps || thread.ExecutionSteps() >= uint64(b.limits.MaxSteps-b.work)
+}
+
+func (b *scriptBudget) stepsError() error {
+	return fmt.Errorf("script exceeded its limit of %d steps", b.limits.MaxSteps)
+}
+
+func (b *scriptBudget) memoryError() error {
+	return fmt.Errorf("script exceeded its limit of %d bytes", b.limits.MaxMemory)
+}
+
+func (b *scriptBudget) stop(thread *starlark.Thread, err error) {
+	b.err = err
+	thread.Cancel(err.Error())
+}
+
+// wrap reports err, from running a script, with the line it happened on.
+func (b *scriptBudget) wrap(err error) error {
+	var syntaxErr syntax.Error
+	if errors.As(err, &syntaxErr) {
+		return fmt.Errorf("line %d: %s", syntaxErr.Pos.Line, syntaxErr.Msg)
+	}
+	var resolveErrs resolve.ErrorList
+	if errors.As(err, &resolveErrs) && len(resolveErrs) > 0 {
+		return fmt.Errorf("line %d: %s", resolveErrs[0].Pos.Line, resolveErrs[0].Msg)
+	}
+	var evalErr *starlark.EvalError
+	if errors.As(err, &evalErr) {
+		msg := evalErr.Msg
+		if b.err != nil {
+			msg = b.err.Error()
+		}
+		// The innermost frame with a line is the script's; builtins
+		// have none.
+		for i := len(evalErr.CallStack) - 1; i >= 0; i-- {
+			if pos := evalErr.CallStack.At(i).Pos; pos.Line > 0 {
+				return fmt.Errorf("line %d: %s", pos.Line, msg)
+			}
+		}
+		return errors.New(msg)
+	}
+	if b.err != nil {
+		return b.err
+	}
+	return err
+}
+
+// builtins returns what a script sees besides the universe: the checked
+// operations scriptRewriter calls, and checked versions of the builtins
+// that copy their arguments. print does nothing, not even format.
+func (b *scriptBudget) builtins() starlark.StringDict {
+	d := starlark.StringDict{
+		"$binary":  starlark.NewBuiltin("$binary", b.binary),
+		"$inplace": starlark.NewBuiltin("$inplace", b.inplace),
+		"$attr":    starlark.NewBuiltin("$attr", b.attr),
+		"$sized":   starlark.NewBuiltin("$sized", b.sized),
+		"getattr":  starlark.NewBuiltin("getattr", b.getattr),
+		"print": starlark.NewBuiltin("print", func(*starlark.Thread, *starlark.Builtin, starlark.Tuple, []starlark.Tuple) (starlark.Value, error) {
+			return starlark.None, nil
+		}),
+	}
+	for name, size := range scriptBuiltinSizes {
+		d[name] = b.checked(name, starlark.Universe[name], func(args starlark.Tuple, kwargs []starlark.Tuple) int {
+			return size(args, kwargs, b.limits.MaxMemory)
+		})
+	}
+	return d
+}
+
+// checked returns fn behind a check that its result, of the size the
+// function reports for the arguments, fits the budget.
+func (b *scriptBudget) checked(name string, fn starlark.Value, size func(starlark.Tuple, []starlark.Tuple) int) *starlark.Builtin {
+	return starlark.NewBuiltin(name, func(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		if err := b.alloc(thread, size(args, kwargs)); err != nil {
+			return nil, err
+		}
+		return starlark.Call(thread, fn, args, kwargs)
+	})
+}
+
+// binary is $binary(op, x, y).
+func (b *scriptBudget) binary(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, _ []starlark.Tuple) (starlark.Value, error) {
+	op, x, y := scriptOp(args[0]), args[1], args[2]
+	if err := b.alloc(thread, scriptBinarySize(op, x, y, b.limits.MaxMemory)); err != nil {
+		return nil, err
+	}
+	return starlark.Binary(op, x, y)
+}
+
+// inplace is $inplace(op, x, y): x += y extends a list x and x |= y
+// updates a dict x, as the interpreter does, and other operators are
+// binary.
+func (b *scriptBudget) inplace(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	op, x, y := scriptOp(args[0]), args[1], args[2]
+	if list, ok := x.(*starlark.List); ok && op == syntax.PLUS {
+		if items, ok := y.(starlark.Iterable); ok {
+			if err := b.alloc(thread, scriptMul(8, starlark.Len(y))); err != nil {
+				return nil, err
+			}
+			// Collect first, so that x += x ends.
+			var values []starlark.Value
+			for v := range starlark.Elements(items) {
+				values = append(values, v)
+			}
+			for _, v := range values {
+				if err := list.Append(v); err != nil {
+					return nil, err
+				}
+			}
+			return list, nil
+		}
+	}
+	if dict, ok := x.(*starlark.Dict); ok && op == syntax.PIPE {
+		if other, ok := y.(*starlark.Dict); ok {
+			if err := b.alloc(thread, scriptMul(32, other.Len())); err != nil {
+				return nil, err
+			}
+			for k, v := range other.Entries() {
+				if err := dict.SetKey(k, v); err != nil {
+					return nil, err
+				}
+			}
+			return dict, nil
+		}
+	}
+	return b.binary(thread, fn, args, kwargs)
+}
+
+// sized is $sized(x), which admits a copy of x before it is sliced.
+func (b *scriptBudget) sized(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, _ []starlark.Tuple) (starlark.Value, error) {
+	if err := b.alloc(thread, scriptSize(args[0])); err != nil {
+		return nil, err
+	}
+	return args[0], nil
+}
+
+// attr is $attr(x, name), the field or method name of x.
+func (b *scriptBudget) attr(_ *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, _ []starlark.Tuple) (starlark.Value, error) {
+	x, name := args[0], string(args[1].(starlark.String))
+	v, err := b.method(x, name)
+	if v == nil && err == nil {
+		err = fmt.Errorf("%s has no .%s field or method", x.Type(), name)
+	}
+	return v, err
+}
+
+func (b *scriptBudget) getattr(_ *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var x, dflt starlark.Value
+	var name string
+	if err := starlark.UnpackPositionalArgs(fn.Name(), args, kwargs, 2, &x, &name, &dflt); err != nil {
+		return nil, err
+	}
+	v, err := b.method(x, name)
+	switch {
+	case v == nil && dflt != nil:
+		return dflt, nil
+	case v == nil && err == nil:
+		err = fmt.Errorf("%s has no .%s field or method", x.Type(), name)
+	}
+	return v, err
+}
+
+// method returns the attribute name of x, or nil. Methods whose results
+// grow with the receiver or the arguments come back checked.
+func (b *scriptBudget) method(x starlark.Value, name string) (starlark.Value, error) {
+	h, ok := x.(starlark.HasAttrs)
+	if !ok {
+		return nil, nil
+	}
+	v, err := h.Attr(name)
+	if _, ok := v.(*starlark.Builtin); !ok || err != nil || scriptPlainMethods[name] {
+		return v, err
+	}
+	return b.checked(name, v, func(args starlark.Tuple, kwargs []starlark.Tuple) int {
+		return scriptMethodSize(x, name, args, kwargs, b.limits.MaxMemory)
+	}), nil
+}
+
+func scriptOp(v starlark.Value) syntax.Token {
+	n, _ := starlark.AsInt32(v)
+	return syntax.Token(n)
+}
+
+// scriptPlainMethods allocate nothing, or a little whatever their
+// operands.
+var scriptPlainMethods = map[string]bool{
+	"get": true, "index": true, "rindex": true, "find": true, "rfind": true, "count": true,
+	"startswith": true, "endswith": true, "isalnum": true, "isalpha": true, "isdigit": true,
+	"islower": true, "isspace": true, "istitle": true, "isupper": true,
+	"append": true, "insert": true, "pop": true, "popitem": true, "remove": true, "clear": true,
+	"setdefault": true, "add": true, "discard": true,
+	"issubset": true, "issuperset": true, "isdisjoint": true,
+	"elems": true, "elem_ords": true, "codepoints": true, "codepoint_ords": true,
+}
+
+// scriptBuiltinSizes are the universe builtins that copy their
+// arguments, with the size of what they return.
+var scriptBuiltinSizes = map[string]func(args starlark.Tuple, kwargs []starlark.Tuple, limit int) int{
+	"list":      scriptCopySize(16),
+	"tuple":     scriptCopySize(16),
+	"set":       scriptCopySize(24),
+	"sorted":    scriptCopySize(16),
+	"reversed":  scriptCopySize(16),
+	"bytes":     scriptCopySize(1),
+	"enumerate": scriptCopySize(48),
+	"dict": func(args starlark.Tuple, kwargs []starlark.Tuple, _ int) int {
+		return 16 + scriptMul(32, scriptArgLen(args)+len(kwargs))
+	},
+	"zip": func(args starlark.Tuple, _ []starlark.Tuple, _ int) int {
+		n := -1
+		for _, arg := range args {
+			if l := starlark.Len(arg); n < 0 || l < n {
+				n = l
+			}
+		}
+		return 16 + scriptMul(24+8*len(args), n)
+	},
+	"str": func(args starlark.Tuple, _ []starlark.Tuple, limit int) int {
+		if len(args) == 0 {
+			return 0
+		}
+		if _, ok := args[0].(starlark.String); ok {
+			return 0
+		}
+		return scriptTextSize(args[0], limit)
+	},
+	"repr": func(args starlark.Tuple, _ []starlark.Tuple, limit int) int {
+		if len(args) == 0 {
+			return 0
+		}
+		return scriptTextSize(args[0], limit)
+	},
+	"fail": func(args starlark.Tuple, _ []starlark.Tuple, limit int) int {
+		n := 0
+		for _, arg := range args {
+			n = scriptAdd(n, scriptTextSize(arg, limit))
+		}
+		return n
+	},
+}
+
+// scriptCopySize sizes a builtin that copies the elements of its first
+// argument, taking per bytes for each.
+func scriptCopySize(per int) func(starlark.Tuple, []starlark.Tuple, int) int {
+	return func(args starlark.Tuple, _ []starlark.Tuple, _ int) int {
+		return 16 + scriptMul(per, scriptArgLen(args))
+	}
+}
+
+func scriptArgLen(args starlark.Tuple) int {
+	if len(args) == 0 {
+		return 0
+	}
+	return max(starlark.Len(args[0]), 0)
+}
+
+// scriptMethodSize approximates the bytes the method name of x returns
+// or adds for args. Methods not listed copy about their operands.
+func scriptMethodSize(x starlark.Value, name string, args starlark.Tuple, kwargs []starlark.Tuple, limit int) int {
+	s, _ := x.(starlark.String)
+	arg := func(i int) string {
+		if i < len(args) {
+			if v, ok := args[i].(starlark.String); ok {
+				return string(v)
+			}
+		}
+		return ""
+	}
+	switch name {
+	case "join":
+		if len(args) == 0 {
+			return 0
+		}
+		iter, ok := args[0].(starlark.Iterable)
+		if !ok {
+			return 0
+		}
+		n := 16
+		for v := range starlark.Elements(iter) {
+			item, _ := v.(starlark.String)
+			if n = scriptAdd(n, len(s)+len(item)); n > limit {
+				break
+			}
+		}
+		return n
+	case "replace":
+		old, repl := arg(0), arg(1)
+		count := strings.Count(string(s), old)
+		if len(args) > 2 {
+			if k, err := starlark.AsInt32(args[2]); err == nil && k >= 0 {
+				count = min(count, k)
+			}
+		}
+		return 16 + len(s) + scriptMul(count, max(len(repl)-len(old), 0))
+	case "format":
+		widest := 0
+		for _, v := range args {
+			widest = max(widest, scriptTextSize(v, limit))
+		}
+		for _, kv := range kwargs {
+			widest = max(widest, scriptTextSize(kv[1], limit))
+		}
+		return 16 + len(s) + scriptMul(strings.Count(string(s), "{"), widest)
+	case "split", "rsplit", "splitlines":
+		parts := len(s)/2 + 1
+		if sep := arg(0); name != "splitlines" && sep != "" {
+			parts = strings.Count(string(s), sep) + 1
+		}
+		return 16 + len(s) + scriptMul(24, parts)
+	case "items":
+		return 16 + scriptMul(40, starlark.Len(x))
+	case "keys", "values":
+		return 16 + scriptMul(8, starlark.Len(x))
+	case "extend":
+		return scriptMul(8, scriptArgLen(args))
+	case "update":
+		return scriptMul(32, scriptArgLen(args)+len(kwargs))
+	}
+	n := scriptSize(x)
+	for _, v := range args {
+		n = scriptAdd(n, scriptSize(v))
+	}
+	return n
+}
+
+// scriptBinarySize approximates the bytes x op y allocates.
+func scriptBinarySize(op syntax.Token, x, y starlark.Value, limit int) int {
+	switch op {
+	case syntax.PLUS:
+		switch x.(type) {
+		case starlark.String, starlark.Bytes, *starlark.List, starlark.Tuple, starlark.Int:
+			return scriptAdd(scriptSize(x), scriptSize(y))
+		}
+	case syntax.STAR:
+		seq, n := x, y
+		if _, ok := x.(starlark.Int); ok {
+			seq, n = y, x
+		}
+		times, ok := n.(starlark.Int)
+		if !ok {
+			return 0
+		}
+		switch seq.(type) {
+		case starlark.String, starlark.Bytes, *starlark.List, starlark.Tuple:
+			k, ok := times.Int64()
+			if !ok || k > math.MaxInt32 {
+				return math.MaxInt
+			}
+			return 16 + scriptMul(scriptSize(seq)-16, int(k))
+		case starlark.Int:
+			return scriptAdd(scriptSize(x), scriptSize(y))
+		}
+	case syntax.PERCENT:
+		if format, ok := x.(starlark.String); ok {
+			widest := scriptTextSize(y, limit)
+			if args, ok := y.(starlark.Tuple); ok {
+				widest = 0
+				for _, v := range args {
+					widest = max(widest, scriptTextSize(v, limit))
+				}
+			}
+			return 16 + len(format) + scriptMul(strings.Count(string(format), "%"), widest)
+		}
+	case syntax.PIPE:
+		switch x.(type) {
+		case *starlark.Dict, *starlark.Set:
+			return scriptAdd(scriptSize(x), scriptSize(y))
+		}
+	}
+	return 0
+}
+
+// scriptSize approximates the bytes v holds itself, without the values
+// it contains, in the units of scriptMeasure.
+func scriptSize(v starlark.Value) int {
+	switch v := v.(type) {
+	case starlark.String:
+		return 16 + len(v)
+	case starlark.Bytes:
+		return 16 + len(v)
+	case starlark.Int:
+		return 16 + scriptIntSize(v)
+	case *starlark.Dict:
+		return 16 + 32*v.Len()
+	case *starlark.Set:
+		return 16 + 16*v.Len()
+	}
+	return 16 + 8*max(starlark.Len(v), 0)
+}
+
+// scriptIntSize is the bytes of an integer too large for an int64.
+func scriptIntSize(v starlark.Int) int {
+	if _, ok := v.Int64(); ok {
+		return 0
+	}
+	return v.BigInt().BitLen() / 8
+}
+
+// scriptTextSize bounds the length of str(v) or repr(v), giving up once
+// it passes limit.
+func scriptTextSize(v starlark.Value, limit int) int {
+	t := scriptText{limit: limit, open: map[starlark.Value]bool{}}
+	t.value(v)
+	return t.size
+}
+
+type scriptText struct {
+	size, limit int
+	open        map[starlark.Value]bool // containers being written
+}
+
+func (t *scriptText) value(v starlark.Value) {
+	if t.size > t.limit {
+		return
+	}
+	switch v := v.(type) {
+	case starlark.String:
+		// An escape takes up to four bytes.
+		t.size = scriptAdd(t.size, 2+scriptMul(4, len(v)))
+	case starlark.Bytes:
+		t.size = scriptAdd(t.size, 3+scriptMul(4, len(v)))
+	case starlark.Int:
+		t.size += 20 + 3*scriptIntSize(v)
+	case starlark.Tuple:
+		t.size += 2
+		for _, item := range v {
+			t.size += 2
+			t.value(item)
+		}
+	case *starlark.List, *starlark.Dict, *starlark.Set:
+		if t.open[v] {
+			t.size += 5
+			return
+		}
+		t.open[v] = true
+		defer delete(t.open, v)
+		t.size += 5
+		switch v := v.(type) {
+		case *starlark.List:
+			for item := range v.Elements() {
+				t.size += 2
+				t.value(item)
+			}
+		case *starlark.Dict:
+			for k, item := range v.Entries() {
+				t.size += 4
+				t.value(k)
+				t.value(item)
+			}
+		case *starlark.Set:
+			for item := range v.Elements() {
+				t.size += 2
+				t.value(item)
+			}
+		}
+	default:
+		t.size += 32
+	}
+}
+
+// scriptAdd and scriptMul saturate at math.MaxInt; a negative operand of
+// scriptMul, an unknown length, counts as zero.
+func scriptAdd(a, b int) int {
+	if a > math.MaxInt-b {
+		return math.MaxInt
+	}
+	return a + b
+}
+
+func scriptMul(a, b int) int {
+	if a <= 0 || b <= 0 {
+		return 0
+	}
+	if a > math.MaxInt/b {
+		return math.MaxInt
+	}
+	return a * b
+}
+
+// scriptMeasure approximates the memory of Starlark values, counting each
+// list, dict and set once however often it is reached.
+type scriptMeasure struct {
+	seen   map[starlark.Value]bool
+	size   int
+	values int
+}
+
+// thread measures the globals and the locals of every frame of thread.
+func (m *scriptMeasure) thread(thread *starlark.Thread) {
+	var globals starlark.StringDict
+	for i := range thread.CallStackDepth() {
+		fr := thread.DebugFrame(i)
+		fn, ok := fr.Callable().(*starlark.Function)
+		if !ok {
+			continue
+		}
+		if globals == nil {
+			globals = fn.Globals()
+			for _, v := range globals {
+				m.value(v)
+			}
+		}
+		for j := range fr.NumLocals() {
+			_, v := fr.Local(j)
+			m.value(v)
+		}
+		for j := range fn.NumFreeVars() {
+			_, v := fn.FreeVar(j)
+			m.value(v)
+		}
+	}
+}
+
+func (m *scriptMeasure) value(v starlark.Value) {
+	if v == nil {
+		return
+	}
+	m.values++
+	m.size += 16
+	switch v := v.(type) {
+	case starlark.String:
+		m.size += len(v)
+	case starlark.Bytes:
+		m.size += len(v)
+	case starlark.Int:
+		m.size += scriptIntSize(v)
+	case starlark.Tuple:
+		m.size += 8 * len(v)
+		for _, item := range v {
+			m.value(item)
+		}
+	case *starlark.List:
+		if m.mark(v) {
+			m.size += 8 * v.Len()
+			for item := range v.Elements() {
+				m.value(item)
+			}
+		}
+	case *starlark.Dict:
+		if m.mark(v) {
+			m.size += 32 * v.Len()
+			for k, item := range v.Entries() {
+				m.value(k)
+				m.value(item)
+			}
+		}
+	case *starlark.Set:
+		if m.mark(v) {
+			m.size += 16 * v.Len()
+			for item := range v.Elements() {
+				m.value(item)
+			}
+		}
+	}
+}
+
+// mark reports whether v is seen for the first time.
+func (m *scriptMeasure) mark(v starlark.Value) bool {
+	if m.seen[v] {
+		return false
+	}
+	if m.seen == nil {
+		m.seen = map[starlark.Value]bool{}
+	}
+	m.seen[v] = true
+	return true
+}
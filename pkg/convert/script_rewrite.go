@@ -0,0 +1,199 @@
+package convert
+
+import (
+	"strconv"
+
+	"go.starlark.net/syntax"
+)
+
+// scriptRewriter routes the operations of a parsed script whose results
+// grow with their operands through the checked builtins of scriptBudget:
+// x + y becomes $binary(PLUS, x, y), s.join(l) calls the method $attr
+// returns, x[i:j] slices $sized(x), and x += y becomes x = $inplace(PLUS,
+// x, y). The names start with $, which no identifier in a script can, and
+// each call sits at the position of the operation it replaces, so errors
+// keep their lines.
+type scriptRewriter struct {
+	temps int
+}
+
+func (w *scriptRewriter) file(f *syntax.File) {
+	f.Stmts = w.stmts(f.Stmts)
+}
+
+func (w *scriptRewriter) stmts(stmts []syntax.Stmt) []syntax.Stmt {
+	out := make([]syntax.Stmt, 0, len(stmts))
+	for _, s := range stmts {
+		out = append(out, w.stmt(s)...)
+	}
+	return out
+}
+
+func (w *scriptRewriter) stmt(s syntax.Stmt) []syntax.Stmt {
+	switch s := s.(type) {
+	case *syntax.AssignStmt:
+		s.RHS = w.expr(s.RHS)
+		if s.Op != syntax.EQ {
+			return w.augmented(s)
+		}
+		s.LHS = w.target(s.LHS)
+	case *syntax.DefStmt:
+		w.exprs(s.Params)
+		s.Body = w.stmts(s.Body)
+	case *syntax.ExprStmt:
+		s.X = w.expr(s.X)
+	case *syntax.ForStmt:
+		s.Vars, s.X = w.target(s.Vars), w.expr(s.X)
+		s.Body = w.stmts(s.Body)
+	case *syntax.WhileStmt:
+		s.Cond = w.expr(s.Cond)
+		s.Body = w.stmts(s.Body)
+	case *syntax.IfStmt:
+		s.Cond = w.expr(s.Cond)
+		s.True, s.False = w.stmts(s.True), w.stmts(s.False)
+	case *syntax.ReturnStmt:
+		if s.Result != nil {
+			s.Result = w.expr(s.Result)
+		}
+	}
+	return []syntax.Stmt{s}
+}
+
+// augmented turns x op= y into x = $inplace(op, x, y), which keeps the
+// in-place meaning of += on lists and |= on dicts. The operands of an
+// indexed or dotted target are saved in temporaries first, so they are
+// evaluated once, in the order they were.
+func (w *scriptRewriter) augmented(s *syntax.AssignStmt) []syntax.Stmt {
+	op := s.Op - syntax.PLUS_EQ + syntax.PLUS
+	lhs := s.LHS
+	for paren, ok := lhs.(*syntax.ParenExpr); ok; paren, ok = lhs.(*syntax.ParenExpr) {
+		lhs = paren.X
+	}
+	var out []syntax.Stmt
+	var target, value syntax.Expr
+	switch lhs := lhs.(type) {
+	case *syntax.Ident:
+		target, value = lhs, &syntax.Ident{NamePos: lhs.NamePos, Name: lhs.Name}
+	case *syntax.IndexExpr:
+		x, setX, getX := w.temp(lhs.X)
+		y, setY, getY := w.temp(lhs.Y)
+		out = append(out, setX, setY)
+		target = &syntax.IndexExpr{X: x, Lbrack: lhs.Lbrack, Y: y, Rbrack: lhs.Rbrack}
+		value = &syntax.IndexExpr{X: getX, Lbrack: lhs.Lbrack, Y: getY, Rbrack: lhs.Rbrack}
+	case *syntax.DotExpr:
+		x, setX, getX := w.temp(lhs.X)
+		out = append(out, setX)
+		target = &syntax.DotExpr{X: x, Dot: lhs.Dot, NamePos: lhs.NamePos, Name: lhs.Name}
+		value = &syntax.DotExpr{X: getX, Dot: lhs.Dot, NamePos: lhs.NamePos, Name: lhs.Name}
+	default:
+		// The resolver rejects any other target.
+		return []syntax.Stmt{s}
+	}
+	rhs := scriptCall(s.OpPos, "$inplace", scriptOpLiteral(op, s.OpPos), value, s.RHS)
+	return append(out, &syntax.AssignStmt{OpPos: s.OpPos, Op: syntax.EQ, LHS: target, RHS: rhs})
+}
+
+// temp returns a new temporary, the statement that sets it to the
+// rewritten e, and a second reference to it.
+func (w *scriptRewriter) temp(e syntax.Expr) (*syntax.Ident, syntax.Stmt, *syntax.Ident) {
+	w.temps++
+	pos, _ := e.Span()
+	name := "$t" + strconv.Itoa(w.temps)
+	set := &syntax.AssignStmt{OpPos: pos, Op: syntax.EQ, LHS: &syntax.Ident{NamePos: pos, Name: name}, RHS: w.expr(e)}
+	return &syntax.Ident{NamePos: pos, Name: name}, set, &syntax.Ident{NamePos: pos, Name: name}
+}
+
+// target rewrites the expressions an assignment target reads, leaving
+// the names, elements and fields it assigns.
+func (w *scriptRewriter) target(e syntax.Expr) syntax.Expr {
+	switch e := e.(type) {
+	case *syntax.IndexExpr:
+		e.X, e.Y = w.expr(e.X), w.expr(e.Y)
+	case *syntax.DotExpr:
+		e.X = w.expr(e.X)
+	case *syntax.ParenExpr:
+		e.X = w.target(e.X)
+	case *syntax.TupleExpr:
+		for i, item := range e.List {
+			e.List[i] = w.target(item)
+		}
+	case *syntax.ListExpr:
+		for i, item := range e.List {
+			e.List[i] = w.target(item)
+		}
+	}
+	return e
+}
+
+func (w *scriptRewriter) exprs(list []syntax.Expr) {
+	for i, e := range list {
+		list[i] = w.expr(e)
+	}
+}
+
+func (w *scriptRewriter) expr(e syntax.Expr) syntax.Expr {
+	switch e := e.(type) {
+	case *syntax.BinaryExpr:
+		e.X, e.Y = w.expr(e.X), w.expr(e.Y)
+		switch e.Op {
+		case syntax.PLUS, syntax.STAR, syntax.PERCENT, syntax.PIPE:
+			return scriptCall(e.OpPos, "$binary", scriptOpLiteral(e.Op, e.OpPos), e.X, e.Y)
+		}
+	case *syntax.CallExpr:
+		e.Fn = w.expr(e.Fn)
+		w.exprs(e.Args)
+	case *syntax.Comprehension:
+		for _, clause := range e.Clauses {
+			switch clause := clause.(type) {
+			case *syntax.ForClause:
+				clause.Vars, clause.X = w.target(clause.Vars), w.expr(clause.X)
+			case *syntax.IfClause:
+				clause.Cond = w.expr(clause.Cond)
+			}
+		}
+		e.Body = w.expr(e.Body)
+	case *syntax.CondExpr:
+		e.Cond, e.True, e.False = w.expr(e.Cond), w.expr(e.True), w.expr(e.False)
+	case *syntax.DictEntry:
+		e.Key, e.Value = w.expr(e.Key), w.expr(e.Value)
+	case *syntax.DictExpr:
+		w.exprs(e.List)
+	case *syntax.DotExpr:
+		return scriptCall(e.Dot, "$attr", w.expr(e.X), scriptStringLiteral(e.Name.Name, e.NamePos))
+	case *syntax.IndexExpr:
+		e.X, e.Y = w.expr(e.X), w.expr(e.Y)
+	case *syntax.LambdaExpr:
+		w.exprs(e.Params)
+		e.Body = w.expr(e.Body)
+	case *syntax.ListExpr:
+		w.exprs(e.List)
+	case *syntax.ParenExpr:
+		e.X = w.expr(e.X)
+	case *syntax.SliceExpr:
+		e.X = scriptCall(e.Lbrack, "$sized", w.expr(e.X))
+		for _, part := range []*syntax.Expr{&e.Lo, &e.Hi, &e.Step} {
+			if *part != nil {
+				*part = w.expr(*part)
+			}
+		}
+	case *syntax.TupleExpr:
+		w.exprs(e.List)
+	case *syntax.UnaryExpr:
+		if e.X != nil {
+			e.X = w.expr(e.X)
+		}
+	}
+	return e
+}
+
+func scriptCall(pos syntax.Position, name string, args ...syntax.Expr) *syntax.CallExpr {
+	return &syntax.CallExpr{Fn: &syntax.Ident{NamePos: pos, Name: name}, Lparen: pos, Args: args, Rparen: pos}
+}
+
+func scriptOpLiteral(op syntax.Token, pos syntax.Position) *syntax.Literal {
+	return &syntax.Literal{Token: syntax.INT, TokenPos: pos, Raw: strconv.Itoa(int(op)), Value: int64(op)}
+}
+
+func scriptStringLiteral(s string, pos syntax.Position) *syntax.Literal {
+	return &syntax.Literal{Token: syntax.STRING, TokenPos: pos, Raw: strconv.Quote(s), Value: s}
+}
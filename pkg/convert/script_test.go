@@ -0,0 +1,177 @@
+package convert
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunScript(t *testing.T) {
+	script := `
+# Keep active users, tidy their names and count them.
+def tidy(user):
+    user["name"] = user["name"].strip().title()
+    return user
+
+def transform(value):
+    users = [tidy(u) for u in value["users"] if u.get("active", False)]
+    total = 0
+    for i, u in enumerate(users):
+        u["rank"] = i + 1
+        u.pop("active")
+        total += u["score"]
+    return {
+        "count": len(users),
+        "users": sorted(users, key=lambda u: u["name"]),
+        "total": total / 2,
+    }
+`
+	input := `{"users":[{"name":" bob smith ","active":true,"score":3},{"name":"eve","active":false,"score":9},{"name":"alice","active":true,"score":4}]}`
+	out, err := RunScript(script, input, ScriptLimits{})
+	require.NoError(t, err)
+	require.Equal(t, `{
+  "count": 2,
+  "users": [
+    {
+      "name": "Alice",
+      "score": 4,
+      "rank": 2
+    },
+    {
+      "name": "Bob Smith",
+      "score": 3,
+      "rank": 1
+    }
+  ],
+  "total": 3.5
+}
+`, out)
+}
+
+func TestRunScript_Language(t *testing.T) {
+	cases := map[string]string{
+		`7 // 2, -7 // 2, 7 % 3, -7 % 3, 1 << 10, 7 / 2`:                         `[3,-4,1,2,1024,3.5]`,
+		`"a,b,,c".split(","), "-".join(["x", "y"]), "abc"[1:], "abc"[-1]`:        `[["a","b","","c"],"x-y","bc","c"]`,
+		`str(1.0), str(2), str([1, "a", None, True]), int("42"), float(3)`:       `["1.0","2","[1, \"a\", None, True]",42,3]`,
+		`"b" in {"a": 1, "b": 2}, 3 not in [1, 2], "ell" in "hello"`:             `[true,true,true]`,
+		`{k: v * 2 for k, v in {"x": 1, "y": 2}.items() if v > 1}`:               `{"y":4}`,
+		`max([3, 9, 4]), min("b", "a"), abs(-2), int(3.9), 12345678901234567890`: `[9,"a",2,3,12345678901234567890]`,
+		`list(range(3)), list(reversed([1, 2])), zip(["a", "b"], [1, 2])`:        `[[0,1,2],[2,1],[["a",1],["b",2]]]`,
+		`"yes" if value else "no", value or "fallback", type(value)`:             `["yes",1,"int"]`,
+		`any([0, "", 1]), all([]), bool([]), dict(a=1), [1, 2] + [3] * 2`:        `[true,true,false,{"a":1},[1,2,3,3]]`,
+		`"""multi
+line""", 'it\'s', "é", 0x10`: `["multi\nline","it's","é",16]`,
+	}
+	for expr, want := range cases {
+		out, err := RunScript("def transform(value):\n    return "+expr+"\n", "1", ScriptLimits{})
+		require.NoError(t, err, expr)
+		require.JSONEq(t, want, out, expr)
+	}
+
+	script := `
+LIMIT = 3
+
+def transform(value):
+    out = []
+    n = 0
+    while_like = range(100)
+    for i in while_like:
+        if i % 2 == 0:
+            continue
+        elif i > 9:
+            break
+        else:
+            n += i
+        if len(out) < LIMIT: out.append(i)
+    a, b = out[0], out[-1]
+    return {"n": n, "out": out, "ends": [a, b]}
+`
+	out, err := RunScript(script, "null", ScriptLimits{})
+	require.NoError(t, err)
+	require.JSONEq(t, `{"n":25,"out":[1,3,5],"ends":[1,5]}`, out)
+
+	// Augmented assignments keep their meaning: += extends a list in
+	// place, and an indexed target is evaluated once.
+	script = `
+def transform(value):
+    a = [1]
+    b = a
+    a += [2]
+    d = {"k": 2, "n": 0}
+    d[value.pop("key")] *= 3
+    d["n"] |= 5
+    s = {"x": "y"}
+    s |= {"z": "w"}
+    t = (1,)
+    t += (2,)
+    return {"a": a, "b": b, "d": d, "s": s, "t": t, "rest": value, "attr": getattr("ab", "upper")()}
+`
+	out, err = RunScript(script, `{"key":"k","other":1}`, ScriptLimits{})
+	require.NoError(t, err)
+	require.JSONEq(t, `{"a":[1,2],"b":[1,2],"d":{"k":6,"n":5},"s":{"x":"y","z":"w"},"t":[1,2],"rest":{"other":1},"attr":"AB"}`, out)
+}
+
+func TestRunScript_Errors(t *testing.T) {
+	cases := map[string]string{
+		"x = 1\n": "must define transform",
+		"def transform(value):\n  return value[\"missing\"]\n":               `line 2: key "missing" not in dict`,
+		"def transform(value):\n  return undefined\n":                        "undefined: undefined",
+		"def transform(value):\n  return 1 +\n":                              "line 2",
+		"def transform(value):\n  return 1\n    x = 2\n":                     "line 3",
+		"def transform(value):\n  fail(\"bad\", 1)\n":                        "fail: bad 1",
+		"def transform(value):\n  return \"a\" + 1\n":                        "unknown binary op: string + int",
+		"def transform(value):\n  return transform\n":                        "cannot return a function",
+		"def f(n):\n  return f(n)\ndef transform(value):\n  return f(1)\n":   "called recursively",
+		"load(\"os.star\", \"getenv\")\ndef transform(value):\n  return 1\n": "load not implemented",
+		"def transform(value):\n  return {1: 2}\n":                           "dict keys must be strings",
+	}
+	for script, want := range cases {
+		_, err := RunScript(script, `{}`, ScriptLimits{})
+		require.ErrorContains(t, err, want, script)
+	}
+
+	loop := "def transform(value):\n  for i in range(1000):\n    for j in range(1000):\n      pass\n"
+	_, err := RunScript(loop, `{}`, ScriptLimits{MaxSteps: 10000})
+	require.ErrorContains(t, err, "limit of 10000 steps")
+
+	grow := "def transform(value):\n  s = \"x\"\n  for i in range(40):\n    s = s + s\n  return len(s)\n"
+	_, err = RunScript(grow, `{}`, ScriptLimits{MaxMemory: 1 << 20})
+	require.ErrorContains(t, err, "limit of 1048576 bytes")
+	_, err = RunScript("def transform(value):\n  return \"x\" * 100000000000\n", `{}`, ScriptLimits{})
+	require.ErrorContains(t, err, "limit of 33554432 bytes")
+
+	// Each of these would allocate hundreds of megabytes in one step.
+	for _, expr := range []string{
+		`"x" * (1 << 28)`,
+		`[0] * (1 << 28)`,
+		`list(range(1 << 28))`,
+		`",".join(["x" * 1000] * 2000)`,
+		`("x" * 2000).replace("x", "y" * 1000)`,
+		`("y" * 600000)[1:] + "y" * 600000`,
+		`"{}{}{}".format("x" * 500000, "x" * 500000, "x" * 500000)`,
+		`str([["x" * 1000] * 1000])`,
+	} {
+		_, err = RunScript("def transform(value):\n  return len("+expr+")\n", `{}`, ScriptLimits{MaxMemory: 1 << 20})
+		require.ErrorContains(t, err, "line 2: script exceeded its limit of 1048576 bytes", expr)
+	}
+	_, err = RunScript("def transform(value):\n  l = [\"x\" * 1000]\n  for i in range(20):\n    l += l\n  return len(l)\n", `{}`, ScriptLimits{MaxMemory: 1 << 20})
+	require.ErrorContains(t, err, "limit of 1048576 bytes")
+
+	cycle := "def transform(value):\n  l = []\n  l.append(l)\n  return l\n"
+	_, err = RunScript(cycle, `{}`, ScriptLimits{})
+	require.ErrorContains(t, err, "nests too deeply")
+}
+
+func TestConvertFormats_Script(t *testing.T) {
+	script := "def transform(value):\n    value[\"port\"] += 1\n    value[\"name\"] = value[\"name\"].upper()\n    return value\n"
+	out, err := ConvertFormatsWithOptions(formatYAML, formatJSON, "name: api\nport: 8080\n", WithScript(script), WithSortKeys(false))
+	require.NoError(t, err)
+	require.Equal(t, "{\n  \"name\": \"API\",\n  \"port\": 8081\n}", out)
+
+	out, err = ConvertFormatsWithOptions(formatJSON, formatYAML, `{"port":1,"name":"x"}`, WithScript(script))
+	require.NoError(t, err)
+	require.Equal(t, "port: 2\nname: X", out)
+
+	_, err = ConvertFormatsWithOptions(formatJSON, formatYAML, `{}`, WithScript(script))
+	require.ErrorContains(t, err, `script: line 2: key "port" not in dict`)
+}
@@ -0,0 +1,251 @@
+package convert
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/linzeyan/transform-go/pkg/code"
+)
+
+const (
+	SelfTestConvert  = "convert"
+	SelfTestHash     = "hash"
+	SelfTestEncoding = "encoding"
+)
+
+// SelfTestResult is the outcome of one capability check.
+type SelfTestResult struct {
+	Kind  string `json:"kind"`
+	Name  string `json:"name"`
+	Pass  bool   `json:"pass"`
+	Error string `json:"error,omitempty"`
+}
+
+// SelfTestReport lists every check; OK is set when all of them passed.
+type SelfTestReport struct {
+	OK      bool             `json:"ok"`
+	Passed  int              `json:"passed"`
+	Failed  int              `json:"failed"`
+	Results []SelfTestResult `json:"results"`
+}
+
+// selfTestSample is round-tripped through every format that converts both
+// ways; selfTestSamples overrides it where a format needs another shape.
+const selfTestSample = `{"id":1,"name":"alpha","active":true,"ratio":0.5,"tags":["x","y"],"meta":{"owner":"ops"}}`
+
+var selfTestSamples = map[string]string{
+	formatCSV:           `[{"id":1,"name":"alpha"},{"id":2,"name":"beta"}]`,
+	formatNDJSON:        `[{"id":1,"name":"alpha"},{"id":2,"name":"beta"}]`,
+	formatHTMLTable:     `[{"id":1,"name":"alpha"},{"id":2,"name":"beta"}]`,
+	formatMarkdownTable: `[{"id":1,"name":"alpha"},{"id":2,"name":"beta"}]`,
+	formatGraphQL:       `{"id":1,"name":"alpha","active":true,"ratio":0.5,"tags":["x","y"]}`,
+	formatPropsXML:      `{"id":"1","name":"alpha"}`,
+	formatDotenv:        `{"id":"1","name":"alpha","owner":"ops"}`,
+	formatSQL:           `{"id":1,"name":"alpha","active":true,"ratio":0.5}`,
+	formatReg: `{"version":"Windows Registry Editor Version 5.00","keys":[{"path":"HKEY_CURRENT_USER\\Software\\Demo","hive":"HKEY_CURRENT_USER",` +
+		`"values":[{"name":"Name","type":"REG_SZ","data":"alpha"},{"name":"Count","type":"REG_DWORD","data":1}]}]}`,
+}
+
+// selfTestShapeOnly lists formats that describe types rather than values,
+// so their round trip is checked for the same keys and value kinds.
+var selfTestShapeOnly = map[string]bool{
+	formatGoStruct: true,
+	formatSchema:   true,
+	formatGraphQL:  true,
+	formatProtobuf: true,
+	formatSQL:      true,
+	formatAvro:     true,
+}
+
+// selfTestTextOnly lists formats without value types, whose round trip
+// turns every scalar into a string.
+var selfTestTextOnly = map[string]bool{
+	formatXML:         true,
+	formatPropsXML:    true,
+	formatProperties:  true,
+	formatDotenv:      true,
+	formatQueryString: true,
+}
+
+// selfTestHashes are the digests of "abc".
+var selfTestHashes = map[string]string{
+	"adler32":          "024d0127",
+	"blake2b_256":      "bddd813c634239723171ef3fee98579b94964e3bb1cb3e427262c8c068d52319",
+	"blake2b_512":      "ba80a53f981c4d0d6a2797b69f12f6e94c212f14685ac4b74b12bb6fdbffa2d17d87c5392aab792dc252d5de4533cc9518d38aa8dbf1925ab92386edd4009923",
+	"blake2s_256":      "508c5e8c327c14e2e1a72ba34eeb452f37458b209ed63a294d999b4c86675982",
+	"blake3":           "6437b3ac38465133ffb63b75273a8db548c558465d79db03fd359c6cd5bd9d85",
+	"crc32_castagnoli": "364b3fb7",
+	"crc32_ieee":       "352441c2",
+	"crc64_ecma":       "2cd8094a1a277627",
+	"crc64_iso":        "3776c42000000000",
+	"fnv128":           "a68bb2a4348b5822836dbc78c6aee73b",
+	"fnv128a":          "a68d622cec8b5822836dbc7977af7f3b",
+	"fnv32":            "439c2f4b",
+	"fnv32a":           "1a47e90b",
+	"fnv64":            "d8dcca186bafadcb",
+	"fnv64a":           "e71fa2190541574b",
+	"keccak256":        "4e03657aea45a94fc7d47ba826c8d667c0d1e6e33a64a036ec44f58fa12d6c45",
+	"md5":              "900150983cd24fb0d6963f7d28e17f72",
+	"sha1":             "a9993e364706816aba3e25717850c26c9cd0d89d",
+	"sha224":           "23097d223405d8228642a477bda255b32aadbce4bda0b3f7e36c9da7",
+	"sha256":           "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad",
+	"sha384":           "cb00753f45a35e8bb5a03d699ac65007272c32ab0eded1631a8b605a43ff5bed8086072ba1e7cc2358baeca134c825a7",
+	"sha3_224":         "e642824c3f8cf24ad09234ee7d3c766fc9a3a5168d0c94ad73b46fdf",
+	"sha3_256":         "3a985da74fe225b2045c172d6bd390bd855f086e3e9d525b46bfe24511431532",
+	"sha3_384":         "ec01498288516fc926459f58e2c6ad8df9b473cb0fc08c2596da7cf0e49be4b298d88cea927ac7f539f1edf228376d25",
+	"sha3_512":         "b751850b1a57168a5693cd924b6b096e08f621827444f70d884f5d0240d2712e10e116e9192af3c91a7ec57647e3934057340b4cf408d5a56592f8274eec53f0",
+	"sha512":           "ddaf35a193617abacc417349ae20413112e6fa4e89a97ea20a9eeee64b55d39a2192992a274fc1a836ba3c23a3feebbd454d4423643ce80e2a9ac94fa54ca49f",
+	"sha512_224":       "4634270f707b6a54daae7530460842e20e37ed265ceee9a43e8924aa",
+	"sha512_256":       "53048e2681941ef99b2e29b76b4c7dabe4c2d0c634fc6d46e0e2f13107e7af23",
+}
+
+// SelfTest runs a small conformance suite so a deployment can check its
+// build: a JSON round trip through every format that converts both ways
+// (registered formats included), the digest of "abc" for every hash and an
+// encode/decode round trip for every encoding.
+func SelfTest() SelfTestReport {
+	var results []SelfTestResult
+	for _, info := range ListFormats() {
+		if !info.ToJSON || !info.FromJSON || info.Name == formatJSON {
+			continue
+		}
+		results = append(results, selfTestResult(SelfTestConvert, "JSON <-> "+info.Name, selfTestFormat(info.Name)))
+	}
+
+	digests := code.HashContent("abc")
+	for _, name := range sortedKeys(digests) {
+		var err error
+		if want, ok := selfTestHashes[name]; !ok {
+			err = fmt.Errorf("no test vector")
+		} else if digests[name] != want {
+			err = fmt.Errorf("got %s, want %s", digests[name], want)
+		}
+		results = append(results, selfTestResult(SelfTestHash, name, err))
+	}
+
+	const plain = "transform-go ✓ self test"
+	encoded, err := code.EncodeContent(plain)
+	if err != nil {
+		results = append(results, selfTestResult(SelfTestEncoding, "encode", err))
+	}
+	for _, name := range sortedKeys(encoded) {
+		decoded, err := code.DecodeContent(name, encoded[name])
+		if err == nil && decoded != plain {
+			err = fmt.Errorf("decoded %q", decoded)
+		}
+		results = append(results, selfTestResult(SelfTestEncoding, name, err))
+	}
+
+	report := SelfTestReport{Results: results}
+	for _, r := range results {
+		if r.Pass {
+			report.Passed++
+		} else {
+			report.Failed++
+		}
+	}
+	report.OK = report.Failed == 0
+	return report
+}
+
+func selfTestFormat(format string) error {
+	sample := selfTestSample
+	if s, ok := selfTestSamples[format]; ok {
+		sample = s
+	}
+	out, err := ConvertFormats(formatJSON, format, sample)
+	if err != nil {
+		return fmt.Errorf("from JSON: %w", err)
+	}
+	back, err := ConvertFormats(format, formatJSON, out)
+	if err != nil {
+		return fmt.Errorf("to JSON: %w", err)
+	}
+	var want, got any
+	if err := json.Unmarshal([]byte(sample), &want); err != nil {
+		return err
+	}
+	if err := json.Unmarshal([]byte(back), &got); err != nil {
+		return err
+	}
+	switch {
+	case selfTestShapeOnly[format]:
+		want, got = valueShape(want), valueShape(got)
+	case selfTestTextOnly[format]:
+		want = scalarsAsText(want)
+	}
+	if !reflect.DeepEqual(want, got) {
+		return fmt.Errorf("round trip changed the document: %s", compactJSON(back))
+	}
+	return nil
+}
+
+// valueShape replaces every scalar with its JSON kind.
+func valueShape(v any) any {
+	switch val := v.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(val))
+		for k, item := range val {
+			out[k] = valueShape(item)
+		}
+		return out
+	case []any:
+		if len(val) == 0 {
+			return []any{}
+		}
+		return []any{valueShape(val[0])}
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	}
+	return "null"
+}
+
+func scalarsAsText(v any) any {
+	switch val := v.(type) {
+	case map[string]any:
+		for k, item := range val {
+			val[k] = scalarsAsText(item)
+		}
+		return val
+	case []any:
+		for i, item := range val {
+			val[i] = scalarsAsText(item)
+		}
+		return val
+	case nil:
+		return nil
+	}
+	return fmt.Sprint(v)
+}
+
+func selfTestResult(kind, name string, err error) SelfTestResult {
+	r := SelfTestResult{Kind: kind, Name: name, Pass: err == nil}
+	if err != nil {
+		r.Error = err.Error()
+	}
+	return r
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func compactJSON(s string) string {
+	var v any
+	if err := json.Unmarshal([]byte(s), &v); err != nil {
+		return s
+	}
+	out, _ := json.Marshal(v)
+	return string(out)
+}
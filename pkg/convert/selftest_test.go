@@ -0,0 +1,40 @@
+package convert
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelfTest(t *testing.T) {
+	report := SelfTest()
+	for _, r := range report.Results {
+		require.True(t, r.Pass, "%s %s: %s", r.Kind, r.Name, r.Error)
+	}
+	require.True(t, report.OK)
+	require.Zero(t, report.Failed)
+	require.Equal(t, len(report.Results), report.Passed)
+
+	kinds := map[string]int{}
+	for _, r := range report.Results {
+		kinds[r.Kind]++
+	}
+	require.Len(t, kinds, 3)
+	require.Equal(t, len(selfTestHashes), kinds[SelfTestHash])
+}
+
+func TestSelfTest_RegisteredFormat(t *testing.T) {
+	name := "SelfTest Lossy"
+	RegisterFormat(name, FormatAdapter{
+		ToJSON:   func(string) (string, error) { return `{}`, nil },
+		FromJSON: func(string) (string, error) { return "lossy", nil },
+	})
+	t.Cleanup(func() {
+		adaptersMu.Lock()
+		delete(adapters, name)
+		adaptersMu.Unlock()
+	})
+	report := SelfTest()
+	require.False(t, report.OK)
+	require.Equal(t, 1, report.Failed)
+}
@@ -0,0 +1,141 @@
+package convert
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/linzeyan/transform-go/pkg/common"
+)
+
+const formatPEM = "PEM"
+
+var (
+	pemBlockRe      = common.LazyRegexp(`(?s)-----BEGIN ([A-Z0-9 ]+)-----.*?-----END ([A-Z0-9 ]+)-----`)
+	yamlSeparatorRe = common.LazyRegexp(`(?m)^(?:---|\.\.\.)(?:[ \t].*)?$`)
+)
+
+// DocumentSegment is one document found by SplitDocuments. Format is a
+// name DetectFormat returns, or "PEM" with the block type in Label. JSON
+// holds the segment converted to JSON when its format reads into JSON, and
+// Error why that failed. Line is where the segment starts in the input.
+type DocumentSegment struct {
+	Index   int    `json:"index"`
+	Format  string `json:"format"`
+	Line    int    `json:"line"`
+	Content string `json:"content"`
+	Label   string `json:"label,omitempty"`
+	JSON    string `json:"json,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// SplitDocuments splits pasted text holding several documents: PEM blocks,
+// JSON values written back to back or one per line (NDJSON), and YAML
+// streams separated by "---". Any other text between them is kept as one
+// segment each. Every segment is detected and read by its own parser.
+func SplitDocuments(input string) ([]DocumentSegment, error) {
+	input = strings.TrimPrefix(input, "\uFEFF")
+	if strings.TrimSpace(input) == "" {
+		return nil, errors.New("input is empty")
+	}
+	var segments []DocumentSegment
+	add := func(offset int, content, format, label string) {
+		trimmed := strings.TrimSpace(content)
+		if trimmed == "" {
+			return
+		}
+		offset += strings.Index(content, trimmed)
+		seg := DocumentSegment{
+			Index:   len(segments),
+			Format:  format,
+			Line:    strings.Count(input[:offset], "\n") + 1,
+			Content: trimmed,
+			Label:   label,
+		}
+		if format == "" {
+			seg.readDocument()
+		}
+		segments = append(segments, seg)
+	}
+
+	last := 0
+	for _, loc := range pemBlockRe().FindAllStringSubmatchIndex(input, -1) {
+		label := input[loc[2]:loc[3]]
+		if label != input[loc[4]:loc[5]] {
+			continue
+		}
+		splitText(input[last:loc[0]], last, add)
+		add(loc[0], input[loc[0]:loc[1]], formatPEM, label)
+		last = loc[1]
+	}
+	splitText(input[last:], last, add)
+	return segments, nil
+}
+
+// splitText splits text without PEM blocks into JSON values or YAML
+// documents, reporting each with its byte offset.
+func splitText(text string, offset int, add func(int, string, string, string)) {
+	trimmed := strings.TrimSpace(text)
+	if trimmed == "" {
+		return
+	}
+	if trimmed[0] == '{' || trimmed[0] == '[' {
+		if spans := jsonValueSpans(text); len(spans) > 0 {
+			for _, span := range spans {
+				add(offset+span[0], text[span[0]:span[1]], "", "")
+			}
+			return
+		}
+	}
+	start := 0
+	for _, loc := range yamlSeparatorRe().FindAllStringIndex(text, -1) {
+		add(offset+start, text[start:loc[0]], "", "")
+		start = loc[1]
+	}
+	add(offset+start, text[start:], "", "")
+}
+
+// jsonValueSpans returns the byte ranges of the JSON values making up all
+// of text, or nil when anything else is in between.
+func jsonValueSpans(text string) [][2]int {
+	dec := json.NewDecoder(strings.NewReader(text))
+	var spans [][2]int
+	for {
+		var raw json.RawMessage
+		err := dec.Decode(&raw)
+		if err == io.EOF {
+			return spans
+		}
+		if err != nil {
+			return nil
+		}
+		end := int(dec.InputOffset())
+		spans = append(spans, [2]int{end - len(raw), end})
+	}
+}
+
+// readDocument detects the segment's format and converts it to JSON.
+func (seg *DocumentSegment) readDocument() {
+	format, _, err := DetectFormat(seg.Content)
+	if err != nil {
+		seg.Error = err.Error()
+		return
+	}
+	seg.Format = format
+	if adapter, ok := lookupAdapter(format); format != formatJSON && (!ok || adapter.ToJSON == nil) {
+		return
+	}
+	if format == formatJSON && !json.Valid([]byte(seg.Content)) {
+		var v any
+		seg.Error = json.Unmarshal([]byte(seg.Content), &v).Error()
+		return
+	}
+	data, err := decodeDocument(seg.Content)
+	if err == nil {
+		seg.JSON, err = encodeJSON(data)
+	}
+	if err != nil {
+		seg.Error = err.Error()
+	}
+}
@@ -0,0 +1,55 @@
+package convert
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitDocuments(t *testing.T) {
+	input := `{"a":1}{"b":[2]}
+{"c":3}
+-----BEGIN CERTIFICATE-----
+MIIBszCCAVmgAwIBAgIUQ
+-----END CERTIFICATE-----
+name: x
+---
+name: y
+list: [1, 2]
+`
+	segments, err := SplitDocuments(input)
+	require.NoError(t, err)
+	require.Len(t, segments, 6)
+
+	formats := make([]string, len(segments))
+	lines := make([]int, len(segments))
+	for i, seg := range segments {
+		require.Equal(t, i, seg.Index)
+		require.Empty(t, seg.Error)
+		formats[i] = seg.Format
+		lines[i] = seg.Line
+	}
+	require.Equal(t, []string{formatJSON, formatJSON, formatJSON, formatPEM, formatYAML, formatYAML}, formats)
+	require.Equal(t, []int{1, 1, 2, 3, 6, 8}, lines)
+
+	require.Equal(t, `{"b":[2]}`, segments[1].Content)
+	require.JSONEq(t, `{"b":[2]}`, segments[1].JSON)
+	require.Equal(t, "CERTIFICATE", segments[3].Label)
+	require.Empty(t, segments[3].JSON)
+	require.JSONEq(t, `{"name":"y","list":[1,2]}`, segments[5].JSON)
+}
+
+func TestSplitDocuments_Single(t *testing.T) {
+	segments, err := SplitDocuments("[1, 2,\n 3]")
+	require.NoError(t, err)
+	require.Len(t, segments, 1)
+	require.Equal(t, formatJSON, segments[0].Format)
+
+	segments, err = SplitDocuments(`{"a":1} trailing`)
+	require.NoError(t, err)
+	require.Len(t, segments, 1)
+	require.NotEmpty(t, segments[0].Error)
+
+	_, err = SplitDocuments("  \n")
+	require.Error(t, err)
+}
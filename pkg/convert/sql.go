@@ -0,0 +1,814 @@
+package convert
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"maps"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/linzeyan/transform-go/pkg/common"
+)
+
+const formatSQL = "SQL DDL"
+
+// sqlKind is the abstract type of a column; each dialect names it.
+type sqlKind int
+
+const (
+	sqlText sqlKind = iota
+	sqlInt
+	sqlFloat
+	sqlBool
+	sqlTimestamp
+	sqlDate
+	sqlUUID
+	sqlJSON
+	sqlBytes
+	sqlTime
+)
+
+var sqlDialectTypes = map[string][]string{
+	SQLDialectPostgres: {"TEXT", "BIGINT", "DOUBLE PRECISION", "BOOLEAN", "TIMESTAMPTZ", "DATE", "UUID", "JSONB", "BYTEA", "TIME"},
+	SQLDialectMySQL:    {"TEXT", "BIGINT", "DOUBLE", "BOOLEAN", "DATETIME(6)", "DATE", "CHAR(36)", "JSON", "BLOB", "TIME"},
+	SQLDialectSQLite:   {"TEXT", "INTEGER", "REAL", "INTEGER", "TEXT", "TEXT", "TEXT", "TEXT", "BLOB", "TEXT"},
+}
+
+var sqlDialectAliases = map[string]string{
+	"":         SQLDialectPostgres,
+	"postgres": SQLDialectPostgres,
+	"pg":       SQLDialectPostgres,
+	"mariadb":  SQLDialectMySQL,
+	"sqlite3":  SQLDialectSQLite,
+}
+
+var (
+	sqlPlainIdentRe = common.LazyRegexp(`^[a-z_][a-z0-9_]*$`)
+	sqlDateRe       = common.LazyRegexp(`^\d{4}-\d{2}-\d{2}$`)
+)
+
+// sqlReserved are words every dialect rejects as bare identifiers.
+var sqlReserved = map[string]bool{
+	"all": true, "and": true, "as": true, "by": true, "check": true, "column": true, "create": true,
+	"default": true, "delete": true, "desc": true, "distinct": true, "from": true, "group": true,
+	"having": true, "in": true, "index": true, "insert": true, "into": true, "is": true, "join": true,
+	"key": true, "limit": true, "not": true, "null": true, "on": true, "or": true, "order": true,
+	"primary": true, "references": true, "select": true, "table": true, "to": true, "union": true,
+	"unique": true, "update": true, "user": true, "values": true, "where": true,
+}
+
+type sqlColumn struct {
+	name     string
+	kind     sqlKind
+	nullable bool
+	// typed is set once a row gives the column a non-null value.
+	typed bool
+
+	// Parsed from CREATE TABLE: the Go type of one value, the length of
+	// a character type, enum values, array and primary key columns and
+	// the MySQL column comment.
+	goType  string
+	size    int
+	enum    []string
+	array   bool
+	primary bool
+	comment string
+}
+
+type sqlTable struct {
+	name    string
+	columns []sqlColumn
+}
+
+func JSONToSQL(input string) (string, error) {
+	return JSONToSQLWithOptions(input)
+}
+
+// JSONToSQLWithOptions writes a CREATE TABLE statement for a JSON object, or
+// for an array of objects read as rows, in the SQLDialect of opts. Nested
+// objects and arrays become JSON columns, RFC 3339 strings timestamps,
+// plain dates DATE and UUIDs UUID where the dialect has them. Columns are
+// NOT NULL unless a row leaves them out or null, and an integer or UUID id
+// column is the primary key.
+func JSONToSQLWithOptions(input string, opts ...ConvertOption) (string, error) {
+	o := NewConvertOptions(opts...)
+	data, err := decodeJSONValue(input)
+	if err != nil {
+		return "", err
+	}
+	rows, path := []any{data}, ""
+	if arr, ok := data.([]any); ok {
+		rows, path = arr, "[]"
+	}
+	var order []string
+	if !o.SortKeys {
+		order = jsonKeyOrder(input)[path]
+	}
+	table := sqlTable{name: sqlColumnName("AutoGenerated")}
+	index := map[string]int{}
+	for i, row := range rows {
+		obj, ok := row.(map[string]any)
+		if !ok {
+			return "", errors.New("SQL DDL needs a JSON object or an array of objects")
+		}
+		for j := range table.columns {
+			if _, ok := obj[table.columns[j].name]; !ok {
+				table.columns[j].nullable = true
+			}
+		}
+		for _, key := range keysInOrder(obj, order) {
+			kind, null := sqlValueKind(obj[key])
+			at, seen := index[key]
+			if !seen {
+				index[key] = len(table.columns)
+				table.columns = append(table.columns, sqlColumn{name: key, kind: kind, nullable: null || i > 0, typed: !null})
+				continue
+			}
+			col := &table.columns[at]
+			switch {
+			case null:
+				col.nullable = true
+			case col.typed:
+				col.kind = commonSQLKind(col.kind, kind)
+			default:
+				col.kind, col.typed = kind, true
+			}
+		}
+	}
+	if o.SortKeys {
+		sort.Slice(table.columns, func(i, j int) bool { return table.columns[i].name < table.columns[j].name })
+	}
+	for i := range table.columns {
+		table.columns[i].name = sqlColumnName(table.columns[i].name)
+	}
+	return o.renderSQL([]sqlTable{table})
+}
+
+func GoStructToSQL(src string) (string, error) {
+	return GoStructToSQLWithOptions(src)
+}
+
+// GoStructToSQLWithOptions writes a CREATE TABLE statement for every struct.
+// Columns are named by the db tag, else the json tag, in snake case, and
+// skipped when either tag is "-". Pointers, slices, maps and the sql.Null
+// types are nullable; time.Time is a timestamp, uuid.UUID a UUID, []byte
+// binary and other composite types JSON.
+func GoStructToSQLWithOptions(src string, opts ...ConvertOption) (string, error) {
+	o := NewConvertOptions(opts...)
+	defs, err := parseGoStructDefinitions(src)
+	if err != nil {
+		return "", err
+	}
+	tables := make([]sqlTable, 0, len(defs))
+	for _, def := range defs {
+		table := sqlTable{name: sqlColumnName(def.Name)}
+		for _, field := range def.Fields {
+			tag := reflect.StructTag(strings.Trim(field.Tag, "`"))
+			if tag.Get("json") == "-" || tag.Get("db") == "-" {
+				continue
+			}
+			name, _, _ := strings.Cut(tag.Get("db"), ",")
+			if name == "" {
+				name = field.JSONName
+			}
+			kind, null := goSQLKind(field.TypeExpr)
+			table.columns = append(table.columns, sqlColumn{name: sqlColumnName(name), kind: kind, nullable: null})
+		}
+		tables = append(tables, table)
+	}
+	return o.renderSQL(tables)
+}
+
+func sqlValueKind(v any) (kind sqlKind, null bool) {
+	switch val := v.(type) {
+	case nil:
+		return sqlText, true
+	case bool:
+		return sqlBool, false
+	case map[string]any, []any:
+		return sqlJSON, false
+	case string:
+		switch {
+		case sqlDateRe().MatchString(val):
+			return sqlDate, false
+		case semanticStringType(val) == "time.Time":
+			return sqlTimestamp, false
+		case semanticStringType(val) == "uuid.UUID":
+			return sqlUUID, false
+		}
+		return sqlText, false
+	}
+	if num, ok := v.(interface{ Int64() (int64, error) }); ok {
+		if _, err := num.Int64(); err == nil {
+			return sqlInt, false
+		}
+	}
+	return sqlFloat, false
+}
+
+// commonSQLKind unifies the kinds two rows give a column.
+func commonSQLKind(a, b sqlKind) sqlKind {
+	switch {
+	case a == b:
+		return a
+	case a == sqlInt && b == sqlFloat, a == sqlFloat && b == sqlInt:
+		return sqlFloat
+	case a == sqlJSON || b == sqlJSON:
+		return sqlJSON
+	}
+	return sqlText
+}
+
+func goSQLKind(expr ast.Expr) (kind sqlKind, null bool) {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		kind, _ := goSQLKind(t.X)
+		return kind, true
+	case *ast.ArrayType:
+		if ident, ok := t.Elt.(*ast.Ident); ok && (ident.Name == "byte" || ident.Name == "uint8") {
+			return sqlBytes, true
+		}
+		return sqlJSON, true
+	case *ast.MapType, *ast.InterfaceType:
+		return sqlJSON, true
+	case *ast.StructType:
+		return sqlJSON, false
+	case *ast.Ident:
+		switch t.Name {
+		case "string":
+			return sqlText, false
+		case "bool":
+			return sqlBool, false
+		case "float32", "float64":
+			return sqlFloat, false
+		case "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64", "byte", "rune":
+			return sqlInt, false
+		case "any":
+			return sqlJSON, true
+		}
+		return sqlJSON, false
+	case *ast.SelectorExpr:
+		switch t.Sel.Name {
+		case "Time":
+			return sqlTimestamp, false
+		case "Duration":
+			return sqlInt, false
+		case "UUID":
+			return sqlUUID, false
+		case "URL":
+			return sqlText, false
+		case "RawMessage":
+			return sqlJSON, true
+		case "NullString":
+			return sqlText, true
+		case "NullInt64", "NullInt32", "NullInt16", "NullByte":
+			return sqlInt, true
+		case "NullFloat64":
+			return sqlFloat, true
+		case "NullBool":
+			return sqlBool, true
+		case "NullTime":
+			return sqlTimestamp, true
+		}
+		return sqlJSON, false
+	}
+	return sqlText, true
+}
+
+func sqlColumnName(name string) string {
+	return applyTagCase(name, TagCaseSnake)
+}
+
+func (o ConvertOptions) sqlDialect() (string, error) {
+	dialect := strings.ToLower(strings.TrimSpace(o.SQLDialect))
+	if alias, ok := sqlDialectAliases[dialect]; ok {
+		dialect = alias
+	}
+	if _, ok := sqlDialectTypes[dialect]; !ok {
+		return "", fmt.Errorf("unsupported SQL dialect: %s", o.SQLDialect)
+	}
+	return dialect, nil
+}
+
+func (o ConvertOptions) renderSQL(tables []sqlTable) (string, error) {
+	dialect, err := o.sqlDialect()
+	if err != nil {
+		return "", err
+	}
+	types := sqlDialectTypes[dialect]
+	quote := func(name string) string {
+		if sqlPlainIdentRe().MatchString(name) && !sqlReserved[name] {
+			return name
+		}
+		if dialect == SQLDialectMySQL {
+			return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+		}
+		return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+	}
+	indent := o.indentString()
+	stmts := make([]string, 0, len(tables))
+	for _, table := range tables {
+		lines := make([]string, 0, len(table.columns))
+		for _, col := range table.columns {
+			line := indent + quote(col.name) + " " + types[col.kind]
+			switch {
+			case col.name == "id" && (col.kind == sqlInt || col.kind == sqlUUID):
+				line += " PRIMARY KEY"
+			case !col.nullable:
+				line += " NOT NULL"
+			}
+			lines = append(lines, line)
+		}
+		stmts = append(stmts, fmt.Sprintf("CREATE TABLE %s (\n%s\n);", quote(table.name), strings.Join(lines, ",\n")))
+	}
+	return strings.Join(stmts, "\n\n") + "\n", nil
+}
+
+var (
+	sqlCreateTableRe = common.LazyRegexp(`(?i)\bcreate\s+(?:[a-z]+\s+)*?table\s+(?:if\s+not\s+exists\s+)?`)
+	sqlPrimaryKeyRe  = common.LazyRegexp(`(?i)\bprimary\s+key\s*\(([^)]*)\)`)
+	sqlQuotedRe      = common.LazyRegexp(`'((?:[^']|'')*)'`)
+)
+
+// sqlTableConstraints start the items of a column list that are not
+// columns.
+var sqlTableConstraints = map[string]bool{
+	"CONSTRAINT": true, "PRIMARY": true, "UNIQUE": true, "FOREIGN": true, "CHECK": true,
+	"KEY": true, "INDEX": true, "FULLTEXT": true, "SPATIAL": true, "EXCLUDE": true,
+}
+
+// sqlColumnConstraints end the type of a column definition.
+var sqlColumnConstraints = map[string]bool{
+	"NOT": true, "NULL": true, "PRIMARY": true, "DEFAULT": true, "REFERENCES": true,
+	"UNIQUE": true, "CHECK": true, "AUTO_INCREMENT": true, "AUTOINCREMENT": true,
+	"COMMENT": true, "GENERATED": true, "COLLATE": true, "CONSTRAINT": true, "ON": true,
+	"IDENTITY": true,
+}
+
+func SQLToJSON(input string) (string, error) {
+	tables, err := parseSQLTables(input)
+	if err != nil {
+		return "", err
+	}
+	// The sample lists the columns in table order.
+	columns := make([]string, len(tables[0].columns))
+	for i, col := range tables[0].columns {
+		columns[i] = col.name
+	}
+	b := &docBuilder{}
+	schema, err := docFromValue(&b.arena, sqlTableSchema(tables[0]), "", map[string][]string{
+		orderPath("", "properties"): columns,
+	})
+	if err != nil {
+		return "", err
+	}
+	return encodeDoc(sampleFromSchema(b, schema))
+}
+
+func SQLToGoStruct(input string) (string, error) {
+	return SQLToGoStructWithOptions(input)
+}
+
+// SQLToGoStructWithOptions turns every CREATE TABLE statement (PostgreSQL
+// or MySQL) into a struct with json and db tags. Columns that may be NULL
+// become pointers, arrays slices, timestamps and dates time.Time, JSON
+// columns json.RawMessage and binary columns []byte; integer types keep
+// their width. TagCase and OmitEmpty apply to the json tag only.
+func SQLToGoStructWithOptions(input string, opts ...ConvertOption) (string, error) {
+	o := NewConvertOptions(opts...)
+	tables, err := parseSQLTables(input)
+	if err != nil {
+		return "", err
+	}
+	tags := o
+	tags.ExtraTags = map[string]string{}
+	maps.Copy(tags.ExtraTags, o.ExtraTags)
+	tags.ExtraTags["db"] = TagCaseOriginal
+
+	var sb strings.Builder
+	for i, table := range tables {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		sb.WriteString("type " + sanitizeTypeName(table.name) + " struct {\n")
+		for _, col := range table.columns {
+			if col.comment != "" {
+				sb.WriteString("\t// " + col.comment + "\n")
+			}
+			name := common.ExportName(col.name)
+			if name == "" {
+				name = "Field"
+			}
+			sb.WriteString("\t" + name + " " + col.goFieldType() + " " + tags.structTags(col.name) + "\n")
+		}
+		sb.WriteString("}\n")
+	}
+	src := "package main\n\n" + goImportBlock(sb.String())
+	formatted, err := format.Source([]byte(src + sb.String()))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(strings.TrimPrefix(string(formatted), "package main\n\n")), nil
+}
+
+func SQLToSchema(input string) (string, error) {
+	return SQLToSchemaWithOptions(input)
+}
+
+// SQLToSchemaWithOptions describes a table as an object schema whose
+// required properties are the NOT NULL columns; several tables go under
+// $defs (definitions for draft-07). Column types map to formats such as
+// date-time, date and uuid, character lengths to maxLength and MySQL
+// enums to enum.
+func SQLToSchemaWithOptions(input string, opts ...ConvertOption) (string, error) {
+	o := NewConvertOptions(opts...)
+	tables, err := parseSQLTables(input)
+	if err != nil {
+		return "", err
+	}
+	schema := sqlTableSchema(tables[0])
+	if len(tables) > 1 {
+		defs := map[string]any{}
+		for _, table := range tables {
+			defs[table.name] = sqlTableSchema(table)
+		}
+		key := "$defs"
+		if o.SchemaDraft == SchemaDraft07 {
+			key = "definitions"
+		}
+		schema = map[string]any{key: defs}
+	}
+	if o.SchemaDraft != "" {
+		uri, ok := schemaDraftURIs[o.SchemaDraft]
+		if !ok {
+			return "", fmt.Errorf("unsupported schema draft %q", o.SchemaDraft)
+		}
+		schema["$schema"] = uri
+	}
+	formatted, err := json.MarshalIndent(schema, "", o.indentString())
+	if err != nil {
+		return "", err
+	}
+	return string(formatted), nil
+}
+
+func sqlTableSchema(table sqlTable) map[string]any {
+	props := map[string]any{}
+	required := []string{}
+	for _, col := range table.columns {
+		prop := col.schema()
+		if col.array {
+			prop = map[string]any{"type": "array", "items": prop}
+		}
+		if t, ok := prop["type"]; ok && col.nullable {
+			prop["type"] = []any{t, "null"}
+		}
+		if col.comment != "" {
+			prop["description"] = col.comment
+		}
+		props[col.name] = prop
+		if !col.nullable {
+			required = append(required, col.name)
+		}
+	}
+	schema := map[string]any{"title": table.name, "type": "object", "properties": props}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+func (c sqlColumn) schema() map[string]any {
+	switch c.kind {
+	case sqlInt:
+		return map[string]any{"type": "integer"}
+	case sqlFloat:
+		return map[string]any{"type": "number"}
+	case sqlBool:
+		return map[string]any{"type": "boolean"}
+	case sqlTimestamp:
+		return map[string]any{"type": "string", "format": "date-time"}
+	case sqlDate:
+		return map[string]any{"type": "string", "format": "date"}
+	case sqlTime:
+		return map[string]any{"type": "string", "format": "time"}
+	case sqlUUID:
+		return map[string]any{"type": "string", "format": "uuid"}
+	case sqlJSON:
+		return map[string]any{}
+	case sqlBytes:
+		return map[string]any{"type": "string", "contentEncoding": "base64"}
+	}
+	prop := map[string]any{"type": "string"}
+	if c.size > 0 {
+		prop["maxLength"] = c.size
+	}
+	if len(c.enum) > 0 {
+		values := make([]any, len(c.enum))
+		for i, v := range c.enum {
+			values[i] = v
+		}
+		prop["enum"] = values
+	}
+	return prop
+}
+
+// goFieldType is the Go type of the column: a slice for arrays, else a
+// pointer when the column may be NULL and the type has no nil of its own.
+func (c sqlColumn) goFieldType() string {
+	switch {
+	case c.array:
+		return "[]" + c.goType
+	case c.nullable && c.goType != "json.RawMessage" && !strings.HasPrefix(c.goType, "[]"):
+		return "*" + c.goType
+	}
+	return c.goType
+}
+
+// parseSQLTables reads every CREATE TABLE statement in src; the rest of
+// the script is ignored.
+func parseSQLTables(src string) ([]sqlTable, error) {
+	src = stripSQLComments(src)
+	var tables []sqlTable
+	for _, loc := range sqlCreateTableRe().FindAllStringIndex(src, -1) {
+		rest := src[loc[1]:]
+		open := strings.IndexByte(rest, '(')
+		if open < 0 {
+			continue
+		}
+		name := strings.TrimSpace(rest[:open])
+		if dot := strings.LastIndexAny(name, "."); dot >= 0 {
+			name = name[dot+1:]
+		}
+		name = sqlUnquote(name)
+		end := sqlClosingParen(rest, open)
+		if end < 0 {
+			return nil, fmt.Errorf("unterminated CREATE TABLE %s", name)
+		}
+		table := sqlTable{name: name}
+		var primary []string
+		for _, item := range sqlSplitList(rest[open+1 : end]) {
+			words := sqlTokens(item)
+			if len(words) == 0 {
+				continue
+			}
+			if sqlTableConstraints[strings.ToUpper(words[0])] {
+				if m := sqlPrimaryKeyRe().FindStringSubmatch(item); m != nil {
+					for _, col := range strings.Split(m[1], ",") {
+						primary = append(primary, sqlUnquote(strings.TrimSpace(col)))
+					}
+				}
+				continue
+			}
+			table.columns = append(table.columns, parseSQLColumn(words))
+		}
+		for i := range table.columns {
+			if slices.Contains(primary, table.columns[i].name) {
+				table.columns[i].primary, table.columns[i].nullable = true, false
+			}
+		}
+		tables = append(tables, table)
+	}
+	if len(tables) == 0 {
+		return nil, errors.New("no CREATE TABLE statement found")
+	}
+	return tables, nil
+}
+
+func parseSQLColumn(words []string) sqlColumn {
+	i := 1
+	for i < len(words) && !sqlColumnConstraints[strings.ToUpper(words[i])] &&
+		!(strings.EqualFold(words[i], "character") && i+1 < len(words) && strings.EqualFold(words[i+1], "set")) {
+		i++
+	}
+	constraints := strings.ToUpper(strings.Join(words[i:], " "))
+	col := sqlColumn{name: sqlUnquote(words[0])}
+	col.primary = strings.Contains(constraints, "PRIMARY KEY")
+	col.nullable = !col.primary && !strings.Contains(constraints, "NOT NULL")
+	for j := i; j+1 < len(words); j++ {
+		if strings.EqualFold(words[j], "comment") {
+			col.comment = sqlUnquote(words[j+1])
+		}
+	}
+	col.setType(strings.Join(words[1:i], " "))
+	return col
+}
+
+// setType reads a column type such as "varchar(255)", "int unsigned",
+// "timestamp(3) with time zone", "text[]" or "enum('a','b')".
+func (c *sqlColumn) setType(typ string) {
+	lower := strings.ToLower(strings.TrimSpace(typ))
+	if trimmed, ok := strings.CutSuffix(lower, "[]"); ok {
+		c.array, lower = true, strings.TrimSpace(trimmed)
+	} else if trimmed, ok := strings.CutSuffix(lower, " array"); ok {
+		c.array, lower = true, trimmed
+	}
+	var args string
+	if open := strings.IndexByte(lower, '('); open >= 0 {
+		if end := strings.LastIndexByte(lower, ')'); end > open {
+			args = typ[open+1 : end]
+			lower = lower[:open] + " " + lower[end+1:]
+		}
+	}
+	unsigned := false
+	var words []string
+	for _, w := range strings.Fields(lower) {
+		switch w {
+		case "unsigned":
+			unsigned = true
+		case "zerofill", "signed":
+		default:
+			words = append(words, w)
+		}
+	}
+	base := strings.Join(words, " ")
+	c.kind, c.goType = sqlText, "string"
+	switch base {
+	case "tinyint":
+		c.kind, c.goType = sqlInt, "int8"
+		if args == "1" {
+			c.kind, c.goType = sqlBool, "bool"
+		}
+	case "smallint", "int2", "smallserial", "year":
+		c.kind, c.goType = sqlInt, "int16"
+	case "mediumint", "int", "integer", "int4", "serial":
+		c.kind, c.goType = sqlInt, "int32"
+	case "bigint", "int8", "bigserial":
+		c.kind, c.goType = sqlInt, "int64"
+	case "real", "float4":
+		c.kind, c.goType = sqlFloat, "float32"
+	case "float", "float8", "double", "double precision", "numeric", "decimal", "dec", "fixed", "money":
+		c.kind, c.goType = sqlFloat, "float64"
+	case "bool", "boolean":
+		c.kind, c.goType = sqlBool, "bool"
+	case "bit":
+		c.kind, c.goType = sqlBool, "bool"
+		if args != "" && args != "1" {
+			c.kind, c.goType = sqlBytes, "[]byte"
+		}
+	case "uuid", "uniqueidentifier":
+		c.kind = sqlUUID
+	case "date":
+		c.kind, c.goType = sqlDate, "time.Time"
+	case "time", "timetz", "time with time zone", "time without time zone":
+		c.kind = sqlTime
+	case "timestamp", "timestamptz", "timestamp with time zone", "timestamp without time zone", "datetime", "datetime2", "smalldatetime":
+		c.kind, c.goType = sqlTimestamp, "time.Time"
+	case "json", "jsonb":
+		c.kind, c.goType = sqlJSON, "json.RawMessage"
+	case "bytea", "blob", "tinyblob", "mediumblob", "longblob", "binary", "varbinary", "image":
+		c.kind, c.goType = sqlBytes, "[]byte"
+	case "enum":
+		for _, m := range sqlQuotedRe().FindAllStringSubmatch(args, -1) {
+			c.enum = append(c.enum, strings.ReplaceAll(m[1], "''", "'"))
+		}
+	case "char", "varchar", "character", "character varying", "nchar", "nvarchar", "varchar2":
+		c.size, _ = strconv.Atoi(strings.TrimSpace(args))
+	}
+	if unsigned && c.kind == sqlInt {
+		c.goType = "u" + c.goType
+	}
+}
+
+func stripSQLComments(src string) string {
+	var b strings.Builder
+	var quote byte
+	for i := 0; i < len(src); i++ {
+		ch := src[i]
+		switch {
+		case quote != 0:
+			if ch == quote {
+				quote = 0
+			}
+		case ch == '\'' || ch == '"' || ch == '`':
+			quote = ch
+		case strings.HasPrefix(src[i:], "--"):
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+			ch = '\n'
+		case strings.HasPrefix(src[i:], "/*"):
+			end := strings.Index(src[i+2:], "*/")
+			if end < 0 {
+				return b.String()
+			}
+			i += end + 3
+			ch = ' '
+		}
+		b.WriteByte(ch)
+	}
+	return b.String()
+}
+
+// sqlClosingParen finds the parenthesis closing the one at open, skipping
+// quoted text.
+func sqlClosingParen(s string, open int) int {
+	depth := 0
+	var quote byte
+	for i := open; i < len(s); i++ {
+		ch := s[i]
+		switch {
+		case quote != 0:
+			if ch == quote {
+				quote = 0
+			}
+		case ch == '\'' || ch == '"' || ch == '`':
+			quote = ch
+		case ch == '(':
+			depth++
+		case ch == ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// sqlSplitList splits at commas outside parentheses and quotes.
+func sqlSplitList(s string) []string {
+	var items []string
+	depth, start := 0, 0
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		switch {
+		case quote != 0:
+			if ch == quote {
+				quote = 0
+			}
+		case ch == '\'' || ch == '"' || ch == '`':
+			quote = ch
+		case ch == '(':
+			depth++
+		case ch == ')':
+			depth--
+		case ch == ',' && depth == 0:
+			items = append(items, strings.TrimSpace(s[start:i]))
+			start = i + 1
+		}
+	}
+	return append(items, strings.TrimSpace(s[start:]))
+}
+
+// sqlTokens splits at spaces outside parentheses and quotes, joining a
+// parenthesised group to the word before it.
+func sqlTokens(s string) []string {
+	var tokens []string
+	var cur strings.Builder
+	depth := 0
+	var quote byte
+	flush := func() {
+		if cur.Len() > 0 {
+			tokens = append(tokens, cur.String())
+			cur.Reset()
+		}
+	}
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		switch {
+		case quote != 0:
+			if ch == quote {
+				quote = 0
+			}
+		case ch == '\'' || ch == '"' || ch == '`':
+			quote = ch
+		case ch == '(':
+			if depth == 0 && cur.Len() == 0 && len(tokens) > 0 {
+				cur.WriteString(tokens[len(tokens)-1])
+				tokens = tokens[:len(tokens)-1]
+			}
+			depth++
+		case ch == ')':
+			depth--
+		case depth == 0 && (ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r'):
+			flush()
+			continue
+		}
+		cur.WriteByte(ch)
+	}
+	flush()
+	return tokens
+}
+
+// sqlUnquote strips the double quotes, backticks, brackets or single
+// quotes around an identifier or string.
+func sqlUnquote(s string) string {
+	if len(s) >= 2 {
+		switch first, last := s[0], s[len(s)-1]; {
+		case first == '"' && last == '"', first == '`' && last == '`', first == '\'' && last == '\'':
+			q := string(first)
+			return strings.ReplaceAll(s[1:len(s)-1], q+q, q)
+		case first == '[' && last == ']':
+			return s[1 : len(s)-1]
+		}
+	}
+	return s
+}
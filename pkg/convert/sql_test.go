@@ -0,0 +1,177 @@
+package convert
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONToSQL(t *testing.T) {
+	input := `{"id":1,"userName":"alice","score":9.5,"active":true,"createdAt":"2024-01-02T03:04:05Z",` +
+		`"birthday":"1990-05-06","token":"123e4567-e89b-12d3-a456-426614174000","profile":{"city":"Taipei"},"tags":["a"],"note":null}`
+	out, err := JSONToSQLWithOptions(input, WithSortKeys(true))
+	require.NoError(t, err)
+	require.Equal(t, `CREATE TABLE auto_generated (
+  active BOOLEAN NOT NULL,
+  birthday DATE NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL,
+  id BIGINT PRIMARY KEY,
+  note TEXT,
+  profile JSONB NOT NULL,
+  score DOUBLE PRECISION NOT NULL,
+  tags JSONB NOT NULL,
+  token UUID NOT NULL,
+  user_name TEXT NOT NULL
+);
+`, out)
+
+	out, err = JSONToSQLWithOptions(input, WithSQLDialect(SQLDialectMySQL), WithSortKeys(false))
+	require.NoError(t, err)
+	require.Contains(t, out, "CREATE TABLE auto_generated (\n  id BIGINT PRIMARY KEY,\n  user_name TEXT NOT NULL,\n  score DOUBLE NOT NULL,")
+	require.Contains(t, out, "created_at DATETIME(6) NOT NULL")
+	require.Contains(t, out, "profile JSON NOT NULL")
+	require.Contains(t, out, "token CHAR(36) NOT NULL")
+
+	out, err = JSONToSQLWithOptions(input, WithSQLDialect("sqlite3"))
+	require.NoError(t, err)
+	require.Contains(t, out, "id INTEGER PRIMARY KEY")
+	require.Contains(t, out, "profile TEXT NOT NULL")
+	require.Contains(t, out, "score REAL NOT NULL")
+
+	_, err = JSONToSQLWithOptions(input, WithSQLDialect("oracle"))
+	require.EqualError(t, err, "unsupported SQL dialect: oracle")
+	_, err = JSONToSQL(`[1,2]`)
+	require.Error(t, err)
+}
+
+func TestJSONToSQL_Rows(t *testing.T) {
+	out, err := JSONToSQLWithOptions(`[{"n":1,"order":"a","x":null},{"n":1.5,"order":2,"x":true,"extra":"e"}]`,
+		WithSortKeys(false), WithIndent(4))
+	require.NoError(t, err)
+	require.Equal(t, `CREATE TABLE auto_generated (
+    n DOUBLE PRECISION NOT NULL,
+    "order" TEXT NOT NULL,
+    x BOOLEAN,
+    extra TEXT
+);
+`, out)
+}
+
+func TestGoStructToSQL(t *testing.T) {
+	src := `type User struct {
+	ID        int64          ` + "`json:\"id\"`" + `
+	Email     string         ` + "`json:\"email\" db:\"email_address\"`" + `
+	Nickname  *string        ` + "`json:\"nickname\"`" + `
+	Bio       sql.NullString ` + "`json:\"bio\"`" + `
+	CreatedAt time.Time      ` + "`json:\"createdAt\"`" + `
+	Settings  map[string]any ` + "`json:\"settings\"`" + `
+	Avatar    []byte         ` + "`json:\"avatar\"`" + `
+	Password  string         ` + "`json:\"-\"`" + `
+}`
+	out, err := GoStructToSQL(src)
+	require.NoError(t, err)
+	require.Equal(t, `CREATE TABLE "user" (
+  id BIGINT PRIMARY KEY,
+  email_address TEXT NOT NULL,
+  nickname TEXT,
+  bio TEXT,
+  created_at TIMESTAMPTZ NOT NULL,
+  settings JSONB,
+  avatar BYTEA
+);
+`, out)
+
+	out, err = ConvertFormatsWithOptions(formatGoStruct, formatSQL, src, WithSQLDialect(SQLDialectMySQL))
+	require.NoError(t, err)
+	require.Contains(t, out, "CREATE TABLE `user` (")
+	require.Contains(t, out, "avatar BLOB")
+}
+
+const sqlUsersDDL = `-- accounts
+CREATE TABLE IF NOT EXISTS public."users" (
+  id BIGSERIAL,
+  email VARCHAR(255) NOT NULL UNIQUE,
+  nickname text,
+  age INT UNSIGNED DEFAULT 0 NOT NULL,
+  score NUMERIC(10, 2),
+  tags TEXT[] NOT NULL,
+  settings JSONB,
+  avatar BYTEA,
+  created_at TIMESTAMP(3) WITH TIME ZONE NOT NULL DEFAULT now(), /* audit */
+  PRIMARY KEY (id)
+);
+
+CREATE TABLE ` + "`orders`" + ` (
+  ` + "`id`" + ` int NOT NULL AUTO_INCREMENT PRIMARY KEY,
+  status enum('new','paid','it''s shipped') NOT NULL COMMENT 'Order status',
+  paid tinyint(1) NOT NULL,
+  placed_on date,
+  KEY idx_status (status)
+) ENGINE=InnoDB;`
+
+func TestSQLToGoStruct(t *testing.T) {
+	out, err := SQLToGoStruct(sqlUsersDDL)
+	require.NoError(t, err)
+	require.Equal(t, `import (
+	"encoding/json"
+	"time"
+)
+
+type Users struct {
+	Id        int64           `+"`json:\"id\" db:\"id\"`"+`
+	Email     string          `+"`json:\"email\" db:\"email\"`"+`
+	Nickname  *string         `+"`json:\"nickname\" db:\"nickname\"`"+`
+	Age       uint32          `+"`json:\"age\" db:\"age\"`"+`
+	Score     *float64        `+"`json:\"score\" db:\"score\"`"+`
+	Tags      []string        `+"`json:\"tags\" db:\"tags\"`"+`
+	Settings  json.RawMessage `+"`json:\"settings\" db:\"settings\"`"+`
+	Avatar    []byte          `+"`json:\"avatar\" db:\"avatar\"`"+`
+	CreatedAt time.Time       `+"`json:\"created_at\" db:\"created_at\"`"+`
+}
+
+type Orders struct {
+	Id int32 `+"`json:\"id\" db:\"id\"`"+`
+	// Order status
+	Status   string     `+"`json:\"status\" db:\"status\"`"+`
+	Paid     bool       `+"`json:\"paid\" db:\"paid\"`"+`
+	PlacedOn *time.Time `+"`json:\"placed_on\" db:\"placed_on\"`"+`
+}`, out)
+
+	out, err = ConvertFormatsWithOptions(formatSQL, formatGoStruct, sqlUsersDDL, WithTagCase(TagCaseCamel), WithOmitEmpty(true))
+	require.NoError(t, err)
+	require.Contains(t, out, "`json:\"createdAt,omitempty\" db:\"created_at\"`")
+
+	_, err = SQLToGoStruct("SELECT 1;")
+	require.EqualError(t, err, "no CREATE TABLE statement found")
+}
+
+func TestSQLToSchema(t *testing.T) {
+	out, err := SQLToSchema(sqlUsersDDL)
+	require.NoError(t, err)
+	var doc map[string]any
+	require.NoError(t, json.Unmarshal([]byte(out), &doc))
+	users := doc["$defs"].(map[string]any)["users"].(map[string]any)
+	require.Equal(t, []any{"id", "email", "age", "tags", "created_at"}, users["required"])
+	props := users["properties"].(map[string]any)
+	require.Equal(t, map[string]any{"type": "string", "maxLength": 255.0}, props["email"])
+	require.Equal(t, map[string]any{"type": []any{"string", "null"}}, props["nickname"])
+	require.Equal(t, map[string]any{"type": "array", "items": map[string]any{"type": "string"}}, props["tags"])
+	require.Equal(t, map[string]any{"type": "string", "format": "date-time"}, props["created_at"])
+	require.Equal(t, map[string]any{}, props["settings"])
+	orders := doc["$defs"].(map[string]any)["orders"].(map[string]any)["properties"].(map[string]any)
+	require.Equal(t, map[string]any{"type": "string", "enum": []any{"new", "paid", "it's shipped"}, "description": "Order status"}, orders["status"])
+
+	out, err = ConvertFormatsWithOptions(formatSQL, formatSchema, "create table t (n integer not null)", WithSchemaDraft(SchemaDraft07))
+	require.NoError(t, err)
+	require.JSONEq(t, `{"$schema":"http://json-schema.org/draft-07/schema#","title":"t","type":"object",
+		"properties":{"n":{"type":"integer"}},"required":["n"]}`, out)
+
+	out, err = ConvertFormats(formatSQL, formatJSON, sqlUsersDDL)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"age":0,"avatar":"","created_at":"","email":"","id":0,"nickname":"","score":0,"settings":{},"tags":[""]}`, out)
+
+	format, _, err := DetectFormat(sqlUsersDDL)
+	require.NoError(t, err)
+	require.Equal(t, formatSQL, format)
+}
@@ -0,0 +1,393 @@
+package convert
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Stream converts from r to w. Only JSON, NDJSON and YAML stream: between
+// those three, JSON top-level arrays, NDJSON lines and YAML documents are
+// read and written one record at a time, so large collections never have
+// to be held in memory at once. Every other pair reads all of r and calls
+// ConvertFormatsWithOptions on it. When from and to are the same format r
+// is copied to w unchanged, after checking that it parses; a streamed copy
+// may have written part of the input before it finds a bad record.
+func Stream(from, to string, r io.Reader, w io.Writer, opts ...ConvertOption) error {
+	o := NewConvertOptions(opts...)
+	if from == to {
+		return copyValidated(from, r, w, o)
+	}
+	sink := newRecordSink(to, w, o)
+	var src *recordStream
+	if sink != nil {
+		src = openRecordStream(from, r, o)
+	}
+	if src == nil {
+		input, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		out, err := ConvertFormatsWithOptions(from, to, string(input), WithOptions(o))
+		if err != nil {
+			return err
+		}
+		_, err = io.WriteString(w, out)
+		return err
+	}
+	first, err := src.next()
+	if errors.Is(err, io.EOF) {
+		if !src.seq {
+			return fmt.Errorf("empty %s input", from)
+		}
+		return sink.empty()
+	}
+	if err != nil {
+		return err
+	}
+	if !src.seq {
+		return sink.single(first)
+	}
+	for rec := first; ; {
+		if err := sink.item(rec); err != nil {
+			return err
+		}
+		rec, err = src.next()
+		if errors.Is(err, io.EOF) {
+			return sink.end()
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// copyValidated copies r to w unchanged, reading it as format on the way
+// so that input a conversion would reject fails here too.
+func copyValidated(format string, r io.Reader, w io.Writer, o ConvertOptions) error {
+	tee := io.TeeReader(r, w)
+	src := openRecordStream(format, tee, o)
+	if src == nil {
+		input, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		if err := Validate(format, string(input)); err != nil {
+			return err
+		}
+		_, err = w.Write(input)
+		return err
+	}
+	_, err := src.next()
+	for src.seq && err == nil {
+		_, err = src.next()
+	}
+	if errors.Is(err, io.EOF) && src.seq {
+		err = nil
+	}
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(io.Discard, tee)
+	return err
+}
+
+// recordStream yields records until io.EOF. When seq is false the input was
+// a single value, returned by the first call to next. Records keep their
+// key order, or have their keys sorted when SortKeys is set.
+type recordStream struct {
+	seq  bool
+	next func() (*docNode, error)
+}
+
+// openRecordStream returns nil, without reading r, for formats that cannot
+// be streamed.
+func openRecordStream(format string, r io.Reader, o ConvertOptions) *recordStream {
+	var src *recordStream
+	switch format {
+	case formatJSON:
+		src = jsonRecordStream(r)
+	case formatNDJSON:
+		src = ndjsonRecordStream(r)
+	case formatYAML:
+		src = yamlRecordStream(r, o)
+	default:
+		return nil
+	}
+	if o.SortKeys {
+		next := src.next
+		src.next = func() (*docNode, error) {
+			rec, err := next()
+			if err == nil {
+				rec.sortKeys(sortedNames)
+			}
+			return rec, err
+		}
+	}
+	return src
+}
+
+// decodeJSONRecord reads the next JSON value from dec into the document
+// model.
+func decodeJSONRecord(dec *json.Decoder) (*docNode, error) {
+	var raw json.RawMessage
+	if err := dec.Decode(&raw); err != nil {
+		return nil, err
+	}
+	return parseJSONDoc(string(raw))
+}
+
+func jsonRecordStream(r io.Reader) *recordStream {
+	br := bufio.NewReader(r)
+	c, peekErr := peekNonSpace(br)
+	dec := json.NewDecoder(br)
+	decode := func() (*docNode, error) { return decodeJSONRecord(dec) }
+	if peekErr != nil || c != '[' {
+		return &recordStream{next: func() (*docNode, error) {
+			if errors.Is(peekErr, io.EOF) {
+				return nil, errors.New("empty JSON input")
+			}
+			if peekErr != nil {
+				return nil, peekErr
+			}
+			return decode()
+		}}
+	}
+	opened := false
+	return &recordStream{seq: true, next: func() (*docNode, error) {
+		if !opened {
+			opened = true
+			if _, err := dec.Token(); err != nil {
+				return nil, err
+			}
+		}
+		if !dec.More() {
+			if _, err := dec.Token(); err != nil {
+				return nil, err
+			}
+			return nil, io.EOF
+		}
+		return decode()
+	}}
+}
+
+func peekNonSpace(br *bufio.Reader) (byte, error) {
+	for {
+		c, err := br.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		if c != ' ' && c != '\t' && c != '\r' && c != '\n' {
+			return c, br.UnreadByte()
+		}
+	}
+}
+
+func ndjsonRecordStream(r io.Reader) *recordStream {
+	br := bufio.NewReader(r)
+	line := 0
+	return &recordStream{seq: true, next: func() (*docNode, error) {
+		for {
+			raw, err := br.ReadBytes('\n')
+			if len(raw) == 0 && err != nil {
+				return nil, err
+			}
+			line++
+			text := bytes.TrimSpace(raw)
+			if len(text) == 0 {
+				if err != nil {
+					return nil, err
+				}
+				continue
+			}
+			dec := json.NewDecoder(bytes.NewReader(text))
+			record, err := decodeJSONRecord(dec)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			if dec.More() {
+				return nil, fmt.Errorf("line %d: multiple values on one line", line)
+			}
+			return record, nil
+		}
+	}}
+}
+
+// yamlRecordStream treats each document as a record. A stream holding one
+// document yields that document's sequence items, or the document itself.
+// Aliases are read as o says, with the alias limit applying per document.
+func yamlRecordStream(r io.Reader, o ConvertOptions) *recordStream {
+	dec := yaml.NewDecoder(r)
+	decode := func() (*docNode, error) {
+		var root yaml.Node
+		if err := dec.Decode(&root); err != nil {
+			return nil, err
+		}
+		reader, err := newYAMLReader(&docArena{}, o)
+		if err != nil {
+			return nil, err
+		}
+		return reader.read(&root, 0)
+	}
+	first, firstErr := decode()
+	if firstErr != nil {
+		return &recordStream{next: func() (*docNode, error) {
+			if errors.Is(firstErr, io.EOF) {
+				return nil, errors.New("empty YAML input")
+			}
+			return nil, firstErr
+		}}
+	}
+	second, secondErr := decode()
+	if errors.Is(secondErr, io.EOF) {
+		if first.kind != docArray {
+			return &recordStream{next: func() (*docNode, error) { return first, nil }}
+		}
+		items := first.items
+		return &recordStream{seq: true, next: func() (*docNode, error) {
+			if len(items) == 0 {
+				return nil, io.EOF
+			}
+			item := items[0]
+			items = items[1:]
+			return item, nil
+		}}
+	}
+	pending := []*docNode{first}
+	if secondErr == nil {
+		pending = append(pending, second)
+	}
+	return &recordStream{seq: true, next: func() (*docNode, error) {
+		if len(pending) > 0 {
+			v := pending[0]
+			pending = pending[1:]
+			return v, nil
+		}
+		if secondErr != nil {
+			return nil, secondErr
+		}
+		return decode()
+	}}
+}
+
+// recordSink writes either a single value or a sequence of items.
+type recordSink struct {
+	single func(*docNode) error
+	item   func(*docNode) error
+	end    func() error
+	empty  func() error
+}
+
+func newRecordSink(format string, w io.Writer, o ConvertOptions) *recordSink {
+	switch format {
+	case formatJSON:
+		return jsonRecordSink(w, o)
+	case formatNDJSON:
+		return ndjsonRecordSink(w)
+	case formatYAML:
+		return yamlRecordSink(w, o)
+	}
+	return nil
+}
+
+func jsonRecordSink(w io.Writer, o ConvertOptions) *recordSink {
+	indent := o.indentString()
+	encode := func(n *docNode, prefix string) ([]byte, error) {
+		var buf bytes.Buffer
+		if err := json.Indent(&buf, n.appendJSON(nil), prefix, indent); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+	count := 0
+	return &recordSink{
+		single: func(v *docNode) error {
+			out, err := encode(v, "")
+			if err != nil {
+				return err
+			}
+			_, err = w.Write(append(out, '\n'))
+			return err
+		},
+		item: func(v *docNode) error {
+			out, err := encode(v, indent)
+			if err != nil {
+				return err
+			}
+			sep := ",\n"
+			if count == 0 {
+				sep = "[\n"
+			}
+			count++
+			_, err = io.WriteString(w, sep+indent+string(out))
+			return err
+		},
+		end: func() error {
+			_, err := io.WriteString(w, "\n]\n")
+			return err
+		},
+		empty: func() error {
+			_, err := io.WriteString(w, "[]\n")
+			return err
+		},
+	}
+}
+
+func ndjsonRecordSink(w io.Writer) *recordSink {
+	write := func(v *docNode) error {
+		_, err := w.Write(append(v.appendJSON(nil), '\n'))
+		return err
+	}
+	return &recordSink{
+		single: write,
+		item:   write,
+		end:    func() error { return nil },
+		empty:  func() error { return nil },
+	}
+}
+
+func yamlRecordSink(w io.Writer, o ConvertOptions) *recordSink {
+	indent := o.Indent
+	if indent <= 0 {
+		indent = 2
+	}
+	pad := strings.Repeat(" ", indent)
+	return &recordSink{
+		single: func(v *docNode) error {
+			out, err := docYAML(v, o)
+			if err != nil {
+				return err
+			}
+			_, err = io.WriteString(w, out+"\n")
+			return err
+		},
+		item: func(v *docNode) error {
+			out, err := docYAML(v, o)
+			if err != nil {
+				return err
+			}
+			lines := strings.Split(out, "\n")
+			for i := range lines {
+				switch {
+				case i == 0:
+					lines[i] = "-" + pad[1:] + lines[i]
+				case lines[i] != "":
+					lines[i] = pad + lines[i]
+				}
+			}
+			_, err = io.WriteString(w, strings.Join(lines, "\n")+"\n")
+			return err
+		},
+		end: func() error { return nil },
+		empty: func() error {
+			_, err := io.WriteString(w, "[]\n")
+			return err
+		},
+	}
+}
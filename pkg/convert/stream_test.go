@@ -0,0 +1,89 @@
+package convert
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStream(t *testing.T) {
+	cases := []struct {
+		name, from, to, input, want string
+	}{
+		{"json array to ndjson", formatJSON, formatNDJSON, `[{"b":1,"a":"x<y"}, 2.5, null]`, "{\"b\":1,\"a\":\"x<y\"}\n2.5\nnull\n"},
+		{"ndjson to json", formatNDJSON, formatJSON, "{\"a\":1}\n\n[true]\n", "[\n  {\n    \"a\": 1\n  },\n  [\n    true\n  ]\n]\n"},
+		{"json object to yaml", formatJSON, formatYAML, `{"a":{"b":[1,2]}}`, "a:\n  b:\n    - 1\n    - 2\n"},
+		{"json array to yaml", formatJSON, formatYAML, `[{"a":1,"b":"two"},"x"]`, "- a: 1\n  b: two\n- x\n"},
+		{"yaml documents to ndjson", formatYAML, formatNDJSON, "a: 1\n---\na: 2\n", "{\"a\":1}\n{\"a\":2}\n"},
+		{"yaml sequence to json", formatYAML, formatJSON, "- 1\n- two\n", "[\n  1,\n  \"two\"\n]\n"},
+		{"empty array", formatJSON, formatYAML, ` [ ] `, "[]\n"},
+		{"fallback", formatJSON, formatTOML, `{"a":1}`, "a = 1\n"},
+		{"same format", formatJSON, formatJSON, `{"a" : 1}`, `{"a" : 1}`},
+		{"same format array", formatJSON, formatJSON, "[1, {\"b\": 2}]\n", "[1, {\"b\": 2}]\n"},
+		{"same format buffered", formatTOML, formatTOML, "b = 1 # one\n", "b = 1 # one\n"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var out bytes.Buffer
+			require.NoError(t, Stream(tc.from, tc.to, strings.NewReader(tc.input), &out))
+			require.Equal(t, tc.want, out.String())
+		})
+	}
+}
+
+func TestStreamMatchesConvertFormats(t *testing.T) {
+	input := `[{"id":1,"tags":["a","b"],"meta":{"ok":true}},{"id":2,"tags":[],"meta":null}]`
+	want, err := ConvertFormats(formatJSON, formatYAML, input)
+	require.NoError(t, err)
+	var out bytes.Buffer
+	require.NoError(t, Stream(formatJSON, formatYAML, strings.NewReader(input), &out))
+	require.Equal(t, want+"\n", out.String())
+
+	out.Reset()
+	require.NoError(t, Stream(formatJSON, formatYAML, strings.NewReader(input), &out, WithIndent(4)))
+	require.Contains(t, out.String(), "-   id: 1\n    tags:\n        - a\n")
+
+	out.Reset()
+	require.NoError(t, Stream(formatJSON, formatYAML, strings.NewReader(input), &out, WithIndent(4), WithSortKeys(true)))
+	require.Contains(t, out.String(), "-   id: 1\n    meta:\n        ok: true\n")
+}
+
+func TestStreamErrors(t *testing.T) {
+	var out bytes.Buffer
+	require.ErrorContains(t, Stream(formatNDJSON, formatJSON, strings.NewReader("{}\n{bad}\n"), &out), "line 2")
+	require.Error(t, Stream(formatJSON, formatNDJSON, strings.NewReader(`[1, {]`), &out))
+	require.ErrorContains(t, Stream(formatJSON, formatYAML, strings.NewReader("  "), &out), "empty JSON input")
+	require.Error(t, Stream(formatTOML, "Nope", strings.NewReader("a = 1"), &out))
+
+	require.Error(t, Stream(formatJSON, formatJSON, strings.NewReader(`[1, {]`), &out))
+	require.Error(t, Stream(formatJSON, formatJSON, strings.NewReader(`{"a":`), &out))
+	require.ErrorContains(t, Stream(formatNDJSON, formatNDJSON, strings.NewReader("{}\n{bad}\n"), &out), "line 2")
+	require.Error(t, Stream(formatYAML, formatYAML, strings.NewReader("a: [1\n"), &out))
+	out.Reset()
+	require.Error(t, Stream(formatTOML, formatTOML, strings.NewReader("a = "), &out))
+	require.Empty(t, out.String())
+}
+
+func BenchmarkStreamJSONToNDJSON(b *testing.B) {
+	var sb strings.Builder
+	sb.WriteString("[")
+	for i := 0; i < 10000; i++ {
+		if i > 0 {
+			sb.WriteString(",")
+		}
+		fmt.Fprintf(&sb, `{"id":%d,"name":"item-%d","tags":["x","y"]}`, i, i)
+	}
+	sb.WriteString("]")
+	input := sb.String()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var out bytes.Buffer
+		if err := Stream(formatJSON, formatNDJSON, strings.NewReader(input), &out); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
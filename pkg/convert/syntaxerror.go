@@ -0,0 +1,155 @@
+package convert
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"go/scanner"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/linzeyan/transform-go/pkg/common"
+	"github.com/pelletier/go-toml/v2"
+)
+
+// SyntaxError is input that does not parse, placed where the parser
+// stopped so an editor can mark it. Line and Column are 1-based, with
+// Column counted in characters, and Offset is the 0-based byte offset;
+// when the parser gives only the line, as YAML's does, Column is 0 and
+// Offset the start of the line. Snippet is the whole line. Error returns
+// the parser's message, whose own column may count bytes.
+type SyntaxError struct {
+	Format  string `json:"format,omitempty"`
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+	Offset  int    `json:"offset"`
+	Snippet string `json:"snippet"`
+	Message string `json:"message"`
+	err     error
+}
+
+func (e *SyntaxError) Error() string { return e.Message }
+
+// Unwrap returns the parser's own error, such as a *toml.DecodeError.
+func (e *SyntaxError) Unwrap() error { return e.err }
+
+// newSyntaxError places message at byte offset in input.
+func newSyntaxError(input string, offset int, message string, err error) *SyntaxError {
+	offset = min(max(offset, 0), len(input))
+	start := strings.LastIndexByte(input[:offset], '\n') + 1
+	end := len(input)
+	if i := strings.IndexByte(input[offset:], '\n'); i >= 0 {
+		end = offset + i
+	}
+	return &SyntaxError{
+		Line:    1 + strings.Count(input[:start], "\n"),
+		Column:  1 + utf8.RuneCountInString(input[start:offset]),
+		Offset:  offset,
+		Snippet: strings.TrimSuffix(input[start:end], "\r"),
+		Message: message,
+		err:     err,
+	}
+}
+
+// lineOffset returns the byte offset of a 1-based line and byte column in
+// input, clamped to the line.
+func lineOffset(input string, line, column int) int {
+	offset := 0
+	for ; line > 1; line-- {
+		i := strings.IndexByte(input[offset:], '\n')
+		if i < 0 {
+			return len(input)
+		}
+		offset += i + 1
+	}
+	end := len(input)
+	if i := strings.IndexByte(input[offset:], '\n'); i >= 0 {
+		end = offset + i
+	}
+	return min(offset+max(column-1, 0), end)
+}
+
+// yamlLineRe finds the line yaml.v3 puts in its syntax errors, and
+// toonLineRe the one the TOON parser ends its errors with.
+var (
+	yamlLineRe = common.LazyRegexp(`^yaml: line (\d+): `)
+	toonLineRe = common.LazyRegexp(`\bline (\d+)$`)
+)
+
+// withPosition returns err as a *SyntaxError in input of format when the
+// parser said where it stopped, and err unchanged otherwise.
+func withPosition(format, input string, err error) error {
+	if err == nil {
+		return nil
+	}
+	var syntaxErr *SyntaxError
+	if errors.As(err, &syntaxErr) {
+		if syntaxErr.Format == "" {
+			syntaxErr.Format = format
+		}
+		return err
+	}
+	var placed *SyntaxError
+	var jsonErr *json.SyntaxError
+	var tomlErr *toml.DecodeError
+	if errors.As(err, &jsonErr) {
+		// Offset counts the bytes read, the offending one included.
+		placed = newSyntaxError(input, int(jsonErr.Offset)-1, err.Error(), err)
+	} else if errors.As(err, &tomlErr) {
+		line, column := tomlErr.Position()
+		placed = newSyntaxError(input, lineOffset(input, line, column), err.Error(), err)
+	} else if m := lineOnlyRe(format).FindStringSubmatch(err.Error()); m != nil {
+		line, _ := strconv.Atoi(m[1])
+		placed = newSyntaxError(input, lineOffset(input, line, 1), err.Error(), err)
+		placed.Column = 0
+	} else {
+		var xmlErr *xml.SyntaxError
+		if !errors.As(err, &xmlErr) {
+			return err
+		}
+		placed = newSyntaxError(input, lineOffset(input, xmlErr.Line, 1), err.Error(), err)
+		placed.Column = 0
+	}
+	placed.Format = format
+	return placed
+}
+
+// lineOnlyRe returns the pattern that finds the line in format's errors
+// when its parser reports no column.
+func lineOnlyRe(format string) *regexp.Regexp {
+	if format == formatTOON {
+		return toonLineRe()
+	}
+	return yamlLineRe()
+}
+
+// xmlSyntaxError places an XML syntax error at the decoder's offset,
+// which is more precise than the line encoding/xml reports.
+func xmlSyntaxError(input string, dec *xml.Decoder, err error) error {
+	var xmlErr *xml.SyntaxError
+	if !errors.As(err, &xmlErr) {
+		return err
+	}
+	return newSyntaxError(input, int(dec.InputOffset()), err.Error(), err)
+}
+
+// goSyntaxError places the first error go/parser reported in source,
+// which is input with leading space trimmed and prefix, a package clause,
+// added, back in input. The message is repositioned to match.
+func goSyntaxError(input, prefix string, err error) error {
+	var list scanner.ErrorList
+	if !errors.As(err, &list) || len(list) == 0 {
+		return err
+	}
+	first := list[0]
+	lead := len(input) - len(strings.TrimLeftFunc(input, unicode.IsSpace))
+	placed := newSyntaxError(input, first.Pos.Offset-len(prefix)+lead, "", err)
+	placed.Message = "line " + strconv.Itoa(placed.Line) + ", column " + strconv.Itoa(placed.Column) + ": " + first.Msg
+	if len(list) > 1 {
+		placed.Message += " (and " + strconv.Itoa(len(list)-1) + " more errors)"
+	}
+	return placed
+}
@@ -0,0 +1,48 @@
+package convert
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSyntaxError(t *testing.T) {
+	for _, c := range []struct {
+		from, input  string
+		line, column int
+		snippet      string
+	}{
+		{formatJSON, "{\n  \"名\": 1,\n  \"b\": ]\n}", 3, 8, `  "b": ]`},
+		{formatYAML, "a: 1\nb: [\n", 2, 0, "b: ["},
+		{formatTOML, "a = 1\nb = \n", 2, 5, "b = "},
+		{formatXML, "<a>\n  <b>é</c>\n</a>", 2, 11, "  <b>é</c>"},
+		{formatGoStruct, "\n\ntype A struct {\n\tB int `json:\"b\"\n}", 4, 8, "\tB int `json:\"b\""},
+		{formatHCL, "a = 1\nb = [\n", 3, 1, ""},
+	} {
+		to := formatJSON
+		if c.from == formatJSON {
+			to = formatYAML
+		}
+		_, err := ConvertFormatsWithOptions(c.from, to, c.input)
+		var syntaxErr *SyntaxError
+		require.True(t, errors.As(err, &syntaxErr), "%s: %v", c.from, err)
+		require.Equal(t, c.from, syntaxErr.Format)
+		require.Equal(t, c.line, syntaxErr.Line, c.from)
+		require.Equal(t, c.column, syntaxErr.Column, c.from)
+		require.Equal(t, c.snippet, syntaxErr.Snippet, c.from)
+		require.NotEmpty(t, syntaxErr.Message, c.from)
+	}
+
+	_, err := FormatContentWithOptions(formatJSON, "[1,\n2,,3]", false)
+	var syntaxErr *SyntaxError
+	require.True(t, errors.As(err, &syntaxErr), err)
+	require.Equal(t, 2, syntaxErr.Line)
+	require.Equal(t, 3, syntaxErr.Column)
+	require.Equal(t, 6, syntaxErr.Offset)
+
+	// Errors that are not about the input's syntax stay as they were.
+	_, err = ConvertFormatsWithOptions(formatJSON, "Nope", "{}")
+	require.Error(t, err)
+	require.False(t, errors.As(err, &syntaxErr))
+}
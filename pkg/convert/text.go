@@ -0,0 +1,252 @@
+package convert
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/linzeyan/transform-go/pkg/common"
+)
+
+var (
+	shortcodeRe      = common.LazyRegexp(`:([a-z0-9_+\-]+):`)
+	emojiByShortcode = map[string]string{
+		"+1":                       "\U0001F44D",
+		"-1":                       "\U0001F44E",
+		"100":                      "\U0001F4AF",
+		"alarm_clock":              "\u23f0",
+		"ambulance":                "\U0001F691",
+		"angry":                    "\U0001F620",
+		"apple":                    "\U0001F34E",
+		"arrow_down":               "\u2b07\ufe0f",
+		"arrow_left":               "\u2b05\ufe0f",
+		"arrow_right":              "\u27a1\ufe0f",
+		"arrow_up":                 "\u2b06\ufe0f",
+		"art":                      "\U0001F3A8",
+		"beer":                     "\U0001F37A",
+		"bell":                     "\U0001F514",
+		"bento":                    "\U0001F371",
+		"bookmark":                 "\U0001F516",
+		"books":                    "\U0001F4DA",
+		"boom":                     "\U0001F4A5",
+		"bug":                      "\U0001F41B",
+		"bulb":                     "\U0001F4A1",
+		"calendar":                 "\U0001F4C6",
+		"camera":                   "\U0001F4F7",
+		"card_file_box":            "\U0001F5C3\ufe0f",
+		"cat":                      "\U0001F431",
+		"chart_with_upwards_trend": "\U0001F4C8",
+		"clap":                     "\U0001F44F",
+		"clipboard":                "\U0001F4CB",
+		"coffee":                   "\u2615",
+		"computer":                 "\U0001F4BB",
+		"confused":                 "\U0001F615",
+		"construction":             "\U0001F6A7",
+		"cry":                      "\U0001F622",
+		"dart":                     "\U0001F3AF",
+		"dog":                      "\U0001F436",
+		"dizzy":                    "\U0001F4AB",
+		"email":                    "\U0001F4E7",
+		"eyes":                     "\U0001F440",
+		"fire":                     "\U0001F525",
+		"gear":                     "\u2699\ufe0f",
+		"gift":                     "\U0001F381",
+		"globe_with_meridians":     "\U0001F310",
+		"green_heart":              "\U0001F49A",
+		"grin":                     "\U0001F601",
+		"grinning":                 "\U0001F600",
+		"hammer":                   "\U0001F528",
+		"hankey":                   "\U0001F4A9",
+		"heart":                    "\u2764\ufe0f",
+		"heart_eyes":               "\U0001F60D",
+		"heavy_check_mark":         "\u2714\ufe0f",
+		"heavy_minus_sign":         "\u2796",
+		"heavy_plus_sign":          "\u2795",
+		"hourglass":                "\u231b",
+		"information_source":       "\u2139\ufe0f",
+		"joy":                      "\U0001F602",
+		"key":                      "\U0001F511",
+		"label":                    "\U0001F3F7\ufe0f",
+		"laughing":                 "\U0001F606",
+		"link":                     "\U0001F517",
+		"lipstick":                 "\U0001F484",
+		"lock":                     "\U0001F512",
+		"loud_sound":               "\U0001F50A",
+		"mag":                      "\U0001F50D",
+		"memo":                     "\U0001F4DD",
+		"moneybag":                 "\U0001F4B0",
+		"muscle":                   "\U0001F4AA",
+		"mute":                     "\U0001F507",
+		"new":                      "\U0001F195",
+		"no_entry":                 "\u26d4",
+		"ok":                       "\U0001F197",
+		"ok_hand":                  "\U0001F44C",
+		"package":                  "\U0001F4E6",
+		"pencil2":                  "\u270f\ufe0f",
+		"point_right":              "\U0001F449",
+		"pray":                     "\U0001F64F",
+		"pushpin":                  "\U0001F4CC",
+		"question":                 "\u2753",
+		"rainbow":                  "\U0001F308",
+		"raised_hands":             "\U0001F64C",
+		"recycle":                  "\u267b\ufe0f",
+		"red_circle":               "\U0001F534",
+		"rewind":                   "\u23ea",
+		"rocket":                   "\U0001F680",
+		"rotating_light":           "\U0001F6A8",
+		"see_no_evil":              "\U0001F648",
+		"seedling":                 "\U0001F331",
+		"shield":                   "\U0001F6E1\ufe0f",
+		"smile":                    "\U0001F604",
+		"smiley":                   "\U0001F603",
+		"smirk":                    "\U0001F60F",
+		"sob":                      "\U0001F62D",
+		"sparkles":                 "\u2728",
+		"speech_balloon":           "\U0001F4AC",
+		"star":                     "\u2b50",
+		"star2":                    "\U0001F31F",
+		"sunglasses":               "\U0001F60E",
+		"sunny":                    "\u2600\ufe0f",
+		"tada":                     "\U0001F389",
+		"thinking":                 "\U0001F914",
+		"thumbsdown":               "\U0001F44E",
+		"thumbsup":                 "\U0001F44D",
+		"trophy":                   "\U0001F3C6",
+		"truck":                    "\U0001F69A",
+		"umbrella":                 "\u2614",
+		"unlock":                   "\U0001F513",
+		"warning":                  "\u26a0\ufe0f",
+		"wave":                     "\U0001F44B",
+		"white_check_mark":         "\u2705",
+		"wink":                     "\U0001F609",
+		"wrench":                   "\U0001F527",
+		"x":                        "\u274c",
+		"zap":                      "\u26a1",
+		"zzz":                      "\U0001F4A4",
+	}
+)
+
+// emojiReplacer is built on first use from emojiByShortcode. Aliases such
+// as :+1: and :thumbsup: map back to the alphabetically first name.
+var emojiReplacer = sync.OnceValue(func() *strings.Replacer {
+	shortcodes := map[string]string{}
+	for code, emoji := range emojiByShortcode {
+		if prev, ok := shortcodes[emoji]; ok && prev < code {
+			continue
+		}
+		shortcodes[emoji] = code
+	}
+	// Match each emoji with and without its variation selector, longest
+	// first so "❤️" is not split into "❤" plus a stray selector.
+	keys := make([]string, 0, len(shortcodes)*2)
+	byKey := map[string]string{}
+	for emoji, code := range shortcodes {
+		keys = append(keys, emoji)
+		byKey[emoji] = code
+		if bare := strings.TrimSuffix(emoji, "\ufe0f"); bare != emoji {
+			keys = append(keys, bare)
+			byKey[bare] = code
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if len(keys[i]) != len(keys[j]) {
+			return len(keys[i]) > len(keys[j])
+		}
+		return keys[i] < keys[j]
+	})
+	pairs := make([]string, 0, len(keys)*2)
+	for _, k := range keys {
+		pairs = append(pairs, k, ":"+byKey[k]+":")
+	}
+	return strings.NewReplacer(pairs...)
+})
+
+// Slugify lowercases text and joins its letter and digit runs with hyphens,
+// for URLs and file names.
+func Slugify(text string) string {
+	var b strings.Builder
+	pendingDash := false
+	for _, r := range strings.ToLower(text) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if pendingDash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			pendingDash = false
+			b.WriteRune(r)
+			continue
+		}
+		pendingDash = true
+	}
+	return b.String()
+}
+
+// HeadingAnchor returns the anchor GitHub generates for a heading: lower
+// case, punctuation and emoji dropped, spaces turned into hyphens.
+func HeadingAnchor(text string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '_':
+			b.WriteRune(r)
+		case r == ' ':
+			b.WriteByte('-')
+		}
+	}
+	return b.String()
+}
+
+// ShortcodeToEmoji replaces known :shortcode: names with their emoji and
+// leaves unknown ones untouched.
+func ShortcodeToEmoji(text string) string {
+	return shortcodeRe().ReplaceAllStringFunc(text, func(m string) string {
+		if emoji, ok := emojiByShortcode[m[1:len(m)-1]]; ok {
+			return emoji
+		}
+		return m
+	})
+}
+
+// EmojiToShortcode replaces known emoji with their :shortcode: names.
+func EmojiToShortcode(text string) string {
+	return emojiReplacer().Replace(text)
+}
+
+// StripEmoji removes emoji along with the joiners, variation selectors and
+// skin tone modifiers that build emoji sequences.
+func StripEmoji(text string) string {
+	return strings.Map(func(r rune) rune {
+		if isEmojiRune(r) {
+			return -1
+		}
+		return r
+	}, text)
+}
+
+// StripControlChars removes control and invisible format characters (such
+// as zero-width spaces and bidi overrides), keeping tabs and line breaks.
+func StripControlChars(text string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == '\t', r == '\n', r == '\r':
+			return r
+		case unicode.IsControl(r), unicode.Is(unicode.Cf, r):
+			return -1
+		}
+		return r
+	}, text)
+}
+
+func isEmojiRune(r rune) bool {
+	switch {
+	case r >= 0x1F000 && r <= 0x1FAFF, // pictographs, emoticons, flags, modifiers
+		r >= 0x2600 && r <= 0x27BF,                                                     // misc symbols and dingbats
+		r >= 0x2B00 && r <= 0x2BFF,                                                     // arrows and stars
+		r == 0x231A, r == 0x231B, r == 0x2328, r == 0x23CF, r >= 0x23E9 && r <= 0x23FA, // ⌚ ⏰ and friends
+		r >= 0xE0020 && r <= 0xE007F, // tag sequences
+		r == 0x200D, r == 0xFE0F, r == 0x20E3,
+		r == 0x2139, r == 0x3030, r == 0x303D, r == 0x3297, r == 0x3299:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,27 @@
+package convert
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlugifyAndHeadingAnchor(t *testing.T) {
+	require.Equal(t, "hello-world-2024", Slugify("  Hello, World! -- 2024 "))
+	require.Equal(t, "café-déjà-vu", Slugify("Café / Déjà vu"))
+	require.Equal(t, "whats-new-in-v12", HeadingAnchor("What's new in v1.2?"))
+	require.Equal(t, "-release-notes", HeadingAnchor("\U0001F389 Release notes"))
+	require.Equal(t, "snake_case--kebab", HeadingAnchor("snake_case & kebab"))
+}
+
+func TestEmojiShortcodes(t *testing.T) {
+	require.Equal(t, "Shipped \U0001F389 with \u2764\ufe0f :unknown:", ShortcodeToEmoji("Shipped :tada: with :heart: :unknown:"))
+	require.Equal(t, "Shipped :tada: with :heart: and :heart:", EmojiToShortcode("Shipped \U0001F389 with \u2764\ufe0f and \u2764"))
+	require.Equal(t, ":+1:", EmojiToShortcode(ShortcodeToEmoji(":thumbsup:")))
+}
+
+func TestStripEmojiAndControlChars(t *testing.T) {
+	family := "\U0001F468\u200d\U0001F469\u200d\U0001F467"
+	require.Equal(t, "Team  rocks ", StripEmoji("Team "+family+" rocks \U0001F44D\U0001F3FD"))
+	require.Equal(t, "a\tb\nc", StripControlChars("a\tb\x00\n\u200bc\u202e\x1b"))
+}
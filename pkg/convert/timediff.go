@@ -0,0 +1,179 @@
+package convert
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TimeDiffResult is the span between two timestamps. Negative is set when
+// To is before From; every other field is then the absolute span.
+type TimeDiffResult struct {
+	From         string       `json:"from"`
+	To           string       `json:"to"`
+	Negative     bool         `json:"negative"`
+	Duration     string       `json:"duration"`
+	Nanoseconds  int64        `json:"nanoseconds"`
+	Seconds      float64      `json:"seconds"`
+	Minutes      float64      `json:"minutes"`
+	Hours        float64      `json:"hours"`
+	Days         float64      `json:"days"`
+	Weeks        float64      `json:"weeks"`
+	Calendar     CalendarSpan `json:"calendar"`
+	BusinessDays int          `json:"businessDays"`
+	ISO8601      string       `json:"iso8601"`
+	Human        string       `json:"human"`
+}
+
+// CalendarSpan breaks a span into calendar units; months are counted from
+// the start date, clamped to the end of shorter months.
+type CalendarSpan struct {
+	Years       int `json:"years"`
+	Months      int `json:"months"`
+	Days        int `json:"days"`
+	Hours       int `json:"hours"`
+	Minutes     int `json:"minutes"`
+	Seconds     int `json:"seconds"`
+	Nanoseconds int `json:"nanoseconds,omitempty"`
+}
+
+// TimeDiff measures b - a. Both accept anything the timestamp parser does;
+// values without an offset are read as UTC.
+func TimeDiff(a, b string) (TimeDiffResult, error) {
+	from, _, err := parseTimestamp(a, time.UTC)
+	if err != nil {
+		return TimeDiffResult{}, fmt.Errorf("from: %w", err)
+	}
+	to, _, err := parseTimestamp(b, time.UTC)
+	if err != nil {
+		return TimeDiffResult{}, fmt.Errorf("to: %w", err)
+	}
+	res := TimeDiffResult{
+		From: from.Format(time.RFC3339Nano),
+		To:   to.Format(time.RFC3339Nano),
+	}
+	start, end := from, to.In(from.Location())
+	if end.Before(start) {
+		res.Negative = true
+		start, end = end, start
+	}
+	d := end.Sub(start)
+	res.Duration = d.String()
+	res.Nanoseconds = d.Nanoseconds()
+	res.Seconds = d.Seconds()
+	res.Minutes = d.Minutes()
+	res.Hours = d.Hours()
+	res.Days = d.Hours() / 24
+	res.Weeks = res.Days / 7
+	res.Calendar = calendarSpan(start, end)
+	res.BusinessDays = businessDays(start, end)
+	res.ISO8601 = res.Calendar.iso8601()
+	res.Human = res.Calendar.human()
+	if res.Negative {
+		res.ISO8601 = "-" + res.ISO8601
+		res.Human += " ago"
+	}
+	return res, nil
+}
+
+func calendarSpan(start, end time.Time) CalendarSpan {
+	months := (end.Year()-start.Year())*12 + int(end.Month()-start.Month())
+	anchor := addMonthsClamped(start, months)
+	for months > 0 && anchor.After(end) {
+		months--
+		anchor = addMonthsClamped(start, months)
+	}
+	rest := end.Sub(anchor)
+	span := CalendarSpan{Years: months / 12, Months: months % 12}
+	span.Days = int(rest / (24 * time.Hour))
+	rest %= 24 * time.Hour
+	span.Hours = int(rest / time.Hour)
+	span.Minutes = int(rest % time.Hour / time.Minute)
+	span.Seconds = int(rest % time.Minute / time.Second)
+	span.Nanoseconds = int(rest % time.Second)
+	return span
+}
+
+// addMonthsClamped adds months without overflowing short months, so Jan 31
+// plus one month is the last day of February rather than early March.
+func addMonthsClamped(t time.Time, months int) time.Time {
+	y, m, d := t.Date()
+	first := time.Date(y, m+time.Month(months), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	if last := first.AddDate(0, 1, -1).Day(); d > last {
+		d = last
+	}
+	return first.AddDate(0, 0, d-1)
+}
+
+// businessDays counts Monday–Friday dates from start (inclusive) to end
+// (exclusive).
+func businessDays(start, end time.Time) int {
+	s := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, time.UTC)
+	e := time.Date(end.Year(), end.Month(), end.Day(), 0, 0, 0, 0, time.UTC)
+	days := int(e.Sub(s).Hours() / 24)
+	count := days / 7 * 5
+	wd := s.Weekday()
+	for i := 0; i < days%7; i++ {
+		if wd != time.Saturday && wd != time.Sunday {
+			count++
+		}
+		wd = (wd + 1) % 7
+	}
+	return count
+}
+
+func (c CalendarSpan) iso8601() string {
+	var b strings.Builder
+	b.WriteString("P")
+	for _, part := range []struct {
+		n    int
+		unit string
+	}{{c.Years, "Y"}, {c.Months, "M"}, {c.Days, "D"}} {
+		if part.n != 0 {
+			b.WriteString(strconv.Itoa(part.n) + part.unit)
+		}
+	}
+	if c.Hours != 0 || c.Minutes != 0 || c.Seconds != 0 || c.Nanoseconds != 0 {
+		b.WriteString("T")
+		if c.Hours != 0 {
+			b.WriteString(strconv.Itoa(c.Hours) + "H")
+		}
+		if c.Minutes != 0 {
+			b.WriteString(strconv.Itoa(c.Minutes) + "M")
+		}
+		if c.Seconds != 0 || c.Nanoseconds != 0 {
+			secs := strconv.Itoa(c.Seconds)
+			if c.Nanoseconds != 0 {
+				secs += strings.TrimRight(fmt.Sprintf(".%09d", c.Nanoseconds), "0")
+			}
+			b.WriteString(secs + "S")
+		}
+	}
+	if b.Len() == 1 {
+		return "PT0S"
+	}
+	return b.String()
+}
+
+func (c CalendarSpan) human() string {
+	var parts []string
+	for _, part := range []struct {
+		n    int
+		unit string
+	}{
+		{c.Years, "year"}, {c.Months, "month"}, {c.Days, "day"},
+		{c.Hours, "hour"}, {c.Minutes, "minute"}, {c.Seconds, "second"},
+	} {
+		switch {
+		case part.n == 1:
+			parts = append(parts, "1 "+part.unit)
+		case part.n > 1:
+			parts = append(parts, fmt.Sprintf("%d %ss", part.n, part.unit))
+		}
+	}
+	if len(parts) == 0 {
+		return "0 seconds"
+	}
+	return strings.Join(parts, ", ")
+}
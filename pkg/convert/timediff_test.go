@@ -0,0 +1,39 @@
+package convert
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimeDiff(t *testing.T) {
+	res, err := TimeDiff("2024-01-31T10:00:00Z", "2024-03-01 09:30:15")
+	require.NoError(t, err)
+	require.False(t, res.Negative)
+	require.Equal(t, CalendarSpan{Months: 1, Hours: 23, Minutes: 30, Seconds: 15}, res.Calendar)
+	require.Equal(t, "P1MT23H30M15S", res.ISO8601)
+	require.Equal(t, "1 month, 23 hours, 30 minutes, 15 seconds", res.Human)
+	require.Equal(t, "719h30m15s", res.Duration)
+	require.InDelta(t, 29.98, res.Days, 0.01)
+	// Jan 31 (Wed) through Feb 29, exclusive of Mar 1.
+	require.Equal(t, 22, res.BusinessDays)
+
+	neg, err := TimeDiff("1700000000", "1699999999500")
+	require.NoError(t, err)
+	require.True(t, neg.Negative)
+	require.Equal(t, "-PT0.5S", neg.ISO8601)
+	require.Equal(t, int64(500000000), neg.Nanoseconds)
+
+	zero, err := TimeDiff("2024-05-05", "2024-05-05T00:00:00Z")
+	require.NoError(t, err)
+	require.Equal(t, "PT0S", zero.ISO8601)
+	require.Equal(t, "0 seconds", zero.Human)
+
+	year, err := TimeDiff("2023-02-28", "2024-02-29T12:00:00+00:00")
+	require.NoError(t, err)
+	require.Equal(t, "P1Y1DT12H", year.ISO8601)
+	require.Equal(t, 262, year.BusinessDays)
+
+	_, err = TimeDiff("yesterday-ish", "2024-01-01")
+	require.ErrorContains(t, err, "from:")
+}
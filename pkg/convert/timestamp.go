@@ -0,0 +1,225 @@
+package convert
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/linzeyan/transform-go/pkg/common"
+)
+
+var (
+	unixTimestampRe = common.LazyRegexp(`^-?\d+(?:\.\d+)?$`)
+	utcOffsetRe     = common.LazyRegexp(`(?i)^(?:UTC|GMT)?\s*([+-])(\d{1,2})(?::?(\d{2}))?$`)
+)
+
+// timestampLayouts are tried in order; layouts without a zone are read in
+// the caller's location.
+var timestampLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999 -0700 MST",
+	"2006-01-02 15:04:05.999999999 -0700",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	"2006/01/02 15:04:05",
+	"2006/01/02",
+	time.RFC1123Z,
+	time.RFC1123,
+	time.RFC850,
+	time.RFC822Z,
+	time.RFC822,
+	time.UnixDate,
+	time.RubyDate,
+	time.ANSIC,
+	"Jan 2, 2006 15:04:05",
+	"Jan 2, 2006",
+	"2 Jan 2006 15:04:05",
+	"2 Jan 2006",
+	"20060102T150405Z0700",
+	"20060102T150405Z",
+	"20060102",
+}
+
+// parseTimestamp reads RFC 3339/ISO 8601, common textual layouts and Unix
+// epochs in seconds, milliseconds, microseconds or nanoseconds (chosen by
+// magnitude). An empty string means now.
+func parseTimestamp(input string, loc *time.Location) (time.Time, string, error) {
+	s := strings.TrimSpace(input)
+	if loc == nil {
+		loc = time.UTC
+	}
+	if s == "" || strings.EqualFold(s, "now") {
+		return time.Now().In(loc), "now", nil
+	}
+	if unixTimestampRe().MatchString(s) && !(len(s) == 8 && !strings.ContainsAny(s, "-.")) {
+		t, unit, err := parseUnixTimestamp(s)
+		if err != nil {
+			return time.Time{}, "", err
+		}
+		return t.In(loc), unit, nil
+	}
+	for _, layout := range timestampLayouts {
+		if t, err := time.ParseInLocation(layout, s, loc); err == nil {
+			return t, layout, nil
+		}
+	}
+	return time.Time{}, "", fmt.Errorf("unrecognized timestamp: %s", s)
+}
+
+func parseUnixTimestamp(s string) (time.Time, string, error) {
+	intPart, frac, _ := strings.Cut(strings.TrimPrefix(s, "-"), ".")
+	digits := len(intPart)
+	unit, scale := "unix", int64(1)
+	switch {
+	case digits > 18:
+		unit, scale = "unix_ns", int64(time.Nanosecond)
+	case digits > 15:
+		unit, scale = "unix_us", int64(time.Microsecond)
+	case digits > 12:
+		unit, scale = "unix_ms", int64(time.Millisecond)
+	default:
+		scale = int64(time.Second)
+	}
+	whole, err := strconv.ParseInt(intPart, 10, 64)
+	if err != nil {
+		return time.Time{}, "", errors.New("timestamp out of range")
+	}
+	nanos := whole * scale
+	if scale > 0 && nanos/scale != whole {
+		return time.Time{}, "", errors.New("timestamp out of range")
+	}
+	if frac != "" {
+		f, _ := strconv.ParseFloat("0."+frac, 64)
+		nanos += int64(f * float64(scale))
+	}
+	if strings.HasPrefix(s, "-") {
+		nanos = -nanos
+	}
+	return time.Unix(0, nanos).UTC(), unit, nil
+}
+
+// loadZone accepts IANA names, "Local", "UTC"/"Z" and fixed offsets such as
+// "+08:00", "-0530" or "UTC+8".
+func loadZone(name string) (*time.Location, error) {
+	s := strings.TrimSpace(name)
+	switch strings.ToUpper(s) {
+	case "", "UTC", "Z", "GMT":
+		return time.UTC, nil
+	}
+	if m := utcOffsetRe().FindStringSubmatch(s); m != nil {
+		hours, _ := strconv.Atoi(m[2])
+		mins, _ := strconv.Atoi(m[3])
+		if hours > 14 || mins > 59 {
+			return nil, fmt.Errorf("invalid UTC offset %s", s)
+		}
+		secs := hours*3600 + mins*60
+		if m[1] == "-" {
+			secs = -secs
+		}
+		return time.FixedZone(formatOffset(secs), secs), nil
+	}
+	loc, err := time.LoadLocation(s)
+	if err != nil {
+		return nil, fmt.Errorf("unknown time zone %s", s)
+	}
+	return loc, nil
+}
+
+func formatOffset(secs int) string {
+	sign := "+"
+	if secs < 0 {
+		sign = "-"
+		secs = -secs
+	}
+	return fmt.Sprintf("%s%02d:%02d", sign, secs/3600, secs/60%60)
+}
+
+// TimestampResult is one instant in every common representation. Local
+// fields are in the selected zone.
+type TimestampResult struct {
+	Input     string `json:"input"`
+	Detected  string `json:"detected"`
+	Zone      string `json:"zone"`
+	Unix      int64  `json:"unix"`
+	UnixMilli int64  `json:"unixMilli"`
+	UnixMicro int64  `json:"unixMicro"`
+	UnixNano  int64  `json:"unixNano"`
+	UTC       string `json:"utc"`
+	Local     string `json:"local"`
+	RFC1123   string `json:"rfc1123"`
+	RFC822    string `json:"rfc822"`
+	UnixDate  string `json:"unixDate"`
+	DayOfWeek string `json:"dayOfWeek"`
+	DayOfYear int    `json:"dayOfYear"`
+	ISOWeek   string `json:"isoWeek"`
+	Offset    string `json:"offset"`
+	IsDST     bool   `json:"isDST"`
+	Relative  string `json:"relative"`
+}
+
+// TimestampInfo reads input as a Unix epoch (seconds, milliseconds,
+// microseconds or nanoseconds), RFC 3339, RFC 1123 or another common date
+// layout and shows it in zone, an IANA name or fixed offset (UTC when
+// empty). Inputs without an offset are read in zone. Detected is the epoch
+// unit or layout that matched.
+func TimestampInfo(input, zone string) (TimestampResult, error) {
+	return timestampInfoAt(input, zone, time.Now())
+}
+
+func timestampInfoAt(input, zone string, now time.Time) (TimestampResult, error) {
+	loc, err := loadZone(zone)
+	if err != nil {
+		return TimestampResult{}, err
+	}
+	t, detected, err := parseTimestamp(input, loc)
+	if err != nil {
+		return TimestampResult{}, err
+	}
+	local := t.In(loc)
+	_, offset := local.Zone()
+	year, week := local.ISOWeek()
+	return TimestampResult{
+		Input:     strings.TrimSpace(input),
+		Detected:  detected,
+		Zone:      loc.String(),
+		Unix:      t.Unix(),
+		UnixMilli: t.UnixMilli(),
+		UnixMicro: t.UnixMicro(),
+		UnixNano:  t.UnixNano(),
+		UTC:       t.UTC().Format(time.RFC3339Nano),
+		Local:     local.Format(time.RFC3339Nano),
+		RFC1123:   local.Format(time.RFC1123Z),
+		RFC822:    local.Format(time.RFC822Z),
+		UnixDate:  local.Format(time.UnixDate),
+		DayOfWeek: local.Weekday().String(),
+		DayOfYear: local.YearDay(),
+		ISOWeek:   fmt.Sprintf("%04d-W%02d", year, week),
+		Offset:    formatOffset(offset),
+		IsDST:     local.IsDST(),
+		Relative:  relativeTime(t, now),
+	}, nil
+}
+
+// relativeTime names the largest calendar unit between t and now, such as
+// "3 days ago" or "in 2 hours".
+func relativeTime(t, now time.Time) string {
+	start, end := t, now.In(t.Location())
+	future := end.Before(start)
+	if future {
+		start, end = end, start
+	}
+	human := calendarSpan(start, end).human()
+	if human == "0 seconds" {
+		return "now"
+	}
+	human, _, _ = strings.Cut(human, ", ")
+	if future {
+		return "in " + human
+	}
+	return human + " ago"
+}
@@ -0,0 +1,43 @@
+package convert
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimestampInfo(t *testing.T) {
+	now := time.Date(2023, 11, 17, 22, 13, 21, 0, time.UTC)
+
+	res, err := timestampInfoAt("1700000000123", "Asia/Taipei", now)
+	require.NoError(t, err)
+	require.Equal(t, "unix_ms", res.Detected)
+	require.Equal(t, int64(1700000000), res.Unix)
+	require.Equal(t, int64(1700000000123), res.UnixMilli)
+	require.Equal(t, "2023-11-14T22:13:20.123Z", res.UTC)
+	require.Equal(t, "2023-11-15T06:13:20.123+08:00", res.Local)
+	require.Equal(t, "Wed, 15 Nov 2023 06:13:20 +0800", res.RFC1123)
+	require.Equal(t, "Wednesday", res.DayOfWeek)
+	require.Equal(t, 319, res.DayOfYear)
+	require.Equal(t, "2023-W46", res.ISOWeek)
+	require.Equal(t, "+08:00", res.Offset)
+	require.Equal(t, "3 days ago", res.Relative)
+
+	res, err = timestampInfoAt("2024-07-01 09:00", "America/New_York", now)
+	require.NoError(t, err)
+	require.Equal(t, "2024-07-01T13:00:00Z", res.UTC)
+	require.True(t, res.IsDST)
+	require.Equal(t, "Monday", res.DayOfWeek)
+	require.Equal(t, "in 7 months", res.Relative)
+
+	res, err = timestampInfoAt("Fri, 17 Nov 2023 22:13:21 GMT", "", now)
+	require.NoError(t, err)
+	require.Equal(t, time.RFC1123, res.Detected)
+	require.Equal(t, "now", res.Relative)
+
+	_, err = timestampInfoAt("soon", "", now)
+	require.Error(t, err)
+	_, err = timestampInfoAt("0", "Nowhere/City", now)
+	require.Error(t, err)
+}
@@ -0,0 +1,108 @@
+package convert
+
+import (
+	"time"
+	_ "time/tzdata" // wasm builds have no system zoneinfo
+)
+
+// TimezoneResult describes a zone at a reference instant.
+type TimezoneResult struct {
+	Zone          string               `json:"zone"`
+	Time          string               `json:"time"`
+	Unix          int64                `json:"unix"`
+	Abbreviation  string               `json:"abbreviation"`
+	Offset        string               `json:"offset"`
+	OffsetSeconds int                  `json:"offsetSeconds"`
+	IsDST         bool                 `json:"isDST"`
+	Transitions   []TimezoneTransition `json:"transitions"`
+	Conversions   []TimezoneConversion `json:"conversions,omitempty"`
+}
+
+// TimezoneTransition is an offset change; At is the UTC instant it happens.
+type TimezoneTransition struct {
+	At           string `json:"at"`
+	Local        string `json:"local"`
+	From         string `json:"from"`
+	To           string `json:"to"`
+	Abbreviation string `json:"abbreviation"`
+	IsDST        bool   `json:"isDST"`
+}
+
+// TimezoneConversion is the reference instant shown in another zone.
+type TimezoneConversion struct {
+	Zone         string `json:"zone"`
+	Time         string `json:"time,omitempty"`
+	Abbreviation string `json:"abbreviation,omitempty"`
+	Offset       string `json:"offset,omitempty"`
+	Error        string `json:"error,omitempty"`
+}
+
+// TimezoneInfo reports the offset and DST state of zone (an IANA name or a
+// fixed offset such as "+05:30") at timestamp, every transition in that
+// year, and the same instant in each of targets. An empty timestamp means
+// now; timestamps without an offset are read in zone.
+func TimezoneInfo(zone, timestamp string, targets []string) (TimezoneResult, error) {
+	loc, err := loadZone(zone)
+	if err != nil {
+		return TimezoneResult{}, err
+	}
+	t, _, err := parseTimestamp(timestamp, loc)
+	if err != nil {
+		return TimezoneResult{}, err
+	}
+	t = t.In(loc)
+	abbr, offset := t.Zone()
+	res := TimezoneResult{
+		Zone:          loc.String(),
+		Time:          t.Format(time.RFC3339),
+		Unix:          t.Unix(),
+		Abbreviation:  abbr,
+		Offset:        formatOffset(offset),
+		OffsetSeconds: offset,
+		IsDST:         t.IsDST(),
+		Transitions:   zoneTransitions(loc, t.Year()),
+	}
+	for _, name := range targets {
+		conv := TimezoneConversion{Zone: name}
+		target, err := loadZone(name)
+		if err != nil {
+			conv.Error = err.Error()
+			res.Conversions = append(res.Conversions, conv)
+			continue
+		}
+		local := t.In(target)
+		abbr, offset := local.Zone()
+		conv.Zone = target.String()
+		conv.Time = local.Format(time.RFC3339)
+		conv.Abbreviation = abbr
+		conv.Offset = formatOffset(offset)
+		res.Conversions = append(res.Conversions, conv)
+	}
+	return res, nil
+}
+
+func zoneTransitions(loc *time.Location, year int) []TimezoneTransition {
+	transitions := []TimezoneTransition{}
+	cur := time.Date(year, time.January, 1, 0, 0, 0, 0, loc)
+	end := time.Date(year+1, time.January, 1, 0, 0, 0, 0, loc)
+	for {
+		_, next := cur.ZoneBounds()
+		if next.IsZero() || !next.Before(end) {
+			return transitions
+		}
+		_, before := cur.Zone()
+		next = next.In(loc)
+		abbr, after := next.Zone()
+		if before != after || cur.IsDST() != next.IsDST() {
+			transitions = append(transitions, TimezoneTransition{
+				At:           next.UTC().Format(time.RFC3339),
+				Local:        next.Format(time.RFC3339),
+				From:         formatOffset(before),
+				To:           formatOffset(after),
+				Abbreviation: abbr,
+				IsDST:        next.IsDST(),
+			})
+		}
+		cur = next
+	}
+}
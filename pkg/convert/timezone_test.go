@@ -0,0 +1,69 @@
+package convert
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimezoneInfo(t *testing.T) {
+	res, err := TimezoneInfo("America/New_York", "2024-07-01 09:00", []string{"Europe/London", "Asia/Kolkata", "UTC+8", "Mars/Olympus"})
+	require.NoError(t, err)
+	require.Equal(t, "America/New_York", res.Zone)
+	require.Equal(t, "2024-07-01T09:00:00-04:00", res.Time)
+	require.Equal(t, "EDT", res.Abbreviation)
+	require.Equal(t, "-04:00", res.Offset)
+	require.True(t, res.IsDST)
+
+	require.Len(t, res.Transitions, 2)
+	require.Equal(t, "2024-03-10T07:00:00Z", res.Transitions[0].At)
+	require.Equal(t, "-05:00", res.Transitions[0].From)
+	require.Equal(t, "-04:00", res.Transitions[0].To)
+	require.True(t, res.Transitions[0].IsDST)
+	require.Equal(t, "2024-11-03T06:00:00Z", res.Transitions[1].At)
+	require.Equal(t, "EST", res.Transitions[1].Abbreviation)
+
+	require.Len(t, res.Conversions, 4)
+	require.Equal(t, "2024-07-01T14:00:00+01:00", res.Conversions[0].Time)
+	require.Equal(t, "BST", res.Conversions[0].Abbreviation)
+	require.Equal(t, "2024-07-01T18:30:00+05:30", res.Conversions[1].Time)
+	require.Equal(t, "2024-07-01T21:00:00+08:00", res.Conversions[2].Time)
+	require.NotEmpty(t, res.Conversions[3].Error)
+}
+
+func TestTimezoneInfoOffsets(t *testing.T) {
+	res, err := TimezoneInfo("+05:30", "1700000000", nil)
+	require.NoError(t, err)
+	require.Equal(t, "+05:30", res.Offset)
+	require.Equal(t, "2023-11-15T03:43:20+05:30", res.Time)
+	require.Empty(t, res.Transitions)
+
+	res, err = TimezoneInfo("Asia/Tokyo", "2024-01-01T00:00:00Z", nil)
+	require.NoError(t, err)
+	require.Equal(t, "2024-01-01T09:00:00+09:00", res.Time)
+	require.False(t, res.IsDST)
+	require.Empty(t, res.Transitions)
+
+	_, err = TimezoneInfo("Nowhere/City", "", nil)
+	require.Error(t, err)
+	_, err = TimezoneInfo("UTC", "not a time", nil)
+	require.Error(t, err)
+}
+
+func TestParseTimestamp(t *testing.T) {
+	cases := map[string]string{
+		"1700000000":                    "2023-11-14T22:13:20Z",
+		"1700000000123":                 "2023-11-14T22:13:20.123Z",
+		"1700000000123456":              "2023-11-14T22:13:20.123456Z",
+		"1700000000123456789":           "2023-11-14T22:13:20.123456789Z",
+		"2024-02-29":                    "2024-02-29T00:00:00Z",
+		"20240229":                      "2024-02-29T00:00:00Z",
+		"Thu, 29 Feb 2024 10:00:00 GMT": "2024-02-29T10:00:00Z",
+		"2024-02-29T10:00:00+02:00":     "2024-02-29T10:00:00+02:00",
+	}
+	for in, want := range cases {
+		got, _, err := parseTimestamp(in, nil)
+		require.NoError(t, err, in)
+		require.Equal(t, want, got.Format("2006-01-02T15:04:05.999999999Z07:00"), in)
+	}
+}
@@ -0,0 +1,71 @@
+package convert
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+// tomlDatetimeKey names the one member of the objects TOMLDatetimesTagged
+// writes a TOML datetime as.
+const tomlDatetimeKey = "$datetime"
+
+// tagTOMLDatetimes replaces, in place, the datetimes toml.Unmarshal read
+// into v with {"$datetime": literal}. Offset datetimes are written in
+// RFC 3339 form and local ones as TOML writes them.
+func tagTOMLDatetimes(v any) any {
+	switch val := v.(type) {
+	case map[string]any:
+		for k, item := range val {
+			val[k] = tagTOMLDatetimes(item)
+		}
+	case []any:
+		for i, item := range val {
+			val[i] = tagTOMLDatetimes(item)
+		}
+	case time.Time:
+		return map[string]any{tomlDatetimeKey: val.Format(time.RFC3339Nano)}
+	case toml.LocalDateTime, toml.LocalDate, toml.LocalTime:
+		return map[string]any{tomlDatetimeKey: val.(fmt.Stringer).String()}
+	}
+	return v
+}
+
+// tomlDatetimesFor returns o with the default TOMLDatetimes settled for
+// output in the targets formats: datetimes are tagged only when every
+// target reads the tags back.
+func (o ConvertOptions) tomlDatetimesFor(targets ...string) ConvertOptions {
+	if o.TOMLDatetimes != "" {
+		return o
+	}
+	o.TOMLDatetimes = TOMLDatetimesTagged
+	for _, to := range targets {
+		if to != formatJSON && to != formatTOML {
+			o.TOMLDatetimes = TOMLDatetimesString
+		}
+	}
+	return o
+}
+
+// tomlDatetime returns the datetime n tags, as go-toml writes it unquoted,
+// when n is {"$datetime": literal} and literal is a TOML datetime.
+func tomlDatetime(n *docNode) (any, bool) {
+	if len(n.keys) != 1 || n.keys[0].name != tomlDatetimeKey || n.items[0].kind != docString {
+		return nil, false
+	}
+	lit := n.items[0].text
+	if strings.ContainsAny(lit, "\r\n#") {
+		return nil, false
+	}
+	var v struct{ V any }
+	if err := toml.Unmarshal([]byte("V = "+lit), &v); err != nil {
+		return nil, false
+	}
+	switch v.V.(type) {
+	case time.Time, toml.LocalDateTime, toml.LocalDate, toml.LocalTime:
+		return v.V, true
+	}
+	return nil, false
+}
@@ -0,0 +1,832 @@
+package convert
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/linzeyan/transform-go/pkg/common"
+)
+
+const toonIndent = "  "
+
+var (
+	// toonKeyRe matches the keys TOON writes without quotes.
+	toonKeyRe = common.LazyRegexp(`^[A-Za-z_][A-Za-z0-9_.]*$`)
+	// toonSegmentRe matches the parts of a dotted key that fold and expand.
+	toonSegmentRe = common.LazyRegexp(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	// toonNumericRe matches strings that look like numbers, leading zeros
+	// included, and so are quoted.
+	toonNumericRe = common.LazyRegexp(`^-?\d+(?:\.\d+)?(?:[eE][+-]?\d+)?$`)
+	// toonBracketRe matches the [N], [N<TAB>] or [N|] of an array header;
+	// the # length marker of older TOON versions is accepted.
+	toonBracketRe = common.LazyRegexp(`^\[#?(\d+)([\t|]?)\]`)
+)
+
+var toonDelimiters = map[string]rune{
+	"":                 ',',
+	TOONDelimiterComma: ',',
+	TOONDelimiterTab:   '\t',
+	TOONDelimiterPipe:  '|',
+}
+
+// JSONToTOON encodes JSON into TOON text.
+func JSONToTOON(input string) (string, error) {
+	return jsonToTOONWithOptions(input, NewConvertOptions())
+}
+
+// jsonToTOONWithOptions writes TOON with the delimiter TOONDelimiter names,
+// folding keys when TOONKeyFolding is set.
+func jsonToTOONWithOptions(input string, o ConvertOptions) (string, error) {
+	delim, ok := toonDelimiters[o.TOONDelimiter]
+	if !ok {
+		return "", fmt.Errorf("unsupported TOON delimiter %q", o.TOONDelimiter)
+	}
+	var data any
+	dec := json.NewDecoder(strings.NewReader(input))
+	dec.UseNumber()
+	if err := dec.Decode(&data); err != nil {
+		return "", err
+	}
+	w := &toonWriter{delim: delim, folding: o.TOONKeyFolding, order: o.sourceOrder(input)}
+	w.root(data)
+	return strings.TrimRight(w.b.String(), "\n"), nil
+}
+
+// TOONToJSON decodes TOON text back into JSON.
+func TOONToJSON(input string) (string, error) {
+	return toonToJSONWithOptions(input, NewConvertOptions())
+}
+
+// toonToJSONWithOptions reads unquoted dotted keys into nested objects when
+// TOONKeyFolding is set.
+func toonToJSONWithOptions(input string, o ConvertOptions) (string, error) {
+	parser := newToonParser(input)
+	parser.expand = o.TOONKeyFolding
+	value, err := parser.parse()
+	if err != nil {
+		return "", err
+	}
+	if o.SortKeys {
+		value.sortKeys(sortedNames)
+	}
+	return docToJSON(value, "  ")
+}
+
+// toonWriter writes TOON with one delimiter for the whole document, as
+// array headers name it, and object keys in order.
+type toonWriter struct {
+	b       strings.Builder
+	delim   rune
+	folding bool
+	order   sourceOrder
+}
+
+func (w *toonWriter) line(depth int, s string) {
+	w.b.WriteString(strings.Repeat(toonIndent, depth) + s + "\n")
+}
+
+func (w *toonWriter) root(value any) {
+	switch v := value.(type) {
+	case map[string]any:
+		w.object(v, 0, "")
+	case []any:
+		w.line(0, w.header("", v, ""))
+		w.items(v, 0, "")
+	default:
+		w.line(0, formatPrimitive(v, w.delim))
+	}
+}
+
+// object writes the fields of obj, the object at path.
+func (w *toonWriter) object(obj map[string]any, depth int, path string) {
+	for _, k := range w.order.keys(obj, path) {
+		w.field(k, obj[k], depth, path)
+	}
+}
+
+// field writes key of the object at path.
+func (w *toonWriter) field(key string, value any, depth int, path string) {
+	name, value, path := w.fold(key, value, path)
+	switch v := value.(type) {
+	case map[string]any:
+		w.line(depth, name+":")
+		w.object(v, depth+1, path)
+	case []any:
+		w.line(depth, w.header(name, v, path))
+		w.items(v, depth, path)
+	default:
+		w.line(depth, name+": "+formatPrimitive(v, w.delim))
+	}
+}
+
+// key quotes a key unless it is an identifier, or a dotted one that path
+// expansion would not split.
+func (w *toonWriter) key(k string) string {
+	if toonKeyRe().MatchString(k) && !(w.folding && strings.Contains(k, ".")) {
+		return k
+	}
+	return quoteString(k)
+}
+
+// fold returns the written key of a field of the object at path, its value
+// and the value's path. With folding, a chain of objects holding one
+// identifier key each becomes one dotted key and the value is the one at
+// the end of the chain.
+func (w *toonWriter) fold(key string, value any, path string) (string, any, string) {
+	path = keyPath(path, key)
+	if !w.folding || !toonSegmentRe().MatchString(key) {
+		return w.key(key), value, path
+	}
+	for {
+		obj, ok := value.(map[string]any)
+		if !ok || len(obj) != 1 {
+			break
+		}
+		var next string
+		for next = range obj {
+		}
+		if !toonSegmentRe().MatchString(next) {
+			break
+		}
+		key += "." + next
+		value = obj[next]
+		path = keyPath(path, next)
+	}
+	return key, value, path
+}
+
+// header writes the header line of an array field called name, or of an
+// array without a key when name is "", found at path. Arrays of primitives
+// are written inline; a delimiter other than the comma is named inside the
+// brackets.
+func (w *toonWriter) header(name string, arr []any, path string) string {
+	bracket := "[" + strconv.Itoa(len(arr))
+	if w.delim != ',' {
+		bracket += string(w.delim)
+	}
+	bracket += "]"
+	if fields, _, ok := w.tabular(arr, path); ok {
+		keys := make([]string, len(fields))
+		for i, f := range fields {
+			keys[i] = w.key(f)
+		}
+		return name + bracket + "{" + strings.Join(keys, string(w.delim)) + "}:"
+	}
+	if len(arr) > 0 && allPrimitives(arr) {
+		return name + bracket + ": " + w.join(arr)
+	}
+	return name + bracket + ":"
+}
+
+func (w *toonWriter) join(values []any) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		parts[i] = formatPrimitive(v, w.delim)
+	}
+	return strings.Join(parts, string(w.delim))
+}
+
+// items writes the rows or list items of the array at path whose header is
+// at depth.
+func (w *toonWriter) items(arr []any, depth int, path string) {
+	if fields, rows, ok := w.tabular(arr, path); ok {
+		for _, row := range rows {
+			values := make([]any, len(fields))
+			for i, f := range fields {
+				values[i] = row[f]
+			}
+			w.line(depth+1, w.join(values))
+		}
+		return
+	}
+	if allPrimitives(arr) {
+		return
+	}
+	for _, item := range arr {
+		w.listItem(item, depth+1, path+"[]")
+	}
+}
+
+// listItem writes an item of a list on a hyphen line at depth. An object
+// item keeps its first field on the hyphen line and its other fields one
+// level below it; the fields of a nested first value go two levels below.
+// path is the path of the item.
+func (w *toonWriter) listItem(item any, depth int, path string) {
+	switch v := item.(type) {
+	case map[string]any:
+		if len(v) == 0 {
+			w.line(depth, "-")
+			return
+		}
+		keys := w.order.keys(v, path)
+		name, first, firstPath := w.fold(keys[0], v[keys[0]], path)
+		switch f := first.(type) {
+		case map[string]any:
+			w.line(depth, "- "+name+":")
+			w.object(f, depth+2, firstPath)
+		case []any:
+			w.line(depth, "- "+w.header(name, f, firstPath))
+			w.items(f, depth, firstPath)
+		default:
+			w.line(depth, "- "+name+": "+formatPrimitive(f, w.delim))
+		}
+		for _, k := range keys[1:] {
+			w.field(k, v[k], depth+1, path)
+		}
+	case []any:
+		w.line(depth, "- "+w.header("", v, path))
+		w.items(v, depth, path)
+	default:
+		w.line(depth, "- "+formatPrimitive(v, w.delim))
+	}
+}
+
+func allPrimitives(arr []any) bool {
+	for _, v := range arr {
+		switch v.(type) {
+		case map[string]any, []any:
+			return false
+		}
+	}
+	return true
+}
+
+// tabular returns the fields and rows of the array at path when every item
+// is an object with the same primitive fields.
+func (w *toonWriter) tabular(arr []any, path string) ([]string, []map[string]any, bool) {
+	if len(arr) == 0 {
+		return nil, nil, false
+	}
+	first, ok := arr[0].(map[string]any)
+	if !ok || len(first) == 0 {
+		return nil, nil, false
+	}
+	fields := w.order.keys(first, path+"[]")
+	rows := make([]map[string]any, 0, len(arr))
+	rows = append(rows, first)
+	for i := 1; i < len(arr); i++ {
+		obj, ok := arr[i].(map[string]any)
+		if !ok {
+			return nil, nil, false
+		}
+		if !sameFieldSet(fields, obj) {
+			return nil, nil, false
+		}
+		rows = append(rows, obj)
+	}
+	for _, row := range rows {
+		for _, f := range fields {
+			if _, ok := row[f]; !ok {
+				return nil, nil, false
+			}
+			switch row[f].(type) {
+			case map[string]any, []any:
+				return nil, nil, false
+			}
+		}
+	}
+	return fields, rows, true
+}
+
+func sameFieldSet(fields []string, obj map[string]any) bool {
+	if len(fields) != len(obj) {
+		return false
+	}
+	for _, f := range fields {
+		if _, ok := obj[f]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func formatPrimitive(value any, delim rune) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		if v {
+			return "true"
+		}
+		return "false"
+	case json.Number:
+		return v.String()
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case string:
+		if needsQuote(v, delim) {
+			return quoteString(v)
+		}
+		return v
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+func needsQuote(s string, delim rune) bool {
+	if s == "" || strings.TrimSpace(s) != s {
+		return true
+	}
+	switch s {
+	case "true", "false", "null":
+		return true
+	}
+	if toonNumericRe().MatchString(s) {
+		return true
+	}
+	if strings.ContainsAny(s, ":\"\\[]{}") {
+		return true
+	}
+	if strings.ContainsRune(s, '\n') || strings.ContainsRune(s, '\r') || strings.ContainsRune(s, '\t') {
+		return true
+	}
+	if strings.ContainsRune(s, delim) {
+		return true
+	}
+	if strings.HasPrefix(s, "-") {
+		return true
+	}
+	return false
+}
+
+func quoteString(s string) string {
+	replacer := strings.NewReplacer(
+		`"`, `\"`,
+		`\`, `\\`,
+		"\n", `\n`,
+		"\r", `\r`,
+		"\t", `\t`,
+	)
+	return `"` + replacer.Replace(s) + `"`
+}
+
+// --------- Parser ----------
+
+type toonParser struct {
+	b     docBuilder
+	lines []toonLine
+	idx   int
+	// expand reads unquoted dotted keys into nested objects.
+	expand bool
+}
+
+type toonLine struct {
+	depth  int
+	indent int // leading spaces
+	text   string
+	number int
+}
+
+// toonHeader is an array header: key[N<delim>]{fields}: inline values.
+type toonHeader struct {
+	key    string
+	keyed  bool // false for a root array or an array list item
+	quoted bool
+	length int
+	delim  rune
+	fields []string // nil unless the array is tabular
+	inline string
+}
+
+func newToonParser(input string) *toonParser {
+	raw := strings.Split(strings.ReplaceAll(input, "\r\n", "\n"), "\n")
+	lines := make([]toonLine, 0, len(raw))
+	for i, line := range raw {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+		lines = append(lines, toonLine{
+			depth:  indent / len(toonIndent),
+			indent: indent,
+			text:   strings.Trim(line, " "),
+			number: i + 1,
+		})
+	}
+	return &toonParser{lines: lines}
+}
+
+func (p *toonParser) parse() (*docNode, error) {
+	for _, line := range p.lines {
+		if line.indent%len(toonIndent) != 0 {
+			return nil, fmt.Errorf("indentation is not a multiple of %d spaces on line %d", len(toonIndent), line.number)
+		}
+	}
+	if len(p.lines) == 0 {
+		return p.b.object(), nil
+	}
+	line := p.lines[0]
+	h, ok, err := parseTOONHeader(line.text, line.number)
+	if err != nil {
+		return nil, err
+	}
+	if ok && !h.keyed {
+		p.idx++
+		arr, err := p.array(h, 0, line.number)
+		if err != nil {
+			return nil, err
+		}
+		if p.idx < len(p.lines) {
+			return nil, fmt.Errorf("unexpected content after the root array on line %d", p.lines[p.idx].number)
+		}
+		return arr, nil
+	}
+	if len(p.lines) == 1 && !ok {
+		if _, _, _, isField := cutTOONKey(line.text); !isField || isQuotedToken(line.text) {
+			return p.primitive(line.text), nil
+		}
+	}
+	return p.parseObject(0)
+}
+
+func (p *toonParser) parseObject(depth int) (*docNode, error) {
+	result := p.b.object()
+	return result, p.fields(result, depth)
+}
+
+// fields reads the fields at depth into obj.
+func (p *toonParser) fields(obj *docNode, depth int) error {
+	for p.idx < len(p.lines) {
+		line := p.lines[p.idx]
+		if line.depth < depth {
+			break
+		}
+		if line.depth > depth {
+			return fmt.Errorf("unexpected indentation near line %d", line.number)
+		}
+		key, quoted, value, err := p.field(line.text, depth, depth+1)
+		if err != nil {
+			return err
+		}
+		if err := p.set(obj, key, quoted, value, line.number); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// field reads the field written as text on the current line at depth. The
+// fields of a nested object are at nested depth.
+func (p *toonParser) field(text string, depth, nested int) (string, bool, *docNode, error) {
+	line := p.lines[p.idx]
+	h, ok, err := parseTOONHeader(text, line.number)
+	if err != nil {
+		return "", false, nil, err
+	}
+	if ok {
+		if !h.keyed {
+			return "", false, nil, fmt.Errorf("array header without a key on line %d", line.number)
+		}
+		p.idx++
+		arr, err := p.array(h, depth, line.number)
+		return h.key, h.quoted, arr, err
+	}
+	key, quoted, rest, ok := cutTOONKey(text)
+	if !ok {
+		return "", false, nil, fmt.Errorf("expected key on line %d", line.number)
+	}
+	p.idx++
+	if rest == "" {
+		obj, err := p.parseObject(nested)
+		return key, quoted, obj, err
+	}
+	return key, quoted, p.primitive(rest), nil
+}
+
+// set stores value under key in obj. When expanding, an unquoted key of
+// dotted identifiers is a path of nested objects, merged with the objects
+// earlier fields made; a path through a non-object value is an error.
+func (p *toonParser) set(obj *docNode, key string, quoted bool, value *docNode, number int) error {
+	if !p.expand {
+		p.b.set(obj, key, value)
+		return nil
+	}
+	path := []string{key}
+	if !quoted && strings.Contains(key, ".") {
+		path = strings.Split(key, ".")
+		for _, segment := range path {
+			if !toonSegmentRe().MatchString(segment) {
+				path = []string{key}
+				break
+			}
+		}
+	}
+	for i := len(path) - 1; i > 0; i-- {
+		parent := p.b.object()
+		p.b.set(parent, path[i], value)
+		value = parent
+	}
+	if !p.merge(obj, path[0], value) {
+		return fmt.Errorf("key %s conflicts with an earlier value on line %d", key, number)
+	}
+	return nil
+}
+
+func (p *toonParser) merge(obj *docNode, key string, value *docNode) bool {
+	old := p.b.get(obj, key)
+	if old == nil {
+		p.b.set(obj, key, value)
+		return true
+	}
+	if old.kind != docObject || value.kind != docObject {
+		return false
+	}
+	for i, k := range value.keys {
+		if !p.merge(old, k.name, value.items[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// array reads the values of the array with header h on line number at
+// depth, and checks there are as many as the header declares.
+func (p *toonParser) array(h toonHeader, depth, number int) (*docNode, error) {
+	arr := p.b.array()
+	switch {
+	case h.fields != nil:
+		if h.inline != "" {
+			return nil, fmt.Errorf("unexpected values after a tabular header on line %d", number)
+		}
+		for p.idx < len(p.lines) {
+			line := p.lines[p.idx]
+			if line.depth != depth+1 || !isTOONRow(line.text, h.delim) {
+				break
+			}
+			values := splitDelimited(line.text, h.delim)
+			if len(values) != len(h.fields) {
+				return nil, fmt.Errorf("row has %d values for %d fields on line %d", len(values), len(h.fields), line.number)
+			}
+			row := p.b.object()
+			for i, field := range h.fields {
+				p.b.set(row, field, p.primitive(values[i]))
+			}
+			arr.items = append(arr.items, row)
+			p.idx++
+		}
+	case h.inline != "":
+		for _, v := range splitDelimited(h.inline, h.delim) {
+			arr.items = append(arr.items, p.primitive(v))
+		}
+	default:
+		for p.idx < len(p.lines) {
+			line := p.lines[p.idx]
+			if line.depth != depth+1 || line.text != "-" && !strings.HasPrefix(line.text, "- ") {
+				break
+			}
+			item, err := p.listItem(strings.TrimSpace(line.text[1:]), depth+1)
+			if err != nil {
+				return nil, err
+			}
+			arr.items = append(arr.items, item)
+		}
+	}
+	if len(arr.items) != h.length {
+		return nil, fmt.Errorf("array declares length %d but has %d items on line %d", h.length, len(arr.items), number)
+	}
+	return arr, nil
+}
+
+// listItem reads the item on the current hyphen line at depth. An object
+// item keeps its first field on the hyphen line, its other fields one
+// level below and the fields of a nested first value two levels below.
+func (p *toonParser) listItem(content string, depth int) (*docNode, error) {
+	line := p.lines[p.idx]
+	if content == "" {
+		p.idx++
+		return p.b.object(), nil
+	}
+	h, ok, err := parseTOONHeader(content, line.number)
+	if err != nil {
+		return nil, err
+	}
+	if ok && !h.keyed {
+		p.idx++
+		return p.array(h, depth, line.number)
+	}
+	if !ok {
+		if _, _, _, isField := cutTOONKey(content); !isField || isQuotedToken(content) {
+			p.idx++
+			return p.primitive(content), nil
+		}
+	}
+	key, quoted, first, err := p.field(content, depth, depth+2)
+	if err != nil {
+		return nil, err
+	}
+	obj := p.b.object()
+	if err := p.set(obj, key, quoted, first, line.number); err != nil {
+		return nil, err
+	}
+	return obj, p.fields(obj, depth+1)
+}
+
+// parseTOONHeader reads text as an array header, reporting false when it
+// is something else.
+func parseTOONHeader(text string, number int) (toonHeader, bool, error) {
+	h := toonHeader{delim: ','}
+	rest := text
+	if strings.HasPrefix(text, `"`) {
+		end := toonQuoteEnd(text)
+		if end < 0 {
+			return h, false, nil
+		}
+		key, err := strconv.Unquote(text[:end])
+		if err != nil {
+			return h, false, nil
+		}
+		h.key, h.keyed, h.quoted, rest = key, true, true, text[end:]
+	} else {
+		i := strings.IndexAny(text, "[:")
+		if i < 0 || text[i] != '[' {
+			return h, false, nil
+		}
+		h.key, h.keyed, rest = strings.TrimSpace(text[:i]), i > 0, text[i:]
+	}
+	m := toonBracketRe().FindStringSubmatch(rest)
+	if m == nil {
+		return h, false, nil
+	}
+	length, err := strconv.Atoi(m[1])
+	if err != nil {
+		return h, false, fmt.Errorf("invalid array length on line %d", number)
+	}
+	h.length = length
+	if m[2] != "" {
+		h.delim = rune(m[2][0])
+	}
+	rest = rest[len(m[0]):]
+	if strings.HasPrefix(rest, "{") {
+		end := strings.IndexByte(rest, '}')
+		for end >= 0 && isTOONQuoted(rest, end) {
+			next := strings.IndexByte(rest[end+1:], '}')
+			if next < 0 {
+				end = -1
+				break
+			}
+			end += next + 1
+		}
+		if end < 0 {
+			return h, false, fmt.Errorf("unterminated field list on line %d", number)
+		}
+		h.fields = []string{}
+		for _, f := range splitDelimited(rest[1:end], h.delim) {
+			if isQuotedToken(f) {
+				f, _ = strconv.Unquote(f)
+			}
+			h.fields = append(h.fields, f)
+		}
+		rest = rest[end+1:]
+	}
+	inline, ok := strings.CutPrefix(rest, ":")
+	if !ok {
+		return h, false, nil
+	}
+	h.inline = strings.Trim(inline, " ")
+	return h, true, nil
+}
+
+// cutTOONKey splits a key: value line, unquoting a quoted key.
+func cutTOONKey(text string) (key string, quoted bool, rest string, ok bool) {
+	if strings.HasPrefix(text, `"`) {
+		end := toonQuoteEnd(text)
+		if end < 0 {
+			return "", false, "", false
+		}
+		unquoted, err := strconv.Unquote(text[:end])
+		if err != nil {
+			return "", false, "", false
+		}
+		rest, ok := strings.CutPrefix(strings.TrimLeft(text[end:], " "), ":")
+		return unquoted, true, strings.TrimSpace(rest), ok
+	}
+	key, rest, ok = strings.Cut(text, ":")
+	return strings.TrimSpace(key), false, strings.TrimSpace(rest), ok
+}
+
+// toonQuoteEnd returns the index after the quoted string s starts with, or
+// -1 when it is not closed.
+func toonQuoteEnd(s string) int {
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1
+		}
+	}
+	return -1
+}
+
+// isTOONQuoted reports whether the byte at i of s is inside a quoted string.
+func isTOONQuoted(s string, i int) bool {
+	inQuotes := false
+	for j := 0; j < i; j++ {
+		switch {
+		case s[j] == '\\' && inQuotes:
+			j++
+		case s[j] == '"':
+			inQuotes = !inQuotes
+		}
+	}
+	return inQuotes
+}
+
+// isTOONRow tells a tabular row from a key: value line, which has a colon
+// outside quotes before any delimiter.
+func isTOONRow(text string, delim rune) bool {
+	inQuotes, escaped := false, false
+	for _, ch := range text {
+		switch {
+		case escaped:
+			escaped = false
+		case ch == '\\':
+			escaped = true
+		case ch == '"':
+			inQuotes = !inQuotes
+		case inQuotes:
+		case ch == delim:
+			return true
+		case ch == ':':
+			return false
+		}
+	}
+	return true
+}
+
+func isQuotedToken(token string) bool {
+	_, err := strconv.Unquote(token)
+	return strings.HasPrefix(token, `"`) && err == nil
+}
+
+// splitDelimited splits values on delim outside quotes; an empty value
+// between two delimiters, or after the last, is kept.
+func splitDelimited(input string, delim rune) []string {
+	if input == "" {
+		return nil
+	}
+	var result []string
+	current := strings.Builder{}
+	inQuotes := false
+	escaped := false
+	for _, ch := range input {
+		switch {
+		case escaped:
+			if inQuotes {
+				// Keep the escape for strconv.Unquote.
+				current.WriteRune('\\')
+			}
+			current.WriteRune(ch)
+			escaped = false
+		case ch == '\\':
+			escaped = true
+		case ch == '"':
+			inQuotes = !inQuotes
+			current.WriteRune(ch)
+		case ch == delim && !inQuotes:
+			result = append(result, strings.TrimSpace(current.String()))
+			current.Reset()
+		default:
+			current.WriteRune(ch)
+		}
+	}
+	return append(result, strings.TrimSpace(current.String()))
+}
+
+func parsePrimitiveToken(token string) any {
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return ""
+	}
+	if strings.HasPrefix(token, "\"") && strings.HasSuffix(token, "\"") {
+		unquoted, err := strconv.Unquote(token)
+		if err == nil {
+			return unquoted
+		}
+		return token
+	}
+	switch token {
+	case "true":
+		return true
+	case "false":
+		return false
+	case "null":
+		return nil
+	}
+	if numberPattern().MatchString(token) {
+		return common.NumberValue(json.Number(token))
+	}
+	return token
+}
+
+// primitive reads token as parsePrimitiveToken does, into the document
+// model.
+func (p *toonParser) primitive(token string) *docNode {
+	n, err := docScalar(&p.b.arena, parsePrimitiveToken(token), docPos{})
+	if err != nil {
+		return p.b.str(token)
+	}
+	return n
+}
@@ -0,0 +1,107 @@
+package convert
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Test_TOONConformance checks encoding and decoding against examples in
+// the shape of the TOON specification's fixtures.
+func Test_TOONConformance(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+		toon string
+		opts []ConvertOption
+	}{
+		{name: "primitives", json: `{"a":1,"b":"x y","c":true,"d":null,"e":-2.5}`, toon: "a: 1\nb: x y\nc: true\nd: null\ne: -2.5"},
+		{name: "quoted strings", json: `{"a":"","b":"05","c":"true","d":"-x","e":"a:b","f":" pad","g":"line\nbreak"}`,
+			toon: "a: \"\"\nb: \"05\"\nc: \"true\"\nd: \"-x\"\ne: \"a:b\"\nf: \" pad\"\ng: \"line\\nbreak\""},
+		{name: "quoted keys", json: `{"":1,"my key":2,"a:b":3,"x.y":4,"9":5}`,
+			toon: "\"\": 1\n\"my key\": 2\n\"a:b\": 3\nx.y: 4\n\"9\": 5"},
+		{name: "nested object", json: `{"a":{"b":{"c":1}},"e":{}}`, toon: "a:\n  b:\n    c: 1\ne:"},
+		{name: "inline array", json: `{"tags":["a","b,c",""],"none":[]}`, toon: "tags[3]: a,\"b,c\",\"\"\nnone[0]:"},
+		{name: "tabular array", json: `{"users":[{"id":1,"name":"Ada"},{"id":2,"name":"Bob, Jr"}]}`,
+			toon: "users[2]{id,name}:\n  1,Ada\n  2,\"Bob, Jr\""},
+		{name: "list array", json: `{"items":[{"k":{"z":1},"n":[1,2]},[1,2],"s",{}]}`,
+			toon: "items[4]:\n  - k:\n      z: 1\n    n[2]: 1,2\n  - [2]: 1,2\n  - s\n  -"},
+		{name: "root array", json: `[{"a":1},{"a":2}]`, toon: "[2]{a}:\n  1\n  2"},
+		{name: "root primitive", json: `"hello"`, toon: "hello"},
+		{name: "tab delimiter", json: `{"tags":["a b","c,d"],"users":[{"id":1,"name":"Ada\tL"}]}`,
+			toon: "tags[2\t]: a b\tc,d\nusers[1\t]{id\tname}:\n  1\t\"Ada\\tL\"", opts: []ConvertOption{WithTOONDelimiter(TOONDelimiterTab)}},
+		{name: "pipe delimiter", json: `{"tags":["a,b","c|d"],"users":[{"id":1,"name":"Ada"}]}`,
+			toon: "tags[2|]: a,b|\"c|d\"\nusers[1|]{id|name}:\n  1|Ada", opts: []ConvertOption{WithTOONDelimiter(TOONDelimiterPipe)}},
+		{name: "key folding", json: `{"a":{"b":{"c":1}},"d":{"e":{"f":1,"g":2}},"h":{"i j":{"k":1}},"l.m":{"n":1},"o":{"p":[1,2]}}`,
+			toon: "a.b.c: 1\nd.e:\n  f: 1\n  g: 2\nh:\n  \"i j\":\n    k: 1\n\"l.m\":\n  n: 1\no.p[2]: 1,2",
+			opts: []ConvertOption{WithTOONKeyFolding(true)}},
+		{name: "key folding in lists", json: `{"items":[{"a":{"b":1},"c":{"d":2}}]}`,
+			toon: "items[1]:\n  - a.b: 1\n    c.d: 2", opts: []ConvertOption{WithTOONKeyFolding(true)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := ConvertFormatsWithOptions(formatJSON, formatTOON, tt.json, tt.opts...)
+			require.NoError(t, err)
+			require.Equal(t, tt.toon, out)
+			back, err := ConvertFormatsWithOptions(formatTOON, formatJSON, out, tt.opts...)
+			require.NoError(t, err)
+			require.JSONEq(t, tt.json, back)
+		})
+	}
+}
+
+func Test_TOONDecode(t *testing.T) {
+	tests := []struct {
+		name string
+		toon string
+		json string
+		opts []ConvertOption
+	}{
+		{name: "quoted key headers", toon: "\"my list\"[2]: 1,2\n\"a:b\"[1]{\"f 1\",g}:\n  1,2",
+			json: `{"my list":[1,2],"a:b":[{"f 1":1,"g":2}]}`},
+		{name: "delimiter in fields", toon: "rows[2|]{id|name}:\n  1|a,b\n  2|\"c|d\"", json: `{"rows":[{"id":1,"name":"a,b"},{"id":2,"name":"c|d"}]}`},
+		{name: "tab rows", toon: "rows[1\t]{id\tname}:\n  1\tAda Lovelace", json: `{"rows":[{"id":1,"name":"Ada Lovelace"}]}`},
+		{name: "length marker", toon: "tags[#2]: a,b", json: `{"tags":["a","b"]}`},
+		{name: "empty values", toon: "tags[3]: a,,", json: `{"tags":["a","",""]}`},
+		{name: "tabular first field", toon: "items[1]:\n  - rows[2]{a}:\n    1\n    2\n    name: x", json: `{"items":[{"rows":[{"a":1},{"a":2}],"name":"x"}]}`},
+		{name: "dotted keys kept", toon: "a.b: 1\n\"c.d\": 2", json: `{"a.b":1,"c.d":2}`},
+		{name: "path expansion", toon: "a.b: 1\na.c: 2\n\"a.d\": 3\nx.y[2]: 1,2", json: `{"a":{"b":1,"c":2},"a.d":3,"x":{"y":[1,2]}}`,
+			opts: []ConvertOption{WithTOONKeyFolding(true)}},
+		{name: "path expansion merge", toon: "a.b: 1\na:\n  c: 2", json: `{"a":{"b":1,"c":2}}`, opts: []ConvertOption{WithTOONKeyFolding(true)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := ConvertFormatsWithOptions(formatTOON, formatJSON, tt.toon, tt.opts...)
+			require.NoError(t, err)
+			require.JSONEq(t, tt.json, out)
+		})
+	}
+}
+
+func Test_TOONErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		toon string
+		err  string
+		opts []ConvertOption
+	}{
+		{name: "short inline array", toon: "a: 1\ntags[3]: a,b", err: "array declares length 3 but has 2 items on line 2"},
+		{name: "long inline array", toon: "tags[1]: a,b", err: "array declares length 1 but has 2 items on line 1"},
+		{name: "missing rows", toon: "rows[3]{a,b}:\n  1,2\n  3,4", err: "array declares length 3 but has 2 items on line 1"},
+		{name: "extra rows", toon: "rows[1]{a,b}:\n  1,2\n  3,4", err: "array declares length 1 but has 2 items on line 1"},
+		{name: "missing list items", toon: "items[2]:\n  - 1", err: "array declares length 2 but has 1 items on line 1"},
+		{name: "row width", toon: "rows[1]{a,b}:\n  1,2,3", err: "row has 3 values for 2 fields on line 2"},
+		{name: "indentation", toon: "a:\n   b: 1", err: "indentation is not a multiple of 2 spaces on line 2"},
+		{name: "after root array", toon: "[1]: 1\na: 2", err: "unexpected content after the root array on line 2"},
+		{name: "path conflict", toon: "a: 1\na.b: 2", err: "key a.b conflicts with an earlier value on line 2", opts: []ConvertOption{WithTOONKeyFolding(true)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ConvertFormatsWithOptions(formatTOON, formatJSON, tt.toon, tt.opts...)
+			require.EqualError(t, err, tt.err)
+		})
+	}
+
+	_, err := ConvertFormatsWithOptions(formatJSON, formatTOON, `{}`, WithTOONDelimiter(";"))
+	require.EqualError(t, err, `unsupported TOON delimiter ";"`)
+}
@@ -0,0 +1,352 @@
+// Package transformtest checks that a format converts to and from JSON
+// without losing anything. It generates random JSON documents from a seed,
+// round-trips each one and reports the first document that comes back
+// different, so formats added with convert.RegisterFormat can be held to
+// the same standard as the built-in ones:
+//
+//	func TestMyFormat(t *testing.T) {
+//		transformtest.RoundTrip(t, myFromJSON, myToJSON, transformtest.Config{})
+//	}
+package transformtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Root is the shape of a generated document.
+type Root int
+
+const (
+	// RootAny is any JSON value.
+	RootAny Root = iota
+	// RootObject is an object, as TOML and INI-like formats require.
+	RootObject
+	// RootRecords is an array of flat objects sharing their keys, the
+	// shape of CSV rows.
+	RootRecords
+)
+
+// Config narrows the generated documents to what a format can hold. The
+// zero value generates any JSON document.
+type Config struct {
+	// Seed is the seed of the first document; document i uses Seed+i, so
+	// a failure can be replayed with Document. Zero means 1.
+	Seed uint64
+	// Runs is the number of documents; zero means 100.
+	Runs int
+	// MaxDepth bounds nesting below the root; zero means 3.
+	MaxDepth int
+	// MaxWidth bounds object keys and array elements; zero means 4.
+	MaxWidth int
+	Root     Root
+	// NoNull, NoBool and NoFloat leave out values the format has no type
+	// for.
+	NoNull  bool
+	NoBool  bool
+	NoFloat bool
+	// NoEmpty leaves out empty strings, arrays and objects.
+	NoEmpty bool
+	// NoArray leaves out arrays below the root and NoNestedArray arrays
+	// directly inside arrays.
+	NoArray       bool
+	NoNestedArray bool
+	// Uniform fills each array with one repeated element, for formats
+	// that give an array a single element type.
+	Uniform bool
+	// Normalize, when set, is applied to the document and to its round
+	// trip before they are compared; see Text and Kinds.
+	Normalize func(any) any
+}
+
+func (c Config) withDefaults() Config {
+	if c.Seed == 0 {
+		c.Seed = 1
+	}
+	if c.Runs == 0 {
+		c.Runs = 100
+	}
+	if c.MaxDepth == 0 {
+		c.MaxDepth = 3
+	}
+	if c.MaxWidth == 0 {
+		c.MaxWidth = 4
+	}
+	return c
+}
+
+// Document returns the JSON text of the document generated from seed.
+func Document(seed uint64, c Config) string {
+	c = c.withDefaults()
+	data, _ := json.Marshal(Generate(rand.New(rand.NewPCG(seed, seed)), c))
+	return string(data)
+}
+
+// Check round-trips c.Runs documents through fromJSON and back through
+// toJSON and returns an error naming the seed, the document and the
+// result of the first one that fails.
+func Check(fromJSON, toJSON func(string) (string, error), c Config) error {
+	c = c.withDefaults()
+	for i := range c.Runs {
+		seed := c.Seed + uint64(i)
+		doc := Document(seed, c)
+		out, err := fromJSON(doc)
+		if err != nil {
+			return fmt.Errorf("seed %d: from JSON: %w\ndocument: %s", seed, err, doc)
+		}
+		back, err := toJSON(out)
+		if err != nil {
+			return fmt.Errorf("seed %d: to JSON: %w\ndocument: %s\nconverted:\n%s", seed, err, doc, out)
+		}
+		if err := Equal(doc, back, c.Normalize); err != nil {
+			return fmt.Errorf("seed %d: %w\ndocument: %s\nconverted:\n%s", seed, err, doc, out)
+		}
+	}
+	return nil
+}
+
+// RoundTrip is Check for tests: it fails t with Check's error.
+func RoundTrip(t testing.TB, fromJSON, toJSON func(string) (string, error), c Config) {
+	t.Helper()
+	if err := Check(fromJSON, toJSON, c); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Equal reports whether two JSON texts hold the same value once
+// normalize, if not nil, has been applied to both. Numbers compare by
+// value, so 1 and 1.0 are equal.
+func Equal(want, got string, normalize func(any) any) error {
+	var w, g any
+	if err := json.Unmarshal([]byte(want), &w); err != nil {
+		return fmt.Errorf("want: %w", err)
+	}
+	if err := json.Unmarshal([]byte(got), &g); err != nil {
+		return fmt.Errorf("got: %w", err)
+	}
+	if normalize != nil {
+		w, g = normalize(w), normalize(g)
+	}
+	if !reflect.DeepEqual(w, g) {
+		return fmt.Errorf("round trip changed the document: got %s", got)
+	}
+	return nil
+}
+
+// Text is a Normalize function for formats without value types, such as
+// XML: every scalar becomes its JSON text and null the empty string.
+func Text(v any) any {
+	switch val := v.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(val))
+		for k, item := range val {
+			out[k] = Text(item)
+		}
+		return out
+	case []any:
+		out := make([]any, len(val))
+		for i, item := range val {
+			out[i] = Text(item)
+		}
+		return out
+	case string:
+		return val
+	case nil:
+		return ""
+	}
+	data, _ := json.Marshal(v)
+	return string(data)
+}
+
+// Kinds is a Normalize function for formats that describe types rather
+// than values, such as schemas: every scalar becomes its JSON kind and an
+// array keeps only its first element.
+func Kinds(v any) any {
+	switch val := v.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(val))
+		for k, item := range val {
+			out[k] = Kinds(item)
+		}
+		return out
+	case []any:
+		if len(val) == 0 {
+			return []any{}
+		}
+		return []any{Kinds(val[0])}
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	}
+	return "null"
+}
+
+// Generate returns a random document shaped by c; Document is the same
+// from a seed.
+func Generate(r *rand.Rand, c Config) any {
+	c = c.withDefaults()
+	g := generator{r: r, c: c}
+	switch c.Root {
+	case RootObject:
+		return g.object(c.MaxDepth)
+	case RootRecords:
+		keys := g.keys(1 + r.IntN(c.MaxWidth))
+		records := make([]any, 1+r.IntN(c.MaxWidth))
+		for i := range records {
+			rec := map[string]any{}
+			for _, k := range keys {
+				rec[k] = g.scalar()
+			}
+			records[i] = rec
+		}
+		return records
+	}
+	return g.value(c.MaxDepth)
+}
+
+type generator struct {
+	r *rand.Rand
+	c Config
+}
+
+const (
+	kindNull = iota
+	kindBool
+	kindInt
+	kindFloat
+	kindString
+	kindArray
+	kindObject
+)
+
+func (g generator) kind(depth int) int {
+	for {
+		k := g.r.IntN(kindObject + 1)
+		switch {
+		case k >= kindArray && depth <= 0,
+			k == kindNull && g.c.NoNull,
+			k == kindBool && g.c.NoBool,
+			k == kindFloat && g.c.NoFloat,
+			k == kindArray && g.c.NoArray:
+			continue
+		}
+		return k
+	}
+}
+
+func (g generator) value(depth int) any {
+	return g.ofKind(g.kind(depth), depth)
+}
+
+func (g generator) ofKind(kind, depth int) any {
+	switch kind {
+	case kindNull:
+		return nil
+	case kindBool:
+		return g.r.IntN(2) == 1
+	case kindInt:
+		return float64(g.r.IntN(2_000_001) - 1_000_000)
+	case kindFloat:
+		// Quarters print the same in every format and survive float32.
+		return float64(g.r.IntN(8001)-4000) + 0.25 + 0.5*float64(g.r.IntN(2))
+	case kindString:
+		return g.text()
+	case kindArray:
+		out := make([]any, g.width())
+		for i := range out {
+			if i > 0 && g.c.Uniform {
+				out[i] = out[0]
+				continue
+			}
+			kind := g.kind(depth - 1)
+			for kind == kindArray && g.c.NoNestedArray {
+				kind = g.kind(depth - 1)
+			}
+			out[i] = g.ofKind(kind, depth-1)
+		}
+		return out
+	}
+	return g.object(depth)
+}
+
+func (g generator) object(depth int) map[string]any {
+	out := map[string]any{}
+	for _, k := range g.keys(g.width()) {
+		out[k] = g.value(depth - 1)
+	}
+	return out
+}
+
+func (g generator) scalar() any {
+	return g.value(0)
+}
+
+func (g generator) width() int {
+	if g.c.NoEmpty {
+		return 1 + g.r.IntN(g.c.MaxWidth)
+	}
+	return g.r.IntN(g.c.MaxWidth + 1)
+}
+
+// keys returns n distinct identifiers, safe as names in every format.
+func (g generator) keys(n int) []string {
+	seen := map[string]bool{}
+	keys := make([]string, 0, n)
+	for len(keys) < n {
+		k := g.word(keyLetters, 1+g.r.IntN(8))
+		if !seen[k] && !reserved[k] {
+			seen[k] = true
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+const keyLetters = "abcdefghijklmnopqrstuvwxyz"
+
+// textRunes mixes letters with characters that need escaping somewhere.
+var textRunes = []rune("abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ&<>'\"\\#:,=éß漢✓")
+
+// reserved words read back as another type in some formats.
+var reserved = map[string]bool{
+	"true": true, "false": true, "null": true, "nil": true, "yes": true, "no": true,
+	"on": true, "off": true, "y": true, "n": true, "nan": true, "inf": true,
+}
+
+// text returns words of textRunes joined by single spaces, never reserved
+// in any case and never padded.
+func (g generator) text() string {
+	n := g.r.IntN(4)
+	if n == 0 && (g.c.NoEmpty || g.r.IntN(2) == 0) {
+		n = 1
+	}
+	for {
+		words := make([]string, n)
+		for i := range words {
+			words[i] = g.runes(textRunes, 1+g.r.IntN(8))
+		}
+		s := strings.Join(words, " ")
+		if !reserved[strings.ToLower(s)] {
+			return s
+		}
+	}
+}
+
+func (g generator) word(letters string, n int) string {
+	return g.runes([]rune(letters), n)
+}
+
+func (g generator) runes(set []rune, n int) string {
+	out := make([]rune, n)
+	for i := range out {
+		out[i] = set[g.r.IntN(len(set))]
+	}
+	return string(out)
+}
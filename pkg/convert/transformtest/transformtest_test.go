@@ -0,0 +1,71 @@
+package transformtest
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func identity(s string) (string, error) { return s, nil }
+
+func TestDocument(t *testing.T) {
+	require.Equal(t, Document(7, Config{}), Document(7, Config{}))
+	require.NotEqual(t, Document(7, Config{}), Document(8, Config{}))
+
+	c := Config{Root: RootObject, NoNull: true, NoBool: true, NoFloat: true, NoArray: true}
+	for seed := uint64(1); seed <= 200; seed++ {
+		var v any
+		require.NoError(t, json.Unmarshal([]byte(Document(seed, c)), &v))
+		obj, ok := v.(map[string]any)
+		require.True(t, ok)
+		var walk func(any)
+		walk = func(v any) {
+			switch val := v.(type) {
+			case map[string]any:
+				for _, item := range val {
+					walk(item)
+				}
+			case float64:
+				require.Equal(t, float64(int(val)), val)
+			case string:
+			default:
+				t.Fatalf("seed %d: unexpected %T", seed, v)
+			}
+		}
+		walk(obj)
+	}
+
+	for seed := uint64(1); seed <= 50; seed++ {
+		var records []map[string]any
+		require.NoError(t, json.Unmarshal([]byte(Document(seed, Config{Root: RootRecords})), &records))
+		require.NotEmpty(t, records)
+		for _, rec := range records[1:] {
+			require.Len(t, rec, len(records[0]))
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	require.NoError(t, Check(identity, identity, Config{}))
+	RoundTrip(t, identity, identity, Config{Runs: 10})
+
+	dropNull := func(s string) (string, error) { return strings.ReplaceAll(s, "null", `""`), nil }
+	err := Check(dropNull, identity, Config{})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "seed ")
+	require.Contains(t, err.Error(), "round trip changed the document")
+
+	require.NoError(t, Check(dropNull, identity, Config{NoNull: true}))
+	require.NoError(t, Check(dropNull, identity, Config{Normalize: Text}))
+}
+
+func TestNormalize(t *testing.T) {
+	v := map[string]any{"a": 1.5, "b": []any{true, "x"}, "c": nil}
+	require.Equal(t, map[string]any{"a": "1.5", "b": []any{"true", "x"}, "c": ""}, Text(v))
+	require.Equal(t, map[string]any{"a": "number", "b": []any{"boolean"}, "c": "null"}, Kinds(v))
+	require.NoError(t, Equal(`{"a":1}`, `{"a":1.0}`, nil))
+	require.Error(t, Equal(`{"a":1}`, `{"a":"1"}`, nil))
+	require.NoError(t, Equal(`{"a":1}`, `{"a":"1"}`, Text))
+}
@@ -0,0 +1,190 @@
+package convert
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+)
+
+// urlDefaultPorts are the ports normalization drops as implied by the
+// scheme.
+var urlDefaultPorts = map[string]string{
+	"http": "80", "https": "443", "ws": "80", "wss": "443", "ftp": "21",
+}
+
+// URLResult is the breakdown of a URL. Segments and Query are decoded;
+// Path keeps the decoded path as a whole. Opaque URLs such as mailto: have
+// no host and carry everything after the scheme in Opaque.
+type URLResult struct {
+	Input      string              `json:"input"`
+	Scheme     string              `json:"scheme,omitempty"`
+	Username   string              `json:"username,omitempty"`
+	Password   string              `json:"password,omitempty"`
+	Host       string              `json:"host,omitempty"`
+	Port       string              `json:"port,omitempty"`
+	Opaque     string              `json:"opaque,omitempty"`
+	Path       string              `json:"path"`
+	Segments   []string            `json:"segments"`
+	Query      map[string][]string `json:"query"`
+	Fragment   string              `json:"fragment,omitempty"`
+	Normalized string              `json:"normalized"`
+}
+
+// ParseURL breaks a URL into its parts and rebuilds it in the normalized
+// form of RFC 3986 section 6: lowercase scheme and host, the scheme's
+// default port dropped, "." and ".." segments resolved, escapes of
+// unreserved characters decoded and the rest in uppercase hex. Query order
+// is kept, since servers may depend on it. A URL without a scheme, such as
+// example.com/a, is read as starting with its host.
+func ParseURL(input string) (URLResult, error) {
+	trimmed := strings.TrimSpace(input)
+	res := URLResult{Input: trimmed, Segments: []string{}, Query: map[string][]string{}}
+	if trimmed == "" {
+		return res, errors.New("input is empty")
+	}
+	if !strings.Contains(trimmed, "://") && !strings.HasPrefix(trimmed, "/") && !urlHasScheme(trimmed) {
+		trimmed = "//" + trimmed
+	}
+	u, err := url.Parse(trimmed)
+	if err != nil {
+		return res, err
+	}
+	query, err := url.ParseQuery(u.RawQuery)
+	if err != nil {
+		return res, fmt.Errorf("invalid query: %w", err)
+	}
+
+	res.Scheme = u.Scheme
+	if u.User != nil {
+		res.Username = u.User.Username()
+		res.Password, _ = u.User.Password()
+	}
+	res.Host = u.Hostname()
+	res.Port = u.Port()
+	res.Opaque = u.Opaque
+	res.Path = u.Path
+	for _, seg := range strings.Split(u.EscapedPath(), "/") {
+		if seg == "" {
+			continue
+		}
+		if decoded, err := url.PathUnescape(seg); err == nil {
+			seg = decoded
+		}
+		res.Segments = append(res.Segments, seg)
+	}
+	res.Query = query
+	res.Fragment = u.Fragment
+	res.Normalized = normalizeURL(u)
+	return res, nil
+}
+
+// urlHasScheme reports whether s starts with a scheme, as mailto:x does,
+// rather than a host and port, as localhost:8080 does.
+func urlHasScheme(s string) bool {
+	scheme, rest, ok := strings.Cut(s, ":")
+	if !ok || scheme == "" {
+		return false
+	}
+	for i, r := range scheme {
+		letter := r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z'
+		if !letter && (i == 0 || !(r >= '0' && r <= '9' || r == '+' || r == '-' || r == '.')) {
+			return false
+		}
+	}
+	port, _, _ := strings.Cut(rest, "/")
+	return port == "" || strings.Trim(port, "0123456789") != ""
+}
+
+func normalizeURL(u *url.URL) string {
+	n := url.URL{
+		Scheme:   strings.ToLower(u.Scheme),
+		User:     u.User,
+		RawQuery: normalizeEscapes(u.RawQuery),
+		Fragment: u.Fragment,
+	}
+	if u.Opaque != "" {
+		n.Opaque = normalizeEscapes(u.Opaque)
+		return n.String()
+	}
+	host := strings.ToLower(u.Hostname())
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	if port := u.Port(); port != "" && port != urlDefaultPorts[n.Scheme] {
+		host = net.JoinHostPort(strings.Trim(host, "[]"), port)
+	}
+	n.Host = host
+	raw := removeDotSegments(normalizeEscapes(u.EscapedPath()))
+	if raw == "" && n.Host != "" {
+		raw = "/"
+	}
+	n.Path, _ = url.PathUnescape(raw)
+	n.RawPath = raw
+	return n.String()
+}
+
+// normalizeEscapes decodes escapes of unreserved characters, which mean
+// the same unescaped, and uppercases the hex digits of the others.
+func normalizeEscapes(s string) string {
+	if !strings.Contains(s, "%") {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '%' || i+2 >= len(s) || !isHex(s[i+1]) || !isHex(s[i+2]) {
+			b.WriteByte(s[i])
+			continue
+		}
+		c := unhex(s[i+1])<<4 | unhex(s[i+2])
+		if c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '.' || c == '_' || c == '~' {
+			b.WriteByte(c)
+		} else {
+			b.WriteString(strings.ToUpper(s[i : i+3]))
+		}
+		i += 2
+	}
+	return b.String()
+}
+
+// removeDotSegments resolves "." and ".." segments as RFC 3986 section
+// 5.2.4 does; ".." never climbs above the root.
+func removeDotSegments(p string) string {
+	if !strings.Contains(p, ".") {
+		return p
+	}
+	segs := strings.Split(p, "/")
+	out := make([]string, 0, len(segs))
+	for i, seg := range segs {
+		last := i == len(segs)-1
+		switch seg {
+		case ".":
+		case "..":
+			if len(out) > 1 || len(out) == 1 && out[0] != "" {
+				out = out[:len(out)-1]
+			}
+		default:
+			out = append(out, seg)
+			continue
+		}
+		if last {
+			out = append(out, "")
+		}
+	}
+	return strings.Join(out, "/")
+}
+
+func isHex(c byte) bool {
+	return c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F'
+}
+
+func unhex(c byte) byte {
+	switch {
+	case c >= 'a':
+		return c - 'a' + 10
+	case c >= 'A':
+		return c - 'A' + 10
+	}
+	return c - '0'
+}
@@ -0,0 +1,135 @@
+package convert
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+// Validate checks that input is well-formed in format without converting
+// it, for linting as the input is typed. It returns nil, or the first
+// problem, a *SyntaxError when the parser says where it is.
+//
+// JSON, YAML, TOML, XML, Go structs and TOON are checked by their parsers
+// alone. The GraphQL and Protobuf converters read what they recognize and
+// skip the rest, so those are checked for balanced brackets, closed strings
+// and comments, and at least one type or message. Other formats are read
+// as a conversion would.
+func Validate(format, input string) error {
+	var err error
+	switch format {
+	case formatJSON:
+		if json.Valid([]byte(input)) {
+			return nil
+		}
+		if _, err = parseJSONDoc(input); err == nil {
+			var v any
+			err = json.Unmarshal([]byte(input), &v)
+		}
+	case formatYAML:
+		_, err = yamlDocuments(input, false)
+	case formatTOML:
+		var v map[string]any
+		err = toml.Unmarshal([]byte(input), &v)
+	case formatXML:
+		_, err = parseXML(input)
+	case formatGoStruct:
+		_, err = parseGoStructDefinitions(input)
+	case formatGraphQL:
+		if err = checkBrackets(input, "#", false, `"`); err == nil && len(parseGraphQLSchema(input).order) == 0 {
+			err = errors.New("no GraphQL type definition found")
+		}
+	case formatProtobuf:
+		if err = checkBrackets(input, "//", true, `"'`); err == nil && len(parseProtoSchema(input).order) == 0 {
+			err = errors.New("no protobuf message found")
+		}
+	case formatTOON:
+		_, err = newToonParser(input).parse()
+	default:
+		_, err = readToJSON(format, input, NewConvertOptions())
+	}
+	return withPosition(format, input, err)
+}
+
+// checkBrackets scans source for unbalanced (), [] and {}, and strings or
+// /* */ comments left open. lineComment starts a comment to the end of the
+// line and quotes lists the string delimiters, with a tripled " read as a
+// block string.
+func checkBrackets(src, lineComment string, blockComments bool, quotes string) error {
+	type open struct {
+		char   byte
+		offset int
+	}
+	var stack []open
+	for i := 0; i < len(src); i++ {
+		c := src[i]
+		switch {
+		case lineComment != "" && strings.HasPrefix(src[i:], lineComment):
+			end := strings.IndexByte(src[i:], '\n')
+			if end < 0 {
+				end = len(src) - i
+			}
+			i += end
+		case blockComments && strings.HasPrefix(src[i:], "/*"):
+			end := strings.Index(src[i+2:], "*/")
+			if end < 0 {
+				return bracketError(src, i, "comment not closed")
+			}
+			i += 2 + end + 1
+		case strings.IndexByte(quotes, c) >= 0:
+			end, ok := stringEnd(src, i)
+			if !ok {
+				return bracketError(src, i, "string not closed")
+			}
+			i = end
+		case c == '(' || c == '[' || c == '{':
+			stack = append(stack, open{c, i})
+		case c == ')' || c == ']' || c == '}':
+			want := map[byte]byte{')': '(', ']': '[', '}': '{'}[c]
+			if len(stack) == 0 || stack[len(stack)-1].char != want {
+				return bracketError(src, i, "unexpected '"+string(c)+"'")
+			}
+			stack = stack[:len(stack)-1]
+		}
+	}
+	if len(stack) > 0 {
+		last := stack[len(stack)-1]
+		return bracketError(src, last.offset, "'"+string(last.char)+"' not closed")
+	}
+	return nil
+}
+
+// stringEnd returns the offset of the quote closing the string that opens
+// at start, reading a """ block string to its closing """.
+func stringEnd(src string, start int) (int, bool) {
+	q := src[start]
+	if q == '"' && strings.HasPrefix(src[start:], `"""`) {
+		end := strings.Index(src[start+3:], `"""`)
+		if end < 0 {
+			return 0, false
+		}
+		return start + 3 + end + 2, true
+	}
+	for i := start + 1; i < len(src); i++ {
+		switch src[i] {
+		case '\\':
+			i++
+		case q:
+			return i, true
+		case '\n':
+			return 0, false
+		}
+	}
+	return 0, false
+}
+
+// bracketError places message at offset in src, repeating the position in
+// the message as the JSON parser does.
+func bracketError(src string, offset int, message string) *SyntaxError {
+	err := newSyntaxError(src, offset, "", nil)
+	err.Message = fmt.Sprintf("line %d, column %d: %s", err.Line, err.Column, message)
+	return err
+}
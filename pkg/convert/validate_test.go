@@ -0,0 +1,53 @@
+package convert
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate(t *testing.T) {
+	for format, input := range map[string]string{
+		formatJSON:     `{"a": [1, 2.50, 12345678901234567890]}`,
+		formatYAML:     "a: 1\nb: [x, y]\n",
+		formatTOML:     "a = 1\n[b]\nc = \"x\"\n",
+		formatXML:      "<a><b>1</b></a>",
+		formatGoStruct: "type A struct {\n\tB int `json:\"b\"`\n}",
+		formatGraphQL:  "# {\ntype User {\n  name: String! # )\n  tags(first: Int): [String]\n}",
+		formatProtobuf: "syntax = \"proto3\";\n/* { */\nmessage User {\n  string name = 1; // }\n  map<string, int32> tags = 2 [json_name = \"t\"];\n}",
+		formatTOON:     "name: demo\ntags[2]: a,b",
+		formatCSV:      "a,b\n1,2\n",
+	} {
+		require.NoError(t, Validate(format, input), format)
+	}
+
+	for _, c := range []struct {
+		format, input string
+		line, column  int
+	}{
+		{formatJSON, "{\n  \"a\": 1,,\n}", 2, 10},
+		{formatJSON, `{"a": 1} x`, 1, 10},
+		{formatYAML, "a: 1\nb: [\n", 2, 0},
+		{formatTOML, "a = 1\nb = \n", 2, 5},
+		{formatXML, "<a>\n<b></a>", 2, 8},
+		{formatGoStruct, "type A struct {\n\tB int\n", 2, 7},
+		{formatGraphQL, "type User {\n  name: String\n  tags: [String\n}", 4, 1},
+		{formatGraphQL, "type User {\n  name: String\n", 1, 11},
+		{formatGraphQL, "type User {\n  \"\"\"doc\n}", 2, 3},
+		{formatProtobuf, "message A {\n  string a = 1;\n}}", 3, 2},
+		{formatProtobuf, "message A {\n  /* x\n}", 2, 3},
+		{formatTOON, "a: 1\nb: 2\nnokey", 3, 0},
+	} {
+		err := Validate(c.format, c.input)
+		var syntaxErr *SyntaxError
+		require.True(t, errors.As(err, &syntaxErr), "%s %q: %v", c.format, c.input, err)
+		require.Equal(t, c.format, syntaxErr.Format)
+		require.Equal(t, c.line, syntaxErr.Line, "%s %q", c.format, c.input)
+		require.Equal(t, c.column, syntaxErr.Column, "%s %q", c.format, c.input)
+	}
+
+	require.EqualError(t, Validate(formatGraphQL, "scalar Time"), "no GraphQL type definition found")
+	require.EqualError(t, Validate(formatProtobuf, `syntax = "proto3";`), "no protobuf message found")
+	require.Error(t, Validate("Nope", "x"))
+}
@@ -0,0 +1,122 @@
+package convert
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Schema types as Confluent Schema Registry names them. The registry leaves
+// the type out for Avro, so "" is read as Avro too.
+const (
+	SchemaTypeAvro     = "AVRO"
+	SchemaTypeProtobuf = "PROTOBUF"
+	SchemaTypeJSON     = "JSON"
+)
+
+// wireHeaderSize is the magic byte and the big-endian schema ID that start
+// every record written by a Confluent serializer.
+const wireHeaderSize = 5
+
+// WireSchemaID returns the schema ID of a Kafka record written in the
+// Confluent wire format, which Apicurio's compatible serializers share.
+func WireSchemaID(payload []byte) (int, error) {
+	if len(payload) < wireHeaderSize || payload[0] != 0 {
+		return 0, errors.New("payload is not in the schema registry wire format")
+	}
+	return int(binary.BigEndian.Uint32(payload[1:wireHeaderSize])), nil
+}
+
+// DecodeWirePayload decodes a record in the Confluent wire format with the
+// schema its ID names and returns it as JSON. Protobuf records also carry
+// the index path of their message in the schema, which picks the message
+// to decode.
+func DecodeWirePayload(schemaType, schema string, payload []byte) (string, error) {
+	if _, err := WireSchemaID(payload); err != nil {
+		return "", err
+	}
+	data := payload[wireHeaderSize:]
+	if strings.ToUpper(schemaType) != SchemaTypeProtobuf {
+		return DecodeSchemaPayload(schemaType, schema, data)
+	}
+	message, data, err := wireProtoMessage(schema, data)
+	if err != nil {
+		return "", err
+	}
+	return DecodeProtobuf(schema, message, data)
+}
+
+// DecodeSchemaPayload decodes data written with a registry schema, without
+// the wire format header, and returns it as JSON. Protobuf data is read as
+// the first message of the schema.
+func DecodeSchemaPayload(schemaType, schema string, data []byte) (string, error) {
+	switch strings.ToUpper(schemaType) {
+	case "", SchemaTypeAvro:
+		return DecodeAvro(schema, data)
+	case SchemaTypeProtobuf:
+		return DecodeProtobuf(schema, "", data)
+	case SchemaTypeJSON:
+		v, err := decodeJSONValue(string(data))
+		if err != nil {
+			return "", err
+		}
+		return encodeJSON(v)
+	}
+	return "", fmt.Errorf("unsupported schema type: %s", schemaType)
+}
+
+// RegistrySchemaToGoStruct generates Go structs from a registry schema of
+// the given type.
+func RegistrySchemaToGoStruct(schemaType, schema string) (string, error) {
+	switch strings.ToUpper(schemaType) {
+	case "", SchemaTypeAvro:
+		return ConvertFormats(formatAvro, formatGoStruct, schema)
+	case SchemaTypeProtobuf:
+		return ProtoToGoStruct(schema)
+	case SchemaTypeJSON:
+		return SchemaToGoStruct(schema)
+	}
+	return "", fmt.Errorf("unsupported schema type: %s", schemaType)
+}
+
+// wireProtoMessage reads the message indexes that follow the schema ID of
+// a protobuf record and returns the message they name with the rest of the
+// data. The indexes are a zig-zag varint count and that many indexes, each
+// into the messages declared at one level; a lone 0 stands for [0].
+func wireProtoMessage(schema string, data []byte) (string, []byte, error) {
+	next := func() (int, error) {
+		v, n := binary.Varint(data)
+		if n <= 0 || v < 0 {
+			return 0, errors.New("protobuf: invalid message index")
+		}
+		data = data[n:]
+		return int(v), nil
+	}
+	count, err := next()
+	if err != nil {
+		return "", nil, err
+	}
+	indexes := []int{0}
+	if count > 0 {
+		if count > len(data) {
+			return "", nil, errors.New("protobuf: invalid message index")
+		}
+		indexes = make([]int, count)
+		for i := range indexes {
+			if indexes[i], err = next(); err != nil {
+				return "", nil, err
+			}
+		}
+	}
+	ps := parseProtoSchema(schema)
+	message := ""
+	for _, i := range indexes {
+		names := ps.nested[message]
+		if i >= len(names) {
+			return "", nil, fmt.Errorf("protobuf: schema has no message at index %v", indexes)
+		}
+		message = names[i]
+	}
+	return message, data, nil
+}
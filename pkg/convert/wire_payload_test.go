@@ -0,0 +1,58 @@
+package convert
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeWirePayload(t *testing.T) {
+	header := []byte{0, 0, 0, 0x01, 0x02}
+	id, err := WireSchemaID(header)
+	require.NoError(t, err)
+	require.Equal(t, 258, id)
+	_, err = WireSchemaID([]byte(`{"a":1}`))
+	require.EqualError(t, err, "payload is not in the schema registry wire format")
+
+	avro := append(append([]byte{}, header...), binary.AppendVarint(nil, 5)...)
+	out, err := DecodeWirePayload("", `{"type": "long"}`, avro)
+	require.NoError(t, err)
+	require.Equal(t, "5\n", out)
+
+	out, err = DecodeWirePayload(SchemaTypeJSON, `{"type": "object"}`, append(append([]byte{}, header...), `{"b":1,"a":[true]}`...))
+	require.NoError(t, err)
+	require.JSONEq(t, `{"a": [true], "b": 1}`, out)
+
+	// A lone 0 picks the first message and [0, 0] the first message nested
+	// in it.
+	line := protoLen(nil, 1, []byte("x"))
+	order := protoLen(nil, 2, []byte("Ricky"))
+	out, err = DecodeWirePayload(SchemaTypeProtobuf, sampleProtoOrder, append(append(append([]byte{}, header...), 0), order...))
+	require.NoError(t, err)
+	require.JSONEq(t, `{"customer": "Ricky"}`, out)
+	indexes := binary.AppendVarint(binary.AppendVarint(binary.AppendVarint(nil, 2), 0), 0)
+	out, err = DecodeWirePayload(SchemaTypeProtobuf, sampleProtoOrder, append(append(append([]byte{}, header...), indexes...), line...))
+	require.NoError(t, err)
+	require.JSONEq(t, `{"sku": "x"}`, out)
+	indexes = binary.AppendVarint(binary.AppendVarint(nil, 1), 5)
+	_, err = DecodeWirePayload(SchemaTypeProtobuf, sampleProtoOrder, append(append([]byte{}, header...), indexes...))
+	require.EqualError(t, err, "protobuf: schema has no message at index [5]")
+
+	_, err = DecodeSchemaPayload("XML", "", nil)
+	require.EqualError(t, err, "unsupported schema type: XML")
+}
+
+func TestRegistrySchemaToGoStruct(t *testing.T) {
+	for schemaType, schema := range map[string]string{
+		"":                 `{"type": "record", "name": "User", "fields": [{"name": "name", "type": "string"}]}`,
+		SchemaTypeProtobuf: "message User {\n  string name = 1;\n}\n",
+		SchemaTypeJSON:     `{"type": "object", "properties": {"name": {"type": "string"}}}`,
+	} {
+		out, err := RegistrySchemaToGoStruct(schemaType, schema)
+		require.NoError(t, err, schemaType)
+		require.Contains(t, out, "Name string", schemaType)
+	}
+	_, err := RegistrySchemaToGoStruct("XML", "")
+	require.EqualError(t, err, "unsupported schema type: XML")
+}
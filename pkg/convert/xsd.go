@@ -0,0 +1,328 @@
+package convert
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/linzeyan/transform-go/pkg/common"
+)
+
+const (
+	formatXSD = "XML Schema"
+	xsdNS     = "http://www.w3.org/2001/XMLSchema"
+)
+
+var (
+	xsdIntegerRe = common.LazyRegexp(`^[+-]?\d+$`)
+	xsdDecimalRe = common.LazyRegexp(`^[+-]?(?:\d+\.\d*|\.\d+)$`)
+)
+
+// xmlSampleElement is an element of a sample document XMLToXSD reads.
+type xmlSampleElement struct {
+	name     xml.Name
+	attrs    []xml.Attr
+	children []*xmlSampleElement
+	text     string
+}
+
+// xsdDecl gathers what the occurrences of an element in a sample say about
+// its declaration.
+type xsdDecl struct {
+	name     string
+	count    int // occurrences
+	seenIn   int // occurrences of the parent holding it
+	min, max int // occurrences within one occurrence of the parent
+	typ      string
+	hasText  bool
+	children []*xsdDecl // in document order
+	attrs    []*xsdAttrDecl
+	// ordered is cleared when children come in differing orders, so no
+	// sequence fits them.
+	ordered bool
+}
+
+type xsdAttrDecl struct {
+	name  string
+	count int
+	typ   string
+}
+
+func XMLToXSD(input string) (string, error) {
+	return XMLToXSDWithOptions(input)
+}
+
+// XMLToXSDWithOptions infers an XML Schema from a sample document, with
+// every element declared inside its parent. Elements missing from some
+// occurrences of their parent get minOccurs="0" and repeated ones
+// maxOccurs="unbounded"; attributes present on every occurrence are
+// required. Text and attribute values are typed xs:boolean, xs:integer,
+// xs:decimal, xs:date or xs:dateTime when every sample fits, and
+// xs:string otherwise. Children that come in differing orders go in an
+// xs:choice instead of an xs:sequence.
+func XMLToXSDWithOptions(input string, opts ...ConvertOption) (string, error) {
+	o := NewConvertOptions(opts...)
+	root, err := parseXMLSample(input)
+	if err != nil {
+		return "", err
+	}
+	decl := &xsdDecl{name: root.name.Local, ordered: true}
+	decl.add(root)
+	decl.seenIn, decl.min, decl.max = 1, 1, 1
+
+	header, err := o.xmlDeclaration(`version="1.0" encoding="UTF-8"`, true)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	if header != "" {
+		b.WriteString(header + "\n")
+	}
+	b.WriteString(`<xs:schema xmlns:xs="` + xsdNS + `"`)
+	if ns := root.name.Space; ns != "" {
+		b.WriteString(` targetNamespace="` + escapeXMLAttr(ns) + `" xmlns="` + escapeXMLAttr(ns) + `"`)
+	}
+	b.WriteString(` elementFormDefault="qualified">` + "\n")
+	w := xsdWriter{b: &b, indent: o.indentString()}
+	w.element(decl, 1, false)
+	b.WriteString("</xs:schema>\n")
+	return b.String(), nil
+}
+
+// jsonToXSDWithOptions infers a schema for the XML JSONToXML writes.
+func jsonToXSDWithOptions(input string, o ConvertOptions) (string, error) {
+	doc, err := jsonToXMLWithOptions(input, o)
+	if err != nil {
+		return "", err
+	}
+	return XMLToXSDWithOptions(doc, WithOptions(o))
+}
+
+func parseXMLSample(input string) (*xmlSampleElement, error) {
+	dec := xml.NewDecoder(strings.NewReader(input))
+	var root *xmlSampleElement
+	var stack []*xmlSampleElement
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, xmlSyntaxError(input, dec, err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			e := &xmlSampleElement{name: t.Name, attrs: t.Copy().Attr}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, e)
+			} else if root == nil {
+				root = e
+			}
+			stack = append(stack, e)
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].text += string(t)
+			}
+		}
+	}
+	if root == nil {
+		return nil, errors.New("invalid XML input")
+	}
+	return root, nil
+}
+
+// add merges one occurrence of the element into d.
+func (d *xsdDecl) add(e *xmlSampleElement) {
+	d.count++
+	for _, a := range e.attrs {
+		if a.Name.Space != "" || a.Name.Local == "xmlns" {
+			continue
+		}
+		attr := d.attr(a.Name.Local)
+		attr.count++
+		attr.typ = mergeXSDTypes(attr.typ, xsdValueType(a.Value))
+	}
+	if text := strings.TrimSpace(e.text); text != "" {
+		d.hasText = true
+		d.typ = mergeXSDTypes(d.typ, xsdValueType(text))
+	}
+
+	counts := map[*xsdDecl]int{}
+	last := -1 // index in d.children of the child before
+	for _, c := range e.children {
+		at := d.child(c.name.Local, last)
+		child := d.children[at]
+		if counts[child] > 0 && at != last {
+			d.ordered = false // it came back after another child
+		}
+		if at < last {
+			d.ordered = false
+		}
+		counts[child]++
+		last = at
+		child.add(c)
+	}
+	for _, child := range d.children {
+		n := counts[child]
+		if n == 0 {
+			child.min = 0
+			continue
+		}
+		if child.seenIn == 0 && d.count > 1 {
+			child.min = 0 // missing from earlier occurrences
+		} else if child.seenIn == 0 || n < child.min {
+			child.min = n
+		}
+		child.seenIn++
+		child.max = max(child.max, n)
+	}
+}
+
+// child returns the index of the child declaration called name, adding
+// one after the child at index after when there is none.
+func (d *xsdDecl) child(name string, after int) int {
+	for i, c := range d.children {
+		if c.name == name {
+			return i
+		}
+	}
+	c := &xsdDecl{name: name, ordered: true}
+	d.children = append(d.children, nil)
+	copy(d.children[after+2:], d.children[after+1:])
+	d.children[after+1] = c
+	return after + 1
+}
+
+func (d *xsdDecl) attr(name string) *xsdAttrDecl {
+	for _, a := range d.attrs {
+		if a.name == name {
+			return a
+		}
+	}
+	a := &xsdAttrDecl{name: name}
+	d.attrs = append(d.attrs, a)
+	return a
+}
+
+// xsdValueType names the narrowest built-in type that holds s.
+func xsdValueType(s string) string {
+	s = strings.TrimSpace(s)
+	switch {
+	case s == "true" || s == "false":
+		return "xs:boolean"
+	case xsdIntegerRe().MatchString(s):
+		return "xs:integer"
+	case xsdDecimalRe().MatchString(s):
+		return "xs:decimal"
+	}
+	if _, err := time.Parse(time.DateOnly, s); err == nil {
+		return "xs:date"
+	}
+	if _, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return "xs:dateTime"
+	}
+	return "xs:string"
+}
+
+// mergeXSDTypes returns the type that holds values of both a and b; "" is
+// no value seen yet.
+func mergeXSDTypes(a, b string) string {
+	switch {
+	case a == "" || a == b:
+		return b
+	case b == "":
+		return a
+	case a == "xs:integer" && b == "xs:decimal" || a == "xs:decimal" && b == "xs:integer":
+		return "xs:decimal"
+	}
+	return "xs:string"
+}
+
+type xsdWriter struct {
+	b      *strings.Builder
+	indent string
+}
+
+func (w xsdWriter) line(depth int, s string) {
+	w.b.WriteString(strings.Repeat(w.indent, depth) + s + "\n")
+}
+
+// element writes the declaration of d; in a choice the choice carries the
+// occurrence bounds.
+func (w xsdWriter) element(d *xsdDecl, depth int, inChoice bool) {
+	typ := d.typ
+	if typ == "" {
+		typ = "xs:string"
+	}
+	simple := len(d.children) == 0 && len(d.attrs) == 0
+	open := `<xs:element name="` + escapeXMLAttr(d.name) + `"`
+	if simple {
+		open += ` type="` + typ + `"`
+	}
+	if !inChoice {
+		if d.min == 0 {
+			open += ` minOccurs="0"`
+		}
+		if d.max > 1 {
+			open += ` maxOccurs="unbounded"`
+		}
+	}
+	if simple {
+		w.line(depth, open+"/>")
+		return
+	}
+	w.line(depth, open+">")
+	switch {
+	case len(d.children) == 0 && d.hasText:
+		w.line(depth+1, "<xs:complexType>")
+		w.line(depth+2, "<xs:simpleContent>")
+		w.line(depth+3, `<xs:extension base="`+typ+`">`)
+		w.attributes(d, depth+4)
+		w.line(depth+3, "</xs:extension>")
+		w.line(depth+2, "</xs:simpleContent>")
+		w.line(depth+1, "</xs:complexType>")
+	case len(d.children) == 0:
+		w.line(depth+1, "<xs:complexType>")
+		w.attributes(d, depth+2)
+		w.line(depth+1, "</xs:complexType>")
+	default:
+		mixed := ""
+		if d.hasText {
+			mixed = ` mixed="true"`
+		}
+		w.line(depth+1, "<xs:complexType"+mixed+">")
+		group := "xs:sequence"
+		if !d.ordered {
+			group = "xs:choice"
+			w.line(depth+2, `<xs:choice minOccurs="0" maxOccurs="unbounded">`)
+		} else {
+			w.line(depth+2, "<xs:sequence>")
+		}
+		for _, c := range d.children {
+			w.element(c, depth+3, !d.ordered)
+		}
+		w.line(depth+2, "</"+group+">")
+		w.attributes(d, depth+2)
+		w.line(depth+1, "</xs:complexType>")
+	}
+	w.line(depth, "</xs:element>")
+}
+
+func (w xsdWriter) attributes(d *xsdDecl, depth int) {
+	for _, a := range d.attrs {
+		typ := a.typ
+		if typ == "" {
+			typ = "xs:string"
+		}
+		use := ""
+		if a.count == d.count {
+			use = ` use="required"`
+		}
+		w.line(depth, `<xs:attribute name="`+escapeXMLAttr(a.name)+`" type="`+typ+`"`+use+"/>")
+	}
+}
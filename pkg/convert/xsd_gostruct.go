@@ -0,0 +1,496 @@
+package convert
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"go/format"
+	"strconv"
+	"strings"
+
+	"github.com/linzeyan/transform-go/pkg/common"
+)
+
+// xsdSchema and the types below are the parts of an XML Schema
+// XSDToGoStruct reads. encoding/xml matches them by local name, so any
+// prefix for the XML Schema namespace works.
+type xsdSchema struct {
+	TargetNamespace string           `xml:"targetNamespace,attr"`
+	Attrs           []xml.Attr       `xml:",any,attr"`
+	Elements        []xsdElement     `xml:"element"`
+	ComplexTypes    []xsdComplexType `xml:"complexType"`
+	SimpleTypes     []xsdSimpleType  `xml:"simpleType"`
+}
+
+type xsdAnnotation struct {
+	Documentation []string `xml:"documentation"`
+}
+
+type xsdElement struct {
+	Name        string          `xml:"name,attr"`
+	Type        string          `xml:"type,attr"`
+	Ref         string          `xml:"ref,attr"`
+	MinOccurs   string          `xml:"minOccurs,attr"`
+	MaxOccurs   string          `xml:"maxOccurs,attr"`
+	Annotation  *xsdAnnotation  `xml:"annotation"`
+	ComplexType *xsdComplexType `xml:"complexType"`
+	SimpleType  *xsdSimpleType  `xml:"simpleType"`
+}
+
+type xsdComplexType struct {
+	Name           string         `xml:"name,attr"`
+	Mixed          bool           `xml:"mixed,attr"`
+	Annotation     *xsdAnnotation `xml:"annotation"`
+	Sequence       *xsdGroup      `xml:"sequence"`
+	Choice         *xsdGroup      `xml:"choice"`
+	All            *xsdGroup      `xml:"all"`
+	Attributes     []xsdAttribute `xml:"attribute"`
+	SimpleContent  *xsdContent    `xml:"simpleContent"`
+	ComplexContent *xsdContent    `xml:"complexContent"`
+}
+
+// xsdGroup is a sequence, choice or all.
+type xsdGroup struct {
+	MinOccurs string        `xml:"minOccurs,attr"`
+	MaxOccurs string        `xml:"maxOccurs,attr"`
+	Particles []xsdParticle `xml:",any"`
+}
+
+// xsdParticle is an element, sequence, choice or all inside a group, in
+// schema order; XMLName tells which.
+type xsdParticle struct {
+	XMLName xml.Name
+	xsdElement
+	Particles []xsdParticle `xml:",any"`
+}
+
+type xsdContent struct {
+	Extension   *xsdDerivation `xml:"extension"`
+	Restriction *xsdDerivation `xml:"restriction"`
+}
+
+type xsdDerivation struct {
+	Base       string         `xml:"base,attr"`
+	Sequence   *xsdGroup      `xml:"sequence"`
+	Choice     *xsdGroup      `xml:"choice"`
+	All        *xsdGroup      `xml:"all"`
+	Attributes []xsdAttribute `xml:"attribute"`
+}
+
+type xsdAttribute struct {
+	Name       string         `xml:"name,attr"`
+	Type       string         `xml:"type,attr"`
+	Use        string         `xml:"use,attr"`
+	Annotation *xsdAnnotation `xml:"annotation"`
+	SimpleType *xsdSimpleType `xml:"simpleType"`
+}
+
+type xsdSimpleType struct {
+	Name        string `xml:"name,attr"`
+	Restriction *struct {
+		Base string `xml:"base,attr"`
+	} `xml:"restriction"`
+}
+
+// xsdGoTypes maps XML Schema built-in types to Go types; the rest are
+// strings.
+var xsdGoTypes = map[string]string{
+	"boolean":            "bool",
+	"int":                "int",
+	"integer":            "int64",
+	"long":               "int64",
+	"nonNegativeInteger": "int64",
+	"nonPositiveInteger": "int64",
+	"positiveInteger":    "int64",
+	"negativeInteger":    "int64",
+	"short":              "int16",
+	"byte":               "int8",
+	"unsignedLong":       "uint64",
+	"unsignedInt":        "uint32",
+	"unsignedShort":      "uint16",
+	"unsignedByte":       "uint8",
+	"decimal":            "float64",
+	"double":             "float64",
+	"float":              "float32",
+	"dateTime":           "time.Time",
+}
+
+// XSDToGoStruct generates Go types with encoding/xml tags from an XML
+// Schema: a struct with an XMLName field for every top-level element and
+// one for every named or nested complex type. Attributes get ,attr tags
+// and simple content a ,chardata field; optional elements and attributes
+// get ,omitempty, optional structs are pointers and repeated elements
+// slices. Types derived by complexContent extension embed their base.
+// Built-in types map to Go types, xs:dateTime to time.Time, and simple
+// types to the Go type of their base.
+func XSDToGoStruct(input string) (string, error) {
+	var schema xsdSchema
+	dec := xml.NewDecoder(strings.NewReader(input))
+	if err := dec.Decode(&schema); err != nil {
+		return "", xmlSyntaxError(input, dec, err)
+	}
+	if len(schema.Elements) == 0 && len(schema.ComplexTypes) == 0 {
+		return "", errors.New("the schema declares no elements or complex types")
+	}
+	g := &xsdGoGen{
+		schema:   &schema,
+		prefixes: map[string]string{},
+		used:     map[string]bool{},
+		named:    map[string]string{},
+		elements: map[string]string{},
+	}
+	for _, a := range schema.Attrs {
+		switch {
+		case a.Name.Space == "xmlns":
+			g.prefixes[a.Name.Local] = a.Value
+		case a.Name.Space == "" && a.Name.Local == "xmlns":
+			g.prefixes[""] = a.Value
+		}
+	}
+	// Named types keep their names; elements and nested types take what
+	// is left.
+	for _, ct := range schema.ComplexTypes {
+		g.named[ct.Name] = g.typeName(ct.Name, "")
+	}
+	for _, e := range schema.Elements {
+		if e.Name != "" {
+			g.elements[e.Name] = g.typeName(e.Name, "")
+		}
+	}
+	for i := range schema.Elements {
+		e := &schema.Elements[i]
+		if e.Name == "" {
+			continue
+		}
+		tag := e.Name
+		if schema.TargetNamespace != "" {
+			tag = schema.TargetNamespace + " " + e.Name
+		}
+		fields := []string{"XMLName xml.Name `xml:\"" + tag + "\"`"}
+		at := g.reserve()
+		var err error
+		switch {
+		case e.ComplexType != nil:
+			fields, err = g.fields(e.ComplexType, g.elements[e.Name], fields)
+		case g.complexType(e.Type) != nil:
+			fields = append(fields, g.named[xsdLocal(e.Type)])
+		default:
+			fields = append(fields, "Value "+g.simpleType(e.Type, e.SimpleType)+" `xml:\",chardata\"`")
+		}
+		if err != nil {
+			return "", err
+		}
+		g.declare(at, g.elements[e.Name], e.Annotation, fields)
+	}
+	for i := range schema.ComplexTypes {
+		ct := &schema.ComplexTypes[i]
+		at := g.reserve()
+		fields, err := g.fields(ct, g.named[ct.Name], nil)
+		if err != nil {
+			return "", err
+		}
+		g.declare(at, g.named[ct.Name], ct.Annotation, fields)
+	}
+
+	decls := strings.Join(g.decls, "\n")
+	src := "package main\n\n" + goImportBlock(decls) + decls
+	formatted, err := format.Source([]byte(src))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(strings.TrimPrefix(string(formatted), "package main\n\n")), nil
+}
+
+type xsdGoGen struct {
+	schema   *xsdSchema
+	prefixes map[string]string // namespace URI by prefix
+	used     map[string]bool   // Go type names taken
+	named    map[string]string // Go type name by complex type name
+	elements map[string]string // Go type name by top-level element name
+	decls    []string
+	depth    int
+}
+
+// typeName returns an unused Go type name for name, prefixed with the
+// parent type's name if needed.
+func (g *xsdGoGen) typeName(name, parent string) string {
+	base := sanitizeTypeName(name)
+	candidate := base
+	if g.used[candidate] && parent != "" {
+		candidate = parent + base
+	}
+	for i := 2; g.used[candidate]; i++ {
+		candidate = base + strconv.Itoa(i)
+	}
+	g.used[candidate] = true
+	return candidate
+}
+
+// reserve holds the place of a struct declared once its fields are known,
+// so that it comes before the nested types they declare.
+func (g *xsdGoGen) reserve() int {
+	g.decls = append(g.decls, "")
+	return len(g.decls) - 1
+}
+
+// declare writes a struct declaration at a reserved place.
+func (g *xsdGoGen) declare(at int, name string, doc *xsdAnnotation, fields []string) {
+	var b strings.Builder
+	b.WriteString(xsdComment(doc))
+	b.WriteString("type " + name + " struct {\n")
+	for _, f := range fields {
+		b.WriteString("\t" + f + "\n")
+	}
+	b.WriteString("}\n")
+	g.decls[at] = b.String()
+}
+
+func xsdComment(doc *xsdAnnotation) string {
+	if doc == nil {
+		return ""
+	}
+	var b strings.Builder
+	for _, d := range doc.Documentation {
+		for _, line := range strings.Split(strings.TrimSpace(d), "\n") {
+			b.WriteString("// " + strings.TrimSpace(line) + "\n")
+		}
+	}
+	return b.String()
+}
+
+// fields appends the fields of a complex type, that of the struct parent,
+// to fields.
+func (g *xsdGoGen) fields(ct *xsdComplexType, parent string, fields []string) ([]string, error) {
+	g.depth++
+	defer func() { g.depth-- }()
+	if g.depth > docMaxDepth {
+		return nil, errors.New("complex types nested too deeply")
+	}
+	names := map[string]bool{"XMLName": len(fields) > 0}
+	attrs := ct.Attributes
+	switch {
+	case ct.SimpleContent != nil:
+		d := ct.SimpleContent.derivation()
+		if d == nil {
+			return nil, errors.New("simpleContent without extension or restriction")
+		}
+		typ := g.simpleType(d.Base, nil)
+		if base := g.complexType(d.Base); base != nil {
+			typ = "string"
+			if base.SimpleContent != nil && base.SimpleContent.derivation() != nil {
+				typ = g.simpleType(base.SimpleContent.derivation().Base, nil)
+			}
+		}
+		fields = append(fields, "Value "+typ+" `xml:\",chardata\"`")
+		names["Value"] = true
+		attrs = append(d.Attributes, attrs...)
+	case ct.ComplexContent != nil:
+		d := ct.ComplexContent.derivation()
+		if d == nil {
+			return nil, errors.New("complexContent without extension or restriction")
+		}
+		if ct.ComplexContent.Extension != nil && g.complexType(d.Base) != nil {
+			fields = append(fields, g.named[xsdLocal(d.Base)])
+		}
+		for _, group := range []*xsdGroup{d.Sequence, d.Choice, d.All} {
+			var err error
+			if fields, err = g.group(group, group == d.Choice, parent, names, fields); err != nil {
+				return nil, err
+			}
+		}
+		attrs = append(d.Attributes, attrs...)
+	default:
+		if ct.Mixed {
+			fields = append(fields, "Text string `xml:\",chardata\"`")
+			names["Text"] = true
+		}
+		for _, group := range []*xsdGroup{ct.Sequence, ct.Choice, ct.All} {
+			var err error
+			if fields, err = g.group(group, group == ct.Choice, parent, names, fields); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for _, a := range attrs {
+		if a.Name == "" {
+			continue
+		}
+		tag := a.Name + ",attr"
+		if a.Use != "required" {
+			tag += ",omitempty"
+		}
+		name := xsdFieldName(a.Name, names, "Attr")
+		fields = append(fields, xsdComment(a.Annotation)+name+" "+g.simpleType(a.Type, a.SimpleType)+" `xml:\""+tag+"\"`")
+	}
+	return fields, nil
+}
+
+func (c *xsdContent) derivation() *xsdDerivation {
+	if c.Extension != nil {
+		return c.Extension
+	}
+	return c.Restriction
+}
+
+// group appends a field for every element of a sequence, choice or all;
+// the elements of a choice are optional.
+func (g *xsdGoGen) group(group *xsdGroup, choice bool, parent string, names map[string]bool, fields []string) ([]string, error) {
+	if group == nil {
+		return fields, nil
+	}
+	optional := choice || group.MinOccurs == "0"
+	repeated := xsdRepeated(group.MaxOccurs)
+	for i := range group.Particles {
+		p := &group.Particles[i]
+		switch p.XMLName.Local {
+		case "sequence", "all", "choice":
+			sub := &xsdGroup{MinOccurs: p.MinOccurs, MaxOccurs: p.MaxOccurs, Particles: p.Particles}
+			if optional {
+				sub.MinOccurs = "0"
+			}
+			if repeated {
+				sub.MaxOccurs = "unbounded"
+			}
+			var err error
+			if fields, err = g.group(sub, p.XMLName.Local == "choice", parent, names, fields); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if p.XMLName.Local != "element" {
+			continue // xs:any and group references have no fields
+		}
+		e := &p.xsdElement
+		decl := e
+		if e.Ref != "" {
+			if decl = g.element(e.Ref); decl == nil {
+				return nil, fmt.Errorf("element %s is not declared", e.Ref)
+			}
+		}
+		if decl.Name == "" {
+			continue
+		}
+		var typ string
+		isStruct := true
+		switch {
+		case e.Ref != "" && decl.ComplexType != nil:
+			typ = g.elements[decl.Name]
+		case decl.ComplexType != nil:
+			typ = g.typeName(decl.Name, parent)
+			at := g.reserve()
+			nested, err := g.fields(decl.ComplexType, typ, nil)
+			if err != nil {
+				return nil, err
+			}
+			g.declare(at, typ, decl.Annotation, nested)
+		case g.complexType(decl.Type) != nil:
+			typ = g.named[xsdLocal(decl.Type)]
+		default:
+			typ, isStruct = g.simpleType(decl.Type, decl.SimpleType), false
+		}
+		tag := decl.Name
+		switch {
+		case repeated || xsdRepeated(e.MaxOccurs):
+			typ = "[]" + typ
+		case optional || e.MinOccurs == "0":
+			tag += ",omitempty"
+			if isStruct {
+				typ = "*" + typ
+			}
+		}
+		name := xsdFieldName(decl.Name, names, "Elem")
+		fields = append(fields, xsdComment(decl.Annotation)+name+" "+typ+" `xml:\""+tag+"\"`")
+	}
+	return fields, nil
+}
+
+func xsdRepeated(maxOccurs string) bool {
+	return maxOccurs == "unbounded" || maxOccurs != "" && maxOccurs != "0" && maxOccurs != "1"
+}
+
+// xsdFieldName returns an unused field name for name, suffixed when an
+// element and an attribute share it.
+func xsdFieldName(name string, names map[string]bool, suffix string) string {
+	field := common.ExportName(name)
+	if field == "" {
+		field = "Field"
+	}
+	if names[field] {
+		field += suffix
+	}
+	for i := 2; names[field]; i++ {
+		field = common.ExportName(name) + suffix + strconv.Itoa(i)
+	}
+	names[field] = true
+	return field
+}
+
+// xsdLocal drops the prefix of a qualified name.
+func xsdLocal(qname string) string {
+	if i := strings.IndexByte(qname, ':'); i >= 0 {
+		return qname[i+1:]
+	}
+	return qname
+}
+
+// builtin reports whether qname names an XML Schema built-in type.
+func (g *xsdGoGen) builtin(qname string) bool {
+	prefix := ""
+	if i := strings.IndexByte(qname, ':'); i >= 0 {
+		prefix = qname[:i]
+	}
+	return g.prefixes[prefix] == xsdNS
+}
+
+func (g *xsdGoGen) complexType(qname string) *xsdComplexType {
+	if qname == "" || g.builtin(qname) {
+		return nil
+	}
+	for i, ct := range g.schema.ComplexTypes {
+		if ct.Name == xsdLocal(qname) {
+			return &g.schema.ComplexTypes[i]
+		}
+	}
+	return nil
+}
+
+func (g *xsdGoGen) element(qname string) *xsdElement {
+	for i, e := range g.schema.Elements {
+		if e.Name == xsdLocal(qname) {
+			return &g.schema.Elements[i]
+		}
+	}
+	return nil
+}
+
+// simpleType returns the Go type for a built-in or simple type, following
+// restrictions to their base; an inline simple type wins over qname.
+func (g *xsdGoGen) simpleType(qname string, inline *xsdSimpleType) string {
+	for range len(g.schema.SimpleTypes) + 1 {
+		if inline != nil {
+			if inline.Restriction == nil {
+				return "string"
+			}
+			qname, inline = inline.Restriction.Base, nil
+		}
+		if qname == "" {
+			return "string"
+		}
+		if g.builtin(qname) {
+			if t, ok := xsdGoTypes[xsdLocal(qname)]; ok {
+				return t
+			}
+			return "string"
+		}
+		for i, st := range g.schema.SimpleTypes {
+			if st.Name == xsdLocal(qname) {
+				inline = &g.schema.SimpleTypes[i]
+				break
+			}
+		}
+		if inline == nil {
+			return "string"
+		}
+	}
+	return "string"
+}
@@ -0,0 +1,184 @@
+package convert
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestXMLToXSD(t *testing.T) {
+	out, err := XMLToXSD(`<orders xmlns="urn:shop">
+  <order id="1" rush="true">
+    <item sku="a">2</item>
+    <item sku="b">1.5</item>
+    <placed>2024-05-01T10:00:00Z</placed>
+  </order>
+  <order id="2">
+    <item sku="c">3</item>
+    <note>call first</note>
+  </order>
+  <log><warn>x</warn><info>y</info><warn>z</warn></log>
+</orders>`)
+	require.NoError(t, err)
+	require.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>
+<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema" targetNamespace="urn:shop" xmlns="urn:shop" elementFormDefault="qualified">
+  <xs:element name="orders">
+    <xs:complexType>
+      <xs:sequence>
+        <xs:element name="order" maxOccurs="unbounded">
+          <xs:complexType>
+            <xs:sequence>
+              <xs:element name="item" maxOccurs="unbounded">
+                <xs:complexType>
+                  <xs:simpleContent>
+                    <xs:extension base="xs:decimal">
+                      <xs:attribute name="sku" type="xs:string" use="required"/>
+                    </xs:extension>
+                  </xs:simpleContent>
+                </xs:complexType>
+              </xs:element>
+              <xs:element name="note" type="xs:string" minOccurs="0"/>
+              <xs:element name="placed" type="xs:dateTime" minOccurs="0"/>
+            </xs:sequence>
+            <xs:attribute name="id" type="xs:integer" use="required"/>
+            <xs:attribute name="rush" type="xs:boolean"/>
+          </xs:complexType>
+        </xs:element>
+        <xs:element name="log">
+          <xs:complexType>
+            <xs:choice minOccurs="0" maxOccurs="unbounded">
+              <xs:element name="warn" type="xs:string"/>
+              <xs:element name="info" type="xs:string"/>
+            </xs:choice>
+          </xs:complexType>
+        </xs:element>
+      </xs:sequence>
+    </xs:complexType>
+  </xs:element>
+</xs:schema>
+`, out)
+
+	out, err = ConvertFormatsWithOptions(formatJSON, formatXSD, `{"a": [1, 2]}`, WithXMLDeclaration(XMLDeclarationOmit), WithIndent(1))
+	require.NoError(t, err)
+	require.Equal(t, `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema" elementFormDefault="qualified">
+ <xs:element name="root">
+  <xs:complexType>
+   <xs:sequence>
+    <xs:element name="a" type="xs:integer" maxOccurs="unbounded"/>
+   </xs:sequence>
+  </xs:complexType>
+ </xs:element>
+</xs:schema>
+`, out)
+
+	_, err = XMLToXSD("<a><b></a>")
+	require.Error(t, err)
+}
+
+const xsdLibrary = `<?xml version="1.0"?>
+<xsd:schema xmlns:xsd="http://www.w3.org/2001/XMLSchema" xmlns:lib="urn:lib" targetNamespace="urn:lib">
+  <xsd:element name="library">
+    <xsd:annotation><xsd:documentation>A lending library.</xsd:documentation></xsd:annotation>
+    <xsd:complexType>
+      <xsd:sequence>
+        <xsd:element name="book" type="lib:Book" maxOccurs="unbounded"/>
+        <xsd:element ref="lib:opened" minOccurs="0"/>
+        <xsd:element name="address" minOccurs="0">
+          <xsd:complexType>
+            <xsd:all>
+              <xsd:element name="city" type="xsd:string"/>
+            </xsd:all>
+          </xsd:complexType>
+        </xsd:element>
+      </xsd:sequence>
+    </xsd:complexType>
+  </xsd:element>
+  <xsd:element name="opened" type="xsd:dateTime"/>
+  <xsd:complexType name="Item">
+    <xsd:sequence>
+      <xsd:element name="title" type="xsd:string"/>
+    </xsd:sequence>
+    <xsd:attribute name="id" type="xsd:positiveInteger" use="required"/>
+  </xsd:complexType>
+  <xsd:complexType name="Book">
+    <xsd:complexContent>
+      <xsd:extension base="lib:Item">
+        <xsd:sequence>
+          <xsd:choice>
+            <xsd:element name="isbn" type="lib:ISBN"/>
+            <xsd:element name="issn" type="xsd:string"/>
+          </xsd:choice>
+          <xsd:element name="price" type="lib:Price" minOccurs="0"/>
+        </xsd:sequence>
+        <xsd:attribute name="pages" type="xsd:unsignedShort"/>
+      </xsd:extension>
+    </xsd:complexContent>
+  </xsd:complexType>
+  <xsd:complexType name="Price">
+    <xsd:simpleContent>
+      <xsd:extension base="xsd:decimal">
+        <xsd:attribute name="currency" type="xsd:string" use="required"/>
+      </xsd:extension>
+    </xsd:simpleContent>
+  </xsd:complexType>
+  <xsd:simpleType name="ISBN">
+    <xsd:restriction base="xsd:string"><xsd:pattern value="\d{13}"/></xsd:restriction>
+  </xsd:simpleType>
+</xsd:schema>`
+
+func TestXSDToGoStruct(t *testing.T) {
+	out, err := XSDToGoStruct(xsdLibrary)
+	require.NoError(t, err)
+	require.Equal(t, `import (
+	"encoding/xml"
+	"time"
+)
+
+// A lending library.
+type Library struct {
+	XMLName xml.Name  `+"`"+`xml:"urn:lib library"`+"`"+`
+	Book    []Book    `+"`"+`xml:"book"`+"`"+`
+	Opened  time.Time `+"`"+`xml:"opened,omitempty"`+"`"+`
+	Address *Address  `+"`"+`xml:"address,omitempty"`+"`"+`
+}
+
+type Address struct {
+	City string `+"`"+`xml:"city"`+"`"+`
+}
+
+type Opened struct {
+	XMLName xml.Name  `+"`"+`xml:"urn:lib opened"`+"`"+`
+	Value   time.Time `+"`"+`xml:",chardata"`+"`"+`
+}
+
+type Item struct {
+	Title string `+"`"+`xml:"title"`+"`"+`
+	Id    int64  `+"`"+`xml:"id,attr"`+"`"+`
+}
+
+type Book struct {
+	Item
+	Isbn  string `+"`"+`xml:"isbn,omitempty"`+"`"+`
+	Issn  string `+"`"+`xml:"issn,omitempty"`+"`"+`
+	Price *Price `+"`"+`xml:"price,omitempty"`+"`"+`
+	Pages uint16 `+"`"+`xml:"pages,attr,omitempty"`+"`"+`
+}
+
+type Price struct {
+	Value    float64 `+"`"+`xml:",chardata"`+"`"+`
+	Currency string  `+"`"+`xml:"currency,attr"`+"`"+`
+}`, out)
+
+	// A schema inferred from a sample turns into types for the sample.
+	xsd, err := XMLToXSD(`<user id="1"><name>a</name><name>b</name></user>`)
+	require.NoError(t, err)
+	out, err = ConvertFormats(formatXSD, formatGoStruct, xsd)
+	require.NoError(t, err)
+	require.Contains(t, out, "Name    []string `xml:\"name\"`")
+	require.Contains(t, out, "Id      int64    `xml:\"id,attr\"`")
+
+	_, err = XSDToGoStruct(`<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"/>`)
+	require.Error(t, err)
+	_, err = XSDToGoStruct(`<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:element name="a"><xs:complexType><xs:sequence><xs:element ref="b"/></xs:sequence></xs:complexType></xs:element></xs:schema>`)
+	require.EqualError(t, err, "element b is not declared")
+}
@@ -1,19 +1,17 @@
 package generate
 
 import (
-	"bytes"
+	"cmp"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
-	"net/http"
-	"runtime"
 	"sort"
 	"strings"
 	"sync"
 	"time"
 
-	"github.com/PuerkitoBio/goquery"
-	"github.com/go-resty/resty/v2"
+	"github.com/linzeyan/transform-go/pkg/storage"
 )
 
 // UserAgentInfo represents a generated user agent entry.
@@ -69,16 +67,60 @@ var (
 	latestDataMu    sync.RWMutex
 	latestData      *versionCache
 	fetchInProgress bool
-	networkAllowed  = runtime.GOOS != "js" && runtime.GOARCH != "wasm"
 )
 
 const cacheTTL = 6 * time.Hour
 
-var (
-	restyClient   = resty.New().SetTimeout(20 * time.Second)
-	fetchDocument = fetchDocumentHTTP
+// userAgentCache, when set, keeps fetched tables as well, so they outlive a
+// restart and servers sharing the storage fetch them once between them.
+// Stored tables expire after a week, past which the built-in ones serve.
+var userAgentCache storage.Storage
+
+const (
+	userAgentCacheKey = "cache/user-agents"
+	userAgentCacheTTL = 7 * 24 * time.Hour
 )
 
+// SetUserAgentCache keeps fetched browser and platform tables in s.
+func SetUserAgentCache(s storage.Storage) {
+	latestDataMu.Lock()
+	defer latestDataMu.Unlock()
+	userAgentCache = s
+}
+
+// cachedVersions is a versionCache as stored in userAgentCache.
+type cachedVersions struct {
+	Browsers  map[string][]tableRow `json:"browsers"`
+	Platforms map[string][]tableRow `json:"platforms"`
+	FetchedAt time.Time             `json:"fetchedAt"`
+}
+
+// loadVersionCache returns the tables in s, or nil when there are none.
+func loadVersionCache(s storage.Storage) *versionCache {
+	if s == nil {
+		return nil
+	}
+	data, err := s.Get(userAgentCacheKey)
+	if err != nil {
+		return nil
+	}
+	var c cachedVersions
+	if json.Unmarshal(data, &c) != nil || len(c.Browsers) == 0 || len(c.Platforms) == 0 {
+		return nil
+	}
+	return &versionCache{browsers: c.Browsers, platforms: c.Platforms, fetchedAt: c.FetchedAt}
+}
+
+func saveVersionCache(s storage.Storage, cache *versionCache) {
+	if s == nil {
+		return
+	}
+	data, err := json.Marshal(cachedVersions{Browsers: cache.browsers, Platforms: cache.platforms, FetchedAt: cache.fetchedAt})
+	if err == nil {
+		s.Put(userAgentCacheKey, data, userAgentCacheTTL)
+	}
+}
+
 type versionCache struct {
 	browsers  map[string][]tableRow
 	platforms map[string][]tableRow
@@ -94,10 +136,6 @@ type platformDetail struct {
 	Token        string
 }
 
-func init() {
-	latestData = fallbackVersionCache()
-}
-
 // GenerateUserAgents fetches the latest browser + platform data and builds
 // example user-agent strings. browser/os filters may be empty to list all.
 func GenerateUserAgents(browser, os string) ([]UserAgentInfo, error) {
@@ -123,10 +161,15 @@ func GenerateUserAgents(browser, os string) ([]UserAgentInfo, error) {
 }
 
 func ensureLatestData(ctx context.Context) (*versionCache, error) {
-	latestDataMu.RLock()
+	latestDataMu.Lock()
+	if latestData == nil {
+		// The built-in tables are copied on first use rather than at
+		// startup.
+		latestData = cmp.Or(loadVersionCache(userAgentCache), fallbackVersionCache())
+	}
 	data := latestData
 	expired := time.Since(data.fetchedAt) >= cacheTTL
-	latestDataMu.RUnlock()
+	latestDataMu.Unlock()
 
 	if expired && networkAllowed {
 		triggerBackgroundFetch()
@@ -144,6 +187,7 @@ func triggerBackgroundFetch() {
 		return
 	}
 	fetchInProgress = true
+	cacheStorage := userAgentCache
 	latestDataMu.Unlock()
 
 	go func() {
@@ -152,9 +196,14 @@ func triggerBackgroundFetch() {
 			fetchInProgress = false
 			latestDataMu.Unlock()
 		}()
-		cache, err := fetchLatestData(context.Background())
-		if err != nil {
-			return
+		// Another server may have fetched the tables since.
+		cache := loadVersionCache(cacheStorage)
+		if cache == nil || time.Since(cache.fetchedAt) >= cacheTTL {
+			var err error
+			if cache, err = fetchLatestData(context.Background()); err != nil {
+				return
+			}
+			saveVersionCache(cacheStorage, cache)
 		}
 		latestDataMu.Lock()
 		latestData = cache
@@ -162,143 +211,6 @@ func triggerBackgroundFetch() {
 	}()
 }
 
-func fetchLatestData(ctx context.Context) (*versionCache, error) {
-	cache := &versionCache{
-		browsers:  make(map[string][]tableRow, len(browserSources)),
-		platforms: make(map[string][]tableRow, len(platformSources)),
-		fetchedAt: time.Now(),
-	}
-
-	for slug, url := range browserSources {
-		doc, rows, err := fetchDocumentRows(ctx, url)
-		if err != nil {
-			return nil, err
-		}
-		if len(rows) == 0 {
-			if slug == "vivaldi" {
-				rows = parseVivaldiDoc(doc)
-			}
-			if len(rows) == 0 {
-				return nil, fmt.Errorf("no table data for %s", slug)
-			}
-		}
-		cache.browsers[slug] = rows
-	}
-
-	for slug, url := range platformSources {
-		_, rows, err := fetchDocumentRows(ctx, url)
-		if err != nil {
-			return nil, err
-		}
-		if len(rows) == 0 {
-			return nil, fmt.Errorf("no table data for %s", slug)
-		}
-		cache.platforms[slug] = rows
-	}
-
-	return cache, nil
-}
-
-func fetchDocumentRows(ctx context.Context, url string) (*goquery.Document, []tableRow, error) {
-	doc, err := fetchDocument(ctx, url)
-	if err != nil {
-		return nil, nil, err
-	}
-	rows := extractLatestTable(doc)
-	return doc, rows, nil
-}
-
-func fetchDocumentHTTP(ctx context.Context, url string) (*goquery.Document, error) {
-	resp, err := restyClient.R().
-		SetContext(ctx).
-		SetHeader("User-Agent", "Mozilla/5.0 (compatible; transform-go/1.0; +https://github.com/linzeyan/transform-go)").
-		Get(url)
-	if err != nil {
-		return nil, err
-	}
-	if resp.StatusCode() != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode())
-	}
-	return goquery.NewDocumentFromReader(bytes.NewReader(resp.Body()))
-}
-
-func extractLatestTable(doc *goquery.Document) []tableRow {
-	var rows []tableRow
-	doc.Find("table").EachWithBreak(func(_ int, table *goquery.Selection) bool {
-		headerCells := table.Find("thead th")
-		if headerCells.Length() == 0 {
-			headerCells = table.Find("tr").First().Find("th")
-		}
-		if headerCells.Length() == 0 {
-			headerCells = table.Find("tr").First().Find("td")
-		}
-		headers := make([]string, headerCells.Length())
-		headerCells.Each(func(i int, sel *goquery.Selection) {
-			headers[i] = strings.TrimSpace(sel.Text())
-		})
-		if len(headers) == 0 {
-			return true
-		}
-		table.Find("tbody tr").Each(func(_ int, rowSel *goquery.Selection) {
-			values := make(tableRow)
-			rowSel.Find("td").Each(func(i int, cell *goquery.Selection) {
-				if i < len(headers) {
-					values[headers[i]] = strings.TrimSpace(cell.Text())
-				}
-			})
-			if len(values) > 0 {
-				rows = append(rows, values)
-			}
-		})
-		if len(rows) == 0 {
-			table.Find("tr").Each(func(idx int, rowSel *goquery.Selection) {
-				if idx == 0 {
-					return
-				}
-				values := make(tableRow)
-				rowSel.Find("td").Each(func(i int, cell *goquery.Selection) {
-					if i < len(headers) {
-						values[headers[i]] = strings.TrimSpace(cell.Text())
-					}
-				})
-				if len(values) > 0 {
-					rows = append(rows, values)
-				}
-			})
-		}
-		return len(rows) == 0
-	})
-	return rows
-}
-
-func parseVivaldiDoc(doc *goquery.Document) []tableRow {
-	var version, date string
-	doc.Find("h2").EachWithBreak(func(_ int, sel *goquery.Selection) bool {
-		text := strings.TrimSpace(sel.Text())
-		if strings.HasPrefix(text, "The latest version of Vivaldi is") {
-			parts := strings.Split(text, ":")
-			if len(parts) == 2 {
-				version = strings.TrimSpace(parts[1])
-			}
-			return false
-		}
-		return true
-	})
-	if version == "" {
-		return nil
-	}
-	doc.Find("p").EachWithBreak(func(_ int, sel *goquery.Selection) bool {
-		text := strings.TrimSpace(sel.Text())
-		if strings.HasPrefix(text, "It was released") {
-			date = strings.TrimSpace(strings.TrimPrefix(text, "It was released"))
-			date = strings.Trim(date, ". ")
-			return false
-		}
-		return true
-	})
-	return []tableRow{{"Platform": "Vivaldi", "Version": version, "Release Date": date}}
-}
-
 func normalizeBrowser(input string) string {
 	slug := strings.ToLower(strings.TrimSpace(input))
 	slug = strings.ReplaceAll(slug, " ", "-")
@@ -650,84 +562,74 @@ func firstNonEmpty(row tableRow, keys ...string) string {
 	return ""
 }
 
+// fallbackVersionCache returns fresh copies of the built-in tables, which
+// are only built when the fetched data is unavailable.
 func fallbackVersionCache() *versionCache {
 	return &versionCache{
-		browsers:  cloneTableData(defaultBrowserData),
-		platforms: cloneTableData(defaultPlatformData),
+		browsers:  defaultBrowserData(),
+		platforms: defaultPlatformData(),
 		fetchedAt: time.Unix(0, 0),
 	}
 }
 
-func cloneTableData(src map[string][]tableRow) map[string][]tableRow {
-	dst := make(map[string][]tableRow, len(src))
-	for key, rows := range src {
-		copied := make([]tableRow, len(rows))
-		for i, row := range rows {
-			clone := make(tableRow, len(row))
-			for k, v := range row {
-				clone[k] = v
-			}
-			copied[i] = clone
-		}
-		dst[key] = copied
+func defaultBrowserData() map[string][]tableRow {
+	return map[string][]tableRow{
+		"chrome": {
+			{"Platform": "Chrome on Windows", "Version": "142.0.7444.136", "Release Date": "2025-11-11"},
+			{"Platform": "Chrome on macOS", "Version": "142.0.7444.134", "Release Date": "2025-11-11"},
+			{"Platform": "Chrome on Linux", "Version": "142.0.7444.162", "Release Date": "2025-11-11"},
+			{"Platform": "Chrome on Android", "Version": "142.0.7444.139", "Release Date": "2025-11-11"},
+			{"Platform": "Chrome on iOS", "Version": "142.0.7444.148", "Release Date": "2025-11-11"},
+		},
+		"firefox": {
+			{"Release Edition": "Firefox Standard Release", "Platform": "Desktop", "Version": "145.0", "Release Date": "2025-11-11"},
+			{"Release Edition": "Firefox Extended Support Release", "Platform": "Desktop", "Version": "140.5.0", "Release Date": "2025-11-11"},
+			{"Release Edition": "Firefox iOS", "Platform": "Mobile", "Version": "145.0", "Release Date": "2025-11-11"},
+			{"Release Edition": "Firefox Android", "Platform": "Mobile", "Version": "145.0", "Release Date": "2025-11-11"},
+		},
+		"opera": {
+			{"Platform": "Opera on Desktop", "Version": "123.0.5669.18", "Release Date": "2025-11-06"},
+			{"Platform": "Opera on Android", "Version": "76.2.4027.73374", "Release Date": "2023-10-08"},
+		},
+		"safari": {
+			{"Platform": "Safari on macOS (Laptops and Desktops)", "Version": "26.0", "Release Date": "2025-09-15"},
+			{"Platform": "Safari on iOS (iPhone, iPad and iPod)", "Version": "26.0", "Release Date": "2025-09-15"},
+		},
+		"edge": {
+			{"Platform": "Edge on Windows", "Version": "142.0.3595.80", "Release Date": "2025-11-13"},
+			{"Platform": "Edge on macOS", "Version": "142.0.3595.80", "Release Date": "2025-11-13"},
+			{"Platform": "Edge on Linux", "Version": "142.0.3595.80", "Release Date": "2025-11-13"},
+			{"Platform": "Edge on iOS", "Version": "142.3595.66", "Release Date": "2025-11-11"},
+			{"Platform": "Edge on Android", "Version": "142.0.3595.66", "Release Date": "2025-11-13"},
+		},
+		"vivaldi": {
+			{"Platform": "Vivaldi", "Version": "7.6.3797.63", "Release Date": "2025-10-09"},
+		},
+		"yandex": {
+			{"Platform": "Yandex Browser on Windows", "Version": "25.10.0.2516", "Release Date": "2025-11-11"},
+			{"Platform": "Yandex Browser on macOS", "Version": "25.10.0.2516", "Release Date": "2025-11-11"},
+			{"Platform": "Yandex Browser on iOS", "Version": "25.10.5.774", "Release Date": "2025-11-13"},
+			{"Platform": "Yandex Browser on Android", "Version": "25.10.3.136", "Release Date": "2025-11-13"},
+		},
+	}
+}
+
+func defaultPlatformData() map[string][]tableRow {
+	return map[string][]tableRow{
+		"windows": {
+			{"Platform": "Windows 11", "Version Number": "25H2", "Build": "26200.7171", "Release Date": "2025-11-11"},
+		},
+		"macos": {
+			{"Platform": "macOS", "Version Number": "15.7.2", "Release Date": "2025-11-04"},
+		},
+		"ios": {
+			{"Platform": "iOS on iPhone, iPad & iPod", "Version": "18.7.2", "Release Date": "2025-11-06"},
+		},
+		"android": {
+			{"Platform": "Android (Standard)", "Version Number": "16.0", "Release Date": "2025-06-10"},
+		},
+		"chrome-os": {
+			{"Platform": "ChromeOS on Chromebooks", "Platform Version": "16181.61.0", "Version": "134.0.6998.198", "Release Date": "2025-04-11"},
+		},
 	}
-	return dst
-}
-
-var defaultBrowserData = map[string][]tableRow{
-	"chrome": {
-		{"Platform": "Chrome on Windows", "Version": "142.0.7444.136", "Release Date": "2025-11-11"},
-		{"Platform": "Chrome on macOS", "Version": "142.0.7444.134", "Release Date": "2025-11-11"},
-		{"Platform": "Chrome on Linux", "Version": "142.0.7444.162", "Release Date": "2025-11-11"},
-		{"Platform": "Chrome on Android", "Version": "142.0.7444.139", "Release Date": "2025-11-11"},
-		{"Platform": "Chrome on iOS", "Version": "142.0.7444.148", "Release Date": "2025-11-11"},
-	},
-	"firefox": {
-		{"Release Edition": "Firefox Standard Release", "Platform": "Desktop", "Version": "145.0", "Release Date": "2025-11-11"},
-		{"Release Edition": "Firefox Extended Support Release", "Platform": "Desktop", "Version": "140.5.0", "Release Date": "2025-11-11"},
-		{"Release Edition": "Firefox iOS", "Platform": "Mobile", "Version": "145.0", "Release Date": "2025-11-11"},
-		{"Release Edition": "Firefox Android", "Platform": "Mobile", "Version": "145.0", "Release Date": "2025-11-11"},
-	},
-	"opera": {
-		{"Platform": "Opera on Desktop", "Version": "123.0.5669.18", "Release Date": "2025-11-06"},
-		{"Platform": "Opera on Android", "Version": "76.2.4027.73374", "Release Date": "2023-10-08"},
-	},
-	"safari": {
-		{"Platform": "Safari on macOS (Laptops and Desktops)", "Version": "26.0", "Release Date": "2025-09-15"},
-		{"Platform": "Safari on iOS (iPhone, iPad and iPod)", "Version": "26.0", "Release Date": "2025-09-15"},
-	},
-	"edge": {
-		{"Platform": "Edge on Windows", "Version": "142.0.3595.80", "Release Date": "2025-11-13"},
-		{"Platform": "Edge on macOS", "Version": "142.0.3595.80", "Release Date": "2025-11-13"},
-		{"Platform": "Edge on Linux", "Version": "142.0.3595.80", "Release Date": "2025-11-13"},
-		{"Platform": "Edge on iOS", "Version": "142.3595.66", "Release Date": "2025-11-11"},
-		{"Platform": "Edge on Android", "Version": "142.0.3595.66", "Release Date": "2025-11-13"},
-	},
-	"vivaldi": {
-		{"Platform": "Vivaldi", "Version": "7.6.3797.63", "Release Date": "2025-10-09"},
-	},
-	"yandex": {
-		{"Platform": "Yandex Browser on Windows", "Version": "25.10.0.2516", "Release Date": "2025-11-11"},
-		{"Platform": "Yandex Browser on macOS", "Version": "25.10.0.2516", "Release Date": "2025-11-11"},
-		{"Platform": "Yandex Browser on iOS", "Version": "25.10.5.774", "Release Date": "2025-11-13"},
-		{"Platform": "Yandex Browser on Android", "Version": "25.10.3.136", "Release Date": "2025-11-13"},
-	},
-}
-
-var defaultPlatformData = map[string][]tableRow{
-	"windows": {
-		{"Platform": "Windows 11", "Version Number": "25H2", "Build": "26200.7171", "Release Date": "2025-11-11"},
-	},
-	"macos": {
-		{"Platform": "macOS", "Version Number": "15.7.2", "Release Date": "2025-11-04"},
-	},
-	"ios": {
-		{"Platform": "iOS on iPhone, iPad & iPod", "Version": "18.7.2", "Release Date": "2025-11-06"},
-	},
-	"android": {
-		{"Platform": "Android (Standard)", "Version Number": "16.0", "Release Date": "2025-06-10"},
-	},
-	"chrome-os": {
-		{"Platform": "ChromeOS on Chromebooks", "Platform Version": "16181.61.0", "Version": "134.0.6998.198", "Release Date": "2025-04-11"},
-	},
 }
@@ -0,0 +1,161 @@
+//go:build !wasm
+
+package generate
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/go-resty/resty/v2"
+)
+
+// networkAllowed is false in the wasm build, which has no HTTP client and
+// only uses the built-in tables; see useragent_wasm.go.
+const networkAllowed = true
+
+var (
+	restyClient   = resty.New().SetTimeout(20 * time.Second)
+	fetchDocument = fetchDocumentHTTP
+)
+
+func fetchLatestData(ctx context.Context) (*versionCache, error) {
+	cache := &versionCache{
+		browsers:  make(map[string][]tableRow, len(browserSources)),
+		platforms: make(map[string][]tableRow, len(platformSources)),
+		fetchedAt: time.Now(),
+	}
+
+	for slug, url := range browserSources {
+		doc, rows, err := fetchDocumentRows(ctx, url)
+		if err != nil {
+			return nil, err
+		}
+		if len(rows) == 0 {
+			if slug == "vivaldi" {
+				rows = parseVivaldiDoc(doc)
+			}
+			if len(rows) == 0 {
+				return nil, fmt.Errorf("no table data for %s", slug)
+			}
+		}
+		cache.browsers[slug] = rows
+	}
+
+	for slug, url := range platformSources {
+		_, rows, err := fetchDocumentRows(ctx, url)
+		if err != nil {
+			return nil, err
+		}
+		if len(rows) == 0 {
+			return nil, fmt.Errorf("no table data for %s", slug)
+		}
+		cache.platforms[slug] = rows
+	}
+
+	return cache, nil
+}
+
+func fetchDocumentRows(ctx context.Context, url string) (*goquery.Document, []tableRow, error) {
+	doc, err := fetchDocument(ctx, url)
+	if err != nil {
+		return nil, nil, err
+	}
+	rows := extractLatestTable(doc)
+	return doc, rows, nil
+}
+
+func fetchDocumentHTTP(ctx context.Context, url string) (*goquery.Document, error) {
+	resp, err := restyClient.R().
+		SetContext(ctx).
+		SetHeader("User-Agent", "Mozilla/5.0 (compatible; transform-go/1.0; +https://github.com/linzeyan/transform-go)").
+		Get(url)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode())
+	}
+	return goquery.NewDocumentFromReader(bytes.NewReader(resp.Body()))
+}
+
+func extractLatestTable(doc *goquery.Document) []tableRow {
+	var rows []tableRow
+	doc.Find("table").EachWithBreak(func(_ int, table *goquery.Selection) bool {
+		headerCells := table.Find("thead th")
+		if headerCells.Length() == 0 {
+			headerCells = table.Find("tr").First().Find("th")
+		}
+		if headerCells.Length() == 0 {
+			headerCells = table.Find("tr").First().Find("td")
+		}
+		headers := make([]string, headerCells.Length())
+		headerCells.Each(func(i int, sel *goquery.Selection) {
+			headers[i] = strings.TrimSpace(sel.Text())
+		})
+		if len(headers) == 0 {
+			return true
+		}
+		table.Find("tbody tr").Each(func(_ int, rowSel *goquery.Selection) {
+			values := make(tableRow)
+			rowSel.Find("td").Each(func(i int, cell *goquery.Selection) {
+				if i < len(headers) {
+					values[headers[i]] = strings.TrimSpace(cell.Text())
+				}
+			})
+			if len(values) > 0 {
+				rows = append(rows, values)
+			}
+		})
+		if len(rows) == 0 {
+			table.Find("tr").Each(func(idx int, rowSel *goquery.Selection) {
+				if idx == 0 {
+					return
+				}
+				values := make(tableRow)
+				rowSel.Find("td").Each(func(i int, cell *goquery.Selection) {
+					if i < len(headers) {
+						values[headers[i]] = strings.TrimSpace(cell.Text())
+					}
+				})
+				if len(values) > 0 {
+					rows = append(rows, values)
+				}
+			})
+		}
+		return len(rows) == 0
+	})
+	return rows
+}
+
+func parseVivaldiDoc(doc *goquery.Document) []tableRow {
+	var version, date string
+	doc.Find("h2").EachWithBreak(func(_ int, sel *goquery.Selection) bool {
+		text := strings.TrimSpace(sel.Text())
+		if strings.HasPrefix(text, "The latest version of Vivaldi is") {
+			parts := strings.Split(text, ":")
+			if len(parts) == 2 {
+				version = strings.TrimSpace(parts[1])
+			}
+			return false
+		}
+		return true
+	})
+	if version == "" {
+		return nil
+	}
+	doc.Find("p").EachWithBreak(func(_ int, sel *goquery.Selection) bool {
+		text := strings.TrimSpace(sel.Text())
+		if strings.HasPrefix(text, "It was released") {
+			date = strings.TrimSpace(strings.TrimPrefix(text, "It was released"))
+			date = strings.Trim(date, ". ")
+			return false
+		}
+		return true
+	})
+	return []tableRow{{"Platform": "Vivaldi", "Version": version, "Release Date": date}}
+}
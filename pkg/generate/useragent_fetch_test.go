@@ -1,3 +1,5 @@
+//go:build !wasm
+
 package generate
 
 import (
@@ -7,6 +9,7 @@ import (
 	"time"
 
 	"github.com/PuerkitoBio/goquery"
+	"github.com/linzeyan/transform-go/pkg/storage"
 	"github.com/stretchr/testify/require"
 )
 
@@ -66,3 +69,52 @@ func TestGenerateUserAgents(t *testing.T) {
 		require.Contains(t, entry.UserAgent, "Chrome/123.0.0.1")
 	}
 }
+
+func TestUserAgentCache(t *testing.T) {
+	origFetch := fetchDocument
+	defer func() {
+		fetchDocument = origFetch
+		SetUserAgentCache(nil)
+		latestDataMu.Lock()
+		latestData = fallbackVersionCache()
+		fetchInProgress = false
+		latestDataMu.Unlock()
+	}()
+	fetched := make(chan string, 100)
+	fetchDocument = func(_ context.Context, url string) (*goquery.Document, error) {
+		fetched <- url
+		return goquery.NewDocumentFromReader(strings.NewReader(""))
+	}
+
+	// Fresh tables another server stored are used as they are.
+	s := storage.NewMemory()
+	stored := fallbackVersionCache()
+	stored.fetchedAt = time.Now()
+	stored.browsers["chrome"] = []tableRow{{"Platform": "Chrome on Windows", "Version": "999.0.0.1"}}
+	saveVersionCache(s, stored)
+	SetUserAgentCache(s)
+	latestDataMu.Lock()
+	latestData = nil
+	latestDataMu.Unlock()
+
+	list, err := GenerateUserAgents("chrome", "windows")
+	require.NoError(t, err)
+	require.Contains(t, list[0].UserAgent, "Chrome/999.0.0.1")
+	require.Empty(t, fetched)
+
+	// Stale tables are fetched again and not replaced when the fetch fails.
+	stored.fetchedAt = time.Now().Add(-cacheTTL)
+	saveVersionCache(s, stored)
+	latestDataMu.Lock()
+	latestData = nil
+	latestDataMu.Unlock()
+	_, err = GenerateUserAgents("chrome", "windows")
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return len(fetched) > 0 }, time.Second, time.Millisecond)
+	require.Eventually(t, func() bool {
+		latestDataMu.Lock()
+		defer latestDataMu.Unlock()
+		return !fetchInProgress
+	}, time.Second, time.Millisecond)
+	require.Equal(t, stored.fetchedAt.Unix(), loadVersionCache(s).fetchedAt.Unix())
+}
@@ -0,0 +1,16 @@
+//go:build wasm
+
+package generate
+
+import (
+	"context"
+	"errors"
+)
+
+// The wasm build leaves out the HTTP client and HTML parser behind the
+// fetch, and generates user agents from the built-in tables only.
+const networkAllowed = false
+
+func fetchLatestData(context.Context) (*versionCache, error) {
+	return nil, errors.New("fetching user agent data is not supported in this build")
+}
@@ -5,7 +5,9 @@ import (
 	"crypto/rand"
 	"crypto/sha1"
 	"encoding/binary"
+	"encoding/hex"
 	"fmt"
+	"hash"
 	"strings"
 	"sync"
 	"time"
@@ -15,6 +17,7 @@ type uuid [16]byte
 
 var (
 	uuidMutex    sync.Mutex
+	uuidSeed     sync.Once
 	uuidNodeID   [6]byte
 	uuidClockSeq uint16
 	uuidLastTime uint64
@@ -29,7 +32,10 @@ var uuidNamespaces = map[string]uuid{
 	"X500": {0x6b, 0xa7, 0xb8, 0x14, 0x9d, 0xad, 0x11, 0xd1, 0x80, 0xb4, 0x00, 0xc0, 0x4f, 0xd4, 0x30, 0xc8},
 }
 
-func init() {
+// seedUUIDState picks a random node ID and clock sequence on first use
+// rather than at startup, where it would call into the system's random
+// source for programs that never make a version 1 UUID.
+func seedUUIDState() {
 	if _, err := rand.Read(uuidNodeID[:]); err != nil {
 		panic(err)
 	}
@@ -150,28 +156,59 @@ func uuidV8() (string, error) {
 }
 
 func uuidNameBased(version int) (string, error) {
-	ns := uuidNamespaces["DNS"]
 	name := make([]byte, 32)
 	if _, err := rand.Read(name); err != nil {
 		return "", err
 	}
-	var sum []byte
+	return nameBasedUUID(uuidNamespaces["DNS"], name, version).String(), nil
+}
+
+// GenerateNameBasedUUID derives a version 3 (MD5) or 5 (SHA-1) UUID from a
+// namespace and name, so the same pair always yields the same UUID.
+// namespace is one of DNS, URL, OID and X500 or any UUID.
+func GenerateNameBasedUUID(namespace, name string, version int) (string, error) {
+	if version != 3 && version != 5 {
+		return "", fmt.Errorf("name-based UUIDs are version 3 or 5, not %d", version)
+	}
+	ns, err := parseUUIDNamespace(namespace)
+	if err != nil {
+		return "", err
+	}
+	return nameBasedUUID(ns, []byte(name), version).String(), nil
+}
+
+func nameBasedUUID(ns uuid, name []byte, version int) uuid {
+	var h hash.Hash
 	if version == 3 {
-		h := md5.New()
-		h.Write(ns[:])
-		h.Write(name)
-		sum = h.Sum(nil)
+		h = md5.New()
 	} else {
-		h := sha1.New()
-		h.Write(ns[:])
-		h.Write(name)
-		sum = h.Sum(nil)
+		h = sha1.New()
 	}
+	h.Write(ns[:])
+	h.Write(name)
 	var u uuid
-	copy(u[:], sum[:16])
+	copy(u[:], h.Sum(nil)[:16])
 	u[6] = (u[6] & 0x0f) | byte(version<<4)
 	setVariant(&u)
-	return u.String(), nil
+	return u
+}
+
+// parseUUIDNamespace accepts a well-known namespace name or a UUID in the
+// usual spellings ({...}, urn:uuid:..., with or without hyphens).
+func parseUUIDNamespace(namespace string) (uuid, error) {
+	clean := strings.TrimSpace(namespace)
+	if ns, ok := uuidNamespaces[strings.ToUpper(clean)]; ok {
+		return ns, nil
+	}
+	hexDigits := strings.ReplaceAll(strings.Trim(strings.TrimPrefix(strings.ToLower(clean), "urn:uuid:"), "{}"), "-", "")
+	var u uuid
+	if len(hexDigits) != 32 {
+		return u, fmt.Errorf("invalid namespace %q: use DNS, URL, OID, X500 or a UUID", namespace)
+	}
+	if _, err := hex.Decode(u[:], []byte(hexDigits)); err != nil {
+		return u, fmt.Errorf("invalid namespace %q: use DNS, URL, OID, X500 or a UUID", namespace)
+	}
+	return u, nil
 }
 
 func generateGUID() (string, error) {
@@ -220,6 +257,7 @@ func encodeULID(data []byte) string {
 }
 
 func nextUUIDState() (uint64, uint16) {
+	uuidSeed.Do(seedUUIDState)
 	uuidMutex.Lock()
 	defer uuidMutex.Unlock()
 	ts := uint64(time.Now().UnixNano()/100) + uuidEpoch
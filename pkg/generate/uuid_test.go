@@ -52,3 +52,24 @@ func TestGenerateUUIDs(t *testing.T) {
 		}
 	}
 }
+
+func TestGenerateNameBasedUUID(t *testing.T) {
+	v5, err := GenerateNameBasedUUID("DNS", "python.org", 5)
+	require.NoError(t, err)
+	require.Equal(t, "886313e1-3b8a-5372-9b90-0c9aee199e5d", v5)
+
+	v3, err := GenerateNameBasedUUID("dns", "python.org", 3)
+	require.NoError(t, err)
+	require.Equal(t, "6fa459ea-ee8a-3ca4-894e-db77e160355e", v3)
+
+	custom, err := GenerateNameBasedUUID("{6BA7B811-9DAD-11D1-80B4-00C04FD430C8}", "https://example.com/", 5)
+	require.NoError(t, err)
+	viaName, err := GenerateNameBasedUUID("URL", "https://example.com/", 5)
+	require.NoError(t, err)
+	require.Equal(t, viaName, custom)
+
+	_, err = GenerateNameBasedUUID("DNS", "x", 4)
+	require.Error(t, err)
+	_, err = GenerateNameBasedUUID("not-a-namespace", "x", 5)
+	require.Error(t, err)
+}
@@ -0,0 +1,308 @@
+package qrcode
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"  // decodes GIF screenshots
+	_ "image/jpeg" // decodes JPEG screenshots
+	_ "image/png"  // decodes PNG screenshots
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+)
+
+// maxPixels bounds the images DecodeImage reads, so that a small file
+// claiming huge dimensions cannot exhaust memory.
+const maxPixels = 40 << 20
+
+// ErrNotFound is returned for an image without a QR code in it.
+var ErrNotFound = errors.New("no QR code found")
+
+// DecodeImage reads the QR code in a PNG, JPEG or GIF file. Other 2D codes
+// and 1D barcodes are not supported.
+func DecodeImage(data []byte) (string, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return "", fmt.Errorf("unreadable image: %w", err)
+	}
+	if cfg.Width*cfg.Height > maxPixels {
+		return "", fmt.Errorf("image of %dx%d pixels is too large", cfg.Width, cfg.Height)
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return "", fmt.Errorf("unreadable image: %w", err)
+	}
+	return Decode(img)
+}
+
+// Decode reads the QR code in img. When there are several, which one is
+// read is unspecified.
+func Decode(img image.Image) (string, error) {
+	b := binarize(img)
+	var firstErr error
+	for range 2 {
+		for _, s := range b.symbols() {
+			text, err := decodeSymbol(s)
+			if err == nil {
+				return text, nil
+			}
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+		// Light on dark codes, as in dark mode screenshots.
+		b.invert()
+	}
+	if firstErr == nil {
+		return "", ErrNotFound
+	}
+	return "", firstErr
+}
+
+// sampler reads the module in column x of row y of a symbol as dark or
+// light.
+type sampler func(x, y int) bool
+
+// decodeSymbol reads the symbol of version that sample sees.
+func decodeSymbol(s symbol) (string, error) {
+	version, sample := s.version, s.sample
+	size := sizeOf(version)
+	level, mask, err := readFormat(sample, size)
+	if err != nil {
+		return "", err
+	}
+	if version >= 7 {
+		if v, ok := readVersion(sample, size); ok && v != version {
+			return "", fmt.Errorf("version information says %d, not %d", v, version)
+		}
+	}
+
+	g := newGrid(version)
+	raw := make([]byte, rawModules(version)/8)
+	i := 0
+	g.eachDataModule(func(x, y int) {
+		if i < len(raw)*8 && sample(x, y) != masked(mask, x, y) {
+			raw[i/8] |= 0x80 >> (i % 8)
+		}
+		i++
+	})
+
+	layout := layoutFor(version, level)
+	var data []byte
+	for b, block := range layout.deinterleave(raw) {
+		if _, err := rsCorrect(block, layout.ecc); err != nil {
+			return "", fmt.Errorf("block %d: %w", b+1, err)
+		}
+		data = append(data, block[:layout.dataLen(b)]...)
+	}
+	return parseSegments(data, version)
+}
+
+// readFormat reads both copies of the format information and takes the
+// valid value nearest to either, which survives three wrong bits.
+func readFormat(sample sampler, size int) (Level, int, error) {
+	var copies [2]int
+	for i, p := range formatPositions(size) {
+		if sample(p[0], p[1]) {
+			copies[0] |= 1 << i
+		}
+		if sample(p[2], p[3]) {
+			copies[1] |= 1 << i
+		}
+	}
+	best, bestLevel, bestMask := 16, L, 0
+	for level := L; level <= H; level++ {
+		for mask := range 8 {
+			info := formatInfo(level, mask)
+			for _, c := range copies {
+				if d := bitCount(c ^ info); d < best {
+					best, bestLevel, bestMask = d, level, mask
+				}
+			}
+		}
+	}
+	if best > 3 {
+		return 0, 0, errors.New("unreadable format information")
+	}
+	return bestLevel, bestMask, nil
+}
+
+// readVersion reads the version information of versions 7 and up.
+func readVersion(sample sampler, size int) (int, bool) {
+	var copies [2]int
+	for i, p := range versionPositions(size) {
+		if sample(p[0], p[1]) {
+			copies[0] |= 1 << i
+		}
+		if sample(p[1], p[0]) {
+			copies[1] |= 1 << i
+		}
+	}
+	best, version := 19, 0
+	for v := 7; v <= maxVersion; v++ {
+		for _, c := range copies {
+			if d := bitCount(c ^ versionInfo(v)); d < best {
+				best, version = d, v
+			}
+		}
+	}
+	return version, best <= 3
+}
+
+func bitCount(v int) int {
+	n := 0
+	for ; v != 0; v &= v - 1 {
+		n++
+	}
+	return n
+}
+
+const alphanumeric = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ $%*+-./:"
+
+// parseSegments reads the segments in the data codewords as text. Byte
+// segments are UTF-8 unless an ECI designator says otherwise or they are
+// not valid UTF-8, in which case they are ISO 8859-1, the standard's
+// default; kanji segments are Shift JIS.
+func parseSegments(data []byte, version int) (string, error) {
+	r := bitReader{data: data}
+	var out strings.Builder
+	eci := -1
+	for r.left() >= 4 {
+		mode := r.read(4)
+		switch mode {
+		case 0:
+			return out.String(), r.err
+		case modeECI:
+			eci = r.read(8)
+			switch {
+			case eci&0x80 == 0:
+			case eci&0xc0 == 0x80:
+				eci = eci&0x3f<<8 | r.read(8)
+			default:
+				eci = eci&0x1f<<16 | r.read(16)
+			}
+			continue
+		case modeStructured:
+			// Which part of a multi-symbol message this is; the text of the
+			// part still reads on its own.
+			r.read(16)
+			continue
+		case modeFNC1First:
+			continue
+		case modeFNC1Second:
+			r.read(8)
+			continue
+		case modeNumeric, modeAlphanumeric, modeByte, modeKanji:
+		default:
+			return "", fmt.Errorf("unknown segment mode %d", mode)
+		}
+		count := r.read(charCountBits(mode, version))
+		switch mode {
+		case modeNumeric:
+			for ; count >= 3; count -= 3 {
+				fmt.Fprintf(&out, "%03d", r.read(10))
+			}
+			if count == 2 {
+				fmt.Fprintf(&out, "%02d", r.read(7))
+			} else if count == 1 {
+				fmt.Fprintf(&out, "%d", r.read(4))
+			}
+		case modeAlphanumeric:
+			for ; count >= 2; count -= 2 {
+				v := r.read(11)
+				if v >= 45*45 {
+					return "", errors.New("invalid alphanumeric segment")
+				}
+				out.WriteByte(alphanumeric[v/45])
+				out.WriteByte(alphanumeric[v%45])
+			}
+			if count == 1 {
+				v := r.read(6)
+				if v >= 45 {
+					return "", errors.New("invalid alphanumeric segment")
+				}
+				out.WriteByte(alphanumeric[v])
+			}
+		case modeByte:
+			seg := make([]byte, count)
+			for i := range seg {
+				seg[i] = byte(r.read(8))
+			}
+			out.WriteString(byteSegmentText(seg, eci))
+		case modeKanji:
+			seg := make([]byte, 0, 2*count)
+			for range count {
+				v := r.read(13)
+				c := v/0xc0<<8 | v%0xc0
+				if c < 0x1f00 {
+					c += 0x8140
+				} else {
+					c += 0xc140
+				}
+				seg = append(seg, byte(c>>8), byte(c))
+			}
+			text, err := japanese.ShiftJIS.NewDecoder().Bytes(seg)
+			if err != nil {
+				return "", fmt.Errorf("invalid kanji segment: %w", err)
+			}
+			out.Write(text)
+		}
+		if r.err != nil {
+			return "", r.err
+		}
+	}
+	return out.String(), r.err
+}
+
+// byteSegmentText decodes a byte segment in the character set its ECI
+// designator names.
+func byteSegmentText(seg []byte, eci int) string {
+	switch eci {
+	case 20:
+		if text, err := japanese.ShiftJIS.NewDecoder().Bytes(seg); err == nil {
+			return string(text)
+		}
+	case 1, 3:
+		return latin1(seg)
+	case -1:
+		if !utf8.Valid(seg) {
+			return latin1(seg)
+		}
+	}
+	return string(seg)
+}
+
+func latin1(seg []byte) string {
+	text, _ := charmap.ISO8859_1.NewDecoder().Bytes(seg)
+	return string(text)
+}
+
+// bitReader reads bits most significant first and remembers reading past
+// the end.
+type bitReader struct {
+	data []byte
+	pos  int
+	err  error
+}
+
+func (r *bitReader) left() int {
+	return len(r.data)*8 - r.pos
+}
+
+func (r *bitReader) read(n int) int {
+	if n > r.left() {
+		r.pos = len(r.data) * 8
+		r.err = errors.New("data ends inside a segment")
+		return 0
+	}
+	v := 0
+	for range n {
+		v = v<<1 | int(r.data[r.pos/8]>>(7-r.pos%8)&1)
+		r.pos++
+	}
+	return v
+}
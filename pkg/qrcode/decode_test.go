@@ -0,0 +1,51 @@
+package qrcode
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func segmentBits(fields ...int) []byte {
+	var w bitWriter
+	for i := 0; i < len(fields); i += 2 {
+		w.write(fields[i], fields[i+1])
+	}
+	return w.bytes
+}
+
+func TestParseSegments(t *testing.T) {
+	text, err := parseSegments(annexData, 1)
+	require.NoError(t, err)
+	require.Equal(t, "01234567", text)
+
+	// "AC-42" in alphanumeric mode, then "é" as Latin-1 under ECI 3.
+	data := segmentBits(
+		modeAlphanumeric, 4, 5, 9, 10*45+12, 11, 41*45+4, 11, 2, 6,
+		modeECI, 4, 3, 8, modeByte, 4, 1, 8, 0xe9, 8,
+		0, 4,
+	)
+	text, err = parseSegments(data, 1)
+	require.NoError(t, err)
+	require.Equal(t, "AC-42é", text)
+
+	// "点茗" in kanji mode (Shift JIS 0x935F and 0xE4AA).
+	data = segmentBits(modeKanji, 4, 2, 8, 0x0d9f, 13, 0x1aaa, 13, 0, 4)
+	text, err = parseSegments(data, 1)
+	require.NoError(t, err)
+	require.Equal(t, "点茗", text)
+
+	// Byte segments that are not UTF-8 read as Latin-1.
+	data = segmentBits(modeByte, 4, 2, 8, 'h', 8, 0xe9, 8)
+	text, err = parseSegments(data, 1)
+	require.NoError(t, err)
+	require.Equal(t, "hé", text)
+}
+
+func TestParseSegments_Invalid(t *testing.T) {
+	_, err := parseSegments(segmentBits(6, 4, 0, 4), 1)
+	require.ErrorContains(t, err, "unknown segment mode 6")
+
+	_, err = parseSegments(segmentBits(modeByte, 4, 9, 8, 'a', 8), 1)
+	require.ErrorContains(t, err, "data ends inside a segment")
+}
@@ -0,0 +1,306 @@
+package qrcode
+
+import (
+	"image"
+	"math"
+	"sort"
+)
+
+// bitmap is an image thresholded to dark and light pixels.
+type bitmap struct {
+	w, h int
+	dark []bool
+}
+
+// binarize thresholds img at the luminance that best splits its histogram
+// in two (Otsu's method), which suits the flat colors of screenshots.
+func binarize(img image.Image) *bitmap {
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	lum := make([]uint8, w*h)
+	var hist [256]int
+	for y := range h {
+		for x := range w {
+			r, g, b, a := img.At(bounds.Min.X+x, bounds.Min.Y+y).RGBA()
+			// Colors are premultiplied; transparent pixels show the page
+			// behind them, taken as white.
+			l := (299*r+587*g+114*b)/1000 + 0xffff - a
+			lum[y*w+x] = uint8(l >> 8)
+			hist[lum[y*w+x]]++
+		}
+	}
+	total := w * h
+	var sum float64
+	for i, n := range hist {
+		sum += float64(i * n)
+	}
+	var sumLow float64
+	low, threshold, best := 0, 127, -1.0
+	for t := range 255 {
+		low += hist[t]
+		if low == 0 {
+			continue
+		}
+		high := total - low
+		if high == 0 {
+			break
+		}
+		sumLow += float64(t * hist[t])
+		meanLow, meanHigh := sumLow/float64(low), (sum-sumLow)/float64(high)
+		if between := float64(low) * float64(high) * (meanLow - meanHigh) * (meanLow - meanHigh); between > best {
+			best, threshold = between, t
+		}
+	}
+	b := &bitmap{w: w, h: h, dark: make([]bool, w*h)}
+	for i, l := range lum {
+		b.dark[i] = int(l) <= threshold
+	}
+	return b
+}
+
+func (b *bitmap) at(x, y int) bool {
+	return x >= 0 && y >= 0 && x < b.w && y < b.h && b.dark[y*b.w+x]
+}
+
+func (b *bitmap) invert() {
+	for i := range b.dark {
+		b.dark[i] = !b.dark[i]
+	}
+}
+
+// finder is a candidate finder pattern: its center, its module size and
+// how many scan lines crossed it.
+type finder struct {
+	x, y, module float64
+	hits         int
+}
+
+// symbol is a way to read the image as a QR code: a version and where its
+// modules are.
+type symbol struct {
+	version int
+	sample  sampler
+}
+
+// symbols returns the readings of the code in the image worth trying, the
+// likeliest first.
+func (b *bitmap) symbols() []symbol {
+	tl, tr, bl, ok := pickFinders(b.finders())
+	if !ok {
+		return nil
+	}
+	module := (tl.module + tr.module + bl.module) / 3
+	span := (math.Hypot(tr.x-tl.x, tr.y-tl.y) + math.Hypot(bl.x-tl.x, bl.y-tl.y)) / 2
+	estimate := int(math.Round((span/module + 7 - 17) / 4))
+	var out []symbol
+	for _, d := range []int{0, -1, 1, -2, 2} {
+		v := estimate + d
+		if v < minVersion || v > maxVersion {
+			continue
+		}
+		// A mirrored code has its top right and bottom left finders
+		// swapped.
+		out = append(out, symbol{v, b.grid(tl, tr, bl, v)}, symbol{v, b.grid(tl, bl, tr, v)})
+	}
+	return out
+}
+
+// grid maps the modules of a version v symbol onto the image from the
+// centers of its finders, which sit 3.5 modules in from the corners.
+func (b *bitmap) grid(tl, tr, bl finder, v int) sampler {
+	span := float64(sizeOf(v) - 7)
+	ux, uy := (tr.x-tl.x)/span, (tr.y-tl.y)/span
+	vx, vy := (bl.x-tl.x)/span, (bl.y-tl.y)/span
+	return func(x, y int) bool {
+		u, v := float64(x)-3, float64(y)-3
+		px := tl.x + u*ux + v*vx
+		py := tl.y + u*uy + v*vy
+		return b.at(int(math.Floor(px)), int(math.Floor(py)))
+	}
+}
+
+// finders scans every row for the 1:1:3:1:1 dark and light runs across a
+// finder pattern and confirms each hit along the column through it.
+func (b *bitmap) finders() []finder {
+	var found []finder
+	for y := range b.h {
+		var runs [5]int
+		state := 0
+		check := func(end int) {
+			if !finderRatio(runs) {
+				return
+			}
+			cx := float64(end-runs[4]-runs[3]) - float64(runs[2])/2
+			f, ok := b.confirm(cx, float64(y)+0.5, runs)
+			if !ok {
+				return
+			}
+			for i := range found {
+				g := &found[i]
+				if math.Abs(g.x-f.x) <= g.module && math.Abs(g.y-f.y) <= g.module && math.Abs(g.module-f.module) <= math.Max(1, g.module/2) {
+					n := float64(g.hits)
+					g.x = (g.x*n + f.x) / (n + 1)
+					g.y = (g.y*n + f.y) / (n + 1)
+					g.module = (g.module*n + f.module) / (n + 1)
+					g.hits++
+					return
+				}
+			}
+			found = append(found, f)
+		}
+		for x := range b.w {
+			dark := b.at(x, y)
+			switch {
+			case !dark && state == 0 && runs[0] == 0:
+				// Light before the first dark run.
+			case dark && state%2 == 1, !dark && state%2 == 0 && state < 4:
+				state++
+				runs[state]++
+			case !dark && state == 4:
+				check(x)
+				runs = [5]int{runs[2], runs[3], runs[4], 1, 0}
+				state = 3
+			default:
+				runs[state]++
+			}
+		}
+		if state == 4 {
+			check(b.w)
+		}
+	}
+	return found
+}
+
+// finderRatio reports whether runs, dark, light, dark, light and dark, are
+// in the 1:1:3:1:1 proportions of a finder, give or take half a module.
+func finderRatio(runs [5]int) bool {
+	total := 0
+	for _, n := range runs {
+		if n == 0 {
+			return false
+		}
+		total += n
+	}
+	if total < 7 {
+		return false
+	}
+	m := float64(total) / 7
+	tol := math.Max(m/2, 1)
+	return math.Abs(float64(runs[0])-m) <= tol && math.Abs(float64(runs[1])-m) <= tol &&
+		math.Abs(float64(runs[2])-3*m) <= 3*tol &&
+		math.Abs(float64(runs[3])-m) <= tol && math.Abs(float64(runs[4])-m) <= tol
+}
+
+// confirm checks for the finder ratios down the column through a row hit,
+// then across the row through the column's center, and returns the
+// refined center.
+func (b *bitmap) confirm(cx, cy float64, rowRuns [5]int) (finder, bool) {
+	rowTotal := 0
+	for _, n := range rowRuns {
+		rowTotal += n
+	}
+	col, ok := b.runsThrough(int(cx), int(cy), 0, 1, rowTotal)
+	if !ok {
+		return finder{}, false
+	}
+	y := col.center
+	row, ok := b.runsThrough(int(cx), int(y), 1, 0, rowTotal)
+	if !ok {
+		return finder{}, false
+	}
+	return finder{x: row.center, y: y, module: float64(row.total+col.total) / 14, hits: 1}, true
+}
+
+type crossing struct {
+	center float64
+	total  int
+}
+
+// runsThrough measures the five runs of a finder along direction (dx, dy)
+// through the dark pixel at (x, y), inside the center stone.
+func (b *bitmap) runsThrough(x, y, dx, dy, expected int) (crossing, bool) {
+	if !b.at(x, y) {
+		return crossing{}, false
+	}
+	limit := 2 * expected
+	var runs [5]int
+	// Back from the center: the stone, the light ring, the dark ring.
+	i := 0
+	for s := 2; s >= 0; s-- {
+		for b.at(x-(i+1)*dx, y-(i+1)*dy) == (s%2 == 0) && runs[s] < limit {
+			runs[s]++
+			i++
+		}
+	}
+	// Forward: the rest of the stone, the light ring, the dark ring.
+	j := 0
+	for s := 2; s <= 4; s++ {
+		for b.at(x+j*dx, y+j*dy) == (s%2 == 0) && runs[s] < limit {
+			runs[s]++
+			j++
+		}
+	}
+	if !finderRatio(runs) {
+		return crossing{}, false
+	}
+	total := runs[0] + runs[1] + runs[2] + runs[3] + runs[4]
+	if 5*abs(total-expected) >= 2*expected {
+		return crossing{}, false
+	}
+	pos := x
+	if dy != 0 {
+		pos = y
+	}
+	end := pos + j
+	return crossing{center: float64(end-runs[4]-runs[3]) - float64(runs[2])/2, total: total}, true
+}
+
+// pickFinders picks the three candidates that best form the corners of a
+// square, the one at the right angle being the top left, and orders the
+// other two so that the code reads clockwise from top left to top right.
+func pickFinders(candidates []finder) (tl, tr, bl finder, ok bool) {
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].hits > candidates[j].hits })
+	if len(candidates) > 12 {
+		candidates = candidates[:12]
+	}
+	best := math.Inf(1)
+	for i := range candidates {
+		for j := i + 1; j < len(candidates); j++ {
+			for k := j + 1; k < len(candidates); k++ {
+				a, b, c := candidates[i], candidates[j], candidates[k]
+				// The corner is opposite the longest side.
+				ab, ac, bc := dist(a, b), dist(a, c), dist(b, c)
+				switch {
+				case ab >= ac && ab >= bc:
+					a, c = c, a
+				case ac >= ab && ac >= bc:
+					a, b = b, a
+				}
+				side1, side2, hyp := dist(a, b), dist(a, c), dist(b, c)
+				module := (a.module + b.module + c.module) / 3
+				if math.Min(side1, side2) < 8*module {
+					continue
+				}
+				score := math.Abs(side1-side2)/math.Max(side1, side2) +
+					math.Abs(hyp-math.Hypot(side1, side2))/hyp +
+					(math.Max(a.module, math.Max(b.module, c.module))-math.Min(a.module, math.Min(b.module, c.module)))/module
+				if score < best {
+					best, tl, tr, bl = score, a, b, c
+				}
+			}
+		}
+	}
+	if best > 0.5 {
+		return finder{}, finder{}, finder{}, false
+	}
+	// In image coordinates, y pointing down, top right to bottom left
+	// turns clockwise around the top left corner.
+	if (tr.x-tl.x)*(bl.y-tl.y)-(tr.y-tl.y)*(bl.x-tl.x) < 0 {
+		tr, bl = bl, tr
+	}
+	return tl, tr, bl, true
+}
+
+func dist(a, b finder) float64 {
+	return math.Hypot(a.x-b.x, a.y-b.y)
+}
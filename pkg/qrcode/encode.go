@@ -0,0 +1,230 @@
+package qrcode
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+)
+
+// quietZone is the light border, in modules, that readers need around a
+// code.
+const quietZone = 4
+
+// Code is an encoded QR code.
+type Code struct {
+	Version int
+	Level   Level
+	// Size is the width and height in modules, without the quiet zone.
+	Size    int
+	modules []bool
+}
+
+// Black reports whether the module in column x of row y is dark; modules
+// outside the code are light.
+func (c *Code) Black(x, y int) bool {
+	if x < 0 || y < 0 || x >= c.Size || y >= c.Size {
+		return false
+	}
+	return c.modules[y*c.Size+x]
+}
+
+// Image draws the code with scale pixels per module and the quiet zone
+// around it.
+func (c *Code) Image(scale int) *image.Gray {
+	scale = max(scale, 1)
+	side := (c.Size + 2*quietZone) * scale
+	img := image.NewGray(image.Rect(0, 0, side, side))
+	for py := range side {
+		for px := range side {
+			v := color.Gray{Y: 0xff}
+			if c.Black(px/scale-quietZone, py/scale-quietZone) {
+				v.Y = 0
+			}
+			img.SetGray(px, py, v)
+		}
+	}
+	return img
+}
+
+// Encode writes text, as UTF-8 bytes, in the smallest code that holds it
+// at level.
+func Encode(text string, level Level) (*Code, error) {
+	if level < L || level > H {
+		return nil, fmt.Errorf("unknown error correction level %d", level)
+	}
+	data := []byte(text)
+	version := 0
+	for v := minVersion; v <= maxVersion; v++ {
+		if 4+charCountBits(modeByte, v)+8*len(data) <= dataCodewords(v, level)*8 {
+			version = v
+			break
+		}
+	}
+	if version == 0 {
+		return nil, fmt.Errorf("text of %d bytes is too long for a QR code at level %s", len(data), level)
+	}
+
+	var bits bitWriter
+	bits.write(modeByte, 4)
+	bits.write(len(data), charCountBits(modeByte, version))
+	for _, b := range data {
+		bits.write(int(b), 8)
+	}
+	capacity := dataCodewords(version, level) * 8
+	bits.write(0, min(4, capacity-bits.n))
+	bits.write(0, (8-bits.n%8)%8)
+	for pad := 0xec; bits.n < capacity; pad ^= 0xec ^ 0x11 {
+		bits.write(pad, 8)
+	}
+
+	layout := layoutFor(version, level)
+	blocks := make([][]byte, layout.blocks)
+	k := 0
+	for b := range blocks {
+		n := layout.dataLen(b)
+		blocks[b] = append(bits.bytes[k:k+n:k+n], rsEncode(bits.bytes[k:k+n], layout.ecc)...)
+		k += n
+	}
+	codewords := layout.interleave(blocks)
+
+	g := newGrid(version)
+	i := 0
+	g.eachDataModule(func(x, y int) {
+		if i < len(codewords)*8 {
+			g.dark[y*g.size+x] = codewords[i/8]>>(7-i%8)&1 == 1
+		}
+		i++
+	})
+
+	best, bestPenalty := 0, -1
+	for mask := range 8 {
+		g.applyMask(mask)
+		g.drawFormat(level, mask)
+		if p := g.penalty(); bestPenalty < 0 || p < bestPenalty {
+			best, bestPenalty = mask, p
+		}
+		g.applyMask(mask)
+	}
+	g.applyMask(best)
+	g.drawFormat(level, best)
+	if version >= 7 {
+		info := versionInfo(version)
+		for i, p := range versionPositions(g.size) {
+			dark := info>>i&1 == 1
+			g.dark[p[1]*g.size+p[0]] = dark
+			g.dark[p[0]*g.size+p[1]] = dark
+		}
+	}
+	return &Code{Version: version, Level: level, Size: g.size, modules: g.dark}, nil
+}
+
+// applyMask inverts the data modules the mask selects; applying it twice
+// undoes it.
+func (g *grid) applyMask(mask int) {
+	for y := range g.size {
+		for x := range g.size {
+			if !g.function[y*g.size+x] && masked(mask, x, y) {
+				g.dark[y*g.size+x] = !g.dark[y*g.size+x]
+			}
+		}
+	}
+}
+
+func (g *grid) drawFormat(level Level, mask int) {
+	info := formatInfo(level, mask)
+	for i, p := range formatPositions(g.size) {
+		dark := info>>i&1 == 1
+		g.dark[p[1]*g.size+p[0]] = dark
+		g.dark[p[3]*g.size+p[2]] = dark
+	}
+}
+
+// penalty scores how hard the symbol is to read, as the mask selection of
+// ISO/IEC 18004 section 7.8.3 does: long runs, 2x2 blocks, finder-like
+// patterns and an imbalance of dark and light each cost points.
+func (g *grid) penalty() int {
+	at := func(x, y int, transpose bool) bool {
+		if transpose {
+			x, y = y, x
+		}
+		return g.dark[y*g.size+x]
+	}
+	score := 0
+	for _, transpose := range []bool{false, true} {
+		for y := range g.size {
+			run := 0
+			for x := range g.size {
+				if x > 0 && at(x, y, transpose) == at(x-1, y, transpose) {
+					run++
+				} else {
+					run = 1
+				}
+				if run == 5 {
+					score += 3
+				} else if run > 5 {
+					score++
+				}
+				// 1:1:3:1:1 with four light modules on either side.
+				if x >= 6 && at(x, y, transpose) && at(x-6, y, transpose) &&
+					!at(x-5, y, transpose) && at(x-4, y, transpose) && at(x-3, y, transpose) &&
+					at(x-2, y, transpose) && !at(x-1, y, transpose) &&
+					(g.lightRun(x+1, y, 4, transpose) || g.lightRun(x-10, y, 4, transpose)) {
+					score += 40
+				}
+			}
+		}
+	}
+	dark := 0
+	for y := range g.size {
+		for x := range g.size {
+			if g.dark[y*g.size+x] {
+				dark++
+			}
+			if x > 0 && y > 0 {
+				c := g.dark[y*g.size+x]
+				if c == g.dark[y*g.size+x-1] && c == g.dark[(y-1)*g.size+x] && c == g.dark[(y-1)*g.size+x-1] {
+					score += 3
+				}
+			}
+		}
+	}
+	total := g.size * g.size
+	k := (abs(dark*20-total*10)+total-1)/total - 1
+	return score + max(k, 0)*10
+}
+
+// lightRun reports whether n modules from column x of row y are light,
+// counting modules outside the symbol as light.
+func (g *grid) lightRun(x, y, n int, transpose bool) bool {
+	for i := x; i < x+n; i++ {
+		if i < 0 || i >= g.size {
+			continue
+		}
+		cx, cy := i, y
+		if transpose {
+			cx, cy = y, i
+		}
+		if g.dark[cy*g.size+cx] {
+			return false
+		}
+	}
+	return true
+}
+
+// bitWriter appends bits most significant first.
+type bitWriter struct {
+	bytes []byte
+	n     int
+}
+
+func (w *bitWriter) write(v, bits int) {
+	for i := bits - 1; i >= 0; i-- {
+		if w.n%8 == 0 {
+			w.bytes = append(w.bytes, 0)
+		}
+		if v>>i&1 == 1 {
+			w.bytes[w.n/8] |= 0x80 >> (w.n % 8)
+		}
+		w.n++
+	}
+}
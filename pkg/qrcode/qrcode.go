@@ -0,0 +1,363 @@
+// Package qrcode writes text as QR codes and reads QR codes back out of
+// images such as screenshots. The reader expects what screens show: a code
+// that is upright or turned by quarter turns, scaled evenly and surrounded
+// by its light quiet zone. Photos taken at an angle are out of scope.
+package qrcode
+
+import "fmt"
+
+// Level is the error correction level: the share of codewords that can be
+// damaged and still read back.
+type Level int
+
+const (
+	L Level = iota // 7%
+	M              // 15%
+	Q              // 25%
+	H              // 30%
+)
+
+func (l Level) String() string {
+	if l < L || l > H {
+		return fmt.Sprintf("Level(%d)", int(l))
+	}
+	return "LMQH"[l : l+1]
+}
+
+// formatBits are the level's two bits in the format information.
+func (l Level) formatBits() int {
+	return [...]int{1, 0, 3, 2}[l]
+}
+
+// eccPerBlock and eccBlocks give, per level and version, the error
+// correction codewords in each block and the number of blocks (ISO/IEC
+// 18004 table 9); index 0 is unused.
+var (
+	eccPerBlock = [4][41]int{
+		{0, 7, 10, 15, 20, 26, 18, 20, 24, 30, 18, 20, 24, 26, 30, 22, 24, 28, 30, 28, 28, 28, 28, 30, 30, 26, 28, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+		{0, 10, 16, 26, 18, 24, 16, 18, 22, 22, 26, 30, 22, 22, 24, 24, 28, 28, 26, 26, 26, 26, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28},
+		{0, 13, 22, 18, 26, 18, 24, 18, 22, 20, 24, 28, 26, 24, 20, 30, 24, 28, 28, 26, 30, 28, 30, 30, 30, 30, 28, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+		{0, 17, 28, 22, 16, 22, 28, 26, 26, 24, 28, 24, 28, 22, 24, 24, 30, 28, 28, 26, 28, 30, 24, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+	}
+	eccBlocks = [4][41]int{
+		{0, 1, 1, 1, 1, 1, 2, 2, 2, 2, 4, 4, 4, 4, 4, 6, 6, 6, 6, 7, 8, 8, 9, 9, 10, 12, 12, 12, 13, 14, 15, 16, 17, 18, 19, 19, 20, 21, 22, 24, 25},
+		{0, 1, 1, 1, 2, 2, 4, 4, 4, 5, 5, 5, 8, 9, 9, 10, 10, 11, 13, 14, 16, 17, 17, 18, 20, 21, 23, 25, 26, 28, 29, 31, 33, 35, 37, 38, 40, 43, 45, 47, 49},
+		{0, 1, 1, 2, 2, 4, 4, 6, 6, 8, 8, 8, 10, 12, 16, 12, 17, 16, 18, 21, 20, 23, 23, 25, 27, 29, 34, 34, 35, 38, 40, 43, 45, 48, 51, 53, 56, 59, 62, 65, 68},
+		{0, 1, 1, 2, 4, 4, 4, 5, 6, 8, 8, 11, 11, 16, 16, 18, 16, 19, 21, 25, 25, 25, 34, 30, 32, 35, 37, 40, 42, 45, 48, 51, 54, 57, 60, 63, 66, 70, 74, 77, 81},
+	}
+)
+
+const (
+	minVersion = 1
+	maxVersion = 40
+)
+
+func sizeOf(version int) int {
+	return 17 + 4*version
+}
+
+// rawModules counts the modules left for codewords once the function
+// patterns are placed, remainder bits included.
+func rawModules(version int) int {
+	n := (16*version+128)*version + 64
+	if version >= 2 {
+		align := version/7 + 2
+		n -= (25*align-10)*align - 55
+		if version >= 7 {
+			n -= 36
+		}
+	}
+	return n
+}
+
+func dataCodewords(version int, level Level) int {
+	return rawModules(version)/8 - eccPerBlock[level][version]*eccBlocks[level][version]
+}
+
+// alignmentPositions returns the row and column centers of the alignment
+// patterns.
+func alignmentPositions(version int) []int {
+	if version == 1 {
+		return nil
+	}
+	count := version/7 + 2
+	step := (version*8 + count*3 + 5) / (count*4 - 4) * 2
+	pos := make([]int, count)
+	pos[0] = 6
+	for i, p := count-1, sizeOf(version)-7; i >= 1; i, p = i-1, p-step {
+		pos[i] = p
+	}
+	return pos
+}
+
+// formatInfo is the 15-bit BCH coded, masked format information.
+func formatInfo(level Level, mask int) int {
+	data := level.formatBits()<<3 | mask
+	rem := data
+	for range 10 {
+		rem = rem<<1 ^ (rem>>9)*0x537
+	}
+	return (data<<10 | rem) ^ 0x5412
+}
+
+// versionInfo is the 18-bit BCH coded version information of versions 7
+// and up.
+func versionInfo(version int) int {
+	rem := version
+	for range 12 {
+		rem = rem<<1 ^ (rem>>11)*0x1f25
+	}
+	return version<<12 | rem
+}
+
+// masked reports whether data mask pattern mask inverts the module in
+// column x of row y.
+func masked(mask, x, y int) bool {
+	switch mask {
+	case 0:
+		return (x+y)%2 == 0
+	case 1:
+		return y%2 == 0
+	case 2:
+		return x%3 == 0
+	case 3:
+		return (x+y)%3 == 0
+	case 4:
+		return (x/3+y/2)%2 == 0
+	case 5:
+		return x*y%2+x*y%3 == 0
+	case 6:
+		return (x*y%2+x*y%3)%2 == 0
+	default:
+		return ((x+y)%2+x*y%3)%2 == 0
+	}
+}
+
+// grid is a symbol's modules, dark or light, and which of them belong to
+// function patterns rather than data.
+type grid struct {
+	size     int
+	dark     []bool
+	function []bool
+}
+
+// newGrid lays out the function patterns of version. The format and
+// version areas are reserved but left light for the encoder to fill in.
+func newGrid(version int) *grid {
+	size := sizeOf(version)
+	g := &grid{size: size, dark: make([]bool, size*size), function: make([]bool, size*size)}
+	for i := range size {
+		g.setFunction(6, i, i%2 == 0)
+		g.setFunction(i, 6, i%2 == 0)
+	}
+	for _, c := range [][2]int{{3, 3}, {size - 4, 3}, {3, size - 4}} {
+		for dy := -4; dy <= 4; dy++ {
+			for dx := -4; dx <= 4; dx++ {
+				x, y := c[0]+dx, c[1]+dy
+				if x < 0 || x >= size || y < 0 || y >= size {
+					continue
+				}
+				d := max(abs(dx), abs(dy))
+				g.setFunction(x, y, d != 2 && d != 4)
+			}
+		}
+	}
+	pos := alignmentPositions(version)
+	last := len(pos) - 1
+	for i, cy := range pos {
+		for j, cx := range pos {
+			if i == 0 && j == 0 || i == 0 && j == last || i == last && j == 0 {
+				continue
+			}
+			for dy := -2; dy <= 2; dy++ {
+				for dx := -2; dx <= 2; dx++ {
+					g.setFunction(cx+dx, cy+dy, max(abs(dx), abs(dy)) != 1)
+				}
+			}
+		}
+	}
+	for _, p := range formatPositions(size) {
+		g.setFunction(p[0], p[1], false)
+		g.setFunction(p[2], p[3], false)
+	}
+	g.setFunction(8, size-8, true)
+	if version >= 7 {
+		for _, p := range versionPositions(size) {
+			g.setFunction(p[0], p[1], false)
+			g.setFunction(p[1], p[0], false)
+		}
+	}
+	return g
+}
+
+func (g *grid) setFunction(x, y int, dark bool) {
+	g.dark[y*g.size+x] = dark
+	g.function[y*g.size+x] = true
+}
+
+// formatPositions returns, for each bit of the format information from the
+// least significant, its module in both copies as {x1, y1, x2, y2}.
+func formatPositions(size int) [15][4]int {
+	var p [15][4]int
+	for i := range 15 {
+		switch {
+		case i < 6:
+			p[i][0], p[i][1] = 8, i
+		case i < 8:
+			p[i][0], p[i][1] = 8, i+1
+		case i == 8:
+			p[i][0], p[i][1] = 7, 8
+		default:
+			p[i][0], p[i][1] = 14-i, 8
+		}
+		if i < 8 {
+			p[i][2], p[i][3] = size-1-i, 8
+		} else {
+			p[i][2], p[i][3] = 8, size-15+i
+		}
+	}
+	return p
+}
+
+// versionPositions returns, for each bit of the version information from
+// the least significant, its module {x, y} in the copy above the bottom
+// left finder; the other copy is the transpose.
+func versionPositions(size int) [18][2]int {
+	var p [18][2]int
+	for i := range 18 {
+		p[i] = [2]int{i / 3, size - 11 + i%3}
+	}
+	return p
+}
+
+// eachDataModule calls fn for the modules that hold codewords in the order
+// they are filled: two columns at a time, zigzagging up and down from the
+// right, skipping the vertical timing pattern.
+func (g *grid) eachDataModule(fn func(x, y int)) {
+	for right := g.size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		upward := (right+1)&2 == 0
+		for vert := range g.size {
+			y := vert
+			if upward {
+				y = g.size - 1 - vert
+			}
+			for j := range 2 {
+				x := right - j
+				if !g.function[y*g.size+x] {
+					fn(x, y)
+				}
+			}
+		}
+	}
+}
+
+// blockLayout is how the codewords of a version and level split into
+// Reed-Solomon blocks: the first short blocks hold one data codeword less
+// than the rest.
+type blockLayout struct {
+	blocks, short, shortData, ecc int
+}
+
+func layoutFor(version int, level Level) blockLayout {
+	blocks := eccBlocks[level][version]
+	raw := rawModules(version) / 8
+	ecc := eccPerBlock[level][version]
+	return blockLayout{
+		blocks:    blocks,
+		short:     blocks - raw%blocks,
+		shortData: raw/blocks - ecc,
+		ecc:       ecc,
+	}
+}
+
+func (l blockLayout) dataLen(block int) int {
+	if block < l.short {
+		return l.shortData
+	}
+	return l.shortData + 1
+}
+
+// interleave writes the data codewords of every block in turn, then their
+// error correction codewords.
+func (l blockLayout) interleave(blocks [][]byte) []byte {
+	var out []byte
+	for i := range l.shortData + 1 {
+		for b, block := range blocks {
+			if i < l.dataLen(b) {
+				out = append(out, block[i])
+			}
+		}
+	}
+	for i := range l.ecc {
+		for b, block := range blocks {
+			out = append(out, block[l.dataLen(b)+i])
+		}
+	}
+	return out
+}
+
+// deinterleave undoes interleave.
+func (l blockLayout) deinterleave(raw []byte) [][]byte {
+	blocks := make([][]byte, l.blocks)
+	for b := range blocks {
+		blocks[b] = make([]byte, l.dataLen(b)+l.ecc)
+	}
+	k := 0
+	for i := range l.shortData + 1 {
+		for b := range blocks {
+			if i < l.dataLen(b) {
+				blocks[b][i] = raw[k]
+				k++
+			}
+		}
+	}
+	for i := range l.ecc {
+		for b := range blocks {
+			blocks[b][l.dataLen(b)+i] = raw[k]
+			k++
+		}
+	}
+	return blocks
+}
+
+// charCountBits is the width of a segment's length field.
+func charCountBits(mode, version int) int {
+	i := 0
+	if version >= 27 {
+		i = 2
+	} else if version >= 10 {
+		i = 1
+	}
+	switch mode {
+	case modeNumeric:
+		return [...]int{10, 12, 14}[i]
+	case modeAlphanumeric:
+		return [...]int{9, 11, 13}[i]
+	case modeByte:
+		return [...]int{8, 16, 16}[i]
+	default:
+		return [...]int{8, 10, 12}[i]
+	}
+}
+
+// Segment modes.
+const (
+	modeNumeric      = 1
+	modeAlphanumeric = 2
+	modeStructured   = 3
+	modeByte         = 4
+	modeFNC1First    = 5
+	modeECI          = 7
+	modeKanji        = 8
+	modeFNC1Second   = 9
+)
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,173 @@
+package qrcode
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAlignmentPositions(t *testing.T) {
+	require.Nil(t, alignmentPositions(1))
+	require.Equal(t, []int{6, 18}, alignmentPositions(2))
+	require.Equal(t, []int{6, 22, 38}, alignmentPositions(7))
+	require.Equal(t, []int{6, 34, 60, 86, 112, 138}, alignmentPositions(32))
+	require.Equal(t, []int{6, 24, 50, 76, 102, 128, 154}, alignmentPositions(36))
+	require.Equal(t, []int{6, 30, 58, 86, 114, 142, 170}, alignmentPositions(40))
+}
+
+func TestFormatAndVersionInfo(t *testing.T) {
+	require.Equal(t, 0x77c4, formatInfo(L, 0))
+	require.Equal(t, 0x5412, formatInfo(M, 0))
+	require.Equal(t, 0x2bed, formatInfo(Q, 7))
+	require.Equal(t, 0x07c94, versionInfo(7))
+	require.Equal(t, 0x28c69, versionInfo(40))
+}
+
+func TestDataCodewords(t *testing.T) {
+	require.Equal(t, 16, dataCodewords(1, M))
+	require.Equal(t, 2956, dataCodewords(40, L))
+	require.Equal(t, 1276, dataCodewords(40, H))
+}
+
+func TestEncodeDecode(t *testing.T) {
+	tests := []struct {
+		text  string
+		level Level
+		scale int
+	}{
+		{"hello", M, 4},
+		{"https://example.com/path?q=1", L, 3},
+		{`{"name":"Ricky","tags":["admin","dev"]}`, Q, 5},
+		{"こんにちは世界", H, 2},
+		{strings.Repeat("0123456789", 16), M, 3},
+		{strings.Repeat("transform ", 60), L, 2},
+		{strings.Repeat("x", 1200), H, 1},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s/%d", tt.level, len(tt.text)), func(t *testing.T) {
+			code, err := Encode(tt.text, tt.level)
+			require.NoError(t, err)
+			text, err := Decode(code.Image(tt.scale))
+			require.NoError(t, err)
+			require.Equal(t, tt.text, text)
+		})
+	}
+}
+
+func TestEncode_Versions(t *testing.T) {
+	code, err := Encode("hello", M)
+	require.NoError(t, err)
+	require.Equal(t, 1, code.Version)
+	require.Equal(t, 21, code.Size)
+
+	code, err = Encode(strings.Repeat("a", 200), L)
+	require.NoError(t, err)
+	require.Equal(t, 9, code.Version)
+
+	_, err = Encode(strings.Repeat("a", 3000), L)
+	require.ErrorContains(t, err, "too long")
+}
+
+func TestDecode_Orientation(t *testing.T) {
+	code, err := Encode("turned around", Q)
+	require.NoError(t, err)
+	img := code.Image(3)
+	for turns := range 4 {
+		text, err := Decode(img)
+		require.NoError(t, err, "%d quarter turns", turns)
+		require.Equal(t, "turned around", text)
+		img = rotate(img)
+	}
+
+	text, err := Decode(mirror(code.Image(3)))
+	require.NoError(t, err)
+	require.Equal(t, "turned around", text)
+}
+
+func TestDecode_Inverted(t *testing.T) {
+	code, err := Encode("dark mode", M)
+	require.NoError(t, err)
+	img := code.Image(4)
+	for i, v := range img.Pix {
+		img.Pix[i] = 0xff - v
+	}
+	text, err := Decode(img)
+	require.NoError(t, err)
+	require.Equal(t, "dark mode", text)
+}
+
+func TestDecode_Damaged(t *testing.T) {
+	code, err := Encode("still readable", H)
+	require.NoError(t, err)
+	img := code.Image(4)
+	// Blot out a few data modules in the bottom right corner.
+	for y := 18; y < 22; y++ {
+		for x := 18; x < 22; x++ {
+			for d := range 16 {
+				img.SetGray((x+quietZone)*4+d%4, (y+quietZone)*4+d/4, color.Gray{Y: 0})
+			}
+		}
+	}
+	text, err := Decode(img)
+	require.NoError(t, err)
+	require.Equal(t, "still readable", text)
+}
+
+func TestDecodeImage(t *testing.T) {
+	code, err := Encode("from a screenshot", M)
+	require.NoError(t, err)
+	// Colors on an offset canvas, as a screenshot crop would have.
+	img := image.NewRGBA(image.Rect(10, 20, 200, 210))
+	src := code.Image(5)
+	for y := range 190 {
+		for x := range 190 {
+			c := color.RGBA{R: 0xf0, G: 0xf4, B: 0xff, A: 0xff}
+			if x < src.Bounds().Dx() && y < src.Bounds().Dy() && src.GrayAt(x, y).Y == 0 {
+				c = color.RGBA{R: 0x20, G: 0x30, B: 0x60, A: 0xff}
+			}
+			img.Set(10+x, 20+y, c)
+		}
+	}
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	text, err := DecodeImage(buf.Bytes())
+	require.NoError(t, err)
+	require.Equal(t, "from a screenshot", text)
+
+	_, err = DecodeImage([]byte("not an image"))
+	require.ErrorContains(t, err, "unreadable image")
+
+	buf.Reset()
+	require.NoError(t, png.Encode(&buf, image.NewGray(image.Rect(0, 0, 50, 50))))
+	_, err = DecodeImage(buf.Bytes())
+	require.ErrorIs(t, err, ErrNotFound)
+}
+
+// rotate turns img a quarter turn clockwise.
+func rotate(img *image.Gray) *image.Gray {
+	b := img.Bounds()
+	out := image.NewGray(image.Rect(0, 0, b.Dy(), b.Dx()))
+	for y := range b.Dy() {
+		for x := range b.Dx() {
+			out.SetGray(b.Dy()-1-y, x, img.GrayAt(x, y))
+		}
+	}
+	return out
+}
+
+func mirror(img *image.Gray) *image.Gray {
+	b := img.Bounds()
+	out := image.NewGray(b)
+	for y := range b.Dy() {
+		for x := range b.Dx() {
+			out.SetGray(b.Dx()-1-x, y, img.GrayAt(x, y))
+		}
+	}
+	return out
+}
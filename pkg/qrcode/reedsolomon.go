@@ -0,0 +1,165 @@
+package qrcode
+
+import "errors"
+
+// errTooManyErrors is returned for a block with more damage than its error
+// correction codewords can repair.
+var errTooManyErrors = errors.New("too many errors to correct")
+
+// gfExp and gfLog are the powers and logarithms of GF(256) with the QR
+// code's primitive polynomial x^8+x^4+x^3+x^2+1; gfExp is doubled so that
+// products need no modulo.
+var gfExp, gfLog = func() (exp [512]byte, log [256]byte) {
+	x := 1
+	for i := range 255 {
+		exp[i] = byte(x)
+		log[x] = byte(i)
+		x <<= 1
+		if x&0x100 != 0 {
+			x ^= 0x11d
+		}
+	}
+	for i := 255; i < 512; i++ {
+		exp[i] = exp[i-255]
+	}
+	return exp, log
+}()
+
+func gfMul(a, b byte) byte {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return gfExp[int(gfLog[a])+int(gfLog[b])]
+}
+
+func gfDiv(a, b byte) byte {
+	if a == 0 {
+		return 0
+	}
+	return gfExp[int(gfLog[a])+255-int(gfLog[b])]
+}
+
+// gfPow returns alpha to the power n, for any integer n.
+func gfPow(n int) byte {
+	return gfExp[(n%255+255)%255]
+}
+
+// polyEval evaluates p, coefficients from the lowest degree, at x.
+func polyEval(p []byte, x byte) byte {
+	var y byte
+	for i := len(p) - 1; i >= 0; i-- {
+		y = gfMul(y, x) ^ p[i]
+	}
+	return y
+}
+
+// rsEncode returns the n error correction codewords of data: the remainder
+// of data*x^n divided by the generator (x-1)(x-a)...(x-a^(n-1)).
+func rsEncode(data []byte, n int) []byte {
+	gen := []byte{1}
+	for i := range n {
+		next := make([]byte, len(gen)+1)
+		for j, c := range gen {
+			next[j] ^= c
+			next[j+1] ^= gfMul(c, gfPow(i))
+		}
+		gen = next
+	}
+	rem := make([]byte, n)
+	for _, d := range data {
+		factor := d ^ rem[0]
+		copy(rem, rem[1:])
+		rem[n-1] = 0
+		for j := range n {
+			rem[j] ^= gfMul(gen[j+1], factor)
+		}
+	}
+	return rem
+}
+
+// rsCorrect repairs block, data followed by n error correction codewords,
+// in place and returns the number of codewords it changed.
+func rsCorrect(block []byte, n int) (int, error) {
+	// The codeword is the polynomial with block[0] as its highest degree
+	// coefficient; syndrome j is its value at a^j.
+	size := len(block)
+	synd := make([]byte, n)
+	clean := true
+	for j := range synd {
+		var s byte
+		x := gfPow(j)
+		for _, c := range block {
+			s = gfMul(s, x) ^ c
+		}
+		synd[j] = s
+		clean = clean && s == 0
+	}
+	if clean {
+		return 0, nil
+	}
+
+	// Berlekamp-Massey finds the error locator, whose roots are the
+	// inverses of the error positions.
+	locator, prev := []byte{1}, []byte{1}
+	errs, shift, last := 0, 1, byte(1)
+	for k := range n {
+		d := synd[k]
+		for i := 1; i <= errs && i < len(locator); i++ {
+			d ^= gfMul(locator[i], synd[k-i])
+		}
+		if d == 0 {
+			shift++
+			continue
+		}
+		scale := gfDiv(d, last)
+		next := make([]byte, max(len(locator), len(prev)+shift))
+		copy(next, locator)
+		for i, c := range prev {
+			next[i+shift] ^= gfMul(scale, c)
+		}
+		if 2*errs <= k {
+			prev, locator = locator, next
+			errs, last, shift = k+1-errs, d, 1
+		} else {
+			locator = next
+			shift++
+		}
+	}
+	if 2*errs > n {
+		return 0, errTooManyErrors
+	}
+
+	// The evaluator is syndromes times locator, modulo x^n.
+	omega := make([]byte, n)
+	for i := range n {
+		for j := 0; j <= i && j < len(locator); j++ {
+			omega[i] ^= gfMul(locator[j], synd[i-j])
+		}
+	}
+	var deriv []byte
+	for i := 1; i < len(locator); i += 2 {
+		for len(deriv) < i {
+			deriv = append(deriv, 0)
+		}
+		deriv[i-1] = locator[i]
+	}
+
+	// Chien search over the positions, then Forney for the magnitudes.
+	fixed := 0
+	for p := range size {
+		xinv := gfPow(-p)
+		if polyEval(locator, xinv) != 0 {
+			continue
+		}
+		den := polyEval(deriv, xinv)
+		if den == 0 {
+			return 0, errTooManyErrors
+		}
+		block[size-1-p] ^= gfMul(gfPow(p), gfDiv(polyEval(omega, xinv), den))
+		fixed++
+	}
+	if fixed != errs {
+		return 0, errTooManyErrors
+	}
+	return fixed, nil
+}
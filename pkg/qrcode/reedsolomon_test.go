@@ -0,0 +1,41 @@
+package qrcode
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// The version 1-M symbol for "01234567" of ISO/IEC 18004 annex I.
+var (
+	annexData = []byte{0x10, 0x20, 0x0c, 0x56, 0x61, 0x80, 0xec, 0x11, 0xec, 0x11, 0xec, 0x11, 0xec, 0x11, 0xec, 0x11}
+	annexECC  = []byte{0xa5, 0x24, 0xd4, 0xc1, 0xed, 0x36, 0xc7, 0x87, 0x2c, 0x55}
+)
+
+func TestRSEncode(t *testing.T) {
+	require.Equal(t, annexECC, rsEncode(annexData, len(annexECC)))
+}
+
+func TestRSCorrect(t *testing.T) {
+	block := append(append([]byte{}, annexData...), annexECC...)
+
+	n, err := rsCorrect(append([]byte{}, block...), len(annexECC))
+	require.NoError(t, err)
+	require.Zero(t, n)
+
+	damaged := append([]byte{}, block...)
+	for _, i := range []int{0, 5, 11, 17, 25} {
+		damaged[i] ^= byte(0x5a + i)
+	}
+	n, err = rsCorrect(damaged, len(annexECC))
+	require.NoError(t, err)
+	require.Equal(t, 5, n)
+	require.Equal(t, block, damaged)
+
+	damaged = append([]byte{}, block...)
+	for _, i := range []int{0, 3, 6, 9, 12, 15} {
+		damaged[i] ^= 0xff
+	}
+	_, err = rsCorrect(damaged, len(annexECC))
+	require.ErrorIs(t, err, errTooManyErrors)
+}
@@ -0,0 +1,263 @@
+// Package quota enforces per-key limits for a server hosted for many teams:
+// requests per UTC day, the largest request body and the endpoints a key
+// may call. Keys and their limits come from a YAML or JSON file; usage is
+// counted in memory and starts over each day and on restart. The package
+// is not part of the wasm build.
+package quota
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultName is the name usage is counted under for requests the default
+// limits admit: those without a key or with a key the file does not list.
+// They share one daily allowance.
+const DefaultName = "default"
+
+// Errors Admit returns, wrapped with the details.
+var (
+	ErrUnknownKey = errors.New("unknown API key")
+	ErrFeature    = errors.New("endpoint not allowed for this key")
+	ErrTooLarge   = errors.New("request too large")
+	ErrExhausted  = errors.New("daily request quota used up")
+)
+
+// AdminFeature, among a key's features, lets it see every tenant's usage
+// in Report. Unlike endpoints, a key listing no features does not have it.
+const AdminFeature = "admin"
+
+// Limits bound what one key may do. Zero values, or no features, mean no
+// limit.
+type Limits struct {
+	RequestsPerDay int      `yaml:"requestsPerDay" json:"requestsPerDay,omitempty"`
+	MaxInputBytes  int64    `yaml:"maxInputBytes" json:"maxInputBytes,omitempty"`
+	Features       []string `yaml:"features" json:"features,omitempty"`
+}
+
+// allows reports whether feature is among the allowed ones.
+func (l Limits) allows(feature string) bool {
+	return len(l.Features) == 0 || slices.Contains(l.Features, feature)
+}
+
+// Tenant is one key's entry in the file. The key may be given as is or,
+// to keep it out of the file, as the hex SHA-256 of the key.
+type Tenant struct {
+	Key       string `yaml:"key"`
+	KeySHA256 string `yaml:"keySHA256"`
+	Limits    `yaml:",inline"`
+}
+
+// Config is the quota file:
+//
+//	default:            # requests without a listed key; leave out to refuse them
+//	  requestsPerDay: 100
+//	  maxInputBytes: 65536
+//	  features: [convert, format]
+//	keys:
+//	  platform-team:
+//	    keySHA256: 9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08
+//	    requestsPerDay: 10000
+//	    maxInputBytes: 1048576
+type Config struct {
+	Default *Limits           `yaml:"default"`
+	Keys    map[string]Tenant `yaml:"keys"`
+}
+
+// Load reads the quota file at path.
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+// Parse reads a quota file. JSON is read as the YAML it also is.
+func Parse(data []byte) (*Config, error) {
+	var cfg Config
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&cfg); errors.Is(err, io.EOF) {
+		return nil, errors.New("quota file is empty")
+	} else if err != nil {
+		return nil, fmt.Errorf("quota file: %w", err)
+	}
+	return &cfg, nil
+}
+
+// Usage is what one tenant has used today, with its limits.
+type Usage struct {
+	Name     string         `json:"name"`
+	Day      string         `json:"day"`
+	Requests int            `json:"requests"`
+	Rejected int            `json:"rejected"`
+	Bytes    int64          `json:"bytes"`
+	Features map[string]int `json:"features"`
+	Limits
+}
+
+// Quotas admits requests against a Config and counts them. It is safe for
+// concurrent use.
+type Quotas struct {
+	def    *Limits
+	byHash map[[sha256.Size]byte]string
+	limits map[string]Limits
+	now    func() time.Time
+
+	mu    sync.Mutex
+	usage map[string]*Usage
+}
+
+// New checks cfg and returns quotas enforcing it.
+func New(cfg *Config) (*Quotas, error) {
+	q := &Quotas{
+		def:    cfg.Default,
+		byHash: map[[sha256.Size]byte]string{},
+		limits: map[string]Limits{},
+		now:    time.Now,
+		usage:  map[string]*Usage{},
+	}
+	for _, name := range slices.Sorted(maps.Keys(cfg.Keys)) {
+		t := cfg.Keys[name]
+		if name == DefaultName {
+			return nil, fmt.Errorf("quota file: %q is kept for requests without a listed key", name)
+		}
+		var sum [sha256.Size]byte
+		switch {
+		case t.Key != "" && t.KeySHA256 != "":
+			return nil, fmt.Errorf("quota file: %s has both key and keySHA256", name)
+		case t.Key != "":
+			sum = sha256.Sum256([]byte(t.Key))
+		case t.KeySHA256 != "":
+			b, err := hex.DecodeString(t.KeySHA256)
+			if err != nil || len(b) != sha256.Size {
+				return nil, fmt.Errorf("quota file: %s: keySHA256 must be 64 hex digits", name)
+			}
+			copy(sum[:], b)
+		default:
+			return nil, fmt.Errorf("quota file: %s has no key", name)
+		}
+		if other, ok := q.byHash[sum]; ok {
+			return nil, fmt.Errorf("quota file: %s and %s have the same key", other, name)
+		}
+		q.byHash[sum] = name
+		q.limits[name] = t.Limits
+	}
+	if q.def != nil {
+		q.limits[DefaultName] = *q.def
+	}
+	return q, nil
+}
+
+// Tenant returns the name and limits key is admitted under, or
+// ErrUnknownKey when the file neither lists it nor has default limits.
+func (q *Quotas) Tenant(key string) (string, Limits, error) {
+	if key != "" {
+		if name, ok := q.byHash[sha256.Sum256([]byte(key))]; ok {
+			return name, q.limits[name], nil
+		}
+	}
+	if q.def == nil {
+		if key == "" {
+			return "", Limits{}, fmt.Errorf("%w: send one in X-API-Key or as a bearer token", ErrUnknownKey)
+		}
+		return "", Limits{}, ErrUnknownKey
+	}
+	return DefaultName, *q.def, nil
+}
+
+// Admit counts a request to feature with a body of size bytes (-1 when not
+// known yet) against key's quota, or returns why it is refused. Refusals
+// count as rejected and do not use up the allowance.
+func (q *Quotas) Admit(key, feature string, size int64) (Limits, error) {
+	name, l, err := q.Tenant(key)
+	if err != nil {
+		return l, err
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	u := q.today(name)
+	switch {
+	case !l.allows(feature):
+		err = fmt.Errorf("%w: %s", ErrFeature, feature)
+	case l.MaxInputBytes > 0 && size > l.MaxInputBytes:
+		err = fmt.Errorf("%w: over %d bytes", ErrTooLarge, l.MaxInputBytes)
+	case l.RequestsPerDay > 0 && u.Requests >= l.RequestsPerDay:
+		err = fmt.Errorf("%w: %d requests", ErrExhausted, l.RequestsPerDay)
+	}
+	if err != nil {
+		u.Rejected++
+		return l, err
+	}
+	u.Requests++
+	u.Features[feature]++
+	u.Bytes += max(size, 0)
+	return l, nil
+}
+
+// Usage returns today's usage of the tenant key is admitted under.
+func (q *Quotas) Usage(key string) (Usage, error) {
+	name, _, err := q.Tenant(key)
+	if err != nil {
+		return Usage{}, err
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.today(name).clone(), nil
+}
+
+// Report returns today's usage of every tenant, sorted by name, when the
+// tenant key is admitted under lists AdminFeature, and of that tenant
+// alone otherwise.
+func (q *Quotas) Report(key string) ([]Usage, error) {
+	name, l, err := q.Tenant(key)
+	if err != nil {
+		return nil, err
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if !slices.Contains(l.Features, AdminFeature) {
+		return []Usage{q.today(name).clone()}, nil
+	}
+	out := make([]Usage, 0, len(q.limits))
+	for _, name := range slices.Sorted(maps.Keys(q.limits)) {
+		out = append(out, q.today(name).clone())
+	}
+	return out, nil
+}
+
+// ResetAt returns when today's allowances start over: the next midnight UTC.
+func (q *Quotas) ResetAt() time.Time {
+	now := q.now().UTC()
+	return time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, time.UTC)
+}
+
+// today returns name's counters, starting them over on a new day. The
+// caller holds q.mu.
+func (q *Quotas) today(name string) *Usage {
+	day := q.now().UTC().Format(time.DateOnly)
+	u := q.usage[name]
+	if u == nil || u.Day != day {
+		u = &Usage{Name: name, Day: day, Features: map[string]int{}, Limits: q.limits[name]}
+		q.usage[name] = u
+	}
+	return u
+}
+
+func (u *Usage) clone() Usage {
+	c := *u
+	c.Features = maps.Clone(u.Features)
+	return c
+}
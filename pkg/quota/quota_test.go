@@ -0,0 +1,137 @@
+package quota
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newQuotas(t *testing.T, file string) (*Quotas, *time.Time) {
+	t.Helper()
+	cfg, err := Parse([]byte(file))
+	require.NoError(t, err)
+	q, err := New(cfg)
+	require.NoError(t, err)
+	now := time.Date(2026, 10, 16, 23, 0, 0, 0, time.UTC)
+	q.now = func() time.Time { return now }
+	return q, &now
+}
+
+func TestQuotas(t *testing.T) {
+	sum := sha256.Sum256([]byte("bob-key"))
+	q, now := newQuotas(t, `
+default:
+  requestsPerDay: 1
+  features: [convert]
+keys:
+  alice:
+    key: alice-key
+    requestsPerDay: 2
+    maxInputBytes: 10
+    features: [convert, format]
+  bob:
+    keySHA256: `+hex.EncodeToString(sum[:])+`
+  ops:
+    key: ops-key
+    features: [admin]
+`)
+
+	_, err := q.Admit("alice-key", "convert", 5)
+	require.NoError(t, err)
+	_, err = q.Admit("alice-key", "hash", 5)
+	require.ErrorIs(t, err, ErrFeature)
+	_, err = q.Admit("alice-key", "format", 11)
+	require.ErrorIs(t, err, ErrTooLarge)
+	l, err := q.Admit("alice-key", "format", -1)
+	require.NoError(t, err)
+	require.Equal(t, int64(10), l.MaxInputBytes)
+	_, err = q.Admit("alice-key", "convert", 1)
+	require.ErrorIs(t, err, ErrExhausted)
+
+	u, err := q.Usage("alice-key")
+	require.NoError(t, err)
+	require.Equal(t, "alice", u.Name)
+	require.Equal(t, "2026-10-16", u.Day)
+	require.Equal(t, 2, u.Requests)
+	require.Equal(t, 3, u.Rejected)
+	require.Equal(t, int64(5), u.Bytes)
+	require.Equal(t, map[string]int{"convert": 1, "format": 1}, u.Features)
+
+	// Listed by hash, with no limits.
+	for range 5 {
+		_, err = q.Admit("bob-key", "hash", 1<<30)
+		require.NoError(t, err)
+	}
+
+	// No key and unlisted keys share the default allowance.
+	_, err = q.Admit("", "convert", 1)
+	require.NoError(t, err)
+	_, err = q.Admit("mallory", "convert", 1)
+	require.ErrorIs(t, err, ErrExhausted)
+
+	report, err := q.Report("ops-key")
+	require.NoError(t, err)
+	require.Len(t, report, 4)
+	require.Equal(t, []string{"alice", "bob", DefaultName, "ops"}, []string{report[0].Name, report[1].Name, report[2].Name, report[3].Name})
+	require.Equal(t, 5, report[1].Requests)
+	require.Equal(t, 1, report[2].Rejected)
+
+	// Other keys see only their own usage; listing no features does not
+	// make a key an admin.
+	report, err = q.Report("bob-key")
+	require.NoError(t, err)
+	require.Len(t, report, 1)
+	require.Equal(t, "bob", report[0].Name)
+	report, err = q.Report("")
+	require.NoError(t, err)
+	require.Equal(t, DefaultName, report[0].Name)
+	require.Len(t, report, 1)
+
+	require.Equal(t, time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC), q.ResetAt())
+	*now = now.Add(2 * time.Hour)
+	_, err = q.Admit("alice-key", "convert", 1)
+	require.NoError(t, err)
+	u, err = q.Usage("alice-key")
+	require.NoError(t, err)
+	require.Equal(t, "2026-10-17", u.Day)
+	require.Equal(t, 1, u.Requests)
+	require.Zero(t, u.Rejected)
+}
+
+func TestQuotasNoDefault(t *testing.T) {
+	q, _ := newQuotas(t, `{"keys":{"alice":{"key":"alice-key"}}}`)
+	_, err := q.Admit("", "convert", 1)
+	require.ErrorIs(t, err, ErrUnknownKey)
+	_, err = q.Admit("bob-key", "convert", 1)
+	require.ErrorIs(t, err, ErrUnknownKey)
+	_, err = q.Usage("bob-key")
+	require.ErrorIs(t, err, ErrUnknownKey)
+	_, err = q.Admit("alice-key", "convert", 1)
+	require.NoError(t, err)
+	report, err := q.Report("alice-key")
+	require.NoError(t, err)
+	require.Len(t, report, 1)
+	_, err = q.Report("bob-key")
+	require.ErrorIs(t, err, ErrUnknownKey)
+}
+
+func TestConfigErrors(t *testing.T) {
+	for name, file := range map[string]string{
+		"empty":         ``,
+		"unknown field": "keys:\n  a:\n    key: k\n    requestsPerHour: 1\n",
+		"no key":        "keys:\n  a:\n    requestsPerDay: 1\n",
+		"both keys":     "keys:\n  a:\n    key: k\n    keySHA256: abc\n",
+		"bad hash":      "keys:\n  a:\n    keySHA256: abc\n",
+		"same key":      "keys:\n  a:\n    key: k\n  b:\n    key: k\n",
+		"default name":  "keys:\n  default:\n    key: k\n",
+	} {
+		cfg, err := Parse([]byte(file))
+		if err == nil {
+			_, err = New(cfg)
+		}
+		require.Error(t, err, name)
+	}
+}
@@ -0,0 +1,98 @@
+package schemaregistry
+
+import (
+	"context"
+	"encoding/binary"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const userSchema = `{"type": "record", "name": "User", "fields": [{"name": "name", "type": "string"}, {"name": "age", "type": "int"}]}`
+
+func newRegistry(t *testing.T) (*Client, *atomic.Int32) {
+	t.Helper()
+	var byID atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "key" || pass != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error_code": 401, "message": "Unauthorized"}`))
+			return
+		}
+		schema := strings.ReplaceAll(userSchema, `"`, `\"`)
+		switch r.URL.EscapedPath() {
+		case "/subjects/users-value/versions/latest", "/subjects/users-value/versions/2":
+			w.Write([]byte(`{"subject": "users-value", "id": 7, "version": 2, "schema": "` + schema + `"}`))
+		case "/schemas/ids/7":
+			byID.Add(1)
+			w.Write([]byte(`{"schema": "` + schema + `"}`))
+		case "/subjects/proto%2Fvalue/versions/1":
+			w.Write([]byte(`{"subject": "proto/value", "id": 8, "version": 1, "schemaType": "PROTOBUF", "schema": "message Ping {\n  string id = 1;\n}\n"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error_code": 40401, "message": "Subject not found."}`))
+		}
+	}))
+	t.Cleanup(srv.Close)
+	c, err := New(strings.Replace(srv.URL, "http://", "http://key:secret@", 1) + "/")
+	require.NoError(t, err)
+	return c, &byID
+}
+
+func TestClient(t *testing.T) {
+	c, byID := newRegistry(t)
+	ctx := context.Background()
+
+	s, err := c.Subject(ctx, "users-value", "")
+	require.NoError(t, err)
+	require.Equal(t, Schema{ID: 7, Subject: "users-value", Version: 2, Type: "AVRO", Schema: userSchema}, s)
+
+	record := binary.AppendVarint(nil, 5)
+	record = append(record, "Ricky"...)
+	record = binary.AppendVarint(record, 27)
+	payload := append([]byte{0, 0, 0, 0, 7}, record...)
+	for range 2 {
+		d, err := c.Decode(ctx, payload, "")
+		require.NoError(t, err)
+		require.Equal(t, 7, d.SchemaID)
+		require.Equal(t, "AVRO", d.Type)
+		require.JSONEq(t, `{"name": "Ricky", "age": 27}`, string(d.Value))
+	}
+	require.Equal(t, int32(1), byID.Load(), "schemas by ID are cached")
+
+	d, err := c.Decode(ctx, record, "users-value")
+	require.NoError(t, err)
+	require.JSONEq(t, `{"name": "Ricky", "age": 27}`, string(d.Value))
+	_, err = c.Decode(ctx, record, "")
+	require.EqualError(t, err, "payload is not in the schema registry wire format; name the subject to decode it with")
+
+	out, err := c.GoStruct(ctx, "proto/value", "1")
+	require.NoError(t, err)
+	require.Contains(t, out, "type Ping struct")
+	out, err = c.GoStruct(ctx, "users-value", "2")
+	require.NoError(t, err)
+	require.Contains(t, out, "Age")
+
+	_, err = c.Subject(ctx, "missing", "")
+	require.EqualError(t, err, "schema registry: Subject not found.")
+	_, err = c.Subject(ctx, "", "")
+	require.EqualError(t, err, "schema registry: subject is required")
+
+	c.user = ""
+	_, err = c.SchemaByID(ctx, 9)
+	require.EqualError(t, err, "schema registry: Unauthorized")
+}
+
+func TestNew(t *testing.T) {
+	for _, bad := range []string{"localhost:8081", "ftp://host", "http://"} {
+		_, err := New(bad)
+		require.Error(t, err, bad)
+	}
+	c, err := New("https://registry.example.com/apis/ccompat/v7/")
+	require.NoError(t, err)
+	require.Equal(t, "https://registry.example.com/apis/ccompat/v7", c.URL())
+}
@@ -0,0 +1,168 @@
+// Package sign signs and verifies the server's API responses with Ed25519,
+// so a pipeline can tell a conversion result came from the server unchanged
+// by a proxy or cache in between. The signature covers the canonical form
+// of the JSON body, so re-indenting it or reordering its keys on the way
+// does not break it, while changing any value does.
+package sign
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+)
+
+// Headers a signed response carries: the base64 signature and the ID of
+// the key that made it.
+const (
+	SignatureHeader = "X-Transform-Signature"
+	KeyIDHeader     = "X-Transform-Key-Id"
+)
+
+// ErrSignature is returned when a signature is missing, malformed or does
+// not match the body and key.
+var ErrSignature = errors.New("signature does not match")
+
+// Signer signs response bodies with one Ed25519 key.
+type Signer struct {
+	key ed25519.PrivateKey
+	id  string
+}
+
+// NewSigner returns a Signer for key.
+func NewSigner(key ed25519.PrivateKey) *Signer {
+	return &Signer{key: key, id: KeyID(key.Public().(ed25519.PublicKey))}
+}
+
+// LoadSigner reads a PEM PKCS#8 Ed25519 private key, as written by
+// openssl genpkey -algorithm ed25519, from the file at path.
+func LoadSigner(path string) (*Signer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s: key must be PEM encoded", path)
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	edKey, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("%s: key must be Ed25519, not %T", path, key)
+	}
+	return NewSigner(edKey), nil
+}
+
+// KeyID returns the ID of the signing key.
+func (s *Signer) KeyID() string { return s.id }
+
+// Public returns the public half of the signing key.
+func (s *Signer) Public() ed25519.PublicKey { return s.key.Public().(ed25519.PublicKey) }
+
+// PublicPEM returns the public key as a PEM PKIX block, which
+// ParsePublicKey reads.
+func (s *Signer) PublicPEM() string {
+	der, _ := x509.MarshalPKIXPublicKey(s.Public())
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+}
+
+// Sign returns the base64 signature of the canonical form of the JSON
+// body.
+func (s *Signer) Sign(body []byte) (string, error) {
+	canonical, err := Canonicalize(body)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(ed25519.Sign(s.key, canonical)), nil
+}
+
+// KeyID identifies pub by the first 8 bytes of its SHA-256, in hex, so a
+// client holding several keys knows which to verify with.
+func KeyID(pub ed25519.PublicKey) string {
+	sum := sha256.Sum256(pub)
+	return hex.EncodeToString(sum[:8])
+}
+
+// ParsePublicKey reads a PEM PKIX Ed25519 public key, as PublicPEM and the
+// server's /api/v1/signing-key endpoint return.
+func ParsePublicKey(data []byte) (ed25519.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("key must be PEM encoded")
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	pub, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("key must be Ed25519, not %T", key)
+	}
+	return pub, nil
+}
+
+// Canonicalize returns the JSON body compact, with object keys sorted,
+// strings escaped the same way each time and numbers kept as written.
+func Canonicalize(body []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, errors.New("body must hold a single JSON value")
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// Verify checks that signature, in base64, was made by pub over the
+// canonical form of the JSON body.
+func Verify(pub ed25519.PublicKey, body []byte, signature string) error {
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil || len(sig) != ed25519.SignatureSize {
+		return ErrSignature
+	}
+	canonical, err := Canonicalize(body)
+	if err != nil {
+		return err
+	}
+	if !ed25519.Verify(pub, canonical, sig) {
+		return ErrSignature
+	}
+	return nil
+}
+
+// VerifyResponse reads the body of resp and verifies it against the
+// signature header with pub. The body is returned, and left readable again
+// in resp, whether or not it verifies.
+func VerifyResponse(pub ed25519.PublicKey, resp *http.Response) ([]byte, error) {
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return body, err
+	}
+	if id := resp.Header.Get(KeyIDHeader); id != "" && id != KeyID(pub) {
+		return body, fmt.Errorf("%w: signed with key %s, not %s", ErrSignature, id, KeyID(pub))
+	}
+	return body, Verify(pub, body, resp.Header.Get(SignatureHeader))
+}
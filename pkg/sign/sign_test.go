@@ -0,0 +1,74 @@
+package sign
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCanonicalize(t *testing.T) {
+	got, err := Canonicalize([]byte(`{"result": {"b": 12345678901234567890, "a": "<x>"},
+		"error": null}`))
+	require.NoError(t, err)
+	require.Equal(t, `{"error":null,"result":{"a":"<x>","b":12345678901234567890}}`, string(got))
+
+	for _, body := range []string{"", "{", `{} {}`} {
+		_, err := Canonicalize([]byte(body))
+		require.Error(t, err, body)
+	}
+}
+
+func TestSignAndVerify(t *testing.T) {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "signing.pem")
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600))
+
+	s, err := LoadSigner(path)
+	require.NoError(t, err)
+	pub, err := ParsePublicKey([]byte(s.PublicPEM()))
+	require.NoError(t, err)
+	require.Equal(t, s.Public(), pub)
+	require.Len(t, s.KeyID(), 16)
+
+	sig, err := s.Sign([]byte(`{"result":"a: 1"}`))
+	require.NoError(t, err)
+	require.NoError(t, Verify(pub, []byte("{\n  \"result\": \"a: 1\"\n}"), sig))
+	require.ErrorIs(t, Verify(pub, []byte(`{"result":"a: 2"}`), sig), ErrSignature)
+	require.ErrorIs(t, Verify(pub, []byte(`{"result":"a: 1"}`), "bm9wZQ=="), ErrSignature)
+
+	other, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	require.ErrorIs(t, Verify(other, []byte(`{"result":"a: 1"}`), sig), ErrSignature)
+
+	resp := &http.Response{Header: http.Header{}, Body: io.NopCloser(strings.NewReader(`{"result":"a: 1"}`))}
+	resp.Header.Set(SignatureHeader, sig)
+	resp.Header.Set(KeyIDHeader, s.KeyID())
+	body, err := VerifyResponse(pub, resp)
+	require.NoError(t, err)
+	require.Equal(t, `{"result":"a: 1"}`, string(body))
+	again, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Equal(t, body, again)
+
+	resp.Body = io.NopCloser(strings.NewReader(`{"result":"a: 1"}`))
+	_, err = VerifyResponse(other, resp)
+	require.ErrorIs(t, err, ErrSignature)
+
+	_, err = ParsePublicKey([]byte("nope"))
+	require.Error(t, err)
+	require.NoError(t, os.WriteFile(path, []byte("nope"), 0o600))
+	_, err = LoadSigner(path)
+	require.Error(t, err)
+}
@@ -0,0 +1,107 @@
+//go:build !wasm
+
+package storage
+
+import (
+	"bytes"
+	"slices"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Bolt is a Storage in a bbolt file. bbolt locks the file, so it suits one
+// server; OpenBolt makes a second server on the same file wait a second and
+// fail.
+type Bolt struct {
+	db  *bolt.DB
+	now func() time.Time
+}
+
+// boltBucket holds every key; expired values stay in the file until
+// replaced.
+var boltBucket = []byte("storage")
+
+// OpenBolt opens the bbolt file at path, creating it if needed.
+func OpenBolt(path string) (*Bolt, error) {
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(boltBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &Bolt{db: db, now: time.Now}, nil
+}
+
+// Get implements Storage.
+func (b *Bolt) Get(key string) ([]byte, error) {
+	var value []byte
+	err := b.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(boltBucket).Get([]byte(key))
+		if data == nil {
+			return ErrNotFound
+		}
+		v, live, err := decodeItem(data, b.now())
+		if err != nil {
+			return err
+		}
+		if !live {
+			return ErrNotFound
+		}
+		value = slices.Clone(v)
+		return nil
+	})
+	return value, err
+}
+
+// Put implements Storage.
+func (b *Bolt) Put(key string, value []byte, ttl time.Duration) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltBucket).Put([]byte(key), encodeItem(value, expiry(b.now(), ttl)))
+	})
+}
+
+// List implements Storage.
+func (b *Bolt) List(prefix string) ([]string, error) {
+	keys := []string{}
+	err := b.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(boltBucket).Cursor()
+		for k, data := c.Seek([]byte(prefix)); k != nil && bytes.HasPrefix(k, []byte(prefix)); k, data = c.Next() {
+			_, live, err := decodeItem(data, b.now())
+			if err != nil {
+				return err
+			}
+			if live {
+				keys = append(keys, string(k))
+			}
+		}
+		return nil
+	})
+	return keys, err
+}
+
+// Delete implements Storage.
+func (b *Bolt) Delete(key string) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(boltBucket)
+		data := bucket.Get([]byte(key))
+		if data == nil {
+			return ErrNotFound
+		}
+		if _, live, err := decodeItem(data, b.now()); err != nil || !live {
+			return ErrNotFound
+		}
+		return bucket.Delete([]byte(key))
+	})
+}
+
+// Close implements Storage.
+func (b *Bolt) Close() error {
+	return b.db.Close()
+}
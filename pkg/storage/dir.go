@@ -0,0 +1,137 @@
+//go:build !wasm
+
+package storage
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Dir is a Storage keeping each value in a file under a directory, named
+// by the SHA-256 of its key so any key makes a valid file name; the file
+// holds the key after the value's header. Writes replace files in one step,
+// so several servers may share a directory on one machine.
+type Dir struct {
+	path string
+	now  func() time.Time
+}
+
+// OpenDir opens the storage in the directory at path, creating it if
+// needed.
+func OpenDir(path string) (*Dir, error) {
+	if path == "" {
+		return nil, errors.New("dir storage needs a path")
+	}
+	if err := os.MkdirAll(path, 0o700); err != nil {
+		return nil, err
+	}
+	return &Dir{path: path, now: time.Now}, nil
+}
+
+// Get implements Storage.
+func (d *Dir) Get(key string) ([]byte, error) {
+	got, value, err := d.read(d.file(key))
+	if err != nil {
+		return nil, err
+	}
+	if got != key {
+		return nil, ErrNotFound
+	}
+	return value, nil
+}
+
+// read returns the key and value in the file at name, removing it and
+// returning ErrNotFound once it has expired.
+func (d *Dir) read(name string) (string, []byte, error) {
+	data, err := os.ReadFile(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", nil, ErrNotFound
+	}
+	if err != nil {
+		return "", nil, err
+	}
+	frame, live, err := decodeItem(data, d.now())
+	if err != nil {
+		return "", nil, err
+	}
+	if !live {
+		os.Remove(name)
+		return "", nil, ErrNotFound
+	}
+	n, size := binary.Uvarint(frame)
+	if size <= 0 || uint64(len(frame)-size) < n {
+		return "", nil, errors.New("storage file is truncated")
+	}
+	frame = frame[size:]
+	return string(frame[:n]), frame[n:], nil
+}
+
+// Put implements Storage.
+func (d *Dir) Put(key string, value []byte, ttl time.Duration) error {
+	tmp, err := os.CreateTemp(d.path, ".put-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	frame := binary.AppendUvarint(nil, uint64(len(key)))
+	frame = append(append(frame, key...), value...)
+	if _, err := tmp.Write(encodeItem(frame, expiry(d.now(), ttl))); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), d.file(key))
+}
+
+// List implements Storage. It reads every live file, so a directory is
+// best kept to thousands of keys rather than millions.
+func (d *Dir) List(prefix string) ([]string, error) {
+	entries, err := os.ReadDir(d.path)
+	if err != nil {
+		return nil, err
+	}
+	keys := []string{}
+	for _, e := range entries {
+		if len(e.Name()) != 2*sha256.Size || strings.HasPrefix(e.Name(), ".") {
+			continue // temporary or foreign file
+		}
+		key, _, err := d.read(filepath.Join(d.path, e.Name()))
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+	return keys, nil
+}
+
+// Delete implements Storage.
+func (d *Dir) Delete(key string) error {
+	if _, err := d.Get(key); err != nil {
+		return err
+	}
+	return os.Remove(d.file(key))
+}
+
+// Close implements Storage.
+func (d *Dir) Close() error { return nil }
+
+func (d *Dir) file(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(d.path, hex.EncodeToString(sum[:]))
+}
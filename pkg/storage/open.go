@@ -0,0 +1,62 @@
+//go:build !wasm
+
+package storage
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Open opens the storage spec names:
+//
+//	memory:                       in the process, gone on restart
+//	dir:/var/lib/transform-go     a file per key under a directory
+//	bolt:store.db or store.db     a bbolt file, for one server
+//	redis://:secret@host:6379/0   Redis, shared by many servers (rediss:// for TLS)
+func Open(spec string) (Storage, error) {
+	scheme, rest, _ := strings.Cut(spec, ":")
+	switch scheme {
+	case "memory":
+		return NewMemory(), nil
+	case "dir":
+		return OpenDir(rest)
+	case "bolt":
+		return OpenBolt(rest)
+	case "redis", "rediss":
+		return DialRedis(spec)
+	}
+	if spec == "" {
+		return nil, errors.New("empty storage spec")
+	}
+	if strings.Contains(spec, "://") {
+		return nil, fmt.Errorf("unknown storage %q; use memory:, dir:, bolt: or redis://", spec)
+	}
+	return OpenBolt(spec)
+}
+
+// The directory and bbolt storages keep each value after an 8-byte header
+// holding when it expires in Unix nanoseconds, or 0 if it does not.
+const headerSize = 8
+
+func encodeItem(value []byte, expires time.Time) []byte {
+	out := make([]byte, headerSize+len(value))
+	if !expires.IsZero() {
+		binary.BigEndian.PutUint64(out, uint64(expires.UnixNano()))
+	}
+	copy(out[headerSize:], value)
+	return out
+}
+
+// decodeItem returns the value of an item and whether it is still live at
+// now.
+func decodeItem(data []byte, now time.Time) ([]byte, bool, error) {
+	if len(data) < headerSize {
+		return nil, false, errors.New("storage item is truncated")
+	}
+	expires := int64(binary.BigEndian.Uint64(data))
+	live := expires == 0 || now.UnixNano() < expires
+	return data[headerSize:], live, nil
+}
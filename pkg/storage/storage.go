@@ -0,0 +1,118 @@
+// Package storage is the key-value layer under the server's saved entries
+// and caches, so one deployment can keep them in memory, in a directory,
+// in a bbolt file or in Redis without the code above it changing. Values
+// may expire; expired values read as missing. Only the in-memory storage
+// is part of the wasm build.
+package storage
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNotFound is returned for a key that has no value or whose value has
+// expired.
+var ErrNotFound = errors.New("not found")
+
+// Storage keeps byte values under string keys. Implementations are safe for
+// concurrent use.
+type Storage interface {
+	// Get returns the value at key.
+	Get(key string) ([]byte, error)
+	// Put stores value at key, replacing any value there. A ttl above
+	// zero lets the value expire after it.
+	Put(key string, value []byte, ttl time.Duration) error
+	// List returns the keys starting with prefix, sorted.
+	List(prefix string) ([]string, error)
+	// Delete removes the value at key.
+	Delete(key string) error
+	// Close releases the storage.
+	Close() error
+}
+
+// Memory is a Storage that lives as long as the process.
+type Memory struct {
+	mu    sync.Mutex
+	items map[string]memoryItem
+	now   func() time.Time
+}
+
+type memoryItem struct {
+	value   []byte
+	expires time.Time
+}
+
+// NewMemory returns an empty in-memory storage.
+func NewMemory() *Memory {
+	return &Memory{items: map[string]memoryItem{}, now: time.Now}
+}
+
+// Get implements Storage.
+func (m *Memory) Get(key string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	item, ok := m.live(key)
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return slices.Clone(item.value), nil
+}
+
+// Put implements Storage.
+func (m *Memory) Put(key string, value []byte, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.items[key] = memoryItem{value: slices.Clone(value), expires: expiry(m.now(), ttl)}
+	return nil
+}
+
+// List implements Storage.
+func (m *Memory) List(prefix string) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	keys := []string{}
+	for key := range m.items {
+		if _, ok := m.live(key); ok && strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+	return keys, nil
+}
+
+// Delete implements Storage.
+func (m *Memory) Delete(key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.live(key); !ok {
+		return ErrNotFound
+	}
+	delete(m.items, key)
+	return nil
+}
+
+// Close implements Storage.
+func (m *Memory) Close() error { return nil }
+
+// live returns key's item unless it has expired, dropping it if so. The
+// caller holds m.mu.
+func (m *Memory) live(key string) (memoryItem, bool) {
+	item, ok := m.items[key]
+	if ok && !item.expires.IsZero() && !m.now().Before(item.expires) {
+		delete(m.items, key)
+		return item, false
+	}
+	return item, ok
+}
+
+// expiry returns when a value put at now with ttl expires, or the zero time
+// for one that does not.
+func expiry(now time.Time, ttl time.Duration) time.Time {
+	if ttl <= 0 {
+		return time.Time{}
+	}
+	return now.Add(ttl)
+}
@@ -0,0 +1,281 @@
+//go:build !wasm
+
+package storage
+
+import (
+	"bufio"
+	"net"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// testStorage runs the same checks over every implementation; expire moves
+// the storage's clock past a TTL.
+func testStorage(t *testing.T, s Storage, expire func(time.Duration)) {
+	t.Helper()
+	_, err := s.Get("store/a")
+	require.ErrorIs(t, err, ErrNotFound)
+
+	require.NoError(t, s.Put("store/b", []byte("2"), 0))
+	require.NoError(t, s.Put("store/a", []byte("1"), 0))
+	require.NoError(t, s.Put("store/a*", []byte("star"), 0))
+	require.NoError(t, s.Put("cache/ua", []byte("{}"), time.Minute))
+	require.NoError(t, s.Put("store/a", []byte("one"), 0))
+
+	got, err := s.Get("store/a")
+	require.NoError(t, err)
+	require.Equal(t, "one", string(got))
+	keys, err := s.List("store/")
+	require.NoError(t, err)
+	require.Equal(t, []string{"store/a", "store/a*", "store/b"}, keys)
+	keys, err = s.List("store/a*")
+	require.NoError(t, err)
+	require.Equal(t, []string{"store/a*"}, keys)
+
+	require.NoError(t, s.Delete("store/b"))
+	require.ErrorIs(t, s.Delete("store/b"), ErrNotFound)
+	_, err = s.Get("store/b")
+	require.ErrorIs(t, err, ErrNotFound)
+
+	got, err = s.Get("cache/ua")
+	require.NoError(t, err)
+	require.Equal(t, "{}", string(got))
+	expire(2 * time.Minute)
+	_, err = s.Get("cache/ua")
+	require.ErrorIs(t, err, ErrNotFound)
+	keys, err = s.List("")
+	require.NoError(t, err)
+	require.Equal(t, []string{"store/a", "store/a*"}, keys)
+	require.ErrorIs(t, s.Delete("cache/ua"), ErrNotFound)
+}
+
+func clock(now *func() time.Time) func(time.Duration) {
+	at := time.Now()
+	*now = func() time.Time { return at }
+	return func(d time.Duration) { at = at.Add(d) }
+}
+
+func TestMemory(t *testing.T) {
+	m := NewMemory()
+	testStorage(t, m, clock(&m.now))
+}
+
+func TestDir(t *testing.T) {
+	d, err := OpenDir(t.TempDir())
+	require.NoError(t, err)
+	testStorage(t, d, clock(&d.now))
+
+	// Keys longer than a file name may be are fine.
+	long := strings.Repeat("k", 1000)
+	require.NoError(t, d.Put(long, []byte("v"), 0))
+	got, err := d.Get(long)
+	require.NoError(t, err)
+	require.Equal(t, "v", string(got))
+}
+
+func TestBolt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store.db")
+	b, err := OpenBolt(path)
+	require.NoError(t, err)
+	testStorage(t, b, clock(&b.now))
+	require.NoError(t, b.Close())
+
+	s, err := Open("bolt:" + path)
+	require.NoError(t, err)
+	defer s.Close()
+	got, err := s.Get("store/a")
+	require.NoError(t, err)
+	require.Equal(t, "one", string(got))
+}
+
+func TestRedis(t *testing.T) {
+	srv := newFakeRedis(t, "secret")
+	_, err := DialRedis("redis://:wrong@" + srv.addr)
+	require.ErrorContains(t, err, "WRONGPASS")
+
+	s, err := Open("redis://:secret@" + srv.addr + "/3")
+	require.NoError(t, err)
+	defer s.Close()
+	testStorage(t, s, func(d time.Duration) { srv.advance(d) })
+	srv.mu.Lock()
+	require.Equal(t, 3, srv.selected)
+	srv.mu.Unlock()
+
+	// A dropped connection is dialed again.
+	srv.dropConnections()
+	got, err := s.Get("store/a")
+	require.NoError(t, err)
+	require.Equal(t, "one", string(got))
+}
+
+func TestOpen(t *testing.T) {
+	s, err := Open("memory:")
+	require.NoError(t, err)
+	require.IsType(t, &Memory{}, s)
+	s, err = Open("dir:" + t.TempDir())
+	require.NoError(t, err)
+	require.IsType(t, &Dir{}, s)
+	s, err = Open(filepath.Join(t.TempDir(), "store.db"))
+	require.NoError(t, err)
+	require.IsType(t, &Bolt{}, s)
+	require.NoError(t, s.Close())
+	for _, spec := range []string{"", "s3://bucket", "dir:", "redis://", "redis://host/db"} {
+		_, err := Open(spec)
+		require.Error(t, err, spec)
+	}
+}
+
+// fakeRedis answers the commands Redis sends, over RESP, with a clock the
+// test moves.
+type fakeRedis struct {
+	addr     string
+	password string
+
+	mu       sync.Mutex
+	now      time.Time
+	values   map[string]string
+	expires  map[string]time.Time
+	selected int
+	conns    []net.Conn
+}
+
+func newFakeRedis(t *testing.T, password string) *fakeRedis {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+	f := &fakeRedis{
+		addr:     ln.Addr().String(),
+		password: password,
+		now:      time.Now(),
+		values:   map[string]string{},
+		expires:  map[string]time.Time{},
+	}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			f.mu.Lock()
+			f.conns = append(f.conns, conn)
+			f.mu.Unlock()
+			go f.serve(conn)
+		}
+	}()
+	return f
+}
+
+func (f *fakeRedis) advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.now = f.now.Add(d)
+}
+
+func (f *fakeRedis) dropConnections() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, conn := range f.conns {
+		conn.Close()
+	}
+	f.conns = nil
+}
+
+func (f *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	rd := bufio.NewReader(conn)
+	authed := f.password == ""
+	for {
+		reply, err := readReply(rd)
+		if err != nil {
+			return
+		}
+		var args []string
+		for _, a := range reply.([]any) {
+			args = append(args, string(a.([]byte)))
+		}
+		var out string
+		if strings.ToUpper(args[0]) == "AUTH" {
+			authed = args[len(args)-1] == f.password
+			out = "+OK\r\n"
+			if !authed {
+				out = "-WRONGPASS invalid password\r\n"
+			}
+		} else if !authed {
+			out = "-NOAUTH Authentication required.\r\n"
+		} else {
+			out = f.command(args)
+		}
+		if _, err := conn.Write([]byte(out)); err != nil {
+			return
+		}
+	}
+}
+
+func (f *fakeRedis) command(args []string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for k, at := range f.expires {
+		if !f.now.Before(at) {
+			delete(f.values, k)
+			delete(f.expires, k)
+		}
+	}
+	bulk := func(s string) string { return "$" + strconv.Itoa(len(s)) + "\r\n" + s + "\r\n" }
+	switch strings.ToUpper(args[0]) {
+	case "PING":
+		return "+PONG\r\n"
+	case "SELECT":
+		f.selected, _ = strconv.Atoi(args[1])
+		return "+OK\r\n"
+	case "GET":
+		v, ok := f.values[args[1]]
+		if !ok {
+			return "$-1\r\n"
+		}
+		return bulk(v)
+	case "SET":
+		f.values[args[1]] = args[2]
+		delete(f.expires, args[1])
+		if len(args) == 5 && args[3] == "PX" {
+			ms, _ := strconv.Atoi(args[4])
+			f.expires[args[1]] = f.now.Add(time.Duration(ms) * time.Millisecond)
+		}
+		return "+OK\r\n"
+	case "DEL":
+		_, ok := f.values[args[1]]
+		delete(f.values, args[1])
+		if ok {
+			return ":1\r\n"
+		}
+		return ":0\r\n"
+	case "SCAN":
+		// One key per page, to walk the cursor; the pattern is an
+		// escaped prefix and a trailing *.
+		prefix := strings.TrimSuffix(args[3], "*")
+		prefix = strings.NewReplacer(`\\`, `\`, `\*`, `*`, `\?`, `?`, `\[`, `[`, `\]`, `]`).Replace(prefix)
+		var keys []string
+		for k := range f.values {
+			if strings.HasPrefix(k, prefix) {
+				keys = append(keys, k)
+			}
+		}
+		slices.Sort(keys)
+		i, _ := strconv.Atoi(args[1])
+		if i >= len(keys) {
+			return "*2\r\n" + bulk("0") + "*0\r\n"
+		}
+		next := "0"
+		if i+1 < len(keys) {
+			next = strconv.Itoa(i + 1)
+		}
+		return "*2\r\n" + bulk(next) + "*1\r\n" + bulk(keys[i])
+	}
+	return "-ERR unknown command\r\n"
+}
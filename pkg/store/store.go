@@ -0,0 +1,179 @@
+// Package store keeps named snippets, conversion presets and pipelines in a
+// storage.Storage, so the server can hand back what a user saved yesterday.
+// Each entry belongs to an owner, the API key it was saved with; the key
+// only partitions the store and is not checked against anything, so the
+// server must sit behind something that does authenticate when that
+// matters. The package is not part of the wasm build.
+package store
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/linzeyan/transform-go/pkg/storage"
+)
+
+// Kinds of entry. Snippets are saved inputs, presets are a from/to pair
+// with options, and pipelines are a chain of conversions.
+const (
+	KindSnippet  = "snippets"
+	KindPreset   = "presets"
+	KindPipeline = "pipelines"
+)
+
+// Limits on what one entry may hold.
+const (
+	maxNameLength = 128
+	maxEntrySize  = 1 << 20
+	maxSteps      = 32
+)
+
+// ErrNotFound is returned for an entry the owner has not saved.
+var ErrNotFound = storage.ErrNotFound
+
+// Entry is one saved item. Which fields matter depends on Kind: a snippet
+// has From and Input, a preset From, To and Options, and a pipeline From
+// and Steps.
+type Entry struct {
+	Name    string          `json:"name"`
+	Kind    string          `json:"kind"`
+	From    string          `json:"from,omitempty"`
+	To      string          `json:"to,omitempty"`
+	Options json.RawMessage `json:"options,omitempty"`
+	Input   string          `json:"input,omitempty"`
+	Steps   []Step          `json:"steps,omitempty"`
+	Updated time.Time       `json:"updated"`
+}
+
+// Step is one conversion of a pipeline, from the previous step's format.
+type Step struct {
+	To      string          `json:"to"`
+	Options json.RawMessage `json:"options,omitempty"`
+}
+
+// Store is the saved entries in a storage. It is safe for concurrent use.
+type Store struct {
+	s storage.Storage
+}
+
+// New returns the store kept in s, under keys starting with "store/".
+func New(s storage.Storage) *Store {
+	return &Store{s: s}
+}
+
+// Put saves e for owner under e.Kind and e.Name, replacing an entry of the
+// same name, and returns it as stored.
+func (s *Store) Put(owner string, e Entry) (Entry, error) {
+	if err := validate(e); err != nil {
+		return Entry{}, err
+	}
+	e.Updated = time.Now().UTC().Truncate(time.Second)
+	data, err := json.Marshal(e)
+	if err != nil {
+		return Entry{}, err
+	}
+	if len(data) > maxEntrySize {
+		return Entry{}, fmt.Errorf("entry is larger than %d bytes", maxEntrySize)
+	}
+	if err := s.s.Put(entryKey(owner, e.Kind, e.Name), data, 0); err != nil {
+		return Entry{}, err
+	}
+	return e, nil
+}
+
+// Get returns owner's entry of kind named name.
+func (s *Store) Get(owner, kind, name string) (Entry, error) {
+	var e Entry
+	data, err := s.s.Get(entryKey(owner, kind, name))
+	if err != nil {
+		return e, err
+	}
+	return e, json.Unmarshal(data, &e)
+}
+
+// List returns owner's entries of kind, sorted by name.
+func (s *Store) List(owner, kind string) ([]Entry, error) {
+	if !validKind(kind) {
+		return nil, fmt.Errorf("unknown kind %q; use snippets, presets or pipelines", kind)
+	}
+	keys, err := s.s.List(entryKey(owner, kind, ""))
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]Entry, 0, len(keys))
+	for _, key := range keys {
+		data, err := s.s.Get(key)
+		if errors.Is(err, storage.ErrNotFound) {
+			continue // deleted since listed
+		}
+		if err != nil {
+			return nil, err
+		}
+		var e Entry
+		if err := json.Unmarshal(data, &e); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+// Delete removes owner's entry of kind named name.
+func (s *Store) Delete(owner, kind, name string) error {
+	return s.s.Delete(entryKey(owner, kind, name))
+}
+
+// entryKey is the storage key of an entry: the owner by the SHA-256 of
+// their key, so the storage does not hold the keys themselves, then the
+// kind and the name, which has no slash.
+func entryKey(owner, kind, name string) string {
+	sum := sha256.Sum256([]byte(owner))
+	return strings.Join([]string{"store", hex.EncodeToString(sum[:]), kind, name}, "/")
+}
+
+func validKind(kind string) bool {
+	return kind == KindSnippet || kind == KindPreset || kind == KindPipeline
+}
+
+func validate(e Entry) error {
+	if !validKind(e.Kind) {
+		return fmt.Errorf("unknown kind %q; use snippets, presets or pipelines", e.Kind)
+	}
+	if e.Name == "" || len(e.Name) > maxNameLength || !utf8.ValidString(e.Name) {
+		return fmt.Errorf("name must be 1 to %d bytes of UTF-8", maxNameLength)
+	}
+	for _, r := range e.Name {
+		if unicode.IsControl(r) || r == '/' {
+			return fmt.Errorf("name %q has a control character or slash", e.Name)
+		}
+	}
+	if len(e.Options) > 0 && !json.Valid(e.Options) {
+		return errors.New("options are not valid JSON")
+	}
+	switch e.Kind {
+	case KindPreset:
+		if e.To == "" {
+			return errors.New("a preset needs a target format")
+		}
+	case KindPipeline:
+		if len(e.Steps) == 0 || len(e.Steps) > maxSteps {
+			return fmt.Errorf("a pipeline needs 1 to %d steps", maxSteps)
+		}
+		for i, step := range e.Steps {
+			if step.To == "" {
+				return fmt.Errorf("step %d needs a target format", i+1)
+			}
+			if len(step.Options) > 0 && !json.Valid(step.Options) {
+				return fmt.Errorf("step %d: options are not valid JSON", i+1)
+			}
+		}
+	}
+	return nil
+}
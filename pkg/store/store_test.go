@@ -0,0 +1,99 @@
+package store
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/linzeyan/transform-go/pkg/storage"
+	"github.com/stretchr/testify/require"
+)
+
+func openStore(t *testing.T) *Store {
+	t.Helper()
+	b, err := storage.OpenBolt(filepath.Join(t.TempDir(), "store.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { b.Close() })
+	return New(b)
+}
+
+func TestStore(t *testing.T) {
+	s := openStore(t)
+
+	saved, err := s.Put("alice", Entry{Name: "k8s", Kind: KindPreset, From: "JSON", To: "YAML", Options: json.RawMessage(`{"indent":2}`)})
+	require.NoError(t, err)
+	require.False(t, saved.Updated.IsZero())
+	_, err = s.Put("alice", Entry{Name: "app", Kind: KindPreset, To: "TOML"})
+	require.NoError(t, err)
+
+	got, err := s.Get("alice", KindPreset, "k8s")
+	require.NoError(t, err)
+	require.Equal(t, saved, got)
+	require.JSONEq(t, `{"indent":2}`, string(got.Options))
+
+	list, err := s.List("alice", KindPreset)
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	require.Equal(t, "app", list[0].Name)
+	require.Equal(t, "k8s", list[1].Name)
+
+	// Owners and kinds do not see each other's entries.
+	_, err = s.Get("bob", KindPreset, "k8s")
+	require.ErrorIs(t, err, ErrNotFound)
+	_, err = s.Get("alice", KindSnippet, "k8s")
+	require.ErrorIs(t, err, ErrNotFound)
+	list, err = s.List("bob", KindPreset)
+	require.NoError(t, err)
+	require.Empty(t, list)
+
+	_, err = s.Put("alice", Entry{Name: "k8s", Kind: KindPreset, From: "YAML", To: "JSON"})
+	require.NoError(t, err)
+	got, err = s.Get("alice", KindPreset, "k8s")
+	require.NoError(t, err)
+	require.Equal(t, "YAML", got.From)
+	require.Empty(t, got.Options)
+
+	require.NoError(t, s.Delete("alice", KindPreset, "k8s"))
+	require.ErrorIs(t, s.Delete("alice", KindPreset, "k8s"), ErrNotFound)
+	_, err = s.Get("alice", KindPreset, "k8s")
+	require.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestStoreReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store")
+	d, err := storage.OpenDir(path)
+	require.NoError(t, err)
+	_, err = New(d).Put("", Entry{Name: "sample", Kind: KindSnippet, From: "YAML", Input: "a: 1\n"})
+	require.NoError(t, err)
+
+	d, err = storage.OpenDir(path)
+	require.NoError(t, err)
+	got, err := New(d).Get("", KindSnippet, "sample")
+	require.NoError(t, err)
+	require.Equal(t, "a: 1\n", got.Input)
+}
+
+func TestStoreValidate(t *testing.T) {
+	s := openStore(t)
+	for name, e := range map[string]Entry{
+		"kind":          {Name: "x", Kind: "macros"},
+		"empty name":    {Kind: KindSnippet},
+		"long name":     {Name: strings.Repeat("x", maxNameLength+1), Kind: KindSnippet},
+		"slash":         {Name: "a/b", Kind: KindSnippet},
+		"control":       {Name: "a\nb", Kind: KindSnippet},
+		"options":       {Name: "x", Kind: KindPreset, To: "YAML", Options: json.RawMessage(`{`)},
+		"preset target": {Name: "x", Kind: KindPreset, From: "JSON"},
+		"no steps":      {Name: "x", Kind: KindPipeline, From: "JSON"},
+		"step target":   {Name: "x", Kind: KindPipeline, Steps: []Step{{To: "YAML"}, {}}},
+		"large":         {Name: "x", Kind: KindSnippet, Input: strings.Repeat("x", maxEntrySize)},
+	} {
+		_, err := s.Put("alice", e)
+		require.Error(t, err, name)
+	}
+	_, err := s.List("alice", "macros")
+	require.Error(t, err)
+
+	_, err = s.Put("alice", Entry{Name: "chain", Kind: KindPipeline, From: "YAML", Steps: []Step{{To: "JSON"}, {To: "TOML"}}})
+	require.NoError(t, err)
+}
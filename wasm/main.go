@@ -3,7 +3,12 @@
 package main
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
 	"syscall/js"
+	"time"
 
 	"github.com/linzeyan/transform-go/pkg/code"
 	"github.com/linzeyan/transform-go/pkg/convert"
@@ -19,6 +24,15 @@ type converter func(string) (string, error)
 
 func registerBindings(target js.Value) {
 	bindings := map[string]converter{
+		"avroToJSON": convert.AvroToJSON,
+
+		"changelogToJSON": convert.ChangelogToJSON,
+		"commitsToJSON":   convert.ConventionalCommitsToJSON,
+
+		"dotenvToJSON": convert.DotenvToJSON,
+
+		"goStructToBond":    convert.GoStructToBond,
+		"goStructToCapnp":   convert.GoStructToCapnp,
 		"goStructToGraphQL": convert.GoStructToGraphQL,
 		"goStructToJSON":    convert.GoStructToJSON,
 		"goStructToProto":   convert.GoStructToProto,
@@ -28,14 +42,40 @@ func registerBindings(target js.Value) {
 
 		"graphQLToJSON": convert.GraphQLToJSON,
 
-		"jsonToGoStruct": convert.JSONToGoStruct,
-		"jsonToGraphQL":  convert.JSONToGraphQL,
-		"jsonToProto":    convert.JSONToProto,
-		"jsonToSchema":   convert.JSONToSchema,
-		"jsonToTOML":     convert.JSONToTOML,
-		"jsonToYAML":     convert.JSONToYAML,
+		"hclToJSON":       convert.HCLToJSON,
+		"htmlTableToJSON": convert.HTMLTableToJSON,
+
+		"frontMatterToJSON": convert.FrontMatterToJSON,
+
+		"infToJSON": convert.INFToJSON,
+
+		"jsonToAvro":          convert.JSONToAvro,
+		"jsonToBond":          convert.JSONToBond,
+		"jsonToCapnp":         convert.JSONToCapnp,
+		"jsonToDotenv":        convert.JSONToDotenv,
+		"jsonToGoStruct":      convert.JSONToGoStruct,
+		"jsonToGoStructNamed": convert.JSONToGoStructNamed,
+		"jsonToGraphQL":       convert.JSONToGraphQL,
+		"jsonToHCL":           convert.JSONToHCL,
+		"jsonToHTMLTable":     convert.JSONToHTMLTable,
+		"jsonToMarkdownTable": convert.JSONToMarkdownTable,
+		"jsonToProperties":    convert.JSONToProperties,
+		"jsonToProto":         convert.JSONToProto,
+		"jsonToQueryString":   convert.JSONToQueryString,
+		"jsonToReg":           convert.JSONToReg,
+		"jsonToSchema":        convert.JSONToSchema,
+		"jsonToTOML":          convert.JSONToTOML,
+		"jsonToYAML":          convert.JSONToYAML,
+
+		"markdownTOC":         convert.MarkdownTOC,
+		"markdownTableToJSON": convert.MarkdownTableToJSON,
+
+		"propertiesToJSON": convert.PropertiesToJSON,
+		"protobufToJSON":   convert.ProtoToJSON,
 
-		"protobufToJSON": convert.ProtoToJSON,
+		"queryStringToJSON": convert.QueryStringToJSON,
+
+		"regToJSON": convert.RegToJSON,
 
 		"schemaToGoStruct": convert.SchemaToGoStruct,
 		"schemaToJSON":     convert.SchemaToJSON,
@@ -46,33 +86,127 @@ func registerBindings(target js.Value) {
 		"yamlToGoStruct": convert.YAMLToGoStruct,
 		"yamlToJSON":     convert.YAMLToJSON,
 	}
+	for name, fn := range map[string]func(string) string{
+		"emojiToShortcode":  convert.EmojiToShortcode,
+		"headingAnchor":     convert.HeadingAnchor,
+		"shortcodeToEmoji":  convert.ShortcodeToEmoji,
+		"slugify":           convert.Slugify,
+		"stripControlChars": convert.StripControlChars,
+		"stripEmoji":        convert.StripEmoji,
+		"stripInvisible":    convert.StripInvisibleChars,
+		"stripProvenance":   convert.StripProvenance,
+	} {
+		bindings[name] = infallible(fn)
+	}
 	for name, fn := range bindings {
 		bind(target, name, fn)
 	}
 
 	target.Set("transformFormat", js.FuncOf(transformFormat))
+	target.Set("transformFormats", js.FuncOf(transformFormats))
 	target.Set("formatContent", js.FuncOf(formatContent))
+	target.Set("validateContent", js.FuncOf(validateContent))
+	target.Set("queryContent", js.FuncOf(queryContent))
 	target.Set("encodeContent", js.FuncOf(encodeContent))
 	target.Set("decodeContent", js.FuncOf(decodeContent))
 	target.Set("hashContent", js.FuncOf(hashContent))
+	target.Set("hmacContent", js.FuncOf(hmacContent))
 	target.Set("urlEncode", js.FuncOf(urlEncode))
 	target.Set("urlDecode", js.FuncOf(urlDecode))
 	target.Set("jwtEncode", js.FuncOf(jwtEncode))
 	target.Set("jwtDecode", js.FuncOf(jwtDecode))
+	target.Set("jwtVerify", js.FuncOf(jwtVerify))
+	target.Set("jwtVerifyJWKS", js.FuncOf(jwtVerifyJWKS))
+	target.Set("pasetoEncode", js.FuncOf(pasetoEncode))
+	target.Set("pasetoDecode", js.FuncOf(pasetoDecode))
+	target.Set("brancaEncode", js.FuncOf(brancaEncode))
+	target.Set("brancaDecode", js.FuncOf(brancaDecode))
+	target.Set("fernetDecode", js.FuncOf(fernetDecode))
+	target.Set("macaroonDecode", js.FuncOf(macaroonDecode))
 	target.Set("markdownToHTML", js.FuncOf(markdownToHTML))
 	target.Set("htmlToMarkdown", js.FuncOf(htmlToMarkdown))
+	target.Set("shiftMarkdownHeadings", js.FuncOf(shiftMarkdownHeadings))
+	target.Set("checkMarkdownLinks", js.FuncOf(checkMarkdownLinks))
+	target.Set("convertFrontMatter", js.FuncOf(convertFrontMatter))
+	target.Set("mergeFrontMatter", js.FuncOf(mergeFrontMatter))
 	target.Set("convertNumberBase", js.FuncOf(convertNumberBase))
 	target.Set("ipv4Info", js.FuncOf(ipv4Info))
+	target.Set("ipv6Info", js.FuncOf(ipv6Info))
+	target.Set("urlParse", js.FuncOf(urlParse))
+	target.Set("coordinateInfo", js.FuncOf(coordinateInfo))
+	target.Set("coordinateDistance", js.FuncOf(coordinateDistance))
+	target.Set("timezoneInfo", js.FuncOf(timezoneInfo))
+	target.Set("timeDiff", js.FuncOf(timeDiff))
+	target.Set("detectFormat", js.FuncOf(detectFormat))
+	target.Set("commitsToChangelog", js.FuncOf(commitsToChangelog))
+	target.Set("textFrequency", js.FuncOf(textFrequency))
 	target.Set("generateUUIDs", js.FuncOf(generateUUIDs))
 	target.Set("generateUserAgents", js.FuncOf(generateUserAgents))
 	target.Set("jsonToMsgPack", js.FuncOf(jsonToMsgPack))
 	target.Set("msgPackToJSON", js.FuncOf(msgPackToJSON))
 	target.Set("jsonToTOON", js.FuncOf(jsonToTOON))
 	target.Set("toonToJSON", js.FuncOf(toonToJSON))
+	target.Set("analyzePermissions", js.FuncOf(analyzePermissions))
+	target.Set("csvToJSON", js.FuncOf(csvToJSON))
+	target.Set("jsonToCSV", js.FuncOf(jsonToCSV))
+	target.Set("mapTable", js.FuncOf(mapTable))
+	target.Set("pivotTable", js.FuncOf(pivotTable))
+	target.Set("unpivotTable", js.FuncOf(unpivotTable))
+	target.Set("joinDocuments", js.FuncOf(joinDocuments))
+	target.Set("validateCSV", js.FuncOf(validateCSV))
+	target.Set("selectRecords", js.FuncOf(selectRecords))
+	target.Set("scanPII", js.FuncOf(scanPII))
+	target.Set("detectCharset", js.FuncOf(detectCharset))
+	target.Set("transcodeToUTF8", js.FuncOf(transcodeToUTF8))
+	target.Set("normalizeText", js.FuncOf(normalizeText))
+	target.Set("findInvisibleChars", js.FuncOf(findInvisibleChars))
+	target.Set("parseEmbeddedJSON", js.FuncOf(embeddedJSON(convert.ParseEmbeddedJSON)))
+	target.Set("stringifyEmbeddedJSON", js.FuncOf(embeddedJSON(convert.StringifyEmbeddedJSON)))
+	target.Set("validateAgainstSchema", js.FuncOf(validateAgainstSchema))
+	target.Set("splitDocuments", js.FuncOf(splitDocuments))
+	target.Set("structureOutline", js.FuncOf(structureOutline))
+	target.Set("replaceValues", js.FuncOf(replaceValues))
+	target.Set("listCapabilities", js.FuncOf(listCapabilities))
+	target.Set("mergeDocuments", js.FuncOf(mergeDocuments))
+	target.Set("applyPatch", js.FuncOf(applyPatch))
+	target.Set("diffPatch", js.FuncOf(diffPatch))
+	target.Set("diffDocuments", js.FuncOf(diffDocuments))
+	target.Set("flattenJSON", js.FuncOf(flattenOptions(convert.FlattenJSON)))
+	target.Set("unflattenJSON", js.FuncOf(flattenOptions(convert.UnflattenJSON)))
+	target.Set("interpolateEnv", js.FuncOf(interpolateEnv))
+	target.Set("extractPlaceholders", js.FuncOf(extractPlaceholders))
+	target.Set("timestampInfo", js.FuncOf(timestampInfo))
+	target.Set("resolveIncludes", js.FuncOf(resolveIncludes))
+	target.Set("listProfiles", js.FuncOf(listProfiles))
+	target.Set("colorInfo", js.FuncOf(colorInfo))
+	target.Set("caseConvert", js.FuncOf(caseConvert))
+	target.Set("selfTest", js.FuncOf(selfTest))
+	target.Set("metrics", js.FuncOf(metricsReport))
 }
 
 var boundHandlers []js.Func
 
+func infallible(fn func(string) string) converter {
+	return func(input string) (string, error) { return fn(input), nil }
+}
+
+// errorResult is {error: message}, with the position of a syntax error in
+// the input so the page can mark it.
+func errorResult(err error) map[string]any {
+	result := map[string]any{"error": err.Error()}
+	var syntaxErr *convert.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		result["position"] = map[string]any{
+			"format":  syntaxErr.Format,
+			"line":    syntaxErr.Line,
+			"column":  syntaxErr.Column,
+			"offset":  syntaxErr.Offset,
+			"snippet": syntaxErr.Snippet,
+		}
+	}
+	return result
+}
+
 func bind(target js.Value, name string, fn converter) {
 	handler := js.FuncOf(func(_ js.Value, args []js.Value) any {
 		if len(args) == 0 {
@@ -80,7 +214,7 @@ func bind(target js.Value, name string, fn converter) {
 		}
 		out, err := fn(args[0].String())
 		if err != nil {
-			return map[string]any{"error": err.Error()}
+			return errorResult(err)
 		}
 		return map[string]any{"result": out}
 	})
@@ -95,13 +229,152 @@ func transformFormat(_ js.Value, args []js.Value) any {
 	from := args[0].String()
 	to := args[1].String()
 	input := args[2].String()
-	out, err := convert.ConvertFormats(from, to, input)
+	var opts []convert.ConvertOption
+	if len(args) > 3 {
+		opts = convertOptionsFromJS(args[3])
+	}
+	out, err := convert.ConvertFormatsWithOptions(from, to, input, opts...)
 	if err != nil {
-		return map[string]any{"error": err.Error()}
+		return errorResult(err)
+	}
+	return map[string]any{"result": out}
+}
+
+// transformFormats converts input into every format of a targets array,
+// parsing it once: {result: {YAML: {output: "..."}, TOML: {error: "..."}}}.
+func transformFormats(_ js.Value, args []js.Value) any {
+	if len(args) < 3 || args[1].Type() != js.TypeObject {
+		return map[string]any{"error": "from, targets, input required"}
+	}
+	targets := make([]string, args[1].Length())
+	for i := range targets {
+		targets[i] = args[1].Index(i).String()
+	}
+	var opts []convert.ConvertOption
+	if len(args) > 3 {
+		opts = convertOptionsFromJS(args[3])
+	}
+	results, err := convert.ConvertToManyWithOptions(args[0].String(), targets, args[2].String(), opts...)
+	if err != nil {
+		return errorResult(err)
+	}
+	out := make(map[string]any, len(results))
+	for to, r := range results {
+		if r.Error != "" {
+			out[to] = map[string]any{"error": r.Error}
+		} else {
+			out[to] = map[string]any{"output": r.Output}
+		}
 	}
 	return map[string]any{"result": out}
 }
 
+// convertOptionsFromJS reads an options object such as
+// {indent: 4, sortKeys: true, tagCase: "snake", omitEmpty: true}.
+func convertOptionsFromJS(v js.Value) []convert.ConvertOption {
+	if v.Type() != js.TypeObject {
+		return nil
+	}
+	var opts []convert.ConvertOption
+	if f := v.Get("indent"); f.Type() == js.TypeNumber {
+		opts = append(opts, convert.WithIndent(f.Int()))
+	}
+	if f := v.Get("sortKeys"); f.Type() == js.TypeBoolean {
+		opts = append(opts, convert.WithSortKeys(f.Bool()))
+	}
+	if f := v.Get("tagCase"); f.Type() == js.TypeString {
+		opts = append(opts, convert.WithTagCase(f.String()))
+	}
+	if f := v.Get("pointerFields"); f.Type() == js.TypeBoolean {
+		opts = append(opts, convert.WithPointerFields(f.Bool()))
+	}
+	if f := v.Get("omitEmpty"); f.Type() == js.TypeBoolean {
+		opts = append(opts, convert.WithOmitEmpty(f.Bool()))
+	}
+	if f := v.Get("extraTags"); f.Type() == js.TypeObject {
+		tags := map[string]string{}
+		keys := js.Global().Get("Object").Call("keys", f)
+		for i := 0; i < keys.Length(); i++ {
+			name := keys.Index(i).String()
+			tags[name] = f.Get(name).String()
+		}
+		opts = append(opts, convert.WithExtraTags(tags))
+	}
+	if f := v.Get("numberType"); f.Type() == js.TypeString {
+		opts = append(opts, convert.WithNumberType(f.String()))
+	}
+	if f := v.Get("namedStructs"); f.Type() == js.TypeBoolean {
+		opts = append(opts, convert.WithNamedStructs(f.Bool()))
+	}
+	if f := v.Get("semanticTypes"); f.Type() == js.TypeBoolean {
+		opts = append(opts, convert.WithSemanticTypes(f.Bool()))
+	}
+	if f := v.Get("graphqlInputs"); f.Type() == js.TypeBoolean {
+		opts = append(opts, convert.WithGraphQLInputs(f.Bool()))
+	}
+	if f := v.Get("graphqlOperations"); f.Type() == js.TypeBoolean {
+		opts = append(opts, convert.WithGraphQLOperations(f.Bool()))
+	}
+	if f := v.Get("protoService"); f.Type() == js.TypeBoolean {
+		opts = append(opts, convert.WithProtoService(f.Bool()))
+	}
+	if f := v.Get("protoHttp"); f.Type() == js.TypeBoolean {
+		opts = append(opts, convert.WithProtoHTTP(f.Bool()))
+	}
+	if f := v.Get("schemaDraft"); f.Type() == js.TypeString {
+		opts = append(opts, convert.WithSchemaDraft(f.String()))
+	}
+	if f := v.Get("schemaFormats"); f.Type() == js.TypeBoolean {
+		opts = append(opts, convert.WithSchemaFormats(f.Bool()))
+	}
+	if f := v.Get("sqlDialect"); f.Type() == js.TypeString {
+		opts = append(opts, convert.WithSQLDialect(f.String()))
+	}
+	if f := v.Get("yamlDocuments"); f.Type() == js.TypeString {
+		opts = append(opts, convert.WithYAMLDocuments(f.String()))
+	}
+	if f := v.Get("yamlAliases"); f.Type() == js.TypeString {
+		opts = append(opts, convert.WithYAMLAliases(f.String()))
+	}
+	if f := v.Get("yamlAliasLimit"); f.Type() == js.TypeNumber {
+		opts = append(opts, convert.WithYAMLAliasLimit(f.Int()))
+	}
+	if f := v.Get("xmlDeclaration"); f.Type() == js.TypeString {
+		opts = append(opts, convert.WithXMLDeclaration(f.String()))
+	}
+	if f := v.Get("xmlStandalone"); f.Type() == js.TypeString {
+		opts = append(opts, convert.WithXMLStandalone(f.String()))
+	}
+	if f := v.Get("xmlWrapAttributes"); f.Type() == js.TypeNumber {
+		opts = append(opts, convert.WithXMLWrapAttributes(f.Int()))
+	}
+	if f := v.Get("tomlDatetimes"); f.Type() == js.TypeString {
+		opts = append(opts, convert.WithTOMLDatetimes(f.String()))
+	}
+	if f := v.Get("toonDelimiter"); f.Type() == js.TypeString {
+		opts = append(opts, convert.WithTOONDelimiter(f.String()))
+	}
+	if f := v.Get("toonKeyFolding"); f.Type() == js.TypeBoolean {
+		opts = append(opts, convert.WithTOONKeyFolding(f.Bool()))
+	}
+	if f := v.Get("multilineArrays"); f.Type() == js.TypeBoolean {
+		opts = append(opts, convert.WithMultilineArrays(f.Bool()))
+	}
+	if f := v.Get("finalNewline"); f.Type() == js.TypeBoolean {
+		opts = append(opts, convert.WithFinalNewline(f.Bool()))
+	}
+	if f := v.Get("script"); f.Type() == js.TypeString {
+		opts = append(opts, convert.WithScript(f.String()))
+	}
+	if f := v.Get("provenance"); f.Type() == js.TypeBoolean {
+		opts = append(opts, convert.WithProvenance(f.Bool()))
+	}
+	if f := v.Get("profile"); f.Type() == js.TypeString {
+		opts = append(opts, convert.WithProfile(f.String()))
+	}
+	return opts
+}
+
 func formatContent(_ js.Value, args []js.Value) any {
 	if len(args) < 3 {
 		return map[string]any{"error": "format, input, minify required"}
@@ -109,9 +382,38 @@ func formatContent(_ js.Value, args []js.Value) any {
 	formatName := args[0].String()
 	input := args[1].String()
 	minify := args[2].Bool()
-	out, err := convert.FormatContent(formatName, input, minify)
+	var opts []convert.ConvertOption
+	if len(args) > 3 {
+		opts = convertOptionsFromJS(args[3])
+	}
+	out, err := convert.FormatContentWithOptions(formatName, input, minify, opts...)
 	if err != nil {
-		return map[string]any{"error": err.Error()}
+		return errorResult(err)
+	}
+	return map[string]any{"result": out}
+}
+
+// validateContent checks input without converting it: {result: true}, or
+// the error with its position.
+func validateContent(_ js.Value, args []js.Value) any {
+	if len(args) < 2 {
+		return map[string]any{"error": "format and input required"}
+	}
+	if err := convert.Validate(args[0].String(), args[1].String()); err != nil {
+		return errorResult(err)
+	}
+	return map[string]any{"result": true}
+}
+
+// queryContent selects values from a document with a JSONPath or jq-style
+// expression: {result: "[...]"}, a JSON array of the matches.
+func queryContent(_ js.Value, args []js.Value) any {
+	if len(args) < 2 {
+		return map[string]any{"error": "input and expression required"}
+	}
+	out, err := convert.QueryJSON(args[0].String(), args[1].String())
+	if err != nil {
+		return errorResult(err)
 	}
 	return map[string]any{"result": out}
 }
@@ -146,6 +448,23 @@ func hashContent(_ js.Value, args []js.Value) any {
 	return map[string]any{"result": stringMapToAny(out)}
 }
 
+// hmacContent takes (input, key, algorithm?) and returns hex and base64
+// digests; algorithm defaults to sha256.
+func hmacContent(_ js.Value, args []js.Value) any {
+	if len(args) < 2 {
+		return map[string]any{"error": "input and key required"}
+	}
+	algorithm := ""
+	if len(args) > 2 {
+		algorithm = args[2].String()
+	}
+	res, err := code.HMACContent(args[0].String(), args[1].String(), algorithm)
+	if err != nil {
+		return map[string]any{"error": err.Error()}
+	}
+	return map[string]any{"result": jsonValue(res)}
+}
+
 func urlEncode(_ js.Value, args []js.Value) any {
 	if len(args) == 0 {
 		return map[string]any{"error": "missing input"}
@@ -191,6 +510,143 @@ func jwtDecode(_ js.Value, args []js.Value) any {
 	}}
 }
 
+// jwtVerify takes (token, key, algorithm?, leewaySeconds?) and checks the
+// signature against an HMAC secret or PEM public key, then the claims.
+func jwtVerify(_ js.Value, args []js.Value) any {
+	if len(args) < 2 {
+		return map[string]any{"error": "token and key required"}
+	}
+	var algorithm string
+	if len(args) > 2 && args[2].Type() == js.TypeString {
+		algorithm = args[2].String()
+	}
+	res, err := code.JWTVerifyWithLeeway(args[0].String(), args[1].String(), algorithm, leewayArg(args, 3))
+	return jwtVerificationResult(res, err)
+}
+
+// jwtVerifyJWKS takes (token, jwks, leewaySeconds?) and checks the
+// signature against the JSON Web Key Set, then the claims.
+func jwtVerifyJWKS(_ js.Value, args []js.Value) any {
+	if len(args) < 2 {
+		return map[string]any{"error": "token and jwks required"}
+	}
+	res, err := code.JWTVerifyJWKSWithLeeway(args[0].String(), args[1].String(), leewayArg(args, 2))
+	return jwtVerificationResult(res, err)
+}
+
+// leewayArg reads an optional clock skew allowance in seconds at index i.
+func leewayArg(args []js.Value, i int) time.Duration {
+	if len(args) > i && args[i].Type() == js.TypeNumber {
+		return time.Duration(args[i].Float() * float64(time.Second))
+	}
+	return 0
+}
+
+func jwtVerificationResult(res code.JWTVerification, err error) any {
+	if err != nil {
+		return map[string]any{"error": err.Error()}
+	}
+	return map[string]any{"result": map[string]any{
+		"valid":     res.Valid,
+		"algorithm": res.Algorithm,
+		"kid":       res.KeyID,
+		"header":    res.Header,
+		"payload":   res.Payload,
+		"reason":    res.Reason,
+		"exp":       res.ExpiresAt,
+		"nbf":       res.NotBefore,
+		"iat":       res.IssuedAt,
+	}}
+}
+
+func pasetoEncode(_ js.Value, args []js.Value) any {
+	if len(args) < 4 {
+		return map[string]any{"error": "version, purpose, payload, key required"}
+	}
+	var footer string
+	if len(args) > 4 {
+		footer = args[4].String()
+	}
+	token, err := code.PASETOEncode(args[0].String(), args[1].String(), args[2].String(), args[3].String(), footer)
+	if err != nil {
+		return map[string]any{"error": err.Error()}
+	}
+	return map[string]any{"result": map[string]any{"token": token}}
+}
+
+func pasetoDecode(_ js.Value, args []js.Value) any {
+	if len(args) == 0 {
+		return map[string]any{"error": "token required"}
+	}
+	var key string
+	if len(args) > 1 {
+		key = args[1].String()
+	}
+	info, err := code.PASETODecode(args[0].String(), key)
+	if err != nil {
+		return map[string]any{"error": err.Error()}
+	}
+	return map[string]any{"result": map[string]any{
+		"version":  info.Version,
+		"purpose":  info.Purpose,
+		"payload":  info.Payload,
+		"footer":   info.Footer,
+		"verified": info.Verified,
+	}}
+}
+
+func brancaEncode(_ js.Value, args []js.Value) any {
+	if len(args) < 2 {
+		return map[string]any{"error": "payload and key required"}
+	}
+	token, err := code.BrancaEncode(args[0].String(), args[1].String())
+	if err != nil {
+		return map[string]any{"error": err.Error()}
+	}
+	return map[string]any{"result": map[string]any{"token": token}}
+}
+
+func brancaDecode(_ js.Value, args []js.Value) any {
+	if len(args) < 2 {
+		return map[string]any{"error": "token and key required"}
+	}
+	info, err := code.BrancaDecode(args[0].String(), args[1].String())
+	if err != nil {
+		return map[string]any{"error": err.Error()}
+	}
+	return map[string]any{"result": map[string]any{
+		"payload":   info.Payload,
+		"timestamp": info.Timestamp,
+		"unix":      info.Unix,
+	}}
+}
+
+func fernetDecode(_ js.Value, args []js.Value) any {
+	if len(args) == 0 {
+		return map[string]any{"error": "token required"}
+	}
+	var key string
+	if len(args) > 1 {
+		key = args[1].String()
+	}
+	info, err := code.FernetDecode(args[0].String(), key)
+	if err != nil {
+		return map[string]any{"error": err.Error()}
+	}
+	return map[string]any{"result": jsonValue(info)}
+}
+
+func macaroonDecode(_ js.Value, args []js.Value) any {
+	if len(args) == 0 {
+		return map[string]any{"error": "missing input"}
+	}
+	info, err := code.MacaroonDecode(args[0].String())
+	if err != nil {
+		return map[string]any{"error": err.Error()}
+	}
+	return map[string]any{"result": jsonValue(info)}
+}
+
 func markdownToHTML(_ js.Value, args []js.Value) any {
 	if len(args) == 0 {
 		return map[string]any{"error": "missing input"}
@@ -213,6 +669,50 @@ func htmlToMarkdown(_ js.Value, args []js.Value) any {
 	return map[string]any{"result": out}
 }
 
+func shiftMarkdownHeadings(_ js.Value, args []js.Value) any {
+	if len(args) < 2 {
+		return map[string]any{"error": "input and delta required"}
+	}
+	out, err := convert.ShiftMarkdownHeadings(args[0].String(), args[1].Int())
+	if err != nil {
+		return map[string]any{"error": err.Error()}
+	}
+	return map[string]any{"result": out}
+}
+
+func checkMarkdownLinks(_ js.Value, args []js.Value) any {
+	if len(args) == 0 {
+		return map[string]any{"error": "missing input"}
+	}
+	report, err := convert.CheckMarkdownLinks(args[0].String())
+	if err != nil {
+		return map[string]any{"error": err.Error()}
+	}
+	return map[string]any{"result": jsonValue(report)}
+}
+
+func convertFrontMatter(_ js.Value, args []js.Value) any {
+	if len(args) < 2 {
+		return map[string]any{"error": "input and format required"}
+	}
+	out, err := convert.ConvertFrontMatter(args[0].String(), args[1].String())
+	if err != nil {
+		return map[string]any{"error": err.Error()}
+	}
+	return map[string]any{"result": out}
+}
+
+func mergeFrontMatter(_ js.Value, args []js.Value) any {
+	if len(args) < 2 {
+		return map[string]any{"error": "input and front matter required"}
+	}
+	out, err := convert.MergeFrontMatter(args[0].String(), args[1].String())
+	if err != nil {
+		return map[string]any{"error": err.Error()}
+	}
+	return map[string]any{"result": out}
+}
+
 func convertNumberBase(_ js.Value, args []js.Value) any {
 	if len(args) < 2 {
 		return map[string]any{"error": "base and value required"}
@@ -252,89 +752,762 @@ func ipv4Info(_ js.Value, args []js.Value) any {
 	}}
 }
 
-func generateUUIDs(_ js.Value, _ []js.Value) any {
-	result, err := generate.GenerateUUIDs()
+func ipv6Info(_ js.Value, args []js.Value) any {
+	if len(args) == 0 {
+		return map[string]any{"error": "input required"}
+	}
+	info, err := convert.IPv6Info(args[0].String())
 	if err != nil {
 		return map[string]any{"error": err.Error()}
 	}
-	return map[string]any{"result": stringMapToAny(result)}
+	return map[string]any{"result": jsonValue(info)}
 }
 
-func generateUserAgents(_ js.Value, args []js.Value) any {
-	var browser, os string
-	if len(args) > 0 {
-		browser = args[0].String()
+func urlParse(_ js.Value, args []js.Value) any {
+	if len(args) == 0 {
+		return map[string]any{"error": "input required"}
 	}
-	if len(args) > 1 {
-		os = args[1].String()
+	info, err := convert.ParseURL(args[0].String())
+	if err != nil {
+		return map[string]any{"error": err.Error()}
 	}
-	result, err := generate.GenerateUserAgents(browser, os)
+	return map[string]any{"result": jsonValue(info)}
+}
+
+func coordinateInfo(_ js.Value, args []js.Value) any {
+	if len(args) == 0 {
+		return map[string]any{"error": "input required"}
+	}
+	info, err := convert.CoordinateInfo(args[0].String())
 	if err != nil {
 		return map[string]any{"error": err.Error()}
 	}
-	entries := make([]any, len(result))
-	for i, ua := range result {
-		entries[i] = map[string]any{
-			"userAgent":      ua.UserAgent,
-			"browserName":    ua.BrowserName,
-			"browserVersion": ua.BrowserVersion,
-			"osName":         ua.OSName,
-			"osVersion":      ua.OSVersion,
-			"engineName":     ua.EngineName,
-			"engineVersion":  ua.EngineVersion,
-		}
+	return map[string]any{"result": jsonValue(info)}
+}
+
+func coordinateDistance(_ js.Value, args []js.Value) any {
+	if len(args) < 2 {
+		return map[string]any{"error": "from and to required"}
 	}
-	return map[string]any{"result": entries}
+	res, err := convert.CoordinateDistance(args[0].String(), args[1].String())
+	if err != nil {
+		return map[string]any{"error": err.Error()}
+	}
+	return map[string]any{"result": jsonValue(res)}
 }
 
-func jsonToMsgPack(_ js.Value, args []js.Value) any {
+func timezoneInfo(_ js.Value, args []js.Value) any {
 	if len(args) == 0 {
-		return map[string]any{"error": "missing input"}
+		return map[string]any{"error": "zone required"}
 	}
-	out, err := convert.JSONToMsgPack(args[0].String())
+	var timestamp string
+	if len(args) > 1 && args[1].Type() == js.TypeString {
+		timestamp = args[1].String()
+	}
+	var targets []string
+	if len(args) > 2 && args[2].Type() == js.TypeObject {
+		for i := 0; i < args[2].Length(); i++ {
+			targets = append(targets, args[2].Index(i).String())
+		}
+	}
+	res, err := convert.TimezoneInfo(args[0].String(), timestamp, targets)
 	if err != nil {
 		return map[string]any{"error": err.Error()}
 	}
-	return map[string]any{"result": out}
+	return map[string]any{"result": jsonValue(res)}
 }
 
-func msgPackToJSON(_ js.Value, args []js.Value) any {
-	if len(args) == 0 {
-		return map[string]any{"error": "missing input"}
+func timeDiff(_ js.Value, args []js.Value) any {
+	if len(args) < 2 {
+		return map[string]any{"error": "from and to required"}
 	}
-	out, err := convert.MsgPackToJSON(args[0].String())
+	res, err := convert.TimeDiff(args[0].String(), args[1].String())
 	if err != nil {
 		return map[string]any{"error": err.Error()}
 	}
-	return map[string]any{"result": out}
+	return map[string]any{"result": jsonValue(res)}
 }
 
-func jsonToTOON(_ js.Value, args []js.Value) any {
+func detectFormat(_ js.Value, args []js.Value) any {
 	if len(args) == 0 {
-		return map[string]any{"error": "missing input"}
+		return map[string]any{"error": "input required"}
 	}
-	out, err := convert.JSONToTOON(args[0].String())
+	input := args[0].String()
+	format, confidence, err := convert.DetectFormat(input)
 	if err != nil {
 		return map[string]any{"error": err.Error()}
 	}
-	return map[string]any{"result": out}
+	return map[string]any{"result": map[string]any{
+		"format":     format,
+		"confidence": confidence,
+		"candidates": jsonValue(convert.DetectFormatCandidates(input)),
+	}}
 }
 
-func toonToJSON(_ js.Value, args []js.Value) any {
+func commitsToChangelog(_ js.Value, args []js.Value) any {
 	if len(args) == 0 {
 		return map[string]any{"error": "missing input"}
 	}
-	out, err := convert.TOONToJSON(args[0].String())
+	version := ""
+	if len(args) > 1 && args[1].Type() == js.TypeString {
+		version = args[1].String()
+	}
+	out, err := convert.ConventionalCommitsToChangelog(args[0].String(), version)
 	if err != nil {
 		return map[string]any{"error": err.Error()}
 	}
 	return map[string]any{"result": out}
 }
 
-func stringMapToAny(in map[string]string) map[string]any {
-	result := make(map[string]any, len(in))
+// textFrequency takes (input, top?, format?) where format "csv" returns CSV
+// text instead of the JSON summary.
+func textFrequency(_ js.Value, args []js.Value) any {
+	if len(args) == 0 {
+		return map[string]any{"error": "missing input"}
+	}
+	top := 0
+	if len(args) > 1 && args[1].Type() == js.TypeNumber {
+		top = args[1].Int()
+	}
+	if len(args) > 2 && args[2].Type() == js.TypeString && strings.EqualFold(args[2].String(), "csv") {
+		out, err := convert.TextFrequencyCSV(args[0].String(), top)
+		if err != nil {
+			return map[string]any{"error": err.Error()}
+		}
+		return map[string]any{"result": out}
+	}
+	res, err := convert.TextFrequency(args[0].String(), top)
+	if err != nil {
+		return map[string]any{"error": err.Error()}
+	}
+	return map[string]any{"result": jsonValue(res)}
+}
+
+// generateUUIDs takes (namespace?, name?); when name is given, v3 and v5
+// are derived from it instead of a random name.
+func generateUUIDs(_ js.Value, args []js.Value) any {
+	result, err := generate.GenerateUUIDs()
+	if err != nil {
+		return map[string]any{"error": err.Error()}
+	}
+	if len(args) > 1 && args[1].String() != "" {
+		namespace := args[0].String()
+		if namespace == "" {
+			namespace = "DNS"
+		}
+		for _, version := range []int{3, 5} {
+			id, err := generate.GenerateNameBasedUUID(namespace, args[1].String(), version)
+			if err != nil {
+				return map[string]any{"error": err.Error()}
+			}
+			result[fmt.Sprintf("v%d", version)] = id
+		}
+	}
+	return map[string]any{"result": stringMapToAny(result)}
+}
+
+func generateUserAgents(_ js.Value, args []js.Value) any {
+	var browser, os string
+	if len(args) > 0 {
+		browser = args[0].String()
+	}
+	if len(args) > 1 {
+		os = args[1].String()
+	}
+	result, err := generate.GenerateUserAgents(browser, os)
+	if err != nil {
+		return map[string]any{"error": err.Error()}
+	}
+	entries := make([]any, len(result))
+	for i, ua := range result {
+		entries[i] = map[string]any{
+			"userAgent":      ua.UserAgent,
+			"browserName":    ua.BrowserName,
+			"browserVersion": ua.BrowserVersion,
+			"osName":         ua.OSName,
+			"osVersion":      ua.OSVersion,
+			"engineName":     ua.EngineName,
+			"engineVersion":  ua.EngineVersion,
+		}
+	}
+	return map[string]any{"result": entries}
+}
+
+func jsonToMsgPack(_ js.Value, args []js.Value) any {
+	if len(args) == 0 {
+		return map[string]any{"error": "missing input"}
+	}
+	out, err := convert.JSONToMsgPack(args[0].String())
+	if err != nil {
+		return map[string]any{"error": err.Error()}
+	}
+	return map[string]any{"result": out}
+}
+
+func msgPackToJSON(_ js.Value, args []js.Value) any {
+	if len(args) == 0 {
+		return map[string]any{"error": "missing input"}
+	}
+	out, err := convert.MsgPackToJSON(args[0].String())
+	if err != nil {
+		return map[string]any{"error": err.Error()}
+	}
+	return map[string]any{"result": out}
+}
+
+func jsonToTOON(_ js.Value, args []js.Value) any {
+	if len(args) == 0 {
+		return map[string]any{"error": "missing input"}
+	}
+	out, err := convert.JSONToTOON(args[0].String())
+	if err != nil {
+		return map[string]any{"error": err.Error()}
+	}
+	return map[string]any{"result": out}
+}
+
+func toonToJSON(_ js.Value, args []js.Value) any {
+	if len(args) == 0 {
+		return map[string]any{"error": "missing input"}
+	}
+	out, err := convert.TOONToJSON(args[0].String())
+	if err != nil {
+		return map[string]any{"error": err.Error()}
+	}
+	return map[string]any{"result": out}
+}
+
+func csvOptionsFromArgs(args []js.Value) convert.CSVOptions {
+	opts := convert.CSVOptions{Header: convert.CSVHeaderAuto}
+	if len(args) > 1 {
+		if delim := []rune(args[1].String()); len(delim) > 0 {
+			opts.Delimiter = delim[0]
+		}
+	}
+	if len(args) > 2 && args[2].String() != "" {
+		opts.Header = args[2].String()
+	}
+	return opts
+}
+
+func csvToJSON(_ js.Value, args []js.Value) any {
+	if len(args) == 0 {
+		return map[string]any{"error": "missing input"}
+	}
+	out, err := convert.CSVToJSONWithOptions(args[0].String(), csvOptionsFromArgs(args))
+	if err != nil {
+		return map[string]any{"error": err.Error()}
+	}
+	return map[string]any{"result": out}
+}
+
+func jsonToCSV(_ js.Value, args []js.Value) any {
+	if len(args) == 0 {
+		return map[string]any{"error": "missing input"}
+	}
+	opts := csvOptionsFromArgs(args)
+	if opts.Header == convert.CSVHeaderAuto {
+		opts.Header = convert.CSVHeaderYes
+	}
+	out, err := convert.JSONToCSVWithOptions(args[0].String(), opts)
+	if err != nil {
+		return map[string]any{"error": err.Error()}
+	}
+	return map[string]any{"result": out}
+}
+
+// mapTable takes (from, to, input, spec, delimiter?) and applies a column
+// mapping spec while converting between "csv" and "json".
+func mapTable(_ js.Value, args []js.Value) any {
+	if len(args) < 4 {
+		return map[string]any{"error": "from, to, input and spec required"}
+	}
+	var opts convert.CSVOptions
+	if len(args) > 4 {
+		if delim := []rune(args[4].String()); len(delim) > 0 {
+			opts.Delimiter = delim[0]
+		}
+	}
+	input, spec := args[2].String(), args[3].String()
+	var (
+		out string
+		err error
+	)
+	switch strings.ToLower(args[0].String()) + ">" + strings.ToLower(args[1].String()) {
+	case "csv>csv":
+		out, err = convert.MapCSV(input, spec, opts)
+	case "csv>json":
+		out, err = convert.MapCSVToJSON(input, spec, opts)
+	case "json>csv":
+		out, err = convert.MapJSONToCSV(input, spec, opts)
+	default:
+		err = fmt.Errorf("unsupported mapping %s to %s", args[0].String(), args[1].String())
+	}
+	if err != nil {
+		return map[string]any{"error": err.Error()}
+	}
+	return map[string]any{"result": out}
+}
+
+// pivotTable takes (format, input, options) where format is "csv" or
+// "json" and options is a JSON PivotOptions object.
+func pivotTable(_ js.Value, args []js.Value) any {
+	if len(args) < 3 {
+		return map[string]any{"error": "format, input and options required"}
+	}
+	var opts convert.PivotOptions
+	if err := json.Unmarshal([]byte(args[2].String()), &opts); err != nil {
+		return map[string]any{"error": err.Error()}
+	}
+	var (
+		out string
+		err error
+	)
+	if strings.EqualFold(args[0].String(), "csv") {
+		out, err = convert.PivotCSV(args[1].String(), opts, convert.CSVOptions{})
+	} else {
+		out, err = convert.PivotJSON(args[1].String(), opts)
+	}
+	if err != nil {
+		return map[string]any{"error": err.Error()}
+	}
+	return map[string]any{"result": out}
+}
+
+// unpivotTable takes (format, input, options?) with a JSON UnpivotOptions
+// object.
+func unpivotTable(_ js.Value, args []js.Value) any {
+	if len(args) < 2 {
+		return map[string]any{"error": "format and input required"}
+	}
+	var opts convert.UnpivotOptions
+	if len(args) > 2 && strings.TrimSpace(args[2].String()) != "" {
+		if err := json.Unmarshal([]byte(args[2].String()), &opts); err != nil {
+			return map[string]any{"error": err.Error()}
+		}
+	}
+	var (
+		out string
+		err error
+	)
+	if strings.EqualFold(args[0].String(), "csv") {
+		out, err = convert.UnpivotCSV(args[1].String(), opts, convert.CSVOptions{})
+	} else {
+		out, err = convert.UnpivotJSON(args[1].String(), opts)
+	}
+	if err != nil {
+		return map[string]any{"error": err.Error()}
+	}
+	return map[string]any{"result": out}
+}
+
+// joinDocuments takes (left, right, leftPath, rightPath?, joinType?).
+func joinDocuments(_ js.Value, args []js.Value) any {
+	if len(args) < 3 {
+		return map[string]any{"error": "left, right and join path required"}
+	}
+	rightPath, joinType := "", ""
+	if len(args) > 3 {
+		rightPath = args[3].String()
+	}
+	if len(args) > 4 {
+		joinType = args[4].String()
+	}
+	out, err := convert.JoinDocuments(args[0].String(), args[1].String(), args[2].String(), rightPath, joinType)
+	if err != nil {
+		return map[string]any{"error": err.Error()}
+	}
+	return map[string]any{"result": out}
+}
+
+func validateCSV(_ js.Value, args []js.Value) any {
+	if len(args) < 2 {
+		return map[string]any{"error": "csv and schema required"}
+	}
+	report, err := convert.ValidateCSV(args[0].String(), args[1].String())
+	if err != nil {
+		return map[string]any{"error": err.Error()}
+	}
+	return map[string]any{"result": jsonValue(report)}
+}
+
+// selectRecords takes (format, input, mode, a, b?) and returns part of a
+// top-level array in the same format: head/tail take a count, sample takes
+// a count and seed, page takes a page number and size.
+func selectRecords(_ js.Value, args []js.Value) any {
+	if len(args) < 4 {
+		return map[string]any{"error": "format, input, mode and count required"}
+	}
+	a, b := args[3].Int(), 0
+	if len(args) > 4 && args[4].Type() == js.TypeNumber {
+		b = args[4].Int()
+	}
+	var sel convert.Selection
+	switch strings.ToLower(args[2].String()) {
+	case "head":
+		sel = convert.Head(a)
+	case "tail":
+		sel = convert.Tail(a)
+	case "sample":
+		sel = convert.Sample(a, uint64(b))
+	case "page":
+		sel = convert.Paginate(a, b)
+	default:
+		return map[string]any{"error": "mode must be head, tail, sample or page"}
+	}
+	format := args[0].String()
+	out, err := convert.SelectRecords(format, format, args[1].String(), sel)
+	if err != nil {
+		return map[string]any{"error": err.Error()}
+	}
+	return map[string]any{"result": out}
+}
+
+func scanPII(_ js.Value, args []js.Value) any {
+	if len(args) < 1 {
+		return map[string]any{"error": "input required"}
+	}
+	report, err := convert.ScanPII(args[0].String())
+	if err != nil {
+		return map[string]any{"error": err.Error()}
+	}
+	return map[string]any{"result": jsonValue(report)}
+}
+
+// jsBytes copies a Uint8Array argument into Go.
+func jsBytes(v js.Value) []byte {
+	data := make([]byte, v.Get("length").Int())
+	js.CopyBytesToGo(data, v)
+	return data
+}
+
+func detectCharset(_ js.Value, args []js.Value) any {
+	if len(args) < 1 {
+		return map[string]any{"error": "bytes required"}
+	}
+	return map[string]any{"result": jsonValue(code.DetectCharset(jsBytes(args[0])))}
+}
+
+// transcodeToUTF8 takes a Uint8Array (for example a file read with
+// arrayBuffer) and returns {text, charset}.
+func transcodeToUTF8(_ js.Value, args []js.Value) any {
+	if len(args) < 1 {
+		return map[string]any{"error": "bytes required"}
+	}
+	text, charset, err := code.TranscodeToUTF8(jsBytes(args[0]))
+	if err != nil {
+		return map[string]any{"error": err.Error()}
+	}
+	return map[string]any{"result": map[string]any{"text": text, "charset": jsonValue(charset)}}
+}
+
+// normalizeText takes (input, options?) with a JSON NormalizeOptions object.
+func normalizeText(_ js.Value, args []js.Value) any {
+	if len(args) < 1 {
+		return map[string]any{"error": "input required"}
+	}
+	var opts convert.NormalizeOptions
+	if len(args) > 1 && strings.TrimSpace(args[1].String()) != "" {
+		if err := json.Unmarshal([]byte(args[1].String()), &opts); err != nil {
+			return map[string]any{"error": err.Error()}
+		}
+	}
+	res, err := convert.NormalizeText(args[0].String(), opts)
+	if err != nil {
+		return map[string]any{"error": err.Error()}
+	}
+	return map[string]any{"result": jsonValue(res)}
+}
+
+func findInvisibleChars(_ js.Value, args []js.Value) any {
+	if len(args) < 1 {
+		return map[string]any{"error": "input required"}
+	}
+	return map[string]any{"result": jsonValue(convert.FindInvisibleChars(args[0].String()))}
+}
+
+// embeddedJSON adapts the embedded JSON helpers to (input, depth?) calls.
+func embeddedJSON(fn func(string, int) (string, error)) func(js.Value, []js.Value) any {
+	return func(_ js.Value, args []js.Value) any {
+		if len(args) < 1 {
+			return map[string]any{"error": "input required"}
+		}
+		depth := 0
+		if len(args) > 1 && args[1].Type() == js.TypeNumber {
+			depth = args[1].Int()
+		}
+		out, err := fn(args[0].String(), depth)
+		if err != nil {
+			return map[string]any{"error": err.Error()}
+		}
+		return map[string]any{"result": out}
+	}
+}
+
+func validateAgainstSchema(_ js.Value, args []js.Value) any {
+	if len(args) < 2 {
+		return map[string]any{"error": "schema and document required"}
+	}
+	errs, err := convert.ValidateAgainstSchema(args[0].String(), args[1].String())
+	if err != nil {
+		return map[string]any{"error": err.Error()}
+	}
+	return map[string]any{"result": jsonValue(errs)}
+}
+
+func splitDocuments(_ js.Value, args []js.Value) any {
+	if len(args) < 1 {
+		return map[string]any{"error": "input required"}
+	}
+	segments, err := convert.SplitDocuments(args[0].String())
+	if err != nil {
+		return map[string]any{"error": err.Error()}
+	}
+	return map[string]any{"result": jsonValue(segments)}
+}
+
+func structureOutline(_ js.Value, args []js.Value) any {
+	if len(args) < 1 {
+		return map[string]any{"error": "input required"}
+	}
+	outline, err := convert.StructureOutline(args[0].String())
+	if err != nil {
+		return map[string]any{"error": err.Error()}
+	}
+	return map[string]any{"result": jsonValue(outline)}
+}
+
+// replaceValues takes (input, options) with a JSON ReplaceOptions object.
+func replaceValues(_ js.Value, args []js.Value) any {
+	if len(args) < 2 {
+		return map[string]any{"error": "input and options required"}
+	}
+	var opts convert.ReplaceOptions
+	if err := json.Unmarshal([]byte(args[1].String()), &opts); err != nil {
+		return map[string]any{"error": err.Error()}
+	}
+	res, err := convert.ReplaceValues(args[0].String(), opts)
+	if err != nil {
+		return map[string]any{"error": err.Error()}
+	}
+	return map[string]any{"result": jsonValue(res)}
+}
+
+func listCapabilities(_ js.Value, _ []js.Value) any {
+	return map[string]any{"result": jsonValue(convert.ListFormats())}
+}
+
+// mergeDocuments takes (strategy, to, docs) where docs is an array of
+// document strings and to the output format name.
+func mergeDocuments(_ js.Value, args []js.Value) any {
+	if len(args) < 3 || args[2].Type() != js.TypeObject {
+		return map[string]any{"error": "strategy, to and docs required"}
+	}
+	docs := make([]string, args[2].Length())
+	for i := range docs {
+		docs[i] = args[2].Index(i).String()
+	}
+	out, err := convert.MergeDocumentsTo(args[0].String(), args[1].String(), docs...)
+	if err != nil {
+		return map[string]any{"error": err.Error()}
+	}
+	return map[string]any{"result": out}
+}
+
+// applyPatch takes (kind, doc, patch), kind "json-patch" or "merge-patch".
+func applyPatch(_ js.Value, args []js.Value) any {
+	if len(args) < 3 {
+		return map[string]any{"error": "kind, doc and patch required"}
+	}
+	out, err := convert.ApplyPatch(args[0].String(), args[1].String(), args[2].String())
+	if err != nil {
+		return errorResult(err)
+	}
+	return map[string]any{"result": out}
+}
+
+// diffPatch takes (kind, from, to) and returns the patch from one to the
+// other.
+func diffPatch(_ js.Value, args []js.Value) any {
+	if len(args) < 3 {
+		return map[string]any{"error": "kind, from and to required"}
+	}
+	out, err := convert.DiffPatch(args[0].String(), args[1].String(), args[2].String())
+	if err != nil {
+		return errorResult(err)
+	}
+	return map[string]any{"result": out}
+}
+
+// diffDocuments takes (formatA, inputA, formatB, inputB, context) and
+// returns the changes with a unified diff; context defaults to 3 lines.
+func diffDocuments(_ js.Value, args []js.Value) any {
+	if len(args) < 4 {
+		return map[string]any{"error": "formatA, inputA, formatB and inputB required"}
+	}
+	changes, err := convert.DiffDocuments(args[0].String(), args[1].String(), args[2].String(), args[3].String())
+	if err != nil {
+		return errorResult(err)
+	}
+	context := 3
+	if len(args) > 4 && args[4].Type() == js.TypeNumber {
+		context = args[4].Int()
+	}
+	unified, err := convert.UnifiedDiff(args[0].String(), args[1].String(), args[2].String(), args[3].String(), context)
+	if err != nil {
+		return errorResult(err)
+	}
+	return map[string]any{"result": map[string]any{"changes": jsonValue(changes), "unified": unified}}
+}
+
+// flattenOptions binds fn to take (input, options?) with a JSON
+// FlattenOptions object.
+func flattenOptions(fn func(string, convert.FlattenOptions) (string, error)) func(js.Value, []js.Value) any {
+	return func(_ js.Value, args []js.Value) any {
+		if len(args) < 1 {
+			return map[string]any{"error": "input required"}
+		}
+		var opts convert.FlattenOptions
+		if len(args) > 1 && strings.TrimSpace(args[1].String()) != "" {
+			if err := json.Unmarshal([]byte(args[1].String()), &opts); err != nil {
+				return map[string]any{"error": err.Error()}
+			}
+		}
+		out, err := fn(args[0].String(), opts)
+		if err != nil {
+			return errorResult(err)
+		}
+		return map[string]any{"result": out}
+	}
+}
+
+// interpolateEnv takes (input, vars) with vars as a JSON object of strings.
+func interpolateEnv(_ js.Value, args []js.Value) any {
+	if len(args) < 2 {
+		return map[string]any{"error": "input and vars required"}
+	}
+	var vars map[string]string
+	if err := json.Unmarshal([]byte(args[1].String()), &vars); err != nil {
+		return map[string]any{"error": err.Error()}
+	}
+	return map[string]any{"result": jsonValue(convert.InterpolateEnv(args[0].String(), vars))}
+}
+
+func extractPlaceholders(_ js.Value, args []js.Value) any {
+	if len(args) < 1 {
+		return map[string]any{"error": "input required"}
+	}
+	return map[string]any{"result": jsonValue(convert.ExtractPlaceholders(args[0].String()))}
+}
+
+func timestampInfo(_ js.Value, args []js.Value) any {
+	var input, zone string
+	if len(args) > 0 && args[0].Type() == js.TypeString {
+		input = args[0].String()
+	}
+	if len(args) > 1 && args[1].Type() == js.TypeString {
+		zone = args[1].String()
+	}
+	res, err := convert.TimestampInfo(input, zone)
+	if err != nil {
+		return map[string]any{"error": err.Error()}
+	}
+	return map[string]any{"result": jsonValue(res)}
+}
+
+func resolveIncludes(_ js.Value, args []js.Value) any {
+	if len(args) < 2 || args[1].Type() != js.TypeObject {
+		return map[string]any{"error": "entry and files required"}
+	}
+	files := map[string]string{}
+	keys := js.Global().Get("Object").Call("keys", args[1])
+	for i := 0; i < keys.Length(); i++ {
+		name := keys.Index(i).String()
+		files[name] = args[1].Get(name).String()
+	}
+	to := ""
+	if len(args) > 2 && args[2].Type() == js.TypeString {
+		to = args[2].String()
+	}
+	out, err := convert.ResolveIncludeFiles(files, args[0].String(), to)
+	if err != nil {
+		return map[string]any{"error": err.Error()}
+	}
+	return map[string]any{"result": out}
+}
+
+func listProfiles(_ js.Value, _ []js.Value) any {
+	return map[string]any{"result": jsonValue(convert.ListProfiles())}
+}
+
+func colorInfo(_ js.Value, args []js.Value) any {
+	if len(args) == 0 {
+		return map[string]any{"error": "input required"}
+	}
+	info, err := convert.ColorInfo(args[0].String())
+	if err != nil {
+		return map[string]any{"error": err.Error()}
+	}
+	return map[string]any{"result": jsonValue(info)}
+}
+
+func caseConvert(_ js.Value, args []js.Value) any {
+	if len(args) < 2 {
+		return map[string]any{"error": "input and target case required"}
+	}
+	out, err := convert.CaseConvert(args[0].String(), args[1].String())
+	if err != nil {
+		return map[string]any{"error": err.Error()}
+	}
+	return map[string]any{"result": out}
+}
+
+func selfTest(_ js.Value, _ []js.Value) any {
+	return map[string]any{"result": jsonValue(convert.SelfTest())}
+}
+
+// metricsReport turns local metrics on or off when given a boolean and
+// returns the report recorded so far.
+func metricsReport(_ js.Value, args []js.Value) any {
+	if len(args) > 0 && args[0].Type() == js.TypeBoolean {
+		convert.EnableMetrics(args[0].Bool())
+	}
+	return map[string]any{"result": jsonValue(convert.Metrics())}
+}
+
+func analyzePermissions(_ js.Value, args []js.Value) any {
+	if len(args) < 2 {
+		return map[string]any{"error": "kind and input required"}
+	}
+	output := "markdown"
+	if len(args) > 2 {
+		output = args[2].String()
+	}
+	out, err := convert.AnalyzePermissions(args[0].String(), args[1].String(), output)
+	if err != nil {
+		return map[string]any{"error": err.Error()}
+	}
+	return map[string]any{"result": out}
+}
+
+func stringMapToAny(in map[string]string) map[string]any {
+	result := make(map[string]any, len(in))
 	for k, v := range in {
 		result[k] = v
 	}
 	return result
 }
+
+// jsonValue converts a result struct into plain maps/slices that syscall/js
+// can hand to JavaScript, using the struct's json tags as keys.
+func jsonValue(v any) any {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	var out any
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return nil
+	}
+	return out
+}
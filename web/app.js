@@ -9,6 +9,20 @@ const formats = [
 	"Protobuf",
 	"TOON",
 	"MsgPack",
+	"CSV",
+	"Plist",
+	"Binary Plist",
+	"Properties XML",
+	"Properties",
+	"Dotenv",
+	"Query String",
+	"HTML Table",
+	"Markdown Table",
+	"NDJSON",
+	"Windows Registry",
+	"SQL DDL",
+	"Avro Schema",
+	"HCL",
 ];
 
 const samples = {
@@ -36,6 +50,72 @@ const samples = {
   1,Alice
   2,Bob`,
 	MsgPack: "Paste base64 MsgPack here...",
+	CSV: "name,age\nRicky,27",
+	Plist: `<plist version="1.0">
+<dict>
+  <key>name</key>
+  <string>Ricky</string>
+  <key>age</key>
+  <integer>27</integer>
+</dict>
+</plist>`,
+	"Binary Plist": "Paste base64 bplist00 here...",
+	"Properties XML": `<properties>
+  <entry key="name">Ricky</entry>
+  <entry key="age">27</entry>
+</properties>`,
+	Properties: `# app
+app.name=Ricky
+app.age=27
+app.tags.0=admin`,
+	Dotenv: `# app
+APP_NAME=Ricky
+APP_AGE=27
+GREETING="Hello\\nworld"`,
+	"Query String": "name=Ricky&age=27&tags[]=admin&tags[]=dev",
+	"HTML Table": `<table>
+<thead>
+<tr><th>name</th><th>age</th></tr>
+</thead>
+<tbody>
+<tr><td>Ricky</td><td>27</td></tr>
+<tr><td>Alice</td><td>30</td></tr>
+</tbody>
+</table>`,
+	"Markdown Table": `| name  | age |
+| ----- | --: |
+| Ricky |  27 |
+| Alice |  30 |`,
+	NDJSON: '{"name":"Ricky","age":27}\n{"name":"Alice","age":30}',
+	"Windows Registry": `Windows Registry Editor Version 5.00
+
+[HKEY_CURRENT_USER\\Software\\Demo]
+"name"="Ricky"
+"age"=dword:0000001b`,
+	"SQL DDL": `CREATE TABLE users (
+  id BIGINT PRIMARY KEY,
+  name VARCHAR(64) NOT NULL,
+  age INT
+);`,
+	"Avro Schema": `{
+  "type": "record",
+  "name": "User",
+  "fields": [
+    { "name": "name", "type": "string" },
+    { "name": "age", "type": ["null", "int"], "default": null }
+  ]
+}`,
+	HCL: `variable "region" {
+  default = "us-east-1"
+}
+
+resource "aws_instance" "web" {
+  ami           = data.aws_ami.ubuntu.id
+  instance_type = "t3.micro"
+  tags = {
+    Name = "web"
+  }
+}`,
 };
 
 const coderTools = [
@@ -55,7 +135,7 @@ const coderTools = [
 		id: "coder-hash",
 		mode: "hash",
 		label: "Hash",
-		description: "Hashes from Go's stdlib.",
+		description: "SHA-2, SHA-3, BLAKE and checksum digests.",
 	},
 	{
 		id: "coder-url",
@@ -92,8 +172,13 @@ const toolGroups = [
 			},
 			{
 				id: "converter-ipv4",
-				label: "IPv4 Tools",
-				description: "CIDR, ranges, and alternate formats.",
+				label: "IP Tools",
+				description: "IPv4 / IPv6 CIDR, ranges, and alternate formats.",
+			},
+			{
+				id: "converter-url",
+				label: "URL Inspector",
+				description: "Break a URL into its parts and normalize it.",
 			},
 		],
 	},
@@ -139,6 +224,7 @@ converterPairTools.forEach((id) => pairToolSet.add(id));
 const coderToolSet = new Set([...coderMainTools, ...pairToolSet]);
 const numberToolSet = new Set(["converter-number-bases"]);
 const ipv4ToolSet = new Set(["converter-ipv4"]);
+const urlToolSet = new Set(["converter-url"]);
 const generatorToolSet = new Set(["generator-uuid", "generator-useragent"]);
 const uuidToolSet = new Set(["generator-uuid"]);
 const userAgentToolSet = new Set(["generator-useragent"]);
@@ -170,6 +256,10 @@ function isIPv4Tool(toolId) {
 	return ipv4ToolSet.has(toolId);
 }
 
+function isURLTool(toolId) {
+	return urlToolSet.has(toolId);
+}
+
 function isGeneratorTool(toolId) {
 	return generatorToolSet.has(toolId);
 }
@@ -208,6 +298,22 @@ const encodingGroups = [
 			{ key: "base64_raw_url", label: "URL-safe · Raw" },
 		],
 	},
+	{
+		id: "base58",
+		label: "Base58",
+		variants: [
+			{ key: "base58", label: "Bitcoin" },
+			{ key: "base58_check", label: "Base58Check" },
+		],
+	},
+	{
+		id: "base36",
+		label: "Base36 / Base62",
+		variants: [
+			{ key: "base36", label: "Base36" },
+			{ key: "base62", label: "Base62" },
+		],
+	},
 	{
 		id: "base85",
 		label: "Base85",
@@ -247,6 +353,14 @@ const hashGroups = [
 		label: "SHA-2",
 		keys: ["sha224", "sha256", "sha384", "sha512", "sha512_224", "sha512_256"],
 	},
+	{
+		label: "SHA-3",
+		keys: ["sha3_224", "sha3_256", "sha3_384", "sha3_512", "keccak256"],
+	},
+	{
+		label: "BLAKE",
+		keys: ["blake2b_256", "blake2b_512", "blake2s_256", "blake3"],
+	},
 	{
 		label: "Checksums",
 		keys: ["crc32_ieee", "crc32_castagnoli", "crc64_iso", "crc64_ecma", "adler32"],
@@ -266,6 +380,15 @@ const hashLabels = {
 	sha512: "SHA-512",
 	sha512_224: "SHA-512/224",
 	sha512_256: "SHA-512/256",
+	sha3_224: "SHA3-224",
+	sha3_256: "SHA3-256",
+	sha3_384: "SHA3-384",
+	sha3_512: "SHA3-512",
+	keccak256: "Keccak-256",
+	blake2b_256: "BLAKE2b-256",
+	blake2b_512: "BLAKE2b-512",
+	blake2s_256: "BLAKE2s-256",
+	blake3: "BLAKE3",
 	crc32_ieee: "CRC32 (IEEE)",
 	crc32_castagnoli: "CRC32 (Castagnoli)",
 	crc64_iso: "CRC64 (ISO)",
@@ -282,13 +405,13 @@ const hashLabels = {
 const coderModeDescriptions = {
 	encode: "Encode text into multiple bases.",
 	decode: "Decode text with your selected base.",
-	hash: "Generate hashes provided by Go's standard library.",
+	hash: "Generate message digests, checksums and non-cryptographic hashes.",
 };
 
 const coderResultHints = {
-	encode: "Base32 / Base64 / Base85 / Base91 / Hex",
+	encode: "Base32 / Base36 / Base58 / Base62 / Base64 / Base85 / Base91 / Hex",
 	decode: "Decoded output",
-	hash: "MD5 / SHA / CRC / FNV",
+	hash: "MD5 / SHA-2 / SHA-3 / BLAKE / CRC / FNV",
 };
 
 const coderPlaceholders = {
@@ -346,6 +469,7 @@ let wasmInitPromise = null;
 let currentPairTool = null;
 let pairSyncing = false;
 let pairLastSource = "input";
+let syntaxError = null;
 let numberSyncing = false;
 let uuidUppercase = false;
 let currentUUIDs = {};
@@ -365,6 +489,11 @@ const uuidDisplayLabels = {
 	guid: "GUID",
 	ulid: "ULID",
 };
+// Presets come from the server's /api/v1/store and stay hidden when it has
+// none (a static deploy, or a server started without TRANSFORM_GO_STORE).
+const apiKeyStorage = "transform-go.apiKey";
+let savedPresets = [];
+let presetOptions = null;
 let currentBrowserFilter = "";
 let currentOSFilter = "";
 let currentUserAgents = [];
@@ -386,6 +515,7 @@ document.addEventListener("DOMContentLoaded", () => {
 	selectTool(currentTool);
 	ensureMode();
 	loadWasm();
+	loadPresets();
 });
 
 function cacheElements() {
@@ -397,10 +527,15 @@ function cacheElements() {
 	elements.from = document.getElementById("fromSelect");
 	elements.to = document.getElementById("toSelect");
 	elements.swap = document.getElementById("swap");
+	elements.presetControls = document.getElementById("presetControls");
+	elements.presetSelect = document.getElementById("presetSelect");
+	elements.savePreset = document.getElementById("savePreset");
+	elements.presetKey = document.getElementById("presetKey");
 	elements.copy = document.getElementById("copy");
 	elements.clear = document.getElementById("clear");
 	elements.input = document.getElementById("input");
 	elements.output = document.getElementById("output");
+	elements.query = document.getElementById("query");
 	elements.status = document.getElementById("status");
 	elements.inputLabel = document.getElementById("inputLabel");
 	elements.outputLabel = document.getElementById("outputLabel");
@@ -441,11 +576,16 @@ function cacheElements() {
 	elements.ipv4Workspace = document.getElementById("ipv4Workspace");
 	elements.ipv4Input = document.getElementById("ipv4Input");
 	elements.ipv4Results = document.getElementById("ipv4Results");
+	elements.urlWorkspace = document.getElementById("urlWorkspace");
+	elements.urlInput = document.getElementById("urlInput");
+	elements.urlResults = document.getElementById("urlResults");
 	elements.uuidWorkspace = document.getElementById("uuidWorkspace");
 	elements.userAgentWorkspace = document.getElementById("userAgentWorkspace");
 	elements.uuidList = document.getElementById("uuidList");
 	elements.uuidToggleCase = document.getElementById("uuidToggleCase");
 	elements.uuidRegenerate = document.getElementById("uuidRegenerate");
+	elements.uuidNamespace = document.getElementById("uuidNamespace");
+	elements.uuidName = document.getElementById("uuidName");
 	elements.uaBrowser = document.getElementById("uaBrowser");
 	elements.uaOS = document.getElementById("uaOS");
 	elements.uaResults = document.getElementById("uaResults");
@@ -551,12 +691,27 @@ function initSidebarResizer() {
 }
 
 function bindEvents() {
-	elements.from.addEventListener("change", ensureMode);
-	elements.to.addEventListener("change", ensureMode);
+	elements.from.addEventListener("change", () => {
+		clearPreset();
+		ensureMode();
+	});
+	elements.to.addEventListener("change", () => {
+		clearPreset();
+		ensureMode();
+	});
+	elements.presetSelect.addEventListener("change", applyPreset);
+	elements.savePreset.addEventListener("click", savePreset);
+	elements.presetKey.addEventListener("click", setPresetKey);
 	elements.swap.addEventListener("click", onSwap);
 	elements.copy.addEventListener("click", copyOutput);
 	elements.clear.addEventListener("click", clearAll);
-	elements.input.addEventListener("input", () => scheduleConvert());
+	elements.input.addEventListener("input", () => {
+		lintInput();
+		scheduleConvert();
+	});
+	elements.status.addEventListener("click", showSyntaxError);
+	elements.input.addEventListener("paste", () => setTimeout(detectInputFormat));
+	elements.query.addEventListener("input", () => scheduleConvert());
 	elements.formatInput.addEventListener("click", () =>
 		formatField(elements.input, elements.from.value, false),
 	);
@@ -670,11 +825,22 @@ function bindEvents() {
 	if (elements.ipv4Input) {
 		elements.ipv4Input.addEventListener("input", () => runIPv4Conversion());
 	}
+	if (elements.urlInput) {
+		elements.urlInput.addEventListener("input", () => runURLParse());
+	}
 	if (elements.uuidRegenerate) {
 		elements.uuidRegenerate.addEventListener("click", () =>
 			refreshUUIDs(true),
 		);
 	}
+	if (elements.uuidNamespace) {
+		elements.uuidNamespace.addEventListener("change", () =>
+			refreshUUIDs(true),
+		);
+	}
+	if (elements.uuidName) {
+		elements.uuidName.addEventListener("input", () => refreshUUIDs(true));
+	}
 	if (elements.uaBrowser) {
 		elements.uaBrowser.addEventListener("change", () =>
 			refreshUserAgents(true),
@@ -1206,7 +1372,7 @@ function runNumberConversion(base) {
 function activateIPv4Tool() {
 	if (elements.ipv4Results) {
 		elements.ipv4Results.innerHTML =
-			'<div class="muted">Enter an IPv4 or CIDR block to see details</div>';
+			'<div class="muted">Enter an IPv4/IPv6 address, CIDR block or range to see details</div>';
 	}
 	if (elements.ipv4Input) {
 		elements.ipv4Input.value = "";
@@ -1224,14 +1390,16 @@ function runIPv4Conversion() {
 	if (!value) {
 		if (elements.ipv4Results) {
 			elements.ipv4Results.innerHTML =
-				'<div class="muted">Enter an IPv4 or CIDR block to see details</div>';
+				'<div class="muted">Enter an IPv4/IPv6 address, CIDR block or range to see details</div>';
 		}
 		setStatus("Cleared");
 		return;
 	}
 	let response;
 	try {
-		response = window.ipv4Info(value);
+		response = value.includes(":")
+			? window.ipv6Info(value)
+			: window.ipv4Info(value);
 	} catch (err) {
 		setStatus(err.message, true);
 		return;
@@ -1250,6 +1418,21 @@ function renderIPv4Results(data) {
 	if (data.standard) {
 		stats.push(renderIPv4Row("Standard", data.standard));
 	}
+	if (data.compressed) {
+		stats.push(renderIPv4Row("Compressed", data.compressed));
+	}
+	if (data.expanded) {
+		stats.push(renderIPv4Row("Expanded", data.expanded));
+	}
+	if (data.scope) {
+		stats.push(renderIPv4Row("Scope", data.scope));
+	}
+	if (data.embeddedIPv4) {
+		stats.push(renderIPv4Row("Embedded IPv4", data.embeddedIPv4));
+	}
+	if (data.eui64) {
+		stats.push(renderIPv4Row("EUI-64 MAC", data.mac));
+	}
 	if (data.cidr) {
 		stats.push(renderIPv4Row("CIDR", data.cidr));
 	}
@@ -1295,6 +1478,84 @@ function renderIPv4Row(label, value) {
   `;
 }
 
+const urlEmptyHint =
+	'<div class="muted">Enter a URL to break it into its parts</div>';
+
+function activateURLTool() {
+	if (elements.urlResults) {
+		elements.urlResults.innerHTML = urlEmptyHint;
+	}
+	if (elements.urlInput) {
+		elements.urlInput.value = "";
+	}
+	setStatus("Ready", false, "ready");
+}
+
+function runURLParse() {
+	if (!isURLTool(currentTool)) return;
+	if (!wasmReady) {
+		setStatus("Waiting for WebAssembly...", true);
+		return;
+	}
+	const value = elements.urlInput ? elements.urlInput.value.trim() : "";
+	if (!value) {
+		if (elements.urlResults) {
+			elements.urlResults.innerHTML = urlEmptyHint;
+		}
+		setStatus("Cleared");
+		return;
+	}
+	let response;
+	try {
+		response = window.urlParse(value);
+	} catch (err) {
+		setStatus(err.message, true);
+		return;
+	}
+	if (!response || response.error) {
+		setStatus(response?.error || "Parse failed", true);
+		return;
+	}
+	renderURLResults(response.result || {});
+	setStatus("Done", false, "ready");
+}
+
+function renderURLResults(data) {
+	if (!elements.urlResults) return;
+	const rows = [];
+	const fields = [
+		["Scheme", data.scheme],
+		["Username", data.username],
+		["Password", data.password],
+		["Host", data.host],
+		["Port", data.port],
+		["Opaque", data.opaque],
+		["Path", data.path],
+		["Fragment", data.fragment],
+		["Normalized", data.normalized],
+	];
+	fields.forEach(([label, value]) => {
+		if (value) rows.push(renderIPv4Row(label, value));
+	});
+	(data.segments || []).forEach((segment, i) => {
+		rows.push(renderIPv4Row(`Segment ${i + 1}`, segment));
+	});
+	const query = data.query || {};
+	Object.keys(query)
+		.sort()
+		.forEach((key) => {
+			query[key].forEach((value) => {
+				rows.push(renderIPv4Row(`?${key}`, value));
+			});
+		});
+	if (!rows.length) {
+		elements.urlResults.innerHTML =
+			'<div class="muted">Unable to parse input</div>';
+		return;
+	}
+	elements.urlResults.innerHTML = `<div class="ipv4-stats">${rows.join("")}</div>`;
+}
+
 function activateUUIDTool() {
 	uuidUppercase = false;
 	if (elements.uuidToggleCase) {
@@ -1315,7 +1576,10 @@ function refreshUUIDs(force = false) {
 		return;
 	}
 	try {
-		const response = window.generateUUIDs();
+		const response = window.generateUUIDs(
+			elements.uuidNamespace ? elements.uuidNamespace.value : "",
+			elements.uuidName ? elements.uuidName.value : "",
+		);
 		if (!response) {
 			setStatus("WASM is not ready yet", true);
 			return;
@@ -1479,6 +1743,7 @@ function selectTool(toolId) {
 	const isPair = isPairToolId(toolId);
 	const isNumber = isNumberTool(toolId);
 	const isIPv4 = isIPv4Tool(toolId);
+	const isURL = isURLTool(toolId);
 	const isGenerator = isGeneratorTool(toolId);
 	const isUUID = isUUIDTool(toolId);
 	const isUserAgent = isUserAgentTool(toolId);
@@ -1491,6 +1756,7 @@ function selectTool(toolId) {
 	document.body.classList.toggle("tool-pair", isPair);
 	document.body.classList.toggle("tool-number", isNumber);
 	document.body.classList.toggle("tool-ipv4", isIPv4);
+	document.body.classList.toggle("tool-url", isURL);
 	document.body.classList.toggle("tool-generator", isGenerator);
 	document.body.classList.toggle("tool-uuid", isUUID);
 	document.body.classList.toggle("tool-useragent", isUserAgent);
@@ -1507,6 +1773,9 @@ function selectTool(toolId) {
 	if (elements.ipv4Workspace) {
 		elements.ipv4Workspace.classList.toggle("hidden", !isIPv4);
 	}
+	if (elements.urlWorkspace) {
+		elements.urlWorkspace.classList.toggle("hidden", !isURL);
+	}
 	if (elements.uuidWorkspace) {
 		elements.uuidWorkspace.classList.toggle("hidden", !isUUID);
 	}
@@ -1541,6 +1810,10 @@ function selectTool(toolId) {
 		activateIPv4Tool();
 		return;
 	}
+	if (isURL) {
+		activateURLTool();
+		return;
+	}
 	if (isUUID) {
 		activateUUIDTool();
 		return;
@@ -1587,6 +1860,115 @@ function ensureMode() {
 	return true;
 }
 
+// detectInputFormat pre-selects the "from" format for pasted input when
+// detection is confident and the current choice does not match.
+function detectInputFormat() {
+	if (currentTool !== "format" || !wasmReady || !window.detectFormat) return;
+	const result = window.detectFormat(elements.input.value);
+	if (!result || result.error || !result.result) return;
+	const { format, confidence } = result.result;
+	if (confidence < 0.8 || !supportedFormats.has(format)) return;
+	if (format === elements.from.value || format === elements.to.value) return;
+	elements.from.value = format;
+	ensureMode();
+}
+
+function storeRequest(path, init = {}) {
+	const headers = { ...init.headers };
+	const key = localStorage.getItem(apiKeyStorage);
+	if (key) headers["X-API-Key"] = key;
+	return fetch(`api/v1/store/${path}`, { ...init, headers });
+}
+
+async function loadPresets() {
+	try {
+		const response = await storeRequest("presets");
+		if (!response.ok) return;
+		const { result } = await response.json();
+		savedPresets = result || [];
+	} catch {
+		return;
+	}
+	const selected = elements.presetSelect.value;
+	elements.presetSelect.innerHTML = '<option value="">Presets</option>';
+	savedPresets.forEach((preset) => {
+		const option = document.createElement("option");
+		option.value = preset.name;
+		option.textContent = preset.name;
+		elements.presetSelect.appendChild(option);
+	});
+	elements.presetSelect.value = selected;
+	elements.presetControls.classList.remove("hidden");
+}
+
+function applyPreset() {
+	const preset = savedPresets.find(
+		(p) => p.name === elements.presetSelect.value,
+	);
+	if (!preset) {
+		clearPreset();
+		ensureMode();
+		return;
+	}
+	if (preset.from && supportedFormats.has(preset.from)) {
+		elements.from.value = preset.from;
+	}
+	if (supportedFormats.has(preset.to)) {
+		elements.to.value = preset.to;
+	}
+	presetOptions = preset.options || null;
+	ensureMode();
+}
+
+function clearPreset() {
+	presetOptions = null;
+	elements.presetSelect.value = "";
+}
+
+async function savePreset() {
+	const name = window.prompt(
+		"Save the current conversion as",
+		elements.presetSelect.value,
+	);
+	if (!name) return;
+	const body = { from: elements.from.value, to: elements.to.value };
+	if (presetOptions) body.options = presetOptions;
+	try {
+		const path = `presets/${encodeURIComponent(name)}`;
+		const response = await storeRequest(path, {
+			method: "PUT",
+			headers: { "Content-Type": "application/json" },
+			body: JSON.stringify(body),
+		});
+		const data = await response.json();
+		if (data.error) {
+			setStatus(`⚠️ ${data.error}`, true);
+			return;
+		}
+	} catch (err) {
+		setStatus(`⚠️ ${err.message}`, true);
+		return;
+	}
+	await loadPresets();
+	elements.presetSelect.value = name;
+	setStatus(`Saved preset ${name}`);
+}
+
+function setPresetKey() {
+	const key = window.prompt(
+		"API key to save presets under (empty for the shared presets)",
+		localStorage.getItem(apiKeyStorage) || "",
+	);
+	if (key === null) return;
+	if (key) {
+		localStorage.setItem(apiKeyStorage, key);
+	} else {
+		localStorage.removeItem(apiKeyStorage);
+	}
+	clearPreset();
+	loadPresets();
+}
+
 function scheduleConvert(immediate = false) {
 	if (currentTool !== "format") return;
 	if (immediate) {
@@ -1607,6 +1989,47 @@ function scheduleCoder(immediate = false) {
 	coderTimer = setTimeout(() => runCoder(), 200);
 }
 
+// queryInput applies the query box to the input: a single match is
+// converted on its own and several as a JSON array. It returns null, with
+// the error shown, when the query fails.
+function queryInput(from, raw) {
+	const query = elements.query ? elements.query.value.trim() : "";
+	if (!query) return { from, text: raw };
+	const response = window.queryContent(raw, query);
+	if (!response || response.error) {
+		elements.output.value = "";
+		setStatus(`⚠️ ${response?.error || "Query failed"}`, true);
+		return null;
+	}
+	// The result is indented by two spaces, and JSON strings hold no raw
+	// newlines, so a lone match is unwrapped by text to keep its numbers.
+	const lines = response.result.split("\n");
+	const text =
+		JSON.parse(response.result).length === 1
+			? lines
+					.slice(1, -1)
+					.map((line) => line.slice(2))
+					.join("\n")
+			: response.result;
+	return { from: "JSON", text };
+}
+
+// lintInput checks the input's syntax on each keystroke, ahead of the
+// debounced conversion, so a typo is marked at once.
+function lintInput() {
+	if (currentTool !== "format" || !wasmReady || !window.validateContent) return;
+	const from = elements.from.value;
+	const raw = elements.input.value;
+	if (!supportedFormats.has(from) || !raw.trim()) return;
+	const result = window.validateContent(from, raw);
+	if (result && result.error) {
+		setStatus(`⚠️ ${result.error}`, true);
+		markSyntaxError(elements.input, result.position);
+	} else {
+		clearSyntaxError();
+	}
+}
+
 function convert() {
 	if (currentTool !== "format") return;
 	const from = elements.from.value;
@@ -1626,7 +2049,14 @@ function convert() {
 		return;
 	}
 	try {
-		const result = window.transformFormat(from, to, raw);
+		const source = queryInput(from, raw);
+		if (!source) return;
+		const result = window.transformFormat(
+			source.from,
+			to,
+			source.text,
+			presetOptions,
+		);
 		if (!result) {
 			setStatus("WASM is not ready yet", true);
 			return;
@@ -1634,6 +2064,7 @@ function convert() {
 		if (result.error) {
 			elements.output.value = "";
 			setStatus(`⚠️ ${result.error}`, true);
+			markSyntaxError(elements.input, result.position);
 			return;
 		}
 		elements.output.value = result.result || "";
@@ -1762,7 +2193,15 @@ function clearAll() {
 		if (elements.ipv4Input) elements.ipv4Input.value = "";
 		if (elements.ipv4Results) {
 			elements.ipv4Results.innerHTML =
-				'<div class="muted">Enter an IPv4 or CIDR block to see details</div>';
+				'<div class="muted">Enter an IPv4/IPv6 address, CIDR block or range to see details</div>';
+		}
+		setStatus("Cleared");
+		return;
+	}
+	if (isURLTool(currentTool)) {
+		if (elements.urlInput) elements.urlInput.value = "";
+		if (elements.urlResults) {
+			elements.urlResults.innerHTML = urlEmptyHint;
 		}
 		setStatus("Cleared");
 		return;
@@ -1810,6 +2249,7 @@ function handleHotkeys(e) {
 
 function setStatus(text, isError = false, tone = "") {
 	if (!elements.status) return;
+	clearSyntaxError();
 	elements.status.textContent = text;
 	if (isError) {
 		elements.status.dataset.state = "error";
@@ -1818,6 +2258,51 @@ function setStatus(text, isError = false, tone = "") {
 	elements.status.dataset.state = tone || "";
 }
 
+// markSyntaxError flags target as invalid and lets a click on the status
+// select where the parser stopped; position is the one wasm returns with a
+// syntax error, if any.
+function markSyntaxError(target, position) {
+	if (!target || !position || !position.line) return;
+	syntaxError = { target, position };
+	target.setAttribute("aria-invalid", "true");
+	elements.status.dataset.clickable = "true";
+	elements.status.title = `Line ${position.line}${position.column ? `, column ${position.column}` : ""}: click to show`;
+}
+
+function clearSyntaxError() {
+	if (syntaxError) {
+		syntaxError.target.removeAttribute("aria-invalid");
+		syntaxError = null;
+	}
+	delete elements.status.dataset.clickable;
+	elements.status.removeAttribute("title");
+}
+
+// showSyntaxError selects the character the parser stopped at, or the whole
+// line when it gave no column. Columns count characters, so surrogate pairs
+// are walked as one.
+function showSyntaxError() {
+	if (!syntaxError) return;
+	const { target, position } = syntaxError;
+	const text = target.value;
+	let start = 0;
+	for (let line = 1; line < position.line; line++) {
+		const next = text.indexOf("\n", start);
+		if (next < 0) break;
+		start = next + 1;
+	}
+	let end = text.indexOf("\n", start);
+	if (end < 0) end = text.length;
+	if (position.column > 0) {
+		const chars = Array.from(text.slice(start, end));
+		const before = chars.slice(0, position.column - 1).join("");
+		start = Math.min(start + before.length, end);
+		end = Math.min(start + (chars[position.column - 1] || "").length, end);
+	}
+	target.focus();
+	target.setSelectionRange(start, Math.max(end, start));
+}
+
 function formatField(target, formatName, minify) {
 	if (!wasmReady) {
 		setStatus("Waiting for WebAssembly...", true);
@@ -1840,6 +2325,7 @@ function formatField(target, formatName, minify) {
 		}
 		if (result.error) {
 			setStatus(`⚠️ ${result.error}`, true);
+			markSyntaxError(target, result.position);
 			return;
 		}
 		target.value = result.result || "";
@@ -1893,6 +2379,9 @@ async function loadWasm() {
 		if (isIPv4Tool(currentTool) && elements.ipv4Input?.value.trim()) {
 			runIPv4Conversion();
 		}
+		if (isURLTool(currentTool) && elements.urlInput?.value.trim()) {
+			runURLParse();
+		}
 		if (isUUIDTool(currentTool)) {
 			refreshUUIDs(true);
 		}